@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/dsswift/commit/internal/analyzer"
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/llm"
+	"github.com/dsswift/commit/internal/planner"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// readPatch reads a unified diff from path, or from stdin when path is "-".
+func readPatch(path string) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		return string(data), err
+	}
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+// handleAnalyze plans commits for a unified diff read from flags.analyze
+// without touching the working tree, and prints the plan. The repo's
+// .commit.json and recent commits are used when run inside a repository.
+func handleAnalyze(flags flags) int {
+	patch, err := readPatch(flags.analyze)
+	if err != nil {
+		printError("Failed to read diff", err)
+		return 1
+	}
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		printError("Failed to get current directory", err)
+		return 1
+	}
+
+	// Outside a repository the patch is planned with the default rules
+	workDir := cwd
+	repoConfig := &types.RepoConfig{}
+	gitRoot, err := git.FindGitRoot(cwd)
+	if err == nil {
+		workDir = gitRoot
+		if repoConfig, err = config.LoadRepoConfig(gitRoot); err != nil {
+			printError("Failed to load repo config", err)
+			return 1
+		}
+		if err := useConvention(repoConfig, gitRoot); err != nil {
+			printError("Failed to load repo config", err)
+			return 1
+		}
+	}
+
+	// Load config
+	printStep("🔧", "Loading config...")
+	userConfig, err := config.LoadUserConfig()
+	if err != nil {
+		handleConfigError(err)
+		return 1
+	}
+
+	flags.applyProviderOverride(userConfig)
+	flags.applyDeterminism(userConfig)
+	printSuccess(fmt.Sprintf("Provider: %s", userConfig.Provider))
+
+	contextBuilder := analyzer.NewContextBuilder(workDir, repoConfig)
+	if len(flags.focus) > 0 {
+		contextBuilder.SetFocus(flags.focus)
+	}
+	analysisReq, err := contextBuilder.BuildFromPatch(patch)
+	if err != nil {
+		if _, ok := err.(*analyzer.NoChangesError); ok {
+			printFinal("❌", "No file changes found in the diff")
+			return 1
+		}
+		printError("Failed to build context", err)
+		return 1
+	}
+	analysisReq.SingleCommit = flags.single
+	analysisReq.GuidingMessage = flags.message
+
+	printSuccess(fmt.Sprintf("Found %d files in the diff", len(analysisReq.Files)))
+
+	provider, err := getProviderFunc()(userConfig)
+	if err != nil {
+		printError("Failed to create LLM provider", err)
+		return 1
+	}
+
+	printStep("🤖", "Analyzing changes...")
+	printProgress(fmt.Sprintf("Sending to %s...", provider.Model()))
+	logSampling(flags, userConfig, provider.Name(), nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	chunks := llm.SplitRequest(provider.Model(), userConfig.MaxTokens, analysisReq)
+	if len(chunks) > 1 {
+		reportChunks(provider, chunks, flags.verbose, nil)
+	}
+	plan, err := llm.AnalyzeChunks(ctx, provider, chunks, func(error) {
+		printProgress("Response was not valid JSON, asking for a corrected plan...")
+	})
+	if err != nil {
+		printError("Analysis failed", err)
+		return 1
+	}
+
+	planner.NormalizePlan(plan, repoConfig, convention.Active())
+
+	printFinal("📋", fmt.Sprintf("%d commits planned (nothing committed)", len(plan.Commits)))
+	for i, c := range plan.Commits {
+		printTreeItem(i+1, len(plan.Commits), convention.Format(c))
+		for _, f := range c.Files {
+			printTreeLeaf(f)
+		}
+		if flags.verbose && c.Reasoning != "" {
+			printVerbose("    " + c.Reasoning)
+		}
+	}
+
+	return 0
+}
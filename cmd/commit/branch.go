@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/logging"
+	"github.com/dsswift/commit/internal/planner"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// branchSuggestionTimeout bounds the request for branch names, which is
+// much smaller than the analysis.
+const branchSuggestionTimeout = 30 * time.Second
+
+// suggestBranch asks the LLM for branch names for plan and, in a terminal,
+// creates and switches to the one picked so the plan is committed there.
+// Names that already exist or that branch policies wouldn't allow the plan
+// on are left out. Failures only warn: the run carries on on the current
+// branch.
+func suggestBranch(flags flags, userConfig *types.UserConfig, gitRoot string, repoConfig *types.RepoConfig, plan *types.CommitPlan, logger *logging.ExecutionLogger) {
+	printStep("🌿", "Suggesting branch names...")
+
+	current, _ := git.NewCollector(gitRoot).CurrentBranch()
+	if current != "" && !planner.BranchAllowsPlan(repoConfig, current, plan) {
+		printWarning(fmt.Sprintf("Branch policies don't allow every commit in this plan on %s", current))
+	}
+
+	provider, err := getProviderFunc()(userConfig)
+	if err != nil {
+		printWarning(fmt.Sprintf("Couldn't suggest branch names: %v", err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), branchSuggestionTimeout)
+	defer cancel()
+
+	names, err := planner.SuggestBranches(ctx, provider, plan)
+	if err != nil {
+		printWarning(fmt.Sprintf("Couldn't suggest branch names: %v", err))
+		return
+	}
+
+	var usable []string
+	for _, name := range names {
+		reason := ""
+		switch {
+		case !git.ValidBranchName(gitRoot, name):
+			reason = "not a valid branch name"
+		case git.BranchExists(gitRoot, name):
+			reason = "branch already exists"
+		case !planner.BranchAllowsPlan(repoConfig, name, plan):
+			reason = "branch policies don't allow this plan there"
+		default:
+			usable = append(usable, name)
+		}
+		if reason != "" && flags.verbose {
+			printVerbose(fmt.Sprintf("Skipping %s: %s", name, reason))
+		}
+	}
+
+	created := ""
+	if logger != nil {
+		defer func() { logger.LogBranchSuggestions(names, created) }()
+	}
+
+	if len(usable) == 0 {
+		printWarning("Every suggested branch already exists or is blocked by branch policies")
+		return
+	}
+	for i, name := range usable {
+		printTreeItem(i+1, len(usable), name)
+	}
+
+	switch {
+	case flags.dryRun:
+		printProgress("Dry run: staying on " + current)
+		return
+	case !isTerminal(os.Stdin) || !isTerminal(os.Stdout):
+		printProgress("Create one with: git switch -c <name>")
+		return
+	}
+
+	choices := make([]promptChoice, 0, len(usable)+1)
+	for i, name := range usable {
+		choices = append(choices, promptChoice{key: byte('1' + i), help: name})
+	}
+	choices = append(choices, promptChoice{key: 's', help: "stay on " + current})
+
+	key := promptKey("   Create and switch to a branch?", choices, 's')
+	if key == 's' {
+		return
+	}
+	name := usable[key-'1']
+	if err := git.CreateBranch(gitRoot, name); err != nil {
+		printWarning(fmt.Sprintf("Staying on %s: %v", current, err))
+		return
+	}
+	created = name
+	printSuccess("Switched to new branch " + name)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/logging"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// runEnvironment captures the environment for the execution log, including
+// the provider and model the run will use. Failures leave fields empty.
+func runEnvironment(flags flags) logging.Environment {
+	gitRoot := ""
+	if cwd, err := os.Getwd(); err == nil {
+		gitRoot, _ = git.FindGitRoot(cwd)
+	}
+
+	env := logging.CaptureEnvironment(gitRoot)
+	if userConfig, err := config.LoadUserConfig(); err == nil {
+		flags.applyProviderOverride(userConfig)
+		env.Provider, env.Model = userConfig.Provider, userConfig.Model
+	}
+	return env
+}
+
+// configSecrets returns the credentials in userConfig, which are redacted
+// from bug reports wherever they appear.
+func configSecrets(userConfig *types.UserConfig) []string {
+	if userConfig == nil {
+		return nil
+	}
+	return []string{
+		userConfig.AnthropicAPIKey,
+		userConfig.OpenAIAPIKey,
+		userConfig.GrokAPIKey,
+		userConfig.GeminiAPIKey,
+		userConfig.AzureFoundryAPIKey,
+		userConfig.AzureOpenAIAPIKey,
+		userConfig.OpenRouterAPIKey,
+		userConfig.MistralAPIKey,
+		userConfig.DeepSeekAPIKey,
+		userConfig.GitHubToken,
+	}
+}
+
+// handleBugReport bundles the most recent failed run into a redacted archive
+// in the current directory.
+func handleBugReport() int {
+	printStep("🐞", "Preparing bug report...")
+
+	entry, logPath, err := logging.LatestFailure()
+	if err != nil {
+		var noFailure *logging.NoFailedRunError
+		if errors.As(err, &noFailure) {
+			printFinal("❌", "No failed runs to report")
+			return 1
+		}
+		printError("Failed to read execution logs", err)
+		return 1
+	}
+	printProgress(fmt.Sprintf("Found %s (exit %d)", entry.ExecutionID, entry.ExitCode))
+
+	userConfig, _ := config.LoadUserConfig()
+	env := runEnvironment(flags{})
+
+	name := fmt.Sprintf("commit-bug-report-%s.tar.gz", entry.ExecutionID)
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		printError("Failed to create bug report", err)
+		return 1
+	}
+
+	if err := logging.WriteBugReport(file, *entry, logPath, env, configSecrets(userConfig)); err != nil {
+		_ = file.Close()
+		_ = os.Remove(name)
+		printError("Failed to write bug report", err)
+		return 1
+	}
+	if err := file.Close(); err != nil {
+		printError("Failed to write bug report", err)
+		return 1
+	}
+
+	printProgress("API keys, email addresses and your home directory were redacted")
+	printFinal("✅", fmt.Sprintf("Bug report written to %s", name))
+	return 0
+}
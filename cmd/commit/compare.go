@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/internal/llm"
+	"github.com/dsswift/commit/internal/logging"
+	"github.com/dsswift/commit/internal/planner"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// compareColumnWidth is the width of each plan's column in the side-by-side view.
+const compareColumnWidth = 44
+
+// parseCompare splits a --compare value into its two provider specs.
+func parseCompare(value string) ([2]string, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+		return [2]string{}, fmt.Errorf("expected two providers separated by a comma (e.g. openai,anthropic/claude-3-5-haiku-latest), got %q", value)
+	}
+	return [2]string{strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])}, nil
+}
+
+// comparedPlan is one provider's result in a comparison.
+type comparedPlan struct {
+	label        string
+	plan         *types.CommitPlan
+	err          error
+	promptTokens int
+}
+
+// comparePlans analyzes req with both providers in --compare in parallel,
+// shows the plans side by side and asks which one to execute. It returns nil
+// when the user picks neither, or when there is no terminal to ask on.
+func comparePlans(flags flags, userConfig *types.UserConfig, req *types.AnalysisRequest, logger *logging.ExecutionLogger) (*types.CommitPlan, error) {
+	specs, err := parseCompare(flags.compare)
+	if err != nil {
+		return nil, err
+	}
+
+	var providers [2]llm.Provider
+	for i, spec := range specs {
+		cfg := *userConfig
+		override := flags
+		override.provider, override.model = spec, ""
+		override.applyProviderOverride(&cfg)
+		if providers[i], err = getProviderFunc()(&cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", spec, err)
+		}
+	}
+
+	var results [2]comparedPlan
+	cost := 0.0
+	for i, provider := range providers {
+		results[i].label = provider.Name() + "/" + provider.Model()
+		results[i].promptTokens = reportPromptFit(provider, req, userConfig.MaxTokens, flags.verbose, logger)
+		cost += requestCost(provider.Model(), results[i].promptTokens)
+	}
+	if !checkBudget(flags, userConfig, cost, logger) {
+		return nil, errBudgetExceeded
+	}
+
+	printProgress(fmt.Sprintf("Sending to %s and %s...", providers[0].Model(), providers[1].Model()))
+	for i, provider := range providers {
+		if logger != nil {
+			logger.LogLLMRequest(provider.Name(), provider.Model(), results[i].promptTokens)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider llm.Provider) {
+			defer wg.Done()
+			results[i].plan, results[i].err = llm.AnalyzeWithRepair(ctx, provider, req, nil)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		if r.err != nil {
+			return nil, fmt.Errorf("%s: %w", r.label, r.err)
+		}
+		model := providers[i].Model()
+		responseTokens := llm.PlanTokens(model, r.plan)
+		recordUsage(providers[i].Name(), model, r.promptTokens, responseTokens)
+		if logger != nil {
+			logger.LogLLMResponse(responseTokens, len(r.plan.Commits), estimateCost(model, r.promptTokens, responseTokens))
+		}
+	}
+	printSuccess("Analysis complete")
+
+	printPlanComparison(results[0], results[1])
+
+	if !isTerminal(os.Stdin) {
+		printWarning("No terminal to choose a plan on; nothing was committed")
+		return nil, nil
+	}
+
+	choices := []promptChoice{{'1', results[0].label}, {'2', results[1].label}, {'q', "neither"}}
+	switch promptKey("   Execute which plan?", choices, 'q') {
+	case '1':
+		printSuccess("Using " + results[0].label)
+		return results[0].plan, nil
+	case '2':
+		printSuccess("Using " + results[1].label)
+		return results[1].plan, nil
+	}
+	return nil, nil
+}
+
+// printPlanComparison prints two plans side by side, followed by how their
+// grouping and messages differ.
+func printPlanComparison(a, b comparedPlan) {
+	printStep("⚖️", "Comparing plans...")
+
+	row := func(left, right string) {
+		fmt.Printf("   %s │ %s\n", fitColumn(left), strings.TrimRight(fitColumn(right), " "))
+	}
+	row(fmt.Sprintf("1) %s (%d commits)", a.label, len(a.plan.Commits)),
+		fmt.Sprintf("2) %s (%d commits)", b.label, len(b.plan.Commits)))
+	row(strings.Repeat("─", compareColumnWidth), strings.Repeat("─", compareColumnWidth))
+
+	for i := 0; i < max(len(a.plan.Commits), len(b.plan.Commits)); i++ {
+		var left, right string
+		if i < len(a.plan.Commits) {
+			left = fmt.Sprintf("%d. %s", i+1, convention.Format(a.plan.Commits[i]))
+		}
+		if i < len(b.plan.Commits) {
+			right = fmt.Sprintf("%d. %s", i+1, convention.Format(b.plan.Commits[i]))
+		}
+		row(left, right)
+	}
+
+	diff := planner.DiffPlans(a.plan, b.plan)
+	fmt.Println()
+	if diff.Identical() {
+		printSuccess("Plans are identical")
+		return
+	}
+
+	printProgress(fmt.Sprintf("Same grouping: %d commits (%d worded differently)", len(diff.Matched), diff.MessageChanges()))
+	for _, p := range diff.Matched {
+		if !p.SameMessage() {
+			printVerbose(fmt.Sprintf("  1) %s", convention.Format(p.A)))
+			printVerbose(fmt.Sprintf("  2) %s", convention.Format(p.B)))
+		}
+	}
+	if len(diff.OnlyA) > 0 || len(diff.OnlyB) > 0 {
+		printProgress(fmt.Sprintf("Different grouping: %d commits only in 1, %d only in 2", len(diff.OnlyA), len(diff.OnlyB)))
+		for _, c := range diff.OnlyA {
+			printVerbose(fmt.Sprintf("  1) %s: %s", convention.Format(c), strings.Join(c.Files, ", ")))
+		}
+		for _, c := range diff.OnlyB {
+			printVerbose(fmt.Sprintf("  2) %s: %s", convention.Format(c), strings.Join(c.Files, ", ")))
+		}
+	}
+}
+
+// fitColumn truncates or pads s to the comparison column width.
+func fitColumn(s string) string {
+	runes := []rune(s)
+	if len(runes) > compareColumnWidth {
+		return string(runes[:compareColumnWidth-1]) + "…"
+	}
+	return s + strings.Repeat(" ", compareColumnWidth-len(runes))
+}
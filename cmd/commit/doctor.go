@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/httpclient"
+	"github.com/dsswift/commit/internal/llm"
+	"github.com/dsswift/commit/internal/updater"
+)
+
+// configSetting returns a setting from the environment, or from the config
+// file when the environment doesn't set it.
+func configSetting(key string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	v, _ := config.GetConfigValue(key)
+	return v
+}
+
+// loadNetwork applies COMMIT_PROXY and COMMIT_CONNECT_TIMEOUT to every HTTP
+// request the tool makes, and COMMIT_MAX_ATTEMPTS to every provider request.
+// Without COMMIT_PROXY, the standard HTTPS_PROXY, HTTP_PROXY and NO_PROXY
+// variables apply.
+func loadNetwork() {
+	if err := httpclient.SetProxy(configSetting("COMMIT_PROXY")); err != nil {
+		printWarning(fmt.Sprintf("Ignoring COMMIT_PROXY: %v", err))
+	}
+
+	if v := configSetting("COMMIT_MAX_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err != nil || n <= 0 {
+			printWarning(fmt.Sprintf("Ignoring COMMIT_MAX_ATTEMPTS: %q is not a positive number", v))
+		} else {
+			llm.SetMaxAttempts(n)
+		}
+	}
+
+	if v := configSetting("COMMIT_CONNECT_TIMEOUT"); v != "" {
+		sec, err := strconv.Atoi(v)
+		if err != nil || sec <= 0 {
+			printWarning(fmt.Sprintf("Ignoring COMMIT_CONNECT_TIMEOUT: %q is not a number of seconds", v))
+			return
+		}
+		httpclient.SetConnectTimeout(time.Duration(sec) * time.Second)
+	}
+}
+
+// proxySource describes where the proxy settings in effect come from.
+func proxySource() string {
+	switch v := configSetting("COMMIT_PROXY"); v {
+	case "":
+		return "proxy environment variables"
+	case httpclient.DirectProxy:
+		return "COMMIT_PROXY=direct"
+	default:
+		return "COMMIT_PROXY"
+	}
+}
+
+// describeProxy reports the proxy requests to url go through.
+func describeProxy(url string) string {
+	proxy, err := httpclient.ProxyFor(url)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("unknown (%v)", err)
+	case proxy == nil:
+		return "direct"
+	default:
+		// Don't print proxy credentials
+		if proxy.User != nil {
+			redacted := *proxy
+			redacted.User = nil
+			return "via " + redacted.String() + " (with credentials)"
+		}
+		return "via " + proxy.String()
+	}
+}
+
+// handleDoctor reports the configuration and network settings in effect and
+// checks that the provider can be reached with them.
+func handleDoctor(flags flags) int {
+	failed := false
+
+	printStep("🩺", "Checking configuration...")
+	profilePath, _ := config.ProfilePath()
+	printProgress(fmt.Sprintf("Config: %s", profilePath))
+
+	userConfig, err := config.LoadUserConfig()
+	if err != nil {
+		printError("Config", err)
+		failed = true
+	} else {
+		flags.applyProviderOverride(userConfig)
+	}
+
+	printStep("🌐", "Checking network...")
+	printProgress(fmt.Sprintf("Proxy settings: %s", proxySource()))
+	printProgress(fmt.Sprintf("Update checks: %s", describeProxy(updater.GitHubReleasesURL)))
+
+	if userConfig == nil {
+		printFinal("❌", "Fix the configuration, then run --doctor again")
+		return 1
+	}
+
+	provider, err := getProviderFunc()(userConfig)
+	if err != nil {
+		printError("Failed to create LLM provider", err)
+		return 1
+	}
+	endpoint := llm.Endpoint(provider)
+	if endpoint != "" {
+		printProgress(fmt.Sprintf("%s (%s): %s", userConfig.Provider, endpoint, describeProxy(endpoint)))
+	}
+
+	timeout := time.Duration(userConfig.TimeoutSec) * time.Second
+	if timeout == 0 {
+		timeout = llm.DefaultTimeout(userConfig.Provider)
+	}
+	printProgress(fmt.Sprintf("Request timeout: %s (COMMIT_TIMEOUT)", timeout))
+	if v := configSetting("COMMIT_CONNECT_TIMEOUT"); v != "" {
+		printProgress(fmt.Sprintf("Connect timeout: %ss (COMMIT_CONNECT_TIMEOUT)", v))
+	} else {
+		printProgress(fmt.Sprintf("Connect timeout: %s", httpclient.DefaultConnectTimeout))
+	}
+	printProgress(fmt.Sprintf("Attempts per request: %d (COMMIT_MAX_ATTEMPTS)", llm.MaxAttempts()))
+
+	printStep("🔑", fmt.Sprintf("Contacting %s...", userConfig.Provider))
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := llm.CheckKey(ctx, provider); err != nil {
+		printError("Provider check failed", err)
+		failed = true
+	} else {
+		printSuccess(fmt.Sprintf("%s accepted the key for %s", userConfig.Provider, provider.Model()))
+		if models, ok, err := llm.ListModels(ctx, provider); ok && err == nil {
+			printProgress(fmt.Sprintf("%d models available (COMMIT_MODEL selects one)", len(models)))
+		}
+	}
+
+	if failed {
+		printFinal("❌", "Problems found")
+		return 1
+	}
+	printFinal("✅", "Everything looks good")
+	return 0
+}
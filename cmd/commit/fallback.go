@@ -0,0 +1,38 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/dsswift/commit/internal/llm"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// analysisProvider creates the provider for the commit analysis: the
+// configured one, followed by its COMMIT_PROVIDER_FALLBACKS when any are set.
+func analysisProvider(flags flags, userConfig *types.UserConfig) (llm.Provider, error) {
+	primary, err := getProviderFunc()(userConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	providers := []llm.Provider{primary}
+	for _, spec := range userConfig.ProviderFallbacks {
+		cfg := *userConfig
+		override := flags
+		override.provider, override.model = spec, ""
+		override.applyProviderOverride(&cfg)
+		if cfg.Provider == userConfig.Provider && cfg.Model == userConfig.Model {
+			continue // the provider it would fall back from
+		}
+		provider, err := getProviderFunc()(&cfg)
+		if err != nil {
+			return nil, fmt.Errorf("fallback provider %s: %w", spec, err)
+		}
+		providers = append(providers, provider)
+	}
+
+	if len(providers) == 1 {
+		return primary, nil
+	}
+	return llm.NewFallbackProvider(providers), nil
+}
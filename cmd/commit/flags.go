@@ -3,7 +3,14 @@ package main
 import (
 	"flag"
 	"fmt"
+	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/planner"
+	"github.com/dsswift/commit/pkg/types"
 )
 
 // reverseFlag is a custom flag type that accepts bare --reverse (=1) or --reverse=N.
@@ -32,50 +39,313 @@ func (r *reverseFlag) Set(s string) error {
 func (r *reverseFlag) String() string   { return strconv.Itoa(int(*r)) }
 func (r *reverseFlag) IsBoolFlag() bool { return true }
 
+// aliasFlag is a custom flag type that accepts a bare flag (the default alias
+// name) or --flag=name.
+type aliasFlag string
+
+func (a *aliasFlag) Set(s string) error {
+	switch s {
+	case "true":
+		*a = aliasFlag(git.DefaultAliasName)
+	case "false":
+		*a = ""
+	default:
+		*a = aliasFlag(s)
+	}
+	return nil
+}
+
+func (a *aliasFlag) String() string   { return string(*a) }
+func (a *aliasFlag) IsBoolFlag() bool { return true }
+
+// serveFlag is a custom flag type that accepts a bare --serve (the default
+// socket) or --serve=address.
+type serveFlag string
+
+// defaultServeAddr marks a bare --serve.
+const defaultServeAddr = "default"
+
+func (s *serveFlag) Set(v string) error {
+	switch v {
+	case "true":
+		*s = defaultServeAddr
+	case "false":
+		*s = ""
+	default:
+		*s = serveFlag(v)
+	}
+	return nil
+}
+
+func (s *serveFlag) String() string   { return string(*s) }
+func (s *serveFlag) IsBoolFlag() bool { return true }
+
+// snapshotFlag is a custom flag type that accepts a bare --restore-snapshot
+// (the newest snapshot) or --restore-snapshot=id.
+type snapshotFlag string
+
+// latestSnapshot marks a bare --restore-snapshot.
+const latestSnapshot = "latest"
+
+func (s *snapshotFlag) Set(v string) error {
+	switch v {
+	case "true":
+		*s = latestSnapshot
+	case "false":
+		*s = ""
+	default:
+		*s = snapshotFlag(v)
+	}
+	return nil
+}
+
+func (s *snapshotFlag) String() string   { return string(*s) }
+func (s *snapshotFlag) IsBoolFlag() bool { return true }
+
+// listFlag is a custom flag type that collects repeated or comma-separated values.
+type listFlag []string
+
+func (l *listFlag) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+	return nil
+}
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
 type flags struct {
-	staged      bool
-	dryRun      bool
-	verbose     bool
-	reverse     int
-	force       bool
-	interactive bool
-	version     bool
-	upgrade     bool
-	single      bool
-	smart       bool
-	diffFile    string
-	diffFrom    string
-	diffTo      string
-	provider    string
-	setConfig   string
-	message     string
+	staged         bool
+	dryRun         bool
+	verbose        bool
+	veryVerbose    bool // -vv: verbose plus the git trace
+	reverse        int
+	force          bool // deprecated alias for forcePushed
+	forcePushed    bool
+	forceDirty     bool
+	interactive    bool
+	version        bool
+	upgrade        bool
+	single         bool
+	smart          bool
+	diffFile       string
+	diffFrom       string
+	diffTo         string
+	provider       string
+	model          string
+	profile        string
+	setConfig      string
+	migrateConfig  bool
+	message        string
+	release        string
+	publish        bool
+	noCache        bool
+	fullAnalysis   bool // analyze every file even when only a few changed since the cached plan
+	oops           bool
+	history        bool
+	grep           string
+	stats          bool
+	hotspots       bool
+	since          string // period for --stats, e.g. "30d"
+	usage          bool   // show this month's LLM usage against the budget
+	hardBudget     bool   // refuse requests that would exceed COMMIT_MONTHLY_BUDGET_USD
+	bugReport      bool
+	lint           string // revision range whose commit messages are checked
+	analyze        string // unified diff to plan, or "-" for stdin
+	gitTrace       bool
+	massDelete     bool // allow plans over the mass-deletion thresholds
+	review         bool
+	suggestBranch  bool   // ask for branch names and offer to switch before committing
+	output         string // machine-readable report format ("sarif")
+	planSummaryMD  string // where to write the markdown plan summary, "-" for stdout
+	compare        string // two provider specs to compare, "a,b"
+	diffAlgorithm  string
+	validateKeys   bool
+	funcContext    bool
+	focus          []string // paths or globs whose full diffs the LLM sees
+	fixGitignore   bool
+	maxWait        time.Duration // plan locally if the LLM hasn't answered by then
+	staleAfter     time.Duration // re-check the changes before committing a plan older than this
+	fast           bool          // skip the LLM when the local type guesses are confident
+	deterministic  bool          // temperature 0 and a fixed seed, for reproducible plans
+	verify         bool          // run the repo's verifyCommand against each created commit
+	onError        string        // what a failed commit does to the rest of the plan: abort, skip or ask
+	continueOn     bool          // --continue-on-error, short for --on-error skip
+	snapshot       bool          // copy uncommitted files aside before staging anything
+	restore        string        // snapshot ID to restore, or latestSnapshot
+	installAlias   string
+	uninstallAlias string
+	paths          []string // positional pathspecs scoping the run
+	skip           []string // paths or globs left out of the run
+	serve          string   // address of the local API server, or defaultServeAddr
+	doctor         bool
+	rollback       bool // with upgrade: restore the binary the last upgrade replaced
 }
 
 func parseFlags() flags {
+	return parseArgs(os.Args[1:])
+}
+
+// parseArgs parses command-line arguments (without the program name).
+func parseArgs(args []string) flags {
 	f := flags{}
 
 	flag.BoolVar(&f.staged, "staged", false, "Only commit staged files")
 	flag.BoolVar(&f.dryRun, "dry-run", false, "Preview commits without creating them")
 	flag.BoolVar(&f.verbose, "v", false, "Verbose output")
 	flag.BoolVar(&f.verbose, "verbose", false, "Verbose output")
+	flag.BoolVar(&f.veryVerbose, "vv", false, "Debug output: verbose plus the git trace")
 	flag.Var((*reverseFlag)(&f.reverse), "reverse", "Reverse last N commits into uncommitted changes (default 1)")
-	flag.BoolVar(&f.force, "force", false, "Force operation (for --reverse/--interactive on pushed commits)")
+	flag.BoolVar(&f.forcePushed, "force-pushed", false, "Allow rewriting pushed commits (--reverse/--interactive)")
+	flag.BoolVar(&f.forceDirty, "force-dirty", false, "Rebase with uncommitted changes by stashing them around it (--interactive)")
+	flag.BoolVar(&f.force, "force", false, "Deprecated: use --force-pushed")
 	flag.BoolVar(&f.interactive, "i", false, "Interactive rebase wizard")
 	flag.BoolVar(&f.interactive, "interactive", false, "Interactive rebase wizard")
 	flag.BoolVar(&f.version, "version", false, "Print version")
 	flag.BoolVar(&f.upgrade, "upgrade", false, "Upgrade to latest version")
+	flag.BoolVar(&f.rollback, "rollback", false, "With --upgrade: restore the version the last upgrade replaced")
+	flag.BoolVar(&f.doctor, "doctor", false, "Check the config, proxy and connection to the provider")
 	flag.StringVar(&f.diffFile, "diff", "", "Analyze changes to a specific file")
+	flag.StringVar(&f.analyze, "analyze", "", "Plan commits for a unified diff file (- for stdin) without committing")
 	flag.StringVar(&f.diffFrom, "from", "", "Start ref for diff analysis")
 	flag.StringVar(&f.diffTo, "to", "", "End ref for diff analysis")
-	flag.StringVar(&f.provider, "provider", "", "Override LLM provider")
+	flag.StringVar(&f.provider, "provider", "", "Override LLM provider (or provider/model)")
+	flag.StringVar(&f.model, "model", "", "Override LLM model for this run")
+	flag.StringVar(&f.compare, "compare", "", "Analyze with two providers (a,b; each provider or provider/model) and pick a plan")
+	flag.DurationVar(&f.maxWait, "max-wait", 0, "Stop waiting for the LLM after this long and plan locally (e.g. 20s)")
+	flag.DurationVar(&f.staleAfter, "stale-after", planner.DefaultStaleAfter, "Re-check the changes before committing a plan older than this, e.g. one left waiting at a prompt (0 always re-checks)")
+	flag.BoolVar(&f.fast, "fast", false, "Plan locally without the LLM when every file group's type is obvious (docs, tests, dependencies)")
+	flag.BoolVar(&f.deterministic, "deterministic", false, "Sample with temperature 0 and a fixed seed (where the provider takes one) for reproducible plans")
+	flag.BoolVar(&f.hardBudget, "hard-budget", false, "Refuse LLM requests that would take this month's usage past COMMIT_MONTHLY_BUDGET_USD")
+	flag.BoolVar(&f.validateKeys, "validate-keys", false, "Check the API key with the provider before collecting changes")
+	flag.StringVar(&f.profile, "profile", "", "Use a named config profile (or set COMMIT_PROFILE)")
 	flag.BoolVar(&f.single, "single", false, "Create a single commit for all files")
 	flag.BoolVar(&f.single, "1", false, "Create a single commit for all files (shorthand)")
 	flag.BoolVar(&f.smart, "smart", false, "Create semantic commits (default)")
 	flag.StringVar(&f.setConfig, "set", "", "Set config value (e.g., defaultMode=single)")
+	flag.BoolVar(&f.migrateConfig, "migrate-config", false, "Convert ~/.commit-tool/.env and every profile's .env into ~/.commit-tool/config.yaml")
+	flag.Var((*aliasFlag)(&f.installAlias), "install-alias", "Install a global git alias so the tool runs as git sc (--install-alias=name to rename)")
+	flag.Var((*aliasFlag)(&f.uninstallAlias), "uninstall-alias", "Remove the git alias installed with --install-alias")
 	flag.StringVar(&f.message, "m", "", "Guiding message to provide context for commit generation")
 	flag.StringVar(&f.message, "message", "", "Guiding message to provide context for commit generation")
+	flag.BoolVar(&f.noCache, "no-cache", false, "Ignore cached plans and provider responses and analyze again")
+	flag.BoolVar(&f.fullAnalysis, "full-analysis", false, "Analyze every file instead of only those changed since the cached plan")
+	flag.StringVar(&f.diffAlgorithm, "diff-algorithm", "", "Diff algorithm for the diff sent to the LLM (myers, minimal, patience, histogram)")
+	flag.BoolVar(&f.funcContext, "function-context", false, "Send whole functions around each change to the LLM")
+	flag.Var((*listFlag)(&f.skip), "skip", "Leave paths or globs out of this run and commit everything else (repeatable; ** matches any directories)")
+	flag.Var((*listFlag)(&f.focus), "focus", "Send full diffs only for these paths or globs and summarize the rest (repeatable)")
+	flag.BoolVar(&f.oops, "oops", false, "Amend current changes into HEAD without changing its message")
+	flag.BoolVar(&f.fixGitignore, "fix-gitignore", false, "Add junk files (.DS_Store, *.orig, node_modules/...) to .gitignore in their own commit")
+	flag.BoolVar(&f.massDelete, "allow-mass-delete", false, "Commit plans that delete many files without asking")
+	flag.BoolVar(&f.verify, "verify", false, "Run the repo's verifyCommand in a checkout of each created commit and report the ones that fail")
+	flag.StringVar(&f.onError, "on-error", planner.OnErrorAbort, "When a commit fails: abort the plan, skip the commit and continue, or ask (abort, skip, ask)")
+	flag.BoolVar(&f.continueOn, "continue-on-error", false, "Skip commits that fail and create the rest (same as --on-error skip)")
+	flag.BoolVar(&f.snapshot, "snapshot", false, "Copy every modified and untracked file into .git/commit-tool/snapshots before staging anything")
+	flag.Var((*snapshotFlag)(&f.restore), "restore-snapshot", "Restore the files saved by --snapshot (--restore-snapshot=id for an older run)")
+	flag.BoolVar(&f.review, "review", false, "Review the plan before committing: reorder, merge, split or edit commits")
+	flag.BoolVar(&f.suggestBranch, "suggest-branch", false, "Suggest branch names for the changes and offer to create one before committing")
+
+	flag.Var((*serveFlag)(&f.serve), "serve", "Serve a local API for editor plugins on a Unix socket (--serve=path or --serve=127.0.0.1:port)")
+
+	flag.StringVar(&f.output, "output", "", "Write findings as a machine-readable report to stdout (sarif)")
+	flag.StringVar(&f.planSummaryMD, "plan-summary-md", "", "Write the created commits and why they were split as markdown for a PR description to a file (- for stdout)")
+	flag.BoolVar(&f.gitTrace, "show-git-trace", false, "Print every git command run, with timing and exit codes")
+	flag.BoolVar(&f.history, "history", false, "Search past runs (use with --grep)")
+	flag.StringVar(&f.grep, "grep", "", "Text to find in past commit messages, files or context (--history)")
+	flag.BoolVar(&f.stats, "stats", false, "Summarize past runs in this repository (use --hotspots for the most committed files and scopes)")
+	flag.BoolVar(&f.hotspots, "hotspots", false, "List the files and scopes committed most often (--stats)")
+	flag.StringVar(&f.since, "since", "", "Period --stats covers, e.g. 30d, 2w or 72h (default 30d)")
+	flag.BoolVar(&f.usage, "usage", false, "Show this month's LLM tokens and estimated cost by provider, against COMMIT_MONTHLY_BUDGET_USD")
+	flag.StringVar(&f.lint, "lint", "", "Check commit messages in a range (e.g. origin/main..HEAD) against the repo's rules")
+	flag.BoolVar(&f.bugReport, "bug-report", false, "Bundle the most recent failed run's log into a redacted archive to share")
 
-	flag.Parse()
+	flag.StringVar(&f.release, "release-notes", "", "Draft release notes for a tag (e.g., v1.2.0)")
+	flag.BoolVar(&f.publish, "publish", false, "Publish release notes as a GitHub draft release (requires GITHUB_TOKEN)")
+
+	_ = flag.CommandLine.Parse(args) // ExitOnError: exits on failure
+	f.paths = flag.Args()
+
+	if f.veryVerbose {
+		f.verbose = true
+		f.gitTrace = true
+	}
+	if f.continueOn && f.onError == planner.OnErrorAbort {
+		f.onError = planner.OnErrorSkip
+	}
 
 	return f
 }
+
+// profileArg returns the value of a literal --profile argument, if any.
+func profileArg(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		for _, prefix := range []string{"--profile", "-profile"} {
+			if arg == prefix && i+1 < len(args) {
+				return args[i+1]
+			}
+			if value, ok := strings.CutPrefix(arg, prefix+"="); ok {
+				return value
+			}
+		}
+	}
+	return ""
+}
+
+// applyProviderOverride applies --provider and --model to the loaded config.
+// --provider accepts "provider/model"; an explicit --model takes precedence.
+// Switching providers without a model drops the configured model, since it
+// belongs to the other provider. Returns true if anything was overridden.
+func (f flags) applyProviderOverride(cfg *types.UserConfig) bool {
+	provider, model := f.provider, f.model
+	if p, m, ok := strings.Cut(f.provider, "/"); ok {
+		provider = p
+		if model == "" {
+			model = m
+		}
+	}
+
+	if provider == "" && model == "" {
+		return false
+	}
+
+	if provider != "" && provider != cfg.Provider {
+		cfg.Provider = provider
+		cfg.Model = ""
+	}
+	if model != "" {
+		cfg.Model = model
+	}
+	return true
+}
+
+// deterministicSeed is the seed --deterministic sends to providers that
+// take one. It never changes, so re-runs on the same changes sample alike.
+const deterministicSeed = 42
+
+// applyDeterminism applies --deterministic to the loaded config: temperature
+// 0, overriding COMMIT_LLM_TEMPERATURE, and deterministicSeed.
+func (f flags) applyDeterminism(cfg *types.UserConfig) {
+	if !f.deterministic {
+		return
+	}
+	temperature, seed := 0.0, deterministicSeed
+	cfg.Temperature, cfg.Seed = &temperature, &seed
+}
+
+// diffOptions returns the diff options for the LLM diff: the repo config's,
+// with --diff-algorithm and --function-context taking precedence.
+func diffOptions(f flags, repoConfig *types.RepoConfig) git.DiffOptions {
+	opts := git.DiffOptions{
+		Algorithm:       repoConfig.DiffAlgorithm,
+		FunctionContext: repoConfig.FunctionContext || f.funcContext,
+	}
+	if f.diffAlgorithm != "" {
+		opts.Algorithm = f.diffAlgorithm
+	}
+	return opts
+}
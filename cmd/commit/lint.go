@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/planner"
+	"github.com/dsswift/commit/internal/report"
+)
+
+// handleLint checks the messages of the commits in flags.lint against the
+// repo's rules with the validator used for planned commits. Violations are
+// printed one per line, or written to reportOut as SARIF with --output.
+func handleLint(flags flags, reportOut io.Writer) int {
+	cwd, err := os.Getwd()
+	if err != nil {
+		printError("Failed to get current directory", err)
+		return 1
+	}
+
+	gitRoot, err := git.FindGitRoot(cwd)
+	if err != nil {
+		printError("Not a git repository", err)
+		return 1
+	}
+
+	repoConfig, err := config.LoadRepoConfig(gitRoot)
+	if err != nil {
+		printError("Failed to load repo config", err)
+		return 1
+	}
+	if err := useConvention(repoConfig, gitRoot); err != nil {
+		printError("Failed to load repo config", err)
+		return 1
+	}
+
+	printStep("🔎", fmt.Sprintf("Linting commits in %s...", flags.lint))
+
+	collector := git.NewCollector(gitRoot)
+	commits, err := collector.LogRange(flags.lint)
+	if err != nil {
+		printError("Failed to read commits", err)
+		return 1
+	}
+
+	validator := planner.NewValidator(gitRoot, repoConfig, nil)
+	if branch, err := collector.CurrentBranch(); err == nil {
+		validator.SetBranch(branch)
+	}
+
+	var findings []report.Finding
+	checked, failed := 0, 0
+	for _, c := range commits {
+		if planner.SkipLint(c.Message) {
+			continue
+		}
+		checked++
+
+		errs := validator.ValidateMessage(c.Message)
+		if len(errs) == 0 {
+			continue
+		}
+		failed++
+		for _, e := range errs {
+			fmt.Printf("%s: %s: %s\n", c.ShortHash, c.Message, e.Message)
+			findings = append(findings, report.Finding{
+				RuleID:  report.RuleInvalidMessage,
+				Level:   report.LevelError,
+				Message: fmt.Sprintf("%s %q: %s", c.ShortHash, c.Message, e.Message),
+			})
+		}
+	}
+
+	if flags.output == "sarif" {
+		if err := report.WriteSARIF(reportOut, Version, findings); err != nil {
+			printError("Failed to write SARIF report", err)
+			return 1
+		}
+	}
+
+	if failed > 0 {
+		printFinal("❌", fmt.Sprintf("%d of %d commits break the repo's rules", failed, checked))
+		return 1
+	}
+	printFinal("✅", fmt.Sprintf("%d commits follow the repo's rules", checked))
+	return 0
+}
@@ -3,20 +3,36 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/charmbracelet/x/term"
+
 	"github.com/dsswift/commit/internal/analyzer"
 	"github.com/dsswift/commit/internal/assert"
 	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/convention"
 	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/httpclient"
 	"github.com/dsswift/commit/internal/interactive"
 	"github.com/dsswift/commit/internal/llm"
 	"github.com/dsswift/commit/internal/logging"
 	"github.com/dsswift/commit/internal/planner"
+	"github.com/dsswift/commit/internal/release"
+	"github.com/dsswift/commit/internal/report"
+	"github.com/dsswift/commit/internal/theme"
+	"github.com/dsswift/commit/internal/tokens"
 	"github.com/dsswift/commit/internal/updater"
 	"github.com/dsswift/commit/pkg/types"
 )
@@ -62,8 +78,69 @@ func run() (exitCode int) {
 		}
 	}()
 
-	// Parse flags
-	flags := parseFlags()
+	// Expand @preset arguments, then parse flags
+	args := os.Args[1:]
+	if config.HasPresetArg(args) {
+		expanded, err := expandPresetArgs(args)
+		if err != nil {
+			printError("Invalid preset", err)
+			return 1
+		}
+		args = expanded
+	}
+	flags := parseArgs(args)
+
+	// Keep stdout for the report; console output moves to stderr
+	reportOut := os.Stdout
+	if flags.output != "" {
+		if flags.output != "sarif" {
+			printError("Invalid output format", fmt.Errorf("unknown format %q (supported: sarif)", flags.output))
+			return 1
+		}
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = reportOut }()
+	}
+	if flags.planSummaryMD == "-" {
+		if flags.output != "" {
+			printError("Invalid output", fmt.Errorf("--plan-summary-md - and --output can't both write to stdout"))
+			return 1
+		}
+		os.Stdout = os.Stderr
+		defer func() { os.Stdout = reportOut }()
+	}
+
+	if flags.diffAlgorithm != "" && !git.ValidDiffAlgorithm(flags.diffAlgorithm) {
+		printError("Invalid diff algorithm", fmt.Errorf("unknown algorithm %q (supported: %s)", flags.diffAlgorithm, strings.Join(git.DiffAlgorithms, ", ")))
+		return 1
+	}
+
+	if !slices.Contains(planner.OnErrorPolicies, flags.onError) {
+		printError("Invalid --on-error policy", fmt.Errorf("unknown policy %q (supported: %s)", flags.onError, strings.Join(planner.OnErrorPolicies, ", ")))
+		return 1
+	}
+
+	// Record every git command for the execution log and --show-git-trace
+	trace := &gitTrace{keep: flags.gitTrace}
+	git.SetTracer(trace.record)
+	defer git.SetTracer(nil)
+	if flags.gitTrace {
+		defer func() { printGitTrace(trace.entries, flags.verbose) }()
+	}
+
+	if flags.force {
+		printWarning("--force is deprecated; use --force-pushed")
+		flags.forcePushed = true
+	}
+
+	// Select the profile before anything reads config or opens logs
+	if err := config.SetProfile(flags.profile); err != nil {
+		printError("Invalid profile", err)
+		return 1
+	}
+
+	loadTheme()
+	loadLogLevel(&flags)
+	loadNetwork()
 
 	// Handle special flags
 	if flags.version {
@@ -72,16 +149,27 @@ func run() (exitCode int) {
 			displayVersion = fmt.Sprintf("dev-%s", BuildTime)
 		}
 		fmt.Printf("commit version %s\n", displayVersion)
-		// Always check for updates (bypass cache)
-		versionInfo := updater.CheckVersionFresh(Version)
-		if notice := updater.FormatUpdateNotice(versionInfo); notice != "" {
-			fmt.Print(notice)
+		// Check for updates (bypassing the cache) unless checks are off
+		if updateCheckMode(flags) != updater.CheckNever {
+			versionInfo := updater.CheckVersionFresh(Version)
+			if notice := updater.FormatUpdateNotice(versionInfo); notice != "" {
+				fmt.Print(notice)
+			}
 		}
 		return 0
 	}
 
+	if flags.rollback && !flags.upgrade {
+		printError("Invalid flags", fmt.Errorf("--rollback is used with --upgrade"))
+		return 1
+	}
+
 	if flags.upgrade {
-		result := updater.Upgrade(Version)
+		upgrade := updater.Upgrade
+		if flags.rollback {
+			upgrade = updater.Rollback
+		}
+		result := upgrade(Version)
 		fmt.Println(updater.FormatUpgradeResult(result))
 		if result.Success {
 			return 0
@@ -89,14 +177,76 @@ func run() (exitCode int) {
 		return 1
 	}
 
+	if flags.doctor {
+		return handleDoctor(flags)
+	}
+
 	// Handle --set flag
 	if flags.setConfig != "" {
 		return handleSetConfig(flags.setConfig)
 	}
 
+	// Handle --migrate-config flag
+	if flags.migrateConfig {
+		return handleMigrateConfig()
+	}
+
+	// Handle --install-alias / --uninstall-alias
+	if flags.installAlias != "" {
+		return handleInstallAlias(flags.installAlias)
+	}
+	if flags.uninstallAlias != "" {
+		return handleUninstallAlias(flags.uninstallAlias)
+	}
+
+	// Handle --restore-snapshot flag
+	if flags.restore != "" {
+		return handleRestoreSnapshot(flags)
+	}
+
 	// Handle --diff flag
 	if flags.diffFile != "" {
-		return handleDiff(flags)
+		return readOnly(func() int { return handleDiff(flags) })
+	}
+
+	// Handle --analyze flag
+	if flags.analyze != "" {
+		return readOnly(func() int { return handleAnalyze(flags) })
+	}
+
+	// Handle --serve flag
+	if flags.serve != "" {
+		return handleServe(flags)
+	}
+
+	// Handle --release-notes flag
+	if flags.release != "" {
+		return handleReleaseNotes(flags)
+	}
+
+	// Handle --history flag
+	if flags.history {
+		return handleHistory(flags)
+	}
+
+	// Handle --stats flag
+	if flags.stats || flags.hotspots {
+		return handleStats(flags)
+	}
+
+	// Handle --usage flag
+	if flags.usage {
+		return handleUsage()
+	}
+
+	// Handle --lint flag
+	if flags.lint != "" {
+		return handleLint(flags, reportOut)
+	}
+
+	// Handle --bug-report flag
+	if flags.bugReport {
+		return handleBugReport()
 	}
 
 	// Handle --interactive flag
@@ -113,13 +263,22 @@ func run() (exitCode int) {
 	}
 	defer func() {
 		if logger != nil {
+			trace.setLogger(nil)
 			_ = logger.Close()
 		}
 	}()
+	if logger != nil {
+		trace.setLogger(logger)
+		if mirrorConsoleEnabled() {
+			setConsoleMirror(logger)
+			defer setConsoleMirror(nil)
+		}
+	}
 
 	// Log start
 	if logger != nil {
-		logger.LogStart(Version, os.Args[1:])
+		logger.LogStart(Version, args)
+		logger.LogEnvironment(runEnvironment(flags))
 	}
 
 	// Run cleanup in background
@@ -127,18 +286,51 @@ func run() (exitCode int) {
 
 	// Start version check in background
 	versionChan := make(chan *updater.VersionInfo, 1)
-	go func() {
-		defer func() {
-			if r := recover(); r != nil {
-				versionChan <- nil
-			}
+	if mode := updateCheckMode(flags); mode != updater.CheckNever {
+		check := updater.CheckVersion
+		if mode == updater.CheckAlways {
+			check = updater.CheckVersionFresh
+		}
+		go func() {
+			defer func() {
+				if r := recover(); r != nil {
+					versionChan <- nil
+				}
+			}()
+			versionChan <- check(Version)
 		}()
-		versionChan <- updater.CheckVersion(Version)
-	}()
+	}
 
 	// Execute main logic
 	result := execute(flags, logger)
 
+	// Offer a follow-up commit for files the run left changed
+	if len(result.Leftover) > 0 {
+		if isTerminal(os.Stdin) && confirm("   Commit them now?") {
+			result = followUp(flags, result, logger)
+		} else {
+			fmt.Println("   Run commit again to commit them.")
+		}
+	}
+
+	if flags.output == "sarif" {
+		if err := report.WriteSARIF(reportOut, Version, result.Findings); err != nil {
+			printError("Failed to write SARIF report", err)
+			if result.ExitCode == 0 {
+				result.ExitCode = 1
+			}
+		}
+	}
+
+	if flags.planSummaryMD != "" && len(result.CommitsCreated) > 0 {
+		if err := writePlanSummary(flags.planSummaryMD, reportOut, result.CommitsCreated); err != nil {
+			printError("Failed to write plan summary", err)
+			if result.ExitCode == 0 {
+				result.ExitCode = 1
+			}
+		}
+	}
+
 	// Write registry entry
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -154,14 +346,21 @@ func run() (exitCode int) {
 		Timestamp:      time.Now().UTC().Format(time.RFC3339),
 		Version:        Version,
 		CWD:            cwd,
-		Args:           os.Args[1:],
+		Args:           args,
 		GitRoot:        gitRoot,
 		DurationMS:     result.Duration.Milliseconds(),
 		ExitCode:       result.ExitCode,
 		CommitsCreated: len(result.CommitsCreated),
+		Unverified:     countUnverified(result.CommitsCreated),
+		Partial:        result.Partial,
+		Commits:        registryCommits(result.CommitsCreated),
 	}
 	_ = logging.WriteRegistryEntry(entry)
 
+	if gitRoot != "" {
+		notifyWebhook(gitRoot, flags, result, logger)
+	}
+
 	// Log completion
 	if logger != nil {
 		logger.LogComplete(result.ExitCode, len(result.CommitsCreated))
@@ -173,6 +372,9 @@ func run() (exitCode int) {
 		if notice := updater.FormatUpdateNotice(versionInfo); notice != "" {
 			fmt.Print(notice)
 		}
+		if notice := modelDeprecationNotice(flags, versionInfo); notice != "" {
+			fmt.Print(notice)
+		}
 	default:
 		// Version check not complete, don't wait
 	}
@@ -180,10 +382,104 @@ func run() (exitCode int) {
 	return result.ExitCode
 }
 
+// ciEnvVars are set by CI services; any of them marks a CI run.
+var ciEnvVars = []string{
+	"CI", "CONTINUOUS_INTEGRATION", "GITHUB_ACTIONS", "GITLAB_CI", "BUILDKITE", "CIRCLECI",
+	"JENKINS_URL", "TF_BUILD", "TEAMCITY_VERSION", "BITBUCKET_BUILD_NUMBER", "CODEBUILD_BUILD_ID",
+}
+
+// isCI reports whether the tool runs under a CI service.
+func isCI() bool {
+	for _, name := range ciEnvVars {
+		if v := os.Getenv(name); v != "" && v != "false" && v != "0" {
+			return true
+		}
+	}
+	return false
+}
+
+// updateCheckMode returns how often to check GitHub for a new version:
+// COMMIT_UPDATE_CHECK, daily by default, and never in CI or when writing a
+// machine-readable report, whose consumers don't want the notice.
+func updateCheckMode(flags flags) string {
+	if flags.output != "" || isCI() {
+		return updater.CheckNever
+	}
+	mode := configSetting("COMMIT_UPDATE_CHECK")
+	if mode == "" {
+		return updater.CheckDaily
+	}
+	if !slices.Contains(updater.CheckModes, mode) {
+		printWarning(fmt.Sprintf("Ignoring COMMIT_UPDATE_CHECK: %q is not one of %s", mode, strings.Join(updater.CheckModes, ", ")))
+		return updater.CheckDaily
+	}
+	return mode
+}
+
+// modelDeprecationNotice warns, once per model, when the configured model is
+// in the deprecated model manifest fetched with the version check.
+func modelDeprecationNotice(flags flags, versionInfo *updater.VersionInfo) string {
+	userConfig, err := config.LoadUserConfig()
+	if err != nil {
+		return ""
+	}
+	flags.applyProviderOverride(userConfig)
+	return updater.ModelDeprecationNotice(versionInfo, userConfig.Provider, userConfig.Model)
+}
+
+// expandPresetArgs replaces @name arguments with the flags of the named preset
+// from .commit.json or the profile's .env.
+func expandPresetArgs(args []string) ([]string, error) {
+	// Personal presets live in the profile's .env, so honour a literal --profile first
+	if err := config.SetProfile(profileArg(args)); err != nil {
+		return nil, err
+	}
+
+	gitRoot := ""
+	if cwd, err := os.Getwd(); err == nil {
+		gitRoot, _ = git.FindGitRoot(cwd)
+	}
+
+	presets, err := config.LoadPresets(gitRoot)
+	if err != nil {
+		return nil, err
+	}
+	return config.ExpandPresets(args, presets)
+}
+
+// gitTrace collects traced git commands for the execution log and --show-git-trace.
+type gitTrace struct {
+	mu      sync.Mutex
+	logger  *logging.ExecutionLogger
+	keep    bool
+	entries []git.TraceEntry
+}
+
+func (t *gitTrace) record(e git.TraceEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.logger != nil {
+		t.logger.LogGitCommand(e.Args, e.Duration, e.ExitCode, e.Output, e.Stderr)
+	}
+	if t.keep {
+		t.entries = append(t.entries, e)
+	}
+}
+
+func (t *gitTrace) setLogger(logger *logging.ExecutionLogger) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.logger = logger
+}
+
 type executeResult struct {
 	ExitCode       int
 	Duration       time.Duration
 	CommitsCreated []types.ExecutedCommit
+	Findings       []report.Finding
+	Partial        bool     // planned locally after the LLM missed --max-wait
+	Leftover       []string // planned files still changed after executing
 }
 
 func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
@@ -207,9 +503,50 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 		return result
 	}
 
+	// COMMIT_DRY_RUN has to be known before the first git command runs; a
+	// config that fails to load is reported once the run gets to it
+	if !flags.dryRun {
+		if userConfig, err := config.LoadUserConfig(); err == nil && userConfig.DryRun {
+			flags.dryRun = true
+		}
+	}
+
+	// Dry runs must leave the index and working tree exactly as they found them
+	if flags.dryRun {
+		git.SetReadOnly(true)
+		defer git.SetReadOnly(false)
+	}
+
+	// Keep two runs from interleaving their staging in the same repository
+	if !flags.dryRun {
+		lock, err := acquireLock(gitRoot)
+		if err != nil {
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+		defer lock.Release() //nolint:errcheck // best-effort cleanup
+	}
+
+	// Copy uncommitted work aside before anything stages or resets it
+	if flags.snapshot && !flags.dryRun {
+		if err := takeSnapshot(gitRoot, logger); err != nil {
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+	}
+
 	// Handle --reverse
 	if flags.reverse > 0 {
-		result.ExitCode = handleReverse(gitRoot, flags.reverse, flags.force, flags.verbose)
+		result.ExitCode = handleReverse(gitRoot, flags.reverse, flags.forcePushed, flags.dryRun, flags.verbose)
+		result.Duration = time.Since(startTime)
+		return result
+	}
+
+	// Handle --oops
+	if flags.oops {
+		result.ExitCode = handleOops(gitRoot, cwd, flags)
 		result.Duration = time.Since(startTime)
 		return result
 	}
@@ -225,15 +562,9 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 		return result
 	}
 
-	// Override provider if specified
-	if flags.provider != "" {
-		userConfig.Provider = flags.provider
-	}
-
-	// Override dry-run if configured
-	if userConfig.DryRun {
-		flags.dryRun = true
-	}
+	// Override provider and model if specified
+	overridden := flags.applyProviderOverride(userConfig)
+	flags.applyDeterminism(userConfig)
 
 	repoConfig, err := config.LoadRepoConfig(gitRoot)
 	if err != nil {
@@ -242,6 +573,18 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 		result.Duration = time.Since(startTime)
 		return result
 	}
+	if err := useConvention(repoConfig, gitRoot); err != nil {
+		printError("Failed to load repo config", err)
+		result.ExitCode = 1
+		result.Duration = time.Since(startTime)
+		return result
+	}
+	if flags.verify && repoConfig.VerifyCommand == "" {
+		printError("Cannot verify commits", fmt.Errorf("--verify needs a verifyCommand in .commit.json"))
+		result.ExitCode = 1
+		result.Duration = time.Since(startTime)
+		return result
+	}
 
 	// Log config loaded
 	if logger != nil {
@@ -250,9 +593,18 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 			scopes = append(scopes, s.Scope)
 		}
 		logger.LogConfigLoaded(userConfig.Provider, len(repoConfig.Scopes) > 0, scopes)
+		if overridden {
+			logger.LogProviderOverride(userConfig.Provider, userConfig.Model)
+		}
 	}
 
+	if profile := config.ActiveProfile(); profile != "" {
+		printSuccess(fmt.Sprintf("Profile: %s", profile))
+	}
 	printSuccess(fmt.Sprintf("Provider: %s", userConfig.Provider))
+	if overridden && userConfig.Model != "" {
+		printSuccess(fmt.Sprintf("Model: %s (override)", userConfig.Model))
+	}
 	if len(repoConfig.Scopes) > 0 {
 		seen := make(map[string]bool)
 		var scopeNames []string
@@ -265,10 +617,62 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 		printSuccess(fmt.Sprintf("Scopes (from .commit.json): %s", strings.Join(scopeNames, ", ")))
 	}
 
+	// Surface a bad key now rather than after building the prompt
+	if flags.validateKeys {
+		if err := validateKey(userConfig); err != nil {
+			if _, ok := err.(*config.InvalidAPIKeyError); ok {
+				handleConfigError(err)
+			} else {
+				printError("Failed to check API key", err)
+			}
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+	}
+
 	// Collect git changes
 	printStep("📂", "Collecting changes...")
 
 	collector := git.NewCollector(gitRoot)
+
+	// Scope the run to positional paths, like pathspecs passed to git
+	var pathspecs []string
+	if len(flags.paths) > 0 {
+		pathspecs, err = git.ResolvePathspecs(gitRoot, cwd, flags.paths)
+		if err != nil {
+			printError("Invalid path", err)
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+
+		if ignored := countOutOfScope(gitRoot, pathspecs, flags.staged); ignored > 0 {
+			printProgress(fmt.Sprintf("%d other changed files ignored", ignored))
+		}
+	}
+
+	// Leave --skip paths out of everything the run does
+	var skipped []string
+	if len(flags.skip) > 0 {
+		skips, err := git.ResolvePathspecs(gitRoot, cwd, flags.skip)
+		if err != nil {
+			printError("Invalid --skip path", err)
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+		pathspecs = append(pathspecs, git.SkipPathspecs(skips)...)
+	}
+	if len(pathspecs) > 0 {
+		collector.SetPathspecs(pathspecs)
+		if skipped, err = collector.SkippedFiles(); err != nil {
+			printWarning(fmt.Sprintf("Couldn't list skipped files: %v", err))
+		} else if len(skipped) > 0 {
+			printProgress(fmt.Sprintf("%d files skipped", len(skipped)))
+		}
+	}
+
 	status, err := collector.Status()
 	if err != nil {
 		printError("Failed to get git status", err)
@@ -305,6 +709,12 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 	added := len(status.Added) + len(status.Untracked)
 	printSuccess(fmt.Sprintf("Found %d files (%d modified, %d new)", len(files), modified, added))
 
+	// A new repository's first commits are planned locally from file paths
+	firstCommit := !collector.HasCommits()
+	if firstCommit {
+		printProgress("No commits yet; planning the first commit")
+	}
+
 	if flags.verbose {
 		for _, f := range files {
 			scope := config.ResolveScope(f, repoConfig)
@@ -315,9 +725,61 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 		}
 	}
 
+	// Junk like .DS_Store belongs in .gitignore rather than a commit
+	fixGitignore := false
+	var junk []string
+	if !flags.staged {
+		if suggestions := git.SuggestIgnores(status.Untracked); len(suggestions) > 0 {
+			var patterns []string
+			for _, s := range suggestions {
+				patterns = append(patterns, s.Pattern)
+				junk = append(junk, s.Files...)
+			}
+			if flags.fixGitignore {
+				fixGitignore = true
+				if !flags.dryRun {
+					if _, err := git.AppendIgnores(gitRoot, patterns); err != nil {
+						printError("Failed to update .gitignore", err)
+						result.ExitCode = 1
+						result.Duration = time.Since(startTime)
+						return result
+					}
+				}
+				printSuccess(fmt.Sprintf("Ignoring %d junk files via .gitignore: %s", len(junk), strings.Join(patterns, " ")))
+				if !slices.Contains(files, git.GitignoreFile) {
+					files = append(files, git.GitignoreFile)
+				}
+			} else {
+				printWarning(fmt.Sprintf("%d files look like junk (%s); run with --fix-gitignore to ignore them",
+					len(junk), strings.Join(patterns, " ")))
+			}
+		}
+	}
+
 	// Build analysis context
 	contextBuilder := analyzer.NewContextBuilder(gitRoot, repoConfig)
+	contextBuilder.SetPathspecs(pathspecs)
+	if fixGitignore {
+		contextBuilder.SetExcludes(append(junk, git.GitignoreFile))
+	}
+	if flags.diffAlgorithm != "" || flags.funcContext {
+		contextBuilder.SetDiffOptions(diffOptions(flags, repoConfig))
+	}
+	if len(flags.focus) > 0 {
+		focus, err := git.ResolvePathspecs(gitRoot, cwd, flags.focus)
+		if err != nil {
+			printError("Invalid --focus path", err)
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+		contextBuilder.SetFocus(focus)
+	}
 	analysisReq, err := contextBuilder.Build(flags.staged)
+	if _, ok := err.(*analyzer.NoChangesError); ok && fixGitignore {
+		// Only junk changed; the .gitignore commit is the whole plan
+		analysisReq, err = &types.AnalysisRequest{Rules: types.CommitRules{Types: repoConfig.AllowedTypes()}}, nil
+	}
 	if err != nil {
 		if _, ok := err.(*analyzer.NoChangesError); ok {
 			printFinal("❌", "Nothing to commit")
@@ -331,6 +793,34 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 		return result
 	}
 
+	// What was analyzed, to re-check against if the plan waits too long
+	collectedAt := time.Now()
+	analyzedState := analyzedTreeState(collector, analysisReq, flags.staged, "")
+
+	// Staging LFS files without git-lfs fails part-way or commits their full content
+	if !flags.dryRun {
+		if err := git.CheckLFS(gitRoot, lfsFiles(analysisReq.Files)); err != nil {
+			printError("Git LFS is required", err)
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+	}
+
+	if len(flags.focus) > 0 {
+		focused := 0
+		for _, f := range analysisReq.Files {
+			if f.Focused {
+				focused++
+			}
+		}
+		if focused == 0 {
+			printWarning("No changed files match --focus; sending every file's diff")
+		} else if flags.verbose {
+			printVerbose(fmt.Sprintf("Full diffs for %d focused files; others summarized", focused))
+		}
+	}
+
 	// Resolve commit mode: flags override config
 	singleMode := flags.single
 	if !flags.single && !flags.smart {
@@ -346,6 +836,24 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 	analysisReq.SingleCommit = singleMode
 	analysisReq.GuidingMessage = flags.message
 
+	// Custom prompt templates replace the built-in prompts
+	prompts, err := config.LoadPromptTemplates(repoConfig)
+	if err == nil {
+		err = llm.CheckPromptTemplates(prompts)
+	}
+	if err != nil {
+		printError("Invalid prompt template", err)
+		result.ExitCode = 1
+		result.Duration = time.Since(startTime)
+		return result
+	}
+	if prompts != nil {
+		analysisReq.Prompts = prompts
+		if flags.verbose {
+			printVerbose("Using custom prompt templates")
+		}
+	}
+
 	// Log context built
 	if logger != nil {
 		var scopes []string
@@ -356,82 +864,370 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 				scopeSet[f.Scope] = true
 			}
 		}
-		logger.LogContextBuilt(len(analysisReq.Files), len(analysisReq.Diff), scopes)
+		logger.LogContextBuilt(len(analysisReq.Files), tokens.Count(tokenModel(userConfig), analysisReq.Diff), scopes)
 	}
 
-	// Create LLM provider
 	printStep("🤖", "Analyzing changes...")
 
-	provider, err := getProviderFunc()(userConfig)
-	if err != nil {
-		printError("Failed to create LLM provider", err)
-		result.ExitCode = 1
-		result.Duration = time.Since(startTime)
-		return result
+	// Reuse the last validated plan when nothing changed since it was made
+	var planCache *planner.PlanCache
+	var cacheKey string
+	var treeState *planner.TreeState
+	if treeHash, err := collector.WorkingTreeHash(flags.staged); err == nil {
+		if planCache, err = planner.NewPlanCache(gitRoot); err == nil {
+			inputs := []string{Version, userConfig.Provider, userConfig.Model,
+				strconv.FormatBool(singleMode), flags.message, strings.Join(pathspecs, "\x00")}
+			if prompts != nil {
+				inputs = append(inputs, prompts.System, prompts.User)
+			}
+			cacheKey = planner.CacheKey(treeHash, inputs...)
+			if analyzedState != nil {
+				state := *analyzedState
+				state.Inputs = planner.CacheKey("", inputs...)
+				treeState = &state
+			}
+		}
 	}
 
-	printProgress(fmt.Sprintf("Sending to %s...", provider.Model()))
-
-	// Log LLM request
-	if logger != nil {
-		systemPrompt, userPrompt := llm.BuildPrompt(analysisReq)
-		logger.LogLLMRequest(provider.Name(), provider.Model(), len(systemPrompt)+len(userPrompt))
+	var plan *types.CommitPlan
+	cached, plannedFast := false, false
+	if planCache != nil && !flags.noCache && flags.compare == "" {
+		plan, cached = planCache.Load(cacheKey)
 	}
 
-	// Call LLM
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	plan, err := provider.Analyze(ctx, analysisReq)
-	if err != nil {
-		printStepError("Request failed")
-		printFinal("❌", "LLM request failed")
-		fmt.Printf("   Error: %v\n", err)
-		fmt.Println("\n   💡 Check your API key in ~/.commit-tool/.env")
-		if logger != nil {
-			logger.LogError(err)
+	// After a small edit, re-analyze only the files that changed since the
+	// cached plan was made. A single commit's message covers every file, so
+	// single mode always analyzes everything.
+	var delta *planner.PlanDelta
+	llmReq := analysisReq
+	if planCache != nil && treeState != nil && !cached && !singleMode &&
+		!flags.noCache && !flags.fullAnalysis && flags.compare == "" {
+		if delta, _ = planCache.LoadDelta(treeState); delta != nil {
+			llmReq = deltaRequest(analysisReq, delta.Changed)
 		}
-		result.ExitCode = 1
-		result.Duration = time.Since(startTime)
-		return result
 	}
 
-	printSuccess("Analysis complete")
-
-	// Log LLM response
-	if logger != nil {
-		logger.LogLLMResponse(0, len(plan.Commits))
+	if len(analysisReq.GeneratedFiles) > 0 {
+		printProgress(fmt.Sprintf("%d generated files excluded from analysis", len(analysisReq.GeneratedFiles)))
 	}
 
-	// Validate and fix plan (merges overlapping commits, truncates long messages)
-	printStep("📋", "Planning commits...")
-
-	validator := planner.NewValidator(gitRoot, repoConfig, files)
-	plan, validationResult := validator.ValidateAndFix(plan)
+	// Reuse the provider's answer to an identical request, such as a re-run
+	// after a dry run or a plan that failed validation
+	responses, responseKey := responseCache(flags, userConfig, llmReq)
 
-	// Log validation
-	if logger != nil {
-		var errorStrings []string
-		for _, e := range validationResult.Errors {
-			errorStrings = append(errorStrings, e.Error())
+	if cached {
+		printSuccess("Analysis complete (cached plan)")
+		if logger != nil {
+			logger.LogPlanCacheHit(cacheKey, len(plan.Commits))
 		}
-		logger.LogPlanValidated(validationResult.Valid, errorStrings)
-	}
-
-	if !validationResult.Valid {
-		printStepError("Validation failed")
-		for _, e := range validationResult.Errors {
-			fmt.Printf("   • %s\n", e.Error())
+	} else if delta != nil && len(delta.Changed) == 0 {
+		// Changed files dropped out (or only generated files changed); the rest still holds
+		plan = delta.Plan
+		printSuccess("Analysis complete (cached plan, no files to re-analyze)")
+		if logger != nil {
+			logger.LogPlanDelta(nil, len(delta.Plan.Commits))
+		}
+	} else if len(analysisReq.Files) == 0 {
+		// Only generated files or junk changed; there is nothing for the LLM to classify
+		plan = &types.CommitPlan{}
+		if len(analysisReq.GeneratedFiles) > 0 {
+			printSuccess("Analysis skipped (generated files only)")
+		} else {
+			printSuccess("Analysis skipped (.gitignore only)")
+		}
+	} else if firstCommit {
+		plan = planner.BootstrapPlan(analysisReq)
+		printSuccess("Analysis skipped (first commit, planned from file paths)")
+	} else if flags.compare != "" {
+		plan, err = comparePlans(flags, userConfig, analysisReq, logger)
+		if errors.Is(err, errBudgetExceeded) {
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+		if err != nil {
+			printStepError("Comparison failed")
+			printFinal("❌", "LLM request failed")
+			fmt.Printf("   Error: %v\n", err)
+			if logger != nil {
+				logger.LogError(err)
+			}
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+		if plan == nil {
+			printFinal("👋", "No plan chosen; nothing was committed")
+			result.Duration = time.Since(startTime)
+			return result
 		}
+	} else if fastPlan, ok := planner.FastPlan(llmReq); flags.fast && ok {
+		plan, plannedFast = fastPlan, true
+		if delta != nil {
+			plan = planner.MergeDelta(delta, plan)
+		}
+		printSuccess("Analysis skipped (--fast, typed locally from file paths)")
+		if logger != nil {
+			logger.LogFastPlan(len(plan.Commits))
+		}
+	} else if response, ok := responses.Load(responseKey); ok {
+		plan = response.Plan
+		if delta != nil {
+			plan = planner.MergeDelta(delta, plan)
+		}
+		age := time.Since(response.CreatedAt).Round(time.Second)
+		printSuccess(fmt.Sprintf("Analysis complete (cached %s response from %s ago; --no-cache to ask again)", response.Model, age))
+		if logger != nil {
+			logger.LogResponseCacheHit(responseKey, response.Provider+"/"+response.Model, age, len(plan.Commits))
+		}
+	} else {
+		if flags.fast {
+			printProgress("Types aren't obvious from the file paths; asking the LLM")
+		}
+
+		// Create LLM provider, with COMMIT_PROVIDER_FALLBACKS behind it
+		provider, err := analysisProvider(flags, userConfig)
+		if err != nil {
+			printError("Failed to create LLM provider", err)
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+
+		if delta != nil {
+			printProgress(fmt.Sprintf("Re-analyzing %d changed files; reusing the previous plan for the rest (--full-analysis to redo it)", len(delta.Changed)))
+			if logger != nil {
+				logger.LogPlanDelta(delta.Changed, len(delta.Plan.Commits))
+			}
+		}
+		printProgress(fmt.Sprintf("Sending to %s...", provider.Model()))
+
+		// A change set too large for the context window is analyzed in chunks
+		// rather than cut down to fit
+		chunks := llm.SplitRequest(provider.Model(), userConfig.MaxTokens, llmReq)
+		var promptTokens int
+		if len(chunks) > 1 {
+			promptTokens = reportChunks(provider, chunks, flags.verbose, logger)
+		} else {
+			promptTokens = reportPromptFit(provider, llmReq, userConfig.MaxTokens, flags.verbose, logger)
+		}
+
+		// Log LLM request
+		if logger != nil {
+			logger.LogLLMRequest(provider.Name(), provider.Model(), promptTokens)
+		}
+		logSampling(flags, userConfig, provider.Name(), logger)
+		if !checkBudget(flags, userConfig, requestCost(provider.Model(), promptTokens), logger) {
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+
+		// Call LLM
+		timeout := 60 * time.Second
+		if flags.maxWait > 0 {
+			timeout = flags.maxWait
+		}
+		if chain, ok := provider.(*llm.FallbackProvider); ok {
+			chain.SetOnFallback(func(failed, next llm.Provider, err error) {
+				printWarning(fmt.Sprintf("%s/%s unavailable: %v", failed.Name(), failed.Model(), err))
+				printProgress(fmt.Sprintf("Falling back to %s...", next.Model()))
+				if logger != nil {
+					logger.LogProviderFallback(failed.Name()+"/"+failed.Model(), next.Name()+"/"+next.Model(), err)
+				}
+				// Chunks fall back one by one, concurrently; their total stands
+				if len(chunks) > 1 {
+					return
+				}
+				promptTokens = reportPromptFit(next, llmReq, userConfig.MaxTokens, flags.verbose, logger)
+				if logger != nil {
+					logger.LogLLMRequest(next.Name(), next.Model(), promptTokens)
+				}
+			})
+			// Each provider gets the whole timeout unless --max-wait caps the analysis
+			if flags.maxWait == 0 {
+				chain.SetAttemptTimeout(timeout)
+				timeout *= time.Duration(chain.Len())
+			}
+		}
+		// Chunks beyond the first MaxParallelChunks wait for a turn
+		if flags.maxWait == 0 {
+			timeout *= time.Duration((len(chunks) + llm.MaxParallelChunks - 1) / llm.MaxParallelChunks)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		plan, err = llm.AnalyzeChunks(ctx, provider, chunks, func(parseErr error) {
+			printProgress("Response was not valid JSON, asking for a corrected plan...")
+			if logger != nil {
+				logger.LogPlanRepair(parseErr)
+			}
+		})
+		if err != nil && flags.maxWait > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			// Providers don't stream, so there are no partial commits to keep
+			printStepError(fmt.Sprintf("No response within %s", flags.maxWait))
+			printWarning("Planning locally from file paths; review the messages before pushing")
+			plan = planner.HeuristicPlan(llmReq)
+			result.Partial = true
+			err = nil
+			if logger != nil {
+				logger.LogAnalysisTimeout(flags.maxWait, "heuristic")
+			}
+		}
+		if err != nil {
+			printStepError("Request failed")
+			printFinal("❌", "LLM request failed")
+			fmt.Printf("   Error: %v\n", err)
+			fmt.Printf("\n   💡 Check your API key in %s\n", configFileHint())
+			if logger != nil {
+				logger.LogError(err)
+			}
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+
+		responseTokens := llm.PlanTokens(provider.Model(), plan)
+		if !result.Partial {
+			responses.Save(responseKey, provider.Name(), provider.Model(), plan)
+			recordUsage(provider.Name(), provider.Model(), promptTokens, responseTokens)
+		}
+		if delta != nil {
+			plan = planner.MergeDelta(delta, plan)
+		}
+
+		if !result.Partial {
+			if len(chunks) > 1 {
+				printSuccess(fmt.Sprintf("Analysis complete (%d chunks)", len(chunks)))
+			} else {
+				printSuccess("Analysis complete")
+			}
+			if flags.verbose {
+				printVerbose(fmt.Sprintf("Plan from %s/%s", provider.Name(), provider.Model()))
+			}
+
+			// Log LLM response
+			if logger != nil {
+				logger.LogLLMResponse(responseTokens, len(plan.Commits), estimateCost(provider.Model(), promptTokens, responseTokens))
+			}
+		}
+	}
+
+	// A new branch must exist before validation checks the plan against branch policies
+	if flags.suggestBranch && len(plan.Commits) > 0 {
+		suggestBranch(flags, userConfig, gitRoot, repoConfig, plan, logger)
+	}
+
+	// Validate and fix plan (merges overlapping commits, truncates long messages)
+	printStep("📋", "Planning commits...")
+
+	planner.AddGeneratedFiles(plan, analysisReq.GeneratedFiles, singleMode)
+	if fixGitignore {
+		planner.AddGitignoreCommit(plan)
+	}
+
+	validator := planner.NewValidator(gitRoot, repoConfig, files)
+	if branch, err := collector.CurrentBranch(); err == nil {
+		validator.SetBranch(branch)
+	}
+	validator.SetPathspecs(pathspecs)
+	plan, validationResult := validator.ValidateAndFix(plan)
+
+	// Log validation
+	if logger != nil {
+		var errorStrings []string
+		for _, e := range validationResult.Errors {
+			errorStrings = append(errorStrings, e.Error())
+		}
+		logger.LogPlanValidated(validationResult.Valid, errorStrings)
+	}
+
+	fixedInteractively := false
+	if !validationResult.Valid {
+		printStepError("Validation failed")
+		for _, e := range validationResult.Errors {
+			fmt.Printf("   • %s\n", e.Error())
+		}
+
+		// Let the user repair the plan rather than discard the whole analysis
+		if isTerminal(os.Stdin) && isTerminal(os.Stdout) {
+			fixed, ok, err := interactive.RunPlanFix(plan, planValidator(validator), git.NewMessageEditor(gitRoot))
+			if err != nil {
+				printWarning(fmt.Sprintf("Fix-up unavailable: %v", err))
+			} else if ok {
+				plan = fixed
+				fixedInteractively = true
+				validationResult = &planner.ValidationResult{Valid: true}
+				if logger != nil {
+					logger.LogPlanValidated(true, nil)
+				}
+				printSuccess(fmt.Sprintf("Plan fixed (%d commits)", len(plan.Commits)))
+			} else {
+				printWarning("Fix-up cancelled")
+			}
+		}
+	}
+
+	if !validationResult.Valid {
+		result.Findings = append(result.Findings, validationFindings(plan, validationResult.Errors)...)
 		result.ExitCode = 1
 		result.Duration = time.Since(startTime)
 		return result
 	}
 
+	// Offer to merge commits that make the same change under different subjects
+	merged := validator.MergedDuplicates()
+	if pairs := planner.NearDuplicates(plan.Commits); len(pairs) > 0 {
+		printWarning(fmt.Sprintf("%d pairs of commits look like the same change:", len(pairs)))
+		for _, p := range pairs {
+			fmt.Printf("   • %q and %q\n", plan.Commits[p.First].Message, plan.Commits[p.Second].Message)
+		}
+		if isTerminal(os.Stdin) && isTerminal(os.Stdout) && confirm("   Merge them?") {
+			plan.Commits, merged = planner.MergeNearDuplicates(plan.Commits, pairs)
+		}
+	}
+	if len(merged) > 0 {
+		if logger != nil {
+			logger.LogDuplicatesMerged(merged)
+		}
+		printProgress(fmt.Sprintf("Merged %d near-duplicate commits", len(merged)))
+		if flags.verbose {
+			for _, m := range merged {
+				printVerbose("merged " + m)
+			}
+		}
+	}
+
+	// Make subjects consistent across the plan
+	if changes := planner.NormalizePlan(plan, repoConfig, convention.Active()); len(changes) > 0 {
+		if logger != nil {
+			logger.LogPlanNormalized(changes)
+		}
+		if flags.verbose {
+			for _, c := range changes {
+				printVerbose("normalized " + c)
+			}
+		}
+	}
+
+	// A compared plan may come from a provider other than the cache key's,
+	// and a locally planned one shouldn't stand in for the LLM's next time
+	if planCache != nil && !cached && flags.compare == "" && !result.Partial && !plannedFast {
+		_ = planCache.SaveState(cacheKey, plan, treeState)
+	}
+
 	// Filter sensitive files
 	filteredFiles := planner.FilterSensitiveFiles(plan)
 	if len(filteredFiles) > 0 {
 		printWarning(fmt.Sprintf("Excluded %d sensitive files: %v", len(filteredFiles), filteredFiles))
+		for _, f := range filteredFiles {
+			result.Findings = append(result.Findings, report.Finding{
+				RuleID:  report.RuleSensitiveFile,
+				Level:   report.LevelWarning,
+				Message: "Sensitive file excluded from commits; it may contain secrets",
+				File:    f,
+			})
+		}
 	}
 
 	if len(plan.Commits) == 0 {
@@ -446,16 +1242,78 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 
 	if flags.verbose {
 		for i, c := range plan.Commits {
-			var msg string
-			if c.Scope != nil && *c.Scope != "" {
-				msg = fmt.Sprintf("%s(%s): %s", c.Type, *c.Scope, c.Message)
+			msg := convention.Format(c)
+			printVerbose(fmt.Sprintf("  %d. %s", i+1, msg))
+		}
+	}
+
+	reviewedInteractively := false
+	if flags.review {
+		if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+			printWarning("--review needs a terminal; committing the plan as is")
+		} else {
+			split := planSplitter(userConfig, contextBuilder, flags.message)
+			reviewed, ok, err := interactive.RunPlanReview(plan, planValidator(validator), split, git.NewMessageEditor(gitRoot))
+			if err != nil {
+				printError("Review failed", err)
+				result.ExitCode = 1
+				result.Duration = time.Since(startTime)
+				return result
+			}
+			if !ok {
+				printFinal("❌", "Review cancelled")
+				fmt.Println("   No commits were created.")
+				result.ExitCode = 1
+				result.Duration = time.Since(startTime)
+				return result
+			}
+			plan = reviewed
+			reviewedInteractively = true
+			printSuccess(fmt.Sprintf("Plan reviewed (%d commits)", len(plan.Commits)))
+		}
+	}
+
+	// Guard against accidentally committing a wiped directory
+	if massDelete := detectMassDeletion(collector, status, plan, repoConfig); massDelete != nil {
+		printWarning("Mass deletion: " + massDelete.Summary())
+
+		allowed := flags.massDelete || flags.dryRun ||
+			(isTerminal(os.Stdin) && confirm("   Commit these deletions?"))
+		if logger != nil {
+			logger.LogMassDeletion(massDelete.Files, massDelete.Summary(), allowed)
+		}
+		if !allowed {
+			printFinal("❌", "Mass deletion not confirmed")
+			fmt.Println("   Re-run with --allow-mass-delete to commit it anyway.")
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
+		}
+
+		massDelete.AnnotateCommits(plan)
+	}
+
+	// A plan left waiting at a prompt may describe a tree that has since changed
+	if age := time.Since(collectedAt); !flags.dryRun && analyzedState != nil && age > flags.staleAfter {
+		if err := checkFresh(collector, gitRoot, repoConfig, pathspecs, flags.staged, plan, analyzedState, age, logger); err != nil {
+			var stale *planner.StalePlanError
+			if errors.As(err, &stale) {
+				printStaleError(stale)
 			} else {
-				msg = fmt.Sprintf("%s: %s", c.Type, c.Message)
+				printError("Failed to re-check changes", err)
 			}
-			printVerbose(fmt.Sprintf("  %d. %s", i+1, msg))
+			result.ExitCode = 1
+			result.Duration = time.Since(startTime)
+			return result
 		}
 	}
 
+	planner.AddTrailers(plan, repoConfig.Trailers, planner.PlanMetadata{
+		Version:  Version,
+		PlanID:   planID(logger),
+		Reviewed: cached || fixedInteractively || reviewedInteractively,
+	})
+
 	// Execute plan
 	if flags.dryRun {
 		printStep("🚀", "Preview (dry-run)...")
@@ -464,29 +1322,45 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 	}
 
 	executor := planner.NewExecutor(gitRoot, flags.dryRun)
+	executor.SetVerification(repoConfig.Verification)
+	executor.SetHookAutofix(repoConfig.HookAutofix)
+	executor.SetPathspecs(pathspecs)
+	executor.SetErrorHandler(errorHandler(flags.onError))
+
+	// Stop at the next commit boundary on Ctrl-C; a second Ctrl-C exits immediately
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
 
-	executed, err := executor.Execute(plan, func(current, total int, commit types.PlannedCommit) {
-		var msg string
-		if commit.Scope != nil && *commit.Scope != "" {
-			msg = fmt.Sprintf("%s(%s): %s", commit.Type, *commit.Scope, commit.Message)
-		} else {
-			msg = fmt.Sprintf("%s: %s", commit.Type, commit.Message)
-		}
-
-		switch current {
-		case 1:
-			fmt.Printf("   ┌─ [%d/%d] %s\n", current, total, msg)
-		case total:
-			fmt.Printf("   └─ [%d/%d] %s\n", current, total, msg)
-		default:
-			fmt.Printf("   ├─ [%d/%d] %s\n", current, total, msg)
-		}
+	executed, err := executor.ExecuteContext(ctx, plan, func(current, total int, commit types.PlannedCommit) {
+		msg := convention.Format(commit)
 
+		printTreeItem(current, total, msg)
 		for _, f := range commit.Files {
-			fmt.Printf("   │  └─ %s\n", f)
+			printTreeLeaf(f)
 		}
 	})
 
+	reportVerification(executed, repoConfig.Verification, logger)
+	reportHookRewrites(executed, repoConfig.HookAutofix, logger)
+	emptyCommits := reportSkipped(executor.Skipped(), logger)
+	failedCommits := len(executor.Failed())
+	if failedCommits > 0 {
+		reportExecution(executed, executor.Skipped(), executor.Failed(), logger)
+	}
+
+	var interrupted *planner.InterruptedError
+	if errors.As(err, &interrupted) {
+		handleInterrupted(executor, interrupted, logger)
+		result.ExitCode = 130
+		result.Duration = time.Since(startTime)
+		result.CommitsCreated = executed
+		return result
+	}
+
 	if err != nil {
 		printError("Execution failed", err)
 		if logger != nil {
@@ -498,6 +1372,11 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 		return result
 	}
 
+	// The tree changed, so the cached plan can never match again
+	if planCache != nil && !flags.dryRun {
+		_ = planCache.Clear()
+	}
+
 	// Log commits
 	if logger != nil {
 		for _, c := range executed {
@@ -506,10 +1385,49 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 	}
 
 	// Print final summary
-	if flags.dryRun {
-		printFinal("✅", fmt.Sprintf("Would create %d commits (dry-run)", len(executed)))
-	} else {
-		printFinal("✅", fmt.Sprintf("Created %d commits", len(executed)))
+	note := ""
+	if emptyCommits > 0 {
+		note = fmt.Sprintf(", skipped %d empty", emptyCommits)
+	}
+	if result.Partial {
+		note += fmt.Sprintf(" (partial: planned locally after --max-wait %s)", flags.maxWait)
+	}
+	switch {
+	case flags.dryRun:
+		printFinal("✅", fmt.Sprintf("Would create %d commits (dry-run)%s", len(executed), note))
+	case failedCommits > 0:
+		// Commits created before failing count as failed, not created
+		printFinal("⚠️", fmt.Sprintf("Created %d commits, %d failed%s", len(executed)-committedFailures(executor.Failed()), failedCommits, note))
+		result.ExitCode = 1
+	default:
+		printFinal("✅", fmt.Sprintf("Created %d commits%s", len(executed), note))
+	}
+	if len(skipped) > 0 {
+		printProgress(fmt.Sprintf("Left behind %d skipped files:", len(skipped)))
+		for _, f := range skipped {
+			printTreeLeaf(f)
+		}
+	}
+
+	// Hooks can rewrite files after they're staged; catch what didn't make it in
+	if !flags.dryRun {
+		if leftover, err := executor.Leftover(plan); err == nil && len(leftover) > 0 {
+			printFinal("⚠️", fmt.Sprintf("%d planned files are still changed after committing (rewritten by a hook?):", len(leftover)))
+			for _, f := range leftover {
+				printTreeLeaf(f)
+			}
+			if logger != nil {
+				logger.LogLeftover(leftover)
+			}
+			result.Leftover = leftover
+		}
+	}
+
+	// Check that every intermediate commit builds, so bisecting never lands on a broken one
+	if flags.verify && !flags.dryRun {
+		if failed := verifyCommits(gitRoot, repoConfig.VerifyCommand, executed, flags.verbose, logger); failed > 0 {
+			result.ExitCode = 1
+		}
 	}
 
 	if flags.verbose && logger != nil {
@@ -521,6 +1439,312 @@ func execute(flags flags, logger *logging.ExecutionLogger) executeResult {
 	return result
 }
 
+// responseCache opens the provider response cache in ~/.commit-tool/cache
+// and keys req for it, or returns nil when --no-cache or COMMIT_CACHE_TTL=0
+// turns it off.
+func responseCache(flags flags, userConfig *types.UserConfig, req *types.AnalysisRequest) (*llm.ResponseCache, string) {
+	ttl := llm.DefaultCacheTTL
+	if userConfig.CacheTTL != nil {
+		ttl = *userConfig.CacheTTL
+	}
+	if flags.noCache || ttl <= 0 {
+		return nil, ""
+	}
+	dir, err := config.ConfigPath()
+	if err != nil {
+		return nil, ""
+	}
+
+	var sampling []string
+	if userConfig.Temperature != nil {
+		sampling = append(sampling, fmt.Sprintf("temperature=%g", *userConfig.Temperature))
+	}
+	if userConfig.Seed != nil {
+		sampling = append(sampling, fmt.Sprintf("seed=%d", *userConfig.Seed))
+	}
+	if userConfig.TopP != nil {
+		sampling = append(sampling, fmt.Sprintf("top_p=%g", *userConfig.TopP))
+	}
+	if userConfig.MaxTokens > 0 {
+		sampling = append(sampling, fmt.Sprintf("max_tokens=%d", userConfig.MaxTokens))
+	}
+	key := llm.ResponseKey(req, userConfig.Provider, userConfig.Model, Version, strings.Join(sampling, ","))
+	return llm.NewResponseCache(filepath.Join(dir, "cache"), ttl), key
+}
+
+// logSampling shows (with --verbose) and logs the sampling settings when
+// COMMIT_LLM_TEMPERATURE, COMMIT_LLM_TOP_P, COMMIT_LLM_MAX_TOKENS or
+// --deterministic set any. The seed is only reported for providers that
+// take one.
+func logSampling(flags flags, userConfig *types.UserConfig, provider string, logger *logging.ExecutionLogger) {
+	if userConfig.Temperature == nil && userConfig.Seed == nil && userConfig.TopP == nil && userConfig.MaxTokens == 0 {
+		return
+	}
+	seed := userConfig.Seed
+	if !llm.SupportsSeed(provider) {
+		seed = nil
+	}
+
+	if flags.verbose {
+		var settings []string
+		if userConfig.Temperature != nil {
+			settings = append(settings, fmt.Sprintf("temperature %g", *userConfig.Temperature))
+		}
+		if seed != nil {
+			settings = append(settings, fmt.Sprintf("seed %d", *seed))
+		} else if userConfig.Seed != nil {
+			settings = append(settings, fmt.Sprintf("no seed (%s doesn't take one)", provider))
+		}
+		if userConfig.TopP != nil {
+			settings = append(settings, fmt.Sprintf("top_p %g", *userConfig.TopP))
+		}
+		if userConfig.MaxTokens > 0 {
+			settings = append(settings, fmt.Sprintf("max tokens %d", userConfig.MaxTokens))
+		}
+		printVerbose("Sampling: " + strings.Join(settings, ", "))
+	}
+	if logger != nil {
+		logger.LogSampling(userConfig.Temperature, seed, userConfig.TopP, userConfig.MaxTokens, flags.deterministic)
+	}
+}
+
+// writePlanSummary writes the markdown summary of commits to path, or to
+// stdout if path is "-".
+func writePlanSummary(path string, stdout io.Writer, commits []types.ExecutedCommit) error {
+	if path == "-" {
+		return report.WritePlanSummary(stdout, commits)
+	}
+	var b strings.Builder
+	if err := report.WritePlanSummary(&b, commits); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(b.String()), 0644)
+}
+
+// handleInterrupted cleans up after an interrupted execution and explains how to resume.
+func handleInterrupted(executor *planner.Executor, interrupted *planner.InterruptedError, logger *logging.ExecutionLogger) {
+	remaining := make([]string, len(interrupted.Remaining))
+	for i, c := range interrupted.Remaining {
+		remaining[i] = convention.Format(c)
+	}
+
+	unstageErr := executor.UnstagePartial()
+	if logger != nil {
+		logger.LogInterrupted(interrupted.Completed, remaining)
+	}
+
+	printFinal("⚠️", fmt.Sprintf("Interrupted after %d commits", interrupted.Completed))
+	if unstageErr != nil {
+		printError("Failed to unstage partial work", unstageErr)
+	}
+	fmt.Println("   Not created:")
+	for _, msg := range remaining {
+		fmt.Printf("   • %s\n", msg)
+	}
+	fmt.Println("\n   Their changes are still in the working tree. Run commit again to plan them.")
+}
+
+// countOutOfScope returns the number of changed files outside the pathspecs.
+func countOutOfScope(gitRoot string, pathspecs []string, stagedOnly bool) int {
+	all, err := git.NewCollector(gitRoot).Status()
+	if err != nil {
+		return 0
+	}
+
+	scoped := git.NewCollector(gitRoot)
+	scoped.SetPathspecs(pathspecs)
+	inScope, err := scoped.Status()
+	if err != nil {
+		return 0
+	}
+
+	if stagedOnly {
+		return len(all.Staged) - len(inScope.Staged)
+	}
+	return len(all.AllFiles()) - len(inScope.AllFiles())
+}
+
+// reportVerification logs each commit's verification and warns about
+// mismatches whose severity is "warn". Fatal mismatches surface as errors.
+func reportVerification(executed []types.ExecutedCommit, cfg types.VerificationConfig, logger *logging.ExecutionLogger) {
+	for _, c := range executed {
+		v := c.Verification
+		if v == nil {
+			continue
+		}
+		if logger != nil {
+			logger.LogCommitVerification(c.Hash, v)
+		}
+		if v.Verified() || cfg.Severity(v.Status) != types.SeverityWarn {
+			continue
+		}
+
+		switch v.Status {
+		case types.VerificationExtra:
+			printWarning(fmt.Sprintf("Commit %s includes unplanned files: %s", c.Hash, strings.Join(v.ExtraFiles, ", ")))
+		case types.VerificationMissing:
+			printWarning(fmt.Sprintf("Commit %s is missing planned files: %s", c.Hash, strings.Join(v.MissingFiles, ", ")))
+		}
+	}
+}
+
+// reportHookRewrites logs and reports the files pre-commit hooks rewrote
+// while committing. Amended rewrites are progress; the others are left in
+// the working tree and are listed with the leftover changes.
+func reportHookRewrites(executed []types.ExecutedCommit, mode string, logger *logging.ExecutionLogger) {
+	for _, c := range executed {
+		if len(c.HookRewritten) == 0 {
+			continue
+		}
+		if mode == "" {
+			mode = types.HookAutofixAmend
+		}
+		if logger != nil {
+			logger.LogHookRewrite(c.Hash, c.HookRewritten, mode)
+		}
+		if mode == types.HookAutofixAmend {
+			printProgress(fmt.Sprintf("Amended %d files rewritten by a pre-commit hook into %s", len(c.HookRewritten), c.Hash))
+		}
+	}
+}
+
+// reportSkipped logs and warns about planned commits skipped because their
+// files no longer had changes, and returns how many there were.
+func reportSkipped(skipped []planner.SkippedCommit, logger *logging.ExecutionLogger) int {
+	for _, s := range skipped {
+		msg := convention.Format(s.Planned)
+		if logger != nil {
+			logger.LogCommitSkipped(msg, s.Planned.Files, s.Reason)
+		}
+		printWarning(fmt.Sprintf("Skipped commit %d (%s): its files no longer have changes", s.CommitIndex+1, msg))
+	}
+	return len(skipped)
+}
+
+// errorHandler returns the executor's ErrorHandler for an --on-error policy;
+// nil aborts at the first failed commit. ask aborts without a terminal.
+func errorHandler(policy string) planner.ErrorHandler {
+	switch policy {
+	case planner.OnErrorSkip:
+		return func(err *planner.ExecutionError) bool {
+			printStepError(err.Error())
+			printProgress("Skipping it and continuing (--on-error skip)")
+			return true
+		}
+	case planner.OnErrorAsk:
+		return func(err *planner.ExecutionError) bool {
+			if !isTerminal(os.Stdin) {
+				return false
+			}
+			printStepError(err.Error())
+			return confirm("   Skip it and continue with the rest of the plan?")
+		}
+	}
+	return nil
+}
+
+// reportExecution lists how each planned commit ended after a failed one
+// was skipped: created, skipped because it was empty, or failed and why.
+// Failures are logged.
+func reportExecution(executed []types.ExecutedCommit, skipped []planner.SkippedCommit, failed []planner.FailedCommit, logger *logging.ExecutionLogger) {
+	printStep("📋", "Execution report")
+
+	failedHashes := make(map[string]bool)
+	for _, f := range failed {
+		if f.Hash != "" {
+			failedHashes[f.Hash] = true
+		}
+	}
+	for _, c := range executed {
+		if !failedHashes[c.Hash] {
+			printSuccess(fmt.Sprintf("%s %s", c.Hash, c.Message))
+		}
+	}
+	for _, s := range skipped {
+		printWarning(fmt.Sprintf("Skipped %s: %s", convention.Format(s.Planned), s.Reason))
+	}
+	for _, f := range failed {
+		msg := convention.Format(f.Planned)
+		if logger != nil {
+			logger.LogCommitFailed(msg, f.Planned.Files, f.Hash, f.Err)
+		}
+		if f.Hash != "" {
+			printStepError(fmt.Sprintf("Failed %s (committed as %s): %v", msg, f.Hash, f.Err))
+			continue
+		}
+		printStepError(fmt.Sprintf("Failed %s: %v", msg, f.Err))
+		for _, file := range f.Planned.Files {
+			printTreeLeaf(file)
+		}
+	}
+}
+
+// committedFailures returns how many failed commits were created anyway.
+func committedFailures(failed []planner.FailedCommit) int {
+	count := 0
+	for _, f := range failed {
+		if f.Hash != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// countUnverified returns the number of commits that don't exactly match their plan.
+func countUnverified(executed []types.ExecutedCommit) int {
+	count := 0
+	for _, c := range executed {
+		if c.Verification != nil && !c.Verification.Verified() {
+			count++
+		}
+	}
+	return count
+}
+
+// keyCheckTimeout bounds the --validate-keys request.
+const keyCheckTimeout = 15 * time.Second
+
+// validateKey checks the provider's API key format, then makes an inexpensive
+// authenticated request. A rejected key is returned as an InvalidAPIKeyError.
+func validateKey(userConfig *types.UserConfig) error {
+	printStep("🔑", "Checking API key...")
+
+	if err := config.CheckAPIKeyFormat(userConfig); err != nil {
+		return err
+	}
+
+	provider, err := getProviderFunc()(userConfig)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), keyCheckTimeout)
+	defer cancel()
+
+	if err := llm.CheckKey(ctx, provider); err != nil {
+		var providerErr *llm.ProviderError
+		if errors.As(err, &providerErr) && providerErr.Unauthorized() {
+			envVar := config.APIKeyEnvVar(userConfig.Provider)
+			return &config.InvalidAPIKeyError{Provider: userConfig.Provider, EnvVar: envVar, Reason: "was rejected by the provider"}
+		}
+		return err
+	}
+
+	printSuccess(fmt.Sprintf("API key accepted for %s", provider.Model()))
+	return nil
+}
+
+// lfsFiles returns the paths of the Git LFS-tracked files in files.
+func lfsFiles(files []types.FileChange) []string {
+	var paths []string
+	for _, f := range files {
+		if f.LFSChange != "" {
+			paths = append(paths, f.Path)
+		}
+	}
+	return paths
+}
+
 func handleInteractive(flags flags) int {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -534,20 +1758,56 @@ func handleInteractive(flags flags) int {
 		return 1
 	}
 
+	lock, err := acquireLock(gitRoot)
+	if err != nil {
+		return 1
+	}
+	defer lock.Release() //nolint:errcheck // best-effort cleanup
+
+	// git refuses to rebase over uncommitted changes; only stash them when asked
+	if !flags.forceDirty {
+		if dirty := interactive.NewRebaser(gitRoot).DirtyFiles(); len(dirty) > 0 {
+			printStepError("Working tree has uncommitted changes")
+			printFinal("❌", "Cannot rebase with uncommitted changes")
+			fmt.Println()
+			for _, f := range dirty {
+				fmt.Printf("   • %s\n", f)
+			}
+			fmt.Println("\n   Commit or stash them first, or use -i --force-dirty to stash them")
+			fmt.Println("   automatically and restore them after the rebase.")
+			return 1
+		}
+	}
+
+	remoteRefs := snapshotRemoteRefs(gitRoot)
+	defer warnRemoteRefsChanged(remoteRefs)
+
 	// Run the interactive wizard
 	completed, err := interactive.Run(interactive.Config{
-		GitRoot: gitRoot,
-		Force:   flags.force,
+		GitRoot:     gitRoot,
+		ForcePushed: flags.forcePushed,
+		ForceDirty:  flags.forceDirty,
+		Suggester:   conflictSuggester(flags),
+		RemoteRefs:  remoteRefs,
 	})
 
 	if err != nil {
+		if conflictErr, ok := err.(*interactive.ConflictError); ok {
+			printStepError("Rebase stopped on conflicts")
+			for _, f := range conflictErr.Files {
+				fmt.Printf("   • %s\n", f)
+			}
+			fmt.Println("\n   Resolve the remaining conflicts, stage them, then run:")
+			fmt.Println("   git rebase --continue   (or git rebase --abort)")
+			return 1
+		}
 		// Check if it's a pushed commit error
 		if _, ok := err.(*interactive.PushedCommitError); ok {
 			printStepError("Rebase includes pushed commits")
 			printFinal("❌", "Cannot rebase pushed commits")
 			fmt.Println("\n   Some commits in this rebase have been pushed to origin.")
 			fmt.Println("   Rebasing will require force-push to sync with remote.")
-			fmt.Println("\n   Use -i --force to proceed.")
+			fmt.Println("\n   Use -i --force-pushed to proceed.")
 			return 1
 		}
 		printError("Interactive rebase failed", err)
@@ -563,6 +1823,22 @@ func handleInteractive(flags flags) int {
 	return 0
 }
 
+// conflictSuggester creates the LLM provider used for conflict suggestions.
+// Returns nil when no provider is configured; the wizard works without one.
+func conflictSuggester(flags flags) interactive.ConflictSuggester {
+	userConfig, err := config.LoadUserConfig()
+	if err != nil {
+		return nil
+	}
+	flags.applyProviderOverride(userConfig)
+
+	provider, err := getProviderFunc()(userConfig)
+	if err != nil {
+		return nil
+	}
+	return provider
+}
+
 func handleDiff(flags flags) int {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -584,9 +1860,7 @@ func handleDiff(flags flags) int {
 		return 1
 	}
 
-	if flags.provider != "" {
-		userConfig.Provider = flags.provider
-	}
+	flags.applyProviderOverride(userConfig)
 	printSuccess(fmt.Sprintf("Provider: %s", userConfig.Provider))
 
 	// Create LLM provider
@@ -619,18 +1893,195 @@ func handleDiff(flags flags) int {
 	return 0
 }
 
-func handleReverse(gitRoot string, count int, force, verbose bool) int {
+func handleReleaseNotes(flags flags) int {
+	cwd, err := os.Getwd()
+	if err != nil {
+		printError("Failed to get current directory", err)
+		return 1
+	}
+
+	gitRoot, err := git.FindGitRoot(cwd)
+	if err != nil {
+		printError("Not a git repository", err)
+		return 1
+	}
+
+	// Resolve the GitHub target up front so a bad remote fails before the LLM call
+	var owner, repo, token string
+	if flags.publish {
+		token = os.Getenv("GITHUB_TOKEN")
+		if token == "" {
+			printStepError("GITHUB_TOKEN is not set")
+			printFinal("❌", "Cannot publish release draft")
+			return 1
+		}
+		owner, repo, err = release.OriginRepo(gitRoot)
+		if err != nil {
+			printError("Cannot publish release draft", err)
+			return 1
+		}
+	}
+
+	// Load config
+	printStep("🔧", "Loading config...")
+	userConfig, err := config.LoadUserConfig()
+	if err != nil {
+		handleConfigError(err)
+		return 1
+	}
+
+	flags.applyProviderOverride(userConfig)
+	printSuccess(fmt.Sprintf("Provider: %s", userConfig.Provider))
+
+	provider, err := getProviderFunc()(userConfig)
+	if err != nil {
+		printError("Failed to create LLM provider", err)
+		return 1
+	}
+
+	printStep("📂", fmt.Sprintf("Collecting commits for %s...", flags.release))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second)
+	defer cancel()
+
+	printProgress(fmt.Sprintf("Sending to %s...", provider.Model()))
+
+	notes, err := release.Draft(ctx, gitRoot, flags.release, provider)
+	if err != nil {
+		if _, ok := err.(*release.NoCommitsError); ok {
+			printFinal("❌", err.Error())
+			return 1
+		}
+		printError("Failed to draft release notes", err)
+		return 1
+	}
+
+	if notes.PrevTag != "" {
+		printSuccess(fmt.Sprintf("%d commits since %s", notes.CommitCount(), notes.PrevTag))
+	} else {
+		printSuccess(fmt.Sprintf("%d commits (first release)", notes.CommitCount()))
+	}
+	if len(notes.Breaking) > 0 {
+		printWarning(fmt.Sprintf("%d breaking changes", len(notes.Breaking)))
+	}
+
+	printFinal("📝", fmt.Sprintf("Release notes draft for %s:", notes.Tag))
+	fmt.Println()
+	fmt.Println(notes.Draft)
+	fmt.Println()
+
+	if flags.publish {
+		printStep("🚀", fmt.Sprintf("Publishing draft to %s/%s...", owner, repo))
+		url, err := release.NewPublisher(token, "").PublishDraft(ctx, owner, repo, notes)
+		if err != nil {
+			printError("Failed to publish release draft", err)
+			return 1
+		}
+		printFinal("✅", fmt.Sprintf("Draft release created: %s", url))
+	}
+
+	return 0
+}
+
+// historyLimit caps the runs printed by --history.
+const historyLimit = 20
+
+func handleHistory(flags flags) int {
+	if flags.grep != "" {
+		printStep("📜", fmt.Sprintf("Searching history for %q...", flags.grep))
+	} else {
+		printStep("📜", "Recent runs...")
+	}
+
+	matches, err := logging.SearchHistory(flags.grep)
+	if err != nil {
+		printError("Failed to read execution logs", err)
+		return 1
+	}
+
+	if len(matches) == 0 {
+		printFinal("❌", "No matching runs")
+		return 1
+	}
+
+	shown := matches
+	if len(shown) > historyLimit {
+		shown = shown[:historyLimit]
+	}
+
+	for _, m := range shown {
+		when := m.Entry.Timestamp
+		if ts, err := time.Parse(time.RFC3339, m.Entry.Timestamp); err == nil {
+			when = ts.Local().Format("Mon 2006-01-02 15:04")
+		}
+
+		fmt.Printf("\n   %s  %s  %s\n", when, m.Entry.ExecutionID, m.Entry.GitRoot)
+		if len(m.MatchedIn) > 0 && flags.verbose {
+			printVerbose(fmt.Sprintf("matched in: %s", strings.Join(m.MatchedIn, ", ")))
+		}
+		if len(m.Commits) == 0 {
+			printProgress(fmt.Sprintf("no commits created (exit %d)", m.Entry.ExitCode))
+			continue
+		}
+		for i, c := range m.Commits {
+			printTreeItem(i+1, len(m.Commits), fmt.Sprintf("%s %s", c.Hash, c.Message))
+			if flags.verbose {
+				for _, f := range c.Files {
+					printTreeLeaf(f)
+				}
+			}
+		}
+	}
+
+	if len(matches) > len(shown) {
+		printFinal("✅", fmt.Sprintf("%d matching runs (showing the latest %d)", len(matches), len(shown)))
+	} else {
+		printFinal("✅", fmt.Sprintf("%d matching runs", len(matches)))
+	}
+	return 0
+}
+
+// snapshotRemoteRefs records the remote refs that a history-rewriting flow
+// bases its pushed-commit checks on, or returns nil, for live checks, if
+// they can't be read.
+func snapshotRemoteRefs(gitRoot string) *git.RemoteRefs {
+	refs, err := git.SnapshotRemoteRefs(gitRoot)
+	if err != nil {
+		return nil
+	}
+	return refs
+}
+
+// warnRemoteRefsChanged notes the remote refs a background fetch (e.g. git
+// maintenance) moved since refs was taken, since pushed-commit checks still
+// went by the snapshot.
+func warnRemoteRefsChanged(refs *git.RemoteRefs) {
+	if refs == nil {
+		return
+	}
+	changed, err := refs.Changed()
+	if err != nil || len(changed) == 0 {
+		return
+	}
+	printWarning(fmt.Sprintf("Remote refs changed during the run (%s); pushed-commit checks used the refs from its start", strings.Join(changed, ", ")))
+}
+
+func handleReverse(gitRoot string, count int, forcePushed, dryRun, verbose bool) int {
 	if count == 1 {
 		printStep("🔄", "Reversing HEAD commit...")
 	} else {
 		printStep("🔄", fmt.Sprintf("Reversing last %d commits...", count))
 	}
 
+	remoteRefs := snapshotRemoteRefs(gitRoot)
+	defer warnRemoteRefsChanged(remoteRefs)
+
 	reverser := git.NewReverser(gitRoot)
+	reverser.SetRemoteRefs(remoteRefs)
 
 	// Check if any commit in the range was pushed
 	pushed, _ := reverser.WasPushed(count)
-	if pushed && !force {
+	if pushed && !forcePushed {
 		printStepError("Commit has been pushed")
 		if count == 1 {
 			printFinal("❌", "Cannot reverse pushed commit")
@@ -640,15 +2091,36 @@ func handleReverse(gitRoot string, count int, force, verbose bool) int {
 			fmt.Printf("\n   One or more of the last %d commits have been pushed to origin.\n", count)
 		}
 		fmt.Println("   Reversing will require force-push to sync with remote.")
-		fmt.Println("\n   Use --reverse --force to proceed.")
+		fmt.Println("\n   Use --reverse --force-pushed to proceed.")
 		return 1
 	}
 
-	if err := reverser.Reverse(count, force); err != nil {
+	// Dry runs run the same checks but leave HEAD where it is
+	reverse := reverser.Reverse
+	if dryRun {
+		reverse = reverser.Check
+	}
+	if err := reverse(count, forcePushed); err != nil {
+		if mergeErr, ok := err.(*git.MergeCommitError); ok {
+			printStepError("Merge commit in range")
+			printFinal("❌", "Cannot reverse past a merge")
+			fmt.Printf("\n   %v.\n", mergeErr)
+			fmt.Println("   Reverse only the commits after it, or undo the merge itself with git reset.")
+			return 1
+		}
 		printError("Failed to reverse", err)
 		return 1
 	}
 
+	if dryRun {
+		if count == 1 {
+			printFinal("✅", "Would reverse HEAD commit (dry-run)")
+		} else {
+			printFinal("✅", fmt.Sprintf("Would reverse last %d commits (dry-run)", count))
+		}
+		return 0
+	}
+
 	if count == 1 {
 		printFinal("✅", "Reversed HEAD commit")
 	} else {
@@ -663,6 +2135,180 @@ func handleReverse(gitRoot string, count int, force, verbose bool) int {
 	return 0
 }
 
+// oopsLargeDiffLines is the amend size above which --oops suggests
+// rewording HEAD, since its message likely no longer covers the change.
+const oopsLargeDiffLines = 100
+
+// handleOops stages the current changes and amends them into HEAD without
+// changing its message.
+func handleOops(gitRoot, cwd string, flags flags) int {
+	printStep("🩹", "Amending HEAD...")
+
+	collector := git.NewCollector(gitRoot)
+	if _, err := collector.HeadCommit(); err != nil {
+		printStepError("No commits yet")
+		printFinal("❌", "Nothing to amend")
+		fmt.Println("   Create a commit first, then use --oops for follow-ups.")
+		return 1
+	}
+
+	remoteRefs := snapshotRemoteRefs(gitRoot)
+	defer warnRemoteRefsChanged(remoteRefs)
+	collector.SetRemoteRefs(remoteRefs)
+
+	pushed, _ := collector.IsCommitPushed()
+	if pushed && !flags.forcePushed {
+		printStepError("Commit has been pushed")
+		printFinal("❌", "Cannot amend pushed commit")
+		fmt.Println("\n   HEAD commit has been pushed to origin.")
+		fmt.Println("   Amending will require force-push to sync with remote.")
+		fmt.Println("\n   Use --oops --force-pushed to proceed.")
+		return 1
+	}
+
+	var pathspecs []string
+	if len(flags.paths) > 0 {
+		var err error
+		pathspecs, err = git.ResolvePathspecs(gitRoot, cwd, flags.paths)
+		if err != nil {
+			printError("Invalid path", err)
+			return 1
+		}
+		collector.SetPathspecs(pathspecs)
+	}
+
+	status, err := collector.Status()
+	if err != nil {
+		printError("Failed to get git status", err)
+		return 1
+	}
+
+	files := status.AllFiles()
+	if flags.staged {
+		files = status.Staged
+	}
+	files, sensitive := planner.SplitSensitiveFiles(files)
+	if len(sensitive) > 0 {
+		printWarning(fmt.Sprintf("Excluded %d sensitive files: %v", len(sensitive), sensitive))
+	}
+	if len(files) == 0 {
+		printStepError("No changes found")
+		printFinal("❌", "Nothing to amend")
+		return 1
+	}
+
+	message, _ := git.NewCommitter(gitRoot).GetLastCommitMessage()
+	subject, _, _ := strings.Cut(message, "\n")
+
+	if !flags.dryRun {
+		stager := git.NewStager(gitRoot)
+		stager.SetPathspecs(pathspecs)
+		if !flags.staged {
+			if err := stager.StageFiles(files); err != nil {
+				printError("Failed to stage files", err)
+				return 1
+			}
+		}
+		// Never amend secrets, even if they were staged by hand
+		if len(sensitive) > 0 {
+			if err := stager.UnstageFiles(sensitive); err != nil {
+				printError("Failed to unstage sensitive files", err)
+				return 1
+			}
+		}
+	}
+
+	// Dry runs measure the working tree since nothing was staged
+	stats, _ := collector.GetFileStats(flags.staged || !flags.dryRun)
+	lines := 0
+	for _, st := range stats {
+		lines += st.Added + st.Removed
+	}
+
+	for _, f := range files {
+		fmt.Printf("   • %s\n", f)
+	}
+
+	if flags.dryRun {
+		printFinal("✅", fmt.Sprintf("Would amend %d files into %q (dry-run)", len(files), subject))
+	} else {
+		committer := git.NewCommitter(gitRoot)
+		committer.SetPathspecs(pathspecs)
+		hash, err := committer.Amend()
+		if err != nil {
+			printError("Failed to amend", err)
+			return 1
+		}
+		printFinal("✅", fmt.Sprintf("Amended %d files into %s %q", len(files), hash, subject))
+	}
+
+	if lines > oopsLargeDiffLines {
+		printWarning(fmt.Sprintf("This amend changes %d lines; the message may no longer describe the commit.", lines))
+		fmt.Println("   Consider updating it with: git commit --amend")
+	}
+	if pushed {
+		printWarning("You will need to force-push the amended commit.")
+	}
+
+	return 0
+}
+
+// handleInstallAlias installs a global git alias that runs the tool.
+func handleInstallAlias(name string) int {
+	printStep("🔧", "Installing git alias...")
+
+	binary, note := aliasBinary()
+	if note != "" {
+		printWarning(note)
+	}
+
+	if err := git.InstallAlias(name, binary); err != nil {
+		printError("Failed to install alias", err)
+		return 1
+	}
+
+	printSuccess(fmt.Sprintf("alias.%s runs %s", name, binary))
+	printFinal("✅", fmt.Sprintf("Use git %s in any repository", name))
+	return 0
+}
+
+// handleUninstallAlias removes the git alias installed by --install-alias.
+func handleUninstallAlias(name string) int {
+	removed, err := git.UninstallAlias(name)
+	if err != nil {
+		printError("Failed to remove alias", err)
+		return 1
+	}
+	if !removed {
+		printFinal("✅", fmt.Sprintf("No git alias %q to remove", name))
+		return 0
+	}
+	printFinal("✅", fmt.Sprintf("Removed git alias %q", name))
+	return 0
+}
+
+// aliasBinary returns how the git alias should invoke the tool: by name when
+// PATH resolves to this executable, otherwise by absolute path. The note
+// explains any mismatch.
+func aliasBinary() (binary, note string) {
+	self, err := os.Executable()
+	if err != nil {
+		return "commit", ""
+	}
+
+	onPath, err := exec.LookPath("commit")
+	if err != nil {
+		return self, fmt.Sprintf("commit is not on PATH; the alias will run %s", self)
+	}
+
+	selfInfo, selfErr := os.Stat(self)
+	pathInfo, pathErr := os.Stat(onPath)
+	if selfErr == nil && pathErr == nil && os.SameFile(selfInfo, pathInfo) {
+		return "commit", ""
+	}
+	return "commit", fmt.Sprintf("commit on PATH is %s, not this binary (%s)", onPath, self)
+}
+
 func handleSetConfig(setting string) int {
 	parts := strings.SplitN(setting, "=", 2)
 	if len(parts) != 2 {
@@ -681,9 +2327,51 @@ func handleSetConfig(setting string) int {
 			return 1
 		}
 		envKey = "COMMIT_DEFAULT_MODE"
+	case "theme":
+		if _, err := theme.Load(value); err != nil {
+			fmt.Printf("Invalid value for theme: %v\n", err)
+			return 1
+		}
+		envKey = "COMMIT_THEME"
+	case "logLevel":
+		if _, err := parseLogLevel(value); err != nil {
+			fmt.Printf("Invalid value for logLevel: %v\n", err)
+			return 1
+		}
+		envKey = "COMMIT_LOG_LEVEL"
+	case "logConsole":
+		if _, err := strconv.ParseBool(value); err != nil {
+			fmt.Printf("Invalid value for logConsole. Use: true or false\n")
+			return 1
+		}
+		envKey = "COMMIT_LOG_CONSOLE"
+	case "proxy":
+		if err := httpclient.SetProxy(value); err != nil {
+			fmt.Printf("Invalid value for proxy: %v\n", err)
+			return 1
+		}
+		envKey = "COMMIT_PROXY"
+	case "connectTimeout":
+		if sec, err := strconv.Atoi(value); err != nil || sec <= 0 {
+			fmt.Printf("Invalid value for connectTimeout. Use a number of seconds\n")
+			return 1
+		}
+		envKey = "COMMIT_CONNECT_TIMEOUT"
+	case "maxAttempts":
+		if n, err := strconv.Atoi(value); err != nil || n <= 0 {
+			fmt.Printf("Invalid value for maxAttempts. Use a number of attempts, 1 for no retries\n")
+			return 1
+		}
+		envKey = "COMMIT_MAX_ATTEMPTS"
+	case "updateCheck":
+		if !slices.Contains(updater.CheckModes, value) {
+			fmt.Printf("Invalid value for updateCheck. Use: %s\n", strings.Join(updater.CheckModes, ", "))
+			return 1
+		}
+		envKey = "COMMIT_UPDATE_CHECK"
 	default:
 		fmt.Printf("Unknown config key: %s\n", key)
-		fmt.Println("Available keys: defaultMode")
+		fmt.Println("Available keys: defaultMode, theme, logLevel, logConsole, proxy, connectTimeout, maxAttempts, updateCheck")
 		return 1
 	}
 
@@ -696,6 +2384,359 @@ func handleSetConfig(setting string) int {
 	return 0
 }
 
+// handleMigrateConfig converts the .env files into config.yaml.
+func handleMigrateConfig() int {
+	printStep("📦", "Migrating configuration...")
+
+	result, err := config.MigrateConfig()
+	if err != nil {
+		var exists *config.ConfigFileExistsError
+		var notFound *config.ConfigNotFoundError
+		switch {
+		case errors.As(err, &exists):
+			printFinal("✅", fmt.Sprintf("Already using %s", config.ConfigFile))
+			return 0
+		case errors.As(err, &notFound):
+			printFinal("❌", "No .env config to migrate")
+			return 1
+		}
+		printError("Migration failed", err)
+		return 1
+	}
+
+	printSuccess(fmt.Sprintf("Wrote %s (%d settings)", result.Path, result.Settings))
+	if len(result.Profiles) > 0 {
+		printProgress(fmt.Sprintf("Profiles: %s", strings.Join(result.Profiles, ", ")))
+	}
+	for _, renamed := range result.Renamed {
+		printProgress(fmt.Sprintf("Kept the old file as %s", renamed))
+	}
+	printFinal("✅", "Configuration migrated")
+	return 0
+}
+
+// planID identifies the plan in commit trailers. It matches the execution
+// log's ID so a commit can be traced back to the run that produced it.
+func planID(logger *logging.ExecutionLogger) string {
+	if logger != nil {
+		return logger.ExecutionID()
+	}
+	return logging.GenerateExecutionID()
+}
+
+// analyzedTreeState hashes the files in req for the plan cache, so a later
+// run can tell which of them changed. It returns nil if they can't be hashed.
+func analyzedTreeState(collector *git.Collector, req *types.AnalysisRequest, stagedOnly bool, inputs string) *planner.TreeState {
+	paths := make([]string, len(req.Files))
+	for i, f := range req.Files {
+		paths[i] = f.Path
+	}
+	hashes, err := collector.FileHashes(paths, stagedOnly)
+	if err != nil {
+		return nil
+	}
+	head, _ := collector.HeadCommit()
+	return &planner.TreeState{Inputs: inputs, Head: head, Files: hashes}
+}
+
+// checkFresh re-collects the changes and re-validates plan, made from before
+// age ago, against them. It returns a *planner.StalePlanError if the plan no
+// longer matches the tree.
+func checkFresh(collector *git.Collector, gitRoot string, repoConfig *types.RepoConfig, pathspecs []string, stagedOnly bool,
+	plan *types.CommitPlan, before *planner.TreeState, age time.Duration, logger *logging.ExecutionLogger) error {
+	printProgress(fmt.Sprintf("Plan is %s old; re-checking the changes", age.Round(time.Second)))
+
+	status, err := collector.Status()
+	if err != nil {
+		return err
+	}
+	files := status.AllFiles()
+	if stagedOnly {
+		files = status.Staged
+	}
+	paths := make([]string, 0, len(before.Files))
+	for p := range before.Files {
+		paths = append(paths, p)
+	}
+	hashes, err := collector.FileHashes(paths, stagedOnly)
+	if err != nil {
+		return err
+	}
+	head, _ := collector.HeadCommit()
+	after := &planner.TreeState{Head: head, Files: hashes}
+
+	validator := planner.NewValidator(gitRoot, repoConfig, files)
+	if branch, err := collector.CurrentBranch(); err == nil {
+		validator.SetBranch(branch)
+	}
+	validator.SetPathspecs(pathspecs)
+
+	err = planner.CheckFresh(plan, before, after, validator, age)
+	if logger != nil {
+		var stale *planner.StalePlanError
+		if errors.As(err, &stale) {
+			logger.LogStaleCheck(age, stale.HeadMoved, stale.Changed, len(stale.Errors))
+		} else {
+			logger.LogStaleCheck(age, false, nil, 0)
+		}
+	}
+	if err == nil {
+		printSuccess("Changes still match the plan")
+	}
+	return err
+}
+
+// printStaleError explains why a plan that waited too long wasn't committed.
+func printStaleError(stale *planner.StalePlanError) {
+	printStepError("Changes moved on since analysis")
+	if stale.HeadMoved {
+		fmt.Println("   • HEAD moved (something was committed or checked out meanwhile)")
+	}
+	for _, f := range stale.Changed {
+		fmt.Printf("   • changed: %s\n", f)
+	}
+	for _, e := range stale.Errors {
+		fmt.Printf("   • %s\n", e.Error())
+	}
+	printFinal("❌", "Plan is stale; nothing was committed")
+	fmt.Println("   Run again to plan the current changes.")
+}
+
+// deltaRequest narrows req to the changed files and their diffs.
+func deltaRequest(req *types.AnalysisRequest, changed []string) *types.AnalysisRequest {
+	narrowed := *req
+	narrowed.Files = nil
+	for _, f := range req.Files {
+		if slices.Contains(changed, f.Path) {
+			narrowed.Files = append(narrowed.Files, f)
+		}
+	}
+	narrowed.Diff = git.FilterDiff(req.Diff, changed)
+	narrowed.TypeGuesses = planner.GuessTypes(&narrowed)
+	return &narrowed
+}
+
+// tokenModel names the model tokens are counted for: the configured model,
+// or the provider (standing for its default model) if none is set.
+func tokenModel(userConfig *types.UserConfig) string {
+	if userConfig.Model != "" {
+		return userConfig.Model
+	}
+	return userConfig.Provider
+}
+
+// reportChunks says a request was split into chunks by llm.SplitRequest,
+// with their sizes under --verbose, logs it and returns the approximate
+// prompt tokens of all the chunks.
+func reportChunks(provider llm.Provider, chunks []*types.AnalysisRequest, verbose bool, logger *logging.ExecutionLogger) int {
+	total := 0
+	var sizes []int
+	for _, chunk := range chunks {
+		n := llm.PromptTokens(provider.Model(), chunk)
+		total += n
+		sizes = append(sizes, n)
+	}
+
+	window := tokens.ContextWindow(provider.Model())
+	printProgress(fmt.Sprintf("Changes too large for %s's %d-token context window; analyzing them in %d chunks", provider.Model(), window, len(chunks)))
+	if verbose {
+		for i, chunk := range chunks {
+			printVerbose(fmt.Sprintf("  Chunk %d: %d files, ~%d tokens", i+1, len(chunk.Files), sizes[i]))
+		}
+	}
+	if logger != nil {
+		logger.LogAnalysisChunked(provider.Model(), window, sizes)
+	}
+	return total
+}
+
+// reportPromptFit estimates the tokens the analysis prompt for req takes up
+// with provider's model, warning when its diff has to be cut to fit the
+// model's context window, and returns the estimate.
+func reportPromptFit(provider llm.Provider, req *types.AnalysisRequest, maxTokens int, verbose bool, logger *logging.ExecutionLogger) int {
+	_, fit := llm.FitPrompt(provider.Model(), maxTokens, req)
+	if verbose {
+		printVerbose(fmt.Sprintf("Prompt: ~%d tokens of %s's %d-token context window", fit.Tokens, provider.Model(), fit.Window))
+	}
+	if fit.Dropped > 0 {
+		printWarning(fmt.Sprintf("Diff too large for %s's context window; ~%d tokens were left out of the prompt", provider.Model(), fit.Dropped))
+		if logger != nil {
+			logger.LogPromptTrimmed(provider.Model(), fit.Window, fit.Tokens, fit.Dropped)
+		}
+	}
+	return fit.Tokens
+}
+
+// estimateCost returns the approximate cost in USD of a request to model, or
+// -1 if its price is unknown.
+func estimateCost(model string, promptTokens, responseTokens int) float64 {
+	cost, ok := tokens.EstimateCost(model, promptTokens, responseTokens)
+	if !ok {
+		return -1
+	}
+	return cost
+}
+
+// useConvention formats commits in the convention configured in repoConfig.
+// The bracketed-ticket convention reads its ticket from the current branch
+// and falls back to the message alone, with a warning, when there is none.
+func useConvention(repoConfig *types.RepoConfig, gitRoot string) error {
+	branch, _ := git.NewCollector(gitRoot).CurrentBranch()
+	conv, err := convention.New(repoConfig, branch)
+	if err != nil {
+		return err
+	}
+	if t, ok := conv.(convention.Ticket); ok && t.Ticket == "" {
+		printWarning(fmt.Sprintf("No ticket in branch %q; subjects won't have a ticket prefix", branch))
+	}
+	convention.Use(conv)
+	return nil
+}
+
+// acquireLock takes the repository's run lock, printing why when it can't.
+func acquireLock(gitRoot string) (*git.Lock, error) {
+	lock, err := git.AcquireLock(gitRoot)
+	if err != nil {
+		if lockedErr, ok := err.(*git.LockedError); ok {
+			printFinal("❌", "Another run is in progress")
+			fmt.Printf("   %v\n", lockedErr)
+			fmt.Println("\n   Wait for it to finish, or delete the lock file if no run is active.")
+		} else {
+			printError("Failed to lock repository", err)
+		}
+		return nil, err
+	}
+	return lock, nil
+}
+
+// planValidator adapts the plan validator for the interactive fix-up screen.
+func planValidator(v *planner.Validator) interactive.PlanValidator {
+	return func(plan *types.CommitPlan) []interactive.PlanIssue {
+		var issues []interactive.PlanIssue
+		for _, e := range v.Validate(plan).Errors {
+			issues = append(issues, interactive.PlanIssue{Commit: e.CommitIndex(), Message: e.Message})
+		}
+		return issues
+	}
+}
+
+// validationFindings converts validation errors into report findings, locating
+// file errors at the file the plan referenced.
+func validationFindings(plan *types.CommitPlan, errs []planner.ValidationError) []report.Finding {
+	var findings []report.Finding
+	for _, e := range errs {
+		finding := report.Finding{RuleID: report.RuleInvalidPlan, Level: report.LevelError, Message: e.Message}
+
+		var commit, file int
+		if _, err := fmt.Sscanf(e.Field, "commits[%d].files[%d]", &commit, &file); err == nil &&
+			plan != nil && commit < len(plan.Commits) && file < len(plan.Commits[commit].Files) {
+			finding.File = plan.Commits[commit].Files[file]
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// planSplitter asks the LLM for a message for files split off a commit in the
+// review screen. The provider is created on first use, since a cached plan
+// doesn't need one.
+func planSplitter(userConfig *types.UserConfig, contextBuilder *analyzer.ContextBuilder, guidingMessage string) interactive.CommitSplitter {
+	var provider llm.Provider
+	return func(files []string) (types.PlannedCommit, error) {
+		if provider == nil {
+			p, err := getProviderFunc()(userConfig)
+			if err != nil {
+				return types.PlannedCommit{}, err
+			}
+			provider = p
+		}
+
+		req, err := contextBuilder.BuildForFiles(files)
+		if err != nil {
+			return types.PlannedCommit{}, err
+		}
+		req.SingleCommit = true
+		req.GuidingMessage = guidingMessage
+
+		ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+		defer cancel()
+
+		plan, err := llm.AnalyzeWithRepair(ctx, provider, req, nil)
+		if err != nil {
+			return types.PlannedCommit{}, err
+		}
+		if len(plan.Commits) == 0 {
+			return types.PlannedCommit{}, fmt.Errorf("no commit returned for %s", strings.Join(files, ", "))
+		}
+		return plan.Commits[0], nil
+	}
+}
+
+// detectMassDeletion checks the plan's deletions against the repo's mass-deletion thresholds.
+func detectMassDeletion(collector *git.Collector, status *types.GitStatus, plan *types.CommitPlan, repoConfig *types.RepoConfig) *planner.MassDeletion {
+	if len(status.Deleted) == 0 {
+		return nil
+	}
+	headFiles, err := collector.HeadFiles()
+	if err != nil {
+		return nil
+	}
+	return planner.DetectMassDeletion(plan, status.Deleted, headFiles, repoConfig.MassDelete)
+}
+
+// followUp runs again over the files result left changed and adds the
+// commits it creates to result.
+func followUp(f flags, result executeResult, logger *logging.ExecutionLogger) executeResult {
+	cwd, err := os.Getwd()
+	if err != nil {
+		printError("Failed to get current directory", err)
+		return result
+	}
+	gitRoot, err := git.FindGitRoot(cwd)
+	if err != nil {
+		printError("Not a git repository", err)
+		return result
+	}
+
+	f.paths = nil
+	for _, file := range result.Leftover {
+		f.paths = append(f.paths, filepath.Join(gitRoot, file))
+	}
+	f.staged = false
+
+	next := execute(f, logger)
+	next.Duration += result.Duration
+	next.CommitsCreated = append(result.CommitsCreated, next.CommitsCreated...)
+	next.Findings = append(result.Findings, next.Findings...)
+	next.Partial = next.Partial || result.Partial
+	return next
+}
+
+// readOnly runs a command that only reports on changes with the git
+// read-only guard on, so it can't stage, unstage or commit anything.
+func readOnly(run func() int) int {
+	git.SetReadOnly(true)
+	defer git.SetReadOnly(false)
+	return run()
+}
+
+// isTerminal reports whether f is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(f.Fd())
+}
+
+// configFileHint returns the file holding the active profile's settings,
+// for messages.
+func configFileHint() string {
+	if config.HasConfigFile() {
+		return fmt.Sprintf("~/%s/%s", config.ConfigDir, config.ConfigFile)
+	}
+	if profile := config.ActiveProfile(); profile != "" {
+		return fmt.Sprintf("~/%s/%s/%s/%s", config.ConfigDir, config.ProfilesDir, profile, config.EnvFile)
+	}
+	return fmt.Sprintf("~/%s/%s", config.ConfigDir, config.EnvFile)
+}
+
 func handleConfigError(err error) {
 	switch e := err.(type) {
 	case *config.ConfigNotFoundError:
@@ -703,9 +2744,9 @@ func handleConfigError(err error) {
 		printFinal("❌", "Configuration required")
 		fmt.Println()
 		fmt.Println("   Edit your config file to get started:")
-		fmt.Println("   ~/.commit-tool/.env")
+		fmt.Printf("   %s\n", configFileHint())
 		fmt.Println()
-		fmt.Println("   Set COMMIT_PROVIDER to one of: anthropic, openai, grok, gemini, azure-foundry")
+		fmt.Println("   Set COMMIT_PROVIDER to one of: anthropic, openai, grok, gemini, azure-foundry, azure-openai, ollama, openrouter, mistral, vertex, deepseek, github-models")
 		fmt.Println("   Then add the corresponding API key.")
 		fmt.Println()
 		fmt.Println("   📖 Documentation: https://github.com/dsswift/commit#configuration")
@@ -718,23 +2759,46 @@ func handleConfigError(err error) {
 		printStepError("No provider configured")
 		printFinal("❌", "Configuration required")
 		fmt.Println()
-		fmt.Println("   Edit ~/.commit-tool/.env and set COMMIT_PROVIDER")
+		fmt.Printf("   Edit %s and set COMMIT_PROVIDER\n", configFileHint())
 		fmt.Println()
-		fmt.Println("   Supported providers: anthropic, openai, grok, gemini, azure-foundry")
+		fmt.Println("   Supported providers: anthropic, openai, grok, gemini, azure-foundry, azure-openai, ollama, openrouter, mistral, vertex, deepseek, github-models")
 
 	case *config.InvalidProviderError:
 		printStepError(fmt.Sprintf("Invalid provider: %s", e.Provider))
 		printFinal("❌", "Configuration error")
 		fmt.Println()
 		fmt.Printf("   Provider %q is not supported.\n", e.Provider)
-		fmt.Println("   Supported providers: anthropic, openai, grok, gemini, azure-foundry")
+		fmt.Println("   Supported providers: anthropic, openai, grok, gemini, azure-foundry, azure-openai, ollama, openrouter, mistral, vertex, deepseek, github-models")
 
 	case *config.MissingAPIKeyError:
 		printStepError(fmt.Sprintf("Missing %s", e.EnvVar))
 		printFinal("❌", "Configuration error")
 		fmt.Println()
 		fmt.Printf("   Provider %q requires %s to be set.\n", e.Provider, e.EnvVar)
-		fmt.Println("   Edit ~/.commit-tool/.env to add your API key.")
+		fmt.Printf("   Edit %s to add your API key.\n", configFileHint())
+		if e.Provider == "github-models" {
+			fmt.Println("   Or sign in with gh auth login; its token is used when GITHUB_TOKEN isn't set.")
+		}
+
+	case *config.InvalidAPIKeyError:
+		printStepError(fmt.Sprintf("Invalid %s", e.EnvVar))
+		printFinal("❌", "Configuration error")
+		fmt.Println()
+		fmt.Printf("   The %s API key %s.\n", e.Provider, e.Reason)
+		fmt.Printf("   Edit %s to fix %s.\n", configFileHint(), e.EnvVar)
+
+	case *config.ProfileNotFoundError:
+		printStepError(fmt.Sprintf("Profile not found: %s", e.Name))
+		printFinal("❌", "Configuration error")
+		fmt.Println()
+		if filepath.Base(e.Path) == config.ConfigFile {
+			fmt.Printf("   Add a profiles.%s section to %s to use this profile.\n", e.Name, e.Path)
+		} else {
+			fmt.Printf("   Create %s/.env to use this profile.\n", e.Path)
+		}
+		if names, _ := config.ListProfiles(); len(names) > 0 {
+			fmt.Printf("   Available profiles: %s\n", strings.Join(names, ", "))
+		}
 
 	case *config.InvalidDefaultModeError:
 		printStepError(fmt.Sprintf("Invalid default mode: %s", e.Mode))
@@ -743,6 +2807,36 @@ func handleConfigError(err error) {
 		fmt.Printf("   Default mode %q is not valid.\n", e.Mode)
 		fmt.Println("   Use: smart or single")
 
+	case *config.InvalidTemperatureError:
+		printStepError(fmt.Sprintf("Invalid temperature: %s", e.Value))
+		printFinal("❌", "Configuration error")
+		fmt.Println()
+		fmt.Printf("   COMMIT_LLM_TEMPERATURE must be a number from 0 to 2 in %s.\n", configFileHint())
+
+	case *config.InvalidBudgetError:
+		printStepError(fmt.Sprintf("Invalid monthly budget: %s", e.Value))
+		printFinal("❌", "Configuration error")
+		fmt.Println()
+		fmt.Printf("   COMMIT_MONTHLY_BUDGET_USD must be an amount in USD greater than 0 in %s.\n", configFileHint())
+
+	case *config.InvalidTopPError:
+		printStepError(fmt.Sprintf("Invalid top_p: %s", e.Value))
+		printFinal("❌", "Configuration error")
+		fmt.Println()
+		fmt.Printf("   COMMIT_LLM_TOP_P must be a number above 0 and up to 1 in %s.\n", configFileHint())
+
+	case *config.InvalidMaxTokensError:
+		printStepError(fmt.Sprintf("Invalid max tokens: %s", e.Value))
+		printFinal("❌", "Configuration error")
+		fmt.Println()
+		fmt.Printf("   COMMIT_LLM_MAX_TOKENS must be a whole number greater than 0 in %s.\n", configFileHint())
+
+	case *config.InvalidCacheTTLError:
+		printStepError(fmt.Sprintf("Invalid cache TTL: %s", e.Value))
+		printFinal("❌", "Configuration error")
+		fmt.Println()
+		fmt.Printf("   COMMIT_CACHE_TTL must be a duration such as 30m or 24h, or 0, in %s.\n", configFileHint())
+
 	default:
 		printError("Failed to load config", err)
 	}
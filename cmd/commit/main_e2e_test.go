@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -9,11 +10,16 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/internal/git"
 	"github.com/dsswift/commit/internal/llm"
+	"github.com/dsswift/commit/internal/testutil"
 	"github.com/dsswift/commit/pkg/types"
 )
 
@@ -471,12 +477,22 @@ func TestE2E_DryRun(t *testing.T) {
 	os.Chdir(tmpDir)        //nolint:errcheck // test setup
 	defer os.Chdir(origDir) //nolint:errcheck // test cleanup
 
+	indexBefore, _ := os.ReadFile(filepath.Join(tmpDir, ".git", "index"))
+
 	// Run execute with --dry-run
 	result := execute(flags{dryRun: true}, nil)
 
 	if result.ExitCode != 0 {
 		t.Errorf("expected exit code 0, got %d", result.ExitCode)
 	}
+	if git.ReadOnly() {
+		t.Error("read-only guard still on after the dry run")
+	}
+
+	// Not even an index refresh: the index is byte-for-byte the same
+	if indexAfter, _ := os.ReadFile(filepath.Join(tmpDir, ".git", "index")); !bytes.Equal(indexBefore, indexAfter) {
+		t.Error("dry-run modified the index")
+	}
 
 	// Verify NO new commits were created in git log
 	cmd = exec.Command("git", "rev-list", "--count", "HEAD")
@@ -817,7 +833,7 @@ func TestE2E_HandleReverse(t *testing.T) {
 	}
 
 	// Reverse the last commit
-	code := handleReverse(tmpDir, 1, false, false)
+	code := handleReverse(tmpDir, 1, false, false, false)
 	if code != 0 {
 		t.Errorf("expected exit code 0, got %d", code)
 	}
@@ -859,6 +875,11 @@ func TestE2E_HandleConfigError_AllBranches(t *testing.T) {
 	handleConfigError(&config.InvalidProviderError{Provider: "fake"})
 	handleConfigError(&config.MissingAPIKeyError{Provider: "openai", EnvVar: "OPENAI_API_KEY"})
 	handleConfigError(&config.InvalidDefaultModeError{Mode: "bad"})
+	handleConfigError(&config.InvalidTemperatureError{Value: "hot"})
+	handleConfigError(&config.InvalidCacheTTLError{Value: "soon"})
+	handleConfigError(&config.InvalidTopPError{Value: "2"})
+	handleConfigError(&config.InvalidMaxTokensError{Value: "lots"})
+	handleConfigError(&config.InvalidBudgetError{Value: "ten"})
 	handleConfigError(fmt.Errorf("generic error"))
 }
 
@@ -1188,7 +1209,7 @@ func TestE2E_HandleReverse_Multiple(t *testing.T) {
 	}
 
 	// Reverse the last 2 commits
-	code := handleReverse(tmpDir, 2, false, false)
+	code := handleReverse(tmpDir, 2, false, false, false)
 	if code != 0 {
 		t.Errorf("expected exit code 0, got %d", code)
 	}
@@ -1251,7 +1272,7 @@ func TestE2E_HandleReverse_TooMany(t *testing.T) {
 	runGit("commit", "-m", "initial commit")
 
 	// Try to reverse 5 commits when only 1 exists
-	code := handleReverse(tmpDir, 5, false, false)
+	code := handleReverse(tmpDir, 5, false, false, false)
 	if code != 1 {
 		t.Errorf("expected exit code 1, got %d", code)
 	}
@@ -1306,7 +1327,7 @@ func TestE2E_HandleReverse_VerboseOutput(t *testing.T) {
 	runGit("commit", "-m", "feat: add feature")
 
 	// Reverse with verbose flag
-	code := handleReverse(tmpDir, 1, false, true)
+	code := handleReverse(tmpDir, 1, false, false, true)
 	if code != 0 {
 		t.Errorf("expected exit code 0, got %d", code)
 	}
@@ -1334,6 +1355,76 @@ func TestE2E_HandleReverse_VerboseOutput(t *testing.T) {
 	}
 }
 
+func TestE2E_DryRunReverse(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	runGit := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+	}
+
+	runGit("init")
+	runGit("config", "user.email", "test@test.com")
+	runGit("config", "user.name", "Test")
+	if err := os.WriteFile(filepath.Join(tmpDir, "README.md"), []byte("# Test\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit("add", "README.md")
+	runGit("commit", "-m", "initial commit")
+	if err := os.WriteFile(filepath.Join(tmpDir, "feature.go"), []byte("package main\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit("add", "feature.go")
+	runGit("commit", "-m", "feat: add feature")
+
+	// COMMIT_DRY_RUN in the config counts the same as --dry-run
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\nCOMMIT_DRY_RUN=true\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	origHome := os.Getenv("HOME")
+	os.Setenv("HOME", fakeHome)       //nolint:errcheck // test setup
+	defer os.Setenv("HOME", origHome) //nolint:errcheck // test cleanup
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)        //nolint:errcheck // test setup
+	defer os.Chdir(origDir) //nolint:errcheck // test cleanup
+
+	for _, f := range []flags{{dryRun: true, reverse: 1}, {reverse: 1}} {
+		if result := execute(f, nil); result.ExitCode != 0 {
+			t.Fatalf("dry-run reverse (dryRun flag %v): expected exit code 0, got %d", f.dryRun, result.ExitCode)
+		}
+		if git.ReadOnly() {
+			t.Fatal("read-only guard still on after the dry run")
+		}
+	}
+
+	cmd := exec.Command("git", "rev-list", "--count", "HEAD")
+	cmd.Dir = tmpDir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(out)) != "2" {
+		t.Errorf("dry-run reverse should keep both commits, got %s", strings.TrimSpace(string(out)))
+	}
+
+	// The guard was on before the lock, so no lock file was ever written
+	if _, err := os.Stat(filepath.Join(tmpDir, ".git", git.LockFile)); !os.IsNotExist(err) {
+		t.Errorf("dry run took the run lock: %v", err)
+	}
+}
+
 func containsStr(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsIndex(s, substr))
 }
@@ -1346,3 +1437,1386 @@ func containsIndex(s, substr string) bool {
 	}
 	return false
 }
+
+func TestE2E_ScopedPaths(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := t.TempDir()
+
+	runGit := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+
+	runGit("init")
+	runGit("config", "user.email", "test@test.com")
+	runGit("config", "user.name", "Test")
+
+	writeFile := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeFile("README.md", "# Test\n")
+	runGit("add", "README.md")
+	runGit("commit", "-m", "initial commit")
+
+	// One change in scope, one untracked and one staged change outside it
+	writeFile("services/auth/login.go", "package auth\n")
+	writeFile("services/billing/invoice.go", "package billing\n")
+	writeFile("staged.go", "package main\n")
+	runGit("add", "staged.go")
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "feat", Message: "add login", Files: []string{"services/auth/login.go"}},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &mockProvider{baseURL: mockServer.URL}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+
+	// Run from a subdirectory with a path relative to it
+	origDir, _ := os.Getwd()
+	os.Chdir(filepath.Join(tmpDir, "services")) //nolint:errcheck // test setup
+	defer os.Chdir(origDir)                     //nolint:errcheck // test cleanup
+
+	result := execute(flags{paths: []string{"auth"}}, nil)
+
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", result.ExitCode)
+	}
+	if len(result.CommitsCreated) != 1 {
+		t.Fatalf("expected 1 commit created, got %d", len(result.CommitsCreated))
+	}
+
+	committed := runGit("show", "--name-only", "--format=", "HEAD")
+	if strings.TrimSpace(committed) != "services/auth/login.go" {
+		t.Errorf("expected only services/auth/login.go committed, got:\n%s", committed)
+	}
+
+	// Changes outside the scope are untouched, including the index
+	status := runGit("status", "--porcelain")
+	if !containsStr(status, "A  staged.go") {
+		t.Errorf("expected staged.go to remain staged, got:\n%s", status)
+	}
+	if !containsStr(status, "?? services/billing/") {
+		t.Errorf("expected billing changes to remain untracked, got:\n%s", status)
+	}
+}
+
+func TestE2E_CachedPlan(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "README.md", "# Test\n")
+	testutil.GitAdd(t, tmpDir, "README.md")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "feat", Message: "add main", Files: []string{"main.go"}},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &mockProvider{baseURL: mockServer.URL}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)        //nolint:errcheck // test setup
+	defer os.Chdir(origDir) //nolint:errcheck // test cleanup
+
+	// A dry-run followed by an unchanged tree reuses the plan
+	if result := execute(flags{dryRun: true}, nil); result.ExitCode != 0 {
+		t.Fatalf("dry-run: expected exit code 0, got %d", result.ExitCode)
+	}
+	if result := execute(flags{dryRun: true}, nil); result.ExitCode != 0 {
+		t.Fatalf("cached dry-run: expected exit code 0, got %d", result.ExitCode)
+	}
+	if requests != 1 {
+		t.Errorf("expected cached plan to skip the LLM, got %d requests", requests)
+	}
+
+	// --no-cache always asks again
+	if result := execute(flags{dryRun: true, noCache: true}, nil); result.ExitCode != 0 {
+		t.Fatalf("no-cache: expected exit code 0, got %d", result.ExitCode)
+	}
+	if requests != 2 {
+		t.Errorf("expected --no-cache to call the LLM, got %d requests", requests)
+	}
+
+	// Any content change invalidates the cache
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nfunc main() {}\n")
+	result := execute(flags{}, nil)
+	if result.ExitCode != 0 || len(result.CommitsCreated) != 1 {
+		t.Fatalf("expected 1 commit, got exit %d and %d commits", result.ExitCode, len(result.CommitsCreated))
+	}
+	if requests != 3 {
+		t.Errorf("expected changed tree to call the LLM, got %d requests", requests)
+	}
+}
+
+func TestE2E_Oops(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "README.md", "# Test\n")
+	testutil.GitAdd(t, tmpDir, "README.md")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	testutil.GitAdd(t, tmpDir, "main.go")
+	testutil.GitCommit(t, tmpDir, "feat: add main")
+
+	// The forgotten file, plus a secret that must never be amended in
+	testutil.CreateFile(t, tmpDir, "util.go", "package main\n")
+	testutil.CreateFile(t, tmpDir, ".env", "SECRET=1\n")
+
+	runGit := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)        //nolint:errcheck // test setup
+	defer os.Chdir(origDir) //nolint:errcheck // test cleanup
+
+	if result := execute(flags{oops: true, dryRun: true}, nil); result.ExitCode != 0 {
+		t.Fatalf("dry-run: expected exit code 0, got %d", result.ExitCode)
+	}
+	if files := runGit("show", "--name-only", "--format=", "HEAD"); strings.TrimSpace(files) != "main.go" {
+		t.Fatalf("dry-run should not amend, HEAD has:\n%s", files)
+	}
+
+	if result := execute(flags{oops: true}, nil); result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", result.ExitCode)
+	}
+
+	if count := strings.TrimSpace(runGit("rev-list", "--count", "HEAD")); count != "2" {
+		t.Errorf("expected amend to keep 2 commits, got %s", count)
+	}
+	if msg := strings.TrimSpace(runGit("log", "-1", "--format=%s")); msg != "feat: add main" {
+		t.Errorf("expected message unchanged, got %q", msg)
+	}
+	files := runGit("show", "--name-only", "--format=", "HEAD")
+	if !containsStr(files, "util.go") || containsStr(files, ".env") {
+		t.Errorf("expected util.go amended without .env, got:\n%s", files)
+	}
+	if status := runGit("status", "--porcelain"); !containsStr(status, "?? .env") {
+		t.Errorf("expected .env to remain untracked, got:\n%s", status)
+	}
+}
+
+func TestE2E_GeneratedFiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, ".gitattributes", "*.pb.go linguist-generated\n*.lock -diff\n")
+	testutil.GitAdd(t, tmpDir, ".gitattributes")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	testutil.CreateFile(t, tmpDir, "api.pb.go", "package main\n")
+	testutil.CreateFile(t, tmpDir, "deps.lock", "pinned\n")
+
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "feat", Message: "add main", Files: []string{"main.go"}},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	var analyzed *types.AnalysisRequest
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &recordingProvider{mockProvider{baseURL: mockServer.URL}, &analyzed}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)        //nolint:errcheck // test setup
+	defer os.Chdir(origDir) //nolint:errcheck // test cleanup
+
+	result := execute(flags{}, nil)
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", result.ExitCode)
+	}
+
+	if len(analyzed.Files) != 1 || analyzed.Files[0].Path != "main.go" {
+		t.Errorf("expected only main.go sent to the LLM, got %+v", analyzed.Files)
+	}
+	if containsStr(analyzed.Diff, "api.pb.go") || containsStr(analyzed.Diff, "deps.lock") {
+		t.Error("generated files should not be in the LLM diff")
+	}
+
+	if len(result.CommitsCreated) != 2 {
+		t.Fatalf("expected 2 commits, got %d", len(result.CommitsCreated))
+	}
+	// Commit order isn't fixed, so find the generated commit by type
+	gen := result.CommitsCreated[0]
+	if gen.Type != "chore" {
+		gen = result.CommitsCreated[1]
+	}
+	if gen.Type != "chore" || gen.Message != "chore: update generated files" || len(gen.Files) != 2 {
+		t.Errorf("unexpected generated commit: %+v", gen)
+	}
+
+	// Only generated files changed: no LLM call at all
+	testutil.CreateFile(t, tmpDir, "api.pb.go", "package main\n\nconst V = 2\n")
+	result = execute(flags{}, nil)
+	if result.ExitCode != 0 || len(result.CommitsCreated) != 1 {
+		t.Fatalf("expected 1 commit, got exit %d and %d commits", result.ExitCode, len(result.CommitsCreated))
+	}
+	if requests != 1 {
+		t.Errorf("expected generated-only changes to skip the LLM, got %d requests", requests)
+	}
+}
+
+// recordingProvider is a mockProvider that keeps the last analysis request.
+type recordingProvider struct {
+	mockProvider
+	last **types.AnalysisRequest
+}
+
+func (p *recordingProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	*p.last = req
+	return p.mockProvider.Analyze(ctx, req)
+}
+
+func TestE2E_MassDeletion(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	var legacy []string
+	for i := 0; i < 6; i++ {
+		name := fmt.Sprintf("legacy/f%d.go", i)
+		testutil.CreateFile(t, tmpDir, name, "package legacy\n")
+		legacy = append(legacy, name)
+	}
+	testutil.GitAdd(t, tmpDir, "legacy")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+
+	for _, f := range legacy {
+		if err := os.Remove(filepath.Join(tmpDir, f)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "chore", Message: "remove legacy package", Files: legacy},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &mockProvider{baseURL: mockServer.URL}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+
+	// Without a terminal there is nobody to confirm
+	devNull, err := os.Open(os.DevNull)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer devNull.Close() //nolint:errcheck // test cleanup
+	origStdin := os.Stdin
+	os.Stdin = devNull
+	defer func() { os.Stdin = origStdin }()
+
+	origDir, _ := os.Getwd()
+	os.Chdir(tmpDir)        //nolint:errcheck // test setup
+	defer os.Chdir(origDir) //nolint:errcheck // test cleanup
+
+	result := execute(flags{}, nil)
+	if result.ExitCode != 1 || len(result.CommitsCreated) != 0 {
+		t.Fatalf("expected the deletion to be refused, got exit %d and %d commits", result.ExitCode, len(result.CommitsCreated))
+	}
+
+	result = execute(flags{massDelete: true}, nil)
+	if result.ExitCode != 0 || len(result.CommitsCreated) != 1 {
+		t.Fatalf("expected 1 commit with --allow-mass-delete, got exit %d and %d commits", result.ExitCode, len(result.CommitsCreated))
+	}
+
+	body, err := exec.Command("git", "-C", tmpDir, "log", "-1", "--format=%b").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "legacy/: 6 of 6 files") {
+		t.Errorf("expected the deletion called out in the commit body, got %q", body)
+	}
+}
+
+func TestE2E_FixGitignore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "README.md", "# test\n")
+	testutil.GitAdd(t, tmpDir, "README.md")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	testutil.CreateFile(t, tmpDir, ".DS_Store", "junk")
+	testutil.CreateFile(t, tmpDir, "main.go.orig", "package main\n")
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "feat", Message: "add main", Files: []string{"main.go"}},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	var analyzed *types.AnalysisRequest
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &recordingProvider{mockProvider{baseURL: mockServer.URL}, &analyzed}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	result := execute(flags{fixGitignore: true}, nil)
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", result.ExitCode)
+	}
+
+	if len(analyzed.Files) != 1 || analyzed.Files[0].Path != "main.go" {
+		t.Errorf("expected only main.go sent to the LLM, got %+v", analyzed.Files)
+	}
+	if len(result.CommitsCreated) != 2 {
+		t.Fatalf("expected 2 commits, got %d", len(result.CommitsCreated))
+	}
+	ignore := result.CommitsCreated[0]
+	if ignore.Message != "chore: update gitignore" || len(ignore.Files) != 1 || ignore.Files[0] != ".gitignore" {
+		t.Errorf("unexpected gitignore commit: %+v", ignore)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, ".gitignore"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != ".DS_Store\n*.orig\n" {
+		t.Errorf(".gitignore = %q", content)
+	}
+
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = tmpDir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 0 {
+		t.Errorf("expected a clean tree with the junk ignored, got:\n%s", out)
+	}
+}
+
+func TestE2E_AnalyzePatch(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "README.md", "# test\n")
+	testutil.GitAdd(t, tmpDir, "README.md")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "feat", Message: "add greeting", Files: []string{"hello.go"}},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	var analyzed *types.AnalysisRequest
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &recordingProvider{mockProvider{baseURL: mockServer.URL}, &analyzed}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	patchFile := filepath.Join(t.TempDir(), "change.patch")
+	patch := "diff --git a/hello.go b/hello.go\nnew file mode 100644\n--- /dev/null\n+++ b/hello.go\n@@ -0,0 +1 @@\n+package hello\n"
+	if err := os.WriteFile(patchFile, []byte(patch), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var code int
+	out := captureStdout(t, func() { code = handleAnalyze(flags{analyze: patchFile}) })
+	if code != 0 {
+		t.Fatalf("handleAnalyze() = %d, output:\n%s", code, out)
+	}
+	if !strings.Contains(out, "feat: add greeting") || !strings.Contains(out, "hello.go") {
+		t.Errorf("expected the plan in the output, got:\n%s", out)
+	}
+	if len(analyzed.Files) != 1 || analyzed.Files[0].Status != "added" {
+		t.Errorf("expected hello.go sent as added, got %+v", analyzed.Files)
+	}
+
+	cmd := exec.Command("git", "log", "--oneline")
+	cmd.Dir = tmpDir
+	log, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(log), "\n") != 1 {
+		t.Errorf("expected no commits created, got:\n%s", log)
+	}
+}
+
+func TestE2E_DeltaReanalysis(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "README.md", "# test\n")
+	testutil.GitAdd(t, tmpDir, "README.md")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+
+	testutil.CreateFile(t, tmpDir, "api/a.go", "package api\n")
+	testutil.CreateFile(t, tmpDir, "api/b.go", "package api\n")
+	testutil.CreateFile(t, tmpDir, "docs/guide.md", "# guide\n")
+	testutil.CreateFile(t, tmpDir, "README.md", "# test\n\nUsage.\n")
+
+	full := types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add api", Files: []string{"api/a.go", "api/b.go"}},
+		{Type: "docs", Message: "add guide", Files: []string{"docs/guide.md"}},
+		{Type: "docs", Message: "add usage", Files: []string{"README.md"}},
+	}}
+	plans := []types.CommitPlan{full, full, {Commits: []types.PlannedCommit{
+		{Type: "docs", Message: "add guide", Files: []string{"docs/guide.md"}},
+	}}}
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		plan := plans[min(requests, len(plans)-1)]
+		requests++
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{Message: chatCompletionMessage{Content: mustJSON(t, plan)}, FinishReason: "stop"},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	var analyzed *types.AnalysisRequest
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &recordingProvider{mockProvider{baseURL: mockServer.URL}, &analyzed}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	if result := execute(flags{dryRun: true}, nil); result.ExitCode != 0 {
+		t.Fatalf("dry run failed with exit code %d", result.ExitCode)
+	}
+
+	// --full-analysis sends every file again
+	testutil.CreateFile(t, tmpDir, "docs/guide.md", "# guide\n\nMore.\n")
+	if result := execute(flags{dryRun: true, fullAnalysis: true}, nil); result.ExitCode != 0 {
+		t.Fatalf("dry run failed with exit code %d", result.ExitCode)
+	}
+	if len(analyzed.Files) != 3 {
+		t.Errorf("expected --full-analysis to send all 3 changes, got %+v", analyzed.Files)
+	}
+
+	// One small edit: only that file goes back to the LLM
+	testutil.CreateFile(t, tmpDir, "docs/guide.md", "# guide\n\nEven more.\n")
+	result := execute(flags{}, nil)
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", result.ExitCode)
+	}
+	if requests != 3 {
+		t.Fatalf("expected 3 LLM requests, got %d", requests)
+	}
+	if len(analyzed.Files) != 1 || analyzed.Files[0].Path != "docs/" {
+		t.Errorf("expected only docs/ re-analyzed, got %+v", analyzed.Files)
+	}
+	if strings.Contains(analyzed.Diff, "api/a.go") {
+		t.Errorf("expected the delta diff to omit unchanged files:\n%s", analyzed.Diff)
+	}
+	if len(result.CommitsCreated) != 3 {
+		t.Fatalf("expected 3 commits from the merged plan, got %d", len(result.CommitsCreated))
+	}
+	// Plan validation doesn't keep commit order, so look the commit up
+	i := slices.IndexFunc(result.CommitsCreated, func(c types.ExecutedCommit) bool { return c.Message == "feat: add api" })
+	if i < 0 || len(result.CommitsCreated[i].Files) != 2 {
+		t.Errorf("expected the cached api commit with both files, got %+v", result.CommitsCreated)
+	}
+}
+
+func TestE2E_Convention(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+	defer convention.Use(convention.Active())
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "README.md", "# Test\n")
+	testutil.GitAdd(t, tmpDir, "README.md")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+	testutil.CreateFile(t, tmpDir, ".commit.json", `{"convention": "bracketed-ticket"}`)
+	testutil.GitAdd(t, tmpDir, ".commit.json")
+	testutil.GitCommit(t, tmpDir, "chore: add config")
+	checkout := exec.Command("git", "checkout", "-b", "feature/PROJ-7-login")
+	checkout.Dir = tmpDir
+	if out, err := checkout.CombinedOutput(); err != nil {
+		t.Fatalf("git checkout failed: %v\n%s", err, out)
+	}
+	testutil.CreateFile(t, tmpDir, "login.go", "package main\n")
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "feat", Message: "Add login", Files: []string{"login.go"}},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	var analyzed *types.AnalysisRequest
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &recordingProvider{mockProvider{baseURL: mockServer.URL}, &analyzed}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	result := execute(flags{}, nil)
+	if result.ExitCode != 0 || len(result.CommitsCreated) != 1 {
+		t.Fatalf("expected 1 commit, got exit %d and %d commits", result.ExitCode, len(result.CommitsCreated))
+	}
+	if analyzed == nil || analyzed.Rules.Convention != convention.NameTicket {
+		t.Error("expected the analysis request to carry the bracketed-ticket convention")
+	}
+
+	cmd := exec.Command("git", "log", "-1", "--format=%s")
+	cmd.Dir = tmpDir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git log failed: %v", err)
+	}
+	if subject := strings.TrimSpace(string(out)); subject != "[PROJ-7] Add login" {
+		t.Errorf("expected ticket subject, got %q", subject)
+	}
+}
+
+func TestE2E_Skip(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "README.md", "# Test\n")
+	testutil.GitAdd(t, tmpDir, "README.md")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+	testutil.CreateFile(t, tmpDir, "ui/app.ts", "export {}\n")
+	testutil.CreateFile(t, tmpDir, "ui/__snapshots__/app.snap", "snap\n")
+	testutil.CreateFile(t, tmpDir, "docs/guide.md", "# Guide\n")
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "feat", Message: "add app", Files: []string{"ui/app.ts"}},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	var analyzed *types.AnalysisRequest
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &recordingProvider{mockProvider{baseURL: mockServer.URL}, &analyzed}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	var result executeResult
+	out := captureStdout(t, func() {
+		result = execute(flags{skip: []string{"**/*.snap", "docs/"}}, nil)
+	})
+	if result.ExitCode != 0 || len(result.CommitsCreated) != 1 {
+		t.Fatalf("expected 1 commit, got exit %d and %d commits\n%s", result.ExitCode, len(result.CommitsCreated), out)
+	}
+	if analyzed == nil {
+		t.Fatal("expected an analysis request")
+	}
+	for _, f := range analyzed.Files {
+		if f.Path != "ui/" && f.Path != "ui/app.ts" {
+			t.Errorf("skipped file sent for analysis: %s", f.Path)
+		}
+	}
+	if !strings.Contains(out, "Left behind 2 skipped files") ||
+		!strings.Contains(out, "ui/__snapshots__/app.snap") || !strings.Contains(out, "docs/guide.md") {
+		t.Errorf("expected skipped files in the summary, got:\n%s", out)
+	}
+
+	cmd := exec.Command("git", "show", "--name-only", "--format=", "HEAD")
+	cmd.Dir = tmpDir
+	committed, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git show failed: %v", err)
+	}
+	if files := strings.Fields(string(committed)); !slices.Equal(files, []string{"ui/app.ts"}) {
+		t.Errorf("expected only ui/app.ts committed, got %v", files)
+	}
+
+	status := exec.Command("git", "status", "--porcelain", "-uall")
+	status.Dir = tmpDir
+	left, _ := status.Output()
+	if !strings.Contains(string(left), "?? docs/guide.md") || !strings.Contains(string(left), "?? ui/__snapshots__/app.snap") {
+		t.Errorf("expected skipped files left untracked, got:\n%s", left)
+	}
+}
+func TestE2E_InitialCommit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "go.mod", "module example.com/app\n")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nfunc main() {}\n")
+	testutil.CreateFile(t, tmpDir, "internal/app/app.go", "package app\n")
+	testutil.CreateFile(t, tmpDir, "README.md", "# app\n")
+	testutil.CreateFile(t, tmpDir, ".gitignore", "bin/\n")
+	testutil.GitAdd(t, tmpDir, "README.md")
+
+	// The LLM isn't needed for the first commit
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &mockProvider{baseURL: mockServer.URL}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	gitOutput := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = tmpDir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return string(out)
+	}
+
+	// A dry run leaves the index as it was
+	if result := execute(flags{dryRun: true}, nil); result.ExitCode != 0 {
+		t.Fatalf("dry run: expected exit code 0, got %d", result.ExitCode)
+	}
+	if staged := gitOutput("diff", "--cached", "--name-only"); strings.TrimSpace(staged) != "README.md" {
+		t.Errorf("expected README.md to stay staged after a dry run, got %q", staged)
+	}
+
+	result := execute(flags{}, nil)
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", result.ExitCode)
+	}
+	if requests != 0 {
+		t.Errorf("expected the first commit to be planned without the LLM, got %d requests", requests)
+	}
+
+	messages := make(map[string][]string)
+	for _, c := range result.CommitsCreated {
+		messages[c.Message] = c.Files
+	}
+	want := map[string][]string{
+		"chore: add project configuration": {".gitignore", "go.mod"},
+		"feat: add project skeleton":       {"internal/", "main.go"},
+		"docs: add documentation":          {"README.md"},
+	}
+	for message, files := range want {
+		got, ok := messages[message]
+		slices.Sort(got)
+		if !ok || !slices.Equal(got, files) {
+			t.Errorf("expected %q with %v, got %+v", message, files, result.CommitsCreated)
+		}
+	}
+	if status := gitOutput("status", "--porcelain"); status != "" {
+		t.Errorf("expected a clean tree after the first commits, got:\n%s", status)
+	}
+	if count := strings.TrimSpace(gitOutput("rev-list", "--count", "HEAD")); count != "3" {
+		t.Errorf("expected 3 commits, got %s", count)
+	}
+}
+
+func TestE2E_Fast(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	testutil.GitAdd(t, tmpDir, "main.go")
+	testutil.GitCommit(t, tmpDir, "feat: add main")
+	testutil.CreateFile(t, tmpDir, "docs/setup.md", "# Setup\n")
+
+	// Documentation alone is typed locally
+	requests := 0
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &mockProvider{baseURL: mockServer.URL}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	result := execute(flags{fast: true}, nil)
+	if result.ExitCode != 0 {
+		t.Fatalf("expected exit code 0, got %d", result.ExitCode)
+	}
+	if requests != 0 {
+		t.Errorf("expected --fast to skip the LLM, got %d requests", requests)
+	}
+	if len(result.CommitsCreated) != 1 || result.CommitsCreated[0].Type != "docs" {
+		t.Errorf("expected one docs commit, got %+v", result.CommitsCreated)
+	}
+
+	// Code changes still go to the LLM
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nfunc main() {}\n")
+	if result := execute(flags{fast: true}, nil); result.ExitCode == 0 || requests == 0 {
+		t.Errorf("expected the failing LLM to be asked about code changes, got exit %d after %d requests", result.ExitCode, requests)
+	}
+}
+
+func TestE2E_RemovedDirectory(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	for i := 0; i < 25; i++ {
+		testutil.CreateFile(t, tmpDir, fmt.Sprintf("vendor/lib/f%d.go", i), "package lib\n")
+	}
+	testutil.GitAdd(t, tmpDir, ".")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+	if err := os.RemoveAll(filepath.Join(tmpDir, "vendor")); err != nil {
+		t.Fatal(err)
+	}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "chore", Message: "remove vendored lib", Files: []string{"vendor/"}},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	var analyzed *types.AnalysisRequest
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &recordingProvider{mockProvider{baseURL: mockServer.URL}, &analyzed}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	result := execute(flags{massDelete: true}, nil)
+	if result.ExitCode != 0 || len(result.CommitsCreated) != 1 {
+		t.Fatalf("expected 1 commit, got exit %d and %d commits", result.ExitCode, len(result.CommitsCreated))
+	}
+	if analyzed == nil || len(analyzed.Files) != 1 || analyzed.Files[0].RemovedDir != "removed directory vendor (25 files)" {
+		t.Errorf("expected vendor/ analyzed as one removed directory, got %+v", analyzed)
+	}
+
+	out, err := exec.Command("git", "-C", tmpDir, "show", "--name-only", "--format=%b", "HEAD").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(out), "vendor/lib/"); got != 25 {
+		t.Errorf("expected 25 deletions committed, got %d:\n%s", got, out)
+	}
+	if !strings.Contains(string(out), "vendor/: 25 of 25 files") {
+		t.Errorf("expected the deletion called out in the commit body, got %q", out)
+	}
+}
+
+func TestE2E_Leftover(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "README.md", "# Test\n")
+	// Leave the hook's rewrite in the working tree instead of amending it
+	testutil.CreateFile(t, tmpDir, ".commit.json", `{"hookAutofix": "warn"}`)
+	testutil.GitAdd(t, tmpDir, "README.md", ".commit.json")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+
+	// The hook rewrites main.go once, after it was staged
+	hook := "#!/bin/sh\n[ -f .git/formatted ] && exit 0\ntouch .git/formatted\necho '// formatted' >> main.go\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, ".git", "hooks", "pre-commit"), []byte(hook), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "feat", Message: "add main", Files: []string{"main.go"}},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &mockProvider{baseURL: mockServer.URL}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	var result executeResult
+	out := captureStdout(t, func() { result = execute(flags{}, nil) })
+	if result.ExitCode != 0 || len(result.CommitsCreated) != 1 {
+		t.Fatalf("expected 1 commit, got exit %d and %d commits", result.ExitCode, len(result.CommitsCreated))
+	}
+	if !slices.Equal(result.Leftover, []string{"main.go"}) || !strings.Contains(out, "1 planned files are still changed") {
+		t.Fatalf("expected main.go reported as left over, got %v\n%s", result.Leftover, out)
+	}
+
+	result = followUp(flags{}, result, nil)
+	if result.ExitCode != 0 || len(result.CommitsCreated) != 2 || len(result.Leftover) != 0 {
+		t.Fatalf("expected the follow-up to commit main.go, got exit %d, %d commits, leftover %v",
+			result.ExitCode, len(result.CommitsCreated), result.Leftover)
+	}
+	status, err := exec.Command("git", "-C", tmpDir, "status", "--porcelain").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 0 {
+		t.Errorf("expected a clean tree after the follow-up, got:\n%s", status)
+	}
+}
+
+func TestE2E_StalePlan(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "README.md", "# Test\n")
+	testutil.GitAdd(t, tmpDir, "README.md")
+	testutil.GitCommit(t, tmpDir, "initial commit")
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+
+	// main.go is edited again while the plan is being made
+	mockServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		testutil.CreateFile(t, tmpDir, "main.go", "package main\n\nfunc main() {}\n")
+		resp := chatCompletionResponse{
+			Choices: []chatCompletionChoice{
+				{
+					Message: chatCompletionMessage{
+						Content: mustJSON(t, types.CommitPlan{
+							Commits: []types.PlannedCommit{
+								{Type: "feat", Message: "add main package", Files: []string{"main.go"}},
+							},
+						}),
+					},
+					FinishReason: "stop",
+				},
+			},
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer mockServer.Close()
+
+	providerMu.Lock()
+	origFactory := newProviderFunc
+	newProviderFunc = func(config *types.UserConfig) (llm.Provider, error) {
+		return &mockProvider{baseURL: mockServer.URL}, nil
+	}
+	providerMu.Unlock()
+	defer func() {
+		providerMu.Lock()
+		newProviderFunc = origFactory
+		providerMu.Unlock()
+	}()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	// A plan younger than --stale-after is committed without a re-check
+	var result executeResult
+	out := captureStdout(t, func() { result = execute(flags{staleAfter: time.Hour, noCache: true}, nil) })
+	if result.ExitCode != 0 || len(result.CommitsCreated) != 1 || strings.Contains(out, "re-checking") {
+		t.Fatalf("expected 1 commit without a re-check, got exit %d and %d commits\n%s", result.ExitCode, len(result.CommitsCreated), out)
+	}
+
+	// With --stale-after 0 the edit is caught before committing
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n\n// Package main runs.\n")
+	out = captureStdout(t, func() { result = execute(flags{noCache: true}, nil) })
+	if result.ExitCode != 1 || len(result.CommitsCreated) != 0 {
+		t.Fatalf("expected the stale plan to be refused, got exit %d and %d commits\n%s", result.ExitCode, len(result.CommitsCreated), out)
+	}
+	if !strings.Contains(out, "changed: main.go") || !strings.Contains(out, "Plan is stale") {
+		t.Errorf("expected main.go reported as changed, got:\n%s", out)
+	}
+}
+
+func TestE2E_Verify(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping E2E test in short mode")
+	}
+
+	tmpDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, tmpDir, "main.go", "package main\n")
+	testutil.GitAdd(t, tmpDir, "main.go")
+	testutil.GitCommit(t, tmpDir, "feat: add main")
+	testutil.CreateFile(t, tmpDir, "docs/setup.md", "# Setup\n")
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(filepath.Join(configDir, "logs", "executions"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	envContent := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(envContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Chdir(tmpDir)
+
+	// Without a verifyCommand nothing is committed
+	if result := execute(flags{fast: true, verify: true}, nil); result.ExitCode != 1 || len(result.CommitsCreated) != 0 {
+		t.Fatalf("expected --verify without verifyCommand to fail early, got exit %d, %d commits", result.ExitCode, len(result.CommitsCreated))
+	}
+
+	// The uncommitted file exists in the working tree but not in the commit's checkout
+	testutil.CreateFile(t, tmpDir, ".commit.json", `{"verifyCommand": "test -f docs/setup.md && test ! -f untracked.txt"}`)
+	testutil.GitAdd(t, tmpDir, ".commit.json")
+	testutil.GitCommit(t, tmpDir, "chore: add verify command")
+	testutil.CreateFile(t, tmpDir, "untracked.txt", "scratch\n")
+
+	result := execute(flags{fast: true, verify: true, paths: []string{"docs"}}, nil)
+	if result.ExitCode != 0 || len(result.CommitsCreated) != 1 {
+		t.Fatalf("expected a verified commit, got exit %d, %d commits", result.ExitCode, len(result.CommitsCreated))
+	}
+
+	// A commit that fails the command is kept but fails the run
+	testutil.CreateFile(t, tmpDir, ".commit.json", `{"verifyCommand": "false"}`)
+	testutil.GitAdd(t, tmpDir, ".commit.json")
+	testutil.GitCommit(t, tmpDir, "chore: break verify command")
+	testutil.CreateFile(t, tmpDir, "docs/usage.md", "# Usage\n")
+
+	result = execute(flags{fast: true, verify: true, paths: []string{"docs"}}, nil)
+	if result.ExitCode != 1 || len(result.CommitsCreated) != 1 {
+		t.Errorf("expected the failing commit to be created and the run to fail, got exit %d, %d commits", result.ExitCode, len(result.CommitsCreated))
+	}
+
+	// Worktrees are cleaned up
+	out, err := exec.Command("git", "-C", tmpDir, "worktree", "list").Output()
+	if err != nil || strings.Count(string(out), "\n") != 1 {
+		t.Errorf("expected only the main worktree, got %q (%v)", out, err)
+	}
+}
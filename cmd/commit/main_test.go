@@ -2,11 +2,30 @@ package main
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/httpclient"
+	"github.com/dsswift/commit/internal/logging"
+	"github.com/dsswift/commit/internal/planner"
+	"github.com/dsswift/commit/internal/report"
+	"github.com/dsswift/commit/internal/testutil"
+	"github.com/dsswift/commit/internal/theme"
+	"github.com/dsswift/commit/internal/updater"
+	"github.com/dsswift/commit/internal/webhook"
+	"github.com/dsswift/commit/pkg/types"
 )
 
 func TestReverseFlag_Set(t *testing.T) {
@@ -134,6 +153,29 @@ func TestPrintStep(t *testing.T) {
 	}
 }
 
+func TestPrintHelpers_Theme(t *testing.T) {
+	minimal, err := theme.Load("minimal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := outputTheme
+	outputTheme = minimal
+	defer func() { outputTheme = orig }()
+
+	output := captureStdout(t, func() {
+		printStep("🔧", "loading config")
+		printSuccess("it worked")
+		printTreeItem(1, 2, "feat: first")
+		printTreeLeaf("main.go")
+		printTreeItem(2, 2, "fix: second")
+	})
+
+	expected := "\n==> loading config\n   + it worked\n   +- [1/2] feat: first\n   |  `- main.go\n   `- [2/2] fix: second\n"
+	if output != expected {
+		t.Errorf("themed output = %q, want %q", output, expected)
+	}
+}
+
 func TestPrintSuccess(t *testing.T) {
 	output := captureStdout(t, func() {
 		printSuccess("it worked")
@@ -217,6 +259,9 @@ func TestParseFlags_Defaults(t *testing.T) {
 	if f.force {
 		t.Error("force should default to false")
 	}
+	if f.forcePushed || f.forceDirty {
+		t.Error("force-pushed and force-dirty should default to false")
+	}
 	if f.interactive {
 		t.Error("interactive should default to false")
 	}
@@ -276,6 +321,23 @@ func TestParseFlags_WithFlags(t *testing.T) {
 	}
 }
 
+func TestParseFlags_Alias(t *testing.T) {
+	oldCommandLine := flag.CommandLine
+	defer func() { flag.CommandLine = oldCommandLine }()
+
+	flag.CommandLine = flag.NewFlagSet("commit", flag.ContinueOnError)
+	f := parseArgs([]string{"--install-alias"})
+	if f.installAlias != "sc" {
+		t.Errorf("bare --install-alias = %q, want sc", f.installAlias)
+	}
+
+	flag.CommandLine = flag.NewFlagSet("commit", flag.ContinueOnError)
+	f = parseArgs([]string{"--uninstall-alias=ci"})
+	if f.uninstallAlias != "ci" || f.installAlias != "" {
+		t.Errorf("--uninstall-alias=ci parsed as install %q, uninstall %q", f.installAlias, f.uninstallAlias)
+	}
+}
+
 func TestParseFlags_WithMessage(t *testing.T) {
 	oldArgs := os.Args
 	oldCommandLine := flag.CommandLine
@@ -311,3 +373,642 @@ func TestParseFlags_WithLongMessage(t *testing.T) {
 		t.Errorf("message should be 'fix: fixed contract mismatch between engine and desktop', got %q", f.message)
 	}
 }
+
+func TestFlags_ApplyProviderOverride(t *testing.T) {
+	tests := []struct {
+		name         string
+		flags        flags
+		wantProvider string
+		wantModel    string
+		wantChanged  bool
+	}{
+		{"no override", flags{}, "anthropic", "claude-x", false},
+		{"model only", flags{model: "claude-y"}, "anthropic", "claude-y", true},
+		{"provider only drops model", flags{provider: "openai"}, "openai", "", true},
+		{"same provider keeps model", flags{provider: "anthropic"}, "anthropic", "claude-x", true},
+		{"provider/model form", flags{provider: "openai/gpt-4o-mini"}, "openai", "gpt-4o-mini", true},
+		{"explicit model wins", flags{provider: "openai/gpt-4o-mini", model: "gpt-4o"}, "openai", "gpt-4o", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &types.UserConfig{Provider: "anthropic", Model: "claude-x"}
+			changed := tt.flags.applyProviderOverride(cfg)
+
+			if changed != tt.wantChanged {
+				t.Errorf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if cfg.Provider != tt.wantProvider {
+				t.Errorf("Provider = %q, want %q", cfg.Provider, tt.wantProvider)
+			}
+			if cfg.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", cfg.Model, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestFlags_ApplyDeterminism(t *testing.T) {
+	configured := 0.7
+	cfg := &types.UserConfig{Temperature: &configured}
+	flags{}.applyDeterminism(cfg)
+	if *cfg.Temperature != 0.7 || cfg.Seed != nil {
+		t.Errorf("without --deterministic: temperature %v, seed %v", *cfg.Temperature, cfg.Seed)
+	}
+
+	flags{deterministic: true}.applyDeterminism(cfg)
+	if cfg.Temperature == nil || *cfg.Temperature != 0 {
+		t.Errorf("expected temperature 0, got %v", cfg.Temperature)
+	}
+	if cfg.Seed == nil || *cfg.Seed != deterministicSeed {
+		t.Errorf("expected seed %d, got %v", deterministicSeed, cfg.Seed)
+	}
+	if configured != 0.7 {
+		t.Error("the configured temperature was overwritten in place")
+	}
+}
+
+func TestProfileArg(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"@quick"}, ""},
+		{[]string{"--profile", "work", "@quick"}, "work"},
+		{[]string{"-profile=home", "@quick"}, "home"},
+		{[]string{"--", "--profile", "work"}, ""},
+	}
+	for _, tt := range tests {
+		if got := profileArg(tt.args); got != tt.want {
+			t.Errorf("profileArg(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestPrintGitTrace(t *testing.T) {
+	entries := []git.TraceEntry{
+		{Args: []string{"status", "--porcelain"}, Duration: 3 * time.Millisecond, Output: " M a.go\n"},
+		{Args: []string{"commit", "-m", "feat: add a"}, Duration: 12 * time.Millisecond, ExitCode: 1, Stderr: "hook failed\n"},
+	}
+
+	output := captureStdout(t, func() { printGitTrace(entries, false) })
+
+	for _, want := range []string{
+		"Git trace (2 commands)",
+		"   ✓    3ms  git status --porcelain\n",
+		"   ✗   12ms  git commit -m \"feat: add a\" (exit 1)\n",
+		"   │ hook failed\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "M a.go") {
+		t.Error("command output should only be shown with -v")
+	}
+}
+
+func TestValidationFindings(t *testing.T) {
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add api", Files: []string{"api.go", "gone.go"}},
+	}}
+	errs := []planner.ValidationError{
+		{Field: "commits[0].files[1]", Message: "file does not exist: gone.go"},
+		{Field: "commits[0].type", Message: "commit type is empty"},
+		{Field: "commits[3].files[0]", Message: "out of range"},
+	}
+
+	findings := validationFindings(plan, errs)
+	if len(findings) != 3 {
+		t.Fatalf("findings = %d, want 3", len(findings))
+	}
+	if findings[0].File != "gone.go" || findings[0].RuleID != report.RuleInvalidPlan || findings[0].Level != report.LevelError {
+		t.Errorf("findings[0] = %+v", findings[0])
+	}
+	if findings[1].File != "" || findings[2].File != "" {
+		t.Errorf("non-file findings got locations: %+v", findings[1:])
+	}
+}
+
+func TestParseFlags_Output(t *testing.T) {
+	oldCommandLine := flag.CommandLine
+	defer func() { flag.CommandLine = oldCommandLine }()
+
+	flag.CommandLine = flag.NewFlagSet("commit", flag.ContinueOnError)
+	f := parseArgs([]string{"--output", "sarif", "--dry-run"})
+	if f.output != "sarif" || !f.dryRun {
+		t.Errorf("output = %q, dryRun = %v", f.output, f.dryRun)
+	}
+}
+
+func TestWritePlanSummary(t *testing.T) {
+	commits := []types.ExecutedCommit{{Hash: "0123456789", Message: "feat: add export", Files: []string{"export.go"}, Reasoning: "New feature"}}
+
+	path := filepath.Join(t.TempDir(), "plan.md")
+	if err := writePlanSummary(path, nil, commits); err != nil {
+		t.Fatalf("writePlanSummary failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "**`feat: add export`** (0123456)\n   New feature") {
+		t.Errorf("plan summary file = %q, %v", data, err)
+	}
+
+	var stdout bytes.Buffer
+	if err := writePlanSummary("-", &stdout, commits); err != nil || stdout.String() != string(data) {
+		t.Errorf("stdout summary = %q, %v", stdout.String(), err)
+	}
+}
+
+func TestParseFlags_MaxWait(t *testing.T) {
+	oldCommandLine := flag.CommandLine
+	defer func() { flag.CommandLine = oldCommandLine }()
+
+	flag.CommandLine = flag.NewFlagSet("commit", flag.ContinueOnError)
+	f := parseArgs([]string{"--max-wait", "20s"})
+	if f.maxWait != 20*time.Second {
+		t.Errorf("maxWait = %v, want 20s", f.maxWait)
+	}
+}
+
+func TestParseFlags_Fast(t *testing.T) {
+	oldCommandLine := flag.CommandLine
+	defer func() { flag.CommandLine = oldCommandLine }()
+
+	flag.CommandLine = flag.NewFlagSet("commit", flag.ContinueOnError)
+	if f := parseArgs([]string{"--fast"}); !f.fast {
+		t.Error("expected --fast to set fast")
+	}
+}
+
+func TestPromptKey_LineMode(t *testing.T) {
+	choices := []promptChoice{{'1', "first"}, {'2', "second"}, {'q', "neither"}}
+	tests := []struct {
+		input string
+		want  byte
+	}{
+		{"2\n", '2'},
+		{"Yes\n", 'q'}, // not a choice
+		{"\n", 'q'},
+		{"", 'q'},
+	}
+	for _, tt := range tests {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, _ = w.WriteString(tt.input)
+		_ = w.Close()
+
+		origStdin := os.Stdin
+		os.Stdin = r
+		got := promptKey("pick", choices, 'q')
+		os.Stdin = origStdin
+		_ = r.Close()
+
+		if got != tt.want {
+			t.Errorf("promptKey(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestPromptFooter(t *testing.T) {
+	if got := promptFooter(yesNo, 'n'); !strings.Contains(got, "[y] yes  [N] no") {
+		t.Errorf("promptFooter() = %q", got)
+	}
+}
+
+func TestParseFlags_Verify(t *testing.T) {
+	oldCommandLine := flag.CommandLine
+	defer func() { flag.CommandLine = oldCommandLine }()
+
+	flag.CommandLine = flag.NewFlagSet("commit", flag.ContinueOnError)
+	if f := parseArgs([]string{"--verify"}); !f.verify {
+		t.Error("expected --verify to set verify")
+	}
+}
+
+func TestParseFlags_Stats(t *testing.T) {
+	oldCommandLine := flag.CommandLine
+	defer func() { flag.CommandLine = oldCommandLine }()
+
+	flag.CommandLine = flag.NewFlagSet("commit", flag.ContinueOnError)
+	f := parseArgs([]string{"--stats", "--hotspots", "--since", "2w"})
+	if !f.stats || !f.hotspots || f.since != "2w" {
+		t.Errorf("stats, hotspots, since = %v, %v, %q", f.stats, f.hotspots, f.since)
+	}
+}
+
+func TestParsePeriod(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", defaultStatsPeriod, false},
+		{"7d", 7 * 24 * time.Hour, false},
+		{"2w", 14 * 24 * time.Hour, false},
+		{"72h", 72 * time.Hour, false},
+		{"0d", 0, true},
+		{"xd", 0, true},
+		{"-1h", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parsePeriod(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parsePeriod(%q) = %v, %v", tt.in, got, err)
+		}
+	}
+}
+
+func TestCheckBudget(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	if err := logging.RecordUsage(time.Now(), "openai", 1000, 200, 7.5); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &types.UserConfig{}
+	if !checkBudget(flags{hardBudget: true}, cfg, 100, nil) {
+		t.Error("refused a request without a budget")
+	}
+
+	cfg.MonthlyBudgetUSD = 10
+	if !checkBudget(flags{hardBudget: true}, cfg, 1, nil) {
+		t.Error("refused a request within the budget")
+	}
+	if !checkBudget(flags{}, cfg, 5, nil) {
+		t.Error("refused a request over the budget without --hard-budget")
+	}
+	if checkBudget(flags{hardBudget: true}, cfg, 5, nil) {
+		t.Error("allowed a request over the budget with --hard-budget")
+	}
+}
+
+func TestRegistryCommits(t *testing.T) {
+	scope := "api"
+	got := registryCommits([]types.ExecutedCommit{
+		{Hash: "abc", Type: "fix", Scope: &scope, Files: []string{"api/a.go"}},
+		{Hash: "def", Type: "docs", Files: []string{"README.md"}},
+	})
+	want := []logging.RegistryCommit{
+		{Hash: "abc", Type: "fix", Scope: "api", Files: []string{"api/a.go"}},
+		{Hash: "def", Type: "docs", Files: []string{"README.md"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("registryCommits() = %+v, want %+v", got, want)
+	}
+}
+
+func TestParseFlags_Focus(t *testing.T) {
+	oldCommandLine := flag.CommandLine
+	defer func() { flag.CommandLine = oldCommandLine }()
+
+	flag.CommandLine = flag.NewFlagSet("commit", flag.ContinueOnError)
+	f := parseArgs([]string{"--focus", "api/", "--focus", "web/*.ts, docs"})
+	want := []string{"api/", "web/*.ts", "docs"}
+	if !reflect.DeepEqual(f.focus, want) {
+		t.Errorf("focus = %v, want %v", f.focus, want)
+	}
+}
+
+func TestParseCompare(t *testing.T) {
+	specs, err := parseCompare("openai/gpt-4o-mini, anthropic")
+	if err != nil || specs[0] != "openai/gpt-4o-mini" || specs[1] != "anthropic" {
+		t.Errorf("parseCompare() = %v, %v", specs, err)
+	}
+
+	for _, bad := range []string{"openai", "openai,", "a,b,c", ""} {
+		if _, err := parseCompare(bad); err == nil {
+			t.Errorf("parseCompare(%q) should fail", bad)
+		}
+	}
+}
+
+func TestPrintPlanComparison(t *testing.T) {
+	a := comparedPlan{label: "openai/gpt-4o", plan: &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add handler", Files: []string{"api.go"}},
+		{Type: "docs", Message: "update readme", Files: []string{"README.md"}},
+	}}}
+	b := comparedPlan{label: "openai/gpt-4o-mini", plan: &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add api handler", Files: []string{"api.go", "README.md"}},
+	}}}
+
+	out := captureStdout(t, func() { printPlanComparison(a, b) })
+
+	for _, want := range []string{
+		"1) openai/gpt-4o (2 commits)",
+		"2) openai/gpt-4o-mini (1 commits)",
+		"2. docs: update readme",
+		"Different grouping: 2 commits only in 1, 1 only in 2",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	out = captureStdout(t, func() { printPlanComparison(a, a) })
+	if !strings.Contains(out, "Plans are identical") {
+		t.Errorf("identical plans not reported:\n%s", out)
+	}
+}
+
+func TestFitColumn(t *testing.T) {
+	if got := fitColumn("short"); len([]rune(got)) != compareColumnWidth {
+		t.Errorf("fitColumn pads to %d runes, want %d", len([]rune(got)), compareColumnWidth)
+	}
+	long := strings.Repeat("é", compareColumnWidth+5)
+	if got := []rune(fitColumn(long)); len(got) != compareColumnWidth || got[len(got)-1] != '…' {
+		t.Errorf("fitColumn(long) = %q", string(got))
+	}
+}
+
+func TestHandleBugReport(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Chdir(t.TempDir())
+
+	var code int
+	out := captureStdout(t, func() { code = handleBugReport() })
+	if code != 1 || !strings.Contains(out, "No failed runs") {
+		t.Errorf("handleBugReport() = %d, %q; want 1 with no failed runs", code, out)
+	}
+
+	logger, _ := logging.NewExecutionLogger("exec_failed")
+	logger.LogError(errors.New("boom"))
+	_ = logger.Close()
+	_ = logging.WriteRegistryEntry(logging.RegistryEntry{ExecutionID: "exec_failed", ExitCode: 1})
+
+	out = captureStdout(t, func() { code = handleBugReport() })
+	if code != 0 {
+		t.Fatalf("handleBugReport() = %d, output %q", code, out)
+	}
+	if _, err := os.Stat("commit-bug-report-exec_failed.tar.gz"); err != nil {
+		t.Errorf("expected archive in the working directory: %v", err)
+	}
+}
+
+func TestHandleLint(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "a.txt", "a\n")
+	testutil.GitAdd(t, repoDir, "a.txt")
+	base := testutil.GitCommit(t, repoDir, "chore: initial commit")
+	testutil.CreateFile(t, repoDir, "b.txt", "b\n")
+	testutil.GitAdd(t, repoDir, "b.txt")
+	testutil.GitCommit(t, repoDir, "feat: add b")
+	t.Chdir(repoDir)
+
+	var code int
+	out := captureStdout(t, func() { code = handleLint(flags{lint: base + "..HEAD"}, io.Discard) })
+	if code != 0 || !strings.Contains(out, "1 commits follow") {
+		t.Errorf("handleLint() = %d, %q; want 0", code, out)
+	}
+
+	testutil.CreateFile(t, repoDir, "c.txt", "c\n")
+	testutil.GitAdd(t, repoDir, "c.txt")
+	testutil.GitCommit(t, repoDir, "Added c")
+
+	var sarif bytes.Buffer
+	out = captureStdout(t, func() { code = handleLint(flags{lint: base + "..HEAD", output: "sarif"}, &sarif) })
+	if code != 1 || !strings.Contains(out, `Added c: not in "type(scope): message" form`) {
+		t.Errorf("handleLint() = %d, %q; want 1 with the bad subject", code, out)
+	}
+	if !strings.Contains(sarif.String(), report.RuleInvalidMessage) {
+		t.Errorf("expected an invalid-message finding in the SARIF report:\n%s", sarif.String())
+	}
+}
+
+func TestParseFlags_VeryVerbose(t *testing.T) {
+	oldCommandLine := flag.CommandLine
+	defer func() { flag.CommandLine = oldCommandLine }()
+
+	flag.CommandLine = flag.NewFlagSet("commit", flag.ContinueOnError)
+	f := parseArgs([]string{"-vv"})
+	if !f.verbose || !f.gitTrace {
+		t.Errorf("-vv: verbose = %v, gitTrace = %v; want both set", f.verbose, f.gitTrace)
+	}
+}
+
+func TestParseFlags_OnError(t *testing.T) {
+	oldCommandLine := flag.CommandLine
+	defer func() { flag.CommandLine = oldCommandLine }()
+
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{nil, planner.OnErrorAbort},
+		{[]string{"--on-error", "ask"}, planner.OnErrorAsk},
+		{[]string{"--continue-on-error"}, planner.OnErrorSkip},
+		{[]string{"--continue-on-error", "--on-error", "ask"}, planner.OnErrorAsk},
+	} {
+		flag.CommandLine = flag.NewFlagSet("commit", flag.ContinueOnError)
+		if f := parseArgs(tt.args); f.onError != tt.want {
+			t.Errorf("%v: onError = %q, want %q", tt.args, f.onError, tt.want)
+		}
+	}
+}
+
+func TestLoadLogLevel(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer func() { consoleLevel = levelInfo }()
+
+	tests := []struct {
+		env         string
+		verbose     bool
+		want        logLevel
+		wantVerbose bool
+	}{
+		{"", false, levelInfo, false},
+		{"warn", false, levelWarn, false},
+		{"ERROR", false, levelError, false},
+		{"debug", false, levelDebug, true},
+		{"warn", true, levelDebug, true},
+		{"loud", false, levelInfo, false},
+	}
+	for _, tt := range tests {
+		consoleLevel = levelInfo
+		t.Setenv("COMMIT_LOG_LEVEL", tt.env)
+		f := flags{verbose: tt.verbose}
+		captureStdout(t, func() { loadLogLevel(&f) })
+		if consoleLevel != tt.want || f.verbose != tt.wantVerbose {
+			t.Errorf("COMMIT_LOG_LEVEL=%q -v=%v: level %v verbose %v, want %v %v",
+				tt.env, tt.verbose, consoleLevel, f.verbose, tt.want, tt.wantVerbose)
+		}
+	}
+}
+
+func TestEmit_LevelsAndMirror(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	defer func() { consoleLevel = levelInfo }()
+
+	logger, err := logging.NewExecutionLogger("exec_console_mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer logger.Close() //nolint:errcheck // test cleanup
+	setConsoleMirror(logger)
+	defer setConsoleMirror(nil)
+
+	consoleLevel = levelWarn
+	out := captureStdout(t, func() {
+		printStep("🔧", "Loading config...")
+		printVerbose("details")
+		printWarning("careful")
+		printError("Failed", errors.New("boom"))
+	})
+	if strings.Contains(out, "Loading config") || strings.Contains(out, "details") {
+		t.Errorf("info and debug messages printed at warn level:\n%s", out)
+	}
+	if !strings.Contains(out, "careful") || !strings.Contains(out, "Failed: boom") {
+		t.Errorf("expected the warning and error, got:\n%s", out)
+	}
+
+	_ = logger.Close()
+	content, err := os.ReadFile(logger.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(content)
+	if strings.Contains(log, "Loading config") {
+		t.Errorf("unprinted message mirrored to the log:\n%s", log)
+	}
+	for _, want := range []string{`"level":"warn","message":"careful"`, `"level":"error","message":"Failed: boom"`} {
+		if !strings.Contains(log, want) {
+			t.Errorf("log missing %s:\n%s", want, log)
+		}
+	}
+}
+
+func TestUpdateCheckMode(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	for _, name := range ciEnvVars {
+		t.Setenv(name, "")
+	}
+
+	tests := []struct {
+		env   string
+		flags flags
+		want  string
+	}{
+		{"", flags{}, updater.CheckDaily},
+		{"always", flags{}, updater.CheckAlways},
+		{"never", flags{}, updater.CheckNever},
+		{"hourly", flags{}, updater.CheckDaily},
+		{"always", flags{output: "sarif"}, updater.CheckNever},
+	}
+	for _, tt := range tests {
+		t.Setenv("COMMIT_UPDATE_CHECK", tt.env)
+		if got := updateCheckMode(tt.flags); got != tt.want {
+			t.Errorf("COMMIT_UPDATE_CHECK=%q, output %q: mode = %q, want %q", tt.env, tt.flags.output, got, tt.want)
+		}
+	}
+
+	// CI runs never check
+	t.Setenv("COMMIT_UPDATE_CHECK", "always")
+	t.Setenv("GITHUB_ACTIONS", "true")
+	if got := updateCheckMode(flags{}); got != updater.CheckNever {
+		t.Errorf("in CI: mode = %q, want never", got)
+	}
+}
+
+func TestHandleDoctor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models/gpt-4o" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, `{"id": "gpt-4o"}`)
+	}))
+	defer server.Close()
+
+	fakeHome := t.TempDir()
+	configDir := filepath.Join(fakeHome, ".commit-tool")
+	if err := os.MkdirAll(configDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	env := "COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\nCOMMIT_MODEL=gpt-4o\n" +
+		"COMMIT_BASE_URL=" + server.URL + "/v1/chat/completions\n" +
+		"COMMIT_PROXY=http://proxy.invalid:3128\n"
+	if err := os.WriteFile(filepath.Join(configDir, ".env"), []byte(env), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", fakeHome)
+	t.Setenv("COMMIT_PROXY", "")
+	t.Setenv("NO_PROXY", "127.0.0.1")
+	t.Cleanup(func() { _ = httpclient.SetProxy("") })
+
+	var code int
+	out := captureStdout(t, func() {
+		loadNetwork()
+		code = handleDoctor(flags{})
+	})
+	if code != 0 {
+		t.Fatalf("handleDoctor() = %d, output:\n%s", code, out)
+	}
+	for _, want := range []string{
+		"Proxy settings: COMMIT_PROXY",
+		"Update checks: via http://proxy.invalid:3128",
+		server.URL + "/v1/chat/completions): direct",
+		"accepted the key for gpt-4o",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output should contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestNotifyWebhook(t *testing.T) {
+	var got webhook.Summary
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get(webhook.SignatureHeader)
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("invalid summary: %v", err)
+		}
+		if signature != webhook.Sign("s3cret", body) {
+			t.Errorf("signature %q doesn't match the body", signature)
+		}
+	}))
+	defer server.Close()
+
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "api.go", "package api\n")
+	testutil.GitAdd(t, repoDir, "api.go")
+	hash := testutil.GitCommit(t, repoDir, "feat: add api")
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("COMMIT_WEBHOOK_URL", server.URL+"/hooks/T0KEN")
+	t.Setenv("COMMIT_WEBHOOK_SECRET", "s3cret")
+
+	result := executeResult{
+		Duration:       1500 * time.Millisecond,
+		CommitsCreated: []types.ExecutedCommit{{Hash: hash, Type: "feat", Message: "add api", Files: []string{"api.go"}}},
+	}
+	notifyWebhook(repoDir, flags{}, result, nil)
+
+	if got.Event != webhook.EventRunCompleted || got.Repo != filepath.Base(repoDir) || !got.Success || got.DurationMS != 1500 {
+		t.Errorf("summary = %+v", got)
+	}
+	if got.Branch == "" {
+		t.Error("summary should name the branch")
+	}
+	if len(got.Commits) != 1 || got.Commits[0].Hash != hash || got.Commits[0].Message != "feat: add api" {
+		t.Errorf("commits = %+v", got.Commits)
+	}
+}
+
+func TestNotifyWebhook_Unreachable(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("COMMIT_WEBHOOK_URL", "http://127.0.0.1:1/hooks/T0KEN")
+
+	out := captureStdout(t, func() {
+		notifyWebhook(testutil.TestRepo(t), flags{}, executeResult{}, nil)
+	})
+	if !strings.Contains(out, "Webhook not delivered") {
+		t.Errorf("expected a delivery warning, got: %s", out)
+	}
+	if strings.Contains(out, "T0KEN") {
+		t.Errorf("warning leaks the webhook URL: %s", out)
+	}
+}
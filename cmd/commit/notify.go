@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/logging"
+	"github.com/dsswift/commit/internal/webhook"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// runSummary describes a finished run in gitRoot for the webhook.
+func runSummary(gitRoot string, flags flags, result executeResult) *webhook.Summary {
+	collector := git.NewCollector(gitRoot)
+	branch, _ := collector.CurrentBranch()
+
+	commits := make([]webhook.Commit, 0, len(result.CommitsCreated))
+	for _, c := range result.CommitsCreated {
+		commits = append(commits, webhook.Commit{
+			Hash:    c.Hash,
+			Message: convention.Format(types.PlannedCommit{Type: c.Type, Scope: c.Scope, Message: c.Message}),
+			Files:   c.Files,
+		})
+	}
+
+	return &webhook.Summary{
+		Event:      webhook.EventRunCompleted,
+		Timestamp:  time.Now().UTC().Format(time.RFC3339),
+		Version:    Version,
+		Repo:       filepath.Base(gitRoot),
+		Remote:     collector.OriginURL(),
+		Branch:     branch,
+		Commits:    commits,
+		DurationMS: result.Duration.Milliseconds(),
+		Success:    result.ExitCode == 0,
+		ExitCode:   result.ExitCode,
+		DryRun:     flags.dryRun,
+	}
+}
+
+// notifyWebhook posts the run's summary to COMMIT_WEBHOOK_URL, signed with
+// COMMIT_WEBHOOK_SECRET when set. A failed delivery only warns.
+func notifyWebhook(gitRoot string, flags flags, result executeResult, logger *logging.ExecutionLogger) {
+	hookURL := configSetting("COMMIT_WEBHOOK_URL")
+	if hookURL == "" {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhook.Timeout)
+	defer cancel()
+
+	err := webhook.Send(ctx, hookURL, configSetting("COMMIT_WEBHOOK_SECRET"), runSummary(gitRoot, flags, result))
+
+	// Webhook URLs often carry a token; keep them out of errors
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	if logger != nil {
+		host := hookURL
+		if u, parseErr := url.Parse(hookURL); parseErr == nil {
+			host = u.Host
+		}
+		logger.LogWebhook(host, err)
+	}
+	if err != nil {
+		printWarning(fmt.Sprintf("Webhook not delivered: %v", err))
+	}
+}
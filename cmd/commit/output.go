@@ -1,37 +1,235 @@
 package main
 
-import "fmt"
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/logging"
+	"github.com/dsswift/commit/internal/theme"
+)
 
 // Console output helpers
 
+// outputTheme decorates all console output. It is set once at startup.
+var outputTheme = theme.Default()
+
+// loadTheme selects the output theme from COMMIT_THEME in the environment
+// or the config file. An invalid theme warns and keeps the default.
+func loadTheme() {
+	name := os.Getenv("COMMIT_THEME")
+	if name == "" {
+		name, _ = config.GetConfigValue("COMMIT_THEME")
+	}
+
+	t, err := theme.Load(name)
+	if err != nil {
+		printWarning(fmt.Sprintf("Ignoring theme: %v", err))
+		return
+	}
+	outputTheme = t
+}
+
+// logLevel orders console messages by importance; a message is printed when
+// its level is at or below the configured one.
+type logLevel int
+
+const (
+	levelError logLevel = iota
+	levelWarn
+	levelInfo
+	levelDebug
+)
+
+// logLevelNames are the names accepted by COMMIT_LOG_LEVEL, in level order.
+var logLevelNames = []string{"error", "warn", "info", "debug"}
+
+func (l logLevel) String() string { return logLevelNames[l] }
+
+// parseLogLevel parses a level name, case-insensitively.
+func parseLogLevel(name string) (logLevel, error) {
+	for i, n := range logLevelNames {
+		if strings.EqualFold(name, n) {
+			return logLevel(i), nil
+		}
+	}
+	return levelInfo, fmt.Errorf("unknown log level %q (supported: %s)", name, strings.Join(logLevelNames, ", "))
+}
+
+// consoleLevel is the most detailed level printed. It is set once at startup.
+var consoleLevel = levelInfo
+
+// consoleMirror, when set, receives every printed message so the execution
+// log shows what the user saw.
+var (
+	consoleMirror   *logging.ExecutionLogger
+	consoleMirrorMu sync.Mutex
+)
+
+// loadLogLevel sets the console level from COMMIT_LOG_LEVEL in the environment
+// or the config file, raised to debug by -v or -vv. Verbose output follows the
+// level, so COMMIT_LOG_LEVEL=debug behaves like -v. An invalid level warns and
+// keeps the default.
+func loadLogLevel(f *flags) {
+	name := os.Getenv("COMMIT_LOG_LEVEL")
+	if name == "" {
+		name, _ = config.GetConfigValue("COMMIT_LOG_LEVEL")
+	}
+	if name != "" {
+		level, err := parseLogLevel(name)
+		if err != nil {
+			printWarning(fmt.Sprintf("Ignoring log level: %v", err))
+		} else {
+			consoleLevel = level
+		}
+	}
+
+	if f.verbose {
+		consoleLevel = levelDebug
+	}
+	f.verbose = consoleLevel >= levelDebug
+}
+
+// mirrorConsoleEnabled reports whether COMMIT_LOG_CONSOLE, in the environment
+// or the config file, asks for console output in the execution log.
+func mirrorConsoleEnabled() bool {
+	value := os.Getenv("COMMIT_LOG_CONSOLE")
+	if value == "" {
+		value, _ = config.GetConfigValue("COMMIT_LOG_CONSOLE")
+	}
+	enabled, _ := strconv.ParseBool(value)
+	return enabled
+}
+
+// setConsoleMirror starts (or, with nil, stops) mirroring console output
+// into logger.
+func setConsoleMirror(logger *logging.ExecutionLogger) {
+	consoleMirrorMu.Lock()
+	defer consoleMirrorMu.Unlock()
+	consoleMirror = logger
+}
+
+// emit prints line when level is enabled, mirroring message (the text
+// without glyphs or color) to the execution log.
+func emit(level logLevel, message, line string) {
+	if level > consoleLevel {
+		return
+	}
+	fmt.Print(line)
+
+	consoleMirrorMu.Lock()
+	defer consoleMirrorMu.Unlock()
+	if consoleMirror != nil {
+		consoleMirror.LogConsole(level.String(), message)
+	}
+}
+
+// paint renders s in color, or returns it unchanged when color is empty.
+func paint(color, s string) string {
+	if color == "" {
+		return s
+	}
+	return lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(s)
+}
+
 func printStep(emoji, message string) {
-	fmt.Printf("\n%s %s\n", emoji, message)
+	emit(levelInfo, message, fmt.Sprintf("\n%s %s\n", paint(outputTheme.Colors.Step, outputTheme.StepIcon(emoji)), message))
 }
 
 func printSuccess(message string) {
-	fmt.Printf("   ✓ %s\n", message)
+	emit(levelInfo, message, fmt.Sprintf("   %s %s\n", paint(outputTheme.Colors.Success, outputTheme.Success), message))
 }
 
 func printStepError(message string) {
-	fmt.Printf("   ✗ %s\n", message)
+	emit(levelError, message, fmt.Sprintf("   %s %s\n", paint(outputTheme.Colors.Error, outputTheme.Error), message))
 }
 
 func printProgress(message string) {
-	fmt.Printf("   ⋯ %s\n", message)
+	emit(levelInfo, message, fmt.Sprintf("   %s %s\n", paint(outputTheme.Colors.Subtle, outputTheme.Progress), message))
 }
 
+// printVerbose prints detail. Callers only show debug detail when
+// flags.verbose is set, which follows the debug level.
 func printVerbose(message string) {
-	fmt.Printf("   │ %s\n", message)
+	emit(levelInfo, message, fmt.Sprintf("   %s %s\n", paint(outputTheme.Colors.Subtle, outputTheme.Verbose), message))
 }
 
 func printWarning(message string) {
-	fmt.Printf("   ⚠️  %s\n", message)
+	emit(levelWarn, message, fmt.Sprintf("   %s %s\n", paint(outputTheme.Colors.Warning, outputTheme.Warning), message))
 }
 
 func printError(message string, err error) {
-	fmt.Printf("   ✗ %s: %v\n", message, err)
+	message = fmt.Sprintf("%s: %v", message, err)
+	emit(levelError, message, fmt.Sprintf("   %s %s\n", paint(outputTheme.Colors.Error, outputTheme.Error), message))
 }
 
 func printFinal(emoji, message string) {
-	fmt.Printf("\n%s %s\n", emoji, message)
+	emit(levelInfo, message, fmt.Sprintf("\n%s %s\n", paint(outputTheme.Colors.Step, outputTheme.StepIcon(emoji)), message))
+}
+
+// printTreeItem prints one commit of a plan as a branch of a tree.
+func printTreeItem(current, total int, message string) {
+	branch := outputTheme.Box.Middle
+	switch current {
+	case 1:
+		branch = outputTheme.Box.Top
+	case total:
+		branch = outputTheme.Box.Bottom
+	}
+	emit(levelInfo, fmt.Sprintf("[%d/%d] %s", current, total, message),
+		fmt.Sprintf("   %s [%d/%d] %s\n", paint(outputTheme.Colors.Subtle, branch), current, total, message))
+}
+
+// printTreeLeaf prints a file under the current tree item.
+func printTreeLeaf(file string) {
+	emit(levelInfo, file, fmt.Sprintf("   %s  %s %s\n", paint(outputTheme.Colors.Subtle, outputTheme.Box.Vertical),
+		paint(outputTheme.Colors.Subtle, outputTheme.Box.Leaf), file))
+}
+
+// printGitTrace prints the git commands run during this invocation. Failed
+// commands show their stderr; verbose also shows their (truncated) output.
+func printGitTrace(entries []git.TraceEntry, verbose bool) {
+	printStep("🔍", fmt.Sprintf("Git trace (%d commands)", len(entries)))
+	for _, e := range entries {
+		args := make([]string, len(e.Args))
+		for i, a := range e.Args {
+			if a == "" || strings.ContainsAny(a, " \t\n\"'") {
+				a = strconv.Quote(a)
+			}
+			args[i] = a
+		}
+
+		line := fmt.Sprintf("%6s  git %s", e.Duration.Round(time.Millisecond), strings.Join(args, " "))
+		if e.ExitCode != 0 {
+			printStepError(fmt.Sprintf("%s (exit %d)", line, e.ExitCode))
+			for _, l := range nonEmptyLines(e.Stderr) {
+				printVerbose(l)
+			}
+		} else {
+			printSuccess(line)
+		}
+
+		if verbose {
+			for _, l := range nonEmptyLines(e.Output) {
+				printVerbose(l)
+			}
+		}
+	}
+}
+
+// nonEmptyLines splits s into lines, dropping blank ones.
+func nonEmptyLines(s string) []string {
+	var lines []string
+	for _, l := range strings.Split(s, "\n") {
+		if strings.TrimSpace(l) != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines
 }
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// Control keys read in raw mode.
+const (
+	keyCtrlC  = 0x03
+	keyCtrlD  = 0x04
+	keyEscape = 0x1b
+)
+
+// promptChoice is a key promptKey accepts and what it means.
+type promptChoice struct {
+	key  byte
+	help string
+}
+
+// yesNo are the choices of a confirmation.
+var yesNo = []promptChoice{{'y', "yes"}, {'n', "no"}}
+
+// confirm prints prompt and reports whether the user pressed y. Anything
+// else, including Enter, answers no.
+func confirm(prompt string) bool {
+	return promptKey(prompt, yesNo, 'n') == 'y'
+}
+
+// promptKey prints prompt followed by a footer listing choices, with def in
+// upper case, and returns the key of the choice picked. On a terminal a
+// single keypress answers; Enter, Esc and end of input pick def, and Ctrl-C
+// picks q when it's a choice. Without raw mode (stdin isn't a terminal) a
+// line is read instead and its first character counts.
+func promptKey(prompt string, choices []promptChoice, def byte) byte {
+	fmt.Printf("%s %s ", prompt, promptFooter(choices, def))
+
+	state, err := term.MakeRaw(os.Stdin.Fd())
+	if err != nil {
+		return readLineKey(choices, def)
+	}
+	key := readRawKey(choices, def)
+	_ = term.Restore(os.Stdin.Fd(), state)
+	fmt.Println(string(key))
+	return key
+}
+
+// promptFooter renders the keymap shown after a prompt, e.g.
+// "[y] yes  [N] no".
+func promptFooter(choices []promptChoice, def byte) string {
+	parts := make([]string, len(choices))
+	for i, c := range choices {
+		k := string(c.key)
+		if c.key == def {
+			k = strings.ToUpper(k)
+		}
+		parts[i] = fmt.Sprintf("[%s] %s", k, c.help)
+	}
+	return paint(outputTheme.Colors.Subtle, strings.Join(parts, "  "))
+}
+
+// readRawKey reads keypresses until one picks a choice. Escape sequences
+// such as arrow keys are ignored.
+func readRawKey(choices []promptChoice, def byte) byte {
+	buf := make([]byte, 8)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil || n == 0 {
+			return def
+		}
+		if n > 1 && buf[0] == keyEscape {
+			continue
+		}
+
+		k := lowerKey(buf[0])
+		switch {
+		case k == '\r' || k == '\n' || k == keyEscape || k == keyCtrlD:
+			return def
+		case k == keyCtrlC:
+			if hasChoice(choices, 'q') {
+				return 'q'
+			}
+			return def
+		case hasChoice(choices, k):
+			return k
+		}
+	}
+}
+
+// readLineKey reads a line and returns the choice its first character
+// picks, or def.
+func readLineKey(choices []promptChoice, def byte) byte {
+	line, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return def
+	}
+	if k := lowerKey(line[0]); hasChoice(choices, k) {
+		return k
+	}
+	return def
+}
+
+// hasChoice reports whether k is one of choices' keys.
+func hasChoice(choices []promptChoice, k byte) bool {
+	for _, c := range choices {
+		if c.key == k {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerKey lower-cases an ASCII letter.
+func lowerKey(k byte) byte {
+	if k >= 'A' && k <= 'Z' {
+		return k + 'a' - 'A'
+	}
+	return k
+}
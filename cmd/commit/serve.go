@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/server"
+)
+
+const (
+	// serveSocket is the default socket's name in the profile's config directory.
+	serveSocket = "commit.sock"
+	// serveTokenFile holds a TCP server's bearer token, next to serveSocket.
+	serveTokenFile = "serve.token"
+)
+
+// handleServe runs the local API server until interrupted. Config and the
+// provider are loaded once; repositories are opened per request.
+func handleServe(flags flags) int {
+	printStep("🔧", "Loading config...")
+	userConfig, err := config.LoadUserConfig()
+	if err != nil {
+		handleConfigError(err)
+		return 1
+	}
+
+	flags.applyProviderOverride(userConfig)
+	provider, err := getProviderFunc()(userConfig)
+	if err != nil {
+		printError("Failed to create LLM provider", err)
+		return 1
+	}
+	printSuccess(fmt.Sprintf("Provider: %s (%s)", userConfig.Provider, provider.Model()))
+
+	dir, err := config.ProfilePath()
+	if err != nil {
+		printError("Failed to locate config directory", err)
+		return 1
+	}
+	addr := flags.serve
+	if addr == defaultServeAddr {
+		addr = filepath.Join(dir, serveSocket)
+	}
+
+	ln, err := server.Listen(addr)
+	if err != nil {
+		printError("Failed to listen", err)
+		return 1
+	}
+
+	srv := server.New(provider, Version)
+	if ln.Addr().Network() == "tcp" {
+		tokenPath := filepath.Join(dir, serveTokenFile)
+		if err := writeServeToken(srv, tokenPath); err != nil {
+			ln.Close() //nolint:errcheck // already failing
+			printError("Failed to write API token", err)
+			return 1
+		}
+		defer os.Remove(tokenPath) //nolint:errcheck // best-effort cleanup
+		printProgress(fmt.Sprintf("Requests need the bearer token in %s", tokenPath))
+	}
+	srv.SetRequestLog(func(method, path string, status int, elapsed time.Duration) {
+		printProgress(fmt.Sprintf("%s %s %d (%s)", method, path, status, elapsed.Round(time.Millisecond)))
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	printStep("🚀", fmt.Sprintf("Serving on %s (Ctrl+C to stop)", ln.Addr()))
+	err = srv.Serve(ctx, ln)
+	if ln.Addr().Network() == "unix" {
+		_ = os.Remove(ln.Addr().String())
+	}
+	if err != nil {
+		printError("Server failed", err)
+		return 1
+	}
+
+	printFinal("👋", "Server stopped")
+	return 0
+}
+
+// writeServeToken gives srv a new bearer token and writes it to path, readable
+// only by the user, for clients to send with each request.
+func writeServeToken(srv *server.Server, path string) error {
+	token, err := server.NewToken()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	_ = os.Remove(path) // a token left by an earlier server may not be 0600
+	if err := os.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return err
+	}
+	srv.SetToken(token)
+	return nil
+}
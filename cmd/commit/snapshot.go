@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/logging"
+)
+
+// takeSnapshot copies the uncommitted files at gitRoot aside for
+// --snapshot, named after the run so --restore-snapshot=id can find it.
+func takeSnapshot(gitRoot string, logger *logging.ExecutionLogger) error {
+	printStep("📸", "Snapshotting uncommitted files...")
+
+	snap, err := git.TakeSnapshot(gitRoot, planID(logger))
+	if err != nil {
+		printError("Failed to snapshot uncommitted files", err)
+		return err
+	}
+	if logger != nil {
+		logger.LogSnapshot(snap.Dir(), snap.Files)
+	}
+
+	printSuccess(fmt.Sprintf("Saved %d file(s) to %s", len(snap.Files), snap.Dir()))
+	fmt.Printf("   Restore them with --restore-snapshot=%s\n", snap.ID)
+	return nil
+}
+
+// handleRestoreSnapshot copies the files saved by --snapshot back into the
+// working tree and puts their staged versions back in the index.
+func handleRestoreSnapshot(flags flags) int {
+	cwd, err := os.Getwd()
+	if err != nil {
+		printError("Failed to get current directory", err)
+		return 1
+	}
+	gitRoot, err := git.FindGitRoot(cwd)
+	if err != nil {
+		printError("Not a git repository", err)
+		return 1
+	}
+
+	// Don't write files under a run that's staging them
+	lock, err := acquireLock(gitRoot)
+	if err != nil {
+		return 1
+	}
+	defer lock.Release() //nolint:errcheck // best-effort cleanup
+
+	id := flags.restore
+	if id == latestSnapshot {
+		id = ""
+	}
+	snap, err := git.LoadSnapshot(gitRoot, id)
+	if err != nil {
+		var notFound *git.SnapshotNotFoundError
+		if errors.As(err, &notFound) {
+			printFinal("❌", "No snapshot to restore")
+			fmt.Printf("   %v\n", err)
+			return 1
+		}
+		printError("Failed to load snapshot", err)
+		return 1
+	}
+
+	printStep("📸", fmt.Sprintf("Restoring snapshot %s (%s)...", snap.ID, snap.CreatedAt.Local().Format("2006-01-02 15:04:05")))
+	restored, err := snap.Restore(gitRoot)
+	for _, path := range restored {
+		printProgress(path)
+	}
+	if err != nil {
+		printError("Failed to restore snapshot", err)
+		return 1
+	}
+
+	if len(restored) == 0 {
+		printFinal("✅", "Working tree already matches the snapshot")
+		return 0
+	}
+	printFinal("✅", fmt.Sprintf("Restored %d file(s)", len(restored)))
+	return 0
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/logging"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// defaultStatsPeriod is the period --stats covers without --since.
+const defaultStatsPeriod = 30 * 24 * time.Hour
+
+// hotspotLimit caps the files and scopes printed by --hotspots.
+const hotspotLimit = 10
+
+// registryCommits returns the commits recorded in the registry for a run.
+func registryCommits(executed []types.ExecutedCommit) []logging.RegistryCommit {
+	var commits []logging.RegistryCommit
+	for _, c := range executed {
+		commit := logging.RegistryCommit{Hash: c.Hash, Type: c.Type, Files: c.Files}
+		if c.Scope != nil {
+			commit.Scope = *c.Scope
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
+
+// parsePeriod parses a --since period: a number of days ("30d") or weeks
+// ("2w"), or a Go duration ("72h").
+func parsePeriod(s string) (time.Duration, error) {
+	if s == "" {
+		return defaultStatsPeriod, nil
+	}
+
+	unit := time.Duration(0)
+	switch {
+	case strings.HasSuffix(s, "d"):
+		unit = 24 * time.Hour
+	case strings.HasSuffix(s, "w"):
+		unit = 7 * 24 * time.Hour
+	}
+	if unit != 0 {
+		n, err := strconv.Atoi(s[:len(s)-1])
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid period %q (use e.g. 30d, 2w or 72h)", s)
+		}
+		return time.Duration(n) * unit, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid period %q (use e.g. 30d, 2w or 72h)", s)
+	}
+	return d, nil
+}
+
+func handleStats(flags flags) int {
+	period, err := parsePeriod(flags.since)
+	if err != nil {
+		printError("Invalid --since", err)
+		return 1
+	}
+	since := time.Now().Add(-period)
+
+	// Inside a repository, only its runs count; elsewhere, every repository's
+	cwd, _ := os.Getwd()
+	gitRoot, err := git.FindGitRoot(cwd)
+	if err != nil {
+		gitRoot = ""
+	}
+
+	where := "all repositories"
+	if gitRoot != "" {
+		where = gitRoot
+	}
+	printStep("📊", fmt.Sprintf("Runs since %s in %s...", since.Local().Format("2006-01-02"), where))
+
+	stats, err := logging.ReadStats(since, gitRoot)
+	if err != nil {
+		printError("Failed to read execution logs", err)
+		return 1
+	}
+
+	if stats.Runs == 0 {
+		printFinal("❌", "No runs in this period")
+		return 1
+	}
+
+	printProgress(fmt.Sprintf("%d runs created %d commits", stats.Runs, stats.Commits))
+	if stats.Recorded < stats.Commits {
+		printVerbose(fmt.Sprintf("%d commits are from older versions that didn't record files or scopes", stats.Commits-stats.Recorded))
+	}
+	printCounts("Types", stats.Types, len(stats.Types))
+
+	if flags.hotspots {
+		printCounts("Most committed scopes", stats.Scopes, hotspotLimit)
+		printCounts("Most committed files", stats.Files, hotspotLimit)
+	}
+
+	printFinal("✅", fmt.Sprintf("%d commits in %d runs", stats.Commits, stats.Runs))
+	return 0
+}
+
+// printCounts prints up to limit counts under a heading.
+func printCounts(heading string, counts []logging.StatCount, limit int) {
+	if len(counts) == 0 {
+		return
+	}
+	if len(counts) > limit {
+		counts = counts[:limit]
+	}
+
+	fmt.Printf("\n   %s\n", heading)
+	for i, c := range counts {
+		printTreeItem(i+1, len(counts), fmt.Sprintf("%4d  %s", c.Commits, c.Name))
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/logging"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// budgetAlertRatio is the share of COMMIT_MONTHLY_BUDGET_USD past which each
+// request warns.
+const budgetAlertRatio = 0.8
+
+// expectedResponseTokens stands in for the size of a plan before it arrives,
+// when estimating what a request will cost.
+const expectedResponseTokens = 1000
+
+// errBudgetExceeded is returned when --hard-budget refused a request;
+// checkBudget has already said why.
+var errBudgetExceeded = errors.New("monthly budget exceeded")
+
+// requestCost estimates what a request to model with promptTokens will cost,
+// or 0 when the model's price is unknown.
+func requestCost(model string, promptTokens int) float64 {
+	return max(estimateCost(model, promptTokens, expectedResponseTokens), 0)
+}
+
+// checkBudget compares this month's usage plus a request estimated to cost
+// cost against COMMIT_MONTHLY_BUDGET_USD. It warns when the request takes
+// usage past budgetAlertRatio or the budget, and returns false when the
+// request would exceed the budget under --hard-budget.
+func checkBudget(flags flags, userConfig *types.UserConfig, cost float64, logger *logging.ExecutionLogger) bool {
+	budget := userConfig.MonthlyBudgetUSD
+	if budget <= 0 {
+		return true
+	}
+	usage, err := logging.ReadUsage()
+	if err != nil {
+		return true
+	}
+
+	spent := usage.MonthCost(time.Now())
+	switch {
+	case spent+cost > budget:
+		if logger != nil {
+			logger.LogBudgetAlert(spent, cost, budget, flags.hardBudget)
+		}
+		message := fmt.Sprintf("This request (~$%.2f) would take this month's usage past the $%.2f budget ($%.2f spent)", cost, budget, spent)
+		if flags.hardBudget {
+			printStepError(message)
+			printFinal("❌", "Monthly budget exceeded")
+			fmt.Println("   Raise COMMIT_MONTHLY_BUDGET_USD or run without --hard-budget; commit --usage shows this month's usage.")
+			return false
+		}
+		printWarning(message)
+	case spent+cost >= budget*budgetAlertRatio:
+		if logger != nil {
+			logger.LogBudgetAlert(spent, cost, budget, false)
+		}
+		printWarning(fmt.Sprintf("%.0f%% of this month's $%.2f budget used", (spent+cost)/budget*100, budget))
+	}
+	return true
+}
+
+// recordUsage adds a request to this month's usage. Failures are ignored;
+// tracking usage never fails a run.
+func recordUsage(provider, model string, promptTokens, responseTokens int) {
+	_ = logging.RecordUsage(time.Now(), provider, promptTokens, responseTokens, estimateCost(model, promptTokens, responseTokens))
+}
+
+func handleUsage() int {
+	now := time.Now()
+	printStep("📊", fmt.Sprintf("LLM usage in %s...", now.Format("January 2006")))
+
+	usage, err := logging.ReadUsage()
+	if err != nil {
+		printError("Failed to read usage", err)
+		return 1
+	}
+
+	providers := usage.Providers(now)
+	month := usage.Months[logging.UsageMonth(now)]
+	unpriced := 0
+	for i, name := range providers {
+		p := month[name]
+		printTreeItem(i+1, len(providers), fmt.Sprintf("%-14s %4d requests  %9d tokens  $%.2f",
+			name, p.Requests, p.PromptTokens+p.ResponseTokens, p.CostUSD))
+		unpriced += p.Unpriced
+	}
+	if unpriced > 0 {
+		printVerbose(fmt.Sprintf("%d requests were to models without a known price and aren't in the cost", unpriced))
+	}
+
+	spent := usage.MonthCost(now)
+	budget := 0.0
+	if userConfig, err := config.LoadUserConfig(); err == nil {
+		budget = userConfig.MonthlyBudgetUSD
+	}
+	switch {
+	case len(providers) == 0:
+		printFinal("✅", "No LLM requests this month")
+	case budget > 0:
+		printFinal("✅", fmt.Sprintf("~$%.2f of the $%.2f monthly budget used (%.0f%%)", spent, budget, spent/budget*100))
+	default:
+		printFinal("✅", fmt.Sprintf("~$%.2f this month (set COMMIT_MONTHLY_BUDGET_USD for a budget)", spent))
+	}
+	return 0
+}
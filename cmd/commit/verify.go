@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/logging"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// verifyOutputLines caps the failing command output printed per commit.
+const verifyOutputLines = 10
+
+// verifyCommits runs command in a checkout of each executed commit, oldest
+// first, prints which ones fail and returns how many did.
+func verifyCommits(gitRoot, command string, executed []types.ExecutedCommit, verbose bool, logger *logging.ExecutionLogger) int {
+	if len(executed) == 0 {
+		return 0
+	}
+	printStep("🔨", fmt.Sprintf("Running %q at each commit...", command))
+
+	var failed []string
+	for _, c := range executed {
+		err := git.RunAtCommit(gitRoot, c.Hash, command)
+		output := ""
+		var cmdErr *git.CommandFailedError
+		if errors.As(err, &cmdErr) {
+			output = cmdErr.Output
+		}
+		if logger != nil {
+			logger.LogCommitBuild(c.Hash, command, err, output)
+		}
+
+		if err == nil {
+			printSuccess(fmt.Sprintf("%s %s", c.Hash, c.Message))
+			continue
+		}
+		failed = append(failed, c.Hash)
+		printError(fmt.Sprintf("%s %s", c.Hash, c.Message), err)
+		lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+		if !verbose && len(lines) > verifyOutputLines {
+			lines = lines[len(lines)-verifyOutputLines:]
+		}
+		for _, line := range lines {
+			if line != "" {
+				printTreeLeaf(line)
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		printFinal("❌", fmt.Sprintf("%d of %d commits fail %q: %s", len(failed), len(executed), command, strings.Join(failed, ", ")))
+	} else {
+		printFinal("✅", fmt.Sprintf("All %d commits pass %q", len(executed), command))
+	}
+	return len(failed)
+}
@@ -3,16 +3,21 @@ package analyzer
 
 import (
 	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/dsswift/commit/internal/assert"
 	"github.com/dsswift/commit/internal/config"
 	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/planner"
 	"github.com/dsswift/commit/pkg/types"
 )
 
 const (
-	// MaxDiffChars is the maximum number of characters to include in the diff.
-	MaxDiffChars = 4000
+	// MaxUntrackedFiles is the maximum number of new files whose content is included.
+	MaxUntrackedFiles = 20
+	// MaxUntrackedFileBytes is the largest new file whose content is included.
+	MaxUntrackedFileBytes = 16 * 1024
 	// RecentCommitCount is the number of recent commits to include for style reference.
 	RecentCommitCount = 10
 	// DefaultMaxMessageLength is the default max commit message length (subject line).
@@ -24,17 +29,47 @@ type ContextBuilder struct {
 	collector  *git.Collector
 	repoConfig *types.RepoConfig
 	workDir    string
+	focus      []string
+	excludes   []string
 }
 
 // NewContextBuilder creates a new context builder.
 func NewContextBuilder(workDir string, repoConfig *types.RepoConfig) *ContextBuilder {
+	collector := git.NewCollector(workDir)
+	collector.SetDiffOptions(git.DiffOptions{
+		Algorithm:       repoConfig.DiffAlgorithm,
+		FunctionContext: repoConfig.FunctionContext,
+	})
 	return &ContextBuilder{
-		collector:  git.NewCollector(workDir),
+		collector:  collector,
 		repoConfig: repoConfig,
 		workDir:    workDir,
 	}
 }
 
+// SetDiffOptions overrides the diff algorithm and context from the repo config.
+func (b *ContextBuilder) SetDiffOptions(opts git.DiffOptions) {
+	b.collector.SetDiffOptions(opts)
+}
+
+// SetPathspecs restricts the analyzed changes to the given pathspecs.
+func (b *ContextBuilder) SetPathspecs(pathspecs []string) {
+	b.collector.SetPathspecs(pathspecs)
+}
+
+// SetExcludes leaves the given paths (as listed by git status) out of the
+// analysis, e.g. junk being added to .gitignore and the .gitignore itself.
+func (b *ContextBuilder) SetExcludes(paths []string) {
+	b.excludes = paths
+}
+
+// SetFocus sends the full diff only for files matching the given paths or
+// globs (relative to the repository root) and summarizes the rest. Files
+// outside the focus are still analyzed and committed.
+func (b *ContextBuilder) SetFocus(patterns []string) {
+	b.focus = patterns
+}
+
 // Build creates an AnalysisRequest from the current git state.
 func (b *ContextBuilder) Build(stagedOnly bool) (*types.AnalysisRequest, error) {
 	// Get git status
@@ -51,54 +86,193 @@ func (b *ContextBuilder) Build(stagedOnly bool) (*types.AnalysisRequest, error)
 		files = status.AllFiles()
 	}
 
+	files = withoutFiles(files, b.excludes)
 	if len(files) == 0 {
 		return nil, &NoChangesError{}
 	}
 
+	// Keep generated files out of the LLM context, like GitHub does in review
+	generated, err := b.collector.GeneratedFiles(files)
+	if err != nil {
+		// Non-fatal - treat every file as hand-written
+		generated = nil
+	}
+	excluded := append(append([]string(nil), b.excludes...), generated...)
+
+	// Removed directories are summarized by file count instead of their diffs
+	removedDirs, err := b.collector.RemovedDirs()
+	if err != nil {
+		// Non-fatal - their deletions are diffed like any other
+		removedDirs = nil
+	}
+	for dir := range removedDirs {
+		excluded = append(excluded, strings.TrimSuffix(dir, "/"))
+	}
+	if len(excluded) > 0 {
+		files = withoutFiles(files, generated)
+		b.collector.SetExcludes(excluded)
+		defer b.collector.SetExcludes(nil)
+	}
+
 	// Build file changes with scope resolution
-	fileChanges, err := b.buildFileChanges(files, stagedOnly)
+	fileChanges, err := b.buildFileChanges(files, stagedOnly, removedDirs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to build file changes: %w", err)
 	}
 
+	// LFS pointer diffs are just hash swaps; summarize them by size instead
+	lfsFiles, err := b.collector.LFSFiles(files)
+	if err != nil {
+		// Non-fatal - their pointer diffs are included
+		lfsFiles = nil
+	}
+	if len(lfsFiles) > 0 {
+		lfsChanges := b.collector.LFSChanges(lfsFiles, stagedOnly)
+		for i := range fileChanges {
+			fileChanges[i].LFSChange = lfsChanges[fileChanges[i].Path]
+		}
+		b.collector.SetExcludes(append(excluded, lfsFiles...))
+		defer b.collector.SetExcludes(nil)
+	}
+
+	// A symlink's diff is a one-line path; say what kind of change it is
+	symlinks := b.collector.SymlinkChanges(files, stagedOnly)
+	for i := range fileChanges {
+		fileChanges[i].Symlink = symlinks[fileChanges[i].Path]
+	}
+
 	// Get the diff
 	diff, err := b.collector.Diff(stagedOnly)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get diff: %w", err)
 	}
 
-	// Truncate diff if too large
-	truncatedDiff := git.TruncateDiff(diff, MaxDiffChars)
+	// git diff HEAD omits untracked files, so add their content as new-file diffs
+	if !stagedOnly {
+		untracked := withoutFiles(withoutFiles(status.Untracked, excluded), lfsFiles)
+		if newFiles, err := b.collector.UntrackedDiff(untracked, MaxUntrackedFiles, MaxUntrackedFileBytes); err == nil {
+			diff += newFiles
+		}
+	}
+
+	// Spend the diff budget on the files the user asked about, if any changed
+	if b.markFocused(fileChanges) {
+		diff = git.FocusDiff(diff, b.focus)
+	}
+
+	// Providers cut the diff to their context window; see llm.FitPrompt
+	compactedDiff := compactDiff(diff)
 
 	// Get recent commits for style reference
-	recentCommits, err := b.collector.RecentCommits(RecentCommitCount)
+	recentCommits, err := b.collector.RecentCommits(RecentCommitCount, b.repoConfig.ExcludedAuthors()...)
 	if err != nil {
 		// Non-fatal - proceed without recent commits
 		recentCommits = []string{}
 	}
 
+	// Unconfigured repos rarely get scopes; offer directory-based suggestions
+	if b.suggestScopes(recentCommits) {
+		for i := range fileChanges {
+			fileChanges[i].SuggestedScope = config.SuggestScope(fileChanges[i].Path)
+		}
+	}
+
 	// Build the request
 	request := &types.AnalysisRequest{
-		Files:         fileChanges,
-		Diff:          truncatedDiff,
-		RecentCommits: recentCommits,
-		HasScopes:     config.HasScopes(b.repoConfig),
+		Files:          fileChanges,
+		Diff:           compactedDiff,
+		RecentCommits:  recentCommits,
+		HasScopes:      config.HasScopes(b.repoConfig),
+		GeneratedFiles: generated,
 		Rules: types.CommitRules{
 			Types:            b.repoConfig.AllowedTypes(),
 			MaxMessageLength: b.maxMessageLength(),
 			BehavioralTest:   "feat = behavior change, refactor = same behavior different structure",
+			Convention:       b.repoConfig.Convention,
+			ScopePolicy:      b.repoConfig.ScopePolicy,
 		},
 	}
 
+	request.TypeGuesses = planner.GuessTypes(request)
+
 	// POSTCONDITIONS
-	assert.NotEmpty(request.Files, "analysis request must have files")
+	assert.True(len(request.Files) > 0 || len(request.GeneratedFiles) > 0, "analysis request must have files")
 	assert.NotEmpty(request.Rules.Types, "analysis request must have allowed types")
 
 	return request, nil
 }
 
-// buildFileChanges creates FileChange objects from file paths.
-func (b *ContextBuilder) buildFileChanges(files []string, stagedOnly bool) ([]types.FileChange, error) {
+// suggestScopes reports whether files should carry directory-based scope
+// suggestions: when the repo configures no scopes, unless the history shows
+// the project doesn't use them that way. A required scope always gets
+// suggestions, and a forbidden one never does.
+func (b *ContextBuilder) suggestScopes(recentCommits []string) bool {
+	switch {
+	case b.repoConfig.ScopePolicy == types.ScopePolicyForbidden:
+		return false
+	case config.HasScopes(b.repoConfig) || b.repoConfig.DefaultScope != nil:
+		return false
+	case b.repoConfig.ScopePolicy == types.ScopePolicyRequired:
+		return true
+	default:
+		return len(HistoryScopes(recentCommits)) == 0
+	}
+}
+
+// historyScopePattern matches the scope of a conventional commit subject.
+var historyScopePattern = regexp.MustCompile(`^[a-zA-Z]+\(([^)]+)\)!?:`)
+
+// HistoryScopes returns the distinct scopes used in commit subjects, in order
+// of first appearance.
+func HistoryScopes(commits []string) []string {
+	seen := make(map[string]bool)
+	var scopes []string
+	for _, c := range commits {
+		m := historyScopePattern.FindStringSubmatch(c)
+		if m == nil || seen[m[1]] {
+			continue
+		}
+		seen[m[1]] = true
+		scopes = append(scopes, m[1])
+	}
+	return scopes
+}
+
+// markFocused marks the changes matching the focus patterns and reports
+// whether any did.
+func (b *ContextBuilder) markFocused(changes []types.FileChange) bool {
+	if len(b.focus) == 0 {
+		return false
+	}
+	matched := false
+	for i := range changes {
+		if git.MatchesFocus(changes[i].Path, b.focus) {
+			changes[i].Focused = true
+			matched = true
+		}
+	}
+	return matched
+}
+
+// withoutFiles returns files minus any listed in exclude.
+func withoutFiles(files, exclude []string) []string {
+	excluded := make(map[string]bool, len(exclude))
+	for _, f := range exclude {
+		excluded[f] = true
+	}
+
+	var result []string
+	for _, f := range files {
+		if !excluded[f] {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// buildFileChanges creates FileChange objects from file paths. removedDirs
+// are the directories status listed as one deleted entry.
+func (b *ContextBuilder) buildFileChanges(files []string, stagedOnly bool, removedDirs map[string]int) ([]types.FileChange, error) {
 	// Get diff stats for all files
 	numstat, err := b.collector.DiffNumstat(stagedOnly)
 	if err != nil {
@@ -111,6 +285,13 @@ func (b *ContextBuilder) buildFileChanges(files []string, stagedOnly bool) ([]ty
 		return nil, err
 	}
 
+	// Mode-only changes have empty content diffs; describe them explicitly
+	modeChanges, err := b.collector.ModeChanges(stagedOnly)
+	if err != nil {
+		// Non-fatal - the diff still shows the mode lines
+		modeChanges = nil
+	}
+
 	// Build lookup maps for status
 	statusMap := make(map[string]string)
 	for _, f := range status.Modified {
@@ -141,6 +322,11 @@ func (b *ContextBuilder) buildFileChanges(files []string, stagedOnly bool) ([]ty
 		if stat, ok := numstat[file]; ok {
 			change.DiffSummary = stat.DiffSummary
 		}
+		change.ModeChange = modeChanges[file]
+		if n := removedDirs[file]; n > 0 {
+			change.Status = "deleted"
+			change.RemovedDir = fmt.Sprintf("removed directory %s (%d files)", strings.TrimSuffix(file, "/"), n)
+		}
 
 		changes = append(changes, change)
 	}
@@ -168,23 +354,25 @@ func (b *ContextBuilder) BuildForFiles(files []string) (*types.AnalysisRequest,
 		return nil, fmt.Errorf("failed to get diff: %w", err)
 	}
 
-	truncatedDiff := git.TruncateDiff(diff, MaxDiffChars)
+	compactedDiff := compactDiff(diff)
 
 	// Get recent commits for style reference
-	recentCommits, err := b.collector.RecentCommits(RecentCommitCount)
+	recentCommits, err := b.collector.RecentCommits(RecentCommitCount, b.repoConfig.ExcludedAuthors()...)
 	if err != nil {
 		recentCommits = []string{}
 	}
 
 	return &types.AnalysisRequest{
 		Files:         fileChanges,
-		Diff:          truncatedDiff,
+		Diff:          compactedDiff,
 		RecentCommits: recentCommits,
 		HasScopes:     config.HasScopes(b.repoConfig),
 		Rules: types.CommitRules{
 			Types:            b.repoConfig.AllowedTypes(),
 			MaxMessageLength: b.maxMessageLength(),
 			BehavioralTest:   "feat = behavior change, refactor = same behavior different structure",
+			Convention:       b.repoConfig.Convention,
+			ScopePolicy:      b.repoConfig.ScopePolicy,
 		},
 	}, nil
 }
@@ -221,3 +409,10 @@ func (b *ContextBuilder) maxMessageLength() int {
 	}
 	return DefaultMaxMessageLength
 }
+
+// compactDiff collapses changes repeated across files. It doesn't cut the
+// diff: each provider trims it to its own context window when building the
+// prompt.
+func compactDiff(diff string) string {
+	return git.CompressDiff(diff)
+}
@@ -1,6 +1,10 @@
 package analyzer
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/dsswift/commit/internal/testutil"
@@ -52,6 +56,88 @@ func TestContextBuilder_Build_WithChanges(t *testing.T) {
 	}
 }
 
+func TestContextBuilder_Build_IncludesUntrackedContent(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "existing.txt", "initial")
+	testutil.GitAdd(t, repoDir, "existing.txt")
+	testutil.GitCommit(t, repoDir, "initial commit")
+
+	testutil.CreateFile(t, repoDir, "feature.go", "package main\n\nfunc newFeature() {}\n")
+
+	builder := NewContextBuilder(repoDir, &types.RepoConfig{})
+	req, err := builder.Build(false)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if !strings.Contains(req.Diff, "+func newFeature() {}") {
+		t.Errorf("expected untracked file content in diff, got:\n%s", req.Diff)
+	}
+}
+
+func TestContextBuilder_Build_SuggestsScopes(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "README.md", "initial")
+	testutil.GitAdd(t, repoDir, "README.md")
+	testutil.GitCommit(t, repoDir, "initial commit")
+
+	testutil.CreateFile(t, repoDir, "billing/invoice.go", "package billing")
+
+	builder := NewContextBuilder(repoDir, &types.RepoConfig{})
+	req, err := builder.Build(false)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(req.Files) != 1 || req.Files[0].SuggestedScope != "billing" {
+		t.Errorf("expected suggested scope billing, got %+v", req.Files)
+	}
+
+	// Scopes already used in history take precedence over the heuristic
+	testutil.GitAdd(t, repoDir, "billing/invoice.go")
+	testutil.GitCommit(t, repoDir, "feat(payments): add invoices")
+	testutil.CreateFile(t, repoDir, "billing/invoice.go", "package billing // changed")
+
+	req, err = NewContextBuilder(repoDir, &types.RepoConfig{}).Build(false)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if req.Files[0].SuggestedScope != "" {
+		t.Errorf("expected no suggestion when history has scopes, got %q", req.Files[0].SuggestedScope)
+	}
+}
+
+func TestContextBuilder_Build_ScopePolicy(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "billing/invoice.go", "package billing")
+	testutil.GitAdd(t, repoDir, "billing/invoice.go")
+	testutil.GitCommit(t, repoDir, "feat(payments): add invoices")
+	testutil.CreateFile(t, repoDir, "billing/invoice.go", "package billing // changed")
+
+	// A required scope gets suggestions even when history has scopes
+	req, err := NewContextBuilder(repoDir, &types.RepoConfig{ScopePolicy: types.ScopePolicyRequired}).Build(false)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if req.Files[0].SuggestedScope != "billing" || req.Rules.ScopePolicy != types.ScopePolicyRequired {
+		t.Errorf("required: suggested scope %q, policy %q", req.Files[0].SuggestedScope, req.Rules.ScopePolicy)
+	}
+
+	// A forbidden scope never does
+	req, err = NewContextBuilder(repoDir, &types.RepoConfig{ScopePolicy: types.ScopePolicyForbidden}).Build(false)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if req.Files[0].SuggestedScope != "" {
+		t.Errorf("forbidden: suggested scope %q, want none", req.Files[0].SuggestedScope)
+	}
+}
+
+func TestHistoryScopes(t *testing.T) {
+	scopes := HistoryScopes([]string{"feat(api): add users", "fix: typo", "feat(api)!: drop v1", "chore(deps): bump"})
+	if len(scopes) != 2 || scopes[0] != "api" || scopes[1] != "deps" {
+		t.Errorf("HistoryScopes() = %v, want [api deps]", scopes)
+	}
+}
+
 func TestContextBuilder_Build_WithScopes(t *testing.T) {
 	repoDir := testutil.TestRepo(t)
 
@@ -223,6 +309,63 @@ func TestContextBuilder_BuildForFiles(t *testing.T) {
 	}
 }
 
+func TestContextBuilder_BuildFromPatch(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	config := &types.RepoConfig{
+		Scopes: []types.ScopeConfig{{Path: "src/api/", Scope: "api"}},
+	}
+	builder := NewContextBuilder(repoDir, config)
+
+	// The patched file doesn't exist in the repository
+	patch := "diff --git a/src/api/handler.go b/src/api/handler.go\n" +
+		"--- a/src/api/handler.go\n" +
+		"+++ b/src/api/handler.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-old\n" +
+		"+new\n"
+	req, err := builder.BuildFromPatch(patch)
+	if err != nil {
+		t.Fatalf("BuildFromPatch failed: %v", err)
+	}
+
+	if len(req.Files) != 1 || req.Files[0].Path != "src/api/handler.go" || req.Files[0].Scope != "api" {
+		t.Errorf("unexpected files: %+v", req.Files)
+	}
+	if req.Diff != patch {
+		t.Errorf("expected the patch as the diff, got %q", req.Diff)
+	}
+	if !req.HasScopes || len(req.Rules.Types) == 0 {
+		t.Errorf("expected scopes and rules from the repo config, got %+v", req)
+	}
+
+	if _, err := builder.BuildFromPatch(""); err == nil {
+		t.Error("expected NoChangesError for an empty patch")
+	} else if _, ok := err.(*NoChangesError); !ok {
+		t.Errorf("expected NoChangesError, got %T", err)
+	}
+}
+
+func TestContextBuilder_BuildFromPatch_KeepsLargeDiff(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	builder := NewContextBuilder(repoDir, &types.RepoConfig{})
+
+	patch := "diff --git a/big.txt b/big.txt\n" +
+		"--- a/big.txt\n" +
+		"+++ b/big.txt\n" +
+		"@@ -0,0 +1,1000 @@\n" +
+		strings.Repeat("+some added line\n", 1000)
+	req, err := builder.BuildFromPatch(patch)
+	if err != nil {
+		t.Fatalf("BuildFromPatch failed: %v", err)
+	}
+
+	// The provider fits the diff to its context window, not the builder
+	if req.Diff != patch {
+		t.Errorf("expected the whole patch, got %d of %d chars", len(req.Diff), len(patch))
+	}
+}
+
 func TestNoChangesError(t *testing.T) {
 	err := &NoChangesError{}
 	msg := err.Error()
@@ -258,3 +401,123 @@ func TestSummary(t *testing.T) {
 		t.Errorf("expected summary to mention '2 scopes', got: %s", summary)
 	}
 }
+
+func TestContextBuilder_Build_ModeOnlyChange(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "deploy.sh", "#!/bin/sh\n")
+	testutil.GitAdd(t, repoDir, "deploy.sh")
+	testutil.GitCommit(t, repoDir, "initial commit")
+
+	if err := os.Chmod(filepath.Join(repoDir, "deploy.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := NewContextBuilder(repoDir, &types.RepoConfig{}).Build(false)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(req.Files) != 1 || req.Files[0].ModeChange != "made executable" || !req.Files[0].ModeOnly() {
+		t.Errorf("expected deploy.sh as a mode-only change, got %+v", req.Files)
+	}
+}
+
+func TestContextBuilder_Build_RemovedDirectory(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "main.go", "package main\n")
+	for i := range 25 {
+		testutil.CreateFile(t, repoDir, fmt.Sprintf("vendor/lib/f%d.go", i), "package lib\n")
+	}
+	testutil.GitAdd(t, repoDir, ".")
+	testutil.GitCommit(t, repoDir, "initial commit")
+
+	if err := os.RemoveAll(filepath.Join(repoDir, "vendor")); err != nil {
+		t.Fatal(err)
+	}
+	testutil.CreateFile(t, repoDir, "main.go", "package main\n\nfunc main() {}\n")
+
+	req, err := NewContextBuilder(repoDir, &types.RepoConfig{}).Build(false)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+	if len(req.Files) != 2 {
+		t.Fatalf("expected main.go and vendor/, got %+v", req.Files)
+	}
+	for _, f := range req.Files {
+		if f.Path == "vendor/" && (f.Status != "deleted" || f.RemovedDir != "removed directory vendor (25 files)") {
+			t.Errorf("unexpected removed directory change: %+v", f)
+		}
+	}
+	if strings.Contains(req.Diff, "vendor/lib") || !strings.Contains(req.Diff, "func main") {
+		t.Errorf("expected only main.go in the diff, got:\n%s", req.Diff)
+	}
+}
+
+func TestContextBuilder_Build_LFSFiles(t *testing.T) {
+	pointer := func(oid string, size int) string {
+		return fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", oid, size)
+	}
+
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, ".gitattributes", "*.psd filter=lfs diff=lfs merge=lfs -text\n")
+	testutil.CreateFile(t, repoDir, "art/hero.psd", pointer(strings.Repeat("a", 64), 1024))
+	testutil.GitAdd(t, repoDir, ".gitattributes", "art/hero.psd")
+	testutil.GitCommit(t, repoDir, "initial commit")
+
+	// Without git-lfs the working tree holds the pointer, as after GIT_LFS_SKIP_SMUDGE
+	testutil.CreateFile(t, repoDir, "art/hero.psd", pointer(strings.Repeat("b", 64), 3*1024*1024))
+	testutil.CreateFile(t, repoDir, "main.go", "package main\n")
+
+	req, err := NewContextBuilder(repoDir, &types.RepoConfig{}).Build(false)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	var lfs *types.FileChange
+	for i := range req.Files {
+		if req.Files[i].Path == "art/hero.psd" {
+			lfs = &req.Files[i]
+		}
+	}
+	if lfs == nil || lfs.LFSChange != "LFS asset updated (1.0 KB → 3.0 MB)" {
+		t.Errorf("expected an LFS summary for art/hero.psd, got %+v", req.Files)
+	}
+	if strings.Contains(req.Diff, "oid sha256") {
+		t.Errorf("diff should leave out LFS pointers:\n%s", req.Diff)
+	}
+	if !strings.Contains(req.Diff, "main.go") {
+		t.Errorf("diff should still include other files:\n%s", req.Diff)
+	}
+}
+
+func TestContextBuilder_Build_Focus(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "api/handler.go", "package api\n")
+	testutil.CreateFile(t, repoDir, "web/app.ts", "export {}\n")
+	testutil.GitAdd(t, repoDir, "api/handler.go", "web/app.ts")
+	testutil.GitCommit(t, repoDir, "initial commit")
+
+	testutil.CreateFile(t, repoDir, "api/handler.go", "package api\n\nfunc Handle() {}\n")
+	testutil.CreateFile(t, repoDir, "web/app.ts", "export const app = 1\n")
+
+	builder := NewContextBuilder(repoDir, &types.RepoConfig{})
+	builder.SetFocus([]string{"api"})
+	req, err := builder.Build(false)
+	if err != nil {
+		t.Fatalf("Build failed: %v", err)
+	}
+
+	if len(req.Files) != 2 {
+		t.Fatalf("unfocused files should still be analyzed, got %+v", req.Files)
+	}
+	for _, f := range req.Files {
+		if f.Focused != (f.Path == "api/handler.go") {
+			t.Errorf("%s: Focused = %v", f.Path, f.Focused)
+		}
+	}
+	if !strings.Contains(req.Diff, "+func Handle() {}") {
+		t.Errorf("diff should include the focused file's hunks:\n%s", req.Diff)
+	}
+	if strings.Contains(req.Diff, "export const app") || !strings.Contains(req.Diff, "(summarized: +1 -1 lines)") {
+		t.Errorf("diff should summarize web/app.ts:\n%s", req.Diff)
+	}
+}
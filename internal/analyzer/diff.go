@@ -6,6 +6,8 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/dsswift/commit/internal/git"
 )
 
 // DiffRequest contains parameters for diff analysis.
@@ -66,7 +68,7 @@ func GetDiff(req *DiffRequest) (*DiffResult, error) {
 		diffArgs = []string{"diff", req.FromRef, req.ToRef, "--", req.FilePath}
 	}
 
-	cmd := exec.Command("git", diffArgs...)
+	cmd := git.Command(diffArgs...)
 	cmd.Dir = req.GitRoot
 	output, err := cmd.Output()
 	if err != nil {
@@ -89,7 +91,7 @@ func GetDiff(req *DiffRequest) (*DiffResult, error) {
 		numstatArgs = []string{"diff", req.FromRef, req.ToRef, "--numstat", "--", req.FilePath}
 	}
 
-	numstatCmd := exec.Command("git", numstatArgs...)
+	numstatCmd := git.Command(numstatArgs...)
 	numstatCmd.Dir = req.GitRoot
 	numstatOutput, err := numstatCmd.Output()
 	_ = err // numstat failure is non-fatal; stats will be empty
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/planner"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// BuildFromPatch creates an AnalysisRequest from a unified diff instead of
+// the working tree, so changes that only exist as a patch (piped from git
+// diff, a patch file or a review tool) can be planned. Recent commits are
+// included when the builder's directory is a repository.
+func (b *ContextBuilder) BuildFromPatch(patch string) (*types.AnalysisRequest, error) {
+	fileChanges := git.ParsePatch(patch)
+	if len(fileChanges) == 0 {
+		return nil, &NoChangesError{}
+	}
+
+	for i := range fileChanges {
+		fileChanges[i].Scope = config.ResolveScope(fileChanges[i].Path, b.repoConfig)
+	}
+
+	if b.markFocused(fileChanges) {
+		patch = git.FocusDiff(patch, b.focus)
+	}
+
+	recentCommits, err := b.collector.RecentCommits(RecentCommitCount, b.repoConfig.ExcludedAuthors()...)
+	if err != nil {
+		// Non-fatal - the patch may not belong to this repository
+		recentCommits = []string{}
+	}
+
+	if b.suggestScopes(recentCommits) {
+		for i := range fileChanges {
+			fileChanges[i].SuggestedScope = config.SuggestScope(fileChanges[i].Path)
+		}
+	}
+
+	request := &types.AnalysisRequest{
+		Files:         fileChanges,
+		Diff:          compactDiff(patch),
+		RecentCommits: recentCommits,
+		HasScopes:     config.HasScopes(b.repoConfig),
+		Rules: types.CommitRules{
+			Types:            b.repoConfig.AllowedTypes(),
+			MaxMessageLength: b.maxMessageLength(),
+			BehavioralTest:   "feat = behavior change, refactor = same behavior different structure",
+			Convention:       b.repoConfig.Convention,
+			ScopePolicy:      b.repoConfig.ScopePolicy,
+		},
+	}
+	request.TypeGuesses = planner.GuessTypes(request)
+	return request, nil
+}
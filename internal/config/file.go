@@ -0,0 +1,509 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConfigFile is the structured config file in the config directory. When it
+// exists it replaces every .env file, including named profiles'.
+const ConfigFile = "config.yaml"
+
+// settingPaths maps config.yaml paths to the .env keys they replace.
+var settingPaths = []struct{ path, key string }{
+	{"provider", "COMMIT_PROVIDER"},
+	{"model", "COMMIT_MODEL"},
+	{"providerFallbacks", "COMMIT_PROVIDER_FALLBACKS"},
+	{"dryRun", "COMMIT_DRY_RUN"},
+	{"defaultMode", "COMMIT_DEFAULT_MODE"},
+	{"baseUrl", "COMMIT_BASE_URL"},
+	{"timeout", "COMMIT_TIMEOUT"},
+	{"temperature", "COMMIT_LLM_TEMPERATURE"},
+	{"topP", "COMMIT_LLM_TOP_P"},
+	{"maxTokens", "COMMIT_LLM_MAX_TOKENS"},
+	{"cache.ttl", "COMMIT_CACHE_TTL"},
+	{"budget.monthlyUsd", "COMMIT_MONTHLY_BUDGET_USD"},
+	{"checkKeyFormat", "COMMIT_CHECK_KEY_FORMAT"},
+	{"theme", "COMMIT_THEME"},
+	{"updateCheck", "COMMIT_UPDATE_CHECK"},
+	{"anthropic.apiKey", "ANTHROPIC_API_KEY"},
+	{"anthropic.apiVersion", "ANTHROPIC_API_VERSION"},
+	{"openai.apiKey", "OPENAI_API_KEY"},
+	{"openai.organization", "OPENAI_ORGANIZATION"},
+	{"openai.project", "OPENAI_PROJECT"},
+	{"openai.baseUrl", "COMMIT_OPENAI_BASE_URL"},
+	{"openai.path", "COMMIT_OPENAI_PATH"},
+	{"grok.apiKey", "GROK_API_KEY"},
+	{"gemini.apiKey", "GEMINI_API_KEY"},
+	{"gemini.apiVersion", "GEMINI_API_VERSION"},
+	{"gemini.project", "GEMINI_PROJECT"},
+	{"azureFoundry.endpoint", "AZURE_FOUNDRY_ENDPOINT"},
+	{"azureFoundry.apiKey", "AZURE_FOUNDRY_API_KEY"},
+	{"azureFoundry.deployment", "AZURE_FOUNDRY_DEPLOYMENT"},
+	{"azureOpenai.endpoint", "AZURE_OPENAI_ENDPOINT"},
+	{"azureOpenai.apiKey", "AZURE_OPENAI_API_KEY"},
+	{"azureOpenai.deployment", "AZURE_OPENAI_DEPLOYMENT"},
+	{"azureOpenai.apiVersion", "AZURE_OPENAI_API_VERSION"},
+	{"openrouter.apiKey", "OPENROUTER_API_KEY"},
+	{"openrouter.siteUrl", "OPENROUTER_SITE_URL"},
+	{"openrouter.appName", "OPENROUTER_APP_NAME"},
+	{"mistral.apiKey", "MISTRAL_API_KEY"},
+	{"deepseek.apiKey", "DEEPSEEK_API_KEY"},
+	{"github.token", "GITHUB_TOKEN"},
+	{"vertex.project", "VERTEX_PROJECT"},
+	{"vertex.location", "VERTEX_LOCATION"},
+	{"vertex.credentials", "VERTEX_CREDENTIALS"},
+	{"ollama.host", "OLLAMA_HOST"},
+	{"network.proxy", "COMMIT_PROXY"},
+	{"network.connectTimeout", "COMMIT_CONNECT_TIMEOUT"},
+	{"network.maxAttempts", "COMMIT_MAX_ATTEMPTS"},
+	{"webhook.url", "COMMIT_WEBHOOK_URL"},
+	{"webhook.secret", "COMMIT_WEBHOOK_SECRET"},
+	{"log.level", "COMMIT_LOG_LEVEL"},
+	{"log.console", "COMMIT_LOG_CONSOLE"},
+}
+
+// Sections of config.yaml that aren't plain settings.
+const (
+	extraHeadersKey = "extraHeaders" // header name → value; COMMIT_EXTRA_HEADERS
+	presetsKey      = "presets"      // preset name → arguments; COMMIT_PRESET_*
+	profilesKey     = "profiles"     // profile name → settings overriding the top level
+	envKey          = "env"          // any other .env key, passed through as is
+)
+
+// configFilePath returns the path of config.yaml.
+func configFilePath() (string, error) {
+	root, err := ConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(root, ConfigFile), nil
+}
+
+// HasConfigFile reports whether config.yaml exists.
+func HasConfigFile() bool {
+	path, err := configFilePath()
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(path)
+	return err == nil
+}
+
+// loadSettings returns the active profile's settings as .env keys: from
+// config.yaml when it exists (the top level, overridden by the profile's
+// section), otherwise from the profile's .env. A missing .env is returned
+// as an os.IsNotExist error, a missing profile as a ProfileNotFoundError.
+func loadSettings() (map[string]string, error) {
+	path, err := configFilePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err == nil {
+		doc, err := parseYAML(string(data))
+		if err != nil {
+			return nil, &ConfigFileError{Path: path, Err: err}
+		}
+		settings, err := settingsFromYAML(doc, ActiveProfile())
+		if err != nil {
+			var notFound *ProfileNotFoundError
+			if errors.As(err, &notFound) {
+				notFound.Path = path
+				return nil, notFound
+			}
+			return nil, &ConfigFileError{Path: path, Err: err}
+		}
+		return settings, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	configPath, err := ProfilePath()
+	if err != nil {
+		return nil, err
+	}
+	if name := ActiveProfile(); name != "" {
+		if _, err := os.Stat(configPath); os.IsNotExist(err) {
+			return nil, &ProfileNotFoundError{Name: name, Path: configPath}
+		}
+	}
+	return parseEnvFile(filepath.Join(configPath, EnvFile))
+}
+
+// settingsFromYAML flattens doc into .env keys for the named profile, or
+// for the top level when profile is empty.
+func settingsFromYAML(doc *yamlNode, profile string) (map[string]string, error) {
+	settings := make(map[string]string)
+	if err := flattenSettings(doc, settings, true); err != nil {
+		return nil, err
+	}
+	if profile == "" {
+		return settings, nil
+	}
+
+	section := doc.get(profilesKey).get(profile)
+	switch {
+	case section == nil:
+		return nil, &ProfileNotFoundError{Name: profile}
+	case !section.isMap() && (section.IsList || section.Scalar != ""):
+		return nil, &UnknownSettingError{Path: profilesKey + "." + profile, Reason: "expected profile settings"}
+	case !section.isMap():
+		// "name:" with nothing under it is a profile with no overrides
+		return settings, nil
+	}
+	if err := flattenSettings(section, settings, false); err != nil {
+		return nil, fmt.Errorf("profiles.%s: %w", profile, err)
+	}
+	return settings, nil
+}
+
+// flattenSettings adds a settings mapping's values to settings. The profiles
+// section is only allowed at the top level.
+func flattenSettings(node *yamlNode, settings map[string]string, topLevel bool) error {
+	keys := make(map[string]string, len(settingPaths))
+	for _, s := range settingPaths {
+		keys[s.path] = s.key
+	}
+
+	var walk func(n *yamlNode, prefix string) error
+	walk = func(n *yamlNode, prefix string) error {
+		for _, name := range n.Keys {
+			child, path := n.Fields[name], prefix+name
+			switch {
+			case prefix == "" && name == profilesKey && topLevel:
+				continue
+			case prefix == "" && name == extraHeadersKey:
+				if !child.isMap() {
+					return &UnknownSettingError{Path: path, Reason: "expected header names and values"}
+				}
+				var pairs []string
+				for _, header := range child.Keys {
+					pairs = append(pairs, header+": "+child.Fields[header].Scalar)
+				}
+				settings["COMMIT_EXTRA_HEADERS"] = strings.Join(pairs, "; ")
+			case prefix == "" && name == presetsKey:
+				if !child.isMap() {
+					return &UnknownSettingError{Path: path, Reason: "expected preset names and arguments"}
+				}
+				for _, preset := range child.Keys {
+					settings[PresetEnvPrefix+strings.ToUpper(preset)] = presetValue(child.Fields[preset])
+				}
+			case prefix == "" && name == envKey:
+				if !child.isMap() {
+					return &UnknownSettingError{Path: path, Reason: "expected .env keys and values"}
+				}
+				for _, key := range child.Keys {
+					settings[key] = child.Fields[key].Scalar
+				}
+			case child.isMap():
+				if err := walk(child, path+"."); err != nil {
+					return err
+				}
+			default:
+				key, ok := keys[path]
+				if !ok || child.IsList {
+					return &UnknownSettingError{Path: path}
+				}
+				settings[key] = child.Scalar
+			}
+		}
+		return nil
+	}
+	return walk(node, "")
+}
+
+// presetValue joins a preset given as a list into the string form .env
+// presets use, quoting arguments with spaces.
+func presetValue(node *yamlNode) string {
+	if !node.IsList {
+		return node.Scalar
+	}
+	args := make([]string, len(node.List))
+	for i, arg := range node.List {
+		if strings.ContainsAny(arg, " \t") || arg == "" {
+			arg = `"` + arg + `"`
+		}
+		args[i] = arg
+	}
+	return strings.Join(args, " ")
+}
+
+// settingsToYAML builds a settings mapping from .env keys. Keys with no
+// config.yaml path go in the env section.
+func settingsToYAML(settings map[string]string) *yamlNode {
+	node := newYAMLMap()
+	for _, s := range settingPaths {
+		value, ok := settings[s.key]
+		if !ok {
+			continue
+		}
+		parent := node
+		parts := strings.Split(s.path, ".")
+		for _, part := range parts[:len(parts)-1] {
+			parent = parent.child(part)
+		}
+		parent.set(parts[len(parts)-1], &yamlNode{Scalar: value})
+	}
+
+	if v, ok := settings["COMMIT_EXTRA_HEADERS"]; ok {
+		if headers, err := ParseHeaders(v); err == nil && len(headers) > 0 {
+			section := node.child(extraHeadersKey)
+			for _, name := range sortedKeys(headers) {
+				section.set(name, &yamlNode{Scalar: headers[name]})
+			}
+		} else {
+			node.child(envKey).set("COMMIT_EXTRA_HEADERS", &yamlNode{Scalar: v})
+		}
+	}
+
+	known := map[string]bool{"COMMIT_EXTRA_HEADERS": true}
+	for _, s := range settingPaths {
+		known[s.key] = true
+	}
+	for _, key := range sortedKeys(settings) {
+		if known[key] {
+			continue
+		}
+		if name, ok := strings.CutPrefix(key, PresetEnvPrefix); ok && name != "" {
+			node.child(presetsKey).set(strings.ToLower(name), &yamlNode{Scalar: settings[key]})
+			continue
+		}
+		node.child(envKey).set(key, &yamlNode{Scalar: settings[key]})
+	}
+	return node
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setYAMLValue sets key in config.yaml, in the active profile's section when
+// a profile is selected. The file is rewritten, so comments are lost.
+func setYAMLValue(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	doc, err := parseYAML(string(data))
+	if err != nil {
+		return &ConfigFileError{Path: path, Err: err}
+	}
+
+	section := doc
+	if name := ActiveProfile(); name != "" {
+		section = doc.child(profilesKey).child(name)
+	}
+
+	// Merge the single setting into the section the same way migration would
+	update := settingsToYAML(map[string]string{key: value})
+	mergeYAML(section, update)
+
+	var b strings.Builder
+	writeYAML(&b, doc, 0)
+	return os.WriteFile(path, []byte(b.String()), 0600)
+}
+
+// mergeYAML copies src's entries into dst, merging nested mappings.
+func mergeYAML(dst, src *yamlNode) {
+	for _, key := range src.Keys {
+		child := src.Fields[key]
+		if existing := dst.get(key); child.isMap() && existing.isMap() {
+			mergeYAML(existing, child)
+			continue
+		}
+		dst.set(key, child)
+	}
+}
+
+// MigrationResult describes a .env to config.yaml migration.
+type MigrationResult struct {
+	Path     string
+	Settings int
+	Profiles []string
+	// Renamed lists the .env files moved aside, now ending in .migrated.
+	Renamed []string
+}
+
+// MigrateConfig converts the default .env and every named profile's .env
+// into a single config.yaml, then renames the .env files to .env.migrated
+// so they can be restored. The result is read back and compared with the
+// .env files before anything is renamed.
+func MigrateConfig() (*MigrationResult, error) {
+	root, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(root, ConfigFile)
+	if _, err := os.Stat(path); err == nil {
+		return nil, &ConfigFileExistsError{Path: path}
+	}
+
+	result := &MigrationResult{Path: path}
+	doc := newYAMLMap()
+	var envFiles []string
+
+	defaults, err := parseEnvFile(filepath.Join(root, EnvFile))
+	switch {
+	case err == nil:
+		canonicalPresetKeys(defaults)
+		mergeYAML(doc, settingsToYAML(defaults))
+		result.Settings += len(defaults)
+		envFiles = append(envFiles, filepath.Join(root, EnvFile))
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+
+	profiles, err := ListProfiles()
+	if err != nil {
+		return nil, err
+	}
+	profileSettings := make(map[string]map[string]string)
+	for _, name := range profiles {
+		envPath := filepath.Join(root, ProfilesDir, name, EnvFile)
+		settings, err := parseEnvFile(envPath)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		canonicalPresetKeys(settings)
+		result.Settings += len(settings)
+		// Profiles stay isolated: blank out inherited settings they didn't set
+		for key := range defaults {
+			if _, ok := settings[key]; !ok {
+				settings[key] = ""
+			}
+		}
+		doc.child(profilesKey).set(name, settingsToYAML(settings))
+		profileSettings[name] = settings
+		result.Profiles = append(result.Profiles, name)
+		envFiles = append(envFiles, envPath)
+	}
+
+	if len(envFiles) == 0 {
+		return nil, &ConfigNotFoundError{Path: filepath.Join(root, EnvFile)}
+	}
+
+	var b strings.Builder
+	b.WriteString("# Commit Tool Configuration (migrated from .env)\n")
+	b.WriteString("# Documentation: https://github.com/dsswift/commit#configuration\n\n")
+	writeYAML(&b, doc, 0)
+
+	// Never rename .env files unless the new file reads back the same
+	check, err := parseYAML(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("migrated config doesn't parse: %w", err)
+	}
+	if err := sameSettings(check, "", defaults); err != nil {
+		return nil, err
+	}
+	for name, settings := range profileSettings {
+		if err := sameSettings(check, name, settings); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(b.String()), 0600); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", ConfigFile, err)
+	}
+	for _, envPath := range envFiles {
+		if err := os.Rename(envPath, envPath+".migrated"); err != nil {
+			return result, fmt.Errorf("wrote %s but failed to rename %s: %w", ConfigFile, envPath, err)
+		}
+		result.Renamed = append(result.Renamed, envPath+".migrated")
+	}
+	return result, nil
+}
+
+// sameSettings checks that doc gives profile exactly want's non-empty values.
+func sameSettings(doc *yamlNode, profile string, want map[string]string) error {
+	got, err := settingsFromYAML(doc, profile)
+	if err != nil {
+		return fmt.Errorf("migrated config doesn't load: %w", err)
+	}
+	for key, value := range want {
+		if key == "COMMIT_EXTRA_HEADERS" && sameHeaders(got[key], value) {
+			continue
+		}
+		if got[key] != value {
+			return fmt.Errorf("migrated config changes %s", key)
+		}
+	}
+	for key, value := range got {
+		if _, ok := want[key]; !ok && value != "" {
+			return fmt.Errorf("migrated config adds %s", key)
+		}
+	}
+	return nil
+}
+
+// sameHeaders reports whether two COMMIT_EXTRA_HEADERS values set the same
+// headers, since config.yaml lists them one per line in sorted order.
+func sameHeaders(a, b string) bool {
+	ha, errA := ParseHeaders(a)
+	hb, errB := ParseHeaders(b)
+	return errA == nil && errB == nil && maps.Equal(ha, hb)
+}
+
+// canonicalPresetKeys upper-cases preset names, which are case-insensitive,
+// to match the names config.yaml gives back.
+func canonicalPresetKeys(settings map[string]string) {
+	for key, value := range settings {
+		name, ok := strings.CutPrefix(key, PresetEnvPrefix)
+		if ok && name != strings.ToUpper(name) {
+			delete(settings, key)
+			settings[PresetEnvPrefix+strings.ToUpper(name)] = value
+		}
+	}
+}
+
+// ConfigFileError reports a config.yaml that can't be parsed or has
+// unknown settings.
+type ConfigFileError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigFileError) Error() string {
+	return fmt.Sprintf("invalid %s: %v", e.Path, e.Err)
+}
+
+func (e *ConfigFileError) Unwrap() error {
+	return e.Err
+}
+
+// UnknownSettingError indicates a config.yaml path that isn't a setting.
+type UnknownSettingError struct {
+	Path   string
+	Reason string
+}
+
+func (e *UnknownSettingError) Error() string {
+	if e.Reason != "" {
+		return fmt.Sprintf("%s: %s", e.Path, e.Reason)
+	}
+	return fmt.Sprintf("unknown setting %q (put other .env keys under %q)", e.Path, envKey)
+}
+
+// ConfigFileExistsError indicates config.yaml already exists, so there is
+// nothing to migrate.
+type ConfigFileExistsError struct {
+	Path string
+}
+
+func (e *ConfigFileExistsError) Error() string {
+	return fmt.Sprintf("%s already exists", e.Path)
+}
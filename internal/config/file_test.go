@@ -0,0 +1,239 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupConfigHome points HOME at a temp dir with no profile selected and
+// returns the config directory.
+func setupConfigHome(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(ProfileEnvVar, "")
+	t.Cleanup(func() { _ = SetProfile("") })
+
+	root := filepath.Join(tmpDir, ConfigDir)
+	if err := os.MkdirAll(root, 0700); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+const testConfigYAML = `provider: anthropic
+anthropic:
+  apiKey: sk-ant-personal
+network:
+  proxy: http://proxy:8080
+extraHeaders:
+  X-Team: platform
+presets:
+  quick: [-1, --dry-run]
+profiles:
+  work:
+    provider: openai
+    openai:
+      apiKey: sk-work
+      organization: org-1
+    presets:
+      quick: ""
+  empty:
+`
+
+func TestLoadUserConfig_ConfigFile(t *testing.T) {
+	root := setupConfigHome(t)
+	_ = os.WriteFile(filepath.Join(root, ConfigFile), []byte(testConfigYAML), 0600)
+	// A leftover .env is ignored once config.yaml exists
+	_ = os.WriteFile(filepath.Join(root, EnvFile), []byte("COMMIT_PROVIDER=grok\n"), 0600)
+
+	cfg, err := LoadUserConfig()
+	if err != nil {
+		t.Fatalf("LoadUserConfig failed: %v", err)
+	}
+	if cfg.Provider != "anthropic" || cfg.AnthropicAPIKey != "sk-ant-personal" {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.ExtraHeaders["X-Team"] != "platform" {
+		t.Errorf("ExtraHeaders = %v", cfg.ExtraHeaders)
+	}
+	if v, _ := GetConfigValue("COMMIT_PROXY"); v != "http://proxy:8080" {
+		t.Errorf("GetConfigValue(COMMIT_PROXY) = %q", v)
+	}
+
+	// Profiles inherit the top level and override it
+	_ = SetProfile("work")
+	cfg, err = LoadUserConfig()
+	if err != nil {
+		t.Fatalf("LoadUserConfig(work) failed: %v", err)
+	}
+	if cfg.Provider != "openai" || cfg.OpenAIAPIKey != "sk-work" || cfg.OpenAIOrganization != "org-1" {
+		t.Errorf("unexpected work config: %+v", cfg)
+	}
+	if v, _ := GetConfigValue("COMMIT_PROXY"); v != "http://proxy:8080" {
+		t.Errorf("work profile didn't inherit the proxy: %q", v)
+	}
+	presets, err := LoadPresets("")
+	if err != nil {
+		t.Fatalf("LoadPresets failed: %v", err)
+	}
+	if _, ok := presets["quick"]; ok {
+		t.Errorf("work profile should drop the quick preset: %v", presets)
+	}
+
+	_ = SetProfile("empty")
+	if _, err := LoadUserConfig(); err != nil {
+		t.Errorf("profile with no overrides: %v", err)
+	}
+
+	_ = SetProfile("missing")
+	if _, err := LoadUserConfig(); err == nil {
+		t.Error("expected an error for a profile without a section")
+	} else if _, ok := err.(*ProfileNotFoundError); !ok {
+		t.Errorf("expected ProfileNotFoundError, got %T: %v", err, err)
+	}
+
+	names, err := ListProfiles()
+	if err != nil || strings.Join(names, ",") != "empty,work" {
+		t.Errorf("ListProfiles() = %v, %v", names, err)
+	}
+}
+
+func TestLoadPresets_ConfigFile(t *testing.T) {
+	root := setupConfigHome(t)
+	_ = os.WriteFile(filepath.Join(root, ConfigFile), []byte(testConfigYAML), 0600)
+
+	presets, err := LoadPresets("")
+	if err != nil {
+		t.Fatalf("LoadPresets failed: %v", err)
+	}
+	if got := strings.Join(presets["quick"], " "); got != "-1 --dry-run" {
+		t.Errorf("quick = %q", got)
+	}
+}
+
+func TestLoadUserConfig_ConfigFileErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"unknown setting", "provider: anthropic\nanthropic:\n  apiKye: x\n"},
+		{"list for a scalar", "provider: [anthropic]\n"},
+		{"syntax error", "provider anthropic\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := setupConfigHome(t)
+			_ = os.WriteFile(filepath.Join(root, ConfigFile), []byte(tt.data), 0600)
+
+			_, err := LoadUserConfig()
+			if err == nil || !strings.Contains(err.Error(), ConfigFile) {
+				t.Errorf("expected an error naming %s, got %v", ConfigFile, err)
+			}
+		})
+	}
+}
+
+func TestSetConfigValue_ConfigFile(t *testing.T) {
+	root := setupConfigHome(t)
+	path := filepath.Join(root, ConfigFile)
+	_ = os.WriteFile(path, []byte(testConfigYAML), 0600)
+
+	if err := SetConfigValue("COMMIT_PROXY", "http://other:3128"); err != nil {
+		t.Fatalf("SetConfigValue failed: %v", err)
+	}
+	_ = SetProfile("work")
+	if err := SetConfigValue("COMMIT_THEME", "mono"); err != nil {
+		t.Fatalf("SetConfigValue(work) failed: %v", err)
+	}
+
+	if v, _ := GetConfigValue("COMMIT_PROXY"); v != "http://other:3128" {
+		t.Errorf("COMMIT_PROXY = %q", v)
+	}
+	if v, _ := GetConfigValue("COMMIT_THEME"); v != "mono" {
+		t.Errorf("work COMMIT_THEME = %q", v)
+	}
+	_ = SetProfile("")
+	if v, _ := GetConfigValue("COMMIT_THEME"); v != "" {
+		t.Errorf("profile setting leaked to the top level: %q", v)
+	}
+	if _, err := os.Stat(filepath.Join(root, EnvFile)); !os.IsNotExist(err) {
+		t.Error("SetConfigValue should not create a .env next to config.yaml")
+	}
+}
+
+func TestMigrateConfig(t *testing.T) {
+	root := setupConfigHome(t)
+	_ = os.WriteFile(filepath.Join(root, EnvFile), []byte(`COMMIT_PROVIDER=anthropic
+ANTHROPIC_API_KEY=sk-ant-personal
+COMMIT_EXTRA_HEADERS=X-Team: platform; X-Cost: 42
+COMMIT_PRESET_Quick="-1 --dry-run"
+SOME_FUTURE_KEY=kept
+`), 0600)
+
+	workDir := filepath.Join(root, ProfilesDir, "work")
+	_ = os.MkdirAll(workDir, 0700)
+	_ = os.WriteFile(filepath.Join(workDir, EnvFile), []byte("COMMIT_PROVIDER=openai\nOPENAI_API_KEY=sk-work\n"), 0600)
+
+	result, err := MigrateConfig()
+	if err != nil {
+		t.Fatalf("MigrateConfig failed: %v", err)
+	}
+	if strings.Join(result.Profiles, ",") != "work" || len(result.Renamed) != 2 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+	if _, err := os.Stat(filepath.Join(root, EnvFile+".migrated")); err != nil {
+		t.Errorf("old .env not kept: %v", err)
+	}
+	info, err := os.Stat(filepath.Join(root, ConfigFile))
+	if err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("config.yaml not written with mode 0600: %v", err)
+	}
+
+	cfg, err := LoadUserConfig()
+	if err != nil {
+		t.Fatalf("LoadUserConfig after migration failed: %v", err)
+	}
+	if cfg.AnthropicAPIKey != "sk-ant-personal" || cfg.ExtraHeaders["X-Cost"] != "42" {
+		t.Errorf("unexpected migrated config: %+v", cfg)
+	}
+	if v, _ := GetConfigValue("SOME_FUTURE_KEY"); v != "kept" {
+		t.Errorf("unknown key not passed through: %q", v)
+	}
+	presets, _ := LoadPresets("")
+	if got := strings.Join(presets["quick"], " "); got != "-1 --dry-run" {
+		t.Errorf("quick preset = %q", got)
+	}
+
+	// Migrated profiles stay isolated from the defaults, as they were
+	_ = SetProfile("work")
+	cfg, err = LoadUserConfig()
+	if err != nil {
+		t.Fatalf("LoadUserConfig(work) after migration failed: %v", err)
+	}
+	if cfg.Provider != "openai" || cfg.AnthropicAPIKey != "" || len(cfg.ExtraHeaders) != 0 {
+		t.Errorf("work profile inherited defaults: %+v", cfg)
+	}
+	if presets, _ := LoadPresets(""); len(presets) != 0 {
+		t.Errorf("work profile inherited presets: %v", presets)
+	}
+
+	if _, err := MigrateConfig(); err == nil {
+		t.Error("expected an error migrating twice")
+	} else if _, ok := err.(*ConfigFileExistsError); !ok {
+		t.Errorf("expected ConfigFileExistsError, got %T: %v", err, err)
+	}
+}
+
+func TestMigrateConfig_NothingToMigrate(t *testing.T) {
+	setupConfigHome(t)
+
+	if _, err := MigrateConfig(); err == nil {
+		t.Error("expected an error with no .env files")
+	} else if _, ok := err.(*ConfigNotFoundError); !ok {
+		t.Errorf("expected ConfigNotFoundError, got %T: %v", err, err)
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ghAuthToken returns the GitHub CLI's token for the signed-in account.
+// Tests replace it.
+var ghAuthToken = func() (string, error) {
+	out, err := exec.Command("gh", "auth", "token").Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// gitHubToken returns the token the github-models provider authenticates
+// with: GITHUB_TOKEN from the config file, else from the environment (as in
+// GitHub Actions), else the token gh auth login stored, so signed-in gh
+// users need no separate key. It returns "" if there is none.
+func gitHubToken(env map[string]string) string {
+	if token := env["GITHUB_TOKEN"]; token != "" {
+		return token
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+		return token
+	}
+	if token, err := ghAuthToken(); err == nil {
+		return token
+	}
+	return ""
+}
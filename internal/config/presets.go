@@ -0,0 +1,144 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// PresetEnvPrefix prefixes .env keys defining flag presets, e.g.
+// COMMIT_PRESET_QUICK="-1 --dry-run" defines @quick.
+const PresetEnvPrefix = "COMMIT_PRESET_"
+
+// LoadPresets returns the named flag presets from the repo's .commit.json
+// (shared with the team) and the active profile's config.yaml section or .env (personal). A personal
+// preset replaces a shared one with the same name. gitRoot may be empty
+// outside a repository.
+func LoadPresets(gitRoot string) (map[string][]string, error) {
+	presets := make(map[string][]string)
+
+	if gitRoot != "" {
+		repoConfig, err := LoadRepoConfig(gitRoot)
+		if err != nil {
+			return nil, err
+		}
+		for name, args := range repoConfig.Presets {
+			presets[strings.ToLower(name)] = args
+		}
+	}
+
+	env, err := loadSettings()
+	var notFound *ProfileNotFoundError
+	if err != nil && !os.IsNotExist(err) && !errors.As(err, &notFound) {
+		return nil, err
+	}
+	for key, value := range env {
+		name, ok := strings.CutPrefix(key, PresetEnvPrefix)
+		// An empty value lets a profile drop a preset it would inherit
+		if !ok || name == "" || value == "" {
+			continue
+		}
+		args, err := splitPresetArgs(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid preset %s: %w", key, err)
+		}
+		presets[strings.ToLower(name)] = args
+	}
+
+	return presets, nil
+}
+
+// ExpandPresets replaces each "@name" argument with the preset's arguments.
+// Presets are not expanded recursively.
+func ExpandPresets(args []string, presets map[string][]string) ([]string, error) {
+	var expanded []string
+	for _, arg := range args {
+		name, ok := strings.CutPrefix(arg, "@")
+		if !ok || name == "" {
+			expanded = append(expanded, arg)
+			continue
+		}
+
+		preset, ok := presets[strings.ToLower(name)]
+		if !ok {
+			return nil, &UnknownPresetError{Name: name, Available: presetNames(presets)}
+		}
+		expanded = append(expanded, preset...)
+	}
+	return expanded, nil
+}
+
+// HasPresetArg reports whether any argument invokes a preset.
+func HasPresetArg(args []string) bool {
+	for _, arg := range args {
+		if len(arg) > 1 && arg[0] == '@' {
+			return true
+		}
+	}
+	return false
+}
+
+// splitPresetArgs splits a preset value into arguments like a shell would
+// for simple quoting: whitespace separates, single and double quotes group.
+func splitPresetArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg := false
+
+	for _, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// presetNames returns the preset names, sorted.
+func presetNames(presets map[string][]string) []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UnknownPresetError indicates an @name argument with no matching preset.
+type UnknownPresetError struct {
+	Name      string
+	Available []string
+}
+
+func (e *UnknownPresetError) Error() string {
+	if len(e.Available) == 0 {
+		return fmt.Sprintf("unknown preset @%s: no presets defined", e.Name)
+	}
+	return fmt.Sprintf("unknown preset @%s (available: @%s)", e.Name, strings.Join(e.Available, ", @"))
+}
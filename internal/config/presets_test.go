@@ -0,0 +1,82 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPresets(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(ProfileEnvVar, "")
+
+	repoDir := t.TempDir()
+	repoJSON := `{"presets": {"quick": ["-1", "--dry-run"], "careful": ["--staged", "-v"]}}`
+	_ = os.WriteFile(filepath.Join(repoDir, RepoConfigFile), []byte(repoJSON), 0644)
+
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+	env := "COMMIT_PROVIDER=openai\nCOMMIT_PRESET_QUICK='-1 -m \"work in progress\"'\n"
+	_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte(env), 0600)
+
+	presets, err := LoadPresets(repoDir)
+	if err != nil {
+		t.Fatalf("LoadPresets failed: %v", err)
+	}
+
+	// The personal preset replaces the shared one with the same name
+	if want := []string{"-1", "-m", "work in progress"}; !reflect.DeepEqual(presets["quick"], want) {
+		t.Errorf("quick = %q, want %q", presets["quick"], want)
+	}
+	if want := []string{"--staged", "-v"}; !reflect.DeepEqual(presets["careful"], want) {
+		t.Errorf("careful = %q, want %q", presets["careful"], want)
+	}
+
+	// Outside a repository only personal presets apply
+	presets, err = LoadPresets("")
+	if err != nil || len(presets) != 1 {
+		t.Errorf("expected only the personal preset, got %v, %v", presets, err)
+	}
+}
+
+func TestExpandPresets(t *testing.T) {
+	presets := map[string][]string{"quick": {"-1", "--dry-run"}}
+
+	got, err := ExpandPresets([]string{"@Quick", "-v", "src/"}, presets)
+	if err != nil {
+		t.Fatalf("ExpandPresets failed: %v", err)
+	}
+	if want := []string{"-1", "--dry-run", "-v", "src/"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ExpandPresets() = %q, want %q", got, want)
+	}
+
+	_, err = ExpandPresets([]string{"@missing"}, presets)
+	if e, ok := err.(*UnknownPresetError); !ok || e.Name != "missing" {
+		t.Errorf("expected UnknownPresetError, got %T: %v", err, err)
+	}
+}
+
+func TestSplitPresetArgs(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"-1  --dry-run", []string{"-1", "--dry-run"}},
+		{`-m "fix the thing" -v`, []string{"-m", "fix the thing", "-v"}},
+		{`--provider='openai/gpt-4o'`, []string{"--provider=openai/gpt-4o"}},
+		{`-m ""`, []string{"-m", ""}},
+	}
+	for _, tt := range tests {
+		got, err := splitPresetArgs(tt.input)
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitPresetArgs(%q) = %q, %v; want %q", tt.input, got, err, tt.want)
+		}
+	}
+
+	if _, err := splitPresetArgs(`-m "unterminated`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+const (
+	// ProfilesDir is the subdirectory of the config directory holding named profiles.
+	ProfilesDir = "profiles"
+	// ProfileEnvVar selects a profile when --profile is not given.
+	ProfileEnvVar = "COMMIT_PROFILE"
+)
+
+// profileNamePattern restricts profile names to a single safe path segment.
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// activeProfile is the profile selected with SetProfile. Empty defers to COMMIT_PROFILE.
+var activeProfile string
+
+// SetProfile selects the named profile for the rest of the process.
+// An empty name falls back to COMMIT_PROFILE, then to the default profile.
+func SetProfile(name string) error {
+	if name != "" && !profileNamePattern.MatchString(name) {
+		return &InvalidProfileError{Name: name}
+	}
+	activeProfile = name
+	return nil
+}
+
+// ActiveProfile returns the selected profile name, or "" for the default profile.
+func ActiveProfile() string {
+	if activeProfile != "" {
+		return activeProfile
+	}
+	return os.Getenv(ProfileEnvVar)
+}
+
+// ProfilePath returns the directory holding the active profile's .env and logs.
+// The default profile lives directly in ~/.commit-tool; named profiles live in
+// ~/.commit-tool/profiles/<name> so their credentials and logs stay isolated.
+func ProfilePath() (string, error) {
+	root, err := ConfigPath()
+	if err != nil {
+		return "", err
+	}
+
+	name := ActiveProfile()
+	if name == "" {
+		return root, nil
+	}
+	if !profileNamePattern.MatchString(name) {
+		return "", &InvalidProfileError{Name: name}
+	}
+	return filepath.Join(root, ProfilesDir, name), nil
+}
+
+// ListProfiles returns the names of the configured named profiles, sorted:
+// the profiles sections of config.yaml when it exists, otherwise the
+// profile directories.
+func ListProfiles() ([]string, error) {
+	root, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, ConfigFile)); err == nil {
+		doc, err := parseYAML(string(data))
+		if err != nil {
+			return nil, err
+		}
+		names := slices.Clone(doc.get(profilesKey).keys())
+		sort.Strings(names)
+		return names, nil
+	}
+
+	entries, err := os.ReadDir(filepath.Join(root, ProfilesDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() && profileNamePattern.MatchString(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// InvalidProfileError indicates a profile name that isn't a safe directory name.
+type InvalidProfileError struct {
+	Name string
+}
+
+func (e *InvalidProfileError) Error() string {
+	return fmt.Sprintf("invalid profile name %q. Use letters, digits, '.', '_' or '-'", e.Name)
+}
+
+// ProfileNotFoundError indicates the selected profile has no directory.
+type ProfileNotFoundError struct {
+	Name string
+	Path string
+}
+
+func (e *ProfileNotFoundError) Error() string {
+	return fmt.Sprintf("profile %q not found: %s", e.Name, e.Path)
+}
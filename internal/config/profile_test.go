@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProfilePath(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(ProfileEnvVar, "")
+	t.Cleanup(func() { _ = SetProfile("") })
+
+	root := filepath.Join(tmpDir, ConfigDir)
+
+	path, err := ProfilePath()
+	if err != nil || path != root {
+		t.Errorf("default ProfilePath() = %q, %v; want %q", path, err, root)
+	}
+
+	t.Setenv(ProfileEnvVar, "personal")
+	path, _ = ProfilePath()
+	if want := filepath.Join(root, ProfilesDir, "personal"); path != want {
+		t.Errorf("env ProfilePath() = %q, want %q", path, want)
+	}
+
+	// The flag takes precedence over the environment
+	if err := SetProfile("work"); err != nil {
+		t.Fatalf("SetProfile failed: %v", err)
+	}
+	path, _ = ProfilePath()
+	if want := filepath.Join(root, ProfilesDir, "work"); path != want {
+		t.Errorf("flag ProfilePath() = %q, want %q", path, want)
+	}
+}
+
+func TestSetProfile_InvalidName(t *testing.T) {
+	t.Cleanup(func() { _ = SetProfile("") })
+
+	for _, name := range []string{"../escape", "a/b", ".hidden"} {
+		if _, ok := SetProfile(name).(*InvalidProfileError); !ok {
+			t.Errorf("SetProfile(%q): expected InvalidProfileError", name)
+		}
+	}
+
+	t.Setenv(ProfileEnvVar, "../escape")
+	if _, err := ProfilePath(); err == nil {
+		t.Error("expected error for invalid COMMIT_PROFILE")
+	}
+}
+
+func TestLoadUserConfig_Profile(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	t.Setenv(ProfileEnvVar, "")
+	t.Cleanup(func() { _ = SetProfile("") })
+
+	root := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(root, 0700)
+	_ = os.WriteFile(filepath.Join(root, EnvFile), []byte("COMMIT_PROVIDER=anthropic\nANTHROPIC_API_KEY=sk-ant-personal\n"), 0600)
+
+	workDir := filepath.Join(root, ProfilesDir, "work")
+	_ = os.MkdirAll(workDir, 0700)
+	_ = os.WriteFile(filepath.Join(workDir, EnvFile), []byte("COMMIT_PROVIDER=openai\nOPENAI_API_KEY=sk-work\n"), 0600)
+
+	_ = SetProfile("work")
+	cfg, err := LoadUserConfig()
+	if err != nil {
+		t.Fatalf("LoadUserConfig failed: %v", err)
+	}
+	if cfg.Provider != "openai" || cfg.AnthropicAPIKey != "" {
+		t.Errorf("profile config leaked default credentials: %+v", cfg)
+	}
+
+	_ = SetProfile("missing")
+	_, err = LoadUserConfig()
+	if _, ok := err.(*ProfileNotFoundError); !ok {
+		t.Errorf("expected ProfileNotFoundError, got %T: %v", err, err)
+	}
+
+	names, err := ListProfiles()
+	if err != nil || len(names) != 1 || names[0] != "work" {
+		t.Errorf("ListProfiles() = %v, %v", names, err)
+	}
+}
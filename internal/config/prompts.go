@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+const (
+	// PromptsDir holds personal prompt templates under ConfigDir.
+	PromptsDir = "prompts"
+	// SystemPromptFile and UserPromptFile are the template files in PromptsDir.
+	SystemPromptFile = "system.tmpl"
+	UserPromptFile   = "user.tmpl"
+)
+
+// LoadPromptTemplates returns the prompt templates that replace the built-in
+// prompts: each from ~/.commit-tool/prompts/ (personal) if the file exists,
+// otherwise from the repo's .commit.json prompts (shared with the team). It
+// returns nil when neither has any. repoConfig may be nil.
+func LoadPromptTemplates(repoConfig *types.RepoConfig) (*types.PromptTemplates, error) {
+	var prompts types.PromptTemplates
+	if repoConfig != nil {
+		prompts = repoConfig.Prompts
+	}
+
+	configPath, err := ConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(configPath, PromptsDir)
+	for file, prompt := range map[string]*string{SystemPromptFile: &prompts.System, UserPromptFile: &prompts.User} {
+		data, err := os.ReadFile(filepath.Join(dir, file))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read prompt template: %w", err)
+		}
+		*prompt = string(data)
+	}
+
+	if prompts.System == "" && prompts.User == "" {
+		return nil, nil
+	}
+	return &prompts, nil
+}
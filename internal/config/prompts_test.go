@@ -0,0 +1,42 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func TestLoadPromptTemplates(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	repoConfig := &types.RepoConfig{Prompts: types.PromptTemplates{System: "team system", User: "team user"}}
+
+	// Neither source has templates
+	prompts, err := LoadPromptTemplates(defaultRepoConfig())
+	if err != nil || prompts != nil {
+		t.Fatalf("expected no templates, got %+v, %v", prompts, err)
+	}
+
+	prompts, err = LoadPromptTemplates(repoConfig)
+	if err != nil || prompts.System != "team system" || prompts.User != "team user" {
+		t.Fatalf("expected the repo's templates, got %+v, %v", prompts, err)
+	}
+
+	// A personal template replaces the shared one
+	promptsDir := filepath.Join(tmpDir, ConfigDir, PromptsDir)
+	_ = os.MkdirAll(promptsDir, 0700)
+	_ = os.WriteFile(filepath.Join(promptsDir, UserPromptFile), []byte("my user"), 0600)
+
+	prompts, err = LoadPromptTemplates(repoConfig)
+	if err != nil || prompts.System != "team system" || prompts.User != "my user" {
+		t.Errorf("expected the personal user template, got %+v, %v", prompts, err)
+	}
+
+	prompts, err = LoadPromptTemplates(nil)
+	if err != nil || prompts.System != "" || prompts.User != "my user" {
+		t.Errorf("expected only the personal template, got %+v, %v", prompts, err)
+	}
+}
@@ -4,11 +4,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/internal/git"
 	"github.com/dsswift/commit/pkg/types"
 )
 
@@ -45,6 +48,45 @@ func LoadRepoConfig(gitRoot string) (*types.RepoConfig, error) {
 		return nil, err
 	}
 
+	// Validate branch policies
+	if err := validateBranchPolicies(&config); err != nil {
+		return nil, err
+	}
+
+	// Validate verification severities
+	if err := validateVerification(&config); err != nil {
+		return nil, err
+	}
+
+	// Validate the hook autofix mode
+	switch config.HookAutofix {
+	case "", types.HookAutofixAmend, types.HookAutofixWarn, types.HookAutofixFail:
+	default:
+		return nil, fmt.Errorf("invalid hookAutofix %q (use amend, warn, or fail)", config.HookAutofix)
+	}
+
+	// Validate the commit convention
+	if _, err := convention.New(&config, ""); err != nil {
+		return nil, err
+	}
+
+	// Validate the scope policy
+	if err := validateScopePolicy(&config); err != nil {
+		return nil, err
+	}
+
+	// Validate the diff algorithm
+	if config.DiffAlgorithm != "" && !git.ValidDiffAlgorithm(config.DiffAlgorithm) {
+		return nil, fmt.Errorf("invalid diffAlgorithm %q (use %s)", config.DiffAlgorithm, strings.Join(git.DiffAlgorithms, ", "))
+	}
+
+	// Validate author exclusion patterns
+	for _, pattern := range config.ExcludeAuthors {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid excludeAuthors pattern %q: %w", pattern, err)
+		}
+	}
+
 	// Sort scopes by path length (longest first) for proper matching
 	sortScopesBySpecificity(&config)
 
@@ -96,6 +138,58 @@ func validateScopes(config *types.RepoConfig) error {
 	return nil
 }
 
+// validateBranchPolicies ensures branch policy configurations are valid.
+func validateBranchPolicies(config *types.RepoConfig) error {
+	for i, policy := range config.BranchPolicies {
+		if policy.Branch == "" {
+			return fmt.Errorf("branch policy %d: branch pattern cannot be empty", i)
+		}
+		if _, err := path.Match(policy.Branch, ""); err != nil {
+			return fmt.Errorf("branch policy %d: invalid branch pattern %q: %w", i, policy.Branch, err)
+		}
+		if policy.Mode != "whitelist" && policy.Mode != "blacklist" {
+			return fmt.Errorf("branch policy %d: invalid mode %q (use whitelist or blacklist)", i, policy.Mode)
+		}
+		if len(policy.Types) == 0 {
+			return fmt.Errorf("branch policy %d: types cannot be empty", i)
+		}
+	}
+
+	return nil
+}
+
+// validateVerification ensures verification severities are recognized.
+func validateVerification(config *types.RepoConfig) error {
+	for name, severity := range map[string]string{
+		"extraFiles":   config.Verification.ExtraFiles,
+		"missingFiles": config.Verification.MissingFiles,
+	} {
+		switch severity {
+		case "", types.SeverityIgnore, types.SeverityWarn, types.SeverityError:
+		default:
+			return fmt.Errorf("verification %s: invalid severity %q (use ignore, warn, or error)", name, severity)
+		}
+	}
+
+	return nil
+}
+
+// validateScopePolicy ensures the scope policy is recognized and that a
+// required scope has somewhere to go in the convention's subjects.
+func validateScopePolicy(config *types.RepoConfig) error {
+	switch config.ScopePolicy {
+	case "", types.ScopePolicyOptional, types.ScopePolicyForbidden:
+	case types.ScopePolicyRequired:
+		if config.Convention == convention.NamePlain || config.Convention == convention.NameTicket {
+			return fmt.Errorf("scopePolicy %q: the %s convention has no scope", config.ScopePolicy, config.Convention)
+		}
+	default:
+		return fmt.Errorf("invalid scopePolicy %q (use required, optional, or forbidden)", config.ScopePolicy)
+	}
+
+	return nil
+}
+
 // sortScopesBySpecificity sorts scopes by path length (longest first).
 // This ensures more specific paths are matched before general ones.
 func sortScopesBySpecificity(config *types.RepoConfig) {
@@ -132,6 +226,29 @@ func ResolveScope(filePath string, config *types.RepoConfig) string {
 	return ""
 }
 
+// genericPathSegments are directory names too common to make a useful scope.
+var genericPathSegments = map[string]bool{
+	"src":      true,
+	"internal": true,
+	"pkg":      true,
+	"lib":      true,
+}
+
+// SuggestScope derives a fallback scope from the first meaningful directory
+// in filePath, skipping generic ones like src/ and internal/. Files without
+// such a directory get no suggestion.
+func SuggestScope(filePath string) string {
+	segments := strings.Split(filepath.ToSlash(filePath), "/")
+	for _, segment := range segments[:len(segments)-1] {
+		segment = strings.ToLower(strings.TrimPrefix(segment, "."))
+		if segment == "" || genericPathSegments[segment] {
+			continue
+		}
+		return segment
+	}
+	return ""
+}
+
 // HasScopes returns true if the config has any scope definitions.
 func HasScopes(config *types.RepoConfig) bool {
 	return config != nil && len(config.Scopes) > 0
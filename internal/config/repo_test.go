@@ -258,6 +258,23 @@ func TestResolveScope_NoDefaultScope(t *testing.T) {
 	}
 }
 
+func TestSuggestScope(t *testing.T) {
+	tests := map[string]string{
+		"billing/invoice.go":             "billing",
+		"src/Billing/invoice.go":         "billing",
+		"internal/pkg/auth/token.go":     "auth",
+		".github/workflows/ci.yml":       "github",
+		"README.md":                      "",
+		"src/main.go":                    "",
+		"services/api/handlers/users.go": "services",
+	}
+	for path, want := range tests {
+		if got := SuggestScope(path); got != want {
+			t.Errorf("SuggestScope(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
 func TestResolveScope_NilConfig(t *testing.T) {
 	result := ResolveScope("any/file.go", nil)
 	if result != "" {
@@ -366,3 +383,192 @@ func TestCreateDefaultRepoConfig_DoesNotOverwrite(t *testing.T) {
 		t.Error("config was overwritten")
 	}
 }
+
+func TestLoadRepoConfig_BranchPolicies(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	configContent := `{
+  "branchPolicies": [
+    { "branch": "main", "mode": "blacklist", "types": ["wip"] },
+    { "branch": "release/*", "mode": "whitelist", "types": ["fix", "docs"] }
+  ]
+}`
+	if err := os.WriteFile(filepath.Join(tmpDir, RepoConfigFile), []byte(configContent), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	config, err := LoadRepoConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(config.BranchPolicies) != 2 {
+		t.Fatalf("expected 2 branch policies, got %d", len(config.BranchPolicies))
+	}
+	if config.BranchPolicyViolation("feat", "release/2.0") == nil {
+		t.Error("expected feat to violate release policy")
+	}
+	if config.BranchPolicyViolation("fix", "release/2.0") != nil {
+		t.Error("expected fix to be allowed on release branch")
+	}
+	if config.BranchPolicyViolation("feat", "main") != nil {
+		t.Error("expected feat to be allowed on main")
+	}
+}
+
+func TestLoadRepoConfig_InvalidBranchPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty branch", `{"branchPolicies": [{"branch": "", "mode": "whitelist", "types": ["fix"]}]}`},
+		{"bad mode", `{"branchPolicies": [{"branch": "main", "mode": "allow", "types": ["fix"]}]}`},
+		{"no types", `{"branchPolicies": [{"branch": "main", "mode": "whitelist", "types": []}]}`},
+		{"bad pattern", `{"branchPolicies": [{"branch": "[main", "mode": "whitelist", "types": ["fix"]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, RepoConfigFile), []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+			if _, err := LoadRepoConfig(tmpDir); err == nil {
+				t.Error("expected error for invalid branch policy")
+			}
+		})
+	}
+}
+
+func TestLoadRepoConfig_Verification(t *testing.T) {
+	tmpDir := t.TempDir()
+	content := `{"verification": {"extraFiles": "ignore", "missingFiles": "warn"}}`
+	if err := os.WriteFile(filepath.Join(tmpDir, RepoConfigFile), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	cfg, err := LoadRepoConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadRepoConfig failed: %v", err)
+	}
+
+	if got := cfg.Verification.Severity(types.VerificationExtra); got != types.SeverityIgnore {
+		t.Errorf("extra severity = %q, want %q", got, types.SeverityIgnore)
+	}
+	if got := cfg.Verification.Severity(types.VerificationMissing); got != types.SeverityWarn {
+		t.Errorf("missing severity = %q, want %q", got, types.SeverityWarn)
+	}
+
+	// Invalid severity is rejected
+	content = `{"verification": {"extraFiles": "fatal"}}`
+	if err := os.WriteFile(filepath.Join(tmpDir, RepoConfigFile), []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadRepoConfig(tmpDir); err == nil {
+		t.Error("expected error for invalid verification severity")
+	}
+}
+
+func TestLoadRepoConfig_ExcludeAuthors(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Defaults apply when unset
+	cfg, err := LoadRepoConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadRepoConfig failed: %v", err)
+	}
+	if len(cfg.ExcludedAuthors()) != len(types.DefaultExcludedAuthors()) {
+		t.Errorf("expected default author exclusions, got %v", cfg.ExcludedAuthors())
+	}
+
+	// An explicit empty list disables filtering
+	if err := os.WriteFile(filepath.Join(tmpDir, RepoConfigFile), []byte(`{"excludeAuthors": []}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err = LoadRepoConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadRepoConfig failed: %v", err)
+	}
+	if len(cfg.ExcludedAuthors()) != 0 {
+		t.Errorf("expected no author exclusions, got %v", cfg.ExcludedAuthors())
+	}
+
+	// Invalid patterns are rejected
+	if err := os.WriteFile(filepath.Join(tmpDir, RepoConfigFile), []byte(`{"excludeAuthors": ["[bot"]}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadRepoConfig(tmpDir); err == nil {
+		t.Error("expected error for invalid author pattern")
+	}
+}
+
+func TestLoadRepoConfig_DiffAlgorithm(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, RepoConfigFile)
+
+	if err := os.WriteFile(path, []byte(`{"diffAlgorithm": "histogram", "functionContext": true}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := LoadRepoConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadRepoConfig failed: %v", err)
+	}
+	if cfg.DiffAlgorithm != "histogram" || !cfg.FunctionContext {
+		t.Errorf("got diffAlgorithm %q, functionContext %v", cfg.DiffAlgorithm, cfg.FunctionContext)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"diffAlgorithm": "fast"}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadRepoConfig(tmpDir); err == nil {
+		t.Error("expected error for unknown diff algorithm")
+	}
+}
+
+func TestLoadRepoConfig_HookAutofix(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, RepoConfigFile)
+
+	if err := os.WriteFile(path, []byte(`{"hookAutofix": "fail"}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	cfg, err := LoadRepoConfig(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadRepoConfig failed: %v", err)
+	}
+	if cfg.HookAutofix != types.HookAutofixFail {
+		t.Errorf("got hookAutofix %q", cfg.HookAutofix)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"hookAutofix": "stage"}`), 0644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	if _, err := LoadRepoConfig(tmpDir); err == nil {
+		t.Error("expected error for unknown hookAutofix mode")
+	}
+}
+
+func TestLoadRepoConfig_ScopePolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, RepoConfigFile)
+
+	tests := []struct {
+		json    string
+		wantErr bool
+	}{
+		{`{"scopePolicy": "required"}`, false},
+		{`{"scopePolicy": "forbidden"}`, false},
+		{`{"scopePolicy": "always"}`, true},
+		{`{"scopePolicy": "required", "convention": "plain"}`, true},
+		{`{"scopePolicy": "forbidden", "convention": "plain"}`, false},
+	}
+	for _, tt := range tests {
+		if err := os.WriteFile(path, []byte(tt.json), 0644); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+		_, err := LoadRepoConfig(tmpDir)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("LoadRepoConfig(%s) error = %v, wantErr %v", tt.json, err, tt.wantErr)
+		}
+	}
+}
@@ -3,11 +3,14 @@ package config
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/dsswift/commit/internal/assert"
 	"github.com/dsswift/commit/pkg/types"
@@ -21,7 +24,7 @@ const (
 )
 
 // ValidProviders is the list of supported LLM providers.
-var ValidProviders = []string{"anthropic", "openai", "grok", "gemini", "azure-foundry"}
+var ValidProviders = []string{"anthropic", "openai", "grok", "gemini", "azure-foundry", "azure-openai", "ollama", "openrouter", "mistral", "vertex", "deepseek", "github-models"}
 
 // ConfigPath returns the full path to the user's config directory.
 func ConfigPath() (string, error) {
@@ -32,9 +35,9 @@ func ConfigPath() (string, error) {
 	return filepath.Join(home, ConfigDir), nil
 }
 
-// EnsureConfigDir creates the config directory if it doesn't exist.
+// EnsureConfigDir creates the active profile's config directory if it doesn't exist.
 func EnsureConfigDir() error {
-	path, err := ConfigPath()
+	path, err := ProfilePath()
 	if err != nil {
 		return err
 	}
@@ -52,21 +55,23 @@ func EnsureConfigDir() error {
 	return nil
 }
 
-// LoadUserConfig loads the user configuration from ~/.commit-tool/.env.
+// LoadUserConfig loads the user configuration from ~/.commit-tool/config.yaml
+// when it exists, otherwise from ~/.commit-tool/.env, or from the active
+// profile's .env when a profile is selected.
 func LoadUserConfig() (*types.UserConfig, error) {
-	configPath, err := ConfigPath()
-	if err != nil {
-		return nil, err
+	// A missing named profile is almost always a typo; don't treat it as a fresh install
+	env, err := loadSettings()
+	if os.IsNotExist(err) {
+		configPath, pathErr := ProfilePath()
+		if pathErr != nil {
+			return nil, pathErr
+		}
+		return nil, &ConfigNotFoundError{Path: filepath.Join(configPath, EnvFile)}
 	}
-
-	envPath := filepath.Join(configPath, EnvFile)
-
-	// Check if config file exists
-	if _, err := os.Stat(envPath); os.IsNotExist(err) {
-		return nil, &ConfigNotFoundError{Path: envPath}
+	var notFound *ProfileNotFoundError
+	if errors.As(err, &notFound) {
+		return nil, notFound
 	}
-
-	env, err := parseEnvFile(envPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
@@ -86,7 +91,34 @@ func LoadUserConfig() (*types.UserConfig, error) {
 		AzureFoundryAPIKey:     env["AZURE_FOUNDRY_API_KEY"],
 		AzureFoundryDeployment: env["AZURE_FOUNDRY_DEPLOYMENT"],
 
-		BaseURL: env["COMMIT_BASE_URL"],
+		AzureOpenAIEndpoint:   env["AZURE_OPENAI_ENDPOINT"],
+		AzureOpenAIAPIKey:     env["AZURE_OPENAI_API_KEY"],
+		AzureOpenAIDeployment: env["AZURE_OPENAI_DEPLOYMENT"],
+
+		OpenRouterAPIKey:  env["OPENROUTER_API_KEY"],
+		OpenRouterSiteURL: env["OPENROUTER_SITE_URL"],
+		OpenRouterAppName: env["OPENROUTER_APP_NAME"],
+
+		MistralAPIKey:  env["MISTRAL_API_KEY"],
+		DeepSeekAPIKey: env["DEEPSEEK_API_KEY"],
+
+		VertexProject:     env["VERTEX_PROJECT"],
+		VertexLocation:    env["VERTEX_LOCATION"],
+		VertexCredentials: env["VERTEX_CREDENTIALS"],
+
+		OllamaHost: env["OLLAMA_HOST"],
+
+		BaseURL:       env["COMMIT_BASE_URL"],
+		OpenAIBaseURL: env["COMMIT_OPENAI_BASE_URL"],
+		OpenAIPath:    env["COMMIT_OPENAI_PATH"],
+
+		AnthropicAPIVersion:   env["ANTHROPIC_API_VERSION"],
+		GeminiAPIVersion:      env["GEMINI_API_VERSION"],
+		AzureOpenAIAPIVersion: env["AZURE_OPENAI_API_VERSION"],
+
+		OpenAIOrganization: env["OPENAI_ORGANIZATION"],
+		OpenAIProject:      env["OPENAI_PROJECT"],
+		GeminiProject:      env["GEMINI_PROJECT"],
 	}
 
 	if v := env["COMMIT_TIMEOUT"]; v != "" {
@@ -95,27 +127,97 @@ func LoadUserConfig() (*types.UserConfig, error) {
 		}
 	}
 
+	if v := env["COMMIT_LLM_TEMPERATURE"]; v != "" {
+		temperature, err := strconv.ParseFloat(v, 64)
+		if err != nil || temperature < 0 || temperature > 2 {
+			return nil, &InvalidTemperatureError{Value: v}
+		}
+		config.Temperature = &temperature
+	}
+
+	if v := env["COMMIT_LLM_TOP_P"]; v != "" {
+		topP, err := strconv.ParseFloat(v, 64)
+		if err != nil || topP <= 0 || topP > 1 {
+			return nil, &InvalidTopPError{Value: v}
+		}
+		config.TopP = &topP
+	}
+
+	if v := env["COMMIT_LLM_MAX_TOKENS"]; v != "" {
+		maxTokens, err := strconv.Atoi(v)
+		if err != nil || maxTokens <= 0 {
+			return nil, &InvalidMaxTokensError{Value: v}
+		}
+		config.MaxTokens = maxTokens
+	}
+
+	if v := env["COMMIT_CACHE_TTL"]; v != "" {
+		ttl, err := parseCacheTTL(v)
+		if err != nil {
+			return nil, err
+		}
+		config.CacheTTL = &ttl
+	}
+
+	if v := env["COMMIT_MONTHLY_BUDGET_USD"]; v != "" {
+		budget, err := strconv.ParseFloat(strings.TrimPrefix(v, "$"), 64)
+		if err != nil || budget <= 0 {
+			return nil, &InvalidBudgetError{Value: v}
+		}
+		config.MonthlyBudgetUSD = budget
+	}
+
+	if v := env["COMMIT_EXTRA_HEADERS"]; v != "" {
+		headers, err := ParseHeaders(v)
+		if err != nil {
+			return nil, err
+		}
+		config.ExtraHeaders = headers
+	}
+
 	// Validate provider is set
 	if config.Provider == "" {
 		return nil, &ProviderNotConfiguredError{}
 	}
 
 	// Validate provider is supported
-	validProvider := false
-	for _, p := range ValidProviders {
-		if p == config.Provider {
-			validProvider = true
-			break
-		}
-	}
-	if !validProvider {
+	if !validProvider(config.Provider) {
 		return nil, &InvalidProviderError{Provider: config.Provider}
 	}
 
+	fallbacks, err := parseProviderFallbacks(env["COMMIT_PROVIDER_FALLBACKS"])
+	if err != nil {
+		return nil, err
+	}
+	config.ProviderFallbacks = fallbacks
+
+	// Only look for a GitHub token, which may run gh, when it's needed
+	if usesProvider(config, "github-models") {
+		config.GitHubToken = gitHubToken(env)
+	}
+
+	if err := validateOpenAIEndpoint(config); err != nil {
+		return nil, err
+	}
+
 	// Validate API key is set for the provider
 	if err := validateAPIKey(config); err != nil {
 		return nil, err
 	}
+	for _, spec := range config.ProviderFallbacks {
+		fallback := *config
+		fallback.Provider, _, _ = strings.Cut(spec, "/")
+		if err := validateAPIKey(&fallback); err != nil {
+			return nil, fmt.Errorf("fallback provider %s: %w", spec, err)
+		}
+	}
+
+	// Catch pasted placeholders and keys for the wrong provider before any request
+	if strings.ToLower(env["COMMIT_CHECK_KEY_FORMAT"]) == "true" {
+		if err := CheckAPIKeyFormat(config); err != nil {
+			return nil, err
+		}
+	}
 
 	// Validate default mode if set
 	if config.DefaultMode != "" && config.DefaultMode != "smart" && config.DefaultMode != "single" {
@@ -125,6 +227,68 @@ func LoadUserConfig() (*types.UserConfig, error) {
 	return config, nil
 }
 
+// ParseHeaders parses a COMMIT_EXTRA_HEADERS value: "Name: value" pairs
+// separated by semicolons.
+func ParseHeaders(s string) (map[string]string, error) {
+	headers := make(map[string]string)
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(entry, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, &InvalidHeaderError{Entry: entry}
+		}
+		headers[name] = value
+	}
+	return headers, nil
+}
+
+// parseProviderFallbacks parses COMMIT_PROVIDER_FALLBACKS, a comma-separated
+// list of "provider" or "provider/model" entries such as
+// "openai/gpt-4o-mini, ollama".
+func parseProviderFallbacks(s string) ([]string, error) {
+	var fallbacks []string
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		provider, _, _ := strings.Cut(entry, "/")
+		if !validProvider(provider) {
+			return nil, &InvalidProviderError{Provider: provider}
+		}
+		fallbacks = append(fallbacks, entry)
+	}
+	return fallbacks, nil
+}
+
+// validProvider reports whether provider is one of ValidProviders.
+func validProvider(provider string) bool {
+	for _, p := range ValidProviders {
+		if p == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// usesProvider reports whether config's provider or one of its fallbacks
+// is provider.
+func usesProvider(config *types.UserConfig, provider string) bool {
+	if config.Provider == provider {
+		return true
+	}
+	for _, spec := range config.ProviderFallbacks {
+		if name, _, _ := strings.Cut(spec, "/"); name == provider {
+			return true
+		}
+	}
+	return false
+}
+
 // validateAPIKey ensures the appropriate API key is set for the configured provider.
 func validateAPIKey(config *types.UserConfig) error {
 	switch config.Provider {
@@ -133,7 +297,8 @@ func validateAPIKey(config *types.UserConfig) error {
 			return &MissingAPIKeyError{Provider: "anthropic", EnvVar: "ANTHROPIC_API_KEY"}
 		}
 	case "openai":
-		if config.OpenAIAPIKey == "" {
+		// Self-hosted OpenAI-compatible servers usually need no key
+		if config.OpenAIAPIKey == "" && config.OpenAIBaseURL == "" {
 			return &MissingAPIKeyError{Provider: "openai", EnvVar: "OPENAI_API_KEY"}
 		}
 	case "grok":
@@ -154,6 +319,126 @@ func validateAPIKey(config *types.UserConfig) error {
 		if config.AzureFoundryDeployment == "" {
 			return &MissingAPIKeyError{Provider: "azure-foundry", EnvVar: "AZURE_FOUNDRY_DEPLOYMENT"}
 		}
+	case "azure-openai":
+		if config.AzureOpenAIEndpoint == "" {
+			return &MissingAPIKeyError{Provider: "azure-openai", EnvVar: "AZURE_OPENAI_ENDPOINT"}
+		}
+		if config.AzureOpenAIAPIKey == "" {
+			return &MissingAPIKeyError{Provider: "azure-openai", EnvVar: "AZURE_OPENAI_API_KEY"}
+		}
+		if config.AzureOpenAIDeployment == "" {
+			return &MissingAPIKeyError{Provider: "azure-openai", EnvVar: "AZURE_OPENAI_DEPLOYMENT"}
+		}
+	case "openrouter":
+		if config.OpenRouterAPIKey == "" {
+			return &MissingAPIKeyError{Provider: "openrouter", EnvVar: "OPENROUTER_API_KEY"}
+		}
+	case "mistral":
+		if config.MistralAPIKey == "" {
+			return &MissingAPIKeyError{Provider: "mistral", EnvVar: "MISTRAL_API_KEY"}
+		}
+	case "deepseek":
+		if config.DeepSeekAPIKey == "" {
+			return &MissingAPIKeyError{Provider: "deepseek", EnvVar: "DEEPSEEK_API_KEY"}
+		}
+	case "github-models":
+		if config.GitHubToken == "" {
+			return &MissingAPIKeyError{Provider: "github-models", EnvVar: "GITHUB_TOKEN"}
+		}
+	case "vertex":
+		// Credentials default to Application Default Credentials
+		if config.VertexProject == "" {
+			return &MissingAPIKeyError{Provider: "vertex", EnvVar: "VERTEX_PROJECT"}
+		}
+	}
+	return nil
+}
+
+// APIKeyEnvVar returns the .env variable holding provider's API key.
+func APIKeyEnvVar(provider string) string {
+	switch provider {
+	case "anthropic":
+		return "ANTHROPIC_API_KEY"
+	case "openai":
+		return "OPENAI_API_KEY"
+	case "grok":
+		return "GROK_API_KEY"
+	case "gemini":
+		return "GEMINI_API_KEY"
+	case "azure-foundry":
+		return "AZURE_FOUNDRY_API_KEY"
+	case "azure-openai":
+		return "AZURE_OPENAI_API_KEY"
+	case "openrouter":
+		return "OPENROUTER_API_KEY"
+	case "mistral":
+		return "MISTRAL_API_KEY"
+	case "deepseek":
+		return "DEEPSEEK_API_KEY"
+	case "github-models":
+		return "GITHUB_TOKEN"
+	case "vertex":
+		return "VERTEX_CREDENTIALS"
+	}
+	return ""
+}
+
+// validateOpenAIEndpoint checks COMMIT_OPENAI_BASE_URL is an http(s) URL and
+// gives COMMIT_OPENAI_PATH a leading slash.
+func validateOpenAIEndpoint(config *types.UserConfig) error {
+	if config.OpenAIPath != "" && !strings.HasPrefix(config.OpenAIPath, "/") {
+		config.OpenAIPath = "/" + config.OpenAIPath
+	}
+	if config.OpenAIBaseURL == "" {
+		return nil
+	}
+	u, err := url.Parse(config.OpenAIBaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &InvalidURLError{EnvVar: "COMMIT_OPENAI_BASE_URL", URL: config.OpenAIBaseURL}
+	}
+	return nil
+}
+
+// apiKeyFormats lists the prefix and minimum length of each provider's keys.
+var apiKeyFormats = map[string]struct {
+	prefix    string
+	minLength int
+}{
+	"anthropic":  {"sk-ant-", 40},
+	"openai":     {"sk-", 20},
+	"grok":       {"xai-", 20},
+	"gemini":     {"AIza", 39},
+	"openrouter": {"sk-or-", 20},
+	"mistral":    {"", 32},
+	"deepseek":   {"sk-", 30},
+}
+
+// CheckAPIKeyFormat checks the configured provider's API key against the
+// provider's key format. Keys for a custom BaseURL (proxies, gateways) or
+// OpenAI-compatible server are only checked for stray whitespace and quotes.
+func CheckAPIKeyFormat(config *types.UserConfig) error {
+	envVar := APIKeyEnvVar(config.Provider)
+	key := config.APIKey()
+	if envVar == "" || key == "" {
+		return nil
+	}
+
+	if strings.ContainsAny(key, " \t\"'") {
+		return &InvalidAPIKeyError{Provider: config.Provider, EnvVar: envVar, Reason: "contains whitespace or quotes"}
+	}
+	if config.BaseURL != "" || (config.Provider == "openai" && config.OpenAIBaseURL != "") {
+		return nil
+	}
+
+	format, ok := apiKeyFormats[config.Provider]
+	if !ok {
+		return nil
+	}
+	if !strings.HasPrefix(key, format.prefix) {
+		return &InvalidAPIKeyError{Provider: config.Provider, EnvVar: envVar, Reason: fmt.Sprintf("%s keys start with %q", config.Provider, format.prefix)}
+	}
+	if len(key) < format.minLength {
+		return &InvalidAPIKeyError{Provider: config.Provider, EnvVar: envVar, Reason: "too short; it may be truncated"}
 	}
 	return nil
 }
@@ -208,7 +493,7 @@ func parseEnvFile(path string) (map[string]string, error) {
 
 // CreateDefaultConfig creates a default .env template file.
 func CreateDefaultConfig() error {
-	configPath, err := ConfigPath()
+	configPath, err := ProfilePath()
 	if err != nil {
 		return err
 	}
@@ -230,9 +515,33 @@ func CreateDefaultConfig() error {
 # ═══════════════════════════════════════════════════════════════════════════════
 # PROVIDER SELECTION (required)
 # ═══════════════════════════════════════════════════════════════════════════════
-# Choose one: anthropic | openai | grok | gemini | azure-foundry
+# Choose one: anthropic | openai | grok | gemini | azure-foundry | azure-openai | ollama | openrouter | mistral | vertex | deepseek | github-models
 COMMIT_PROVIDER=
 
+# Providers to try in order when the one above is rate limited, failing or
+# too slow, as provider or provider/model, e.g. openai/gpt-4o-mini,ollama
+# COMMIT_PROVIDER_FALLBACKS=
+
+# Sampling temperature from 0 to 2; defaults to 0.3 (Anthropic's own default
+# for Claude). --deterministic uses 0 and a fixed seed.
+# COMMIT_LLM_TEMPERATURE=
+
+# Nucleus sampling, above 0 and up to 1; unset leaves the provider's default.
+# Some Claude models take either a temperature or top_p, not both.
+# COMMIT_LLM_TOP_P=
+
+# Most tokens the LLM may answer with; defaults to 8192. Raise it if large
+# plans come back truncated, lower it for models with small context windows.
+# COMMIT_LLM_MAX_TOKENS=
+
+# How long an identical request reuses the provider's earlier answer, e.g.
+# 30m or 12h; defaults to 24h. 0 turns the response cache off.
+# COMMIT_CACHE_TTL=
+
+# Monthly spending limit in USD across providers, e.g. 10. Runs warn as it
+# nears; --hard-budget refuses requests that would exceed it.
+# COMMIT_MONTHLY_BUDGET_USD=
+
 # ═══════════════════════════════════════════════════════════════════════════════
 # PUBLIC CLOUD API KEYS (use one matching your provider)
 # ═══════════════════════════════════════════════════════════════════════════════
@@ -249,6 +558,68 @@ AZURE_FOUNDRY_ENDPOINT=
 AZURE_FOUNDRY_API_KEY=
 AZURE_FOUNDRY_DEPLOYMENT=
 
+# ═══════════════════════════════════════════════════════════════════════════════
+# AZURE OPENAI (private cloud - optional)
+# ═══════════════════════════════════════════════════════════════════════════════
+# For an Azure OpenAI resource; AZURE_OPENAI_API_VERSION pins the api-version
+AZURE_OPENAI_ENDPOINT=
+AZURE_OPENAI_API_KEY=
+AZURE_OPENAI_DEPLOYMENT=
+
+# ═══════════════════════════════════════════════════════════════════════════════
+# OPENROUTER (many vendors' models with one key - optional)
+# ═══════════════════════════════════════════════════════════════════════════════
+# Set COMMIT_MODEL to a vendor/model ID, e.g. openai/gpt-4o. The site URL and
+# app name attribute usage in OpenRouter's dashboard
+OPENROUTER_API_KEY=
+# OPENROUTER_SITE_URL=https://github.com/dsswift/commit
+# OPENROUTER_APP_NAME=commit
+
+# ═══════════════════════════════════════════════════════════════════════════════
+# MISTRAL (optional)
+# ═══════════════════════════════════════════════════════════════════════════════
+# COMMIT_MODEL defaults to mistral-large-latest; codestral-latest suits code
+MISTRAL_API_KEY=
+
+# ═══════════════════════════════════════════════════════════════════════════════
+# DEEPSEEK (optional)
+# ═══════════════════════════════════════════════════════════════════════════════
+# COMMIT_MODEL defaults to deepseek-chat; deepseek-reasoner is slower
+DEEPSEEK_API_KEY=
+
+# ═══════════════════════════════════════════════════════════════════════════════
+# GITHUB MODELS (optional)
+# ═══════════════════════════════════════════════════════════════════════════════
+# Defaults to $GITHUB_TOKEN, then the token from gh auth login
+# COMMIT_MODEL defaults to openai/gpt-4.1
+GITHUB_TOKEN=
+
+# ═══════════════════════════════════════════════════════════════════════════════
+# GOOGLE VERTEX AI (private cloud - optional, no API key)
+# ═══════════════════════════════════════════════════════════════════════════════
+# Authenticates with a service account key file, or without one with
+# GOOGLE_APPLICATION_CREDENTIALS, gcloud auth application-default login or the
+# metadata server. VERTEX_LOCATION defaults to us-central1; use global for the
+# global endpoint
+VERTEX_PROJECT=
+# VERTEX_LOCATION=europe-west4
+# VERTEX_CREDENTIALS=/path/to/service-account.json
+
+# ═══════════════════════════════════════════════════════════════════════════════
+# OPENAI-COMPATIBLE SERVER (vLLM, LM Studio, LiteLLM - optional)
+# ═══════════════════════════════════════════════════════════════════════════════
+# With COMMIT_PROVIDER=openai, send requests to this server instead of OpenAI.
+# OPENAI_API_KEY is optional; COMMIT_OPENAI_PATH defaults to /chat/completions
+# COMMIT_OPENAI_BASE_URL=http://localhost:8000/v1
+# COMMIT_OPENAI_PATH=/chat/completions
+
+# ═══════════════════════════════════════════════════════════════════════════════
+# OLLAMA (local models - optional, no API key)
+# ═══════════════════════════════════════════════════════════════════════════════
+# Server address; defaults to http://localhost:11434. Set COMMIT_MODEL to a
+# pulled model (default llama3.1)
+# OLLAMA_HOST=http://localhost:11434
+
 # ═══════════════════════════════════════════════════════════════════════════════
 # OPTIONAL SETTINGS
 # ═══════════════════════════════════════════════════════════════════════════════
@@ -260,6 +631,22 @@ AZURE_FOUNDRY_DEPLOYMENT=
 
 # Default commit mode: smart (multiple semantic commits) or single (one commit)
 # COMMIT_DEFAULT_MODE=smart
+
+# Reject API keys that don't match the provider's key format at startup
+# COMMIT_CHECK_KEY_FORMAT=true
+
+# Attribute usage to a team budget
+# OPENAI_ORGANIZATION=org-...
+# OPENAI_PROJECT=proj_...
+# GEMINI_PROJECT=my-billing-project
+
+# Extra headers sent to any provider, e.g. for a gateway that tracks teams
+# COMMIT_EXTRA_HEADERS=X-Team: payments; X-Cost-Center: 1234
+
+# Pin provider API versions (defaults shown)
+# ANTHROPIC_API_VERSION=2023-06-01
+# GEMINI_API_VERSION=v1beta
+# AZURE_OPENAI_API_VERSION=2024-02-15-preview
 `
 
 	if err := os.WriteFile(envPath, []byte(template), 0600); err != nil {
@@ -269,9 +656,29 @@ AZURE_FOUNDRY_DEPLOYMENT=
 	return nil
 }
 
-// SetConfigValue updates or adds a key-value pair in the .env config file.
+// GetConfigValue returns a single value from config.yaml or the .env config
+// file without validating the rest of the config. A missing file, profile or
+// key returns "".
+func GetConfigValue(key string) (string, error) {
+	env, err := loadSettings()
+	var notFound *ProfileNotFoundError
+	if os.IsNotExist(err) || errors.As(err, &notFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return env[key], nil
+}
+
+// SetConfigValue updates or adds a key-value pair in the .env config file,
+// or the matching setting in config.yaml when it exists.
 func SetConfigValue(key, value string) error {
-	configPath, err := ConfigPath()
+	if path, err := configFilePath(); err == nil && HasConfigFile() {
+		return setYAMLValue(path, key, value)
+	}
+
+	configPath, err := ProfilePath()
 	if err != nil {
 		return err
 	}
@@ -346,6 +753,18 @@ func (e *MissingAPIKeyError) Error() string {
 	return fmt.Sprintf("missing API key for provider %q. Set %s in ~/.commit-tool/.env", e.Provider, e.EnvVar)
 }
 
+// InvalidAPIKeyError indicates the configured API key is malformed or was
+// rejected by the provider.
+type InvalidAPIKeyError struct {
+	Provider string
+	EnvVar   string
+	Reason   string
+}
+
+func (e *InvalidAPIKeyError) Error() string {
+	return fmt.Sprintf("invalid API key for provider %q (%s). Check %s in ~/.commit-tool/.env", e.Provider, e.Reason, e.EnvVar)
+}
+
 // InvalidDefaultModeError indicates an invalid default mode value.
 type InvalidDefaultModeError struct {
 	Mode string
@@ -354,3 +773,83 @@ type InvalidDefaultModeError struct {
 func (e *InvalidDefaultModeError) Error() string {
 	return fmt.Sprintf("invalid default mode %q. Use: smart or single", e.Mode)
 }
+
+// InvalidTemperatureError indicates a COMMIT_LLM_TEMPERATURE that isn't a
+// number from 0 to 2.
+type InvalidTemperatureError struct {
+	Value string
+}
+
+func (e *InvalidTemperatureError) Error() string {
+	return fmt.Sprintf("invalid COMMIT_LLM_TEMPERATURE %q. Use a number from 0 to 2", e.Value)
+}
+
+// InvalidTopPError indicates a COMMIT_LLM_TOP_P that isn't a number above 0
+// and up to 1.
+type InvalidTopPError struct {
+	Value string
+}
+
+func (e *InvalidTopPError) Error() string {
+	return fmt.Sprintf("invalid COMMIT_LLM_TOP_P %q. Use a number above 0 and up to 1", e.Value)
+}
+
+// InvalidMaxTokensError indicates a COMMIT_LLM_MAX_TOKENS that isn't a
+// positive whole number.
+type InvalidMaxTokensError struct {
+	Value string
+}
+
+func (e *InvalidMaxTokensError) Error() string {
+	return fmt.Sprintf("invalid COMMIT_LLM_MAX_TOKENS %q. Use a whole number of tokens greater than 0", e.Value)
+}
+
+// parseCacheTTL parses COMMIT_CACHE_TTL: a Go duration, or a bare 0.
+func parseCacheTTL(v string) (time.Duration, error) {
+	if v == "0" {
+		return 0, nil
+	}
+	ttl, err := time.ParseDuration(v)
+	if err != nil || ttl < 0 {
+		return 0, &InvalidCacheTTLError{Value: v}
+	}
+	return ttl, nil
+}
+
+// InvalidCacheTTLError indicates a COMMIT_CACHE_TTL that isn't a duration.
+type InvalidCacheTTLError struct {
+	Value string
+}
+
+func (e *InvalidCacheTTLError) Error() string {
+	return fmt.Sprintf("invalid COMMIT_CACHE_TTL %q. Use a duration such as 30m or 24h, or 0 to disable", e.Value)
+}
+
+// InvalidBudgetError indicates a COMMIT_MONTHLY_BUDGET_USD that isn't a
+// positive number.
+type InvalidBudgetError struct {
+	Value string
+}
+
+func (e *InvalidBudgetError) Error() string {
+	return fmt.Sprintf("invalid COMMIT_MONTHLY_BUDGET_USD %q. Use an amount in USD greater than 0, e.g. 10", e.Value)
+}
+
+// InvalidHeaderError indicates a malformed COMMIT_EXTRA_HEADERS entry.
+type InvalidHeaderError struct {
+	Entry string
+}
+
+func (e *InvalidHeaderError) Error() string {
+	return fmt.Sprintf("invalid COMMIT_EXTRA_HEADERS entry %q. Use: Name: value; Other-Name: value", e.Entry)
+}
+
+// InvalidURLError indicates a URL setting that isn't an http or https URL.
+type InvalidURLError struct {
+	EnvVar string
+	URL    string
+}
+
+func (e *InvalidURLError) Error() string {
+	return fmt.Sprintf("invalid %s %q. Use an http:// or https:// URL", e.EnvVar, e.URL)
+}
@@ -1,9 +1,13 @@
 package config
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/dsswift/commit/pkg/types"
 )
@@ -172,6 +176,320 @@ COMMIT_DRY_RUN=true`
 	}
 }
 
+func TestLoadUserConfig_UsageHeaders(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+	envContent := `COMMIT_PROVIDER=openai
+OPENAI_API_KEY=sk-test
+OPENAI_ORGANIZATION=org-123
+OPENAI_PROJECT=proj_abc
+GEMINI_PROJECT=billing-1
+COMMIT_EXTRA_HEADERS=X-Team: payments; X-Cost-Center: 12:34;`
+	_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte(envContent), 0600)
+
+	config, err := LoadUserConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.OpenAIOrganization != "org-123" || config.OpenAIProject != "proj_abc" || config.GeminiProject != "billing-1" {
+		t.Errorf("unexpected usage settings: %+v", config)
+	}
+	want := map[string]string{"X-Team": "payments", "X-Cost-Center": "12:34"}
+	if !reflect.DeepEqual(config.ExtraHeaders, want) {
+		t.Errorf("ExtraHeaders = %v, want %v", config.ExtraHeaders, want)
+	}
+
+	for _, bad := range []string{"X-Team", ": value", "X Team: payments"} {
+		var headerErr *InvalidHeaderError
+		if _, err := ParseHeaders(bad); !errors.As(err, &headerErr) {
+			t.Errorf("ParseHeaders(%q) = %v, want InvalidHeaderError", bad, err)
+		}
+	}
+}
+
+func TestLoadUserConfig_Ollama(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+	envContent := `COMMIT_PROVIDER=ollama
+COMMIT_MODEL=qwen2.5-coder
+OLLAMA_HOST=http://gpu-box:11434
+COMMIT_CHECK_KEY_FORMAT=true`
+	_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte(envContent), 0600)
+
+	// No API key is needed for a local server
+	config, err := LoadUserConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.OllamaHost != "http://gpu-box:11434" || config.Model != "qwen2.5-coder" {
+		t.Errorf("unexpected Ollama settings: %+v", config)
+	}
+}
+
+func TestLoadUserConfig_GitHubModelsToken(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+
+	ghCalls := 0
+	gh := "gho_from_gh"
+	orig := ghAuthToken
+	ghAuthToken = func() (string, error) {
+		ghCalls++
+		if gh == "" {
+			return "", errors.New("gh: not logged in")
+		}
+		return gh, nil
+	}
+	defer func() { ghAuthToken = orig }()
+
+	tests := []struct {
+		name      string
+		fileToken string
+		envToken  string
+		ghToken   string
+		want      string
+	}{
+		{"config file first", "ghp_from_file", "ghp_from_env", "gho_from_gh", "ghp_from_file"},
+		{"then environment", "", "ghp_from_env", "gho_from_gh", "ghp_from_env"},
+		{"then gh", "", "", "gho_from_gh", "gho_from_gh"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte("COMMIT_PROVIDER=github-models\nGITHUB_TOKEN="+tt.fileToken), 0600)
+			t.Setenv("GITHUB_TOKEN", tt.envToken)
+			gh = tt.ghToken
+
+			config, err := LoadUserConfig()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if config.GitHubToken != tt.want {
+				t.Errorf("token = %q, want %q", config.GitHubToken, tt.want)
+			}
+		})
+	}
+
+	t.Setenv("GITHUB_TOKEN", "")
+	gh = ""
+	_, err := LoadUserConfig()
+	var missing *MissingAPIKeyError
+	if !errors.As(err, &missing) || missing.EnvVar != "GITHUB_TOKEN" {
+		t.Errorf("expected a missing GITHUB_TOKEN error, got %v", err)
+	}
+
+	// Other providers never run gh
+	ghCalls = 0
+	_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte("COMMIT_PROVIDER=ollama"), 0600)
+	if _, err := LoadUserConfig(); err != nil || ghCalls != 0 {
+		t.Errorf("ollama config: err %v, gh ran %d times", err, ghCalls)
+	}
+}
+
+func TestLoadUserConfig_CacheTTL(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+
+	write := func(ttl string) {
+		content := "COMMIT_PROVIDER=anthropic\nANTHROPIC_API_KEY=sk-ant-test\nCOMMIT_CACHE_TTL=" + ttl
+		_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte(content), 0600)
+	}
+
+	write("")
+	config, err := LoadUserConfig()
+	if err != nil || config.CacheTTL != nil {
+		t.Fatalf("unset: ttl %v, err %v", config.CacheTTL, err)
+	}
+
+	for value, want := range map[string]time.Duration{"0": 0, "30m": 30 * time.Minute, "48h": 48 * time.Hour} {
+		write(value)
+		config, err = LoadUserConfig()
+		if err != nil || config.CacheTTL == nil || *config.CacheTTL != want {
+			t.Errorf("COMMIT_CACHE_TTL=%s: got %v (err %v), want %v", value, config.CacheTTL, err, want)
+		}
+	}
+
+	for _, bad := range []string{"soon", "12", "-1h"} {
+		write(bad)
+		var invalid *InvalidCacheTTLError
+		if _, err := LoadUserConfig(); !errors.As(err, &invalid) || invalid.Value != bad {
+			t.Errorf("COMMIT_CACHE_TTL=%s: expected InvalidCacheTTLError, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoadUserConfig_MonthlyBudget(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+
+	write := func(budget string) {
+		content := "COMMIT_PROVIDER=anthropic\nANTHROPIC_API_KEY=sk-ant-test\nCOMMIT_MONTHLY_BUDGET_USD=" + budget
+		_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte(content), 0600)
+	}
+
+	for value, want := range map[string]float64{"": 0, "10": 10, "2.50": 2.5, "$25": 25} {
+		write(value)
+		config, err := LoadUserConfig()
+		if err != nil || config.MonthlyBudgetUSD != want {
+			t.Errorf("COMMIT_MONTHLY_BUDGET_USD=%s: got %v (err %v), want %v", value, config, err, want)
+		}
+	}
+
+	for _, bad := range []string{"ten", "0", "-5"} {
+		write(bad)
+		var invalid *InvalidBudgetError
+		if _, err := LoadUserConfig(); !errors.As(err, &invalid) || invalid.Value != bad {
+			t.Errorf("COMMIT_MONTHLY_BUDGET_USD=%s: expected InvalidBudgetError, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoadUserConfig_GenerationLimits(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+
+	write := func(settings string) {
+		content := "COMMIT_PROVIDER=anthropic\nANTHROPIC_API_KEY=sk-ant-test\n" + settings
+		_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte(content), 0600)
+	}
+
+	write("")
+	config, err := LoadUserConfig()
+	if err != nil || config.TopP != nil || config.MaxTokens != 0 {
+		t.Fatalf("unset: top_p %v, max tokens %d, err %v", config.TopP, config.MaxTokens, err)
+	}
+
+	write("COMMIT_LLM_TOP_P=0.9\nCOMMIT_LLM_MAX_TOKENS=4096")
+	config, err = LoadUserConfig()
+	if err != nil || config.TopP == nil || *config.TopP != 0.9 || config.MaxTokens != 4096 {
+		t.Fatalf("got top_p %v, max tokens %d, err %v", config.TopP, config.MaxTokens, err)
+	}
+
+	for _, bad := range []string{"0", "1.5", "most"} {
+		write("COMMIT_LLM_TOP_P=" + bad)
+		var invalid *InvalidTopPError
+		if _, err := LoadUserConfig(); !errors.As(err, &invalid) || invalid.Value != bad {
+			t.Errorf("COMMIT_LLM_TOP_P=%s: expected InvalidTopPError, got %v", bad, err)
+		}
+	}
+	for _, bad := range []string{"0", "-1", "4k"} {
+		write("COMMIT_LLM_MAX_TOKENS=" + bad)
+		var invalid *InvalidMaxTokensError
+		if _, err := LoadUserConfig(); !errors.As(err, &invalid) || invalid.Value != bad {
+			t.Errorf("COMMIT_LLM_MAX_TOKENS=%s: expected InvalidMaxTokensError, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoadUserConfig_Temperature(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+
+	write := func(temperature string) {
+		content := "COMMIT_PROVIDER=anthropic\nANTHROPIC_API_KEY=sk-ant-test\nCOMMIT_LLM_TEMPERATURE=" + temperature
+		_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte(content), 0600)
+	}
+
+	write("")
+	config, err := LoadUserConfig()
+	if err != nil || config.Temperature != nil {
+		t.Fatalf("unset: temperature %v, err %v", config.Temperature, err)
+	}
+
+	write("0")
+	config, err = LoadUserConfig()
+	if err != nil || config.Temperature == nil || *config.Temperature != 0 {
+		t.Fatalf("expected temperature 0, got %v (err %v)", config.Temperature, err)
+	}
+
+	for _, bad := range []string{"warm", "-0.1", "2.5"} {
+		write(bad)
+		var invalid *InvalidTemperatureError
+		if _, err := LoadUserConfig(); !errors.As(err, &invalid) || invalid.Value != bad {
+			t.Errorf("COMMIT_LLM_TEMPERATURE=%s: expected InvalidTemperatureError, got %v", bad, err)
+		}
+	}
+}
+
+func TestLoadUserConfig_ProviderFallbacks(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+
+	write := func(content string) {
+		_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte(content), 0600)
+	}
+
+	write("COMMIT_PROVIDER=anthropic\nANTHROPIC_API_KEY=sk-ant-test\nOPENAI_API_KEY=sk-test\nCOMMIT_PROVIDER_FALLBACKS=openai/gpt-4o-mini, ollama,")
+	config, err := LoadUserConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"openai/gpt-4o-mini", "ollama"}; !reflect.DeepEqual(config.ProviderFallbacks, want) {
+		t.Errorf("fallbacks = %v, want %v", config.ProviderFallbacks, want)
+	}
+
+	write("COMMIT_PROVIDER=anthropic\nANTHROPIC_API_KEY=sk-ant-test\nCOMMIT_PROVIDER_FALLBACKS=openia")
+	var invalid *InvalidProviderError
+	if _, err := LoadUserConfig(); !errors.As(err, &invalid) || invalid.Provider != "openia" {
+		t.Errorf("expected an invalid provider error, got %v", err)
+	}
+
+	// A fallback without a key would only fail when it's needed
+	write("COMMIT_PROVIDER=anthropic\nANTHROPIC_API_KEY=sk-ant-test\nCOMMIT_PROVIDER_FALLBACKS=deepseek")
+	var missing *MissingAPIKeyError
+	if _, err := LoadUserConfig(); !errors.As(err, &missing) || missing.EnvVar != "DEEPSEEK_API_KEY" {
+		t.Errorf("expected a missing DEEPSEEK_API_KEY error, got %v", err)
+	}
+}
+
+func TestLoadUserConfig_OpenAICompatible(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+	envPath := filepath.Join(configDir, EnvFile)
+	envContent := `COMMIT_PROVIDER=openai
+COMMIT_MODEL=qwen2.5-coder
+COMMIT_OPENAI_BASE_URL=http://localhost:1234/v1
+COMMIT_OPENAI_PATH=chat/completions
+OPENAI_API_KEY=lm-studio
+COMMIT_CHECK_KEY_FORMAT=true`
+	_ = os.WriteFile(envPath, []byte(envContent), 0600)
+
+	// Local servers take any key, so its format isn't checked
+	config, err := LoadUserConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config.OpenAIBaseURL != "http://localhost:1234/v1" || config.OpenAIPath != "/chat/completions" {
+		t.Errorf("unexpected endpoint settings: %q %q", config.OpenAIBaseURL, config.OpenAIPath)
+	}
+
+	_ = os.WriteFile(envPath, []byte("COMMIT_PROVIDER=openai\nCOMMIT_OPENAI_BASE_URL=localhost:1234/v1\n"), 0600)
+	var urlErr *InvalidURLError
+	if _, err := LoadUserConfig(); !errors.As(err, &urlErr) || urlErr.EnvVar != "COMMIT_OPENAI_BASE_URL" {
+		t.Errorf("expected InvalidURLError, got %v", err)
+	}
+}
+
 func TestLoadUserConfig_ValidAzureFoundryConfig(t *testing.T) {
 	tmpDir, _ := os.MkdirTemp("", "config-test-*")
 	defer os.RemoveAll(tmpDir) //nolint:errcheck // test cleanup
@@ -182,7 +500,8 @@ func TestLoadUserConfig_ValidAzureFoundryConfig(t *testing.T) {
 	envContent := `COMMIT_PROVIDER=azure-foundry
 AZURE_FOUNDRY_ENDPOINT=https://test.openai.azure.com
 AZURE_FOUNDRY_API_KEY=test-key
-AZURE_FOUNDRY_DEPLOYMENT=gpt-4`
+AZURE_FOUNDRY_DEPLOYMENT=gpt-4
+AZURE_OPENAI_API_VERSION=2025-01-01-preview`
 	_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte(envContent), 0600)
 
 	config, err := LoadUserConfig()
@@ -202,6 +521,9 @@ AZURE_FOUNDRY_DEPLOYMENT=gpt-4`
 	if config.AzureFoundryDeployment != "gpt-4" {
 		t.Errorf("unexpected deployment: %q", config.AzureFoundryDeployment)
 	}
+	if config.AzureOpenAIAPIVersion != "2025-01-01-preview" {
+		t.Errorf("unexpected API version: %q", config.AzureOpenAIAPIVersion)
+	}
 }
 
 func TestEnsureConfigDir(t *testing.T) {
@@ -502,6 +824,93 @@ func TestValidateAPIKey_AllProviders(t *testing.T) {
 			},
 			expectError: false,
 		},
+		// azure-openai
+		{
+			name: "azure-openai missing deployment",
+			config: &types.UserConfig{
+				Provider:            "azure-openai",
+				AzureOpenAIEndpoint: "https://test.openai.azure.com",
+				AzureOpenAIAPIKey:   "test-key",
+			},
+			expectError: true,
+			expectVar:   "AZURE_OPENAI_DEPLOYMENT",
+		},
+		{
+			name: "azure-openai all fields set",
+			config: &types.UserConfig{
+				Provider:              "azure-openai",
+				AzureOpenAIEndpoint:   "https://test.openai.azure.com",
+				AzureOpenAIAPIKey:     "test-key",
+				AzureOpenAIDeployment: "gpt-4o-prod",
+			},
+			expectError: false,
+		},
+		// openrouter
+		{
+			name:        "openrouter missing key",
+			config:      &types.UserConfig{Provider: "openrouter"},
+			expectError: true,
+			expectVar:   "OPENROUTER_API_KEY",
+		},
+		{
+			name:        "openai-compatible server without key",
+			config:      &types.UserConfig{Provider: "openai", OpenAIBaseURL: "http://localhost:8000/v1"},
+			expectError: false,
+		},
+		// mistral
+		{
+			name:        "mistral missing key",
+			config:      &types.UserConfig{Provider: "mistral"},
+			expectError: true,
+			expectVar:   "MISTRAL_API_KEY",
+		},
+		{
+			name:        "mistral with key",
+			config:      &types.UserConfig{Provider: "mistral", MistralAPIKey: "0123456789abcdef0123456789abcdef"},
+			expectError: false,
+		},
+		// deepseek
+		{
+			name:        "deepseek missing key",
+			config:      &types.UserConfig{Provider: "deepseek"},
+			expectError: true,
+			expectVar:   "DEEPSEEK_API_KEY",
+		},
+		{
+			name:        "deepseek with key",
+			config:      &types.UserConfig{Provider: "deepseek", DeepSeekAPIKey: "sk-0123456789abcdef0123456789abcdef"},
+			expectError: false,
+		},
+		// github-models
+		{
+			name:        "github-models missing token",
+			config:      &types.UserConfig{Provider: "github-models"},
+			expectError: true,
+			expectVar:   "GITHUB_TOKEN",
+		},
+		{
+			name:        "github-models with token",
+			config:      &types.UserConfig{Provider: "github-models", GitHubToken: "gho_0123456789abcdef"},
+			expectError: false,
+		},
+		// vertex authenticates with Google credentials, not a key
+		{
+			name:        "vertex missing project",
+			config:      &types.UserConfig{Provider: "vertex"},
+			expectError: true,
+			expectVar:   "VERTEX_PROJECT",
+		},
+		{
+			name:        "vertex with project",
+			config:      &types.UserConfig{Provider: "vertex", VertexProject: "my-project"},
+			expectError: false,
+		},
+		// ollama needs no key
+		{
+			name:        "ollama without key",
+			config:      &types.UserConfig{Provider: "ollama"},
+			expectError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -550,7 +959,7 @@ func TestErrorTypes(t *testing.T) {
 		{
 			name:     "InvalidProviderError",
 			err:      &InvalidProviderError{Provider: "bad"},
-			expected: "invalid provider \"bad\". Supported: [anthropic openai grok gemini azure-foundry]",
+			expected: "invalid provider \"bad\". Supported: [anthropic openai grok gemini azure-foundry azure-openai ollama openrouter mistral vertex deepseek github-models]",
 		},
 		{
 			name:     "MissingAPIKeyError",
@@ -562,6 +971,31 @@ func TestErrorTypes(t *testing.T) {
 			err:      &InvalidDefaultModeError{Mode: "invalid"},
 			expected: "invalid default mode \"invalid\". Use: smart or single",
 		},
+		{
+			name:     "InvalidTemperatureError",
+			err:      &InvalidTemperatureError{Value: "hot"},
+			expected: "invalid COMMIT_LLM_TEMPERATURE \"hot\". Use a number from 0 to 2",
+		},
+		{
+			name:     "InvalidCacheTTLError",
+			err:      &InvalidCacheTTLError{Value: "soon"},
+			expected: "invalid COMMIT_CACHE_TTL \"soon\". Use a duration such as 30m or 24h, or 0 to disable",
+		},
+		{
+			name:     "InvalidTopPError",
+			err:      &InvalidTopPError{Value: "2"},
+			expected: "invalid COMMIT_LLM_TOP_P \"2\". Use a number above 0 and up to 1",
+		},
+		{
+			name:     "InvalidMaxTokensError",
+			err:      &InvalidMaxTokensError{Value: "lots"},
+			expected: "invalid COMMIT_LLM_MAX_TOKENS \"lots\". Use a whole number of tokens greater than 0",
+		},
+		{
+			name:     "InvalidBudgetError",
+			err:      &InvalidBudgetError{Value: "ten"},
+			expected: "invalid COMMIT_MONTHLY_BUDGET_USD \"ten\". Use an amount in USD greater than 0, e.g. 10",
+		},
 	}
 
 	for _, tt := range tests {
@@ -585,3 +1019,52 @@ func containsAt(s, substr string, start int) bool {
 	}
 	return false
 }
+
+func TestCheckAPIKeyFormat(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  types.UserConfig
+		wantErr bool
+	}{
+		{"valid anthropic", types.UserConfig{Provider: "anthropic", AnthropicAPIKey: "sk-ant-api03-" + strings.Repeat("x", 40)}, false},
+		{"openai key for anthropic", types.UserConfig{Provider: "anthropic", AnthropicAPIKey: "sk-proj-" + strings.Repeat("x", 40)}, true},
+		{"valid openai", types.UserConfig{Provider: "openai", OpenAIAPIKey: "sk-proj-" + strings.Repeat("x", 40)}, false},
+		{"truncated openai", types.UserConfig{Provider: "openai", OpenAIAPIKey: "sk-abc"}, true},
+		{"placeholder grok", types.UserConfig{Provider: "grok", GrokAPIKey: "your-key-here"}, true},
+		{"valid gemini", types.UserConfig{Provider: "gemini", GeminiAPIKey: "AIza" + strings.Repeat("x", 35)}, false},
+		{"quoted key", types.UserConfig{Provider: "azure-foundry", AzureFoundryAPIKey: `"abc123"`}, true},
+		{"proxy key", types.UserConfig{Provider: "openai", OpenAIAPIKey: "team-token", BaseURL: "https://gateway.internal/v1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckAPIKeyFormat(&tt.config)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("CheckAPIKeyFormat() = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if e, ok := err.(*InvalidAPIKeyError); !ok || e.EnvVar != APIKeyEnvVar(tt.config.Provider) {
+					t.Errorf("expected InvalidAPIKeyError for %s, got %T: %v", APIKeyEnvVar(tt.config.Provider), err, err)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadUserConfig_CheckKeyFormat(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	configDir := filepath.Join(tmpDir, ConfigDir)
+	_ = os.MkdirAll(configDir, 0700)
+	_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte("COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\n"), 0600)
+
+	// Off by default
+	if _, err := LoadUserConfig(); err != nil {
+		t.Fatalf("LoadUserConfig failed: %v", err)
+	}
+
+	_ = os.WriteFile(filepath.Join(configDir, EnvFile), []byte("COMMIT_PROVIDER=openai\nOPENAI_API_KEY=test-key\nCOMMIT_CHECK_KEY_FORMAT=true\n"), 0600)
+	if _, err := LoadUserConfig(); err == nil {
+		t.Error("expected InvalidAPIKeyError with COMMIT_CHECK_KEY_FORMAT=true")
+	}
+}
@@ -0,0 +1,375 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// yamlNode is a node in the subset of YAML config.yaml is written in:
+// nested mappings whose values are scalars or lists of scalars. Anchors,
+// multi-line scalars and flow mappings aren't supported.
+type yamlNode struct {
+	Scalar string
+	List   []string
+	IsList bool
+	// Keys holds a mapping's keys in file order; Fields is nil for scalars
+	// and lists.
+	Keys   []string
+	Fields map[string]*yamlNode
+}
+
+func newYAMLMap() *yamlNode {
+	return &yamlNode{Fields: make(map[string]*yamlNode)}
+}
+
+func (n *yamlNode) isMap() bool {
+	return n != nil && n.Fields != nil
+}
+
+// get returns the child at key, or nil.
+func (n *yamlNode) get(key string) *yamlNode {
+	if !n.isMap() {
+		return nil
+	}
+	return n.Fields[key]
+}
+
+// keys returns a mapping's keys, or nil.
+func (n *yamlNode) keys() []string {
+	if !n.isMap() {
+		return nil
+	}
+	return n.Keys
+}
+
+// set replaces or appends the child at key.
+func (n *yamlNode) set(key string, child *yamlNode) {
+	if _, ok := n.Fields[key]; !ok {
+		n.Keys = append(n.Keys, key)
+	}
+	n.Fields[key] = child
+}
+
+// child returns the mapping at key, creating it if needed.
+func (n *yamlNode) child(key string) *yamlNode {
+	if c := n.get(key); c.isMap() {
+		return c
+	}
+	c := newYAMLMap()
+	n.set(key, c)
+	return c
+}
+
+// yamlLine is a non-blank line with its comment removed.
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+// parseYAML parses data into a mapping node.
+func parseYAML(data string) (*yamlNode, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(data, "\r\n", "\n"), "\n") {
+		text := strings.TrimRight(stripYAMLComment(raw), " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "\t") {
+			return nil, &YAMLError{Line: i + 1, Message: "tabs can't be used for indentation"}
+		}
+		lines = append(lines, yamlLine{number: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+
+	root := newYAMLMap()
+	if len(lines) == 0 {
+		return root, nil
+	}
+	if lines[0].indent != 0 {
+		return nil, &YAMLError{Line: lines[0].number, Message: "unexpected indentation"}
+	}
+	next, err := parseYAMLMap(lines, 0, 0, root)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, &YAMLError{Line: lines[next].number, Message: "unexpected indentation"}
+	}
+	return root, nil
+}
+
+// parseYAMLMap parses the mapping entries at indent starting at lines[i]
+// into node and returns the index of the first line after them.
+func parseYAMLMap(lines []yamlLine, i, indent int, node *yamlNode) (int, error) {
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		if line.text == "-" || strings.HasPrefix(line.text, "- ") {
+			return 0, &YAMLError{Line: line.number, Message: "list item where a key was expected"}
+		}
+		key, rest, err := splitYAMLKey(line)
+		if err != nil {
+			return 0, err
+		}
+		if _, dup := node.Fields[key]; dup {
+			return 0, &YAMLError{Line: line.number, Message: fmt.Sprintf("duplicate key %q", key)}
+		}
+		i++
+
+		switch {
+		case rest != "":
+			child, err := parseYAMLValue(line, rest)
+			if err != nil {
+				return 0, err
+			}
+			node.set(key, child)
+		case i < len(lines) && lines[i].indent >= indent && isYAMLListItem(lines[i].text):
+			// Lists may sit at the key's indentation or deeper
+			child := &yamlNode{IsList: true}
+			listIndent := lines[i].indent
+			for i < len(lines) && lines[i].indent == listIndent && isYAMLListItem(lines[i].text) {
+				item := strings.TrimSpace(strings.TrimPrefix(lines[i].text, "-"))
+				value, err := parseYAMLScalar(lines[i], item)
+				if err != nil {
+					return 0, err
+				}
+				child.List = append(child.List, value)
+				i++
+			}
+			node.set(key, child)
+		case i < len(lines) && lines[i].indent > indent:
+			child := newYAMLMap()
+			next, err := parseYAMLMap(lines, i, lines[i].indent, child)
+			if err != nil {
+				return 0, err
+			}
+			i = next
+			node.set(key, child)
+		default:
+			node.set(key, &yamlNode{})
+		}
+
+		if i < len(lines) && lines[i].indent > indent {
+			return 0, &YAMLError{Line: lines[i].number, Message: "unexpected indentation"}
+		}
+	}
+	return i, nil
+}
+
+func isYAMLListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// splitYAMLKey splits "key: value" into its key and the rest of the line.
+func splitYAMLKey(line yamlLine) (key, rest string, err error) {
+	text := line.text
+	if text[0] == '"' || text[0] == '\'' {
+		end := closingQuote(text)
+		if end < 0 {
+			return "", "", &YAMLError{Line: line.number, Message: "unterminated quoted key"}
+		}
+		key, err = parseYAMLScalar(line, text[:end+1])
+		if err != nil {
+			return "", "", err
+		}
+		text = text[end+1:]
+		if !strings.HasPrefix(text, ":") {
+			return "", "", &YAMLError{Line: line.number, Message: "expected ':' after key"}
+		}
+		return key, strings.TrimSpace(text[1:]), nil
+	}
+
+	idx := strings.Index(text, ": ")
+	if idx < 0 && strings.HasSuffix(text, ":") {
+		idx = len(text) - 1
+	}
+	if idx <= 0 {
+		return "", "", &YAMLError{Line: line.number, Message: "expected 'key: value'"}
+	}
+	return strings.TrimSpace(text[:idx]), strings.TrimSpace(text[idx+1:]), nil
+}
+
+// parseYAMLValue parses the value after a key: a scalar or a flow list.
+func parseYAMLValue(line yamlLine, rest string) (*yamlNode, error) {
+	switch {
+	case strings.HasPrefix(rest, "["):
+		if !strings.HasSuffix(rest, "]") {
+			return nil, &YAMLError{Line: line.number, Message: "unterminated list"}
+		}
+		node := &yamlNode{IsList: true}
+		for _, item := range splitFlowList(rest[1 : len(rest)-1]) {
+			value, err := parseYAMLScalar(line, item)
+			if err != nil {
+				return nil, err
+			}
+			node.List = append(node.List, value)
+		}
+		return node, nil
+	case strings.HasPrefix(rest, "{"):
+		return nil, &YAMLError{Line: line.number, Message: "flow mappings aren't supported; use an indented block"}
+	case rest == "|" || rest == ">" || strings.HasPrefix(rest, "|-") || strings.HasPrefix(rest, ">-"):
+		return nil, &YAMLError{Line: line.number, Message: "multi-line strings aren't supported"}
+	}
+	value, err := parseYAMLScalar(line, rest)
+	if err != nil {
+		return nil, err
+	}
+	return &yamlNode{Scalar: value}, nil
+}
+
+// parseYAMLScalar unquotes a scalar. Double-quoted scalars support \", \\,
+// \n and \t; single-quoted ones escape a quote by doubling it.
+func parseYAMLScalar(line yamlLine, s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || (s[0] != '"' && s[0] != '\'') {
+		if s == "~" || s == "null" {
+			return "", nil
+		}
+		return s, nil
+	}
+	if closingQuote(s) != len(s)-1 {
+		return "", &YAMLError{Line: line.number, Message: "unterminated or trailing text after quoted string"}
+	}
+
+	body := s[1 : len(s)-1]
+	if s[0] == '\'' {
+		return strings.ReplaceAll(body, "''", "'"), nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' || i == len(body)-1 {
+			b.WriteByte(body[i])
+			continue
+		}
+		i++
+		switch body[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case '"', '\\', '/':
+			b.WriteByte(body[i])
+		default:
+			return "", &YAMLError{Line: line.number, Message: fmt.Sprintf("unsupported escape \\%c", body[i])}
+		}
+	}
+	return b.String(), nil
+}
+
+// closingQuote returns the index of the quote closing the one s starts
+// with, or -1.
+func closingQuote(s string) int {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case s[i] == quote && quote == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// splitFlowList splits the inside of a [a, b] list at commas outside quotes.
+func splitFlowList(s string) []string {
+	var items []string
+	var quote byte
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == '\\' && quote == '"' {
+				i++
+			} else if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == ',':
+			items = append(items, s[start:i])
+			start = i + 1
+		}
+	}
+	if strings.TrimSpace(s[start:]) != "" || len(items) > 0 {
+		items = append(items, s[start:])
+	}
+	return items
+}
+
+// stripYAMLComment removes a "#" comment that isn't inside quotes. A "#"
+// only starts a comment at the start of the line or after whitespace.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			// Quotes only open a string at the start of a key or value
+			if i == 0 || line[i-1] == ' ' || line[i-1] == '[' || line[i-1] == ',' || line[i-1] == '-' {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// writeYAML renders node's entries at the given indentation. Empty
+// mappings are left out.
+func writeYAML(b *strings.Builder, node *yamlNode, indent int) {
+	pad := strings.Repeat(" ", indent)
+	for _, key := range node.Keys {
+		child := node.Fields[key]
+		switch {
+		case child.isMap():
+			if len(child.Keys) == 0 {
+				continue
+			}
+			fmt.Fprintf(b, "%s%s:\n", pad, quoteYAML(key))
+			writeYAML(b, child, indent+2)
+		case child.IsList:
+			items := make([]string, len(child.List))
+			for i, item := range child.List {
+				items[i] = quoteYAML(item)
+			}
+			fmt.Fprintf(b, "%s%s: [%s]\n", pad, quoteYAML(key), strings.Join(items, ", "))
+		default:
+			fmt.Fprintf(b, "%s%s: %s\n", pad, quoteYAML(key), quoteYAML(child.Scalar))
+		}
+	}
+}
+
+// quoteYAML double-quotes s when it would otherwise parse differently.
+func quoteYAML(s string) string {
+	needsQuotes := s == "" || s == "~" || s == "null" ||
+		strings.TrimSpace(s) != s ||
+		strings.ContainsAny(s[:1], "-?:,[]{}#&*!|>'\"%@`") ||
+		strings.Contains(s, ": ") || strings.Contains(s, " #") || strings.HasSuffix(s, ":") ||
+		strings.ContainsAny(s, "\n\t,\"")
+	if !needsQuotes {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
+
+// YAMLError reports a line of config.yaml that couldn't be parsed.
+type YAMLError struct {
+	Line    int
+	Message string
+}
+
+func (e *YAMLError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
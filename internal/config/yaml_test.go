@@ -0,0 +1,106 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	doc, err := parseYAML(`# settings
+provider: anthropic   # trailing comment
+model: "claude # not a comment"
+anthropic:
+  apiKey: 'it''s'
+presets:
+  quick: [-1, "--dry-run"]
+  review:
+  - --review
+  - -m
+  - "two words"
+empty: ~
+`)
+	if err != nil {
+		t.Fatalf("parseYAML failed: %v", err)
+	}
+
+	checks := map[string]string{
+		"provider": doc.get("provider").Scalar,
+		"model":    doc.get("model").Scalar,
+		"apiKey":   doc.get("anthropic").get("apiKey").Scalar,
+		"empty":    doc.get("empty").Scalar,
+	}
+	want := map[string]string{"provider": "anthropic", "model": "claude # not a comment", "apiKey": "it's", "empty": ""}
+	for name, got := range checks {
+		if got != want[name] {
+			t.Errorf("%s = %q, want %q", name, got, want[name])
+		}
+	}
+
+	if got := doc.get("presets").get("quick").List; strings.Join(got, "|") != "-1|--dry-run" {
+		t.Errorf("flow list = %q", got)
+	}
+	if got := doc.get("presets").get("review").List; strings.Join(got, "|") != "--review|-m|two words" {
+		t.Errorf("block list = %q", got)
+	}
+}
+
+func TestParseYAML_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		line int
+	}{
+		{"tab indentation", "a:\n\tb: c\n", 2},
+		{"duplicate key", "a: 1\na: 2\n", 2},
+		{"flow mapping", "a: {b: c}\n", 1},
+		{"multi-line string", "a: |\n  text\n", 1},
+		{"stray indentation", "a: 1\n  b: 2\n", 2},
+		{"unterminated quote", "a: \"open\n", 1},
+		{"missing colon", "just text\n", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML(tt.data)
+			yamlErr, ok := err.(*YAMLError)
+			if !ok {
+				t.Fatalf("expected YAMLError, got %T: %v", err, err)
+			}
+			if yamlErr.Line != tt.line {
+				t.Errorf("line = %d, want %d", yamlErr.Line, tt.line)
+			}
+		})
+	}
+}
+
+func TestWriteYAML_RoundTrip(t *testing.T) {
+	doc := newYAMLMap()
+	doc.set("plain", &yamlNode{Scalar: "value"})
+	doc.set("empty", &yamlNode{Scalar: ""})
+	doc.set("tricky", &yamlNode{Scalar: `a: "b" # c`})
+	doc.set("list", &yamlNode{IsList: true, List: []string{"-1", "two words", "x,y"}})
+	doc.child("nested").set("key", &yamlNode{Scalar: "~"})
+	doc.child("skipped")
+
+	var b strings.Builder
+	writeYAML(&b, doc, 0)
+	if strings.Contains(b.String(), "skipped") {
+		t.Errorf("empty mapping written:\n%s", b.String())
+	}
+
+	parsed, err := parseYAML(b.String())
+	if err != nil {
+		t.Fatalf("written YAML doesn't parse: %v\n%s", err, b.String())
+	}
+	for _, key := range []string{"plain", "empty", "tricky"} {
+		if got, want := parsed.get(key).Scalar, doc.get(key).Scalar; got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+	if got := parsed.get("nested").get("key").Scalar; got != "~" {
+		t.Errorf("nested.key = %q, want %q", got, "~")
+	}
+	if got := parsed.get("list").List; strings.Join(got, "|") != "-1|two words|x,y" {
+		t.Errorf("list = %q", got)
+	}
+}
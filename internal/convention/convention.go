@@ -0,0 +1,285 @@
+// Package convention renders planned commits as subject lines in the style a
+// repository uses and reads such subjects back.
+package convention
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// Names of the built-in conventions, as set in .commit.json.
+const (
+	NameConventional = "conventional"
+	NamePlain        = "plain"
+	NameTicket       = "bracketed-ticket"
+	NameGitmoji      = "gitmoji"
+)
+
+// Names lists the built-in conventions.
+var Names = []string{NameConventional, NamePlain, NameTicket, NameGitmoji}
+
+// DefaultTicketPattern matches issue keys such as JIRA-123.
+const DefaultTicketPattern = `[A-Z][A-Z0-9]+-[0-9]+`
+
+// Convention is a commit subject style. Planned commits always carry a type,
+// scope and message; a convention decides which of them the subject shows.
+type Convention interface {
+	// Name returns the convention's name in .commit.json.
+	Name() string
+	// Format renders c's subject line.
+	Format(c types.PlannedCommit) string
+	// Parse reads a subject written in this convention. Fields the
+	// convention doesn't show are left empty; ok is false if subject doesn't
+	// follow it.
+	Parse(subject string) (c types.PlannedCommit, ok bool)
+	// Form describes the subject format, e.g. "type(scope): message".
+	Form() string
+	// Instructions are the prompt rules telling the LLM how to write
+	// messages for this convention.
+	Instructions() string
+}
+
+// New returns the convention configured in cfg. The bracketed-ticket
+// convention takes its ticket from branch.
+func New(cfg *types.RepoConfig, branch string) (Convention, error) {
+	switch cfg.Convention {
+	case "", NameConventional:
+		return Conventional{}, nil
+	case NamePlain:
+		return Plain{}, nil
+	case NameGitmoji:
+		return Gitmoji{}, nil
+	case NameTicket:
+		pattern := cfg.TicketPattern
+		if pattern == "" {
+			pattern = DefaultTicketPattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ticketPattern %q: %w", pattern, err)
+		}
+		return Ticket{Ticket: re.FindString(branch), Pattern: re}, nil
+	default:
+		return nil, fmt.Errorf("invalid convention %q (use %s)", cfg.Convention, strings.Join(Names, ", "))
+	}
+}
+
+// Instructions returns the prompt rules of the convention called name, or
+// of conventional commits if there is none.
+func Instructions(name string) string {
+	c, err := New(&types.RepoConfig{Convention: name}, "")
+	if err != nil {
+		c = Conventional{}
+	}
+	return c.Instructions()
+}
+
+// active is the convention commits are formatted with. The CLI sets it once
+// per run; code serving several repositories at once passes each one's
+// convention instead.
+var (
+	active   Convention = Conventional{}
+	activeMu sync.RWMutex
+)
+
+// Use makes c the convention Format and Parse use.
+func Use(c Convention) {
+	activeMu.Lock()
+	defer activeMu.Unlock()
+	active = c
+}
+
+// Active returns the convention in use.
+func Active() Convention {
+	activeMu.RLock()
+	defer activeMu.RUnlock()
+	return active
+}
+
+// Format renders c's subject line in the active convention.
+func Format(c types.PlannedCommit) string {
+	return Active().Format(c)
+}
+
+// FormatWith renders c's subject line with f, or in the active convention if
+// f is nil, for callers that may be given a repository's convention.
+func FormatWith(f types.SubjectFormatter, c types.PlannedCommit) string {
+	if f == nil {
+		return Format(c)
+	}
+	return f.Format(c)
+}
+
+// Parse reads a subject written in the active convention.
+func Parse(subject string) (types.PlannedCommit, bool) {
+	return Active().Parse(subject)
+}
+
+// scopeOf returns c's scope, or "".
+func scopeOf(c types.PlannedCommit) string {
+	if c.Scope != nil {
+		return *c.Scope
+	}
+	return ""
+}
+
+// withScope sets c's scope unless it's empty.
+func withScope(c types.PlannedCommit, scope string) types.PlannedCommit {
+	if scope != "" {
+		c.Scope = &scope
+	}
+	return c
+}
+
+// lowercaseRules and sentenceRules end every convention's instructions.
+const (
+	lowercaseRules = `15. Message must be lowercase, imperative mood, no period at end`
+	sentenceRules  = `15. Message must start with a capital letter, use imperative mood and have no period at end`
+)
+
+// Conventional renders "type(scope): message".
+type Conventional struct{}
+
+// conventionalPattern matches type(scope)!: message.
+var conventionalPattern = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^)]*)\))?!?:\s*(.+)$`)
+
+func (Conventional) Name() string { return NameConventional }
+
+func (Conventional) Format(c types.PlannedCommit) string {
+	if scope := scopeOf(c); scope != "" {
+		return fmt.Sprintf("%s(%s): %s", c.Type, scope, c.Message)
+	}
+	return fmt.Sprintf("%s: %s", c.Type, c.Message)
+}
+
+func (Conventional) Parse(subject string) (types.PlannedCommit, bool) {
+	m := conventionalPattern.FindStringSubmatch(subject)
+	if m == nil {
+		return types.PlannedCommit{}, false
+	}
+	return withScope(types.PlannedCommit{Type: m[1], Message: m[3]}, m[2]), true
+}
+
+func (Conventional) Form() string { return "type(scope): message" }
+
+func (Conventional) Instructions() string {
+	return `14. Use conventional commit format: "type(scope): message"
+` + lowercaseRules
+}
+
+// Plain renders the message alone.
+type Plain struct{}
+
+func (Plain) Name() string { return NamePlain }
+
+func (Plain) Format(c types.PlannedCommit) string { return c.Message }
+
+func (Plain) Parse(subject string) (types.PlannedCommit, bool) {
+	subject = strings.TrimSpace(subject)
+	return types.PlannedCommit{Message: subject}, subject != ""
+}
+
+func (Plain) Form() string { return "Summary" }
+
+func (Plain) Instructions() string {
+	return `14. Subjects are the message alone, without type or scope (e.g. "Add logout button"); still return a type and scope for each commit
+` + sentenceRules
+}
+
+// Ticket renders "[TICKET-123] Message", with the ticket taken from the
+// branch name. Without a ticket it renders the message alone.
+type Ticket struct {
+	Ticket  string
+	Pattern *regexp.Regexp // what a ticket looks like
+}
+
+// ticketSubject matches "[ticket] message".
+var ticketSubject = regexp.MustCompile(`^\[([^\]]+)\]\s+(.+)$`)
+
+func (Ticket) Name() string { return NameTicket }
+
+func (t Ticket) Format(c types.PlannedCommit) string {
+	if t.Ticket == "" {
+		return c.Message
+	}
+	return fmt.Sprintf("[%s] %s", t.Ticket, c.Message)
+}
+
+func (t Ticket) Parse(subject string) (types.PlannedCommit, bool) {
+	m := ticketSubject.FindStringSubmatch(subject)
+	if m == nil || (t.Pattern != nil && !t.Pattern.MatchString(m[1])) {
+		return types.PlannedCommit{}, false
+	}
+	return types.PlannedCommit{Message: m[2]}, true
+}
+
+func (Ticket) Form() string { return "[TICKET-123] Summary" }
+
+func (Ticket) Instructions() string {
+	return `14. Subjects are rendered as "[TICKET-123] Message" and the tool adds the ticket, so write only the message; still return a type and scope for each commit
+` + sentenceRules
+}
+
+// Gitmoji renders the type as its gitmoji: "✨ (scope): message".
+type Gitmoji struct{}
+
+// gitmojis maps commit types to their emoji. Other types use the chore emoji.
+var gitmojis = map[string]string{
+	"feat":     "✨",
+	"fix":      "🐛",
+	"docs":     "📝",
+	"style":    "🎨",
+	"refactor": "♻️",
+	"perf":     "⚡️",
+	"test":     "✅",
+	"build":    "📦️",
+	"ci":       "💚",
+	"chore":    "🔧",
+	"revert":   "⏪️",
+}
+
+// gitmojiScope matches "(scope): message" after the emoji.
+var gitmojiScope = regexp.MustCompile(`^\(([^)]*)\):\s*(.+)$`)
+
+func (Gitmoji) Name() string { return NameGitmoji }
+
+func (Gitmoji) Format(c types.PlannedCommit) string {
+	emoji, ok := gitmojis[c.Type]
+	if !ok {
+		emoji = gitmojis["chore"]
+	}
+	if scope := scopeOf(c); scope != "" {
+		return fmt.Sprintf("%s (%s): %s", emoji, scope, c.Message)
+	}
+	return fmt.Sprintf("%s %s", emoji, c.Message)
+}
+
+func (Gitmoji) Parse(subject string) (types.PlannedCommit, bool) {
+	for typ, emoji := range gitmojis {
+		// Emoji are often typed without their variation selector
+		rest, ok := strings.CutPrefix(subject, emoji)
+		if !ok {
+			rest, ok = strings.CutPrefix(subject, strings.TrimSuffix(emoji, "️"))
+		}
+		if !ok || !strings.HasPrefix(rest, " ") {
+			continue
+		}
+		rest = strings.TrimSpace(rest)
+		if m := gitmojiScope.FindStringSubmatch(rest); m != nil {
+			return withScope(types.PlannedCommit{Type: typ, Message: m[2]}, m[1]), true
+		}
+		return types.PlannedCommit{Type: typ, Message: rest}, rest != ""
+	}
+	return types.PlannedCommit{}, false
+}
+
+func (Gitmoji) Form() string { return "<gitmoji> (scope): message" }
+
+func (Gitmoji) Instructions() string {
+	return `14. Subjects are rendered as gitmoji: the tool replaces the type with its emoji (feat ✨, fix 🐛, docs 📝, refactor ♻️, test ✅, chore 🔧) followed by "(scope): message"; return the type as usual
+` + lowercaseRules
+}
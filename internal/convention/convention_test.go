@@ -0,0 +1,146 @@
+package convention
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		cfg    types.RepoConfig
+		branch string
+		want   string
+	}{
+		{types.RepoConfig{}, "", NameConventional},
+		{types.RepoConfig{Convention: "plain"}, "", NamePlain},
+		{types.RepoConfig{Convention: "gitmoji"}, "", NameGitmoji},
+		{types.RepoConfig{Convention: "bracketed-ticket"}, "feature/PROJ-42-login", NameTicket},
+	}
+	for _, tt := range tests {
+		c, err := New(&tt.cfg, tt.branch)
+		if err != nil {
+			t.Fatalf("New(%q) error = %v", tt.cfg.Convention, err)
+		}
+		if c.Name() != tt.want {
+			t.Errorf("New(%q).Name() = %q, want %q", tt.cfg.Convention, c.Name(), tt.want)
+		}
+	}
+
+	if _, err := New(&types.RepoConfig{Convention: "angular"}, ""); err == nil || !strings.Contains(err.Error(), "invalid convention") {
+		t.Errorf("expected invalid convention error, got %v", err)
+	}
+	if _, err := New(&types.RepoConfig{Convention: NameTicket, TicketPattern: "["}, ""); err == nil || !strings.Contains(err.Error(), "invalid ticketPattern") {
+		t.Errorf("expected invalid ticketPattern error, got %v", err)
+	}
+}
+
+func TestTicket_FromBranch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		branch  string
+		want    string
+	}{
+		{"", "feature/PROJ-42-login", "PROJ-42"},
+		{"", "main", ""},
+		{`#[0-9]+`, "fix/#118-crash", "#118"},
+	}
+	for _, tt := range tests {
+		c, err := New(&types.RepoConfig{Convention: NameTicket, TicketPattern: tt.pattern}, tt.branch)
+		if err != nil {
+			t.Fatalf("New error = %v", err)
+		}
+		if got := c.(Ticket).Ticket; got != tt.want {
+			t.Errorf("ticket from %q = %q, want %q", tt.branch, got, tt.want)
+		}
+	}
+}
+
+func TestFormatAndParse(t *testing.T) {
+	scope := "api"
+	commit := types.PlannedCommit{Type: "feat", Scope: &scope, Message: "add retry"}
+	unscoped := types.PlannedCommit{Type: "fix", Message: "handle nil config"}
+	ticket, _ := New(&types.RepoConfig{Convention: NameTicket}, "PROJ-7-retry")
+
+	tests := []struct {
+		conv Convention
+		c    types.PlannedCommit
+		want string
+	}{
+		{Conventional{}, commit, "feat(api): add retry"},
+		{Conventional{}, unscoped, "fix: handle nil config"},
+		{Plain{}, commit, "add retry"},
+		{ticket, commit, "[PROJ-7] add retry"},
+		{Gitmoji{}, commit, "✨ (api): add retry"},
+		{Gitmoji{}, unscoped, "🐛 handle nil config"},
+		{Gitmoji{}, types.PlannedCommit{Type: "wip", Message: "try"}, "🔧 try"},
+	}
+	for _, tt := range tests {
+		got := tt.conv.Format(tt.c)
+		if got != tt.want {
+			t.Errorf("%s.Format() = %q, want %q", tt.conv.Name(), got, tt.want)
+			continue
+		}
+		parsed, ok := tt.conv.Parse(got)
+		if !ok {
+			t.Errorf("%s.Parse(%q) failed", tt.conv.Name(), got)
+			continue
+		}
+		if again := tt.conv.Format(types.PlannedCommit{Type: tt.c.Type, Scope: parsed.Scope, Message: parsed.Message}); again != got {
+			t.Errorf("%s.Parse(%q) round-trips to %q", tt.conv.Name(), got, again)
+		}
+	}
+}
+
+func TestTicket_FormatWithoutTicket(t *testing.T) {
+	if got := (Ticket{}).Format(types.PlannedCommit{Type: "feat", Message: "add retry"}); got != "add retry" {
+		t.Errorf("Format without ticket = %q, want the message alone", got)
+	}
+}
+
+func TestParse_Rejects(t *testing.T) {
+	ticket, _ := New(&types.RepoConfig{Convention: NameTicket}, "")
+	tests := []struct {
+		conv    Convention
+		subject string
+	}{
+		{Conventional{}, "Add retry"},
+		{Plain{}, "  "},
+		{ticket, "[wip] Add retry"},
+		{ticket, "PROJ-1 Add retry"},
+		{Gitmoji{}, "feat: add retry"},
+	}
+	for _, tt := range tests {
+		if _, ok := tt.conv.Parse(tt.subject); ok {
+			t.Errorf("%s.Parse(%q) succeeded, want failure", tt.conv.Name(), tt.subject)
+		}
+	}
+}
+
+func TestGitmoji_ParseWithoutVariationSelector(t *testing.T) {
+	c, ok := Gitmoji{}.Parse("♻ (git): split collector")
+	if !ok || c.Type != "refactor" || c.Message != "split collector" || scopeOf(c) != "git" {
+		t.Errorf("Parse = %+v, %v; want refactor(git): split collector", c, ok)
+	}
+}
+
+func TestUse(t *testing.T) {
+	defer Use(Active())
+	Use(Plain{})
+	if got := Format(types.PlannedCommit{Type: "feat", Message: "Add retry"}); got != "Add retry" {
+		t.Errorf("Format with plain convention = %q", got)
+	}
+}
+
+func TestInstructions(t *testing.T) {
+	if got := Instructions(""); !strings.Contains(got, "conventional commit format") {
+		t.Errorf("default instructions = %q", got)
+	}
+	if got := Instructions(NameGitmoji); !strings.Contains(got, "gitmoji") {
+		t.Errorf("gitmoji instructions = %q", got)
+	}
+	if got := Instructions("unknown"); got != Instructions(NameConventional) {
+		t.Errorf("unknown convention should fall back to conventional, got %q", got)
+	}
+}
@@ -0,0 +1,108 @@
+package git
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultAliasName is the git alias installed when no name is given.
+const DefaultAliasName = "sc"
+
+// aliasNamePattern matches names git accepts for aliases.
+var aliasNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*$`)
+
+// Git runs "!" aliases from the repository root and exports the original
+// subdirectory as GIT_PREFIX; changing back to it keeps relative paths working.
+const (
+	aliasPrefix = `!f() { cd "${GIT_PREFIX:-.}" && `
+	aliasSuffix = ` "$@"; }; f`
+)
+
+// AliasCommand returns the alias value that runs binary from the directory
+// git was invoked in.
+func AliasCommand(binary string) string {
+	return aliasPrefix + shellQuote(binary) + aliasSuffix
+}
+
+// IsToolAlias reports whether an alias value was written by InstallAlias.
+func IsToolAlias(value string) bool {
+	return strings.HasPrefix(value, aliasPrefix) && strings.HasSuffix(value, aliasSuffix)
+}
+
+// GlobalAlias returns the value of a global git alias, if it is set.
+func GlobalAlias(name string) (string, bool) {
+	cmd := Command("config", "--global", "--get", "alias."+name)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	return strings.TrimSpace(string(out)), true
+}
+
+// InstallAlias sets the global git alias name to run binary, so the tool can
+// be invoked as "git <name>". An existing alias is only replaced if it was
+// installed by this tool.
+func InstallAlias(name, binary string) error {
+	if !aliasNamePattern.MatchString(name) {
+		return &InvalidAliasError{Name: name}
+	}
+	if existing, ok := GlobalAlias(name); ok && !IsToolAlias(existing) {
+		return &AliasConflictError{Name: name, Existing: existing}
+	}
+
+	cmd := Command("config", "--global", "alias."+name, AliasCommand(binary))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to set git alias: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return nil
+}
+
+// UninstallAlias removes a global git alias installed by InstallAlias.
+// It reports false if the alias wasn't set.
+func UninstallAlias(name string) (bool, error) {
+	if !aliasNamePattern.MatchString(name) {
+		return false, &InvalidAliasError{Name: name}
+	}
+
+	existing, ok := GlobalAlias(name)
+	if !ok {
+		return false, nil
+	}
+	if !IsToolAlias(existing) {
+		return false, &AliasConflictError{Name: name, Existing: existing}
+	}
+
+	cmd := Command("config", "--global", "--unset", "alias."+name)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return false, fmt.Errorf("failed to remove git alias: %s: %w", strings.TrimSpace(string(out)), err)
+	}
+	return true, nil
+}
+
+// shellQuote quotes s for sh when it contains anything but safe characters.
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// InvalidAliasError indicates a name git can't use as an alias.
+type InvalidAliasError struct {
+	Name string
+}
+
+func (e *InvalidAliasError) Error() string {
+	return fmt.Sprintf("invalid alias name %q. Use letters, digits or '-', starting with a letter", e.Name)
+}
+
+// AliasConflictError indicates an alias that exists but wasn't installed by this tool.
+type AliasConflictError struct {
+	Name     string
+	Existing string
+}
+
+func (e *AliasConflictError) Error() string {
+	return fmt.Sprintf("git alias %q is already set to %q; remove it with: git config --global --unset alias.%s", e.Name, e.Existing, e.Name)
+}
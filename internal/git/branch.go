@@ -0,0 +1,57 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dsswift/commit/internal/assert"
+)
+
+// BranchExists reports whether a local branch named name exists.
+func BranchExists(workDir, name string) bool {
+	cmd := Command("rev-parse", "--verify", "--quiet", "refs/heads/"+name)
+	cmd.Dir = workDir
+	return cmd.Run() == nil
+}
+
+// ValidBranchName reports whether git accepts name as a branch name.
+func ValidBranchName(workDir, name string) bool {
+	cmd := Command("check-ref-format", "--branch", name)
+	cmd.Dir = workDir
+	return cmd.Run() == nil
+}
+
+// CreateBranch creates a branch named name at HEAD and switches to it. The
+// index and working tree are carried over unchanged, so changes collected
+// on the old branch are committed on the new one. Before the first commit
+// it renames the unborn branch instead.
+func CreateBranch(workDir, name string) error {
+	// PRECONDITIONS
+	assert.NotEmptyString(name, "branch name cannot be empty")
+	assertWritable("creating a branch")
+
+	if BranchExists(workDir, name) {
+		return &BranchExistsError{Name: name}
+	}
+
+	// EXECUTION
+	cmd := Command("switch", "-c", name)
+	cmd.Dir = workDir
+	if !NewCollector(workDir).HasCommits() {
+		cmd = Command("symbolic-ref", "HEAD", "refs/heads/"+name)
+		cmd.Dir = workDir
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create branch %s: %s", name, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// BranchExistsError indicates a branch to be created already exists.
+type BranchExistsError struct {
+	Name string
+}
+
+func (e *BranchExistsError) Error() string {
+	return fmt.Sprintf("branch %s already exists", e.Name)
+}
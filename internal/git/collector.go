@@ -4,10 +4,16 @@ package git
 import (
 	"bufio"
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/url"
+	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -19,7 +25,40 @@ import (
 // Collector gathers git state information.
 type Collector struct {
 	workDir      string
+	pathspecs    []string
+	excludes     []string
+	diffOptions  DiffOptions
 	cachedStatus *types.GitStatus
+	removedDirs  map[string]int // set with cachedStatus
+	remoteRefs   *RemoteRefs
+}
+
+// DiffAlgorithms lists the values git accepts for --diff-algorithm.
+var DiffAlgorithms = []string{"myers", "minimal", "patience", "histogram"}
+
+// ValidDiffAlgorithm reports whether name is one of DiffAlgorithms.
+func ValidDiffAlgorithm(name string) bool {
+	return slices.Contains(DiffAlgorithms, name)
+}
+
+// DiffOptions controls how diffs are generated. Patience and histogram keep
+// moved and rewritten blocks together where myers interleaves them, and
+// function context shows the whole function around each change.
+type DiffOptions struct {
+	Algorithm       string // empty uses git's default (diff.algorithm or myers)
+	FunctionContext bool
+}
+
+// args returns the git diff options for o.
+func (o DiffOptions) args() []string {
+	var args []string
+	if o.Algorithm != "" {
+		args = append(args, "--diff-algorithm="+o.Algorithm)
+	}
+	if o.FunctionContext {
+		args = append(args, "--function-context")
+	}
+	return args
 }
 
 // NewCollector creates a new git collector for the given directory.
@@ -27,9 +66,50 @@ func NewCollector(workDir string) *Collector {
 	return &Collector{workDir: workDir}
 }
 
-// FindGitRoot finds the root directory of the git repository.
+// SetPathspecs restricts status and diffs to the given pathspecs (relative to
+// the repository root). An empty list means the whole working tree.
+func (c *Collector) SetPathspecs(pathspecs []string) {
+	c.pathspecs = pathspecs
+	c.cachedStatus = nil
+}
+
+// SetExcludes leaves the given files (relative to the repository root) out of
+// status and diffs, e.g. generated files that shouldn't reach the LLM.
+func (c *Collector) SetExcludes(files []string) {
+	c.excludes = files
+	c.cachedStatus = nil
+}
+
+// SetDiffOptions sets the algorithm and context used by Diff.
+func (c *Collector) SetDiffOptions(opts DiffOptions) {
+	c.diffOptions = opts
+}
+
+// SetRemoteRefs makes pushed-status checks use a snapshot of the remote
+// refs instead of the refs as they are when asked.
+func (c *Collector) SetRemoteRefs(refs *RemoteRefs) {
+	c.remoteRefs = refs
+}
+
+// withPathspecs appends the collector's pathspecs and excludes to args, if any.
+func (c *Collector) withPathspecs(args []string) []string {
+	if len(c.pathspecs) == 0 && len(c.excludes) == 0 {
+		return args
+	}
+	args = append(append(args, "--"), c.pathspecs...)
+	for _, f := range c.excludes {
+		args = append(args, ":(exclude,literal)"+f)
+	}
+	return args
+}
+
+// FindGitRoot finds the root directory of the git repository, with any
+// symlinks in it resolved (e.g. macOS's /var, which is /private/var), so
+// paths compared against it or joined to it agree however the repository
+// was reached. Paths inside the repository are kept relative to it, and
+// symlinks there are never resolved: git tracks them as links.
 func FindGitRoot(startDir string) (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd := Command("rev-parse", "--show-toplevel")
 	cmd.Dir = startDir
 
 	out, err := cmd.Output()
@@ -37,12 +117,80 @@ func FindGitRoot(startDir string) (string, error) {
 		return "", fmt.Errorf("not a git repository: %w", err)
 	}
 
-	return strings.TrimSpace(string(out)), nil
+	root := strings.TrimSpace(string(out))
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	return root, nil
+}
+
+// ResolvePathspecs converts command-line paths (relative to cwd) into
+// pathspecs relative to the repository root. The directories leading to each
+// path are resolved like the root, but not the path itself, so a symlink in
+// the repository names the link rather than its target.
+func ResolvePathspecs(gitRoot, cwd string, paths []string) ([]string, error) {
+	root := gitRoot
+	if resolved, err := filepath.EvalSymlinks(gitRoot); err == nil {
+		root = resolved
+	}
+
+	base := cwd
+	if resolved, err := filepath.EvalSymlinks(cwd); err == nil {
+		base = resolved
+	}
+
+	var pathspecs []string
+	for _, p := range paths {
+		abs := p
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(base, p)
+		}
+
+		rel, ok := relativeTo(root, resolveParent(abs))
+		if !ok {
+			// A link to the repository itself, e.g. the root reached through a symlink
+			if resolved, err := filepath.EvalSymlinks(abs); err == nil {
+				rel, ok = relativeTo(root, resolved)
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("path %s is outside the repository", p)
+		}
+		pathspecs = append(pathspecs, filepath.ToSlash(rel))
+	}
+
+	return pathspecs, nil
+}
+
+// relativeTo returns path relative to root, if it's inside root.
+func relativeTo(root, path string) (string, bool) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	return rel, true
+}
+
+// resolveParent resolves symlinks in the directories leading to path,
+// leaving its last element as is. Paths whose directories don't exist are
+// returned unchanged.
+func resolveParent(path string) string {
+	dir, name := filepath.Split(filepath.Clean(path))
+	if name == "" || name == "." || name == ".." {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return resolved
+		}
+		return path
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		return filepath.Join(resolved, name)
+	}
+	return path
 }
 
 // IsGitRepo checks if the current directory is inside a git repository.
 func IsGitRepo(dir string) bool {
-	cmd := exec.Command("git", "rev-parse", "--git-dir")
+	cmd := Command("rev-parse", "--git-dir")
 	cmd.Dir = dir
 	return cmd.Run() == nil
 }
@@ -60,7 +208,7 @@ func (c *Collector) Status() (*types.GitStatus, error) {
 		return c.cachedStatus, nil
 	}
 
-	cmd := exec.Command("git", "status", "--porcelain")
+	cmd := Command(c.withPathspecs([]string{"status", "--porcelain"})...)
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -117,7 +265,8 @@ func (c *Collector) Status() (*types.GitStatus, error) {
 		}
 
 		switch {
-		case entry.indexStatus == 'M' || entry.workTreeStatus == 'M':
+		case entry.indexStatus == 'M' || entry.workTreeStatus == 'M',
+			entry.indexStatus == 'T' || entry.workTreeStatus == 'T': // a file replaced by a symlink or back
 			status.Modified = append(status.Modified, entry.filename)
 		case entry.indexStatus == 'A':
 			status.Added = append(status.Added, entry.filename)
@@ -135,6 +284,9 @@ func (c *Collector) Status() (*types.GitStatus, error) {
 		}
 	}
 
+	// A removed vendored tree is one change, not thousands of deletions
+	status.Deleted, status.Staged, c.removedDirs = c.collapseRemovedDirs(status.Deleted, status.Staged)
+
 	c.cachedStatus = status
 	return status, nil
 }
@@ -146,7 +298,7 @@ func (c *Collector) InvalidateStatusCache() {
 
 // IsIgnored checks if a file is ignored by .gitignore.
 func (c *Collector) IsIgnored(file string) bool {
-	cmd := exec.Command("git", "check-ignore", "-q", file)
+	cmd := Command("check-ignore", "-q", file)
 	cmd.Dir = c.workDir
 	return cmd.Run() == nil
 }
@@ -159,7 +311,7 @@ func (c *Collector) filterIgnoredFiles(files []string) []string {
 	}
 
 	// Use git check-ignore --stdin to batch check
-	cmd := exec.Command("git", "check-ignore", "--stdin")
+	cmd := Command("check-ignore", "--stdin")
 	cmd.Dir = c.workDir
 	cmd.Stdin = strings.NewReader(strings.Join(files, "\n"))
 
@@ -206,6 +358,46 @@ func (c *Collector) filterIgnoredFiles(files []string) []string {
 	return result
 }
 
+// GeneratedFiles returns the subset of files that .gitattributes marks as
+// generated (linguist-generated) or undiffable (-diff), the files GitHub
+// collapses in review.
+func (c *Collector) GeneratedFiles(files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	cmd := Command("check-attr", "--stdin", "-z", "linguist-generated", "diff")
+	cmd.Dir = c.workDir
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\x00"))
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check attributes: %w", err)
+	}
+
+	return parseGeneratedAttrs(string(out)), nil
+}
+
+// parseGeneratedAttrs parses `git check-attr -z` output (path, attribute and
+// value triples) and returns the generated paths in output order.
+func parseGeneratedAttrs(output string) []string {
+	fields := strings.Split(output, "\x00")
+
+	var generated []string
+	seen := make(map[string]bool)
+	for i := 0; i+2 < len(fields); i += 3 {
+		file, attr, value := fields[i], fields[i+1], fields[i+2]
+
+		isGenerated := (attr == "linguist-generated" && (value == "set" || value == "true")) ||
+			(attr == "diff" && value == "unset")
+		if isGenerated && !seen[file] {
+			seen[file] = true
+			generated = append(generated, file)
+		}
+	}
+	return generated
+}
+
 // getTrackedFiles returns the subset of files that are tracked by git.
 func (c *Collector) getTrackedFiles(files []string) []string {
 	if len(files) == 0 {
@@ -214,7 +406,7 @@ func (c *Collector) getTrackedFiles(files []string) []string {
 
 	args := []string{"ls-files", "--error-unmatch", "--"}
 	args = append(args, files...)
-	cmd := exec.Command("git", args...)
+	cmd := Command(args...)
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -249,16 +441,19 @@ func (c *Collector) Diff(stagedOnly bool, files ...string) (string, error) {
 	if stagedOnly {
 		args = append(args, "--staged")
 	} else {
-		args = append(args, "HEAD")
+		args = append(args, c.diffBase())
 	}
+	args = append(args, c.diffOptions.args()...)
 
-	// Add file paths if specified
+	// Add file paths if specified, otherwise limit to the collector's pathspecs
 	if len(files) > 0 {
 		args = append(args, "--")
 		args = append(args, files...)
+	} else {
+		args = c.withPathspecs(args)
 	}
 
-	cmd := exec.Command("git", args...)
+	cmd := Command(args...)
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -275,6 +470,71 @@ func (c *Collector) Diff(stagedOnly bool, files ...string) (string, error) {
 	return string(out), nil
 }
 
+// UntrackedDiff returns new-file diffs for the untracked files under paths,
+// which `git diff HEAD` leaves out. Untracked directories are expanded. At most
+// maxFiles files are included; files larger than maxBytes and binary files are
+// skipped. Symlinks are shown with their targets as content, without being
+// followed.
+func (c *Collector) UntrackedDiff(paths []string, maxFiles, maxBytes int) (string, error) {
+	assert.Positive(maxFiles, "maxFiles must be positive")
+	assert.Positive(maxBytes, "maxBytes must be positive")
+
+	if len(paths) == 0 {
+		return "", nil
+	}
+
+	args := append([]string{"ls-files", "--others", "--exclude-standard", "-z", "--"}, paths...)
+	cmd := Command(args...)
+	cmd.Dir = c.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	var diff strings.Builder
+	included := 0
+	for _, file := range strings.Split(string(out), "\x00") {
+		if file == "" || included >= maxFiles {
+			continue
+		}
+
+		info, err := os.Lstat(filepath.Join(c.workDir, file))
+		if err == nil && info.Mode()&os.ModeSymlink != 0 {
+			if target, _, _ := c.worktreeSymlink(file); target != "" {
+				diff.WriteString(symlinkDiff(file, target))
+				included++
+			}
+			continue
+		}
+		if err != nil || !info.Mode().IsRegular() || info.Size() > int64(maxBytes) {
+			continue
+		}
+
+		// --no-index exits 1 when the files differ, which they always do here
+		cmd := Command("diff", "--no-index", "--no-color", "--", "/dev/null", file)
+		cmd.Dir = c.workDir
+		fileDiff, err := cmd.Output()
+		if err != nil {
+			if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+				continue
+			}
+		}
+		if isBinaryDiff(string(fileDiff)) {
+			continue
+		}
+
+		diff.Write(fileDiff)
+		included++
+	}
+
+	return diff.String(), nil
+}
+
+// isBinaryDiff reports whether a diff is git's placeholder for binary content.
+func isBinaryDiff(diff string) bool {
+	return strings.Contains(diff, "\nBinary files ") || strings.HasPrefix(diff, "Binary files ")
+}
+
 // DiffStat returns a summary of changes (lines added/removed) for each file.
 func (c *Collector) DiffStat(stagedOnly bool) (map[string]string, error) {
 	args := []string{"diff", "--stat"}
@@ -282,10 +542,11 @@ func (c *Collector) DiffStat(stagedOnly bool) (map[string]string, error) {
 	if stagedOnly {
 		args = append(args, "--staged")
 	} else {
-		args = append(args, "HEAD")
+		args = append(args, c.diffBase())
 	}
+	args = c.withPathspecs(args)
 
-	cmd := exec.Command("git", args...)
+	cmd := Command(args...)
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -306,10 +567,11 @@ func (c *Collector) DiffNumstat(stagedOnly bool) (map[string]types.FileChange, e
 	if stagedOnly {
 		args = append(args, "--staged")
 	} else {
-		args = append(args, "HEAD")
+		args = append(args, c.diffBase())
 	}
+	args = c.withPathspecs(args)
 
-	cmd := exec.Command("git", args...)
+	cmd := Command(args...)
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -323,12 +585,86 @@ func (c *Collector) DiffNumstat(stagedOnly bool) (map[string]types.FileChange, e
 	return parseNumstat(string(out)), nil
 }
 
-// RecentCommits returns recent commit messages.
-func (c *Collector) RecentCommits(count int) ([]string, error) {
+// ModeChanges returns a description of each file whose mode changed (e.g. a
+// chmod +x), keyed by path, from `git diff --summary`.
+func (c *Collector) ModeChanges(stagedOnly bool) (map[string]string, error) {
+	args := []string{"diff", "--summary"}
+
+	if stagedOnly {
+		args = append(args, "--staged")
+	} else {
+		args = append(args, c.diffBase())
+	}
+	args = c.withPathspecs(args)
+
+	cmd := Command(args...)
+	cmd.Dir = c.workDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 128 {
+			// No HEAD yet: every file is new, so there are no mode changes
+			return map[string]string{}, nil
+		}
+		return nil, fmt.Errorf("failed to get diff summary: %w", err)
+	}
+
+	return parseModeChanges(string(out)), nil
+}
+
+// parseModeChanges parses " mode change 100644 => 100755 path" lines.
+func parseModeChanges(output string) map[string]string {
+	result := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		rest, ok := strings.CutPrefix(strings.TrimSpace(line), "mode change ")
+		if !ok {
+			continue
+		}
+		var oldMode, newMode string
+		if n, _ := fmt.Sscanf(rest, "%s => %s", &oldMode, &newMode); n != 2 {
+			continue
+		}
+		fields := strings.SplitN(rest, " ", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		result[strings.TrimSpace(fields[3])] = DescribeModeChange(oldMode, newMode)
+	}
+	return result
+}
+
+// DescribeModeChange describes a change between two git file modes.
+func DescribeModeChange(oldMode, newMode string) string {
+	switch {
+	case oldMode == "100644" && newMode == "100755":
+		return "made executable"
+	case oldMode == "100755" && newMode == "100644":
+		return "made non-executable"
+	case newMode == symlinkMode:
+		return "replaced by a symlink"
+	case oldMode == symlinkMode:
+		return "symlink replaced by a file"
+	default:
+		return fmt.Sprintf("mode %s => %s", oldMode, newMode)
+	}
+}
+
+// RecentCommits returns recent commit messages from the current branch's
+// first-parent history, skipping merges, reverts and the commits they revert,
+// and commits whose author name or email matches any of the excludeAuthors
+// glob patterns.
+func (c *Collector) RecentCommits(count int, excludeAuthors ...string) ([]string, error) {
 	assert.Positive(count, "commit count must be positive")
 
-	args := []string{"log", "--oneline", fmt.Sprintf("-%d", count)}
-	cmd := exec.Command("git", args...)
+	// Over-fetch so filtered commits don't starve the result
+	limit := count * 2
+	if len(excludeAuthors) > 0 {
+		limit = count * 4
+	}
+
+	args := []string{"log", "--first-parent", "--no-merges",
+		"--format=%H%x1f%an%x1f%ae%x1f%s%x1f%b%x1e", fmt.Sprintf("-%d", limit)}
+	cmd := Command(args...)
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -340,24 +676,75 @@ func (c *Collector) RecentCommits(count int) ([]string, error) {
 		return nil, fmt.Errorf("failed to get recent commits: %w", err)
 	}
 
-	var commits []string
-	scanner := bufio.NewScanner(bytes.NewReader(out))
+	// Newest first, so a revert is always seen before the commit it undoes
+	var commits, reverted []string
+	for _, record := range strings.Split(string(out), "\x1e") {
+		if len(commits) == count {
+			break
+		}
+		parts := strings.SplitN(strings.TrimSpace(record), "\x1f", 5)
+		if len(parts) != 5 || parts[3] == "" {
+			continue
+		}
+		hash, name, email, subject, body := parts[0], parts[1], parts[2], parts[3], parts[4]
 
-	for scanner.Scan() {
-		line := scanner.Text()
-		// Extract just the message part (skip the hash)
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) == 2 {
-			commits = append(commits, parts[1])
+		// A reverted commit's subject describes a change the branch no longer has
+		if slices.ContainsFunc(reverted, func(prefix string) bool { return strings.HasPrefix(hash, prefix) }) {
+			continue
 		}
+		if IsRevert(subject, body) {
+			if target := RevertedCommit(body); target != "" {
+				reverted = append(reverted, target)
+			}
+			continue
+		}
+		if authorExcluded(name, email, excludeAuthors) {
+			continue
+		}
+		commits = append(commits, subject)
 	}
 
-	return commits, scanner.Err()
+	return commits, nil
+}
+
+// revertedPattern matches the line git revert adds to a revert's message.
+var revertedPattern = regexp.MustCompile(`This reverts commit ([0-9a-f]{7,64})`)
+
+// IsRevert reports whether a commit with this subject and body was made by
+// git revert.
+func IsRevert(subject, body string) bool {
+	return strings.HasPrefix(subject, `Revert "`) || revertedPattern.MatchString(body)
+}
+
+// RevertedCommit returns the hash of the commit a revert's message body
+// names, or "" if it names none.
+func RevertedCommit(body string) string {
+	if m := revertedPattern.FindStringSubmatch(body); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// authorExcluded reports whether the author name or email matches any pattern.
+func authorExcluded(name, email string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, email); ok {
+			return true
+		}
+	}
+	return false
 }
 
 // CurrentBranch returns the name of the current branch.
 func (c *Collector) CurrentBranch() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd := Command("rev-parse", "--abbrev-ref", "HEAD")
+	if !c.HasCommits() {
+		// Before the first commit HEAD only names the branch it will create
+		cmd = Command("symbolic-ref", "--short", "HEAD")
+	}
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -368,9 +755,28 @@ func (c *Collector) CurrentBranch() (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// OriginURL returns the URL of the origin remote without any credentials
+// embedded in it, or "" if there is no origin.
+func (c *Collector) OriginURL() string {
+	cmd := Command("remote", "get-url", "origin")
+	cmd.Dir = c.workDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+
+	remote := strings.TrimSpace(string(out))
+	if u, err := url.Parse(remote); err == nil && u.User != nil && u.Host != "" {
+		u.User = nil
+		remote = u.String()
+	}
+	return remote
+}
+
 // HeadCommit returns the hash of the HEAD commit.
 func (c *Collector) HeadCommit() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "HEAD")
+	cmd := Command("rev-parse", "HEAD")
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -381,9 +787,202 @@ func (c *Collector) HeadCommit() (string, error) {
 	return strings.TrimSpace(string(out)), nil
 }
 
+// WorkingTreeHash returns a hash identifying HEAD plus the exact content of
+// the changes a run would analyze: the staged diff, and unless stagedOnly,
+// the unstaged diff and untracked files. Two calls return the same hash only
+// if nothing in scope changed in between.
+func (c *Collector) WorkingTreeHash(stagedOnly bool) (string, error) {
+	h := sha256.New()
+
+	// An unborn HEAD hashes as empty
+	head, _ := c.HeadCommit()
+	fmt.Fprintf(h, "head %s\n", head)
+
+	diffs := [][]string{{"diff", "--cached", "--binary"}}
+	if !stagedOnly {
+		diffs = append(diffs, []string{"diff", "--binary"})
+	}
+	for _, args := range diffs {
+		cmd := Command(c.withPathspecs(args)...)
+		cmd.Dir = c.workDir
+		out, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("failed to hash working tree: %w", err)
+		}
+		fmt.Fprintf(h, "%s %d\n", args[1], len(out))
+		h.Write(out)
+	}
+
+	if !stagedOnly {
+		untracked, err := c.untrackedBlobs()
+		if err != nil {
+			return "", err
+		}
+		for _, line := range untracked {
+			fmt.Fprintf(h, "untracked %s\n", line)
+		}
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// untrackedBlobs returns "<blob> <path>" for each untracked, non-ignored file.
+func (c *Collector) untrackedBlobs() ([]string, error) {
+	cmd := Command(c.withPathspecs([]string{"ls-files", "--others", "--exclude-standard", "-z"})...)
+	cmd.Dir = c.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	var paths []string
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, nil
+	}
+
+	hashCmd := Command("hash-object", "--stdin-paths")
+	hashCmd.Dir = c.workDir
+	hashCmd.Stdin = strings.NewReader(strings.Join(paths, "\n") + "\n")
+	hashes, err := hashCmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash untracked files: %w", err)
+	}
+
+	blobs := strings.Fields(string(hashes))
+	if len(blobs) != len(paths) {
+		return nil, fmt.Errorf("failed to hash untracked files: expected %d hashes, got %d", len(paths), len(blobs))
+	}
+
+	lines := make([]string, len(paths))
+	for i, p := range paths {
+		lines[i] = blobs[i] + " " + p
+	}
+	return lines, nil
+}
+
+// FileHashes returns a content hash for each of paths as a run would see it:
+// the staged blob when stagedOnly, otherwise the working tree file. An
+// untracked directory hashes the untracked files in it, and files that don't
+// exist (deleted or unstaged) hash as "deleted".
+func (c *Collector) FileHashes(paths []string, stagedOnly bool) (map[string]string, error) {
+	hashes := make(map[string]string, len(paths))
+	for _, p := range paths {
+		hashes[p] = "deleted"
+	}
+	if len(paths) == 0 {
+		return hashes, nil
+	}
+
+	if stagedOnly {
+		cmd := Command(append([]string{"ls-files", "--stage", "-z", "--"}, paths...)...)
+		cmd.Dir = c.workDir
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list staged files: %w", err)
+		}
+		// "<mode> <blob> <stage>\t<path>"
+		for _, entry := range strings.Split(string(out), "\x00") {
+			info, path, ok := strings.Cut(entry, "\t")
+			if fields := strings.Fields(info); ok && len(fields) == 3 {
+				hashes[path] = fields[0] + " " + fields[1]
+			}
+		}
+		return hashes, nil
+	}
+
+	var existing []string
+	for _, p := range paths {
+		info, err := os.Lstat(filepath.Join(c.workDir, p))
+		switch {
+		case err != nil:
+		case info.IsDir():
+			hash, err := c.untrackedDirHash(p)
+			if err != nil {
+				return nil, err
+			}
+			hashes[p] = hash
+		default:
+			existing = append(existing, p)
+		}
+	}
+	if len(existing) == 0 {
+		return hashes, nil
+	}
+
+	cmd := Command("hash-object", "--stdin-paths")
+	cmd.Dir = c.workDir
+	cmd.Stdin = strings.NewReader(strings.Join(existing, "\n") + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash files: %w", err)
+	}
+	blobs := strings.Fields(string(out))
+	if len(blobs) != len(existing) {
+		return nil, fmt.Errorf("failed to hash files: expected %d hashes, got %d", len(existing), len(blobs))
+	}
+	for i, p := range existing {
+		hashes[p] = blobs[i]
+	}
+	return hashes, nil
+}
+
+// untrackedDirHash hashes the paths and content of the untracked files in dir.
+func (c *Collector) untrackedDirHash(dir string) (string, error) {
+	cmd := Command("ls-files", "--others", "--exclude-standard", "-z", "--", dir)
+	cmd.Dir = c.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	var files []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	hashes, err := c.FileHashes(files, false)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	for _, f := range files {
+		fmt.Fprintf(h, "%s %s\n", hashes[f], f)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HeadFiles returns every file path in the HEAD tree. It returns nil before
+// the first commit.
+func (c *Collector) HeadFiles() ([]string, error) {
+	cmd := Command("ls-tree", "-r", "--name-only", "-z", "HEAD")
+	cmd.Dir = c.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 128 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to list HEAD files: %w", err)
+	}
+
+	var files []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
 // IsInitialCommit returns true if HEAD is the first commit.
 func (c *Collector) IsInitialCommit() bool {
-	cmd := exec.Command("git", "rev-parse", "HEAD~1")
+	cmd := Command("rev-parse", "HEAD~1")
 	cmd.Dir = c.workDir
 	return cmd.Run() != nil
 }
@@ -393,9 +992,14 @@ func (c *Collector) IsCommitPushed() (bool, error) {
 	return c.IsRefPushed("HEAD")
 }
 
-// IsRefPushed checks if the given ref exists on any remote branch.
+// IsRefPushed checks if the given ref exists on any remote branch, as of
+// the remote refs snapshot if one is set.
 func (c *Collector) IsRefPushed(ref string) (bool, error) {
-	cmd := exec.Command("git", "branch", "-r", "--contains", ref)
+	if c.remoteRefs != nil {
+		return c.remoteRefs.Contains(ref)
+	}
+
+	cmd := Command("branch", "-r", "--contains", ref)
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -413,7 +1017,7 @@ func (c *Collector) HasCommitDepth(count int) error {
 	assert.Positive(count, "commit depth must be positive")
 
 	ref := fmt.Sprintf("HEAD~%d", count)
-	cmd := exec.Command("git", "rev-parse", "--verify", ref)
+	cmd := Command("rev-parse", "--verify", ref)
 	cmd.Dir = c.workDir
 
 	if err := cmd.Run(); err != nil {
@@ -424,9 +1028,25 @@ func (c *Collector) HasCommitDepth(count int) error {
 	return nil
 }
 
+// LastMerge returns the hash of the newest merge commit among the last count
+// first-parent commits, or "" if there's none.
+func (c *Collector) LastMerge(count int) (string, error) {
+	assert.Positive(count, "commit count must be positive")
+
+	rangeSpec := fmt.Sprintf("HEAD~%d..HEAD", count)
+	cmd := Command("rev-list", "--first-parent", "--min-parents=2", "-n", "1", rangeSpec)
+	cmd.Dir = c.workDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to look for merges in %s: %w", rangeSpec, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
 // countCommits returns the number of commits reachable from HEAD.
 func (c *Collector) countCommits() int {
-	cmd := exec.Command("git", "rev-list", "--count", "HEAD")
+	cmd := Command("rev-list", "--count", "HEAD")
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -523,6 +1143,7 @@ type FileStat struct {
 	Path    string
 	Added   int
 	Removed int
+	Binary  bool // added and removed lines aren't counted
 }
 
 // GetFileStats returns detailed stats for changed files.
@@ -532,10 +1153,11 @@ func (c *Collector) GetFileStats(stagedOnly bool) ([]FileStat, error) {
 	if stagedOnly {
 		args = append(args, "--staged")
 	} else {
-		args = append(args, "HEAD")
+		args = append(args, c.diffBase())
 	}
+	args = c.withPathspecs(args)
 
-	cmd := exec.Command("git", args...)
+	cmd := Command(args...)
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -582,18 +1204,22 @@ type CommitInfo struct {
 	Author    string
 	Date      time.Time
 	IsPushed  bool
+	IsMerge   bool // more than one parent
 }
 
+// commitLogFormat is the git log format parseCommitLog reads:
+// hash|short_hash|author|date_unix|subject, then the parents after a unit
+// separator, since the subject may contain "|".
+const commitLogFormat = "%H|%h|%an|%at|%s%x1f%P"
+
 // GetCommitLog returns detailed commit information for interactive rebase.
-// The commits are returned in reverse chronological order (most recent first).
+// The commits are returned in reverse chronological order (most recent first)
+// along the first-parent history, so a merge stands for the branch it merged.
 func (c *Collector) GetCommitLog(count int) ([]CommitInfo, error) {
 	assert.Positive(count, "commit count must be positive")
 
-	// Use a custom format to get all needed fields
-	// Format: hash|short_hash|author|date_unix|subject
-	format := "%H|%h|%an|%at|%s"
-	args := []string{"log", fmt.Sprintf("-%d", count), "--format=" + format}
-	cmd := exec.Command("git", args...)
+	args := []string{"log", "--first-parent", fmt.Sprintf("-%d", count), "--format=" + commitLogFormat}
+	cmd := Command(args...)
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -612,9 +1238,8 @@ func (c *Collector) GetCommitLog(count int) ([]CommitInfo, error) {
 
 // GetCommitsInRange returns commits between two refs (exclusive of 'from', inclusive of 'to').
 func (c *Collector) GetCommitsInRange(from, to string) ([]CommitInfo, error) {
-	format := "%H|%h|%an|%at|%s"
-	args := []string{"log", "--format=" + format, from + ".." + to}
-	cmd := exec.Command("git", args...)
+	args := []string{"log", "--format=" + commitLogFormat, from + ".." + to}
+	cmd := Command(args...)
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -628,6 +1253,20 @@ func (c *Collector) GetCommitsInRange(from, to string) ([]CommitInfo, error) {
 	return commits, nil
 }
 
+// LogRange returns the non-merge commits in a revision range such as
+// "origin/main..HEAD", most recent first, without pushed status.
+func (c *Collector) LogRange(rangeSpec string) ([]CommitInfo, error) {
+	cmd := Command("log", "--no-merges", "--format="+commitLogFormat, rangeSpec, "--")
+	cmd.Dir = c.workDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commits in %s: %w", rangeSpec, err)
+	}
+
+	return c.parseCommitLog(out), nil
+}
+
 // parseCommitLog parses git log output into CommitInfo structs (without pushed status).
 func (c *Collector) parseCommitLog(out []byte) []CommitInfo {
 	var commits []CommitInfo
@@ -645,12 +1284,14 @@ func (c *Collector) parseCommitLog(out []byte) []CommitInfo {
 			continue // skip commits with unparseable timestamps
 		}
 
+		subject, parents, _ := strings.Cut(parts[4], "\x1f")
 		commits = append(commits, CommitInfo{
 			Hash:      parts[0],
 			ShortHash: parts[1],
 			Author:    parts[2],
 			Date:      time.Unix(dateUnix, 0),
-			Message:   parts[4],
+			Message:   subject,
+			IsMerge:   len(strings.Fields(parents)) > 1,
 		})
 	}
 
@@ -680,7 +1321,7 @@ func (c *Collector) batchResolvePushedStatus(commits []CommitInfo) {
 // has no upstream (all commits are local) or on error (safe default).
 func (c *Collector) getLocalOnlyCommits() map[string]bool {
 	// Check if current branch has an upstream tracking branch
-	upCmd := exec.Command("git", "rev-parse", "--abbrev-ref", "@{upstream}")
+	upCmd := Command("rev-parse", "--symbolic-full-name", "@{upstream}")
 	upCmd.Dir = c.workDir
 
 	upOut, err := upCmd.Output()
@@ -693,9 +1334,17 @@ func (c *Collector) getLocalOnlyCommits() map[string]bool {
 	if upstream == "" {
 		return nil
 	}
+	if c.remoteRefs != nil && strings.HasPrefix(upstream, "refs/remotes/") {
+		// Compare against where the upstream was when the snapshot was taken
+		hash, ok := c.remoteRefs.Commit(upstream)
+		if !ok {
+			return nil
+		}
+		upstream = hash
+	}
 
 	// Get commits on HEAD not reachable from upstream
-	cmd := exec.Command("git", "log", "--format=%H", upstream+"..HEAD")
+	cmd := Command("log", "--format=%H", upstream+"..HEAD")
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
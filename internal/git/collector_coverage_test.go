@@ -594,6 +594,16 @@ func TestCollector_parseCommitLog(t *testing.T) {
 				}
 			},
 		},
+		{
+			name:     "merge commit parents",
+			input:    "dddd3333dddd3333dddd3333dddd3333dddd3333|dddd333|Fay|1700000004|Merge branch 'side'\x1faaaa000 bbbb111\n",
+			expected: 1,
+			check: func(commits []CommitInfo) {
+				if commits[0].Message != "Merge branch 'side'" || !commits[0].IsMerge {
+					t.Errorf("expected a merge with its subject alone, got %+v", commits[0])
+				}
+			},
+		},
 		{
 			name:     "malformed line ignored",
 			input:    "not|enough|fields\naaaa0000aaaa0000aaaa0000aaaa0000aaaa0000|aaaa000|Eve|1700000003|chore: cleanup\n",
@@ -1035,3 +1045,47 @@ func TestCollector_GetCommitLog_WithRemote(t *testing.T) {
 		}
 	}
 }
+
+func TestCollector_ModeChanges(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	testutil.CreateFile(t, repoDir, "scripts/deploy.sh", "#!/bin/sh\n")
+	testutil.CreateFile(t, repoDir, "run.sh", "#!/bin/sh\n")
+	testutil.GitAdd(t, repoDir, "scripts/deploy.sh", "run.sh")
+	testutil.GitCommit(t, repoDir, "initial")
+
+	if err := os.Chmod(filepath.Join(repoDir, "scripts/deploy.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	testutil.CreateFile(t, repoDir, "run.sh", "#!/bin/sh\necho run\n")
+
+	modes, err := NewCollector(repoDir).ModeChanges(false)
+	if err != nil {
+		t.Fatalf("ModeChanges failed: %v", err)
+	}
+	if len(modes) != 1 || modes["scripts/deploy.sh"] != "made executable" {
+		t.Errorf("ModeChanges() = %v, want only scripts/deploy.sh made executable", modes)
+	}
+}
+
+func TestParseModeChanges(t *testing.T) {
+	output := " mode change 100755 => 100644 bin/tool\n" +
+		" create mode 100644 new.go\n" +
+		" mode change 100644 => 100755 scripts/with space.sh\n" +
+		" mode change 100644 => 120000 link\n"
+
+	modes := parseModeChanges(output)
+	want := map[string]string{
+		"bin/tool":              "made non-executable",
+		"scripts/with space.sh": "made executable",
+		"link":                  "replaced by a symlink",
+	}
+	if len(modes) != len(want) {
+		t.Fatalf("parseModeChanges() = %v, want %v", modes, want)
+	}
+	for path, desc := range want {
+		if modes[path] != desc {
+			t.Errorf("modes[%q] = %q, want %q", path, modes[path], desc)
+		}
+	}
+}
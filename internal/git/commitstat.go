@@ -0,0 +1,59 @@
+package git
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CommitFileStats returns the files a commit changed with their added and
+// removed lines. Binary files are marked instead of counted.
+func (c *Collector) CommitFileStats(hash string) ([]FileStat, error) {
+	// -z keeps paths with spaces or quotes intact
+	cmd := Command("show", "--numstat", "--format=", "--no-renames", "-z", hash, "--")
+	cmd.Dir = c.workDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get files of %s: %w", hash, err)
+	}
+
+	return parseNumstatZ(string(out)), nil
+}
+
+// parseNumstatZ parses `--numstat -z` output: "added\tremoved\tpath" records
+// separated by NUL, with "-" counts for binary files.
+func parseNumstatZ(output string) []FileStat {
+	var stats []FileStat
+	for _, record := range strings.Split(output, "\x00") {
+		record = strings.TrimPrefix(record, "\n")
+		parts := strings.SplitN(record, "\t", 3)
+		if len(parts) != 3 || parts[2] == "" {
+			continue
+		}
+		stat := FileStat{Path: parts[2]}
+		if parts[0] == "-" && parts[1] == "-" {
+			stat.Binary = true
+		} else {
+			added, err := strconv.Atoi(parts[0])
+			if err != nil {
+				continue
+			}
+			removed, err := strconv.Atoi(parts[1])
+			if err != nil {
+				continue
+			}
+			stat.Added, stat.Removed = added, removed
+		}
+		stats = append(stats, stat)
+	}
+	return stats
+}
+
+// ShowCommand returns the command that prints a commit with its diff. Run
+// attached to a terminal, git pages it with the user's pager.
+func (c *Collector) ShowCommand(hash string) *Cmd {
+	cmd := Command("show", "--stat", "--patch", hash, "--")
+	cmd.Dir = c.workDir
+	return cmd
+}
@@ -2,17 +2,22 @@ package git
 
 import (
 	"fmt"
-	"os/exec"
+	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/internal/convention"
 	"github.com/dsswift/commit/pkg/types"
 )
 
 // Committer handles git commit operations.
 type Committer struct {
-	workDir string
+	workDir   string
+	pathspecs []string
+	conv      convention.Convention
 }
 
 // NewCommitter creates a new git committer for the given directory.
@@ -20,14 +25,42 @@ func NewCommitter(workDir string) *Committer {
 	return &Committer{workDir: workDir}
 }
 
+// SetConvention sets the convention commit subjects are written in. Without
+// one the active convention is used.
+func (c *Committer) SetConvention(conv convention.Convention) {
+	c.conv = conv
+}
+
+// SetPathspecs scopes commits to the given pathspecs. Changes staged outside
+// them are neither committed nor unstaged.
+func (c *Committer) SetPathspecs(pathspecs []string) {
+	c.pathspecs = pathspecs
+}
+
+// newStager returns a stager sharing the committer's pathspecs.
+func (c *Committer) newStager() *Stager {
+	stager := NewStager(c.workDir)
+	stager.SetPathspecs(c.pathspecs)
+	return stager
+}
+
 // Commit creates a new commit with the given message.
 func (c *Committer) Commit(message string) (string, error) {
+	return c.CommitWithBody(message, "")
+}
+
+// CommitWithBody creates a new commit with the given subject line and an
+// optional body, which git separates with a blank line. The message is
+// passed in a file rather than as arguments, so it reaches git byte for
+// byte however long it is.
+func (c *Committer) CommitWithBody(message, body string) (string, error) {
 	// PRECONDITIONS
 	assert.NotEmptyString(message, "commit message cannot be empty")
-	assert.MaxLength(message, 200, "commit message too long: %d chars", len(message))
+	assert.True(utf8.RuneCountInString(message) <= 200, "commit message too long: %d chars", utf8.RuneCountInString(message))
+	assertWritable("committing")
 
 	// Verify there are staged changes
-	stager := NewStager(c.workDir)
+	stager := c.newStager()
 	hasStaged, err := stager.HasStagedChanges()
 	if err != nil {
 		return "", fmt.Errorf("failed to check staged changes: %w", err)
@@ -35,12 +68,34 @@ func (c *Committer) Commit(message string) (string, error) {
 	assert.True(hasStaged, "no staged changes to commit")
 
 	// EXECUTION
-	cmd := exec.Command("git", "commit", "-m", message)
+	full := message
+	if body != "" {
+		full += "\n\n" + body
+	}
+	msgFile, err := writeMessageFile(full)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(msgFile) //nolint:errcheck // best-effort cleanup
+
+	args := []string{"commit", "--file", msgFile}
+	cmd := Command(args...)
 	cmd.Dir = c.workDir
 
+	if len(c.pathspecs) > 0 {
+		// Commit only the staged paths in scope; --only leaves the rest of the index alone
+		scoped, err := c.scopedStagedPaths()
+		if err != nil {
+			return "", err
+		}
+		cmd = Command(append(args, "--only", "--pathspec-from-file=-", "--pathspec-file-nul")...)
+		cmd.Dir = c.workDir
+		cmd.Stdin = strings.NewReader(strings.Join(scoped, "\x00"))
+	}
+
 	out, err := cmd.CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("failed to commit: %s: %w", string(out), err)
+		return "", &CommitError{Output: string(out), Err: err}
 	}
 
 	// POSTCONDITIONS
@@ -55,26 +110,124 @@ func (c *Committer) Commit(message string) (string, error) {
 	return hash, nil
 }
 
+// writeMessageFile writes a commit message to a temporary file for
+// git commit --file and returns its path.
+func writeMessageFile(message string) (string, error) {
+	f, err := os.CreateTemp("", "commit-msg-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to write commit message: %w", err)
+	}
+	if _, err := f.WriteString(message); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write commit message: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", fmt.Errorf("failed to write commit message: %w", err)
+	}
+	return f.Name(), nil
+}
+
+// Amend folds the staged changes into HEAD, keeping its message.
+func (c *Committer) Amend() (string, error) {
+	// PRECONDITIONS
+	assertWritable("amending")
+	stager := c.newStager()
+	hasStaged, err := stager.HasStagedChanges()
+	if err != nil {
+		return "", fmt.Errorf("failed to check staged changes: %w", err)
+	}
+	assert.True(hasStaged, "no staged changes to amend")
+
+	// EXECUTION
+	cmd := Command("commit", "--amend", "--no-edit")
+	cmd.Dir = c.workDir
+
+	if len(c.pathspecs) > 0 {
+		scoped, err := c.scopedStagedPaths()
+		if err != nil {
+			return "", err
+		}
+		cmd = Command("commit", "--amend", "--no-edit", "--only", "--pathspec-from-file=-", "--pathspec-file-nul")
+		cmd.Dir = c.workDir
+		cmd.Stdin = strings.NewReader(strings.Join(scoped, "\x00"))
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("failed to amend: %s: %w", string(out), err)
+	}
+
+	// POSTCONDITIONS
+	hash, err := c.getLastCommitHash()
+	if err != nil {
+		return "", fmt.Errorf("amend succeeded but failed to get hash: %w", err)
+	}
+
+	assert.NotEmptyString(hash, "commit hash should not be empty after amend")
+
+	return hash, nil
+}
+
 // CommitWithScope creates a commit with type and optional scope.
 func (c *Committer) CommitWithScope(commitType string, scope *string, message string) (string, error) {
 	// PRECONDITIONS
 	assert.NotEmptyString(commitType, "commit type cannot be empty")
 	assert.NotEmptyString(message, "commit message cannot be empty")
 
-	// Build the full commit message
-	var fullMessage string
-	if scope != nil && *scope != "" {
-		fullMessage = fmt.Sprintf("%s(%s): %s", commitType, *scope, message)
-	} else {
-		fullMessage = fmt.Sprintf("%s: %s", commitType, message)
+	return c.Commit(convention.FormatWith(c.conv, types.PlannedCommit{Type: commitType, Scope: scope, Message: message}))
+}
+
+// scopedStagedPaths lists staged paths within the committer's pathspecs.
+// Renames are split so both the old and new paths are committed.
+func (c *Committer) scopedStagedPaths() ([]string, error) {
+	args := append([]string{"diff", "--cached", "--name-only", "--no-renames", "-z", "--"}, c.pathspecs...)
+	cmd := Command(args...)
+	cmd.Dir = c.workDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged files: %w", err)
+	}
+
+	var paths []string
+	for _, p := range strings.Split(string(out), "\x00") {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// RewrittenFiles returns the files among files, or under them, whose working
+// tree differs from the index. Right after a commit staged them in full, that
+// means a pre-commit hook rewrote them.
+func (c *Committer) RewrittenFiles(files []string) ([]string, error) {
+	args := []string{"diff", "--name-only", "--no-renames", "-z"}
+	if len(c.pathspecs) > 0 {
+		args = append(append(args, "--"), c.pathspecs...)
+	}
+	cmd := Command(args...)
+	cmd.Dir = c.workDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unstaged changes: %w", err)
 	}
 
-	return c.Commit(fullMessage)
+	var rewritten []string
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" && MatchesFocus(f, files) {
+			rewritten = append(rewritten, f)
+		}
+	}
+	return rewritten, nil
 }
 
 // getLastCommitHash returns the hash of the most recent commit.
 func (c *Committer) getLastCommitHash() (string, error) {
-	cmd := exec.Command("git", "rev-parse", "--short", "HEAD")
+	cmd := Command("rev-parse", "--short", "HEAD")
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -87,7 +240,7 @@ func (c *Committer) getLastCommitHash() (string, error) {
 
 // GetLastCommitMessage returns the message of the most recent commit.
 func (c *Committer) GetLastCommitMessage() (string, error) {
-	cmd := exec.Command("git", "log", "-1", "--pretty=%B")
+	cmd := Command("log", "-1", "--pretty=%B")
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
@@ -110,30 +263,81 @@ func (c *Committer) VerifyCommit(expectedHash string, expectedFiles []string) er
 		return fmt.Errorf("commit hash mismatch: expected %s, got %s", expectedHash, hash)
 	}
 
-	// Verify files in commit
-	cmd := exec.Command("git", "diff-tree", "--no-commit-id", "--name-only", "-r", "HEAD")
+	committedFiles, err := c.committedFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, expected := range expectedFiles {
+		if !committedFiles[expected] {
+			return fmt.Errorf("expected file not in commit: %s", expected)
+		}
+	}
+
+	return nil
+}
+
+// VerifyCommitFiles compares the files in HEAD against the planned files and
+// reports extra and missing paths. Planned directories are satisfied by any
+// committed file beneath them.
+func (c *Committer) VerifyCommitFiles(expectedFiles []string) (*types.CommitVerification, error) {
+	committedFiles, err := c.committedFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	covered := make(map[string]bool)
+	var missing []string
+	for _, expected := range expectedFiles {
+		expected = filepath.ToSlash(strings.TrimSuffix(expected, "/"))
+		if committedFiles[expected] {
+			covered[expected] = true
+			continue
+		}
+
+		found := false
+		for file := range committedFiles {
+			if strings.HasPrefix(file, expected+"/") {
+				covered[file] = true
+				found = true
+			}
+		}
+		if !found {
+			missing = append(missing, expected)
+		}
+	}
+
+	var extra []string
+	for file := range committedFiles {
+		if !covered[file] {
+			extra = append(extra, file)
+		}
+	}
+	sort.Strings(extra)
+
+	return types.NewCommitVerification(extra, missing), nil
+}
+
+// committedFiles returns the set of paths changed by HEAD.
+func (c *Committer) committedFiles() (map[string]bool, error) {
+	// --root so the initial commit lists its files too
+	cmd := Command("diff-tree", "--root", "--no-commit-id", "--name-only", "-r", "HEAD")
 	cmd.Dir = c.workDir
 
 	out, err := cmd.Output()
 	if err != nil {
-		return fmt.Errorf("failed to get commit files: %w", err)
+		return nil, fmt.Errorf("failed to get commit files: %w", err)
 	}
 
-	committedFiles := make(map[string]bool)
+	files := make(map[string]bool)
 	for _, line := range strings.Split(string(out), "\n") {
 		line = strings.TrimSpace(line)
 		if line != "" {
-			committedFiles[line] = true
-		}
-	}
-
-	for _, expected := range expectedFiles {
-		if !committedFiles[expected] {
-			return fmt.Errorf("expected file not in commit: %s", expected)
+			files[line] = true
 		}
 	}
 
-	return nil
+	return files, nil
 }
 
 // NoStagedFilesError is returned when a commit has no stageable files (e.g., all directories).
@@ -145,6 +349,49 @@ func (e *NoStagedFilesError) Error() string {
 	return fmt.Sprintf("no stageable files in commit (all %d paths were directories)", len(e.PlannedFiles))
 }
 
+// CommitError is returned when git commit itself fails, usually because a
+// pre-commit or commit-msg hook rejected the commit.
+type CommitError struct {
+	Output string
+	Err    error
+}
+
+func (e *CommitError) Error() string {
+	return fmt.Sprintf("failed to commit: %s: %v", e.Output, e.Err)
+}
+
+func (e *CommitError) Unwrap() error {
+	return e.Err
+}
+
+// EmptyCommitError is returned when none of a commit's planned files have
+// changes anymore, e.g. a formatter reverted them or an earlier run already
+// committed them.
+type EmptyCommitError struct {
+	PlannedFiles []string
+}
+
+func (e *EmptyCommitError) Error() string {
+	return fmt.Sprintf("nothing to commit (none of the %d planned files have changes anymore)", len(e.PlannedFiles))
+}
+
+// hasChanges reports whether any of files, or files under them, are changed
+// in the working tree or index.
+func (c *Committer) hasChanges(files []string) (bool, error) {
+	collector := NewCollector(c.workDir)
+	collector.SetPathspecs(c.pathspecs)
+	status, err := collector.Status()
+	if err != nil {
+		return false, err
+	}
+	for _, f := range status.AllFiles() {
+		if MatchesFocus(f, files) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // ExecutePlannedCommit executes a single planned commit.
 func (c *Committer) ExecutePlannedCommit(planned types.PlannedCommit) (*types.ExecutedCommit, error) {
 	// PRECONDITIONS
@@ -160,7 +407,7 @@ func (c *Committer) ExecutePlannedCommit(planned types.PlannedCommit) (*types.Ex
 	}
 
 	// Stage only the files for this commit
-	stager := NewStager(c.workDir)
+	stager := c.newStager()
 
 	// First, unstage everything to start clean
 	if err := stager.UnstageAll(); err != nil {
@@ -169,6 +416,11 @@ func (c *Committer) ExecutePlannedCommit(planned types.PlannedCommit) (*types.Ex
 
 	// Stage the specific files for this commit
 	if err := stager.StageFiles(planned.Files); err != nil {
+		// Files that vanished since planning can't be staged; that's only an
+		// error while something else in the commit is left to stage
+		if changed, statusErr := c.hasChanges(planned.Files); statusErr == nil && !changed {
+			return nil, &EmptyCommitError{PlannedFiles: planned.Files}
+		}
 		return nil, fmt.Errorf("failed to stage files: %w", err)
 	}
 
@@ -181,25 +433,21 @@ func (c *Committer) ExecutePlannedCommit(planned types.PlannedCommit) (*types.Ex
 		return nil, &NoStagedFilesError{PlannedFiles: planned.Files}
 	}
 
+	// Build the full message
+	fullMessage := convention.FormatWith(c.conv, planned)
+
 	// Create the commit
-	hash, err := c.CommitWithScope(planned.Type, planned.Scope, planned.Message)
+	hash, err := c.CommitWithBody(fullMessage, planned.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create commit: %w", err)
 	}
 
-	// Build the full message for the result
-	var fullMessage string
-	if planned.Scope != nil && *planned.Scope != "" {
-		fullMessage = fmt.Sprintf("%s(%s): %s", planned.Type, *planned.Scope, planned.Message)
-	} else {
-		fullMessage = fmt.Sprintf("%s: %s", planned.Type, planned.Message)
-	}
-
 	return &types.ExecutedCommit{
-		Hash:    hash,
-		Type:    planned.Type,
-		Scope:   planned.Scope,
-		Message: fullMessage,
-		Files:   planned.Files,
+		Hash:      hash,
+		Type:      planned.Type,
+		Scope:     planned.Scope,
+		Message:   fullMessage,
+		Files:     planned.Files,
+		Reasoning: planned.Reasoning,
 	}, nil
 }
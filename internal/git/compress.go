@@ -0,0 +1,136 @@
+package git
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MinRepeatedFiles is how many files must share an identical hunk before
+// CompressDiff shows it only once.
+const MinRepeatedFiles = 3
+
+// CompressDiff collapses hunks that make the same change in several files,
+// such as a renamed symbol or a changed import across a package. Hunks whose
+// added and removed lines are identical in at least MinRepeatedFiles files
+// are kept in the first file and followed by the list of other files they
+// also apply to; those files drop the hunk, and files left with no hunks keep
+// only their "diff --git" line. Context lines and line numbers are ignored
+// when comparing hunks.
+func CompressDiff(diff string) string {
+	sections := splitDiff(diff)
+	parsed := make([]diffSection, len(sections))
+	filesByHunk := make(map[string][]string)
+	for i, section := range sections {
+		parsed[i] = parseDiffSection(section)
+		file := diffSectionPath(section)
+		if file == "" {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, h := range parsed[i].hunks {
+			if key := hunkKey(h); key != "" && !seen[key] {
+				seen[key] = true
+				filesByHunk[key] = append(filesByHunk[key], file)
+			}
+		}
+	}
+
+	repeated := false
+	for _, files := range filesByHunk {
+		if len(files) >= MinRepeatedFiles {
+			repeated = true
+			break
+		}
+	}
+	if !repeated {
+		return diff
+	}
+
+	var out strings.Builder
+	for i, section := range sections {
+		file := diffSectionPath(section)
+		if file == "" || len(parsed[i].hunks) == 0 {
+			out.WriteString(section)
+			continue
+		}
+
+		var kept strings.Builder
+		var shownIn []string
+		for _, h := range parsed[i].hunks {
+			files := filesByHunk[hunkKey(h)]
+			if len(files) < MinRepeatedFiles {
+				kept.WriteString(h)
+				continue
+			}
+			if files[0] != file {
+				if !slices.Contains(shownIn, files[0]) {
+					shownIn = append(shownIn, files[0])
+				}
+				continue
+			}
+			kept.WriteString(h)
+			fmt.Fprintf(&kept, "... (same change in %d more files: %s)\n", len(files)-1, strings.Join(files[1:], ", "))
+		}
+
+		if kept.Len() == 0 {
+			header, _, _ := strings.Cut(section, "\n")
+			fmt.Fprintf(&out, "%s\n... (repeated change, shown in %s)\n", header, strings.Join(shownIn, ", "))
+			continue
+		}
+		out.WriteString(parsed[i].header)
+		out.WriteString(kept.String())
+	}
+	return out.String()
+}
+
+// diffSection is a file's diff split into its header lines and hunks.
+type diffSection struct {
+	header string
+	hunks  []string
+}
+
+// parseDiffSection splits a diff section at its "@@" lines. Each hunk keeps
+// its trailing newline.
+func parseDiffSection(section string) diffSection {
+	var parsed diffSection
+	lines := strings.SplitAfter(section, "\n")
+	var hunk strings.Builder
+	for _, line := range lines {
+		if strings.HasPrefix(line, "@@") {
+			if hunk.Len() > 0 {
+				parsed.hunks = append(parsed.hunks, hunk.String())
+				hunk.Reset()
+			}
+			hunk.WriteString(line)
+			continue
+		}
+		if hunk.Len() == 0 && len(parsed.hunks) == 0 {
+			parsed.header += line
+			continue
+		}
+		hunk.WriteString(line)
+	}
+	if hunk.Len() > 0 {
+		parsed.hunks = append(parsed.hunks, hunk.String())
+	}
+	return parsed
+}
+
+// hunkKey returns a hunk's added and removed lines, trimmed, which identify
+// the change regardless of where it is. Hunks with no changed lines have
+// no key.
+func hunkKey(hunk string) string {
+	var key strings.Builder
+	for _, line := range strings.Split(hunk, "\n") {
+		if strings.HasPrefix(line, "@@") || len(line) == 0 {
+			continue
+		}
+		if line[0] == '+' || line[0] == '-' {
+			key.WriteByte(line[0])
+			key.WriteString(strings.TrimSpace(line[1:]))
+			key.WriteByte('\n')
+		}
+	}
+	return key.String()
+}
@@ -0,0 +1,268 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// EditMsgFile is the file git edits commit messages in; editors recognise it
+// and switch to commit message highlighting.
+const EditMsgFile = "COMMIT_EDITMSG"
+
+// scissors separates the message from the verbose diff, as in `git commit -v`.
+const scissors = "------------------------ >8 ------------------------"
+
+// MessageEditor edits planned commit messages in the user's git editor, laid
+// out like `git commit`: the repository's commit.template as comments, a
+// diffstat, and with commit.verbose set the full diff below a scissors line.
+type MessageEditor struct {
+	workDir string
+}
+
+// NewMessageEditor creates a message editor for the repository at workDir.
+func NewMessageEditor(workDir string) *MessageEditor {
+	return &MessageEditor{workDir: workDir}
+}
+
+// Command writes the edit buffer for c and returns the editor process along
+// with a function that reads the edited commit back once the editor exits.
+func (e *MessageEditor) Command(c types.PlannedCommit) (*exec.Cmd, func() (types.PlannedCommit, error), error) {
+	editor, err := e.gitOutput("var", "GIT_EDITOR")
+	if err != nil {
+		return nil, nil, fmt.Errorf("no editor configured: %w", err)
+	}
+
+	path, err := e.gitOutput("rev-parse", "--git-path", EditMsgFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to locate git directory: %w", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(e.workDir, path)
+	}
+
+	commentChar := e.commentChar()
+	buffer := BuildEditBuffer(c, EditBufferOptions{
+		CommentChar: commentChar,
+		Template:    e.template(),
+		DiffStat:    e.diff(c.Files, "--stat"),
+		Diff:        e.verboseDiff(c.Files),
+	})
+	if err := os.WriteFile(path, []byte(buffer), 0600); err != nil {
+		return nil, nil, fmt.Errorf("failed to write %s: %w", EditMsgFile, err)
+	}
+
+	// Run through the shell, like git does, so editors with arguments work
+	cmd := shellCommand(editor, path)
+	cmd.Dir = e.workDir
+
+	read := func() (types.PlannedCommit, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return c, err
+		}
+		return ParseEditBuffer(c, string(data), commentChar)
+	}
+	return cmd, read, nil
+}
+
+// gitOutput runs git in the work directory and returns its trimmed output.
+func (e *MessageEditor) gitOutput(args ...string) (string, error) {
+	cmd := Command(args...)
+	cmd.Dir = e.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// commentChar returns core.commentChar, defaulting to "#".
+func (e *MessageEditor) commentChar() string {
+	value, err := e.gitOutput("config", "core.commentChar")
+	if err != nil || value == "" || value == "auto" {
+		return "#"
+	}
+	return value
+}
+
+// template returns the contents of commit.template, or "" if it isn't set.
+func (e *MessageEditor) template() string {
+	path, err := e.gitOutput("config", "--path", "commit.template")
+	if err != nil || path == "" {
+		return ""
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(e.workDir, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// verboseDiff returns the full diff of files when commit.verbose is enabled.
+func (e *MessageEditor) verboseDiff(files []string) string {
+	value, err := e.gitOutput("config", "commit.verbose")
+	if err != nil || !verboseEnabled(value) {
+		return ""
+	}
+	return e.diff(files, "--no-color")
+}
+
+// diff runs `git diff HEAD` over files with the given option, returning ""
+// on failure (e.g. before the first commit).
+func (e *MessageEditor) diff(files []string, option string) string {
+	if len(files) == 0 {
+		return ""
+	}
+	args := append([]string{"diff", option, "HEAD", "--"}, files...)
+	out, err := e.gitOutput(args...)
+	if err != nil {
+		return ""
+	}
+	return out
+}
+
+// verboseEnabled interprets commit.verbose, which git accepts as a boolean or
+// a verbosity level.
+func verboseEnabled(value string) bool {
+	switch strings.ToLower(value) {
+	case "true", "yes", "on":
+		return true
+	}
+	n, err := strconv.Atoi(value)
+	return err == nil && n > 0
+}
+
+// EditBufferOptions holds the commented context shown below the message.
+type EditBufferOptions struct {
+	CommentChar string
+	Template    string
+	DiffStat    string
+	// Diff is placed below a scissors line when set
+	Diff string
+}
+
+// BuildEditBuffer lays out c's message for editing: the header and body, then
+// the template, instructions and diffstat as comments, then the diff.
+func BuildEditBuffer(c types.PlannedCommit, opts EditBufferOptions) string {
+	cc := opts.CommentChar
+
+	var b strings.Builder
+	b.WriteString(convention.Format(c) + "\n")
+	if c.Body != "" {
+		b.WriteString("\n" + c.Body + "\n")
+	}
+	b.WriteString("\n")
+
+	if template := strings.TrimRight(opts.Template, "\n"); template != "" {
+		for _, line := range strings.Split(template, "\n") {
+			if !strings.HasPrefix(line, cc) {
+				line = commentLine(cc, line)
+			}
+			b.WriteString(line + "\n")
+		}
+		b.WriteString(cc + "\n")
+	}
+
+	b.WriteString(commentLine(cc, fmt.Sprintf("Please enter the commit message for your changes. Lines starting\n"+
+		"with '%s' will be ignored, and an empty message keeps the current one.", cc)))
+	b.WriteString("\n")
+
+	if opts.DiffStat != "" {
+		b.WriteString(cc + "\n")
+		b.WriteString(commentLine(cc, "Changes to be committed:") + "\n")
+		for _, line := range strings.Split(opts.DiffStat, "\n") {
+			b.WriteString(cc + "\t" + strings.TrimSpace(line) + "\n")
+		}
+	}
+
+	if opts.Diff != "" {
+		b.WriteString(cc + " " + scissors + "\n")
+		b.WriteString(commentLine(cc, "Do not modify or remove the line above.\nEverything below it will be ignored.") + "\n")
+		b.WriteString(opts.Diff + "\n")
+	}
+
+	return b.String()
+}
+
+// commentLine prefixes every line of s with the comment character.
+func commentLine(cc, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line == "" {
+			lines[i] = cc
+		} else {
+			lines[i] = cc + " " + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// StripEditBuffer removes comments and everything below the scissors line,
+// then trims trailing whitespace and collapses blank lines, like git's
+// default "strip" cleanup.
+func StripEditBuffer(buffer, commentChar string) string {
+	var lines []string
+	blank := false
+	for _, line := range strings.Split(buffer, "\n") {
+		if line == commentChar+" "+scissors {
+			break
+		}
+		if strings.HasPrefix(line, commentChar) {
+			continue
+		}
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			blank = len(lines) > 0
+			continue
+		}
+		if blank {
+			lines = append(lines, "")
+			blank = false
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseEditBuffer reads an edited buffer back into c. A header in the active
+// convention replaces the fields the convention shows (for conventional
+// commits the type, scope and message); any other first line replaces the
+// message only. An empty buffer leaves c unchanged.
+func ParseEditBuffer(c types.PlannedCommit, buffer, commentChar string) (types.PlannedCommit, error) {
+	text := StripEditBuffer(buffer, commentChar)
+	if text == "" {
+		return c, &EmptyMessageError{}
+	}
+
+	header, body, _ := strings.Cut(text, "\n")
+	c.Body = strings.TrimSpace(body)
+
+	parsed, ok := convention.Parse(header)
+	if !ok {
+		c.Message = header
+		return c, nil
+	}
+	if parsed.Type != "" {
+		c.Type = parsed.Type
+		c.Scope = parsed.Scope
+	}
+	c.Message = parsed.Message
+	return c, nil
+}
+
+// EmptyMessageError indicates the edited message was left empty.
+type EmptyMessageError struct{}
+
+func (e *EmptyMessageError) Error() string {
+	return "empty commit message; keeping the current one"
+}
@@ -0,0 +1,132 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// FaultEnvVar lists git failures to inject, for tests that exercise
+// recovery paths. Entries are comma-separated, each a git subcommand with an
+// optional call number and message:
+//
+//	COMMIT_GIT_FAULTS="add#2,commit=pre-commit hook failed"
+//
+// makes the second git add fail and every git commit fail with the given
+// output. It is read once, before the first git command.
+const FaultEnvVar = "COMMIT_GIT_FAULTS"
+
+// Fault makes a git subcommand fail without running it.
+type Fault struct {
+	// Command is the git subcommand that fails, e.g. "add" or "commit".
+	Command string
+	// Call is the 1-based call of Command that fails; 0 fails every call.
+	Call int
+	// Message is the failed command's output; empty uses a generic one.
+	Message string
+}
+
+// faults holds the injected faults and counts calls per subcommand.
+var faults struct {
+	mu     sync.Mutex
+	once   sync.Once
+	active []Fault
+	calls  map[string]int
+}
+
+// InjectFaults replaces the injected faults and resets the call counts. It
+// returns a function that removes them again, for use with defer or
+// t.Cleanup.
+func InjectFaults(fs ...Fault) (reset func()) {
+	faults.once.Do(func() {}) // the environment no longer applies
+	faults.mu.Lock()
+	defer faults.mu.Unlock()
+	faults.active = fs
+	faults.calls = make(map[string]int)
+	return func() { InjectFaults() }
+}
+
+// ParseFaults parses a FaultEnvVar value.
+func ParseFaults(spec string) ([]Fault, error) {
+	var fs []Fault
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		var f Fault
+		entry, f.Message, _ = strings.Cut(entry, "=")
+		command, call, hasCall := strings.Cut(entry, "#")
+		f.Command = strings.TrimSpace(command)
+		if hasCall {
+			n, err := strconv.Atoi(call)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid call number in %s entry %q", FaultEnvVar, entry)
+			}
+			f.Call = n
+		}
+		if f.Command == "" {
+			return nil, fmt.Errorf("missing git subcommand in %s entry %q", FaultEnvVar, entry)
+		}
+		fs = append(fs, f)
+	}
+	return fs, nil
+}
+
+// loadFaultEnv injects the faults in FaultEnvVar, once. An invalid value
+// is reported on stderr and ignored, so it can't break a real run.
+func loadFaultEnv() {
+	faults.once.Do(func() {
+		spec := os.Getenv(FaultEnvVar)
+		if spec == "" {
+			return
+		}
+		fs, err := ParseFaults(spec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			return
+		}
+		faults.mu.Lock()
+		defer faults.mu.Unlock()
+		faults.active = fs
+		faults.calls = make(map[string]int)
+	})
+}
+
+// injectedFault counts the call and returns the FaultError it should fail
+// with, or nil to run it.
+func (c *Cmd) injectedFault() *FaultError {
+	loadFaultEnv()
+	faults.mu.Lock()
+	defer faults.mu.Unlock()
+	if len(faults.active) == 0 || len(c.Args) < 2 {
+		return nil
+	}
+
+	command := c.Args[1]
+	faults.calls[command]++
+	call := faults.calls[command]
+	for _, f := range faults.active {
+		if f.Command == command && (f.Call == 0 || f.Call == call) {
+			message := f.Message
+			if message == "" {
+				message = fmt.Sprintf("fatal: injected failure of git %s (call %d)", command, call)
+			}
+			return &FaultError{Args: c.Args[1:], Call: call, Message: message}
+		}
+	}
+	return nil
+}
+
+// FaultError is the error of a git command failed by an injected fault.
+type FaultError struct {
+	Args    []string
+	Call    int
+	Message string
+}
+
+func (e *FaultError) Error() string {
+	return fmt.Sprintf("git %s: exit status 1 (injected)", strings.Join(e.Args, " "))
+}
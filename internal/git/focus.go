@@ -0,0 +1,138 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// MatchesFocus reports whether file (relative to the repository root) is
+// selected by any of patterns: the file itself, a directory containing it, or
+// a glob matching its path.
+func MatchesFocus(file string, patterns []string) bool {
+	for _, p := range patterns {
+		p = strings.TrimSuffix(p, "/")
+		if p == "" || p == "." {
+			return true
+		}
+		if file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+		if ok, _ := path.Match(p, file); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// FocusDiff keeps the full diff of files matching patterns and replaces every
+// other file's hunks with a one-line summary of its added and removed lines,
+// so a size-limited diff spends its space on the files that matter most.
+func FocusDiff(diff string, patterns []string) string {
+	var out strings.Builder
+	for _, section := range splitDiff(diff) {
+		file := diffSectionPath(section)
+		if file == "" || MatchesFocus(file, patterns) {
+			out.WriteString(section)
+			continue
+		}
+		out.WriteString(summarizeDiffSection(section))
+	}
+	return out.String()
+}
+
+// FilterDiff keeps only the sections of diff that change one of files or a
+// file in one of them, for directories.
+func FilterDiff(diff string, files []string) string {
+	var out strings.Builder
+	for _, section := range splitDiff(diff) {
+		if file := diffSectionPath(section); file != "" && MatchesFocus(file, files) {
+			out.WriteString(section)
+		}
+	}
+	return out.String()
+}
+
+// DiffByFile splits diff into the section changing each file, keyed by path.
+func DiffByFile(diff string) map[string]string {
+	sections := make(map[string]string)
+	for _, section := range splitDiff(diff) {
+		if file := diffSectionPath(section); file != "" {
+			sections[file] += section
+		}
+	}
+	return sections
+}
+
+// splitDiff splits a multi-file diff into one section per file, each starting
+// with its "diff --git" line. Text before the first section is its own section.
+func splitDiff(diff string) []string {
+	var sections []string
+	start := 0
+	for i := 0; i < len(diff); {
+		next := strings.Index(diff[i:], "\ndiff --git ")
+		if next < 0 {
+			break
+		}
+		i += next + 1
+		if i > start {
+			sections = append(sections, diff[start:i])
+		}
+		start = i
+	}
+	if start < len(diff) {
+		sections = append(sections, diff[start:])
+	}
+	return sections
+}
+
+// diffSectionPath returns the path of the file a diff section changes, or ""
+// if the section isn't a file diff.
+func diffSectionPath(section string) string {
+	header, _, _ := strings.Cut(section, "\n")
+	rest, ok := strings.CutPrefix(header, "diff --git ")
+	if !ok {
+		return ""
+	}
+	for _, line := range strings.Split(section, "\n") {
+		if name, ok := strings.CutPrefix(line, "+++ b/"); ok {
+			return name
+		}
+		if name, ok := strings.CutPrefix(line, "--- a/"); ok && strings.Contains(section, "\n+++ /dev/null") {
+			return name
+		}
+		if strings.HasPrefix(line, "@@") {
+			break
+		}
+	}
+	// No content lines (mode change, binary); fall back to the header
+	if i := strings.LastIndex(rest, " b/"); i >= 0 {
+		return rest[i+len(" b/"):]
+	}
+	return ""
+}
+
+// summarizeDiffSection keeps a section's header lines and replaces its hunks
+// with a count of added and removed lines.
+func summarizeDiffSection(section string) string {
+	var header strings.Builder
+	added, removed := 0, 0
+	inHunks := false
+	for _, line := range strings.Split(strings.TrimSuffix(section, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			inHunks = true
+		case !inHunks:
+			header.WriteString(line + "\n")
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	if !inHunks {
+		return section
+	}
+	fmt.Fprintf(&header, "... (summarized: +%d -%d lines)\n", added, removed)
+	return header.String()
+}
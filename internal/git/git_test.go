@@ -1,12 +1,17 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/dsswift/commit/internal/testutil"
 	"github.com/dsswift/commit/pkg/types"
@@ -166,6 +171,49 @@ func TestCollector_Diff(t *testing.T) {
 	}
 }
 
+func TestCollector_Diff_Options(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	original := "package main\n\nfunc add(a, b int) int {\n\tsum := a + b\n\n\n\n\treturn sum\n}\n"
+	testutil.CreateFile(t, repoDir, "main.go", original)
+	testutil.GitAdd(t, repoDir, "main.go")
+	testutil.GitCommit(t, repoDir, "initial")
+
+	testutil.CreateFile(t, repoDir, "main.go", strings.Replace(original, "a + b", "b + a", 1))
+
+	collector := NewCollector(repoDir)
+	diff, err := collector.Diff(false)
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if strings.Contains(diff, "return sum") {
+		t.Errorf("default diff should not reach the end of the function:\n%s", diff)
+	}
+
+	collector.SetDiffOptions(DiffOptions{Algorithm: "histogram", FunctionContext: true})
+	diff, err = collector.Diff(false)
+	if err != nil {
+		t.Fatalf("Diff with options failed: %v", err)
+	}
+	if !strings.Contains(diff, " \treturn sum") {
+		t.Errorf("expected the whole function with --function-context:\n%s", diff)
+	}
+	if got := strings.Join(DiffOptions{Algorithm: "patience"}.args(), " "); got != "--diff-algorithm=patience" {
+		t.Errorf("args() = %q", got)
+	}
+}
+
+func TestValidDiffAlgorithm(t *testing.T) {
+	for _, name := range []string{"myers", "minimal", "patience", "histogram"} {
+		if !ValidDiffAlgorithm(name) {
+			t.Errorf("ValidDiffAlgorithm(%q) = false", name)
+		}
+	}
+	if ValidDiffAlgorithm("fast") {
+		t.Error("ValidDiffAlgorithm(\"fast\") = true")
+	}
+}
+
 func TestCollector_RecentCommits(t *testing.T) {
 	repoDir := testutil.TestRepo(t)
 
@@ -192,6 +240,105 @@ func TestCollector_RecentCommits(t *testing.T) {
 	}
 }
 
+func TestCollector_RecentCommits_FirstParentExcludesMergesAndBots(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s: %v", args, out, err)
+		}
+	}
+
+	testutil.CreateFile(t, repoDir, "main.txt", "base")
+	testutil.GitAdd(t, repoDir, "main.txt")
+	testutil.GitCommit(t, repoDir, "feat: base")
+
+	// Side branch commit, merged back with a merge commit
+	git("checkout", "-q", "-b", "side")
+	testutil.CreateFile(t, repoDir, "side.txt", "side")
+	testutil.GitAdd(t, repoDir, "side.txt")
+	testutil.GitCommit(t, repoDir, "feat: side branch work")
+	git("checkout", "-q", "-")
+	testutil.CreateFile(t, repoDir, "main.txt", "main")
+	testutil.GitAdd(t, repoDir, "main.txt")
+	testutil.GitCommit(t, repoDir, "fix: main work")
+	git("merge", "-q", "--no-ff", "-m", "Merge branch 'side'", "side")
+
+	// Bot-authored commit
+	testutil.CreateFile(t, repoDir, "deps.txt", "deps")
+	testutil.GitAdd(t, repoDir, "deps.txt")
+	git("-c", "user.name=dependabot[bot]", "-c", "user.email=bot@example.com", "commit", "-q", "-m", "chore: bump deps")
+
+	collector := NewCollector(repoDir)
+	commits, err := collector.RecentCommits(10, types.DefaultExcludedAuthors()...)
+	if err != nil {
+		t.Fatalf("RecentCommits failed: %v", err)
+	}
+
+	expected := []string{"fix: main work", "feat: base"}
+	if strings.Join(commits, "|") != strings.Join(expected, "|") {
+		t.Errorf("RecentCommits() = %v, want %v", commits, expected)
+	}
+
+	// Without exclusions the bot commit is included
+	commits, err = collector.RecentCommits(10)
+	if err != nil {
+		t.Fatalf("RecentCommits failed: %v", err)
+	}
+	if len(commits) == 0 || commits[0] != "chore: bump deps" {
+		t.Errorf("expected bot commit first without exclusions, got %v", commits)
+	}
+}
+
+func TestCollector_RecentCommits_SkipsReverts(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	testutil.CreateFile(t, repoDir, "a.txt", "a")
+	testutil.GitAdd(t, repoDir, "a.txt")
+	testutil.GitCommit(t, repoDir, "feat: add a")
+	testutil.CreateFile(t, repoDir, "b.txt", "b")
+	testutil.GitAdd(t, repoDir, "b.txt")
+	testutil.GitCommit(t, repoDir, "feat: add b")
+
+	cmd := exec.Command("git", "revert", "--no-edit", "HEAD")
+	cmd.Dir = repoDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git revert failed: %s: %v", out, err)
+	}
+
+	testutil.CreateFile(t, repoDir, "c.txt", "c")
+	testutil.GitAdd(t, repoDir, "c.txt")
+	testutil.GitCommit(t, repoDir, "fix: add c")
+
+	commits, err := NewCollector(repoDir).RecentCommits(10)
+	if err != nil {
+		t.Fatalf("RecentCommits failed: %v", err)
+	}
+	// Neither the revert nor the change it undid is an example to follow
+	expected := []string{"fix: add c", "feat: add a"}
+	if !reflect.DeepEqual(commits, expected) {
+		t.Errorf("RecentCommits() = %v, want %v", commits, expected)
+	}
+}
+
+func TestIsRevert(t *testing.T) {
+	hash := "0123456789abcdef0123456789abcdef01234567"
+	body := "This reverts commit " + hash + "."
+
+	if !IsRevert(`Revert "feat: add b"`, body) || RevertedCommit(body) != hash {
+		t.Errorf("git revert message not recognized")
+	}
+	if !IsRevert("fix: back out retry change", body) {
+		t.Error("expected a reworded revert to be recognized by its body")
+	}
+	if IsRevert("fix: revert to the old timeout", "") || RevertedCommit("") != "" {
+		t.Error("expected an ordinary commit not to be a revert")
+	}
+}
+
 func TestCollector_RecentCommits_Empty(t *testing.T) {
 	repoDir := testutil.TestRepo(t)
 
@@ -226,6 +373,97 @@ func TestCollector_CurrentBranch(t *testing.T) {
 	}
 }
 
+func TestCollector_RemovedDirectory(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "main.go", "package main\n")
+	for i := range RemovedDirMinFiles {
+		testutil.CreateFile(t, repoDir, fmt.Sprintf("vendor/lib/f%d.go", i), "package lib\n")
+	}
+	for i := range 3 {
+		testutil.CreateFile(t, repoDir, fmt.Sprintf("old/f%d.go", i), "package old\n")
+	}
+	testutil.GitAdd(t, repoDir, ".")
+	testutil.GitCommit(t, repoDir, "initial commit")
+
+	for _, dir := range []string{"vendor", "old"} {
+		if err := os.RemoveAll(filepath.Join(repoDir, dir)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	collector := NewCollector(repoDir)
+	status, err := collector.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	// Small removed directories keep their files listed
+	want := []string{"old/f0.go", "old/f1.go", "old/f2.go", "vendor/"}
+	if !slices.Equal(status.Deleted, want) {
+		t.Errorf("Deleted = %v, want %v", status.Deleted, want)
+	}
+	removed, err := collector.RemovedDirs()
+	if err != nil || !reflect.DeepEqual(removed, map[string]int{"vendor/": RemovedDirMinFiles}) {
+		t.Errorf("RemovedDirs() = %v, %v", removed, err)
+	}
+
+	// The directory is staged with one pathspec
+	stager := NewStager(repoDir)
+	if err := stager.StageFiles([]string{"vendor/"}); err != nil {
+		t.Fatalf("StageFiles failed: %v", err)
+	}
+	staged, _ := stager.StagedFiles()
+	if len(staged) != RemovedDirMinFiles {
+		t.Errorf("expected %d staged deletions, got %v", RemovedDirMinFiles, staged)
+	}
+
+	collector.InvalidateStatusCache()
+	status, _ = collector.Status()
+	if !slices.Equal(status.Staged, []string{"vendor/"}) {
+		t.Errorf("Staged = %v, want the collapsed directory", status.Staged)
+	}
+}
+
+func TestCollector_NoCommitsYet(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "staged.txt", "staged\n")
+	testutil.CreateFile(t, repoDir, "other.txt", "other\n")
+	testutil.GitAdd(t, repoDir, "staged.txt", "other.txt")
+
+	collector := NewCollector(repoDir)
+	if collector.HasCommits() {
+		t.Fatal("expected no commits in a new repo")
+	}
+
+	branch, err := collector.CurrentBranch()
+	if err != nil || branch != "main" {
+		t.Errorf("CurrentBranch() = %q, %v; want main", branch, err)
+	}
+
+	// Diffs compare against the empty tree, so staged files show as added
+	diff, err := collector.Diff(false)
+	if err != nil || !strings.Contains(diff, "+staged") {
+		t.Errorf("expected the staged file in the diff, got %q, %v", diff, err)
+	}
+	numstat, err := collector.DiffNumstat(true)
+	if err != nil || len(numstat) != 2 {
+		t.Errorf("expected numstat for 2 files, got %v, %v", numstat, err)
+	}
+
+	stager := NewStager(repoDir)
+	if err := stager.UnstageFiles([]string{"other.txt"}); err != nil {
+		t.Fatalf("UnstageFiles failed: %v", err)
+	}
+	staged, err := stager.StagedFiles()
+	if err != nil || len(staged) != 1 || staged[0] != "staged.txt" {
+		t.Errorf("expected only staged.txt staged, got %v, %v", staged, err)
+	}
+
+	testutil.GitCommit(t, repoDir, "initial")
+	if !collector.HasCommits() {
+		t.Error("expected commits after the first commit")
+	}
+}
+
 func TestCollector_IsInitialCommit(t *testing.T) {
 	repoDir := testutil.TestRepo(t)
 
@@ -310,6 +548,44 @@ func TestStager_StageFiles(t *testing.T) {
 	}
 }
 
+func TestStager_StageFiles_ManyFiles(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large staging test in short mode")
+	}
+
+	repoDir := testutil.TestRepo(t)
+
+	// Long nested paths push the combined argv well past Windows' 32K limit
+	const count = 5000
+	dir := filepath.Join(repoDir, strings.Repeat("deeply_nested_directory_name/", 4))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+
+	files := make([]string, 0, count)
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("generated_file_with_a_rather_long_name_%05d.txt", i)
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+		rel, _ := filepath.Rel(repoDir, filepath.Join(dir, name))
+		files = append(files, filepath.ToSlash(rel))
+	}
+
+	stager := NewStager(repoDir)
+	if err := stager.StageFiles(files); err != nil {
+		t.Fatalf("StageFiles failed: %v", err)
+	}
+
+	staged, err := stager.StagedFiles()
+	if err != nil {
+		t.Fatalf("StagedFiles failed: %v", err)
+	}
+	if len(staged) != count {
+		t.Errorf("expected %d staged files, got %d", count, len(staged))
+	}
+}
+
 func TestStager_UnstageAll(t *testing.T) {
 	repoDir := testutil.TestRepo(t)
 
@@ -471,6 +747,72 @@ func TestReverser_Reverse_InitialCommit(t *testing.T) {
 	}
 }
 
+func TestRemoteRefs_BackgroundFetch(t *testing.T) {
+	remoteDir := t.TempDir()
+	run := func(dir string, args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %s\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	run(remoteDir, "init", "--bare", "-b", "main")
+
+	repoDir := t.TempDir()
+	run(repoDir, "clone", remoteDir, ".")
+	run(repoDir, "config", "user.email", "test@test.com")
+	run(repoDir, "config", "user.name", "Test")
+	testutil.CreateFile(t, repoDir, "file.txt", "v1")
+	run(repoDir, "add", "file.txt")
+	run(repoDir, "commit", "-m", "pushed commit")
+	run(repoDir, "push", "origin", "main")
+	testutil.CreateFile(t, repoDir, "file.txt", "v2")
+	run(repoDir, "add", "file.txt")
+	run(repoDir, "commit", "-m", "local commit")
+
+	refs, err := SnapshotRemoteRefs(repoDir)
+	if err != nil {
+		t.Fatalf("SnapshotRemoteRefs failed: %v", err)
+	}
+	if _, ok := refs.Commit("refs/remotes/origin/main"); !ok || len(refs.Refs) != 1 {
+		t.Fatalf("unexpected snapshot %v", refs.Refs)
+	}
+
+	// A background fetch picks up the local commit, pushed from elsewhere
+	run(repoDir, "update-ref", "refs/remotes/origin/main", "HEAD")
+
+	collector := NewCollector(repoDir)
+	if live, _ := collector.IsCommitPushed(); !live {
+		t.Fatal("expected the live check to see the moved ref")
+	}
+
+	collector.SetRemoteRefs(refs)
+	if pushed, err := collector.IsCommitPushed(); err != nil || pushed {
+		t.Errorf("IsCommitPushed with snapshot = %v, %v; want false", pushed, err)
+	}
+	if pushed, _ := collector.IsRefPushed("HEAD~1"); !pushed {
+		t.Error("expected the commit pushed before the snapshot to stay pushed")
+	}
+	commits, err := collector.GetCommitLog(2)
+	if err != nil || len(commits) != 2 || commits[0].IsPushed || !commits[1].IsPushed {
+		t.Errorf("GetCommitLog with snapshot = %+v, %v", commits, err)
+	}
+
+	reverser := NewReverser(repoDir)
+	reverser.SetRemoteRefs(refs)
+	if pushed, _ := reverser.WasPushed(1); pushed {
+		t.Error("expected WasPushed to use the snapshot")
+	}
+
+	changed, err := refs.Changed()
+	if err != nil || !reflect.DeepEqual(changed, []string{"origin/main"}) {
+		t.Errorf("Changed = %v, %v", changed, err)
+	}
+}
+
 func TestPushedCommitError(t *testing.T) {
 	t.Run("single commit", func(t *testing.T) {
 		err := &PushedCommitError{Count: 1}
@@ -878,6 +1220,60 @@ func TestReverser_Reverse_Multiple(t *testing.T) {
 	}
 }
 
+func TestReverser_Reverse_Merge(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s: %v", args, out, err)
+		}
+	}
+
+	testutil.CreateFile(t, repoDir, "main.txt", "base")
+	testutil.GitAdd(t, repoDir, "main.txt")
+	testutil.GitCommit(t, repoDir, "feat: base")
+	git("checkout", "-q", "-b", "side")
+	testutil.CreateFile(t, repoDir, "side.txt", "side")
+	testutil.GitAdd(t, repoDir, "side.txt")
+	testutil.GitCommit(t, repoDir, "feat: side work")
+	git("checkout", "-q", "-")
+	testutil.CreateFile(t, repoDir, "main.txt", "main")
+	testutil.GitAdd(t, repoDir, "main.txt")
+	testutil.GitCommit(t, repoDir, "fix: main work")
+	git("merge", "-q", "--no-ff", "-m", "Merge branch 'side'", "side")
+	testutil.CreateFile(t, repoDir, "after.txt", "after")
+	testutil.GitAdd(t, repoDir, "after.txt")
+	testutil.GitCommit(t, repoDir, "feat: after merge")
+
+	collector := NewCollector(repoDir)
+	commits, err := collector.GetCommitLog(10)
+	if err != nil {
+		t.Fatalf("GetCommitLog failed: %v", err)
+	}
+	// First-parent history: the side branch's commit is behind the merge
+	var subjects []string
+	for _, c := range commits {
+		subjects = append(subjects, c.Message)
+	}
+	if want := []string{"feat: after merge", "Merge branch 'side'", "fix: main work", "feat: base"}; !reflect.DeepEqual(subjects, want) {
+		t.Errorf("GetCommitLog() = %v, want %v", subjects, want)
+	}
+	if len(commits) > 1 && (!commits[1].IsMerge || commits[0].IsMerge) {
+		t.Errorf("IsMerge not set on the merge alone: %+v", commits)
+	}
+
+	reverser := NewReverser(repoDir)
+	var mergeErr *MergeCommitError
+	if err := reverser.Reverse(2, false); !errors.As(err, &mergeErr) {
+		t.Fatalf("Reverse(2) error = %v, want MergeCommitError", err)
+	}
+	if err := reverser.Reverse(1, false); err != nil {
+		t.Fatalf("Reverse(1) after the merge failed: %v", err)
+	}
+}
+
 func TestReverser_Reverse_TooMany(t *testing.T) {
 	repoDir := testutil.TestRepo(t)
 
@@ -972,6 +1368,53 @@ func TestCommitter_VerifyCommit(t *testing.T) {
 	}
 }
 
+func TestCommitter_VerifyCommitFiles(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	testutil.CreateFile(t, repoDir, "init.txt", "init")
+	testutil.GitAdd(t, repoDir, "init.txt")
+	testutil.GitCommit(t, repoDir, "initial commit")
+
+	testutil.CreateFile(t, repoDir, "a.go", "package a")
+	testutil.CreateFile(t, repoDir, "pkg/b.go", "package pkg")
+	testutil.CreateFile(t, repoDir, "extra.go", "package extra")
+	testutil.GitAdd(t, repoDir, "a.go", "pkg/b.go", "extra.go")
+	testutil.GitCommit(t, repoDir, "add files")
+
+	committer := NewCommitter(repoDir)
+
+	tests := []struct {
+		name        string
+		expected    []string
+		wantStatus  string
+		wantExtra   []string
+		wantMissing []string
+	}{
+		{"exact", []string{"a.go", "pkg/b.go", "extra.go"}, types.VerificationExact, nil, nil},
+		{"directory covers files", []string{"a.go", "pkg/", "extra.go"}, types.VerificationExact, nil, nil},
+		{"extra files", []string{"a.go", "pkg/b.go"}, types.VerificationExtra, []string{"extra.go"}, nil},
+		{"missing files", []string{"a.go", "pkg/b.go", "extra.go", "gone.go"}, types.VerificationMissing, nil, []string{"gone.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := committer.VerifyCommitFiles(tt.expected)
+			if err != nil {
+				t.Fatalf("VerifyCommitFiles failed: %v", err)
+			}
+			if v.Status != tt.wantStatus {
+				t.Errorf("Status = %q, want %q", v.Status, tt.wantStatus)
+			}
+			if strings.Join(v.ExtraFiles, ",") != strings.Join(tt.wantExtra, ",") {
+				t.Errorf("ExtraFiles = %v, want %v", v.ExtraFiles, tt.wantExtra)
+			}
+			if strings.Join(v.MissingFiles, ",") != strings.Join(tt.wantMissing, ",") {
+				t.Errorf("MissingFiles = %v, want %v", v.MissingFiles, tt.wantMissing)
+			}
+		})
+	}
+}
+
 func TestCommitter_ExecutePlannedCommit(t *testing.T) {
 	repoDir := testutil.TestRepo(t)
 
@@ -1415,3 +1858,1298 @@ func TestStager_expandDirectory(t *testing.T) {
 		}
 	})
 }
+
+func TestResolvePathspecs(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	subDir := filepath.Join(repoDir, "services")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	pathspecs, err := ResolvePathspecs(repoDir, subDir, []string{"auth", "../docs/readme.md", "."})
+	if err != nil {
+		t.Fatalf("ResolvePathspecs failed: %v", err)
+	}
+	expected := []string{"services/auth", "docs/readme.md", "services"}
+	if strings.Join(pathspecs, ",") != strings.Join(expected, ",") {
+		t.Errorf("ResolvePathspecs() = %v, want %v", pathspecs, expected)
+	}
+
+	if _, err := ResolvePathspecs(repoDir, repoDir, []string{"../elsewhere"}); err == nil {
+		t.Error("expected error for path outside the repository")
+	}
+}
+
+func TestSymlinks(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "config/dev.yaml", "env: dev\n")
+	testutil.CreateFile(t, repoDir, "config/prod.yaml", "env: prod\n")
+	testutil.CreateFile(t, repoDir, "notes.txt", "notes\n")
+	symlink := func(target, link string) {
+		t.Helper()
+		_ = os.Remove(filepath.Join(repoDir, link))
+		if err := os.Symlink(target, filepath.Join(repoDir, link)); err != nil {
+			t.Skipf("symlinks unavailable: %v", err)
+		}
+	}
+	symlink("config/dev.yaml", "current.yaml")
+	testutil.GitAdd(t, repoDir, ".")
+	testutil.GitCommit(t, repoDir, "initial")
+
+	symlink("config/prod.yaml", "current.yaml") // retargeted
+	_ = os.Remove(filepath.Join(repoDir, "notes.txt"))
+	symlink("config/dev.yaml", "notes.txt") // file replaced by a link
+	symlink("config", "settings")           // link to a directory
+	symlink("missing.yaml", "dangling")     // link to nothing
+
+	collector := NewCollector(repoDir)
+	status, err := collector.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if got := strings.Join(status.Modified, ","); got != "current.yaml,notes.txt" {
+		t.Errorf("Modified = %s, want the retargeted link and the replaced file", got)
+	}
+
+	files := []string{"current.yaml", "notes.txt", "settings", "dangling"}
+	want := map[string]string{
+		"current.yaml": "symlink retargeted (config/dev.yaml → config/prod.yaml)",
+		"notes.txt":    "symlink to config/dev.yaml",
+		"settings":     "symlink to config",
+		"dangling":     "symlink to missing.yaml",
+	}
+	if got := collector.SymlinkChanges(files, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("SymlinkChanges() = %v, want %v", got, want)
+	}
+
+	// New links are diffed as their targets, not followed
+	diff, err := collector.UntrackedDiff([]string{"settings", "dangling"}, 10, 1024)
+	if err != nil {
+		t.Fatalf("UntrackedDiff failed: %v", err)
+	}
+	if !strings.Contains(diff, "new file mode 120000\n--- /dev/null\n+++ b/settings\n@@ -0,0 +1 @@\n+config\n") ||
+		!strings.Contains(diff, "+missing.yaml") {
+		t.Errorf("UntrackedDiff() = %q", diff)
+	}
+
+	// Links are staged as links, even to directories or to nothing
+	if err := NewStager(repoDir).StageFiles(files); err != nil {
+		t.Fatalf("StageFiles failed: %v", err)
+	}
+	if got := collector.SymlinkChanges(files, true); !reflect.DeepEqual(got, want) {
+		t.Errorf("SymlinkChanges(staged) = %v, want %v", got, want)
+	}
+
+	// A link in the repository names the link; a link to the repository names it
+	outside := t.TempDir()
+	symlink(outside, "external")
+	alias := filepath.Join(t.TempDir(), "repo")
+	if err := os.Symlink(repoDir, alias); err != nil {
+		t.Fatal(err)
+	}
+	pathspecs, err := ResolvePathspecs(repoDir, alias, []string{"external", "settings", "."})
+	if err != nil {
+		t.Fatalf("ResolvePathspecs failed: %v", err)
+	}
+	if got := strings.Join(pathspecs, ","); got != "external,settings,." {
+		t.Errorf("ResolvePathspecs() = %s, want external,settings,.", got)
+	}
+	root, err := FindGitRoot(alias)
+	if err != nil || root != mustEvalSymlinks(t, repoDir) {
+		t.Errorf("FindGitRoot(through a link) = %q, %v", root, err)
+	}
+}
+
+func TestDescribeSymlinkChange(t *testing.T) {
+	tests := []struct {
+		oldTarget, newTarget string
+		wasLink, isLink      bool
+		exists               bool
+		want                 string
+	}{
+		{"", "a", false, true, true, "symlink to a"},
+		{"a", "b", true, true, true, "symlink retargeted (a → b)"},
+		{"a", "", true, false, true, "symlink to a replaced by a file"},
+		{"a", "", true, false, false, "symlink to a removed"},
+		{"", "", false, false, true, ""},
+	}
+	for _, tt := range tests {
+		if got := DescribeSymlinkChange(tt.oldTarget, tt.wasLink, tt.newTarget, tt.isLink, tt.exists); got != tt.want {
+			t.Errorf("DescribeSymlinkChange(%q, %v, %q, %v, %v) = %q, want %q",
+				tt.oldTarget, tt.wasLink, tt.newTarget, tt.isLink, tt.exists, got, tt.want)
+		}
+	}
+}
+
+func TestCollector_WorkingTreeHash(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "a.txt", "one\n")
+	testutil.GitAdd(t, repoDir, "a.txt")
+	testutil.GitCommit(t, repoDir, "initial")
+
+	collector := NewCollector(repoDir)
+	hash := func(stagedOnly bool) string {
+		t.Helper()
+		h, err := collector.WorkingTreeHash(stagedOnly)
+		if err != nil {
+			t.Fatalf("WorkingTreeHash failed: %v", err)
+		}
+		return h
+	}
+
+	clean := hash(false)
+	stagedClean := hash(true)
+	if hash(false) != clean {
+		t.Error("expected hash to be stable for an unchanged tree")
+	}
+
+	testutil.CreateFile(t, repoDir, "new.txt", "untracked\n")
+	withUntracked := hash(false)
+	if withUntracked == clean {
+		t.Error("expected untracked file to change the hash")
+	}
+
+	// Same path, different content
+	testutil.CreateFile(t, repoDir, "new.txt", "edited\n")
+	if hash(false) == withUntracked {
+		t.Error("expected untracked content change to change the hash")
+	}
+
+	// Staged-only hashes ignore untracked changes but not the index
+	if hash(true) != stagedClean {
+		t.Error("expected staged-only hash to ignore untracked files")
+	}
+	testutil.GitAdd(t, repoDir, "new.txt")
+	if hash(true) == stagedClean {
+		t.Error("expected staged file to change the staged-only hash")
+	}
+}
+
+func TestCollector_GeneratedFiles(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, ".gitattributes",
+		"gen/** linguist-generated\n*.lock -diff\nvendor/keep.go linguist-generated=false\n")
+
+	collector := NewCollector(repoDir)
+	files := []string{"main.go", "gen/api.pb.go", "deps.lock", "vendor/keep.go", "name with spaces.go"}
+
+	generated, err := collector.GeneratedFiles(files)
+	if err != nil {
+		t.Fatalf("GeneratedFiles failed: %v", err)
+	}
+	if len(generated) != 2 || generated[0] != "gen/api.pb.go" || generated[1] != "deps.lock" {
+		t.Errorf("GeneratedFiles() = %v, want [gen/api.pb.go deps.lock]", generated)
+	}
+
+	// Excluded files drop out of status
+	testutil.CreateFile(t, repoDir, "main.go", "package main\n")
+	testutil.CreateFile(t, repoDir, "deps.lock", "pinned\n")
+	collector.SetExcludes([]string{"deps.lock"})
+	status, err := collector.Status()
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	for _, f := range status.AllFiles() {
+		if f == "deps.lock" {
+			t.Error("expected excluded file to be left out of status")
+		}
+	}
+}
+
+func TestCollector_UntrackedDiff(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "new.go", "package main\n\nfunc hello() {}\n")
+	testutil.CreateFile(t, repoDir, "pkg/util.go", "package pkg\n")
+	testutil.CreateFile(t, repoDir, "big.txt", strings.Repeat("x", 200))
+	testutil.CreateFile(t, repoDir, "image.bin", "\x00\x01\x02")
+
+	collector := NewCollector(repoDir)
+	diff, err := collector.UntrackedDiff([]string{"new.go", "pkg/", "big.txt", "image.bin"}, 10, 100)
+	if err != nil {
+		t.Fatalf("UntrackedDiff failed: %v", err)
+	}
+
+	// Untracked directories are expanded
+	for _, want := range []string{"+func hello() {}", "b/pkg/util.go", "new file mode"} {
+		if !strings.Contains(diff, want) {
+			t.Errorf("diff missing %q:\n%s", want, diff)
+		}
+	}
+	// Oversized and binary files are skipped
+	if strings.Contains(diff, "big.txt") || strings.Contains(diff, "image.bin") {
+		t.Errorf("diff should skip oversized and binary files:\n%s", diff)
+	}
+
+	diff, _ = collector.UntrackedDiff([]string{"new.go", "pkg/"}, 1, 100)
+	if strings.Count(diff, "diff --git") != 1 {
+		t.Errorf("expected maxFiles to cap the diff at 1 file:\n%s", diff)
+	}
+}
+
+func TestCommitter_CommitWithBody(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "file.txt", "content")
+	testutil.GitAdd(t, repoDir, "file.txt")
+
+	committer := NewCommitter(repoDir)
+	if _, err := committer.CommitWithBody("chore: remove files", "Deleted files: 3"); err != nil {
+		t.Fatalf("CommitWithBody failed: %v", err)
+	}
+
+	out, err := exec.Command("git", "-C", repoDir, "log", "-1", "--format=%s%n--%n%b").Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "chore: remove files\n--\nDeleted files: 3"; strings.TrimSpace(string(out)) != want {
+		t.Errorf("commit message = %q, want %q", strings.TrimSpace(string(out)), want)
+	}
+}
+
+func TestAcquireLock(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	lock, err := AcquireLock(repoDir)
+	if err != nil {
+		t.Fatalf("AcquireLock failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(repoDir, ".git", LockFile)); err != nil {
+		t.Errorf("expected lock file in .git: %v", err)
+	}
+
+	_, err = AcquireLock(repoDir)
+	lockedErr, ok := err.(*LockedError)
+	if !ok {
+		t.Fatalf("expected LockedError, got %T: %v", err, err)
+	}
+	if lockedErr.Info.PID != os.Getpid() {
+		t.Errorf("LockedError PID = %d, want %d", lockedErr.Info.PID, os.Getpid())
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatalf("Release failed: %v", err)
+	}
+	lock, err = AcquireLock(repoDir)
+	if err != nil {
+		t.Fatalf("AcquireLock after release failed: %v", err)
+	}
+	_ = lock.Release()
+}
+
+func TestAcquireLock_Stale(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	lockPath := filepath.Join(repoDir, ".git", LockFile)
+	host, _ := os.Hostname()
+
+	// A finished process on this host
+	cmd := exec.Command("git", "--version")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	dead := fmt.Sprintf(`{"pid":%d,"host":%q,"started_at":%q}`, cmd.Process.Pid, host, time.Now().Format(time.RFC3339))
+
+	// A lock from another host that was never released
+	abandoned := fmt.Sprintf(`{"pid":1,"host":"elsewhere","started_at":%q}`, time.Now().Add(-2*StaleLockAge).Format(time.RFC3339))
+
+	for name, content := range map[string]string{"dead process": dead, "abandoned": abandoned} {
+		if err := os.WriteFile(lockPath, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		lock, err := AcquireLock(repoDir)
+		if err != nil {
+			t.Errorf("%s: expected stale lock to be replaced, got %v", name, err)
+			continue
+		}
+		_ = lock.Release()
+	}
+
+	// A recent lock from another host is respected
+	recent := fmt.Sprintf(`{"pid":1,"host":"elsewhere","started_at":%q}`, time.Now().Format(time.RFC3339))
+	_ = os.WriteFile(lockPath, []byte(recent), 0600)
+	if _, err := AcquireLock(repoDir); err == nil {
+		t.Error("expected a recent lock from another host to block")
+	}
+
+	// A live run on this host, e.g. waiting at --confirm, is never stale
+	waiting := fmt.Sprintf(`{"pid":%d,"host":%q,"started_at":%q}`, os.Getpid(), host, time.Now().Add(-2*StaleLockAge).Format(time.RFC3339))
+	_ = os.WriteFile(lockPath, []byte(waiting), 0600)
+	if _, err := AcquireLock(repoDir); err == nil {
+		t.Error("expected an old lock held by a live process on this host to block")
+	}
+}
+
+func TestAcquireLock_Concurrent(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	lockPath := filepath.Join(repoDir, ".git", LockFile)
+	host, _ := os.Hostname()
+
+	cmd := exec.Command("git", "--version")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	dead := fmt.Sprintf(`{"pid":%d,"host":%q,"started_at":%q}`, cmd.Process.Pid, host, time.Now().Format(time.RFC3339))
+
+	// Runs racing for a free lock and for the same stale lock: one wins each time
+	for name, content := range map[string]string{"free": "", "stale": dead} {
+		if content != "" {
+			if err := os.WriteFile(lockPath, []byte(content), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		const runs = 16
+		locks := make(chan *Lock, runs)
+		var wg sync.WaitGroup
+		for i := 0; i < runs; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				lock, err := AcquireLock(repoDir)
+				var locked *LockedError
+				switch {
+				case err == nil:
+					locks <- lock
+				case !errors.As(err, &locked):
+					t.Errorf("%s: unexpected error: %v", name, err)
+				}
+			}()
+		}
+		wg.Wait()
+		close(locks)
+
+		if len(locks) != 1 {
+			t.Errorf("%s: %d runs acquired the lock, want 1", name, len(locks))
+		}
+		for lock := range locks {
+			_ = lock.Release()
+		}
+
+		// Nothing is left behind but the lock itself, now released
+		entries, _ := filepath.Glob(lockPath + "*")
+		if len(entries) != 0 {
+			t.Errorf("%s: leftover lock files: %v", name, entries)
+		}
+	}
+}
+
+func TestSnapshot_TakeAndRestore(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "tracked.txt", "committed")
+	testutil.CreateFile(t, repoDir, "clean.txt", "clean")
+	testutil.CreateFile(t, repoDir, ".gitignore", "*.log\n")
+	testutil.GitAdd(t, repoDir, "tracked.txt", "clean.txt", ".gitignore")
+	testutil.GitCommit(t, repoDir, "initial")
+
+	testutil.CreateFile(t, repoDir, "tracked.txt", "edited")
+	testutil.CreateFile(t, repoDir, "staged.txt", "staged")
+	testutil.GitAdd(t, repoDir, "staged.txt")
+	testutil.CreateFile(t, repoDir, "dir/new.txt", "untracked")
+	testutil.CreateFile(t, repoDir, "debug.log", "ignored")
+
+	snap, err := TakeSnapshot(repoDir, "exec_1")
+	if err != nil {
+		t.Fatalf("TakeSnapshot failed: %v", err)
+	}
+	if want := []string{"dir/new.txt", "staged.txt", "tracked.txt"}; !reflect.DeepEqual(snap.Files, want) {
+		t.Errorf("Files = %v, want %v", snap.Files, want)
+	}
+
+	// Lose the work the way a bad reset would
+	for _, args := range [][]string{{"reset", "--hard"}, {"clean", "-fd"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	loaded, err := LoadSnapshot(repoDir, "")
+	if err != nil || loaded.ID != "exec_1" {
+		t.Fatalf("LoadSnapshot(latest) = %+v, %v", loaded, err)
+	}
+	restored, err := loaded.Restore(repoDir)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if len(restored) != 3 {
+		t.Errorf("restored %v, want all three files", restored)
+	}
+	for path, want := range map[string]string{"tracked.txt": "edited", "staged.txt": "staged", "dir/new.txt": "untracked"} {
+		if got, _ := os.ReadFile(filepath.Join(repoDir, path)); string(got) != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+
+	// Restoring again changes nothing
+	if restored, err := loaded.Restore(repoDir); err != nil || len(restored) != 0 {
+		t.Errorf("second Restore = %v, %v; want nothing restored", restored, err)
+	}
+
+	for _, id := range []string{"exec_missing", "../exec_1"} {
+		var notFound *SnapshotNotFoundError
+		if _, err := LoadSnapshot(repoDir, id); !errors.As(err, &notFound) {
+			t.Errorf("LoadSnapshot(%q) error = %v, want SnapshotNotFoundError", id, err)
+		}
+	}
+}
+
+func TestSnapshot_PartiallyStaged(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "file.txt", "committed")
+	testutil.GitAdd(t, repoDir, "file.txt")
+	testutil.GitCommit(t, repoDir, "initial")
+
+	// The staged and working-tree versions differ
+	testutil.CreateFile(t, repoDir, "file.txt", "staged")
+	testutil.GitAdd(t, repoDir, "file.txt")
+	testutil.CreateFile(t, repoDir, "file.txt", "unstaged")
+
+	snap, err := TakeSnapshot(repoDir, "exec_1")
+	if err != nil {
+		t.Fatalf("TakeSnapshot failed: %v", err)
+	}
+
+	cmd := exec.Command("git", "reset", "--hard")
+	cmd.Dir = repoDir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git reset: %v\n%s", err, out)
+	}
+
+	loaded, err := LoadSnapshot(repoDir, snap.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := loaded.Restore(repoDir); err != nil || !reflect.DeepEqual(restored, []string{"file.txt"}) {
+		t.Fatalf("Restore = %v, %v", restored, err)
+	}
+
+	if got, _ := os.ReadFile(filepath.Join(repoDir, "file.txt")); string(got) != "unstaged" {
+		t.Errorf("working tree = %q, want %q", got, "unstaged")
+	}
+	cmd = exec.Command("git", "show", ":file.txt")
+	cmd.Dir = repoDir
+	if out, err := cmd.Output(); err != nil || string(out) != "staged" {
+		t.Errorf("index = %q, %v; want %q", out, err, "staged")
+	}
+}
+
+func TestSnapshot_RestoreUnsafePath(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	outside := filepath.Join(filepath.Dir(repoDir), "outside.txt")
+
+	for _, path := range []string{"../outside.txt", outside, "a/../../outside.txt"} {
+		snap := &Snapshot{ID: "exec_1", Files: []string{path}, dir: t.TempDir()}
+		// The copy sits where Restore would look for it
+		src := filepath.Join(snap.dir, snapshotFiles, path)
+		if err := os.MkdirAll(filepath.Dir(src), 0700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(src, []byte("escaped"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := snap.Restore(repoDir); err == nil {
+			t.Errorf("Restore(%q) succeeded, want an unsafe path error", path)
+		}
+		if _, err := os.Stat(outside); !os.IsNotExist(err) {
+			t.Fatalf("Restore(%q) wrote outside the repository", path)
+		}
+	}
+
+	snap := &Snapshot{ID: "exec_1", Index: []IndexEntry{{Path: "../outside.txt", Mode: "100644", Blob: strings.Repeat("0", 40)}}, dir: t.TempDir()}
+	if _, err := snap.Restore(repoDir); err == nil {
+		t.Error("Restore accepted an unsafe index path")
+	}
+}
+
+func TestSnapshot_Prune(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "new.txt", "untracked")
+
+	for i := range SnapshotsKept + 2 {
+		if _, err := TakeSnapshot(repoDir, fmt.Sprintf("exec_%02d", i)); err != nil {
+			t.Fatalf("TakeSnapshot failed: %v", err)
+		}
+	}
+	snaps, err := ListSnapshots(repoDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snaps) != SnapshotsKept {
+		t.Fatalf("kept %d snapshots, want %d", len(snaps), SnapshotsKept)
+	}
+	if newest := fmt.Sprintf("exec_%02d", SnapshotsKept+1); snaps[0].ID != newest {
+		t.Errorf("newest snapshot = %s, want %s", snaps[0].ID, newest)
+	}
+}
+
+func TestInstallAlias(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	repoDir := testutil.TestRepo(t)
+	subDir := filepath.Join(repoDir, "sub")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// pwd stands in for the tool to show the alias keeps the caller's directory
+	if err := InstallAlias("sc", "pwd"); err != nil {
+		t.Fatalf("InstallAlias failed: %v", err)
+	}
+	out, err := exec.Command("git", "-C", subDir, "sc").Output()
+	if err != nil {
+		t.Fatalf("git sc failed: %v", err)
+	}
+	if got, _ := filepath.EvalSymlinks(strings.TrimSpace(string(out))); got != mustEvalSymlinks(t, subDir) {
+		t.Errorf("alias ran in %q, want %q", got, subDir)
+	}
+
+	// Reinstalling over our own alias is fine; other aliases are left alone
+	if err := InstallAlias("sc", "commit"); err != nil {
+		t.Errorf("reinstall failed: %v", err)
+	}
+	_ = exec.Command("git", "config", "--global", "alias.st", "status").Run()
+	if _, ok := InstallAlias("st", "commit").(*AliasConflictError); !ok {
+		t.Error("expected AliasConflictError for a foreign alias")
+	}
+	if _, err := UninstallAlias("st"); err == nil {
+		t.Error("expected uninstall to refuse a foreign alias")
+	}
+	if _, ok := InstallAlias("bad name", "commit").(*InvalidAliasError); !ok {
+		t.Error("expected InvalidAliasError")
+	}
+
+	removed, err := UninstallAlias("sc")
+	if err != nil || !removed {
+		t.Fatalf("UninstallAlias() = %v, %v", removed, err)
+	}
+	if _, ok := GlobalAlias("sc"); ok {
+		t.Error("expected alias to be removed")
+	}
+	if removed, _ := UninstallAlias("sc"); removed {
+		t.Error("expected nothing to remove the second time")
+	}
+}
+
+func TestAliasCommand_Quoting(t *testing.T) {
+	if got := AliasCommand("/opt/my tools/commit"); !strings.Contains(got, `'/opt/my tools/commit' "$@"`) {
+		t.Errorf("AliasCommand() = %q, want quoted path", got)
+	}
+	if !IsToolAlias(AliasCommand("commit")) || IsToolAlias("!commit") {
+		t.Error("IsToolAlias should only match installed aliases")
+	}
+}
+
+func mustEvalSymlinks(t *testing.T, path string) string {
+	t.Helper()
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resolved
+}
+
+func TestCommand_Trace(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	var entries []TraceEntry
+	SetTracer(func(e TraceEntry) { entries = append(entries, e) })
+	defer SetTracer(nil)
+
+	cmd := Command("rev-parse", "--is-inside-work-tree")
+	cmd.Dir = repoDir
+	if out, err := cmd.Output(); err != nil || strings.TrimSpace(string(out)) != "true" {
+		t.Fatalf("rev-parse failed: %v %q", err, out)
+	}
+
+	cmd = Command("rev-parse", "--verify", "no-such-ref")
+	cmd.Dir = repoDir
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected rev-parse of a missing ref to fail")
+	}
+
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 trace entries, got %d", len(entries))
+	}
+	if got := strings.Join(entries[0].Args, " "); got != "rev-parse --is-inside-work-tree" {
+		t.Errorf("unexpected args: %q", got)
+	}
+	if entries[0].ExitCode != 0 || strings.TrimSpace(entries[0].Output) != "true" || entries[0].Dir != repoDir {
+		t.Errorf("unexpected success entry: %+v", entries[0])
+	}
+	if entries[1].ExitCode == 0 {
+		t.Errorf("expected non-zero exit code, got %+v", entries[1])
+	}
+
+	if got := truncateTrace(strings.Repeat("x", maxTraceOutput+10)); len(got) > maxTraceOutput+20 {
+		t.Errorf("expected output to be truncated, got %d bytes", len(got))
+	}
+}
+
+func TestEditBuffer_RoundTrip(t *testing.T) {
+	scope := "api"
+	c := types.PlannedCommit{Type: "feat", Scope: &scope, Message: "add handler", Body: "Details.", Files: []string{"api.go"}}
+
+	buffer := BuildEditBuffer(c, EditBufferOptions{
+		CommentChar: "#",
+		Template:    "# Why is this change needed?\nRefs: ",
+		DiffStat:    " api.go | 2 +-\n 1 file changed",
+		Diff:        "diff --git a/api.go b/api.go\n+# not a comment below the scissors",
+	})
+
+	for _, want := range []string{
+		"feat(api): add handler\n\nDetails.\n",
+		"# Why is this change needed?\n# Refs:",
+		"#\tapi.go | 2 +-",
+		"# " + scissors,
+	} {
+		if !strings.Contains(buffer, want) {
+			t.Errorf("buffer missing %q:\n%s", want, buffer)
+		}
+	}
+
+	if got := StripEditBuffer(buffer, "#"); got != "feat(api): add handler\n\nDetails." {
+		t.Errorf("StripEditBuffer() = %q", got)
+	}
+
+	edited := strings.Replace(buffer, "feat(api): add handler", "fix: handle errors", 1)
+	parsed, err := ParseEditBuffer(c, edited, "#")
+	if err != nil {
+		t.Fatalf("ParseEditBuffer() error = %v", err)
+	}
+	if parsed.Type != "fix" || parsed.Scope != nil || parsed.Message != "handle errors" || parsed.Body != "Details." {
+		t.Errorf("parsed = %+v", parsed)
+	}
+
+	// A free-form header only replaces the message
+	parsed, _ = ParseEditBuffer(c, "Handle errors\n", "#")
+	if parsed.Type != "feat" || parsed.Message != "Handle errors" || parsed.Body != "" {
+		t.Errorf("parsed free-form = %+v", parsed)
+	}
+
+	if _, err := ParseEditBuffer(c, "# only comments\n\n", "#"); err == nil {
+		t.Error("expected EmptyMessageError for an empty buffer")
+	}
+}
+
+func TestStripEditBuffer_Whitespace(t *testing.T) {
+	got := StripEditBuffer("\n\nsubject  \n\n\n; comment\nbody line\t\n\n", ";")
+	if got != "subject\n\nbody line" {
+		t.Errorf("StripEditBuffer() = %q", got)
+	}
+}
+
+func TestMessageEditor_Command(t *testing.T) {
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(t.TempDir(), "gitconfig"))
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "api.go", "package api\n")
+	testutil.GitAdd(t, repoDir, "api.go")
+	testutil.GitCommit(t, repoDir, "chore: initial commit")
+	testutil.CreateFile(t, repoDir, "api.go", "package api\n\nfunc Handle() {}\n")
+
+	testutil.CreateFile(t, repoDir, ".gitmessage", "# Explain why\n")
+	for _, kv := range [][2]string{{"commit.template", ".gitmessage"}, {"commit.verbose", "true"}} {
+		if err := exec.Command("git", "-C", repoDir, "config", kv[0], kv[1]).Run(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The "editor" keeps a copy of the buffer and writes a new message
+	script := filepath.Join(t.TempDir(), "editor.sh")
+	content := "#!/bin/sh\ncp \"$1\" \"$1.seen\"\nprintf 'fix(api): handle requests\\n\\nWhy it changed.\\n' > \"$1\"\n"
+	if err := os.WriteFile(script, []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GIT_EDITOR", script)
+
+	editor := NewMessageEditor(repoDir)
+	cmd, read, err := editor.Command(types.PlannedCommit{Type: "feat", Message: "add handler", Files: []string{"api.go"}})
+	if err != nil {
+		t.Fatalf("Command() error = %v", err)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("editor failed: %v\n%s", err, out)
+	}
+
+	seen, err := os.ReadFile(filepath.Join(repoDir, ".git", EditMsgFile+".seen"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"feat: add handler", "# Explain why", "api.go | 2 ++", scissors, "+func Handle() {}"} {
+		if !strings.Contains(string(seen), want) {
+			t.Errorf("buffer missing %q:\n%s", want, seen)
+		}
+	}
+
+	edited, err := read()
+	if err != nil {
+		t.Fatalf("read() error = %v", err)
+	}
+	if edited.Type != "fix" || edited.Scope == nil || *edited.Scope != "api" || edited.Message != "handle requests" || edited.Body != "Why it changed." {
+		t.Errorf("edited = %+v", edited)
+	}
+}
+
+func TestShellCommand(t *testing.T) {
+	// Arguments reach the command as is, whatever they contain
+	out, err := shellCommand("printf '%s|'", "a b", "$HOME", `"quoted"`).Output()
+	if err != nil {
+		t.Fatalf("shellCommand failed: %v", err)
+	}
+	if want := `a b|$HOME|"quoted"|`; string(out) != want {
+		t.Errorf("output = %q, want %q", out, want)
+	}
+
+	if out, err := shellCommand("echo one && echo two").Output(); err != nil || string(out) != "one\ntwo\n" {
+		t.Errorf("shellCommand(line) = %q, %v", out, err)
+	}
+}
+
+func TestParseLFSPointer(t *testing.T) {
+	pointer := "version https://git-lfs.github.com/spec/v1\noid sha256:" + strings.Repeat("c", 64) + "\nsize 12345\n"
+	if size, ok := ParseLFSPointer([]byte(pointer)); !ok || size != 12345 {
+		t.Errorf("ParseLFSPointer() = %d, %v; want 12345, true", size, ok)
+	}
+	if _, ok := ParseLFSPointer([]byte("size 10\n")); ok {
+		t.Error("ParseLFSPointer() accepted a non-pointer")
+	}
+}
+
+func TestDescribeLFSChange(t *testing.T) {
+	tests := []struct {
+		oldSize, newSize int64
+		hadOld, hasNew   bool
+		want             string
+	}{
+		{0, 2048, false, true, "LFS asset added (2.0 KB)"},
+		{512, 0, true, false, "LFS asset deleted (512 B)"},
+		{1536, 1536, true, true, "LFS asset updated (1.5 KB)"},
+		{1 << 20, 5 << 30, true, true, "LFS asset updated (1.0 MB → 5.0 GB)"},
+	}
+	for _, tt := range tests {
+		if got := DescribeLFSChange(tt.oldSize, tt.hadOld, tt.newSize, tt.hasNew); got != tt.want {
+			t.Errorf("DescribeLFSChange(%d, %v, %d, %v) = %q, want %q", tt.oldSize, tt.hadOld, tt.newSize, tt.hasNew, got, tt.want)
+		}
+	}
+}
+
+func TestCollector_LFSFiles(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, ".gitattributes", "*.bin filter=lfs diff=lfs merge=lfs -text\n")
+
+	files, err := NewCollector(repoDir).LFSFiles([]string{"model.bin", "main.go"})
+	if err != nil {
+		t.Fatalf("LFSFiles failed: %v", err)
+	}
+	if len(files) != 1 || files[0] != "model.bin" {
+		t.Errorf("LFSFiles() = %v, want [model.bin]", files)
+	}
+
+	if err := CheckLFS(repoDir, nil); err != nil {
+		t.Errorf("CheckLFS() with no LFS files = %v, want nil", err)
+	}
+}
+
+func TestMatchesFocus(t *testing.T) {
+	tests := []struct {
+		file     string
+		patterns []string
+		want     bool
+	}{
+		{"api/handler.go", []string{"api"}, true},
+		{"api/handler.go", []string{"api/"}, true},
+		{"api/handler.go", []string{"api/handler.go"}, true},
+		{"apiv2/handler.go", []string{"api"}, false},
+		{"api/handler.go", []string{"api/*.go"}, true},
+		{"web/app.ts", []string{"api", "web"}, true},
+		{"web/app.ts", []string{"api"}, false},
+		{"web/app.ts", []string{"."}, true},
+	}
+	for _, tt := range tests {
+		if got := MatchesFocus(tt.file, tt.patterns); got != tt.want {
+			t.Errorf("MatchesFocus(%q, %v) = %v, want %v", tt.file, tt.patterns, got, tt.want)
+		}
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{"**/*.snap", "top.snap", true},
+		{"**/*.snap", "ui/__snapshots__/app.snap", true},
+		{"*.snap", "ui/app.snap", false},
+		{"docs", "docs/guide/intro.md", true},
+		{"docs", "docsite/index.md", false},
+		{"ui/**/fixtures", "ui/a/b/fixtures/x.json", true},
+		{"ui/**", "ui/app.ts", true},
+		{"api/*.go", "api/handler.go", true},
+	}
+	for _, tt := range tests {
+		if got := MatchGlob(tt.pattern, tt.file); got != tt.want {
+			t.Errorf("MatchGlob(%q, %q) = %v, want %v", tt.pattern, tt.file, got, tt.want)
+		}
+	}
+}
+
+func TestSkipPathspecs(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "README.md", "# Test\n")
+	testutil.GitAdd(t, repoDir, "README.md")
+	testutil.GitCommit(t, repoDir, "initial commit")
+	testutil.CreateFile(t, repoDir, "main.go", "package main\n")
+	testutil.CreateFile(t, repoDir, "ui/app.ts", "export {}\n")
+	testutil.CreateFile(t, repoDir, "ui/__snapshots__/app.snap", "snap\n")
+	testutil.CreateFile(t, repoDir, "docs/guide.md", "# Guide\n")
+
+	specs := SkipPathspecs([]string{"**/*.snap", "docs/"})
+	if include, skips := SplitSkipPathspecs(append([]string{"ui"}, specs...)); len(include) != 1 || len(skips) != 2 || skips[1] != "docs" {
+		t.Errorf("SplitSkipPathspecs = %v, %v", include, skips)
+	}
+
+	collector := NewCollector(repoDir)
+	collector.SetPathspecs(specs)
+	skipped, err := collector.SkippedFiles()
+	if err != nil {
+		t.Fatalf("SkippedFiles failed: %v", err)
+	}
+	slices.Sort(skipped)
+	if want := []string{"docs/guide.md", "ui/__snapshots__/app.snap"}; !slices.Equal(skipped, want) {
+		t.Errorf("SkippedFiles() = %v, want %v", skipped, want)
+	}
+
+	// Positive pathspecs narrow the skipped files to the run's scope
+	scoped := NewCollector(repoDir)
+	scoped.SetPathspecs(append([]string{"ui"}, specs...))
+	if skipped, _ := scoped.SkippedFiles(); !slices.Equal(skipped, []string{"ui/__snapshots__/app.snap"}) {
+		t.Errorf("scoped SkippedFiles() = %v", skipped)
+	}
+
+	// Untracked directories expand without their skipped files
+	stager := NewStager(repoDir)
+	stager.SetPathspecs(specs)
+	if err := stager.StageFiles([]string{"ui/"}); err != nil {
+		t.Fatalf("StageFiles failed: %v", err)
+	}
+	if staged, _ := stager.StagedFiles(); !slices.Equal(staged, []string{"ui/app.ts"}) {
+		t.Errorf("expected only ui/app.ts staged, got %v", staged)
+	}
+}
+
+func TestFocusDiff(t *testing.T) {
+	diff := `diff --git a/api/handler.go b/api/handler.go
+index 1111111..2222222 100644
+--- a/api/handler.go
++++ b/api/handler.go
+@@ -1,2 +1,3 @@
+ package api
++func Handle() {}
+diff --git a/web/app.ts b/web/app.ts
+index 3333333..4444444 100644
+--- a/web/app.ts
++++ b/web/app.ts
+@@ -1,3 +1,3 @@
+-const a = 1
+-const b = 2
++const a = 3
+ export {}
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+index 5555555..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+`
+
+	got := FocusDiff(diff, []string{"api"})
+
+	if !strings.Contains(got, "+func Handle() {}") {
+		t.Errorf("focused file should keep its hunks:\n%s", got)
+	}
+	if strings.Contains(got, "const a = 3") || strings.Contains(got, "-gone") {
+		t.Errorf("other files should be summarized:\n%s", got)
+	}
+	if !strings.Contains(got, "+++ b/web/app.ts\n... (summarized: +1 -2 lines)\n") {
+		t.Errorf("expected a summary for web/app.ts:\n%s", got)
+	}
+	if !strings.Contains(got, "+++ /dev/null\n... (summarized: +0 -1 lines)\n") {
+		t.Errorf("expected a summary for the deleted old.txt:\n%s", got)
+	}
+
+	if kept := FocusDiff(diff, []string{"old.txt"}); !strings.Contains(kept, "-gone") {
+		t.Errorf("a deleted file can be focused:\n%s", kept)
+	}
+}
+
+func TestSuggestIgnores(t *testing.T) {
+	untracked := []string{
+		".DS_Store", "docs/.DS_Store", "main.go.orig", ".main.go.swp",
+		"web/node_modules/", "build/", "tools/build/gen.go", "main.go",
+	}
+
+	suggestions := SuggestIgnores(untracked)
+
+	got := make(map[string][]string)
+	for _, s := range suggestions {
+		got[s.Pattern] = s.Files
+	}
+	want := map[string][]string{
+		".DS_Store":     {".DS_Store", "docs/.DS_Store"},
+		"*.orig":        {"main.go.orig"},
+		"*.swp":         {".main.go.swp"},
+		"node_modules/": {"web/node_modules/"},
+		"build/":        {"build/"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SuggestIgnores() = %v, want %v", got, want)
+	}
+}
+
+func TestAppendIgnores(t *testing.T) {
+	dir := t.TempDir()
+	gitignore := filepath.Join(dir, GitignoreFile)
+	if err := os.WriteFile(gitignore, []byte("bin/\n*.orig"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	added, err := AppendIgnores(dir, []string{"*.orig", ".DS_Store"})
+	if err != nil {
+		t.Fatalf("AppendIgnores failed: %v", err)
+	}
+	if !reflect.DeepEqual(added, []string{".DS_Store"}) {
+		t.Errorf("added = %v", added)
+	}
+	content, _ := os.ReadFile(gitignore)
+	if string(content) != "bin/\n*.orig\n.DS_Store\n" {
+		t.Errorf(".gitignore = %q", content)
+	}
+
+	if added, err := AppendIgnores(dir, []string{".DS_Store"}); err != nil || added != nil {
+		t.Errorf("AppendIgnores with nothing new = %v, %v", added, err)
+	}
+}
+
+func TestParsePatch(t *testing.T) {
+	patch := `From 1234 Mon Sep 17 00:00:00 2001
+Subject: [PATCH] example
+
+diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,3 @@
+ package main
+--- removed comment
++// added comment
+ func main() {}
+diff --git a/new.txt b/new.txt
+new file mode 100644
+index 0000000..3333333
+--- /dev/null
++++ b/new.txt
+@@ -0,0 +1,2 @@
++one
++two
+diff --git a/old.txt b/old.txt
+deleted file mode 100644
+index 4444444..0000000
+--- a/old.txt
++++ /dev/null
+@@ -1 +0,0 @@
+-gone
+diff --git a/before.go b/after.go
+similarity index 100%
+rename from before.go
+rename to after.go
+--- plain.c	2024-01-01 10:00:00.000000000 +0000
++++ plain.c	2024-01-02 10:00:00.000000000 +0000
+@@ -1,2 +1,2 @@
+-int x;
++int y;
+ int z;
+\ No newline at end of file
+`
+
+	want := []types.FileChange{
+		{Path: "main.go", Status: "modified", DiffSummary: "+1 -1"},
+		{Path: "new.txt", Status: "added", DiffSummary: "+2 -0"},
+		{Path: "old.txt", Status: "deleted", DiffSummary: "+0 -1"},
+		{Path: "after.go", Status: "renamed", DiffSummary: "+0 -0"},
+		{Path: "plain.c", Status: "modified", DiffSummary: "+1 -1"},
+	}
+	if got := ParsePatch(patch); !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePatch() =\n%+v\nwant\n%+v", got, want)
+	}
+
+	if got := ParsePatch("not a diff\n"); len(got) != 0 {
+		t.Errorf("ParsePatch(non-diff) = %+v, want none", got)
+	}
+}
+
+func TestCollector_FileHashes(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "a.txt", "a\n")
+	testutil.CreateFile(t, repoDir, "gone.txt", "gone\n")
+	testutil.GitAdd(t, repoDir, "a.txt", "gone.txt")
+	testutil.GitCommit(t, repoDir, "initial")
+
+	testutil.CreateFile(t, repoDir, "a.txt", "changed\n")
+	testutil.CreateFile(t, repoDir, "new/b.txt", "b\n")
+	if err := os.Remove(filepath.Join(repoDir, "gone.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	collector := NewCollector(repoDir)
+	paths := []string{"a.txt", "gone.txt", "new/"}
+	before, err := collector.FileHashes(paths, false)
+	if err != nil {
+		t.Fatalf("FileHashes failed: %v", err)
+	}
+	if before["gone.txt"] != "deleted" || before["a.txt"] == "" || before["new/"] == "" {
+		t.Errorf("unexpected hashes: %v", before)
+	}
+
+	testutil.CreateFile(t, repoDir, "new/b.txt", "b changed\n")
+	after, err := collector.FileHashes(paths, false)
+	if err != nil {
+		t.Fatalf("FileHashes failed: %v", err)
+	}
+	if after["a.txt"] != before["a.txt"] || after["new/"] == before["new/"] {
+		t.Errorf("expected only new/ to change: before %v, after %v", before, after)
+	}
+
+	staged, err := collector.FileHashes([]string{"a.txt"}, true)
+	if err != nil {
+		t.Fatalf("FileHashes(staged) failed: %v", err)
+	}
+	if staged["a.txt"] == before["a.txt"] || staged["a.txt"] == "deleted" {
+		t.Errorf("expected the index blob for a.txt, got %q", staged["a.txt"])
+	}
+}
+
+func TestFilterDiff(t *testing.T) {
+	diff := "diff --git a/a.go b/a.go\n--- a/a.go\n+++ b/a.go\n@@ -1 +1 @@\n-a\n+b\n" +
+		"diff --git a/docs/x.md b/docs/x.md\n--- a/docs/x.md\n+++ b/docs/x.md\n@@ -1 +1 @@\n-x\n+y\n" +
+		"diff --git a/c.go b/c.go\n--- a/c.go\n+++ b/c.go\n@@ -1 +1 @@\n-c\n+d\n"
+
+	got := FilterDiff(diff, []string{"c.go", "docs/"})
+	want := "diff --git a/docs/x.md b/docs/x.md\n--- a/docs/x.md\n+++ b/docs/x.md\n@@ -1 +1 @@\n-x\n+y\n" +
+		"diff --git a/c.go b/c.go\n--- a/c.go\n+++ b/c.go\n@@ -1 +1 @@\n-c\n+d\n"
+	if got != want {
+		t.Errorf("FilterDiff() =\n%s\nwant\n%s", got, want)
+	}
+
+	sections := DiffByFile(diff)
+	if len(sections) != 3 || sections["docs/x.md"] != "diff --git a/docs/x.md b/docs/x.md\n--- a/docs/x.md\n+++ b/docs/x.md\n@@ -1 +1 @@\n-x\n+y\n" {
+		t.Errorf("DiffByFile() = %q", sections)
+	}
+}
+
+func TestCompressDiff(t *testing.T) {
+	section := func(file, hunks string) string {
+		return "diff --git a/" + file + " b/" + file + "\nindex 1111111..2222222 100644\n--- a/" + file + "\n+++ b/" + file + "\n" + hunks
+	}
+	importHunk := func(line int) string {
+		return fmt.Sprintf("@@ -%d,3 +%d,3 @@\n import (\n-\t\"example.com/old/log\"\n+\t\"example.com/new/log\"\n )\n", line, line)
+	}
+	diff := section("a.go", importHunk(3)) +
+		section("b.go", importHunk(5)+"@@ -20,1 +20,1 @@\n-return nil\n+return err\n") +
+		section("c.go", importHunk(4)) +
+		section("d.go", "@@ -1 +1 @@\n-x\n+y\n")
+
+	got := CompressDiff(diff)
+
+	want := section("a.go", importHunk(3)+"... (same change in 2 more files: b.go, c.go)\n") +
+		section("b.go", "@@ -20,1 +20,1 @@\n-return nil\n+return err\n") +
+		"diff --git a/c.go b/c.go\n... (repeated change, shown in a.go)\n" +
+		section("d.go", "@@ -1 +1 @@\n-x\n+y\n")
+	if got != want {
+		t.Errorf("CompressDiff() =\n%s\nwant\n%s", got, want)
+	}
+
+	// Changes in fewer than MinRepeatedFiles files are left alone
+	pair := section("a.go", importHunk(3)) + section("b.go", importHunk(5))
+	if got := CompressDiff(pair); got != pair {
+		t.Errorf("CompressDiff() changed a diff without repeats:\n%s", got)
+	}
+}
+
+func TestCollector_CommitFileStats(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	collector := NewCollector(repoDir)
+
+	testutil.CreateFile(t, repoDir, "a.txt", "one\ntwo\n")
+	testutil.GitAdd(t, repoDir, "a.txt")
+	testutil.GitCommit(t, repoDir, "initial commit")
+
+	testutil.CreateFile(t, repoDir, "a.txt", "one\nthree\nfour\n")
+	testutil.CreateFile(t, repoDir, "my file.txt", "x\n")
+	if err := os.WriteFile(filepath.Join(repoDir, "image.bin"), []byte{0, 1, 2, 0}, 0644); err != nil {
+		t.Fatal(err)
+	}
+	testutil.GitAdd(t, repoDir, "a.txt", "my file.txt", "image.bin")
+	hash := testutil.GitCommit(t, repoDir, "second commit")
+
+	stats, err := collector.CommitFileStats(hash)
+	if err != nil {
+		t.Fatalf("CommitFileStats failed: %v", err)
+	}
+	want := []FileStat{
+		{Path: "a.txt", Added: 2, Removed: 1},
+		{Path: "image.bin", Binary: true},
+		{Path: "my file.txt", Added: 1},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("CommitFileStats() = %+v, want %+v", stats, want)
+	}
+
+	if _, err := collector.CommitFileStats("0000000"); err == nil {
+		t.Error("expected an error for an unknown commit")
+	}
+}
+
+func TestSetReadOnly(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "new.go", "package main")
+
+	SetReadOnly(true)
+	defer SetReadOnly(false)
+
+	mustPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: expected a panic in read-only mode", name)
+			}
+		}()
+		fn()
+	}
+	mustPanic("StageFiles", func() { _ = NewStager(repoDir).StageFiles([]string{"new.go"}) })
+	mustPanic("UnstageAll", func() { _ = NewStager(repoDir).UnstageAll() })
+	mustPanic("Commit", func() { _, _ = NewCommitter(repoDir).Commit("add new") })
+	mustPanic("git add", func() {
+		cmd := Command("add", "new.go")
+		cmd.Dir = repoDir
+		_ = cmd.Run()
+	})
+
+	// Reading still works, without refreshing the index
+	status, err := NewCollector(repoDir).Status()
+	if err != nil {
+		t.Fatalf("Status() failed in read-only mode: %v", err)
+	}
+	if !slices.Contains(status.Untracked, "new.go") {
+		t.Errorf("expected new.go untracked, got %+v", status)
+	}
+	cmd := Command("status")
+	_, _ = cmd.Output()
+	if !slices.Contains(cmd.Env, "GIT_OPTIONAL_LOCKS=0") {
+		t.Error("expected GIT_OPTIONAL_LOCKS=0 in read-only mode")
+	}
+
+	SetReadOnly(false)
+	if err := NewStager(repoDir).StageFiles([]string{"new.go"}); err != nil {
+		t.Errorf("StageFiles() after the guard is off: %v", err)
+	}
+}
+
+func TestCreateBranch(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "file.txt", "content")
+	testutil.GitAdd(t, repoDir, "file.txt")
+	testutil.GitCommit(t, repoDir, "initial")
+
+	// Staged and unstaged changes come along to the new branch
+	testutil.CreateFile(t, repoDir, "staged.txt", "staged")
+	testutil.GitAdd(t, repoDir, "staged.txt")
+	testutil.CreateFile(t, repoDir, "file.txt", "changed")
+
+	if err := CreateBranch(repoDir, "feat/retry-backoff"); err != nil {
+		t.Fatalf("CreateBranch failed: %v", err)
+	}
+	collector := NewCollector(repoDir)
+	if branch, _ := collector.CurrentBranch(); branch != "feat/retry-backoff" {
+		t.Errorf("CurrentBranch() = %q, want feat/retry-backoff", branch)
+	}
+	status, err := collector.Status()
+	if err != nil || !slices.Contains(status.Staged, "staged.txt") || !slices.Contains(status.Modified, "file.txt") {
+		t.Errorf("changes not carried over: %+v, %v", status, err)
+	}
+
+	if _, ok := CreateBranch(repoDir, "feat/retry-backoff").(*BranchExistsError); !ok {
+		t.Error("expected BranchExistsError for an existing branch")
+	}
+	if !BranchExists(repoDir, "feat/retry-backoff") || BranchExists(repoDir, "feat/missing") {
+		t.Error("BranchExists reported the wrong branches")
+	}
+	if ValidBranchName(repoDir, "feat/bad..name") || !ValidBranchName(repoDir, "fix/ok-name") {
+		t.Error("ValidBranchName disagrees with git")
+	}
+}
+
+func TestCreateBranch_NoCommitsYet(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "staged.txt", "staged")
+	testutil.GitAdd(t, repoDir, "staged.txt")
+
+	if err := CreateBranch(repoDir, "feat/first"); err != nil {
+		t.Fatalf("CreateBranch failed: %v", err)
+	}
+	if branch, _ := NewCollector(repoDir).CurrentBranch(); branch != "feat/first" {
+		t.Errorf("CurrentBranch() = %q, want feat/first", branch)
+	}
+}
+
+func TestParseFaults(t *testing.T) {
+	fs, err := ParseFaults("add#2, commit=pre-commit hook failed: lint,status")
+	if err != nil {
+		t.Fatalf("ParseFaults failed: %v", err)
+	}
+	want := []Fault{
+		{Command: "add", Call: 2},
+		{Command: "commit", Message: "pre-commit hook failed: lint"},
+		{Command: "status"},
+	}
+	if !slices.Equal(fs, want) {
+		t.Errorf("ParseFaults() = %+v, want %+v", fs, want)
+	}
+
+	for _, spec := range []string{"add#0", "add#x", "#2", "=boom"} {
+		if _, err := ParseFaults(spec); err == nil {
+			t.Errorf("ParseFaults(%q): expected an error", spec)
+		}
+	}
+}
+
+func TestInjectFaults(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "a.txt", "a")
+
+	var traced []TraceEntry
+	SetTracer(func(e TraceEntry) { traced = append(traced, e) })
+	defer SetTracer(nil)
+	defer InjectFaults(Fault{Command: "add", Call: 2, Message: "fatal: index.lock exists"})()
+
+	add := func() ([]byte, error) {
+		cmd := Command("add", "a.txt")
+		cmd.Dir = repoDir
+		return cmd.CombinedOutput()
+	}
+	if _, err := add(); err != nil {
+		t.Fatalf("first add should run: %v", err)
+	}
+	out, err := add()
+	var fault *FaultError
+	if !errors.As(err, &fault) || fault.Call != 2 || string(out) != "fatal: index.lock exists" {
+		t.Errorf("second add: got %q, %v; want the injected failure", out, err)
+	}
+	if _, err := add(); err != nil {
+		t.Errorf("third add should run: %v", err)
+	}
+
+	// Injected failures are traced like real ones
+	if len(traced) != 3 || traced[1].ExitCode != 1 || traced[1].Stderr != "fatal: index.lock exists" {
+		t.Errorf("unexpected trace: %+v", traced)
+	}
+}
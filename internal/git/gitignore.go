@@ -0,0 +1,127 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// GitignoreFile is the repository root's ignore file.
+const GitignoreFile = ".gitignore"
+
+// junkPattern is a .gitignore pattern for files that are almost never meant
+// to be committed.
+type junkPattern struct {
+	pattern string
+	// newDirOnly limits a directory pattern to directories that are wholly
+	// untracked, since names like build/ are sometimes source directories.
+	newDirOnly bool
+}
+
+// junkPatterns are the OS, editor, merge and build leftovers suggested for .gitignore.
+var junkPatterns = []junkPattern{
+	{pattern: ".DS_Store"},
+	{pattern: "Thumbs.db"},
+	{pattern: "*.orig"},
+	{pattern: "*.rej"},
+	{pattern: "*.swp"},
+	{pattern: "*.swo"},
+	{pattern: "*~"},
+	{pattern: "*.pyc"},
+	{pattern: "node_modules/"},
+	{pattern: "__pycache__/"},
+	{pattern: ".pytest_cache/"},
+	{pattern: "build/", newDirOnly: true},
+	{pattern: "dist/", newDirOnly: true},
+	{pattern: "target/", newDirOnly: true},
+}
+
+// IgnoreSuggestion is a .gitignore pattern and the untracked paths it would ignore.
+type IgnoreSuggestion struct {
+	Pattern string
+	Files   []string
+}
+
+// SuggestIgnores returns .gitignore patterns for junk among untracked paths,
+// as listed by git status (untracked directories end in "/").
+func SuggestIgnores(untracked []string) []IgnoreSuggestion {
+	var suggestions []IgnoreSuggestion
+	for _, junk := range junkPatterns {
+		var matched []string
+		for _, p := range untracked {
+			if junk.matches(p) {
+				matched = append(matched, p)
+			}
+		}
+		if len(matched) > 0 {
+			suggestions = append(suggestions, IgnoreSuggestion{Pattern: junk.pattern, Files: matched})
+		}
+	}
+	return suggestions
+}
+
+// matches reports whether the untracked path p is junk by this pattern.
+func (j junkPattern) matches(p string) bool {
+	dir, isDirPattern := strings.CutSuffix(j.pattern, "/")
+	if !isDirPattern {
+		ok, _ := path.Match(j.pattern, path.Base(p))
+		return ok
+	}
+
+	if j.newDirOnly {
+		return strings.HasSuffix(p, "/") && path.Base(strings.TrimSuffix(p, "/")) == dir
+	}
+	components := strings.Split(strings.TrimSuffix(p, "/"), "/")
+	if !strings.HasSuffix(p, "/") {
+		// The last component of a file path is the file itself
+		components = components[:len(components)-1]
+	}
+	for _, c := range components {
+		if c == dir {
+			return true
+		}
+	}
+	return false
+}
+
+// AppendIgnores adds patterns missing from the .gitignore in workDir,
+// creating it if needed, and returns the patterns added.
+func AppendIgnores(workDir string, patterns []string) ([]string, error) {
+	assertWritable("updating " + GitignoreFile)
+
+	gitignore := filepath.Join(workDir, GitignoreFile)
+	content, err := os.ReadFile(gitignore)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read %s: %w", GitignoreFile, err)
+	}
+
+	existing := make(map[string]bool)
+	for _, line := range strings.Split(string(content), "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+
+	var added []string
+	var b strings.Builder
+	b.Write(content)
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		b.WriteString("\n")
+	}
+	for _, p := range patterns {
+		if existing[p] {
+			continue
+		}
+		existing[p] = true
+		added = append(added, p)
+		b.WriteString(p + "\n")
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	if err := os.WriteFile(gitignore, []byte(b.String()), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", GitignoreFile, err)
+	}
+	return added, nil
+}
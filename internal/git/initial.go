@@ -0,0 +1,43 @@
+package git
+
+import (
+	"strings"
+)
+
+// emptyTreeSHA1 is git's empty tree in SHA-1 repositories, used if the empty
+// tree can't be hashed.
+const emptyTreeSHA1 = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// hasHead reports whether the repository at workDir has a commit at HEAD. A
+// new repository has none until its first commit.
+func hasHead(workDir string) bool {
+	cmd := Command("rev-parse", "--verify", "--quiet", "HEAD")
+	cmd.Dir = workDir
+	return cmd.Run() == nil
+}
+
+// emptyTree returns the hash of the empty tree in the repository's object
+// format.
+func emptyTree(workDir string) string {
+	cmd := Command("hash-object", "-t", "tree", "--stdin")
+	cmd.Dir = workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return emptyTreeSHA1
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// HasCommits reports whether the repository has any commits yet.
+func (c *Collector) HasCommits() bool {
+	return hasHead(c.workDir)
+}
+
+// diffBase returns what working tree diffs compare against: HEAD, or before
+// the first commit the empty tree, so every tracked file shows as added.
+func (c *Collector) diffBase() string {
+	if c.HasCommits() {
+		return "HEAD"
+	}
+	return emptyTree(c.workDir)
+}
@@ -0,0 +1,167 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// lfsPointerVersion is the first line of every Git LFS pointer file.
+const lfsPointerVersion = "version https://git-lfs.github.com/spec/v1"
+
+// lfsPointerMaxBytes bounds how much of a file is read looking for a pointer;
+// pointers are well under 200 bytes.
+const lfsPointerMaxBytes = 1024
+
+// LFSFiles returns the subset of files that .gitattributes routes through the
+// Git LFS filter (filter=lfs).
+func (c *Collector) LFSFiles(files []string) ([]string, error) {
+	if len(files) == 0 {
+		return nil, nil
+	}
+
+	cmd := Command("check-attr", "--stdin", "-z", "filter")
+	cmd.Dir = c.workDir
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\x00"))
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to check attributes: %w", err)
+	}
+
+	fields := strings.Split(string(out), "\x00")
+	var lfs []string
+	for i := 0; i+2 < len(fields); i += 3 {
+		if fields[i+1] == "filter" && fields[i+2] == "lfs" {
+			lfs = append(lfs, fields[i])
+		}
+	}
+	return lfs, nil
+}
+
+// LFSChanges describes how each LFS-tracked file changed, e.g. "LFS asset
+// updated (1.2 MB → 1.5 MB)", from the pointer sizes rather than a diff of the
+// pointers. The old size comes from HEAD and the new one from the index when
+// stagedOnly is set, otherwise from the working tree.
+func (c *Collector) LFSChanges(files []string, stagedOnly bool) map[string]string {
+	changes := make(map[string]string, len(files))
+	for _, file := range files {
+		oldSize, hadOld := c.blobLFSSize("HEAD:" + file)
+
+		var newSize int64
+		var hasNew bool
+		if stagedOnly {
+			newSize, hasNew = c.blobLFSSize(":" + file)
+		} else {
+			newSize, hasNew = c.worktreeLFSSize(file)
+		}
+
+		changes[file] = DescribeLFSChange(oldSize, hadOld, newSize, hasNew)
+	}
+	return changes
+}
+
+// blobLFSSize returns the object size recorded in the pointer at rev.
+func (c *Collector) blobLFSSize(rev string) (int64, bool) {
+	cmd := Command("cat-file", "blob", rev)
+	cmd.Dir = c.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return 0, false
+	}
+	if size, ok := ParseLFSPointer(out); ok {
+		return size, true
+	}
+	// Committed before the file was tracked by LFS
+	return int64(len(out)), true
+}
+
+// worktreeLFSSize returns the size of a working tree file, which is either
+// the smudged content or, when smudging was skipped, a pointer.
+func (c *Collector) worktreeLFSSize(file string) (int64, bool) {
+	path := filepath.Join(c.workDir, file)
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	if info.Size() <= lfsPointerMaxBytes {
+		if data, err := os.ReadFile(path); err == nil {
+			if size, ok := ParseLFSPointer(data); ok {
+				return size, true
+			}
+		}
+	}
+	return info.Size(), true
+}
+
+// ParseLFSPointer returns the object size from a Git LFS pointer file.
+func ParseLFSPointer(data []byte) (int64, bool) {
+	if len(data) > lfsPointerMaxBytes || !bytes.HasPrefix(data, []byte(lfsPointerVersion)) {
+		return 0, false
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if value, ok := strings.CutPrefix(scanner.Text(), "size "); ok {
+			size, err := strconv.ParseInt(value, 10, 64)
+			return size, err == nil
+		}
+	}
+	return 0, false
+}
+
+// DescribeLFSChange summarizes an LFS asset change from its old and new sizes.
+func DescribeLFSChange(oldSize int64, hadOld bool, newSize int64, hasNew bool) string {
+	switch {
+	case !hadOld && hasNew:
+		return fmt.Sprintf("LFS asset added (%s)", formatBytes(newSize))
+	case hadOld && !hasNew:
+		return fmt.Sprintf("LFS asset deleted (%s)", formatBytes(oldSize))
+	case oldSize == newSize:
+		return fmt.Sprintf("LFS asset updated (%s)", formatBytes(newSize))
+	default:
+		return fmt.Sprintf("LFS asset updated (%s → %s)", formatBytes(oldSize), formatBytes(newSize))
+	}
+}
+
+// formatBytes formats a size in bytes with a binary unit, e.g. "1.5 MB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// LFSNotInstalledError indicates files are tracked by Git LFS but git-lfs
+// isn't available, so staging them would fail or commit their full content.
+type LFSNotInstalledError struct {
+	Files []string
+}
+
+func (e *LFSNotInstalledError) Error() string {
+	return fmt.Sprintf("%d files are tracked by Git LFS (%s) but git-lfs isn't installed; install it and run `git lfs install`",
+		len(e.Files), strings.Join(e.Files, ", "))
+}
+
+// CheckLFS returns an LFSNotInstalledError when lfsFiles is non-empty and
+// git-lfs can't run in workDir.
+func CheckLFS(workDir string, lfsFiles []string) error {
+	if len(lfsFiles) == 0 {
+		return nil
+	}
+	cmd := Command("lfs", "version")
+	cmd.Dir = workDir
+	if err := cmd.Run(); err != nil {
+		return &LFSNotInstalledError{Files: lfsFiles}
+	}
+	return nil
+}
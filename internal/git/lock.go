@@ -0,0 +1,187 @@
+package git
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// LockFile is the run lock's file name inside the repository's git directory.
+	LockFile = "commit-tool.lock"
+
+	// StaleLockAge is how old a lock must be before it's considered abandoned
+	// when its owner can't be checked (e.g. it was taken on another host).
+	StaleLockAge = 30 * time.Minute
+)
+
+// Lock is a held per-repository run lock.
+type Lock struct {
+	path string
+}
+
+// LockInfo describes the run holding a lock.
+type LockInfo struct {
+	PID       int       `json:"pid"`
+	Host      string    `json:"host"`
+	StartedAt time.Time `json:"started_at"`
+
+	// Nonce tells apart runs that share a PID, e.g. goroutines of a server
+	Nonce string `json:"nonce,omitempty"`
+}
+
+// AcquireLock takes the run lock for the repository at gitRoot so two runs
+// can't stage and commit at the same time. Locks left by a process on this
+// host that no longer exists are replaced, as are locks older than
+// StaleLockAge whose owner can't be checked.
+func AcquireLock(gitRoot string) (*Lock, error) {
+	cmd := Command("rev-parse", "--git-path", LockFile)
+	cmd.Dir = gitRoot
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate git directory: %w", err)
+	}
+
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(gitRoot, path)
+	}
+
+	host, _ := os.Hostname()
+	own := LockInfo{PID: os.Getpid(), Host: host, StartedAt: time.Now(), Nonce: newLockNonce()}
+	data, err := json.Marshal(own)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lock is written in full under a private name and linked into
+	// place, so it's never seen half-written and linking fails if it exists
+	tmp := path + "." + own.Nonce
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write lock file: %w", err)
+	}
+	defer os.Remove(tmp) //nolint:errcheck // best-effort cleanup
+
+	// One retry after clearing a stale lock
+	for attempt := 0; attempt < 2; attempt++ {
+		err := os.Link(tmp, path)
+		if err == nil {
+			if info := readLockInfo(path); info.PID != own.PID || info.Nonce != own.Nonce {
+				return nil, &LockedError{Path: path, Info: info}
+			}
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		info := readLockInfo(path)
+		if !info.stale(host) {
+			return nil, &LockedError{Path: path, Info: info}
+		}
+		if err := clearStaleLock(path, info, own.Nonce); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, &LockedError{Path: path, Info: readLockInfo(path)}
+}
+
+// clearStaleLock removes the lock at path, which was found to be stale.
+// It's renamed aside first: only one of several runs that found the same
+// stale lock can move it, and one that moves a lock that was replaced in
+// the meantime puts it back instead of deleting it.
+func clearStaleLock(path string, stale LockInfo, nonce string) error {
+	aside := path + "." + nonce + ".stale"
+	if err := os.Rename(path, aside); err != nil {
+		if os.IsNotExist(err) {
+			// Another run cleared it first
+			return nil
+		}
+		return fmt.Errorf("failed to remove stale lock file: %w", err)
+	}
+	defer os.Remove(aside) //nolint:errcheck // best-effort cleanup
+
+	if moved := readLockInfo(aside); moved.Nonce != stale.Nonce || moved.PID != stale.PID || !moved.StartedAt.Equal(stale.StartedAt) {
+		_ = os.Link(aside, path)
+		return &LockedError{Path: path, Info: moved}
+	}
+	return nil
+}
+
+// newLockNonce returns a random hex string identifying one acquisition.
+func newLockNonce() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Release removes the lock file.
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// readLockInfo reads a lock file. An unreadable file yields the zero LockInfo.
+func readLockInfo(path string) LockInfo {
+	var info LockInfo
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &info)
+	}
+	if info.StartedAt.IsZero() {
+		// Fall back to the file's age, e.g. for a lock still being written
+		if stat, err := os.Stat(path); err == nil {
+			info.StartedAt = stat.ModTime()
+		}
+	}
+	return info
+}
+
+// stale reports whether the lock's owner is gone. Owners on this host are
+// checked directly, however long they've held the lock (e.g. waiting at
+// --confirm); others are trusted until the lock is StaleLockAge old.
+func (i LockInfo) stale(host string) bool {
+	if i.PID > 0 && i.Host == host {
+		return !processAlive(i.PID)
+	}
+	return !i.StartedAt.IsZero() && time.Since(i.StartedAt) > StaleLockAge
+}
+
+// processAlive reports whether a process with the given PID exists.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess opens the process on Windows, so it already exists
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// LockedError indicates another run holds the repository's lock.
+type LockedError struct {
+	Path string
+	Info LockInfo
+}
+
+func (e *LockedError) Error() string {
+	if e.Info.PID == 0 {
+		return fmt.Sprintf("another run is in progress in this repository (lock file: %s)", e.Path)
+	}
+	return fmt.Sprintf("another run is in progress in this repository (pid %d on %s, started %s; lock file: %s)",
+		e.Info.PID, e.Info.Host, e.Info.StartedAt.Format(time.Kitchen), e.Path)
+}
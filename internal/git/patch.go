@@ -0,0 +1,143 @@
+package git
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// patchFile accumulates one file's section of a unified diff.
+type patchFile struct {
+	change  types.FileChange
+	oldPath string // from the "diff --git" line, if any
+	added   int
+	removed int
+	inHunks bool
+	sawOld  bool
+}
+
+// ParsePatch lists the files a unified diff changes, with their status and
+// added/removed line counts. It reads both git diffs and plain `diff -u`
+// output; paths have their a/ and b/ prefixes removed.
+func ParsePatch(patch string) []types.FileChange {
+	var files []types.FileChange
+	var current *patchFile
+	oldLeft, newLeft := 0, 0
+
+	flush := func() {
+		if current != nil && current.change.Path != "" {
+			current.change.DiffSummary = fmt.Sprintf("+%d -%d", current.added, current.removed)
+			files = append(files, current.change)
+		}
+		current = nil
+	}
+	start := func() {
+		flush()
+		current = &patchFile{change: types.FileChange{Status: "modified"}}
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(patch))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// Inside a hunk, lines are content until its counts are used up
+		if oldLeft > 0 || newLeft > 0 {
+			switch {
+			case strings.HasPrefix(line, "+"):
+				current.added++
+				newLeft--
+			case strings.HasPrefix(line, "-"):
+				current.removed++
+				oldLeft--
+			case strings.HasPrefix(line, `\`):
+				// "\ No newline at end of file"
+			default:
+				oldLeft--
+				newLeft--
+			}
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			start()
+			rest := strings.TrimPrefix(line, "diff --git ")
+			if i := strings.LastIndex(rest, " b/"); i >= 0 {
+				current.oldPath = strings.TrimPrefix(rest[:i], "a/")
+				current.change.Path = rest[i+len(" b/"):]
+			}
+		case strings.HasPrefix(line, "--- "):
+			p := patchPath(line[4:])
+			// A plain diff -u has no "diff --git" line before each file
+			if current == nil || current.inHunks || current.sawOld ||
+				(current.oldPath != "" && p != "" && p != current.oldPath) {
+				start()
+			}
+			current.sawOld = true
+			if p == "" {
+				current.change.Status = "added"
+			} else if current.change.Path == "" {
+				// A deleted file's path is only on this line
+				current.change.Path = p
+			}
+		case strings.HasPrefix(line, "+++ ") && current != nil:
+			if p := patchPath(line[4:]); p == "" {
+				current.change.Status = "deleted"
+			} else {
+				current.change.Path = p
+			}
+		case current == nil:
+			// Preamble such as a commit message in git format-patch output
+		case strings.HasPrefix(line, "new file mode"):
+			current.change.Status = "added"
+		case strings.HasPrefix(line, "deleted file mode"):
+			current.change.Status = "deleted"
+		case strings.HasPrefix(line, "rename to "):
+			current.change.Status = "renamed"
+			current.change.Path = strings.TrimPrefix(line, "rename to ")
+		case strings.HasPrefix(line, "@@ "):
+			current.inHunks = true
+			oldLeft, newLeft = hunkCounts(line)
+		}
+	}
+	flush()
+
+	return files
+}
+
+// patchPath returns the path on a "---" or "+++" line without its a/ or b/
+// prefix and trailing timestamp, or "" for /dev/null.
+func patchPath(s string) string {
+	s, _, _ = strings.Cut(s, "\t")
+	s = strings.TrimSpace(s)
+	if s == "/dev/null" {
+		return ""
+	}
+	if rest, ok := strings.CutPrefix(s, "a/"); ok {
+		return rest
+	}
+	if rest, ok := strings.CutPrefix(s, "b/"); ok {
+		return rest
+	}
+	return s
+}
+
+// hunkCounts returns the old and new line counts of a "@@ -a,b +c,d @@" header.
+func hunkCounts(header string) (int, int) {
+	fields := strings.Fields(header)
+	if len(fields) < 3 {
+		return 0, 0
+	}
+	count := func(r string) int {
+		if _, n, ok := strings.Cut(r, ","); ok {
+			var v int
+			_, _ = fmt.Sscanf(n, "%d", &v)
+			return v
+		}
+		return 1
+	}
+	return count(fields[1]), count(fields[2])
+}
@@ -0,0 +1,54 @@
+package git
+
+import (
+	"os"
+	"slices"
+	"sync/atomic"
+
+	"github.com/dsswift/commit/internal/assert"
+)
+
+// readOnly is set while a run must leave the index and working tree alone.
+var readOnly atomic.Bool
+
+// mutatingCommands are git subcommands that change the index, working tree
+// or HEAD.
+var mutatingCommands = []string{
+	"add", "rm", "mv", "reset", "commit", "restore", "checkout", "switch",
+	"stash", "apply", "update-index", "clean", "merge", "rebase", "cherry-pick",
+}
+
+// SetReadOnly turns the read-only guard on or off. While it is on, staging,
+// unstaging, committing or any other git command that would change the index
+// or working tree panics with an assertion failure instead of running, and
+// git is told not to refresh the index as a side effect of status and diff.
+// Dry runs and commands that only report on changes use it to guarantee
+// they modify nothing.
+func SetReadOnly(on bool) {
+	readOnly.Store(on)
+}
+
+// ReadOnly reports whether the read-only guard is on.
+func ReadOnly() bool {
+	return readOnly.Load()
+}
+
+// assertWritable panics if the read-only guard is on.
+func assertWritable(op string) {
+	assert.False(readOnly.Load(), "%s attempted in read-only mode", op)
+}
+
+// guardReadOnly checks a command before it runs: mutating subcommands panic
+// under the guard, and the rest skip git's optional index refresh.
+func (c *Cmd) guardReadOnly() {
+	if !readOnly.Load() {
+		return
+	}
+	if len(c.Args) > 1 && slices.Contains(mutatingCommands, c.Args[1]) {
+		assertWritable("git " + c.Args[1])
+	}
+	if c.Env == nil {
+		c.Env = os.Environ()
+	}
+	c.Env = append(c.Env, "GIT_OPTIONAL_LOCKS=0")
+}
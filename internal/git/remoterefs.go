@@ -0,0 +1,101 @@
+package git
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RemoteRefs is a snapshot of the remote-tracking refs. Background fetches
+// (git maintenance, IDEs) can move them at any moment, so destructive flows
+// take one snapshot up front and answer every "was this pushed?" question
+// from it, rather than from whatever the refs are by the time they ask.
+type RemoteRefs struct {
+	workDir string
+	// Refs maps each remote-tracking ref, e.g. refs/remotes/origin/main, to
+	// the commit it pointed at
+	Refs map[string]string
+}
+
+// SnapshotRemoteRefs records the remote-tracking refs of the repository at
+// workDir.
+func SnapshotRemoteRefs(workDir string) (*RemoteRefs, error) {
+	refs, err := readRemoteRefs(workDir)
+	if err != nil {
+		return nil, err
+	}
+	return &RemoteRefs{workDir: workDir, Refs: refs}, nil
+}
+
+// readRemoteRefs returns the current remote-tracking refs and their commits.
+func readRemoteRefs(workDir string) (map[string]string, error) {
+	cmd := Command("for-each-ref", "--format=%(objectname) %(refname)", "refs/remotes")
+	cmd.Dir = workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read remote refs: %w", err)
+	}
+
+	refs := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		hash, name, ok := strings.Cut(line, " ")
+		// refs/remotes/origin/HEAD is a symref to a branch listed anyway
+		if !ok || strings.HasSuffix(name, "/HEAD") {
+			continue
+		}
+		refs[name] = hash
+	}
+	return refs, nil
+}
+
+// Contains reports whether ref's commit was on any remote-tracking branch
+// when the snapshot was taken.
+func (s *RemoteRefs) Contains(ref string) (bool, error) {
+	if len(s.Refs) == 0 {
+		return false, nil
+	}
+
+	// rev-list lists nothing when the commit is reachable from a snapshot ref
+	input := []string{ref}
+	for _, hash := range s.Refs {
+		input = append(input, "^"+hash)
+	}
+	cmd := Command("rev-list", "-n", "1", "--stdin")
+	cmd.Dir = s.workDir
+	cmd.Stdin = strings.NewReader(strings.Join(input, "\n") + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return false, fmt.Errorf("failed to check %s against remote refs: %w", ref, err)
+	}
+	return strings.TrimSpace(string(out)) == "", nil
+}
+
+// Commit returns the commit the remote-tracking ref name (e.g.
+// refs/remotes/origin/main) pointed at, and whether the snapshot has it.
+func (s *RemoteRefs) Commit(name string) (string, bool) {
+	hash, ok := s.Refs[name]
+	return hash, ok
+}
+
+// Changed returns the remote-tracking refs that moved, appeared or went
+// away since the snapshot, shortened to e.g. origin/main and sorted.
+func (s *RemoteRefs) Changed() ([]string, error) {
+	now, err := readRemoteRefs(s.workDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for name, hash := range s.Refs {
+		if now[name] != hash {
+			changed = append(changed, strings.TrimPrefix(name, "refs/remotes/"))
+		}
+	}
+	for name := range now {
+		if _, ok := s.Refs[name]; !ok {
+			changed = append(changed, strings.TrimPrefix(name, "refs/remotes/"))
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
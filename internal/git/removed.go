@@ -0,0 +1,80 @@
+package git
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// RemovedDirMinFiles is the number of deleted files under a removed
+// directory at which status lists the directory as one entry instead of each
+// file, so deleting a vendored tree doesn't flood the prompt and staging.
+const RemovedDirMinFiles = 20
+
+// RemovedDirs returns the directories status collapsed into one deleted
+// entry ("vendor/"), with the number of deleted files under each.
+func (c *Collector) RemovedDirs() (map[string]int, error) {
+	if _, err := c.Status(); err != nil {
+		return nil, err
+	}
+	return c.removedDirs, nil
+}
+
+// collapseRemovedDirs replaces the deleted files of each directory that no
+// longer exists with a single "dir/" entry, when there are at least
+// RemovedDirMinFiles of them. Staged deletions are collapsed the same way.
+func (c *Collector) collapseRemovedDirs(deleted, staged []string) ([]string, []string, map[string]int) {
+	if len(deleted) < RemovedDirMinFiles {
+		return deleted, staged, nil
+	}
+
+	// Group deletions by the outermost missing directory above them
+	roots := make(map[string]string, len(deleted))
+	counts := make(map[string]int)
+	missing := make(map[string]bool)
+	for _, f := range deleted {
+		root := ""
+		for dir := path.Dir(f); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			gone, ok := missing[dir]
+			if !ok {
+				_, err := os.Lstat(filepath.Join(c.workDir, dir))
+				gone = os.IsNotExist(err)
+				missing[dir] = gone
+			}
+			if !gone {
+				break
+			}
+			root = dir
+		}
+		if root != "" {
+			roots[f] = root + "/"
+			counts[root+"/"]++
+		}
+	}
+
+	removed := make(map[string]int)
+	for dir, n := range counts {
+		if n >= RemovedDirMinFiles {
+			removed[dir] = n
+		}
+	}
+	if len(removed) == 0 {
+		return deleted, staged, nil
+	}
+
+	collapse := func(files []string) []string {
+		var result []string
+		listed := make(map[string]bool)
+		for _, f := range files {
+			dir := roots[f]
+			if _, ok := removed[dir]; !ok {
+				result = append(result, f)
+			} else if !listed[dir] {
+				listed[dir] = true
+				result = append(result, dir)
+			}
+		}
+		return result
+	}
+	return collapse(deleted), collapse(staged), removed
+}
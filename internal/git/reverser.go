@@ -2,14 +2,14 @@ package git
 
 import (
 	"fmt"
-	"os/exec"
 
 	"github.com/dsswift/commit/internal/assert"
 )
 
 // Reverser handles reversing commits.
 type Reverser struct {
-	workDir string
+	workDir    string
+	remoteRefs *RemoteRefs
 }
 
 // NewReverser creates a new git reverser for the given directory.
@@ -17,8 +17,15 @@ func NewReverser(workDir string) *Reverser {
 	return &Reverser{workDir: workDir}
 }
 
-// Reverse undoes the last count commits, keeping changes in the working directory.
-func (r *Reverser) Reverse(count int, force bool) error {
+// SetRemoteRefs makes pushed-status checks use a snapshot of the remote
+// refs instead of the refs as they are when asked.
+func (r *Reverser) SetRemoteRefs(refs *RemoteRefs) {
+	r.remoteRefs = refs
+}
+
+// Check reports whether the last count commits can be reversed, without
+// touching the repository. It returns the error Reverse would.
+func (r *Reverser) Check(count int, forcePushed bool) error {
 	assert.Positive(count, "reverse count must be positive")
 
 	collector := NewCollector(r.workDir)
@@ -28,19 +35,38 @@ func (r *Reverser) Reverse(count int, force bool) error {
 		return err
 	}
 
+	// Folding a merge into the working tree would squash the merged branch
+	// into whatever gets committed next
+	merge, err := collector.LastMerge(count)
+	if err != nil {
+		return err
+	}
+	if merge != "" {
+		return &MergeCommitError{Hash: merge}
+	}
+
 	// Check if the oldest commit being reversed has been pushed
 	pushed, err := r.WasPushed(count)
 	if err != nil {
 		return fmt.Errorf("failed to check if commit is pushed: %w", err)
 	}
 
-	if pushed && !force {
+	if pushed && !forcePushed {
 		return &PushedCommitError{Count: count}
 	}
 
+	return nil
+}
+
+// Reverse undoes the last count commits, keeping changes in the working directory.
+func (r *Reverser) Reverse(count int, forcePushed bool) error {
+	if err := r.Check(count, forcePushed); err != nil {
+		return err
+	}
+
 	// Perform soft reset (keeps changes staged)
 	ref := fmt.Sprintf("HEAD~%d", count)
-	cmd := exec.Command("git", "reset", "--soft", ref)
+	cmd := Command("reset", "--soft", ref)
 	cmd.Dir = r.workDir
 
 	if out, err := cmd.CombinedOutput(); err != nil {
@@ -63,11 +89,12 @@ func (r *Reverser) WasPushed(count int) (bool, error) {
 	assert.Positive(count, "reverse count must be positive")
 
 	collector := NewCollector(r.workDir)
+	collector.SetRemoteRefs(r.remoteRefs)
 	ref := fmt.Sprintf("HEAD~%d", count-1)
 	return collector.IsRefPushed(ref)
 }
 
-// PushedCommitError indicates attempting to reverse a pushed commit without force.
+// PushedCommitError indicates attempting to reverse a pushed commit without --force-pushed.
 type PushedCommitError struct {
 	Count int
 }
@@ -76,9 +103,27 @@ func (e *PushedCommitError) Error() string {
 	if e.Count > 1 {
 		return fmt.Sprintf("One or more of the last %d commits have been pushed to origin.\n", e.Count) +
 			"Reversing will require force-push to sync with remote.\n" +
-			"Use --reverse --force to proceed."
+			"Use --reverse --force-pushed to proceed."
 	}
 	return "HEAD commit has been pushed to origin.\n" +
 		"Reversing will require force-push to sync with remote.\n" +
-		"Use --reverse --force to proceed."
+		"Use --reverse --force-pushed to proceed."
+}
+
+// MergeCommitError indicates a merge commit is among the commits to reverse
+// or rewrite, which would flatten the merged branch into one change.
+type MergeCommitError struct {
+	Hash string
+}
+
+func (e *MergeCommitError) Error() string {
+	return fmt.Sprintf("commit %s is a merge; going past it would flatten the merged branch", shortHash(e.Hash))
+}
+
+// shortHash abbreviates a full commit hash for messages.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
 }
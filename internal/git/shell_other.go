@@ -0,0 +1,15 @@
+//go:build !windows
+
+package git
+
+import "os/exec"
+
+// shellCommand runs line through sh, as git runs editors and hooks, so
+// commands with arguments and quoting work. args are passed to it as
+// separate arguments, unaffected by the shell's quoting rules.
+func shellCommand(line string, args ...string) *exec.Cmd {
+	if len(args) == 0 {
+		return exec.Command("sh", "-c", line)
+	}
+	return exec.Command("sh", append([]string{"-c", line + ` "$@"`, line}, args...)...)
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package git
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// shellCommand runs line through cmd.exe, since Windows has no sh unless Git
+// Bash is on PATH. args are appended in double quotes, which Windows paths
+// can't contain.
+func shellCommand(line string, args ...string) *exec.Cmd {
+	var b strings.Builder
+	b.WriteString(line)
+	for _, arg := range args {
+		b.WriteString(` "` + arg + `"`)
+	}
+
+	shell := os.Getenv("ComSpec")
+	if shell == "" {
+		shell = "cmd.exe"
+	}
+	cmd := exec.Command(shell)
+	// cmd.exe doesn't follow the quoting rules exec uses to join arguments,
+	// so the command line is passed as is; /S keeps line's own quotes intact
+	cmd.SysProcAttr = &syscall.SysProcAttr{CmdLine: syscall.EscapeArg(shell) + ` /S /C "` + b.String() + `"`}
+	return cmd
+}
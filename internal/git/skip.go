@@ -0,0 +1,103 @@
+package git
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// skipMagic marks a pathspec that leaves matching paths out of a run.
+const skipMagic = ":(exclude,glob)"
+
+// SkipPathspecs converts paths or globs (relative to the repository root)
+// into exclude pathspecs. Added to a collector, stager or committer's
+// pathspecs, they leave matching files out while everything else in scope is
+// committed. As in git's glob magic, ** matches any number of directories.
+func SkipPathspecs(patterns []string) []string {
+	specs := make([]string, len(patterns))
+	for i, p := range patterns {
+		specs[i] = skipMagic + strings.TrimSuffix(p, "/")
+	}
+	return specs
+}
+
+// SplitSkipPathspecs separates exclude pathspecs made by SkipPathspecs from
+// the rest, returning the skipped patterns without their magic.
+func SplitSkipPathspecs(pathspecs []string) (include, skips []string) {
+	for _, p := range pathspecs {
+		if pattern, ok := strings.CutPrefix(p, skipMagic); ok {
+			skips = append(skips, pattern)
+		} else {
+			include = append(include, p)
+		}
+	}
+	return include, skips
+}
+
+// MatchGlob reports whether file, or a directory containing it, matches
+// pattern. * and ? don't cross directories; a ** segment matches any number
+// of them, including none.
+func MatchGlob(pattern, file string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(file, "/"))
+}
+
+// matchSegments matches path segments against pattern segments. Leftover
+// file segments match, so a pattern naming a directory matches its contents.
+func matchSegments(pattern, file []string) bool {
+	if len(pattern) == 0 {
+		return true
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(file); i++ {
+			if matchSegments(pattern[1:], file[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(file) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(pattern[0], file[0]); !ok {
+		return false
+	}
+	return matchSegments(pattern[1:], file[1:])
+}
+
+// SkippedFiles returns the changed files within the collector's pathspecs
+// that its exclude pathspecs leave out, with untracked directories expanded.
+func (c *Collector) SkippedFiles() ([]string, error) {
+	include, skips := SplitSkipPathspecs(c.pathspecs)
+	if len(skips) == 0 {
+		return nil, nil
+	}
+
+	args := []string{"status", "--porcelain", "-z", "--untracked-files=all", "--"}
+	for _, p := range skips {
+		args = append(args, ":(glob)"+p)
+	}
+	cmd := Command(args...)
+	cmd.Dir = c.workDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list skipped files: %w", err)
+	}
+
+	var files []string
+	fields := strings.Split(string(out), "\x00")
+	for i := 0; i < len(fields); i++ {
+		entry := fields[i]
+		if len(entry) < 4 {
+			continue
+		}
+		if entry[0] == 'R' || entry[0] == 'C' {
+			i++ // the rename source follows in its own field
+		}
+		file := entry[3:]
+		if len(include) == 0 || MatchesFocus(file, include) {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,422 @@
+package git
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// SnapshotDir is where --snapshot copies are kept inside the
+	// repository's git directory, one directory per run.
+	SnapshotDir = "commit-tool/snapshots"
+
+	// SnapshotsKept is how many snapshots are kept; taking a new one removes
+	// the oldest beyond it.
+	SnapshotsKept = 10
+
+	// snapshotManifest lists a snapshot's files; their copies sit beside it
+	// under snapshotFiles.
+	snapshotManifest = "manifest.json"
+	snapshotFiles    = "files"
+)
+
+// Snapshot is a copy of the files with uncommitted changes in a working
+// tree, taken before a run stages anything.
+type Snapshot struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Head      string    `json:"head,omitempty"` // empty before the first commit
+	// Files are the copied paths, relative to the repository root
+	Files []string `json:"files"`
+	// Index holds the index entries of Files as they were, so content that
+	// was staged but since changed in the working tree isn't lost
+	Index []IndexEntry `json:"index,omitempty"`
+
+	dir string
+}
+
+// IndexEntry is a file's entry in the index: its mode and the blob staged
+// for it.
+type IndexEntry struct {
+	Path string `json:"path"`
+	Mode string `json:"mode"`
+	Blob string `json:"blob"`
+}
+
+// TakeSnapshot copies every file in gitRoot with uncommitted changes —
+// modified, staged, or untracked and not ignored — into the snapshot named
+// id, records their index entries, then removes all but the newest
+// SnapshotsKept snapshots. Deleted files aren't copied, since HEAD still has
+// them. Staged content is kept by blob id, so it's only as safe as git's
+// object store: gc prunes unreachable blobs after two weeks by default.
+func TakeSnapshot(gitRoot, id string) (*Snapshot, error) {
+	root, err := snapshotsRoot(gitRoot)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := uncommittedFiles(gitRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	snap := &Snapshot{ID: id, CreatedAt: time.Now().UTC(), Head: headCommit(gitRoot), dir: filepath.Join(root, id)}
+
+	for _, path := range paths {
+		copied, err := copyFile(filepath.Join(gitRoot, path), filepath.Join(snap.dir, snapshotFiles, path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to snapshot %s: %w", path, err)
+		}
+		if copied {
+			snap.Files = append(snap.Files, path)
+		}
+	}
+
+	index, err := indexEntries(gitRoot)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range snap.Files {
+		if entry, ok := index[path]; ok {
+			snap.Index = append(snap.Index, entry)
+		}
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(snap.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(snap.dir, snapshotManifest), data, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write snapshot manifest: %w", err)
+	}
+
+	pruneSnapshots(gitRoot)
+	return snap, nil
+}
+
+// ListSnapshots returns the repository's snapshots, newest first.
+func ListSnapshots(gitRoot string) ([]*Snapshot, error) {
+	root, err := snapshotsRoot(gitRoot)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(root)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snaps []*Snapshot
+	for _, entry := range entries {
+		if snap, err := readSnapshot(filepath.Join(root, entry.Name())); err == nil {
+			snaps = append(snaps, snap)
+		}
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		if !snaps[i].CreatedAt.Equal(snaps[j].CreatedAt) {
+			return snaps[i].CreatedAt.After(snaps[j].CreatedAt)
+		}
+		return snaps[i].ID > snaps[j].ID
+	})
+	return snaps, nil
+}
+
+// LoadSnapshot returns the snapshot named id, or the newest one if id is
+// empty. It returns a *SnapshotNotFoundError if there's no such snapshot.
+func LoadSnapshot(gitRoot, id string) (*Snapshot, error) {
+	if id == "" {
+		snaps, err := ListSnapshots(gitRoot)
+		if err != nil {
+			return nil, err
+		}
+		if len(snaps) == 0 {
+			return nil, &SnapshotNotFoundError{}
+		}
+		return snaps[0], nil
+	}
+
+	// IDs name a directory; don't let one reach outside the snapshots
+	if filepath.Base(id) != id || id == "." || id == ".." {
+		return nil, &SnapshotNotFoundError{ID: id}
+	}
+	root, err := snapshotsRoot(gitRoot)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := readSnapshot(filepath.Join(root, id))
+	if os.IsNotExist(err) {
+		return nil, &SnapshotNotFoundError{ID: id}
+	}
+	return snap, err
+}
+
+// Dir returns the directory holding the snapshot's manifest and copies.
+func (s *Snapshot) Dir() string {
+	return s.dir
+}
+
+// Restore copies the snapshot's files back into the working tree at gitRoot,
+// overwriting what's there, puts their index entries back, and returns the
+// paths it changed. Files that already match the snapshot are left alone.
+func (s *Snapshot) Restore(gitRoot string) ([]string, error) {
+	// The manifest is only a file on disk; don't let it write outside the tree
+	for _, path := range s.Files {
+		if !isSnapshotPath(path) {
+			return nil, fmt.Errorf("unsafe path in snapshot manifest: %s", path)
+		}
+	}
+	for _, entry := range s.Index {
+		if !isSnapshotPath(entry.Path) {
+			return nil, fmt.Errorf("unsafe path in snapshot manifest: %s", entry.Path)
+		}
+	}
+
+	var restored []string
+	for _, path := range s.Files {
+		src := filepath.Join(s.dir, snapshotFiles, path)
+		dst := filepath.Join(gitRoot, path)
+		if sameFile(src, dst) {
+			continue
+		}
+		if info, err := os.Lstat(dst); err == nil && !info.Mode().IsRegular() && info.Mode()&fs.ModeSymlink == 0 {
+			return restored, fmt.Errorf("failed to restore %s: a directory is in its place", path)
+		}
+		if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+			return restored, fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+		if _, err := copyFile(src, dst); err != nil {
+			return restored, fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+		restored = append(restored, path)
+	}
+
+	staged, err := s.restoreIndex(gitRoot)
+	for _, path := range staged {
+		if !slices.Contains(restored, path) {
+			restored = append(restored, path)
+		}
+	}
+	return restored, err
+}
+
+// restoreIndex puts back the snapshot's index entries that have changed
+// since, and returns their paths.
+func (s *Snapshot) restoreIndex(gitRoot string) ([]string, error) {
+	current, err := indexEntries(gitRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths, input []string
+	for _, entry := range s.Index {
+		if current[entry.Path] == entry {
+			continue
+		}
+		cmd := Command("cat-file", "-e", entry.Blob)
+		cmd.Dir = gitRoot
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("failed to restore the staged version of %s: blob %s is gone", entry.Path, shortHash(entry.Blob))
+		}
+		paths = append(paths, entry.Path)
+		input = append(input, entry.Mode+" "+entry.Blob+"\t"+entry.Path)
+	}
+	if len(input) == 0 {
+		return nil, nil
+	}
+
+	cmd := Command("update-index", "-z", "--index-info")
+	cmd.Dir = gitRoot
+	cmd.Stdin = strings.NewReader(strings.Join(input, "\x00") + "\x00")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("failed to restore the index: %s: %w", string(out), err)
+	}
+	return paths, nil
+}
+
+// isSnapshotPath reports whether path is relative and stays inside the
+// repository.
+func isSnapshotPath(path string) bool {
+	return filepath.IsLocal(filepath.FromSlash(path))
+}
+
+// indexEntries returns the index's merged (stage 0) entries by path.
+func indexEntries(gitRoot string) (map[string]IndexEntry, error) {
+	cmd := Command("ls-files", "-s", "-z")
+	cmd.Dir = gitRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the index: %w", err)
+	}
+
+	entries := make(map[string]IndexEntry)
+	for _, record := range strings.Split(string(out), "\x00") {
+		// <mode> <blob> <stage>\t<path>
+		info, path, ok := strings.Cut(record, "\t")
+		fields := strings.Fields(info)
+		if !ok || len(fields) != 3 || fields[2] != "0" {
+			continue
+		}
+		entries[path] = IndexEntry{Path: path, Mode: fields[0], Blob: fields[1]}
+	}
+	return entries, nil
+}
+
+// snapshotsRoot returns the directory holding gitRoot's snapshots.
+func snapshotsRoot(gitRoot string) (string, error) {
+	cmd := Command("rev-parse", "--git-path", SnapshotDir)
+	cmd.Dir = gitRoot
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate git directory: %w", err)
+	}
+
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(gitRoot, path)
+	}
+	return path, nil
+}
+
+// uncommittedFiles lists the files that differ from HEAD in the index or
+// working tree, plus untracked files that aren't ignored, sorted.
+func uncommittedFiles(gitRoot string) ([]string, error) {
+	listings := [][]string{
+		{"ls-files", "-z", "--modified", "--others", "--exclude-standard"},
+		{"diff", "--cached", "--name-only", "-z", "--no-renames", "--diff-filter=d"},
+	}
+	if headCommit(gitRoot) == "" {
+		// Before the first commit everything in the index is staged
+		listings[1] = []string{"ls-files", "-z", "--cached"}
+	}
+
+	seen := make(map[string]bool)
+	var paths []string
+	for _, args := range listings {
+		cmd := Command(args...)
+		cmd.Dir = gitRoot
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list changed files: %w", err)
+		}
+		for _, path := range strings.Split(string(out), "\x00") {
+			if path != "" && !seen[path] {
+				seen[path] = true
+				paths = append(paths, path)
+			}
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// headCommit returns the commit HEAD points at, or "" before the first.
+func headCommit(gitRoot string) string {
+	cmd := Command("rev-parse", "--verify", "--quiet", "HEAD")
+	cmd.Dir = gitRoot
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// copyFile copies the regular file or symlink at src to dst, creating dst's
+// directory and keeping its mode. It returns false, copying nothing, if src
+// doesn't exist or is something else, such as a submodule's directory.
+func copyFile(src, dst string) (bool, error) {
+	info, err := os.Lstat(src)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0700); err != nil {
+		return false, err
+	}
+
+	switch {
+	case info.Mode()&fs.ModeSymlink != 0:
+		target, err := os.Readlink(src)
+		if err != nil {
+			return false, err
+		}
+		return true, os.Symlink(target, dst)
+	case info.Mode().IsRegular():
+		data, err := os.ReadFile(src)
+		if err != nil {
+			return false, err
+		}
+		return true, os.WriteFile(dst, data, info.Mode().Perm())
+	default:
+		return false, nil
+	}
+}
+
+// sameFile reports whether a and b are both regular files with the same
+// content and mode, or symlinks to the same target.
+func sameFile(a, b string) bool {
+	infoA, errA := os.Lstat(a)
+	infoB, errB := os.Lstat(b)
+	if errA != nil || errB != nil || infoA.Mode() != infoB.Mode() {
+		return false
+	}
+	if infoA.Mode()&fs.ModeSymlink != 0 {
+		targetA, errA := os.Readlink(a)
+		targetB, errB := os.Readlink(b)
+		return errA == nil && errB == nil && targetA == targetB
+	}
+	dataA, errA := os.ReadFile(a)
+	dataB, errB := os.ReadFile(b)
+	return errA == nil && errB == nil && bytes.Equal(dataA, dataB)
+}
+
+func readSnapshot(dir string) (*Snapshot, error) {
+	data, err := os.ReadFile(filepath.Join(dir, snapshotManifest))
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("invalid snapshot manifest in %s: %w", dir, err)
+	}
+	snap.dir = dir
+	return &snap, nil
+}
+
+// pruneSnapshots removes all but the newest SnapshotsKept snapshots.
+func pruneSnapshots(gitRoot string) {
+	snaps, err := ListSnapshots(gitRoot)
+	if err != nil || len(snaps) <= SnapshotsKept {
+		return
+	}
+	for _, snap := range snaps[SnapshotsKept:] {
+		_ = os.RemoveAll(snap.dir)
+	}
+}
+
+// SnapshotNotFoundError indicates --restore-snapshot named a snapshot that
+// doesn't exist, or that none has been taken.
+type SnapshotNotFoundError struct {
+	ID string // empty when looking for the newest
+}
+
+func (e *SnapshotNotFoundError) Error() string {
+	if e.ID == "" {
+		return "no snapshots found; take one with --snapshot"
+	}
+	return fmt.Sprintf("snapshot %q not found", e.ID)
+}
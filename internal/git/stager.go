@@ -3,8 +3,8 @@ package git
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/dsswift/commit/internal/assert"
@@ -12,7 +12,8 @@ import (
 
 // Stager handles git staging operations.
 type Stager struct {
-	workDir string
+	workDir   string
+	pathspecs []string
 }
 
 // NewStager creates a new git stager for the given directory.
@@ -20,11 +21,26 @@ func NewStager(workDir string) *Stager {
 	return &Stager{workDir: workDir}
 }
 
+// SetPathspecs restricts unstaging and staged-file queries to the given
+// pathspecs so the index outside them is left untouched.
+func (s *Stager) SetPathspecs(pathspecs []string) {
+	s.pathspecs = pathspecs
+}
+
+// withPathspecs appends the stager's pathspecs to args, if any.
+func (s *Stager) withPathspecs(args []string) []string {
+	if len(s.pathspecs) == 0 {
+		return args
+	}
+	return append(append(args, "--"), s.pathspecs...)
+}
+
 // StageFiles adds specific files to the staging area.
 // Directories are expanded to include all files within them.
 func (s *Stager) StageFiles(files []string) error {
 	// PRECONDITIONS
 	assert.NotEmpty(files, "files cannot be empty")
+	assertWritable("staging")
 
 	// Get staged renames to handle rename sources correctly
 	stagedRenames, err := s.getStagedRenames()
@@ -46,8 +62,10 @@ func (s *Stager) StageFiles(files []string) error {
 			continue
 		}
 
+		// Lstat: a symlink is staged as a link, even to a directory or to
+		// nothing, never by what it points to
 		fullPath := s.fullPath(f)
-		info, err := os.Stat(fullPath)
+		info, err := os.Lstat(fullPath)
 		if os.IsNotExist(err) {
 			// Check if it's a tracked deleted file
 			if !s.isTrackedFile(f) {
@@ -95,9 +113,10 @@ func (s *Stager) StageFiles(files []string) error {
 	// tracked files matching ignore patterns without -f, even though they're
 	// already in the index. Use git check-ignore --no-index to detect this,
 	// since plain check-ignore skips tracked files.
+	ignoredPatterns := s.ignoredPatternSet(filesToStage)
 	var regularFiles, forceFiles []string
 	for _, f := range filesToStage {
-		if s.isIgnoredPattern(f) && s.isTrackedFile(f) {
+		if ignoredPatterns[f] && s.isTrackedFile(f) {
 			forceFiles = append(forceFiles, f)
 		} else {
 			regularFiles = append(regularFiles, f)
@@ -105,19 +124,13 @@ func (s *Stager) StageFiles(files []string) error {
 	}
 
 	if len(regularFiles) > 0 {
-		args := append([]string{"add", "--"}, regularFiles...)
-		cmd := exec.Command("git", args...)
-		cmd.Dir = s.workDir
-		if out, err := cmd.CombinedOutput(); err != nil {
+		if out, err := s.addPathspecs(regularFiles); err != nil {
 			return fmt.Errorf("failed to stage files: %s: %w", string(out), err)
 		}
 	}
 
 	if len(forceFiles) > 0 {
-		args := append([]string{"add", "-f", "--"}, forceFiles...)
-		cmd := exec.Command("git", args...)
-		cmd.Dir = s.workDir
-		if out, err := cmd.CombinedOutput(); err != nil {
+		if out, err := s.addPathspecs(forceFiles, "-f"); err != nil {
 			return fmt.Errorf("failed to stage tracked-but-ignored files: %s: %w", string(out), err)
 		}
 	}
@@ -146,6 +159,10 @@ func (s *Stager) StageFiles(files []string) error {
 	// Verify regular files are staged
 	for _, f := range filesToStage {
 		if !stagedSet[f] {
+			// A removed directory is staged as the deletion of every file under it
+			if strings.HasSuffix(f, "/") && slices.ContainsFunc(staged, func(s string) bool { return strings.HasPrefix(s, f) }) {
+				continue
+			}
 			// Check if this file became the source of an auto-detected rename
 			if renameSources[f] {
 				// File was staged as part of a rename - the destination is in stagedSet
@@ -174,35 +191,56 @@ func (s *Stager) StageFiles(files []string) error {
 	return nil
 }
 
-// isIgnored checks if a file is ignored by git (skips tracked files).
-func (s *Stager) isIgnored(file string) bool {
-	cmd := exec.Command("git", "check-ignore", "-q", file)
+// addPathspecs runs git add with the paths fed through stdin as NUL-separated
+// pathspecs, so large file sets never hit OS command-line length limits.
+func (s *Stager) addPathspecs(files []string, flags ...string) ([]byte, error) {
+	args := append([]string{"add"}, flags...)
+	args = append(args, "--pathspec-from-file=-", "--pathspec-file-nul")
+	cmd := Command(args...)
 	cmd.Dir = s.workDir
-	return cmd.Run() == nil
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\x00"))
+	return cmd.CombinedOutput()
 }
 
-// isIgnoredPattern checks if a file matches a .gitignore pattern, regardless
-// of tracking status. Unlike isIgnored, this returns true even for tracked files
-// that match an ignore rule.
-func (s *Stager) isIgnoredPattern(file string) bool {
-	cmd := exec.Command("git", "check-ignore", "-q", "--no-index", file)
+// ignoredPatternSet returns the subset of files matching a .gitignore pattern,
+// regardless of tracking status, using a single batched check-ignore call.
+func (s *Stager) ignoredPatternSet(files []string) map[string]bool {
+	cmd := Command("check-ignore", "--no-index", "--stdin", "-z")
+	cmd.Dir = s.workDir
+	cmd.Stdin = strings.NewReader(strings.Join(files, "\x00"))
+
+	// Exit code 1 means no files matched; any output is still valid.
+	out, _ := cmd.Output()
+
+	ignored := make(map[string]bool)
+	for _, f := range strings.Split(string(out), "\x00") {
+		if f != "" {
+			ignored[f] = true
+		}
+	}
+	return ignored
+}
+
+// isIgnored checks if a file is ignored by git (skips tracked files).
+func (s *Stager) isIgnored(file string) bool {
+	cmd := Command("check-ignore", "-q", file)
 	cmd.Dir = s.workDir
 	return cmd.Run() == nil
 }
 
 // UnstageAll removes all files from the staging area (keeps changes in working directory).
 func (s *Stager) UnstageAll() error {
-	// Check if HEAD exists (has at least one commit)
-	checkHead := exec.Command("git", "rev-parse", "HEAD")
-	checkHead.Dir = s.workDir
-	hasHead := checkHead.Run() == nil
-
-	var cmd *exec.Cmd
-	if hasHead {
-		cmd = exec.Command("git", "reset", "HEAD")
-	} else {
+	assertWritable("unstaging")
+
+	var cmd *Cmd
+	switch {
+	case hasHead(s.workDir):
+		cmd = Command(s.withPathspecs([]string{"reset", "HEAD"})...)
+	case len(s.pathspecs) > 0:
+		cmd = Command(append([]string{"rm", "--cached", "-r", "-q", "--ignore-unmatch", "--"}, s.pathspecs...)...)
+	default:
 		// No commits yet - use rm --cached to unstage
-		cmd = exec.Command("git", "rm", "--cached", "-r", "--ignore-unmatch", ".")
+		cmd = Command("rm", "--cached", "-r", "--ignore-unmatch", ".")
 	}
 	cmd.Dir = s.workDir
 
@@ -222,9 +260,14 @@ func (s *Stager) UnstageAll() error {
 // UnstageFiles removes specific files from the staging area.
 func (s *Stager) UnstageFiles(files []string) error {
 	assert.NotEmpty(files, "files cannot be empty")
+	assertWritable("unstaging")
 
 	args := append([]string{"reset", "HEAD", "--"}, files...)
-	cmd := exec.Command("git", args...)
+	if !hasHead(s.workDir) {
+		// No commits yet - use rm --cached to unstage
+		args = append([]string{"rm", "--cached", "-r", "-q", "--ignore-unmatch", "--"}, files...)
+	}
+	cmd := Command(args...)
 	cmd.Dir = s.workDir
 
 	if out, err := cmd.CombinedOutput(); err != nil {
@@ -236,7 +279,7 @@ func (s *Stager) UnstageFiles(files []string) error {
 
 // StagedFiles returns the list of currently staged files.
 func (s *Stager) StagedFiles() ([]string, error) {
-	cmd := exec.Command("git", "diff", "--cached", "--name-only")
+	cmd := Command(s.withPathspecs([]string{"diff", "--cached", "--name-only"})...)
 	cmd.Dir = s.workDir
 
 	out, err := cmd.Output()
@@ -257,7 +300,9 @@ func (s *Stager) StagedFiles() ([]string, error) {
 
 // StageAll stages all changes (modified, added, deleted, untracked).
 func (s *Stager) StageAll() error {
-	cmd := exec.Command("git", "add", "-A")
+	assertWritable("staging")
+
+	cmd := Command("add", "-A")
 	cmd.Dir = s.workDir
 
 	if out, err := cmd.CombinedOutput(); err != nil {
@@ -269,7 +314,7 @@ func (s *Stager) StageAll() error {
 
 // isTrackedFile checks if a file is tracked by git.
 func (s *Stager) isTrackedFile(file string) bool {
-	cmd := exec.Command("git", "ls-files", file)
+	cmd := Command("ls-files", file)
 	cmd.Dir = s.workDir
 
 	out, err := cmd.Output()
@@ -288,14 +333,18 @@ func (s *Stager) fullPath(file string) string {
 	return filepath.Join(s.workDir, file)
 }
 
-// expandDirectory returns all untracked, non-ignored files within a directory.
+// expandDirectory returns all untracked, non-ignored files within a directory,
+// leaving out files skipped by the stager's pathspecs.
 // Uses git ls-files for efficient batch ignore checking instead of per-file subprocess calls.
 // Returns paths relative to the work directory.
 func (s *Stager) expandDirectory(dir string) ([]string, error) {
 	// Use git ls-files to get untracked, non-ignored files
 	// --other: show untracked files
 	// --exclude-standard: apply .gitignore rules
-	cmd := exec.Command("git", "ls-files", "--other", "--exclude-standard", dir)
+	args := []string{"ls-files", "--other", "--exclude-standard", "--", dir}
+	_, skips := SplitSkipPathspecs(s.pathspecs)
+	args = append(args, SkipPathspecs(skips)...)
+	cmd := Command(args...)
 	cmd.Dir = s.workDir
 
 	out, err := cmd.Output()
@@ -329,7 +378,7 @@ func (s *Stager) diagnoseFile(file string) string {
 
 	// Check if file exists on disk
 	fullPath := s.fullPath(file)
-	if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+	if _, err := os.Lstat(fullPath); os.IsNotExist(err) {
 		diag = append(diag, "  - file does not exist on disk")
 	} else {
 		diag = append(diag, "  - file exists on disk")
@@ -343,7 +392,7 @@ func (s *Stager) diagnoseFile(file string) string {
 	}
 
 	// Check git status for this file
-	cmd := exec.Command("git", "status", "--porcelain", "--", file)
+	cmd := Command("status", "--porcelain", "--", file)
 	cmd.Dir = s.workDir
 	if out, err := cmd.Output(); err == nil {
 		status := strings.TrimSpace(string(out))
@@ -377,7 +426,7 @@ func (s *Stager) diagnoseFile(file string) string {
 
 // getStagedRenames returns a map of old_path -> new_path for staged renames.
 func (s *Stager) getStagedRenames() (map[string]string, error) {
-	cmd := exec.Command("git", "status", "--porcelain")
+	cmd := Command("status", "--porcelain")
 	cmd.Dir = s.workDir
 
 	out, err := cmd.Output()
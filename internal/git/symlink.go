@@ -0,0 +1,157 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// symlinkMode is the git file mode of a symbolic link.
+const symlinkMode = "120000"
+
+// SymlinkChanges describes each file that is, or was, a symbolic link, e.g.
+// "symlink to config/dev.yaml" or "symlink retargeted (a.yaml → b.yaml)",
+// since a link's diff is only its target path. The old target comes from HEAD
+// and the new one from the index when stagedOnly is set, otherwise from the
+// working tree. Files that are links on neither side are left out.
+func (c *Collector) SymlinkChanges(files []string, stagedOnly bool) map[string]string {
+	changes := make(map[string]string)
+	if len(files) == 0 {
+		return changes
+	}
+
+	old := c.treeSymlinks(files)
+	var current map[string]string
+	if stagedOnly {
+		current = c.indexSymlinks(files)
+	}
+
+	for _, file := range files {
+		oldTarget, wasLink := old[file]
+		var newTarget string
+		var isLink, exists bool
+		if stagedOnly {
+			newTarget, isLink = current[file]
+			exists = isLink || c.inIndex(file)
+		} else {
+			newTarget, isLink, exists = c.worktreeSymlink(file)
+		}
+
+		if desc := DescribeSymlinkChange(oldTarget, wasLink, newTarget, isLink, exists); desc != "" {
+			changes[file] = desc
+		}
+	}
+	return changes
+}
+
+// treeSymlinks returns the targets of the symlinks among files in HEAD.
+func (c *Collector) treeSymlinks(files []string) map[string]string {
+	cmd := Command(append([]string{"ls-tree", "-z", "HEAD", "--"}, files...)...)
+	cmd.Dir = c.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil // no HEAD yet
+	}
+
+	// "<mode> <type> <object>\t<path>"
+	links := make(map[string]string)
+	for _, entry := range strings.Split(string(out), "\x00") {
+		meta, path, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if ok && len(fields) == 3 && fields[0] == symlinkMode {
+			if target, ok := c.blobText(fields[2]); ok {
+				links[path] = target
+			}
+		}
+	}
+	return links
+}
+
+// indexSymlinks returns the targets of the symlinks among files in the index.
+func (c *Collector) indexSymlinks(files []string) map[string]string {
+	cmd := Command(append([]string{"ls-files", "-s", "-z", "--"}, files...)...)
+	cmd.Dir = c.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	// "<mode> <object> <stage>\t<path>"
+	links := make(map[string]string)
+	for _, entry := range strings.Split(string(out), "\x00") {
+		meta, path, ok := strings.Cut(entry, "\t")
+		fields := strings.Fields(meta)
+		if ok && len(fields) == 3 && fields[0] == symlinkMode {
+			if target, ok := c.blobText(fields[1]); ok {
+				links[path] = target
+			}
+		}
+	}
+	return links
+}
+
+// inIndex reports whether file is in the index.
+func (c *Collector) inIndex(file string) bool {
+	cmd := Command("ls-files", "--error-unmatch", "--", file)
+	cmd.Dir = c.workDir
+	return cmd.Run() == nil
+}
+
+// blobText returns the content of a blob, such as a symlink's target.
+func (c *Collector) blobText(object string) (string, bool) {
+	cmd := Command("cat-file", "blob", object)
+	cmd.Dir = c.workDir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	return string(bytes.TrimSuffix(out, []byte("\n"))), true
+}
+
+// worktreeSymlink returns the target of file in the working tree if it's a
+// symlink, and whether anything exists at its path. Links aren't followed.
+func (c *Collector) worktreeSymlink(file string) (target string, isLink, exists bool) {
+	path := filepath.Join(c.workDir, file)
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", false, false
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return "", false, true
+	}
+	target, err = os.Readlink(path)
+	if err != nil {
+		return "", false, true
+	}
+	return filepath.ToSlash(target), true, true
+}
+
+// DescribeSymlinkChange summarizes a change to a path that was or is a
+// symlink. exists is whether anything is at the path now. It returns "" when
+// the path is a link on neither side.
+func DescribeSymlinkChange(oldTarget string, wasLink bool, newTarget string, isLink, exists bool) string {
+	switch {
+	case wasLink && isLink && oldTarget == newTarget:
+		return fmt.Sprintf("symlink to %s", newTarget)
+	case wasLink && isLink:
+		return fmt.Sprintf("symlink retargeted (%s → %s)", oldTarget, newTarget)
+	case wasLink && exists:
+		return fmt.Sprintf("symlink to %s replaced by a file", oldTarget)
+	case wasLink:
+		return fmt.Sprintf("symlink to %s removed", oldTarget)
+	case isLink:
+		return fmt.Sprintf("symlink to %s", newTarget)
+	default:
+		return ""
+	}
+}
+
+// symlinkDiff returns a new-file diff for the untracked symlink file, the
+// way git shows one: its target as the content, in mode 120000. git diff
+// --no-index follows links to directories instead.
+func symlinkDiff(file, target string) string {
+	return fmt.Sprintf("diff --git a/%[1]s b/%[1]s\nnew file mode %[2]s\n--- /dev/null\n+++ b/%[1]s\n@@ -0,0 +1 @@\n+%[3]s\n\\ No newline at end of file\n",
+		file, symlinkMode, target)
+}
@@ -0,0 +1,126 @@
+package git
+
+import (
+	"errors"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// maxTraceOutput caps the output kept per traced command.
+const maxTraceOutput = 2000
+
+// TraceEntry records a single git invocation.
+type TraceEntry struct {
+	Args     []string
+	Dir      string
+	Duration time.Duration
+	ExitCode int    // -1 if git could not be started
+	Output   string // stdout (or combined output), truncated
+	Stderr   string // stderr captured on failure, truncated
+}
+
+var (
+	tracerMu sync.Mutex
+	tracer   func(TraceEntry)
+)
+
+// SetTracer installs fn to receive every git command run through Command.
+// A nil fn disables tracing.
+func SetTracer(fn func(TraceEntry)) {
+	tracerMu.Lock()
+	defer tracerMu.Unlock()
+	tracer = fn
+}
+
+// Cmd is an exec.Cmd for git whose Run, Output and CombinedOutput are traced.
+type Cmd struct {
+	*exec.Cmd
+}
+
+// Command returns a traced git command. All git invocations in the tool go
+// through it, so a trace shows exactly what ran against the user's repo.
+func Command(args ...string) *Cmd {
+	return &Cmd{Cmd: exec.Command("git", args...)}
+}
+
+// Run runs the command like exec.Cmd.Run and traces it.
+func (c *Cmd) Run() error {
+	c.guardReadOnly()
+	start := time.Now()
+	if fault := c.injectedFault(); fault != nil {
+		c.trace(start, nil, fault)
+		return fault
+	}
+	err := c.Cmd.Run()
+	c.trace(start, nil, err)
+	return err
+}
+
+// Output runs the command like exec.Cmd.Output and traces it.
+func (c *Cmd) Output() ([]byte, error) {
+	c.guardReadOnly()
+	start := time.Now()
+	if fault := c.injectedFault(); fault != nil {
+		c.trace(start, nil, fault)
+		return nil, fault
+	}
+	out, err := c.Cmd.Output()
+	c.trace(start, out, err)
+	return out, err
+}
+
+// CombinedOutput runs the command like exec.Cmd.CombinedOutput and traces it.
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	c.guardReadOnly()
+	start := time.Now()
+	if fault := c.injectedFault(); fault != nil {
+		out := []byte(fault.Message)
+		c.trace(start, out, fault)
+		return out, fault
+	}
+	out, err := c.Cmd.CombinedOutput()
+	c.trace(start, out, err)
+	return out, err
+}
+
+func (c *Cmd) trace(start time.Time, out []byte, err error) {
+	tracerMu.Lock()
+	fn := tracer
+	tracerMu.Unlock()
+	if fn == nil {
+		return
+	}
+
+	entry := TraceEntry{
+		Args:     c.Args[1:],
+		Dir:      c.Dir,
+		Duration: time.Since(start),
+		Output:   truncateTrace(string(out)),
+	}
+
+	var exitErr *exec.ExitError
+	var fault *FaultError
+	switch {
+	case err == nil:
+	case errors.As(err, &fault):
+		entry.ExitCode = 1
+		entry.Stderr = truncateTrace(fault.Message)
+	case errors.As(err, &exitErr):
+		entry.ExitCode = exitErr.ExitCode()
+		entry.Stderr = truncateTrace(string(exitErr.Stderr))
+	default:
+		entry.ExitCode = -1
+		entry.Stderr = err.Error()
+	}
+
+	fn(entry)
+}
+
+// truncateTrace shortens s to maxTraceOutput bytes.
+func truncateTrace(s string) string {
+	if len(s) <= maxTraceOutput {
+		return s
+	}
+	return s[:maxTraceOutput] + "... (truncated)"
+}
@@ -0,0 +1,52 @@
+package git
+
+import (
+	"fmt"
+	"os"
+)
+
+// CommandFailedError is returned by RunAtCommit when the command exits
+// non-zero. Output holds its combined stdout and stderr.
+type CommandFailedError struct {
+	Rev    string
+	Output string
+	Err    error
+}
+
+func (e *CommandFailedError) Error() string {
+	return fmt.Sprintf("command failed at %s: %v", e.Rev, e.Err)
+}
+
+func (e *CommandFailedError) Unwrap() error {
+	return e.Err
+}
+
+// RunAtCommit checks out rev in a temporary detached worktree and runs
+// command there through the shell (cmd.exe on Windows), so the working
+// directory's uncommitted changes can't affect the result. The worktree is
+// removed afterwards.
+func RunAtCommit(workDir, rev, command string) error {
+	dir, err := os.MkdirTemp("", "commit-verify-*")
+	if err != nil {
+		return fmt.Errorf("failed to create worktree directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	add := Command("worktree", "add", "--detach", "--quiet", dir, rev)
+	add.Dir = workDir
+	if out, err := add.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to create worktree: %s: %w", string(out), err)
+	}
+	defer func() {
+		remove := Command("worktree", "remove", "--force", dir)
+		remove.Dir = workDir
+		_ = remove.Run()
+	}()
+
+	cmd := shellCommand(command)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return &CommandFailedError{Rev: rev, Output: string(out), Err: err}
+	}
+	return nil
+}
@@ -1,22 +1,52 @@
 // Package httpclient provides a shared HTTP transport with connection pooling.
+// Every request the tool makes goes through it, so proxy and connection
+// settings apply to providers, update checks and publishing alike.
 package httpclient
 
 import (
+	"fmt"
 	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
+// DefaultConnectTimeout bounds connecting and the TLS handshake.
+const DefaultConnectTimeout = 10 * time.Second
+
+// DirectProxy is the SetProxy value that ignores the proxy environment variables.
+const DirectProxy = "direct"
+
 // sharedTransport is a shared HTTP transport with connection pooling.
-var sharedTransport = &http.Transport{
-	MaxIdleConns:        20,
-	MaxIdleConnsPerHost: 5,
-	IdleConnTimeout:     90 * time.Second,
-	DialContext: (&net.Dialer{
-		Timeout:   10 * time.Second,
-		KeepAlive: 30 * time.Second,
-	}).DialContext,
-	TLSHandshakeTimeout: 10 * time.Second,
+var sharedTransport = newTransport(DefaultConnectTimeout)
+
+// proxyMu guards proxyURL and proxyDirect.
+var (
+	proxyMu     sync.RWMutex
+	proxyURL    *url.URL
+	proxyDirect bool
+)
+
+// envProxy resolves the proxy from HTTPS_PROXY, HTTP_PROXY and NO_PROXY (or
+// their lowercase forms). Overridable for testing, since the standard library
+// reads the environment only once.
+var envProxy = http.ProxyFromEnvironment
+
+func newTransport(connectTimeout time.Duration) *http.Transport {
+	return &http.Transport{
+		Proxy:               proxy,
+		MaxIdleConns:        20,
+		MaxIdleConnsPerHost: 5,
+		IdleConnTimeout:     90 * time.Second,
+		DialContext: (&net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout: connectTimeout,
+	}
 }
 
 // NewClient creates an HTTP client using the shared transport with the given timeout.
@@ -26,3 +56,124 @@ func NewClient(timeout time.Duration) *http.Client {
 		Transport: sharedTransport,
 	}
 }
+
+// SetConnectTimeout bounds connecting and the TLS handshake, which can be slow
+// through a proxy. Call it before making requests.
+func SetConnectTimeout(d time.Duration) {
+	if d <= 0 {
+		d = DefaultConnectTimeout
+	}
+	dialer := &net.Dialer{Timeout: d, KeepAlive: 30 * time.Second}
+	sharedTransport.DialContext = dialer.DialContext
+	sharedTransport.TLSHandshakeTimeout = d
+}
+
+// SetProxy sends requests through the proxy at raw instead of the one in the
+// environment; hosts in NO_PROXY still connect directly. DirectProxy ignores
+// proxies altogether, and an empty raw restores the environment's.
+func SetProxy(raw string) error {
+	proxyMu.Lock()
+	defer proxyMu.Unlock()
+
+	switch raw {
+	case "":
+		proxyURL, proxyDirect = nil, false
+		return nil
+	case DirectProxy:
+		proxyURL, proxyDirect = nil, true
+		return nil
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		// Like the environment variables, accept host:port without a scheme
+		if u, err = url.Parse("http://" + raw); err != nil || u.Host == "" {
+			return &InvalidProxyError{Proxy: raw}
+		}
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return &InvalidProxyError{Proxy: raw}
+	}
+	proxyURL, proxyDirect = u, false
+	return nil
+}
+
+// InvalidProxyError indicates a proxy setting that isn't a usable URL.
+type InvalidProxyError struct {
+	Proxy string
+}
+
+func (e *InvalidProxyError) Error() string {
+	return fmt.Sprintf("invalid proxy %q: want a URL such as http://proxy.example.com:3128, or %q", e.Proxy, DirectProxy)
+}
+
+// ProxyFor returns the proxy a request to rawURL goes through, or nil when it
+// connects directly.
+func ProxyFor(rawURL string) (*url.URL, error) {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return proxy(req)
+}
+
+// proxy selects the proxy for req: none when disabled, the configured proxy
+// unless NO_PROXY lists the host, otherwise the environment's.
+func proxy(req *http.Request) (*url.URL, error) {
+	proxyMu.RLock()
+	u, direct := proxyURL, proxyDirect
+	proxyMu.RUnlock()
+
+	switch {
+	case direct:
+		return nil, nil
+	case u != nil:
+		if bypassProxy(req.URL.Hostname(), noProxyEnv()) {
+			return nil, nil
+		}
+		return u, nil
+	default:
+		return envProxy(req)
+	}
+}
+
+// noProxyEnv returns NO_PROXY, or no_proxy when it isn't set.
+func noProxyEnv() string {
+	if v := os.Getenv("NO_PROXY"); v != "" {
+		return v
+	}
+	return os.Getenv("no_proxy")
+}
+
+// bypassProxy reports whether noProxy, a comma-separated list of hosts,
+// domains (".example.com" or "example.com", matching subdomains), IPs and
+// CIDR ranges, or "*", covers host.
+func bypassProxy(host, noProxy string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+	for _, entry := range strings.Split(noProxy, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+		if h, _, err := net.SplitHostPort(entry); err == nil {
+			entry = h
+		}
+		domain := strings.TrimPrefix(entry, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
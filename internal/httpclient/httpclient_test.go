@@ -1,6 +1,9 @@
 package httpclient
 
 import (
+	"errors"
+	"net/http"
+	"net/url"
 	"testing"
 	"time"
 )
@@ -31,3 +34,78 @@ func TestNewClient_DifferentTimeouts(t *testing.T) {
 		t.Error("clients should share the same transport")
 	}
 }
+
+func TestSetProxy(t *testing.T) {
+	t.Cleanup(func() { _ = SetProxy("") })
+	t.Setenv("NO_PROXY", "internal.example.com,10.0.0.0/8")
+
+	if err := SetProxy("proxy.corp:3128"); err != nil {
+		t.Fatalf("SetProxy() error = %v", err)
+	}
+	u, err := ProxyFor("https://api.openai.com/v1/chat/completions")
+	if err != nil || u == nil || u.String() != "http://proxy.corp:3128" {
+		t.Errorf("ProxyFor(openai) = %v, %v; want http://proxy.corp:3128", u, err)
+	}
+	for _, direct := range []string{"https://llm.internal.example.com/v1", "http://10.1.2.3:8080/"} {
+		if u, _ := ProxyFor(direct); u != nil {
+			t.Errorf("ProxyFor(%s) = %v, want direct (NO_PROXY)", direct, u)
+		}
+	}
+
+	if err := SetProxy(DirectProxy); err != nil {
+		t.Fatalf("SetProxy(direct) error = %v", err)
+	}
+	if u, _ := ProxyFor("https://api.openai.com/"); u != nil {
+		t.Errorf("ProxyFor() = %v with proxies disabled", u)
+	}
+
+	var invalid *InvalidProxyError
+	if err := SetProxy("ftp://proxy.corp"); !errors.As(err, &invalid) {
+		t.Errorf("SetProxy(ftp) error = %v, want InvalidProxyError", err)
+	}
+}
+
+func TestProxyFor_Environment(t *testing.T) {
+	want, _ := url.Parse("http://env-proxy:8080")
+	orig := envProxy
+	envProxy = func(*http.Request) (*url.URL, error) { return want, nil }
+	t.Cleanup(func() { envProxy = orig })
+
+	if u, _ := ProxyFor("https://api.anthropic.com/"); u != want {
+		t.Errorf("ProxyFor() = %v, want the environment's proxy", u)
+	}
+}
+
+func TestBypassProxy(t *testing.T) {
+	tests := []struct {
+		host, noProxy string
+		want          bool
+	}{
+		{"api.openai.com", "", false},
+		{"api.openai.com", "*", true},
+		{"api.openai.com", "openai.com", true},
+		{"api.openai.com", ".openai.com", true},
+		{"notopenai.com", "openai.com", false},
+		{"localhost", "localhost:8080", true},
+		{"192.168.1.5", "192.168.0.0/16", true},
+		{"192.169.1.5", "192.168.0.0/16", false},
+	}
+	for _, tt := range tests {
+		if got := bypassProxy(tt.host, tt.noProxy); got != tt.want {
+			t.Errorf("bypassProxy(%q, %q) = %v, want %v", tt.host, tt.noProxy, got, tt.want)
+		}
+	}
+}
+
+func TestSetConnectTimeout(t *testing.T) {
+	t.Cleanup(func() { SetConnectTimeout(0) })
+
+	SetConnectTimeout(45 * time.Second)
+	if sharedTransport.TLSHandshakeTimeout != 45*time.Second {
+		t.Errorf("TLSHandshakeTimeout = %v, want 45s", sharedTransport.TLSHandshakeTimeout)
+	}
+	SetConnectTimeout(0)
+	if sharedTransport.TLSHandshakeTimeout != DefaultConnectTimeout {
+		t.Errorf("TLSHandshakeTimeout = %v, want the default", sharedTransport.TLSHandshakeTimeout)
+	}
+}
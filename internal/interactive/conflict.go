@@ -0,0 +1,308 @@
+package interactive
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dsswift/commit/internal/git"
+)
+
+// maxHunkChars caps each side of a conflict hunk sent to the LLM.
+const maxHunkChars = 2000
+
+// ConflictSuggester is the subset of an LLM provider used to suggest conflict resolutions.
+type ConflictSuggester interface {
+	AnalyzeDiff(ctx context.Context, system, user string) (string, error)
+}
+
+// ConflictHunk is a single conflicted region with its three sides.
+type ConflictHunk struct {
+	Ours   string
+	Base   string
+	Theirs string
+
+	// start and end are line indices of the marker lines in the merged content
+	start int
+	end   int
+}
+
+// Truncated reports whether any side of h is too long to send to the LLM
+// whole, so a suggestion for it can't be trusted to keep all of it.
+func (h ConflictHunk) Truncated() bool {
+	return len(h.Ours) > maxHunkChars || len(h.Base) > maxHunkChars || len(h.Theirs) > maxHunkChars
+}
+
+// ConflictFile holds the diff3-style merge of a conflicted file and its hunks.
+type ConflictFile struct {
+	Path  string
+	Hunks []ConflictHunk
+	lines []string
+}
+
+// HunkSuggestion is the LLM's proposed resolution for one hunk.
+type HunkSuggestion struct {
+	Hunk       int    `json:"hunk"`
+	Resolution string `json:"resolution"`
+	Rationale  string `json:"rationale"`
+}
+
+// ConflictSuggestion is the LLM's proposed resolution for a conflicted file.
+type ConflictSuggestion struct {
+	Hunks []HunkSuggestion `json:"hunks"`
+}
+
+// LoadConflictFile reconstructs the conflict for path from the index stages
+// (base, ours, theirs) without touching the working tree.
+func LoadConflictFile(gitRoot, path string) (*ConflictFile, error) {
+	tmpDir, err := os.MkdirTemp("", "commit-conflict-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir) //nolint:errcheck // best-effort cleanup
+
+	// Stage 1 = base, 2 = ours, 3 = theirs. Missing stages (add/add, delete) are empty.
+	stagePaths := make([]string, 3)
+	for i, name := range []string{"base", "ours", "theirs"} {
+		cmd := git.Command("show", fmt.Sprintf(":%d:%s", i+1, path))
+		cmd.Dir = gitRoot
+		out, _ := cmd.Output()
+
+		stagePaths[i] = filepath.Join(tmpDir, name)
+		if err := os.WriteFile(stagePaths[i], out, 0600); err != nil {
+			return nil, err
+		}
+	}
+
+	cmd := git.Command("merge-file", "-p", "--diff3",
+		"-L", "ours", "-L", "base", "-L", "theirs",
+		stagePaths[1], stagePaths[0], stagePaths[2])
+	cmd.Dir = gitRoot
+
+	// merge-file exits with the number of conflicts; only negative codes are errors
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() < 0 || exitErr.ExitCode() > 127 {
+			return nil, fmt.Errorf("failed to merge %s: %w", path, err)
+		}
+	}
+
+	file := parseConflictFile(path, string(out))
+	if len(file.Hunks) == 0 {
+		return nil, fmt.Errorf("no conflict hunks found in %s", path)
+	}
+	return file, nil
+}
+
+// Truncated reports whether any hunk in f was cut short for the LLM.
+func (f *ConflictFile) Truncated() bool {
+	for _, h := range f.Hunks {
+		if h.Truncated() {
+			return true
+		}
+	}
+	return false
+}
+
+// parseConflictFile splits diff3-style merged content into conflict hunks.
+func parseConflictFile(path, content string) *ConflictFile {
+	file := &ConflictFile{Path: path, lines: strings.Split(content, "\n")}
+
+	const (
+		outside = iota
+		inOurs
+		inBase
+		inTheirs
+	)
+
+	state := outside
+	var hunk ConflictHunk
+	var ours, base, theirs []string
+
+	for i, line := range file.lines {
+		switch {
+		case state == outside && strings.HasPrefix(line, "<<<<<<< "):
+			state = inOurs
+			hunk = ConflictHunk{start: i}
+			ours, base, theirs = nil, nil, nil
+		case state == inOurs && strings.HasPrefix(line, "||||||| "):
+			state = inBase
+		case (state == inOurs || state == inBase) && line == "=======":
+			state = inTheirs
+		case state == inTheirs && strings.HasPrefix(line, ">>>>>>> "):
+			hunk.end = i
+			hunk.Ours = strings.Join(ours, "\n")
+			hunk.Base = strings.Join(base, "\n")
+			hunk.Theirs = strings.Join(theirs, "\n")
+			file.Hunks = append(file.Hunks, hunk)
+			state = outside
+		case state == inOurs:
+			ours = append(ours, line)
+		case state == inBase:
+			base = append(base, line)
+		case state == inTheirs:
+			theirs = append(theirs, line)
+		}
+	}
+
+	return file
+}
+
+// Resolve returns the file content with each hunk replaced by its resolution.
+// resolutions must have one entry per hunk.
+func (f *ConflictFile) Resolve(resolutions []string) (string, error) {
+	if len(resolutions) != len(f.Hunks) {
+		return "", fmt.Errorf("expected %d resolutions, got %d", len(f.Hunks), len(resolutions))
+	}
+
+	var out []string
+	prev := 0
+	for i, hunk := range f.Hunks {
+		out = append(out, f.lines[prev:hunk.start]...)
+		if resolutions[i] != "" {
+			out = append(out, strings.Split(resolutions[i], "\n")...)
+		}
+		prev = hunk.end + 1
+	}
+	out = append(out, f.lines[prev:]...)
+
+	return strings.Join(out, "\n"), nil
+}
+
+// BuildConflictPrompt creates the LLM prompt for suggesting conflict resolutions.
+func BuildConflictPrompt(file *ConflictFile) (system, user string) {
+	system = `You are helping resolve git rebase conflicts. For each conflict hunk you receive
+the "ours" side (the branch being rebased onto), the "base" (common ancestor), and the
+"theirs" side (the commit being replayed).
+
+Rules:
+- Propose the exact text that should replace each hunk (no conflict markers)
+- Preserve the intent of both sides where possible
+- Give a one or two sentence rationale per hunk
+- Return JSON only, no markdown code blocks, in the form:
+{"hunks": [{"hunk": 0, "resolution": "...", "rationale": "..."}]}`
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "File: %s\n", file.Path)
+	for i, hunk := range file.Hunks {
+		fmt.Fprintf(&b, "\nHUNK %d\n", i)
+		fmt.Fprintf(&b, "--- ours ---\n%s\n", truncateHunk(hunk.Ours))
+		fmt.Fprintf(&b, "--- base ---\n%s\n", truncateHunk(hunk.Base))
+		fmt.Fprintf(&b, "--- theirs ---\n%s\n", truncateHunk(hunk.Theirs))
+	}
+	user = b.String()
+
+	return system, user
+}
+
+// truncateHunk limits a hunk side to maxHunkChars, cutting on a rune boundary.
+func truncateHunk(s string) string {
+	if len(s) <= maxHunkChars {
+		return s
+	}
+	end := maxHunkChars
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + "\n... (truncated)"
+}
+
+// SuggestResolution asks the LLM for a resolution of every hunk in file.
+func SuggestResolution(ctx context.Context, suggester ConflictSuggester, file *ConflictFile) (*ConflictSuggestion, error) {
+	system, user := BuildConflictPrompt(file)
+
+	content, err := suggester.AnalyzeDiff(ctx, system, user)
+	if err != nil {
+		return nil, err
+	}
+
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+
+	var suggestion ConflictSuggestion
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &suggestion); err != nil {
+		return nil, fmt.Errorf("failed to parse suggestion: %w", err)
+	}
+
+	for _, h := range suggestion.Hunks {
+		if h.Hunk < 0 || h.Hunk >= len(file.Hunks) {
+			return nil, fmt.Errorf("suggestion references unknown hunk %d", h.Hunk)
+		}
+	}
+
+	return &suggestion, nil
+}
+
+// Resolutions returns the suggested text for each hunk in order, or false if
+// any hunk is missing a suggestion.
+func (s *ConflictSuggestion) Resolutions(hunkCount int) ([]string, bool) {
+	resolutions := make([]string, hunkCount)
+	covered := make([]bool, hunkCount)
+	for _, h := range s.Hunks {
+		if h.Hunk >= 0 && h.Hunk < hunkCount {
+			resolutions[h.Hunk] = h.Resolution
+			covered[h.Hunk] = true
+		}
+	}
+	for _, ok := range covered {
+		if !ok {
+			return nil, false
+		}
+	}
+	return resolutions, true
+}
+
+// ApplyResolution writes the resolved content to the file and marks it resolved.
+// Files with truncated hunks are refused: the suggestion replaces each hunk
+// whole, so it would drop whatever the LLM never saw.
+func ApplyResolution(gitRoot string, file *ConflictFile, suggestion *ConflictSuggestion) error {
+	if file.Truncated() {
+		return &TruncatedConflictError{Path: file.Path}
+	}
+
+	resolutions, ok := suggestion.Resolutions(len(file.Hunks))
+	if !ok {
+		return fmt.Errorf("suggestion does not cover every hunk in %s", file.Path)
+	}
+
+	content, err := file.Resolve(resolutions)
+	if err != nil {
+		return err
+	}
+
+	fullPath := filepath.Join(gitRoot, file.Path)
+	info, err := os.Stat(fullPath)
+	mode := os.FileMode(0644)
+	if err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	if err := os.WriteFile(fullPath, []byte(content), mode); err != nil {
+		return fmt.Errorf("failed to write %s: %w", file.Path, err)
+	}
+
+	cmd := git.Command("add", "--", file.Path)
+	cmd.Dir = gitRoot
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to mark %s resolved: %s: %w", file.Path, string(out), err)
+	}
+
+	return nil
+}
+
+// TruncatedConflictError indicates a conflict too large to send to the LLM
+// whole, which has to be resolved by hand.
+type TruncatedConflictError struct {
+	Path string
+}
+
+func (e *TruncatedConflictError) Error() string {
+	return fmt.Sprintf("conflicts in %s are too long to suggest in full; resolve it by hand", e.Path)
+}
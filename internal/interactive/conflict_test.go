@@ -0,0 +1,212 @@
+package interactive
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/dsswift/commit/internal/testutil"
+)
+
+type mockSuggester struct {
+	response string
+	system   string
+	user     string
+}
+
+func (m *mockSuggester) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	m.system = system
+	m.user = user
+	return m.response, nil
+}
+
+func TestParseConflictFile(t *testing.T) {
+	content := "header\n<<<<<<< ours\nmine\n||||||| base\norig\n=======\nyours\n>>>>>>> theirs\nmiddle\n<<<<<<< ours\nA\n=======\nB\n>>>>>>> theirs\nfooter\n"
+
+	file := parseConflictFile("f.txt", content)
+	if len(file.Hunks) != 2 {
+		t.Fatalf("expected 2 hunks, got %d", len(file.Hunks))
+	}
+
+	first := file.Hunks[0]
+	if first.Ours != "mine" || first.Base != "orig" || first.Theirs != "yours" {
+		t.Errorf("unexpected first hunk: %+v", first)
+	}
+	if file.Hunks[1].Base != "" {
+		t.Errorf("expected empty base for two-way hunk, got %q", file.Hunks[1].Base)
+	}
+
+	resolved, err := file.Resolve([]string{"merged", ""})
+	if err != nil {
+		t.Fatalf("Resolve failed: %v", err)
+	}
+	expected := "header\nmerged\nmiddle\nfooter\n"
+	if resolved != expected {
+		t.Errorf("Resolve() = %q, want %q", resolved, expected)
+	}
+
+	if _, err := file.Resolve([]string{"only one"}); err == nil {
+		t.Error("expected error for wrong resolution count")
+	}
+}
+
+func TestSuggestResolution(t *testing.T) {
+	file := parseConflictFile("f.txt", "<<<<<<< ours\na\n=======\nb\n>>>>>>> theirs\n")
+
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{"plain json", `{"hunks":[{"hunk":0,"resolution":"ab","rationale":"keep both"}]}`, false},
+		{"fenced json", "```json\n{\"hunks\":[{\"hunk\":0,\"resolution\":\"ab\"}]}\n```", false},
+		{"unknown hunk", `{"hunks":[{"hunk":3,"resolution":"x"}]}`, true},
+		{"invalid json", `not json`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			suggester := &mockSuggester{response: tt.response}
+			suggestion, err := SuggestResolution(context.Background(), suggester, file)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SuggestResolution failed: %v", err)
+			}
+			if suggestion.Hunks[0].Resolution != "ab" {
+				t.Errorf("unexpected resolution: %q", suggestion.Hunks[0].Resolution)
+			}
+			if !strings.Contains(suggester.user, "--- ours ---\na") {
+				t.Errorf("prompt missing ours side: %q", suggester.user)
+			}
+		})
+	}
+}
+
+func TestConflictSuggestion_Resolutions_Incomplete(t *testing.T) {
+	suggestion := &ConflictSuggestion{Hunks: []HunkSuggestion{{Hunk: 0, Resolution: "x"}}}
+	if _, ok := suggestion.Resolutions(2); ok {
+		t.Error("expected incomplete suggestion to be rejected")
+	}
+}
+
+func TestTruncateHunk_RuneBoundary(t *testing.T) {
+	// "é" is two bytes, so the cap falls in the middle of one
+	side := "a" + strings.Repeat("é", maxHunkChars)
+	got := truncateHunk(side)
+	if !utf8.ValidString(got) {
+		t.Errorf("truncateHunk split a rune: %q", got[len(got)-30:])
+	}
+	if !strings.HasSuffix(got, "\n... (truncated)") {
+		t.Errorf("missing truncation marker: %q", got[len(got)-30:])
+	}
+}
+
+func TestApplyResolution_Truncated(t *testing.T) {
+	repoDir := t.TempDir()
+	long := strings.Repeat("x", maxHunkChars+1)
+	content := "one\n<<<<<<< ours\n" + long + "\n||||||| base\ntwo\n=======\nB\n>>>>>>> theirs\nthree\n"
+	testutil.CreateFile(t, repoDir, "file.txt", content)
+
+	file := parseConflictFile("file.txt", content)
+	if !file.Truncated() {
+		t.Fatal("expected the long hunk to count as truncated")
+	}
+
+	suggestion := &ConflictSuggestion{Hunks: []HunkSuggestion{{Hunk: 0, Resolution: "B"}}}
+	err := ApplyResolution(repoDir, file, suggestion)
+	if _, ok := err.(*TruncatedConflictError); !ok {
+		t.Fatalf("expected *TruncatedConflictError, got %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoDir, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != content {
+		t.Error("a truncated conflict was written over")
+	}
+}
+
+func TestRebaser_Execute_Conflict(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	testutil.CreateFile(t, repoDir, "file.txt", "one\ntwo\nthree\n")
+	testutil.GitAdd(t, repoDir, "file.txt")
+	baseHash := testutil.GitCommit(t, repoDir, "base")
+
+	testutil.CreateFile(t, repoDir, "file.txt", "one\nA\nthree\n")
+	testutil.GitAdd(t, repoDir, "file.txt")
+	hashA := testutil.GitCommit(t, repoDir, "A")
+
+	testutil.CreateFile(t, repoDir, "file.txt", "one\nB\nthree\n")
+	testutil.GitAdd(t, repoDir, "file.txt")
+	hashB := testutil.GitCommit(t, repoDir, "B")
+
+	// Picking B (a change from A) directly onto base conflicts on line two
+	entries := []RebaseEntry{
+		{Commit: RebaseCommit{ShortHash: hashB, Message: "B"}, Operation: OpPick},
+		{Commit: RebaseCommit{ShortHash: hashA, Message: "A"}, Operation: OpPick},
+	}
+
+	rebaser := NewRebaser(repoDir)
+	err := rebaser.Execute(entries, baseHash)
+	conflictErr, ok := err.(*ConflictError)
+	if !ok {
+		t.Fatalf("expected *ConflictError, got %v", err)
+	}
+	if len(conflictErr.Files) != 1 || conflictErr.Files[0] != "file.txt" {
+		t.Fatalf("unexpected conflicted files: %v", conflictErr.Files)
+	}
+
+	file, err := LoadConflictFile(repoDir, "file.txt")
+	if err != nil {
+		t.Fatalf("LoadConflictFile failed: %v", err)
+	}
+	if len(file.Hunks) != 1 {
+		t.Fatalf("expected 1 hunk, got %d", len(file.Hunks))
+	}
+	hunk := file.Hunks[0]
+	if hunk.Ours != "two" || hunk.Base != "A" || hunk.Theirs != "B" {
+		t.Errorf("unexpected hunk: %+v", hunk)
+	}
+
+	suggester := &mockSuggester{response: `{"hunks":[{"hunk":0,"resolution":"A\nB","rationale":"keep both"}]}`}
+	suggestion, err := SuggestResolution(context.Background(), suggester, file)
+	if err != nil {
+		t.Fatalf("SuggestResolution failed: %v", err)
+	}
+
+	if err := ApplyResolution(repoDir, file, suggestion); err != nil {
+		t.Fatalf("ApplyResolution failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoDir, "file.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "one\nA\nB\nthree\n" {
+		t.Errorf("unexpected resolved content: %q", string(data))
+	}
+	if files := rebaser.ConflictedFiles(); len(files) != 0 {
+		t.Errorf("expected no remaining conflicts, got %v", files)
+	}
+
+	if err := rebaser.Abort(); err != nil {
+		t.Fatalf("Abort failed: %v", err)
+	}
+	cmd := exec.Command("git", "log", "-1", "--format=%s")
+	cmd.Dir = repoDir
+	out, _ := cmd.Output()
+	if strings.TrimSpace(string(out)) != "B" {
+		t.Errorf("expected original HEAD after abort, got %q", string(out))
+	}
+}
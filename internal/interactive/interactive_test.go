@@ -269,6 +269,41 @@ func TestRebaser_Execute_Reword(t *testing.T) {
 	}
 }
 
+func TestRebaser_Execute_RewordKeepsChangeID(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	testutil.CreateFile(t, repoDir, "base.txt", "base")
+	testutil.GitAdd(t, repoDir, "base.txt")
+	baseHash := testutil.GitCommit(t, repoDir, "base")
+
+	changeID := "Change-Id: I0123456789abcdef0123456789abcdef01234567"
+	testutil.CreateFile(t, repoDir, "feature.txt", "content")
+	testutil.GitAdd(t, repoDir, "feature.txt")
+	testutil.GitCommit(t, repoDir, "old message\n\n"+changeID)
+
+	entries := []RebaseEntry{
+		{
+			Commit:        RebaseCommit{ShortHash: getShortHash(t, repoDir, "HEAD"), Message: "old message"},
+			Operation:     OpReword,
+			NewMessage:    "new message",
+			MessageEdited: true,
+		},
+	}
+	if err := NewRebaser(repoDir).Execute(entries, baseHash); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	cmd := exec.Command("git", "log", "-1", "--format=%B")
+	cmd.Dir = repoDir
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git log failed: %v", err)
+	}
+	if want := "new message\n\n" + changeID; strings.TrimSpace(string(out)) != want {
+		t.Errorf("message = %q, want %q", strings.TrimSpace(string(out)), want)
+	}
+}
+
 func TestRebaser_Execute_EmptyEntries(t *testing.T) {
 	repoDir := testutil.TestRepo(t)
 
@@ -468,3 +503,54 @@ func TestRebaser_Execute_MixedOperationsWithReword(t *testing.T) {
 		}
 	}
 }
+
+func TestRebaser_Execute_DirtyTree(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	testutil.CreateFile(t, repoDir, "file.txt", "initial")
+	testutil.CreateFile(t, repoDir, "other.txt", "other")
+	testutil.GitAdd(t, repoDir, "file.txt", "other.txt")
+	baseHash := testutil.GitCommit(t, repoDir, "initial commit")
+
+	testutil.CreateFile(t, repoDir, "a.txt", "a")
+	testutil.GitAdd(t, repoDir, "a.txt")
+	testutil.GitCommit(t, repoDir, "commit 1")
+
+	testutil.CreateFile(t, repoDir, "b.txt", "b")
+	testutil.GitAdd(t, repoDir, "b.txt")
+	testutil.GitCommit(t, repoDir, "commit 2")
+
+	// Uncommitted change to a tracked file
+	testutil.CreateFile(t, repoDir, "other.txt", "dirty")
+
+	rebaser := NewRebaser(repoDir)
+	if dirty := rebaser.DirtyFiles(); len(dirty) != 1 || dirty[0] != "other.txt" {
+		t.Fatalf("DirtyFiles() = %v, want [other.txt]", dirty)
+	}
+
+	entries := []RebaseEntry{
+		{Commit: RebaseCommit{ShortHash: getShortHash(t, repoDir, "HEAD"), Message: "commit 2"}, Operation: OpPick},
+		{Commit: RebaseCommit{ShortHash: getShortHash(t, repoDir, "HEAD~1"), Message: "commit 1"}, Operation: OpPick},
+	}
+
+	if err := rebaser.Execute(entries, baseHash); err == nil {
+		t.Fatal("expected rebase to refuse a dirty tree without autostash")
+	}
+
+	rebaser.SetAutostash(true)
+	if err := rebaser.Execute(entries, baseHash); err != nil {
+		t.Fatalf("Execute with autostash failed: %v", err)
+	}
+
+	if messages := getCommitMessages(t, repoDir); len(messages) != 3 || messages[1] != "commit 2" {
+		t.Errorf("expected commits reordered, got %v", messages)
+	}
+
+	data, err := os.ReadFile(filepath.Join(repoDir, "other.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "dirty" {
+		t.Errorf("expected uncommitted change restored, got %q", string(data))
+	}
+}
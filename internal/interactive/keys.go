@@ -20,6 +20,20 @@ type KeyMap struct {
 	Drop     key.Binding
 	EditMsg  key.Binding
 	LoadMore key.Binding
+	Details  key.Binding
+	Diff     key.Binding
+
+	EditType  key.Binding
+	EditFiles key.Binding
+	Merge     key.Binding
+	Split     key.Binding
+	Editor    key.Binding
+
+	Suggest key.Binding
+	Apply   key.Binding
+	Abort   key.Binding
+	Yes     key.Binding
+	No      key.Binding
 
 	Help key.Binding
 }
@@ -83,6 +97,54 @@ func DefaultKeyMap() KeyMap {
 			key.WithKeys("l", "m"),
 			key.WithHelp("l", "load more"),
 		),
+		Details: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "details"),
+		),
+		Diff: key.NewBinding(
+			key.WithKeys("o"),
+			key.WithHelp("o", "open diff"),
+		),
+		EditType: key.NewBinding(
+			key.WithKeys("t"),
+			key.WithHelp("t", "edit type"),
+		),
+		EditFiles: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "edit files"),
+		),
+		Merge: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "merge next"),
+		),
+		Split: key.NewBinding(
+			key.WithKeys("s"),
+			key.WithHelp("s", "split"),
+		),
+		Editor: key.NewBinding(
+			key.WithKeys("E"),
+			key.WithHelp("E", "edit in editor"),
+		),
+		Suggest: key.NewBinding(
+			key.WithKeys("x"),
+			key.WithHelp("x", "suggest fix"),
+		),
+		Apply: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "apply"),
+		),
+		Abort: key.NewBinding(
+			key.WithKeys("A"),
+			key.WithHelp("A", "abort rebase"),
+		),
+		Yes: key.NewBinding(
+			key.WithKeys("y"),
+			key.WithHelp("y", "yes"),
+		),
+		No: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "no"),
+		),
 		Help: key.NewBinding(
 			key.WithKeys("?"),
 			key.WithHelp("?", "help"),
@@ -97,10 +159,25 @@ func (k KeyMap) SelectStepHelp() []key.Binding {
 
 // EditStepHelp returns help text for the edit step.
 func (k KeyMap) EditStepHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.MoveUp, k.MoveDown, k.Tab, k.EditMsg, k.Enter, k.Back}
+	return []key.Binding{k.Up, k.Down, k.MoveUp, k.MoveDown, k.Tab, k.EditMsg, k.Details, k.Diff, k.Enter, k.Back}
 }
 
 // ConfirmStepHelp returns help text for the confirm step.
 func (k KeyMap) ConfirmStepHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Enter, k.Back, k.Cancel}
+	return []key.Binding{k.Yes, k.No, k.Up, k.Down, k.Enter, k.Back, k.Cancel}
+}
+
+// ConflictStepHelp returns help text for the conflict step.
+func (k KeyMap) ConflictStepHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Suggest, k.Apply, k.Abort, k.Cancel}
+}
+
+// FixStepHelp returns help text for the plan fix-up screen.
+func (k KeyMap) FixStepHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.EditType, k.EditMsg, k.Editor, k.EditFiles, k.Drop, k.Enter, k.Cancel}
+}
+
+// ReviewStepHelp returns help text for the plan review screen.
+func (k KeyMap) ReviewStepHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.MoveUp, k.MoveDown, k.Merge, k.Split, k.EditType, k.EditMsg, k.Editor, k.EditFiles, k.Drop, k.Enter, k.Cancel}
 }
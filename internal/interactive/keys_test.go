@@ -13,15 +13,39 @@ func TestSelectStepHelp(t *testing.T) {
 func TestEditStepHelp(t *testing.T) {
 	km := DefaultKeyMap()
 	bindings := km.EditStepHelp()
-	if len(bindings) != 8 {
-		t.Errorf("EditStepHelp() returned %d bindings, want 8", len(bindings))
+	if len(bindings) != 10 {
+		t.Errorf("EditStepHelp() returned %d bindings, want 10", len(bindings))
 	}
 }
 
 func TestConfirmStepHelp(t *testing.T) {
 	km := DefaultKeyMap()
 	bindings := km.ConfirmStepHelp()
-	if len(bindings) != 5 {
-		t.Errorf("ConfirmStepHelp() returned %d bindings, want 5", len(bindings))
+	if len(bindings) != 7 {
+		t.Errorf("ConfirmStepHelp() returned %d bindings, want 7", len(bindings))
+	}
+}
+
+func TestConflictStepHelp(t *testing.T) {
+	km := DefaultKeyMap()
+	bindings := km.ConflictStepHelp()
+	if len(bindings) != 6 {
+		t.Errorf("ConflictStepHelp() returned %d bindings, want 6", len(bindings))
+	}
+}
+
+func TestFixStepHelp(t *testing.T) {
+	km := DefaultKeyMap()
+	bindings := km.FixStepHelp()
+	if len(bindings) != 9 {
+		t.Errorf("FixStepHelp() returned %d bindings, want 9", len(bindings))
+	}
+}
+
+func TestReviewStepHelp(t *testing.T) {
+	km := DefaultKeyMap()
+	bindings := km.ReviewStepHelp()
+	if len(bindings) != 13 {
+		t.Errorf("ReviewStepHelp() returned %d bindings, want 13", len(bindings))
 	}
 }
@@ -14,6 +14,7 @@ const (
 	StepEdit
 	StepSquashMessage
 	StepConfirm
+	StepConflict
 )
 
 // String returns the display name for the step.
@@ -29,6 +30,8 @@ func (s WizardStep) String() string {
 		return "Squash Message"
 	case StepConfirm:
 		return "Confirm"
+	case StepConflict:
+		return "Resolve Conflicts"
 	default:
 		return "Unknown"
 	}
@@ -89,6 +92,7 @@ type RebaseCommit struct {
 	Author    string
 	Date      time.Time
 	IsPushed  bool
+	IsMerge   bool
 }
 
 // RebaseEntry represents a commit with its rebase operation.
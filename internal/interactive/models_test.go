@@ -75,6 +75,7 @@ func TestWizardStep_String(t *testing.T) {
 		{StepEdit, "Edit"},
 		{StepSquashMessage, "Squash Message"},
 		{StepConfirm, "Confirm"},
+		{StepConflict, "Resolve Conflicts"},
 	}
 
 	for _, tt := range tests {
@@ -295,6 +296,7 @@ func TestRenderStepIndicator(t *testing.T) {
 		StepEdit,
 		StepSquashMessage,
 		StepConfirm,
+		StepConflict,
 	}
 
 	for _, step := range steps {
@@ -0,0 +1,614 @@
+package interactive
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// PlanIssue is a validation problem reported for a commit plan.
+type PlanIssue struct {
+	// Commit is the index of the offending commit, or -1 for the whole plan
+	Commit  int
+	Message string
+}
+
+// PlanValidator checks a plan and returns its remaining issues.
+type PlanValidator func(plan *types.CommitPlan) []PlanIssue
+
+// CommitSplitter generates a commit for files split off another commit.
+// Only the type, scope and message of the result are used.
+type CommitSplitter func(files []string) (types.PlannedCommit, error)
+
+// MessageEditor opens a commit's message in an external editor. Command
+// returns the editor process and a function that reads the edited commit back
+// after it exits.
+type MessageEditor interface {
+	Command(c types.PlannedCommit) (*exec.Cmd, func() (types.PlannedCommit, error), error)
+}
+
+// editorDoneMsg carries a commit edited in the external editor.
+type editorDoneMsg struct {
+	index  int
+	commit types.PlannedCommit
+	err    error
+}
+
+// splitDoneMsg carries the generated commit for a split-off file list.
+type splitDoneMsg struct {
+	index  int
+	commit types.PlannedCommit
+	err    error
+}
+
+// fixField identifies the commit field being edited inline.
+type fixField int
+
+const (
+	fixNone fixField = iota
+	fixType
+	fixMessage
+	fixFiles
+	fixSplit
+)
+
+// PlanFixModel lets the user repair a commit plan that failed validation:
+// edit a commit's type, message or files inline, or drop it, then re-validate.
+// In review mode it also reorders, merges and splits commits.
+type PlanFixModel struct {
+	commits  []types.PlannedCommit
+	dropped  []bool
+	validate PlanValidator
+	split    CommitSplitter
+	editor   MessageEditor
+	review   bool
+	cursor   int
+	styles   Styles
+	keys     KeyMap
+
+	issues      map[int][]string
+	planIssues  []string
+	editing     fixField
+	input       textinput.Model
+	done        bool
+	cancelled   bool
+	validations int
+	splitting   bool
+	splitErr    string
+	editorErr   string
+}
+
+// NewPlanFixModel creates a fix-up model for plan, validating it immediately.
+func NewPlanFixModel(plan *types.CommitPlan, validate PlanValidator, styles Styles, keys KeyMap) *PlanFixModel {
+	ti := textinput.New()
+	ti.CharLimit = 500
+	ti.Width = 60
+
+	commits := make([]types.PlannedCommit, len(plan.Commits))
+	for i, c := range plan.Commits {
+		c.Files = append([]string(nil), c.Files...)
+		commits[i] = c
+	}
+
+	m := &PlanFixModel{
+		commits:  commits,
+		dropped:  make([]bool, len(commits)),
+		validate: validate,
+		styles:   styles,
+		keys:     keys,
+		input:    ti,
+	}
+	m.revalidate()
+
+	// Start on the first failing commit
+	for i := range m.commits {
+		if len(m.issues[i]) > 0 {
+			m.cursor = i
+			break
+		}
+	}
+	return m
+}
+
+// NewPlanReviewModel creates a review model for a valid plan. split generates
+// the message for a split-off commit; if nil, the new commit's message is left
+// empty for the user to fill in.
+func NewPlanReviewModel(plan *types.CommitPlan, validate PlanValidator, split CommitSplitter, styles Styles, keys KeyMap) *PlanFixModel {
+	m := NewPlanFixModel(plan, validate, styles, keys)
+	m.review = true
+	m.split = split
+	m.cursor = 0
+	return m
+}
+
+// SetEditor enables editing messages in an external editor.
+func (m *PlanFixModel) SetEditor(editor MessageEditor) {
+	m.editor = editor
+}
+
+// Init implements tea.Model.
+func (m *PlanFixModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update implements tea.Model.
+func (m *PlanFixModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch done := msg.(type) {
+	case splitDoneMsg:
+		m.applySplit(done)
+		return m, nil
+	case editorDoneMsg:
+		m.applyEditor(done)
+		return m, nil
+	}
+
+	if m.editing != fixNone {
+		return m.updateEdit(msg)
+	}
+
+	keyMsg, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+
+	// Wait for the split-off commit's message before allowing more changes
+	if m.splitting {
+		if key.Matches(keyMsg, m.keys.Cancel) {
+			m.cancelled = true
+			return m, tea.Quit
+		}
+		return m, nil
+	}
+
+	switch {
+	case key.Matches(keyMsg, m.keys.Up):
+		if m.cursor > 0 {
+			m.cursor--
+		}
+
+	case key.Matches(keyMsg, m.keys.Down):
+		if m.cursor < len(m.commits)-1 {
+			m.cursor++
+		}
+
+	case m.review && key.Matches(keyMsg, m.keys.MoveUp):
+		m.move(-1)
+
+	case m.review && key.Matches(keyMsg, m.keys.MoveDown):
+		m.move(1)
+
+	case m.review && key.Matches(keyMsg, m.keys.Merge):
+		m.mergeNext()
+
+	case m.review && key.Matches(keyMsg, m.keys.Split):
+		m.startEdit(fixSplit)
+
+	case key.Matches(keyMsg, m.keys.EditType):
+		m.startEdit(fixType)
+
+	case key.Matches(keyMsg, m.keys.EditMsg):
+		m.startEdit(fixMessage)
+
+	case key.Matches(keyMsg, m.keys.EditFiles):
+		m.startEdit(fixFiles)
+
+	case m.editor != nil && key.Matches(keyMsg, m.keys.Editor):
+		return m, m.openEditor()
+
+	case key.Matches(keyMsg, m.keys.Drop):
+		if m.cursor < len(m.dropped) {
+			m.dropped[m.cursor] = !m.dropped[m.cursor]
+		}
+
+	case key.Matches(keyMsg, m.keys.Enter):
+		if m.revalidate() {
+			m.done = true
+			return m, tea.Quit
+		}
+
+	case key.Matches(keyMsg, m.keys.Cancel):
+		m.cancelled = true
+		return m, tea.Quit
+	}
+
+	return m, nil
+}
+
+// updateEdit handles updates while editing a field.
+func (m *PlanFixModel) updateEdit(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		switch keyMsg.Type {
+		case tea.KeyEnter:
+			cmd := m.applyEdit(strings.TrimSpace(m.input.Value()))
+			m.editing = fixNone
+			return m, cmd
+
+		case tea.KeyEsc:
+			m.editing = fixNone
+			return m, nil
+		}
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// startEdit begins inline editing of field for the commit under the cursor.
+func (m *PlanFixModel) startEdit(field fixField) {
+	if m.cursor >= len(m.commits) || m.dropped[m.cursor] {
+		return
+	}
+
+	commit := m.commits[m.cursor]
+	switch field {
+	case fixType:
+		m.input.SetValue(commit.Type)
+		m.input.Placeholder = "feat, fix, docs, ..."
+	case fixMessage:
+		m.input.SetValue(commit.Message)
+		m.input.Placeholder = "Enter new commit message..."
+	case fixFiles:
+		m.input.SetValue(strings.Join(commit.Files, ", "))
+		m.input.Placeholder = "Comma-separated file paths"
+	case fixSplit:
+		if len(commit.Files) < 2 {
+			return
+		}
+		m.input.SetValue("")
+		m.input.Placeholder = "Files to move into a new commit"
+	}
+
+	m.editing = field
+	m.input.Focus()
+	m.input.CursorEnd()
+}
+
+// applyEdit stores value in the field being edited. Splitting a commit may
+// return a command that generates the new commit's message.
+func (m *PlanFixModel) applyEdit(value string) tea.Cmd {
+	commit := &m.commits[m.cursor]
+	switch m.editing {
+	case fixType:
+		commit.Type = value
+	case fixMessage:
+		commit.Message = value
+	case fixFiles:
+		commit.Files = splitFiles(value)
+	case fixSplit:
+		return m.splitCommit(splitFiles(value))
+	}
+	return nil
+}
+
+// move swaps the commit under the cursor with its neighbour in direction
+// delta (-1 for up, 1 for down), keeping the cursor on the moved commit.
+func (m *PlanFixModel) move(delta int) {
+	target := m.cursor + delta
+	if target < 0 || target >= len(m.commits) {
+		return
+	}
+
+	m.commits[m.cursor], m.commits[target] = m.commits[target], m.commits[m.cursor]
+	m.dropped[m.cursor], m.dropped[target] = m.dropped[target], m.dropped[m.cursor]
+	m.cursor = target
+	m.revalidate()
+}
+
+// mergeNext folds the commit below the cursor into the one under it, keeping
+// the upper commit's type, scope and message.
+func (m *PlanFixModel) mergeNext() {
+	next := m.cursor + 1
+	if next >= len(m.commits) || m.dropped[m.cursor] || m.dropped[next] {
+		return
+	}
+
+	commit := &m.commits[m.cursor]
+	seen := make(map[string]bool, len(commit.Files))
+	for _, f := range commit.Files {
+		seen[f] = true
+	}
+	for _, f := range m.commits[next].Files {
+		if !seen[f] {
+			commit.Files = append(commit.Files, f)
+			seen[f] = true
+		}
+	}
+
+	m.commits = append(m.commits[:next], m.commits[next+1:]...)
+	m.dropped = append(m.dropped[:next], m.dropped[next+1:]...)
+	m.revalidate()
+}
+
+// splitCommit moves files out of the commit under the cursor into a new commit
+// inserted after it. Files not in the commit are ignored, and at least one file
+// must stay behind. The new commit inherits the type and scope; its message is
+// generated by the splitter when one is set.
+func (m *PlanFixModel) splitCommit(files []string) tea.Cmd {
+	commit := &m.commits[m.cursor]
+
+	moving := make(map[string]bool, len(files))
+	for _, f := range files {
+		moving[f] = true
+	}
+
+	var kept, moved []string
+	for _, f := range commit.Files {
+		if moving[f] {
+			moved = append(moved, f)
+		} else {
+			kept = append(kept, f)
+		}
+	}
+	if len(moved) == 0 || len(kept) == 0 {
+		return nil
+	}
+
+	commit.Files = kept
+	index := m.cursor + 1
+	split := types.PlannedCommit{Type: commit.Type, Scope: commit.Scope, Files: moved}
+
+	m.commits = append(m.commits[:index], append([]types.PlannedCommit{split}, m.commits[index:]...)...)
+	m.dropped = append(m.dropped[:index], append([]bool{false}, m.dropped[index:]...)...)
+	m.cursor = index
+	m.splitErr = ""
+
+	if m.split == nil {
+		m.revalidate()
+		return nil
+	}
+
+	m.splitting = true
+	splitter := m.split
+	return func() tea.Msg {
+		commit, err := splitter(moved)
+		return splitDoneMsg{index: index, commit: commit, err: err}
+	}
+}
+
+// openEditor opens the commit under the cursor in the external editor.
+func (m *PlanFixModel) openEditor() tea.Cmd {
+	if m.cursor >= len(m.commits) || m.dropped[m.cursor] {
+		return nil
+	}
+
+	index := m.cursor
+	cmd, read, err := m.editor.Command(m.commits[index])
+	if err != nil {
+		m.editorErr = err.Error()
+		return nil
+	}
+	m.editorErr = ""
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		if err != nil {
+			return editorDoneMsg{index: index, err: err}
+		}
+		commit, err := read()
+		return editorDoneMsg{index: index, commit: commit, err: err}
+	})
+}
+
+// applyEditor stores a commit edited in the external editor.
+func (m *PlanFixModel) applyEditor(done editorDoneMsg) {
+	if done.err != nil {
+		m.editorErr = done.err.Error()
+	} else if done.index < len(m.commits) {
+		m.commits[done.index] = done.commit
+	}
+	m.revalidate()
+}
+
+// applySplit stores the generated type, scope and message for a split-off commit.
+func (m *PlanFixModel) applySplit(done splitDoneMsg) {
+	m.splitting = false
+	if done.err != nil {
+		m.splitErr = done.err.Error()
+	} else if done.index < len(m.commits) {
+		commit := &m.commits[done.index]
+		if done.commit.Type != "" {
+			commit.Type = done.commit.Type
+		}
+		commit.Scope = done.commit.Scope
+		commit.Message = done.commit.Message
+	}
+	m.revalidate()
+}
+
+// splitFiles parses a comma-separated file list, skipping empty entries.
+func splitFiles(value string) []string {
+	var files []string
+	for _, f := range strings.Split(value, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			files = append(files, f)
+		}
+	}
+	return files
+}
+
+// revalidate validates the kept commits and records their issues against the
+// original commit indices. It reports whether the plan is now valid.
+func (m *PlanFixModel) revalidate() bool {
+	plan, indices := m.keptPlan()
+
+	m.issues = make(map[int][]string)
+	m.planIssues = nil
+	m.validations++
+
+	for _, issue := range m.validate(plan) {
+		if issue.Commit < 0 || issue.Commit >= len(indices) {
+			m.planIssues = append(m.planIssues, issue.Message)
+			continue
+		}
+		i := indices[issue.Commit]
+		m.issues[i] = append(m.issues[i], issue.Message)
+	}
+
+	return len(m.issues) == 0 && len(m.planIssues) == 0
+}
+
+// keptPlan returns the plan without dropped commits, along with the original
+// index of each remaining commit.
+func (m *PlanFixModel) keptPlan() (*types.CommitPlan, []int) {
+	plan := &types.CommitPlan{}
+	var indices []int
+	for i, c := range m.commits {
+		if m.dropped[i] {
+			continue
+		}
+		plan.Commits = append(plan.Commits, c)
+		indices = append(indices, i)
+	}
+	return plan, indices
+}
+
+// View implements tea.Model.
+func (m *PlanFixModel) View() string {
+	if m.editing != fixNone {
+		return m.renderEdit()
+	}
+
+	var s string
+	if m.review {
+		s += m.styles.Title.Render("Review the commit plan:") + "\n\n"
+	} else {
+		s += m.styles.Title.Render("Plan failed validation. Fix or drop the marked commits:") + "\n\n"
+	}
+
+	for i, c := range m.commits {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = m.styles.Cursor.Render("")
+		}
+
+		header := commitHeader(c)
+		switch {
+		case m.dropped[i]:
+			s += cursor + m.styles.OpDrop.Render("drop") + m.styles.ListItemDimmed.Render(header) + "\n"
+			continue
+		case len(m.issues[i]) > 0:
+			s += cursor + m.styles.Error.Render("✗ ") + m.styles.CommitMessage.Render(header) + "\n"
+		default:
+			s += cursor + m.styles.Success.Render("✓ ") + m.styles.CommitMessage.Render(header) + "\n"
+		}
+
+		s += "    " + m.styles.CommitMeta.Render(strings.Join(c.Files, ", ")) + "\n"
+		for _, issue := range m.issues[i] {
+			s += "    " + m.styles.Error.Render("• "+issue) + "\n"
+		}
+	}
+
+	for _, issue := range m.planIssues {
+		s += "\n" + m.styles.Error.Render("• "+issue)
+	}
+	if m.splitting {
+		s += "\n" + m.styles.Subtle.Render("Generating a message for the split-off commit...")
+	}
+	if m.splitErr != "" {
+		s += "\n" + m.styles.Error.Render("• Message generation failed: "+m.splitErr)
+	}
+	if m.editorErr != "" {
+		s += "\n" + m.styles.Error.Render("• Editor: "+m.editorErr)
+	}
+
+	s += "\n"
+	s += m.styles.HelpKey.Render("↑/↓") + m.styles.HelpDesc.Render(" navigate  ")
+	if m.review {
+		s += m.styles.HelpKey.Render("K/J") + m.styles.HelpDesc.Render(" move  ")
+		s += m.styles.HelpKey.Render("m") + m.styles.HelpDesc.Render(" merge next  ")
+		s += m.styles.HelpKey.Render("s") + m.styles.HelpDesc.Render(" split  ")
+	}
+	s += m.styles.HelpKey.Render("t") + m.styles.HelpDesc.Render(" type  ")
+	s += m.styles.HelpKey.Render("e") + m.styles.HelpDesc.Render(" message  ")
+	if m.editor != nil {
+		s += m.styles.HelpKey.Render("E") + m.styles.HelpDesc.Render(" editor  ")
+	}
+	s += m.styles.HelpKey.Render("f") + m.styles.HelpDesc.Render(" files  ")
+	s += m.styles.HelpKey.Render("d") + m.styles.HelpDesc.Render(" drop  ")
+	s += m.styles.HelpKey.Render("enter") + m.styles.HelpDesc.Render(" re-validate  ")
+	s += m.styles.HelpKey.Render("q") + m.styles.HelpDesc.Render(" cancel")
+
+	return s
+}
+
+// renderEdit renders the inline field editor.
+func (m *PlanFixModel) renderEdit() string {
+	label := map[fixField]string{fixType: "type", fixMessage: "message", fixFiles: "files"}[m.editing]
+
+	var s string
+	if m.editing == fixSplit {
+		commit := m.commits[m.cursor]
+		s += m.styles.Title.Render(fmt.Sprintf("Split %s:", commitHeader(commit))) + "\n\n"
+		s += m.styles.CommitMeta.Render(strings.Join(commit.Files, ", ")) + "\n"
+		s += m.styles.Subtle.Render("Move to new commit: ") + m.input.View() + "\n\n"
+		s += m.styles.HelpKey.Render("enter") + m.styles.HelpDesc.Render(" split  ")
+		s += m.styles.HelpKey.Render("esc") + m.styles.HelpDesc.Render(" cancel")
+		return s
+	}
+
+	s += m.styles.Title.Render(fmt.Sprintf("Edit %s for %s:", label, commitHeader(m.commits[m.cursor]))) + "\n\n"
+	for _, issue := range m.issues[m.cursor] {
+		s += m.styles.Error.Render("• "+issue) + "\n"
+	}
+	s += m.styles.Subtle.Render("New: ") + m.input.View() + "\n\n"
+	s += m.styles.HelpKey.Render("enter") + m.styles.HelpDesc.Render(" save  ")
+	s += m.styles.HelpKey.Render("esc") + m.styles.HelpDesc.Render(" cancel")
+
+	return s
+}
+
+// commitHeader formats a planned commit as "type(scope): message".
+func commitHeader(c types.PlannedCommit) string {
+	if c.Scope != nil && *c.Scope != "" {
+		return fmt.Sprintf("%s(%s): %s", c.Type, *c.Scope, c.Message)
+	}
+	return fmt.Sprintf("%s: %s", c.Type, c.Message)
+}
+
+// Result returns the repaired plan once it validated, or false if the user cancelled.
+func (m *PlanFixModel) Result() (*types.CommitPlan, bool) {
+	if !m.done || m.cancelled {
+		return nil, false
+	}
+	plan, _ := m.keptPlan()
+	return plan, true
+}
+
+// RunPlanFix shows the fix-up screen for plan until it passes validate or the
+// user cancels. It returns the repaired plan and whether the user finished.
+func RunPlanFix(plan *types.CommitPlan, validate PlanValidator, editor MessageEditor) (*types.CommitPlan, bool, error) {
+	model := NewPlanFixModel(plan, validate, DefaultStyles(), DefaultKeyMap())
+	model.SetEditor(editor)
+	p := tea.NewProgram(model, tea.WithAltScreen())
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return nil, false, err
+	}
+
+	fixed, ok := finalModel.(*PlanFixModel).Result()
+	return fixed, ok, nil
+}
+
+// RunPlanReview shows plan for review before it's committed. The user can
+// reorder, merge, split, edit or drop commits; the result must pass validate.
+// It returns the reviewed plan and whether the user accepted it.
+func RunPlanReview(plan *types.CommitPlan, validate PlanValidator, split CommitSplitter, editor MessageEditor) (*types.CommitPlan, bool, error) {
+	model := NewPlanReviewModel(plan, validate, split, DefaultStyles(), DefaultKeyMap())
+	model.SetEditor(editor)
+	p := tea.NewProgram(model, tea.WithAltScreen())
+
+	finalModel, err := p.Run()
+	if err != nil {
+		return nil, false, err
+	}
+
+	reviewed, ok := finalModel.(*PlanFixModel).Result()
+	return reviewed, ok, nil
+}
@@ -0,0 +1,381 @@
+package interactive
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// testPlanValidator rejects the "bad" type, empty file lists and empty plans.
+func testPlanValidator(plan *types.CommitPlan) []PlanIssue {
+	if len(plan.Commits) == 0 {
+		return []PlanIssue{{Commit: -1, Message: "no commits in plan"}}
+	}
+	var issues []PlanIssue
+	for i, c := range plan.Commits {
+		if c.Type == "bad" {
+			issues = append(issues, PlanIssue{Commit: i, Message: "type not allowed"})
+		}
+		if len(c.Files) == 0 {
+			issues = append(issues, PlanIssue{Commit: i, Message: "commit has no files"})
+		}
+	}
+	return issues
+}
+
+func makeFixPlan() *types.CommitPlan {
+	return &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add login", Files: []string{"login.go"}},
+		{Type: "bad", Message: "tweak config", Files: []string{"config.go"}},
+	}}
+}
+
+func typeKeys(m *PlanFixModel, s string) {
+	for _, r := range s {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+}
+
+func TestPlanFixModel_StartsOnFailingCommit(t *testing.T) {
+	m := NewPlanFixModel(makeFixPlan(), testPlanValidator, DefaultStyles(), DefaultKeyMap())
+
+	if m.cursor != 1 {
+		t.Errorf("cursor = %d, want 1 (first failing commit)", m.cursor)
+	}
+	if len(m.issues[1]) != 1 || len(m.issues[0]) != 0 {
+		t.Errorf("issues = %v, want one issue on commit 1", m.issues)
+	}
+}
+
+func TestPlanFixModel_EditType(t *testing.T) {
+	plan := makeFixPlan()
+	m := NewPlanFixModel(plan, testPlanValidator, DefaultStyles(), DefaultKeyMap())
+
+	typeKeys(m, "t")
+	if m.editing != fixType || m.input.Value() != "bad" {
+		t.Fatalf("editing = %v, input = %q; want type editor prefilled", m.editing, m.input.Value())
+	}
+	m.input.SetValue("chore")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected quit after a successful re-validation")
+	}
+
+	fixed, ok := m.Result()
+	if !ok || len(fixed.Commits) != 2 || fixed.Commits[1].Type != "chore" {
+		t.Errorf("Result() = %+v, %v", fixed, ok)
+	}
+	if plan.Commits[1].Type != "bad" {
+		t.Error("original plan was modified")
+	}
+}
+
+func TestPlanFixModel_EditFiles(t *testing.T) {
+	m := NewPlanFixModel(makeFixPlan(), testPlanValidator, DefaultStyles(), DefaultKeyMap())
+	m.cursor = 0
+
+	typeKeys(m, "f")
+	m.input.SetValue("login.go, , auth.go")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	files := m.commits[0].Files
+	if len(files) != 2 || files[0] != "login.go" || files[1] != "auth.go" {
+		t.Errorf("files = %v, want [login.go auth.go]", files)
+	}
+
+	// Esc discards the edit
+	typeKeys(m, "e")
+	m.input.SetValue("discarded")
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.commits[0].Message != "add login" {
+		t.Errorf("message = %q, want unchanged after esc", m.commits[0].Message)
+	}
+}
+
+func TestPlanFixModel_DropAndRevalidate(t *testing.T) {
+	m := NewPlanFixModel(makeFixPlan(), testPlanValidator, DefaultStyles(), DefaultKeyMap())
+
+	// Drop the failing commit; the remaining plan is valid
+	typeKeys(m, "d")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	fixed, ok := m.Result()
+	if !ok || len(fixed.Commits) != 1 || fixed.Commits[0].Message != "add login" {
+		t.Errorf("Result() = %+v, %v", fixed, ok)
+	}
+}
+
+func TestPlanFixModel_IssuesMapToOriginalIndex(t *testing.T) {
+	plan := makeFixPlan()
+	plan.Commits[0].Files = nil
+	plan.Commits[1].Type = "fix"
+	m := NewPlanFixModel(plan, testPlanValidator, DefaultStyles(), DefaultKeyMap())
+
+	// Dropping commit 0 shifts commit 1 to index 0 in the validated plan
+	m.cursor = 0
+	typeKeys(m, "d")
+	m.cursor = 1
+	typeKeys(m, "f")
+	m.input.SetValue("")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if len(m.issues[1]) != 1 || len(m.issues[0]) != 0 {
+		t.Errorf("issues = %v, want issue reported on commit 1", m.issues)
+	}
+	if _, ok := m.Result(); ok {
+		t.Error("Result() should not succeed while issues remain")
+	}
+
+	// Dropping everything leaves a plan-level issue
+	typeKeys(m, "d")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.planIssues) != 1 {
+		t.Errorf("planIssues = %v, want one", m.planIssues)
+	}
+}
+
+func TestPlanFixModel_Cancel(t *testing.T) {
+	m := NewPlanFixModel(makeFixPlan(), testPlanValidator, DefaultStyles(), DefaultKeyMap())
+
+	typeKeys(m, "q")
+	if _, ok := m.Result(); ok {
+		t.Error("Result() should report cancellation")
+	}
+}
+
+func makeReviewPlan() *types.CommitPlan {
+	return &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add login", Files: []string{"login.go", "session.go"}},
+		{Type: "fix", Message: "handle timeout", Files: []string{"client.go"}},
+		{Type: "docs", Message: "document auth", Files: []string{"README.md", "session.go"}},
+	}}
+}
+
+func TestPlanReviewModel_Move(t *testing.T) {
+	m := NewPlanReviewModel(makeReviewPlan(), testPlanValidator, nil, DefaultStyles(), DefaultKeyMap())
+
+	typeKeys(m, "J")
+	if m.cursor != 1 || m.commits[1].Message != "add login" || m.commits[0].Message != "handle timeout" {
+		t.Errorf("after J: cursor = %d, commits = %+v", m.cursor, m.commits)
+	}
+
+	// Moving past the top is a no-op
+	typeKeys(m, "KK")
+	if m.cursor != 0 || m.commits[0].Message != "add login" {
+		t.Errorf("after KK: cursor = %d, commits = %+v", m.cursor, m.commits)
+	}
+
+	// Dropped flags travel with their commit
+	typeKeys(m, "dJ")
+	if !m.dropped[1] || m.dropped[0] {
+		t.Errorf("dropped = %v, want commit 1 dropped", m.dropped)
+	}
+}
+
+func TestPlanReviewModel_MergeNext(t *testing.T) {
+	m := NewPlanReviewModel(makeReviewPlan(), testPlanValidator, nil, DefaultStyles(), DefaultKeyMap())
+	m.cursor = 1
+
+	typeKeys(m, "m")
+	if len(m.commits) != 2 || len(m.dropped) != 2 {
+		t.Fatalf("commits = %+v, want 2 after merge", m.commits)
+	}
+	merged := m.commits[1]
+	if merged.Message != "handle timeout" || strings.Join(merged.Files, ",") != "client.go,README.md,session.go" {
+		t.Errorf("merged = %+v", merged)
+	}
+
+	// Nothing below the last commit to merge
+	typeKeys(m, "m")
+	if len(m.commits) != 2 {
+		t.Errorf("commits = %d, want merge at the bottom to be a no-op", len(m.commits))
+	}
+
+	// Duplicate files are kept once
+	m.cursor = 0
+	typeKeys(m, "m")
+	if files := m.commits[0].Files; len(files) != 4 {
+		t.Errorf("files = %v, want 4 unique files", files)
+	}
+}
+
+func TestPlanReviewModel_Split(t *testing.T) {
+	var gotFiles []string
+	split := func(files []string) (types.PlannedCommit, error) {
+		gotFiles = files
+		return types.PlannedCommit{Type: "refactor", Message: "extract session store"}, nil
+	}
+	m := NewPlanReviewModel(makeReviewPlan(), testPlanValidator, split, DefaultStyles(), DefaultKeyMap())
+
+	typeKeys(m, "s")
+	if m.editing != fixSplit {
+		t.Fatalf("editing = %v, want split editor", m.editing)
+	}
+	m.input.SetValue("session.go, unknown.go")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil || !m.splitting {
+		t.Fatal("expected message generation to start")
+	}
+
+	// Keys are ignored until the message arrives
+	typeKeys(m, "d")
+	if m.dropped[1] {
+		t.Error("drop applied while splitting")
+	}
+
+	m.Update(cmd())
+	if m.splitting {
+		t.Error("still splitting after result")
+	}
+	if strings.Join(gotFiles, ",") != "session.go" {
+		t.Errorf("splitter files = %v, want [session.go]", gotFiles)
+	}
+	if len(m.commits) != 4 || strings.Join(m.commits[0].Files, ",") != "login.go" {
+		t.Fatalf("commits = %+v", m.commits)
+	}
+	if c := m.commits[1]; c.Type != "refactor" || c.Message != "extract session store" || strings.Join(c.Files, ",") != "session.go" {
+		t.Errorf("split commit = %+v", c)
+	}
+	if m.cursor != 1 {
+		t.Errorf("cursor = %d, want on the new commit", m.cursor)
+	}
+}
+
+func TestPlanReviewModel_SplitWithoutSplitter(t *testing.T) {
+	m := NewPlanReviewModel(makeReviewPlan(), testPlanValidator, nil, DefaultStyles(), DefaultKeyMap())
+
+	// Moving every file out is refused
+	typeKeys(m, "s")
+	m.input.SetValue("login.go, session.go")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.commits) != 3 {
+		t.Fatalf("commits = %d, want split refused", len(m.commits))
+	}
+
+	typeKeys(m, "s")
+	m.input.SetValue("session.go")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd != nil {
+		t.Error("expected no command without a splitter")
+	}
+	if c := m.commits[1]; c.Type != "feat" || c.Message != "" {
+		t.Errorf("split commit = %+v, want inherited type and empty message", c)
+	}
+
+	// Single-file commits can't be split
+	m.cursor = 2
+	typeKeys(m, "s")
+	if m.editing != fixNone {
+		t.Error("split editor opened for a single-file commit")
+	}
+}
+
+func TestPlanReviewModel_SplitError(t *testing.T) {
+	split := func(files []string) (types.PlannedCommit, error) {
+		return types.PlannedCommit{}, errors.New("rate limited")
+	}
+	m := NewPlanReviewModel(makeReviewPlan(), testPlanValidator, split, DefaultStyles(), DefaultKeyMap())
+
+	typeKeys(m, "s")
+	m.input.SetValue("session.go")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m.Update(cmd())
+
+	if m.splitErr != "rate limited" {
+		t.Errorf("splitErr = %q", m.splitErr)
+	}
+	if !strings.Contains(m.View(), "rate limited") {
+		t.Error("view does not show the generation error")
+	}
+}
+
+func TestPlanReviewModel_Accept(t *testing.T) {
+	m := NewPlanReviewModel(makeReviewPlan(), testPlanValidator, nil, DefaultStyles(), DefaultKeyMap())
+
+	typeKeys(m, "J")
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if cmd == nil {
+		t.Fatal("expected quit on accept")
+	}
+
+	reviewed, ok := m.Result()
+	if !ok || len(reviewed.Commits) != 3 || reviewed.Commits[0].Message != "handle timeout" {
+		t.Errorf("Result() = %+v, %v", reviewed, ok)
+	}
+}
+
+func TestPlanFixModel_NoReorderOutsideReview(t *testing.T) {
+	m := NewPlanFixModel(makeReviewPlan(), testPlanValidator, DefaultStyles(), DefaultKeyMap())
+
+	typeKeys(m, "Jms")
+	if len(m.commits) != 3 || m.commits[0].Message != "add login" || m.editing != fixNone {
+		t.Errorf("fix-up screen changed structure: %+v", m.commits)
+	}
+}
+
+// fakeEditor "edits" a commit by applying edit when read back.
+type fakeEditor struct {
+	edit func(c types.PlannedCommit) types.PlannedCommit
+	err  error
+}
+
+func (e *fakeEditor) Command(c types.PlannedCommit) (*exec.Cmd, func() (types.PlannedCommit, error), error) {
+	if e.err != nil {
+		return nil, nil, e.err
+	}
+	return exec.Command("true"), func() (types.PlannedCommit, error) { return e.edit(c), nil }, nil
+}
+
+func TestPlanFixModel_ExternalEditor(t *testing.T) {
+	m := NewPlanFixModel(makeFixPlan(), testPlanValidator, DefaultStyles(), DefaultKeyMap())
+
+	// Without an editor the key does nothing
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'E'}}); cmd != nil {
+		t.Error("expected no command without an editor")
+	}
+
+	m.SetEditor(&fakeEditor{edit: func(c types.PlannedCommit) types.PlannedCommit {
+		c.Type = "chore"
+		c.Body = "Explained."
+		return c
+	}})
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'E'}})
+	if cmd == nil {
+		t.Fatal("expected the editor to be launched")
+	}
+
+	// Simulate the editor exiting
+	_, read, _ := m.editor.Command(m.commits[1])
+	edited, _ := read()
+	m.Update(editorDoneMsg{index: 1, commit: edited})
+
+	if c := m.commits[1]; c.Type != "chore" || c.Body != "Explained." {
+		t.Errorf("commit = %+v, want edited type and body", c)
+	}
+	if len(m.issues) != 0 {
+		t.Errorf("issues = %v, want revalidated plan", m.issues)
+	}
+}
+
+func TestPlanFixModel_ExternalEditorError(t *testing.T) {
+	m := NewPlanFixModel(makeFixPlan(), testPlanValidator, DefaultStyles(), DefaultKeyMap())
+	m.SetEditor(&fakeEditor{err: errors.New("no editor configured")})
+
+	typeKeys(m, "E")
+	if !strings.Contains(m.View(), "no editor configured") {
+		t.Error("view does not show the editor error")
+	}
+
+	m.Update(editorDoneMsg{index: 1, err: errors.New("empty commit message")})
+	if m.commits[1].Type != "bad" || m.editorErr != "empty commit message" {
+		t.Errorf("commit = %+v, editorErr = %q", m.commits[1], m.editorErr)
+	}
+}
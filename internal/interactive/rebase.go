@@ -3,14 +3,17 @@ package interactive
 import (
 	"fmt"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/planner"
 )
 
 // Rebaser handles git rebase execution.
 type Rebaser struct {
-	workDir string
+	workDir   string
+	autostash bool
 }
 
 // NewRebaser creates a new rebaser for the given directory.
@@ -18,11 +21,42 @@ func NewRebaser(workDir string) *Rebaser {
 	return &Rebaser{workDir: workDir}
 }
 
+// SetAutostash stashes uncommitted changes before the rebase and restores
+// them afterwards, instead of refusing to run on a dirty working tree.
+func (r *Rebaser) SetAutostash(enabled bool) {
+	r.autostash = enabled
+}
+
+// DirtyFiles returns tracked files with uncommitted changes, which would
+// otherwise make git refuse to rebase.
+func (r *Rebaser) DirtyFiles() []string {
+	cmd := git.Command("status", "--porcelain", "--untracked-files=no")
+	cmd.Dir = r.workDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if len(line) > 3 {
+			files = append(files, line[3:])
+		}
+	}
+	return files
+}
+
 // Execute runs the interactive rebase with the given plan.
 func (r *Rebaser) Execute(entries []RebaseEntry, baseCommit string) error {
 	if len(entries) == 0 {
 		return fmt.Errorf("no commits to rebase")
 	}
+	for _, entry := range entries {
+		if entry.Commit.IsMerge {
+			return &git.MergeCommitError{Hash: entry.Commit.Hash}
+		}
+	}
 
 	// Generate the todo list content
 	todoContent := r.generateTodo(entries)
@@ -42,13 +76,17 @@ func (r *Rebaser) Execute(entries []RebaseEntry, baseCommit string) error {
 	defer rewordCleanup()
 
 	// Run git rebase
-	var cmd *exec.Cmd
+	args := []string{"rebase", "-i"}
+	if r.autostash {
+		args = append(args, "--autostash")
+	}
 	if baseCommit == "" {
 		// Root commit selected - use --root flag
-		cmd = exec.Command("git", "rebase", "-i", "--root")
+		args = append(args, "--root")
 	} else {
-		cmd = exec.Command("git", "rebase", "-i", baseCommit)
+		args = append(args, baseCommit)
 	}
+	cmd := git.Command(args...)
 	cmd.Dir = r.workDir
 	cmd.Env = append(os.Environ(),
 		"GIT_SEQUENCE_EDITOR="+scriptPath,
@@ -62,12 +100,57 @@ func (r *Rebaser) Execute(entries []RebaseEntry, baseCommit string) error {
 	// Capture output for error messages
 	output, err := cmd.CombinedOutput()
 	if err != nil {
+		// A rebase that stopped on conflicts is left in progress for resolution
+		if files := r.ConflictedFiles(); len(files) > 0 {
+			return &ConflictError{Files: files, Output: string(output)}
+		}
 		return fmt.Errorf("rebase failed: %s\n%s", err, string(output))
 	}
 
 	return nil
 }
 
+// ConflictedFiles returns the paths with unresolved merge conflicts.
+func (r *Rebaser) ConflictedFiles() []string {
+	cmd := git.Command("diff", "--name-only", "--diff-filter=U")
+	cmd.Dir = r.workDir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			files = append(files, line)
+		}
+	}
+	return files
+}
+
+// Abort aborts an in-progress rebase, restoring the original branch.
+func (r *Rebaser) Abort() error {
+	cmd := git.Command("rebase", "--abort")
+	cmd.Dir = r.workDir
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to abort rebase: %s: %w", string(out), err)
+	}
+	return nil
+}
+
+// ConflictError indicates the rebase stopped on merge conflicts and is still in progress.
+type ConflictError struct {
+	Files  []string
+	Output string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("rebase stopped on conflicts in %d file(s): %s", len(e.Files), strings.Join(e.Files, ", "))
+}
+
 // generateTodo creates the git rebase todo list content.
 func (r *Rebaser) generateTodo(entries []RebaseEntry) string {
 	var lines []string
@@ -176,6 +259,9 @@ func (r *Rebaser) createRewordScript(entries []RebaseEntry) (string, func(), err
 	scriptLines = append(scriptLines, fmt.Sprintf(`COUNTER_FILE="%s"`, counterPath))
 	scriptLines = append(scriptLines, `N=$(cat "$COUNTER_FILE")`)
 	scriptLines = append(scriptLines, `echo $((N + 1)) > "$COUNTER_FILE"`)
+	// Keep the Gerrit Change-Id of the commit being reworded, so the new
+	// message still updates the same change
+	scriptLines = append(scriptLines, fmt.Sprintf(`CHANGE_ID=$(grep -m 1 '^%s: I' "$1")`, planner.TrailerChangeID))
 	scriptLines = append(scriptLines, "case $N in")
 
 	for i := range rewordMsgs {
@@ -184,6 +270,10 @@ func (r *Rebaser) createRewordScript(entries []RebaseEntry) (string, func(), err
 	}
 
 	scriptLines = append(scriptLines, "esac")
+	scriptLines = append(scriptLines, `if [ -n "$CHANGE_ID" ]; then`)
+	scriptLines = append(scriptLines, `  [ -n "$(tail -c 1 "$1")" ] && echo >> "$1"`)
+	scriptLines = append(scriptLines, `  git interpret-trailers --in-place --if-exists doNothing --trailer "$CHANGE_ID" "$1"`)
+	scriptLines = append(scriptLines, "fi")
 
 	scriptContent := strings.Join(scriptLines, "\n") + "\n"
 	scriptPath := filepath.Join(tmpDir, "editor.sh")
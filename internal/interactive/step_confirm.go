@@ -14,6 +14,7 @@ type ConfirmModel struct {
 	entries    []RebaseEntry
 	baseCommit string
 	gitRoot    string
+	autostash  bool // --force-dirty: stash uncommitted changes around the rebase
 	cursor     int
 	styles     Styles
 	keys       KeyMap
@@ -72,33 +73,47 @@ func (m *ConfirmModel) Update(msg tea.Msg) (*ConfirmModel, tea.Cmd) {
 			}
 
 		case key.Matches(msg, m.keys.Enter):
-			switch m.cursor {
-			case 0: // Execute
-				// Check if we need to prompt for squash messages
-				if needsSquashPrompt, parentIdx := m.needsSquashMessagePrompt(); needsSquashPrompt {
-					return m, m.startSquashMsgEdit(parentIdx)
-				}
-				return m, m.executeRebase()
-			case 1: // Go back
-				return m, func() tea.Msg {
-					return ConfirmBackMsg{}
-				}
-			case 2: // Cancel
-				return m, func() tea.Msg {
-					return ConfirmDoneMsg{Executed: false}
-				}
-			}
+			return m, m.choose(m.cursor)
 
-		case key.Matches(msg, m.keys.Back):
-			return m, func() tea.Msg {
-				return ConfirmBackMsg{}
-			}
+		// Single keys skip the cursor: y executes, n/b/e go back to editing
+		case key.Matches(msg, m.keys.Yes):
+			return m, m.choose(confirmExecute)
+
+		case key.Matches(msg, m.keys.No), key.Matches(msg, m.keys.Back), key.Matches(msg, m.keys.EditMsg):
+			return m, m.choose(confirmBack)
 		}
 	}
 
 	return m, nil
 }
 
+// Options on the confirm step, in display order.
+const (
+	confirmExecute = iota
+	confirmBack
+	confirmCancel
+)
+
+// choose acts on one of the confirm step's options.
+func (m *ConfirmModel) choose(option int) tea.Cmd {
+	switch option {
+	case confirmExecute:
+		// Check if we need to prompt for squash messages
+		if needsSquashPrompt, parentIdx := m.needsSquashMessagePrompt(); needsSquashPrompt {
+			return m.startSquashMsgEdit(parentIdx)
+		}
+		return m.executeRebase()
+	case confirmBack:
+		return func() tea.Msg {
+			return ConfirmBackMsg{}
+		}
+	default:
+		return func() tea.Msg {
+			return ConfirmDoneMsg{Executed: false}
+		}
+	}
+}
+
 // needsSquashMessagePrompt checks if any squash group needs a combined message.
 // Returns true and the index of the first parent that needs prompting.
 func (m *ConfirmModel) needsSquashMessagePrompt() (bool, int) {
@@ -194,9 +209,11 @@ func (m *ConfirmModel) executeRebase() tea.Cmd {
 	entries := m.entries
 	baseCommit := m.baseCommit
 	gitRoot := m.gitRoot
+	autostash := m.autostash
 
 	return func() tea.Msg {
 		rebaser := NewRebaser(gitRoot)
+		rebaser.SetAutostash(autostash)
 		err := rebaser.Execute(entries, baseCommit)
 		if err != nil {
 			return ConfirmDoneMsg{Executed: false, Err: err}
@@ -252,22 +269,29 @@ func (m *ConfirmModel) View() string {
 		s += fmt.Sprintf("  %s%s %s %s%s\n", indent, opStr, hash, msgStyled, suffix)
 	}
 
+	if m.autostash {
+		s += "\n" + m.styles.Warning.Render("Uncommitted changes will be stashed and restored after the rebase (--force-dirty).") + "\n"
+	}
+
 	// Options
 	s += "\n"
 	options := []string{"Execute rebase", "Go back and make changes", "Cancel"}
+	shortcuts := []string{"y", "n", "q"}
 	for i, opt := range options {
 		cursor := "  "
 		if i == m.cursor {
 			cursor = m.styles.Cursor.Render("")
 		}
-		s += fmt.Sprintf("%s%d. %s\n", cursor, i+1, opt)
+		s += fmt.Sprintf("%s%d. %s %s\n", cursor, i+1, opt, m.styles.HelpKey.Render("("+shortcuts[i]+")"))
 	}
 
 	// Help bar
 	s += "\n"
+	s += m.styles.HelpKey.Render("y") + m.styles.HelpDesc.Render(" execute  ")
+	s += m.styles.HelpKey.Render("n/b") + m.styles.HelpDesc.Render(" back  ")
+	s += m.styles.HelpKey.Render("q") + m.styles.HelpDesc.Render(" cancel  ")
 	s += m.styles.HelpKey.Render("↑/↓") + m.styles.HelpDesc.Render(" navigate  ")
-	s += m.styles.HelpKey.Render("enter") + m.styles.HelpDesc.Render(" select  ")
-	s += m.styles.HelpKey.Render("b") + m.styles.HelpDesc.Render(" back")
+	s += m.styles.HelpKey.Render("enter") + m.styles.HelpDesc.Render(" select")
 
 	return s
 }
@@ -0,0 +1,37 @@
+package interactive
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func runeKey(r rune) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}}
+}
+
+func TestConfirmModel_SingleKeys(t *testing.T) {
+	entries := []RebaseEntry{
+		{Commit: RebaseCommit{Hash: "hash0", ShortHash: "abc0000", Message: "commit 0"}, Operation: OpPick},
+		{Commit: RebaseCommit{Hash: "hash1", ShortHash: "abc1111", Message: "commit 1"}, Operation: OpSquash},
+	}
+
+	for _, r := range []rune{'n', 'b', 'e'} {
+		m := NewConfirmModel(entries, "base", t.TempDir(), DefaultStyles(), DefaultKeyMap())
+		_, cmd := m.Update(runeKey(r))
+		if cmd == nil {
+			t.Fatalf("%q: expected a command", r)
+		}
+		if _, ok := cmd().(ConfirmBackMsg); !ok {
+			t.Errorf("%q: expected ConfirmBackMsg", r)
+		}
+	}
+
+	// y executes without moving the cursor, starting with the squash message
+	m := NewConfirmModel(entries, "base", t.TempDir(), DefaultStyles(), DefaultKeyMap())
+	m.cursor = confirmCancel
+	m, _ = m.Update(runeKey('y'))
+	if !m.editingSquashMsg || m.squashParentIdx != 0 {
+		t.Errorf("expected y to prompt for the squash message, got editing=%v parent=%d", m.editingSquashMsg, m.squashParentIdx)
+	}
+}
@@ -0,0 +1,272 @@
+package interactive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// suggestTimeout bounds a single conflict suggestion request.
+const suggestTimeout = 90 * time.Second
+
+// ConflictModel handles the conflict resolution step after a rebase stops.
+type ConflictModel struct {
+	gitRoot   string
+	files     []string
+	resolved  map[string]bool
+	cursor    int
+	suggester ConflictSuggester
+	styles    Styles
+	keys      KeyMap
+
+	// State for the selected file
+	current    *ConflictFile
+	suggestion *ConflictSuggestion
+	loading    bool
+	confirming bool
+	status     string
+	err        error
+}
+
+// ConflictDoneMsg is sent when the user leaves the conflict step.
+type ConflictDoneMsg struct {
+	// Aborted is true when the rebase was aborted and the branch restored
+	Aborted bool
+	Err     error
+}
+
+// conflictSuggestionMsg carries the result of an asynchronous suggestion request.
+type conflictSuggestionMsg struct {
+	path       string
+	file       *ConflictFile
+	suggestion *ConflictSuggestion
+	err        error
+}
+
+// NewConflictModel creates a new conflict model.
+func NewConflictModel(gitRoot string, files []string, suggester ConflictSuggester, styles Styles, keys KeyMap) *ConflictModel {
+	return &ConflictModel{
+		gitRoot:   gitRoot,
+		files:     files,
+		resolved:  make(map[string]bool),
+		suggester: suggester,
+		styles:    styles,
+		keys:      keys,
+	}
+}
+
+// Update implements tea.Model.
+func (m *ConflictModel) Update(msg tea.Msg) (*ConflictModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case conflictSuggestionMsg:
+		if len(m.files) == 0 || msg.path != m.files[m.cursor] {
+			// Stale result for a file no longer selected
+			return m, nil
+		}
+		m.loading = false
+		m.current = msg.file
+		m.suggestion = msg.suggestion
+		m.err = msg.err
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.loading {
+			return m, nil
+		}
+
+		// Applying a suggestion always requires an explicit yes
+		if m.confirming {
+			switch {
+			case key.Matches(msg, m.keys.Yes):
+				m.confirming = false
+				return m, m.apply()
+			case key.Matches(msg, m.keys.No), key.Matches(msg, m.keys.Back):
+				m.confirming = false
+				m.status = "Suggestion not applied."
+			}
+			return m, nil
+		}
+
+		switch {
+		case key.Matches(msg, m.keys.Up):
+			if m.cursor > 0 {
+				m.cursor--
+				m.resetSelection()
+			}
+
+		case key.Matches(msg, m.keys.Down):
+			if m.cursor < len(m.files)-1 {
+				m.cursor++
+				m.resetSelection()
+			}
+
+		case key.Matches(msg, m.keys.Suggest):
+			if m.suggester == nil {
+				m.status = "Suggestions unavailable: no LLM provider configured."
+				return m, nil
+			}
+			if len(m.files) == 0 || m.resolved[m.files[m.cursor]] {
+				return m, nil
+			}
+			m.loading = true
+			m.err = nil
+			m.status = ""
+			return m, m.suggest(m.files[m.cursor])
+
+		case key.Matches(msg, m.keys.Apply):
+			if m.suggestion != nil && m.current != nil && !m.resolved[m.current.Path] {
+				// The suggestion covers only what the LLM saw of each hunk
+				if m.current.Truncated() {
+					m.status = (&TruncatedConflictError{Path: m.current.Path}).Error() + "."
+					return m, nil
+				}
+				m.confirming = true
+			}
+
+		case key.Matches(msg, m.keys.Abort):
+			return m, m.abort()
+
+		case key.Matches(msg, m.keys.Enter):
+			return m, func() tea.Msg {
+				return ConflictDoneMsg{}
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// resetSelection clears per-file state when the cursor moves.
+func (m *ConflictModel) resetSelection() {
+	m.current = nil
+	m.suggestion = nil
+	m.err = nil
+	m.status = ""
+}
+
+// suggest loads the conflict for path and asks the provider for a resolution.
+func (m *ConflictModel) suggest(path string) tea.Cmd {
+	gitRoot := m.gitRoot
+	suggester := m.suggester
+
+	return func() tea.Msg {
+		file, err := LoadConflictFile(gitRoot, path)
+		if err != nil {
+			return conflictSuggestionMsg{path: path, err: err}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), suggestTimeout)
+		defer cancel()
+
+		suggestion, err := SuggestResolution(ctx, suggester, file)
+		return conflictSuggestionMsg{path: path, file: file, suggestion: suggestion, err: err}
+	}
+}
+
+// apply writes the confirmed suggestion and marks the file resolved.
+func (m *ConflictModel) apply() tea.Cmd {
+	if err := ApplyResolution(m.gitRoot, m.current, m.suggestion); err != nil {
+		m.err = err
+		return nil
+	}
+
+	m.resolved[m.current.Path] = true
+	m.status = fmt.Sprintf("Applied suggestion to %s and staged it.", m.current.Path)
+	return nil
+}
+
+// abort aborts the in-progress rebase.
+func (m *ConflictModel) abort() tea.Cmd {
+	gitRoot := m.gitRoot
+
+	return func() tea.Msg {
+		if err := NewRebaser(gitRoot).Abort(); err != nil {
+			return ConflictDoneMsg{Err: err}
+		}
+		return ConflictDoneMsg{Aborted: true}
+	}
+}
+
+// View renders the conflict view.
+func (m *ConflictModel) View() string {
+	var s string
+	s += m.styles.Warning.Render("Rebase stopped on conflicts") + "\n\n"
+
+	for i, file := range m.files {
+		cursor := "  "
+		if i == m.cursor {
+			cursor = m.styles.Cursor.Render("")
+		}
+		marker := m.styles.Error.Render("✗")
+		if m.resolved[file] {
+			marker = m.styles.Success.Render("✓")
+		}
+		s += fmt.Sprintf("%s%s %s\n", cursor, marker, file)
+	}
+	s += "\n"
+
+	switch {
+	case m.loading:
+		s += m.styles.Subtle.Render("Asking for a suggested resolution...") + "\n\n"
+	case m.err != nil:
+		s += m.styles.Error.Render("Error: "+m.err.Error()) + "\n\n"
+	case m.suggestion != nil && m.current != nil:
+		s += m.renderSuggestion()
+	}
+
+	if m.status != "" {
+		s += m.styles.Subtle.Render(m.status) + "\n\n"
+	}
+
+	if m.confirming {
+		s += m.styles.Warning.Render(fmt.Sprintf("Apply suggested resolution to %s? ", m.current.Path))
+		s += m.styles.HelpKey.Render("y") + m.styles.HelpDesc.Render("/") + m.styles.HelpKey.Render("n")
+		return s
+	}
+
+	s += m.styles.Subtle.Render("Suggestions are never applied without confirmation.") + "\n"
+	s += m.styles.Subtle.Render("Press enter to exit and finish with 'git rebase --continue'.") + "\n\n"
+	s += m.styles.HelpKey.Render("↑/↓") + m.styles.HelpDesc.Render(" navigate  ")
+	s += m.styles.HelpKey.Render("x") + m.styles.HelpDesc.Render(" suggest  ")
+	s += m.styles.HelpKey.Render("a") + m.styles.HelpDesc.Render(" apply  ")
+	s += m.styles.HelpKey.Render("A") + m.styles.HelpDesc.Render(" abort rebase  ")
+	s += m.styles.HelpKey.Render("enter") + m.styles.HelpDesc.Render(" exit")
+
+	return s
+}
+
+// renderSuggestion renders each hunk with its suggested resolution and rationale.
+func (m *ConflictModel) renderSuggestion() string {
+	var s string
+	s += m.styles.Title.Render("Suggested resolution for "+m.current.Path+":") + "\n\n"
+	if m.current.Truncated() {
+		s += m.styles.Warning.Render("Some hunks were cut short for the LLM; this suggestion can't be applied.") + "\n\n"
+	}
+
+	for _, h := range m.suggestion.Hunks {
+		hunk := m.current.Hunks[h.Hunk]
+		s += m.styles.Subtle.Render(fmt.Sprintf("Hunk %d", h.Hunk+1)) + "\n"
+		s += m.styles.OpDrop.Render(indentLines("ours   | ", hunk.Ours)) + "\n"
+		s += m.styles.OpSquash.Render(indentLines("theirs | ", hunk.Theirs)) + "\n"
+		s += m.styles.Success.Render(indentLines("result | ", h.Resolution)) + "\n"
+		if h.Rationale != "" {
+			s += m.styles.Subtle.Render("Why: "+h.Rationale) + "\n"
+		}
+		s += "\n"
+	}
+
+	return s
+}
+
+// indentLines prefixes every line of s.
+func indentLines(prefix, s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = prefix + line
+	}
+	return strings.Join(lines, "\n")
+}
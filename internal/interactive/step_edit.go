@@ -7,6 +7,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dsswift/commit/internal/git"
 )
 
 // EditModel handles the combined reorder and operations step.
@@ -20,6 +21,31 @@ type EditModel struct {
 	editingMessage bool
 	messageInput   textinput.Model
 	editingIndex   int
+
+	// Per-commit details, fetched when first expanded
+	collector *git.Collector
+	expanded  map[string]bool
+	details   map[string]*commitDetails
+	diffErr   string
+}
+
+// commitDetails holds the files a commit changed, or why they couldn't be read.
+type commitDetails struct {
+	loading bool
+	files   []git.FileStat
+	err     error
+}
+
+// commitDetailsMsg carries the files of a commit loaded for the detail view.
+type commitDetailsMsg struct {
+	hash  string
+	files []git.FileStat
+	err   error
+}
+
+// diffClosedMsg is sent when the diff pager exits.
+type diffClosedMsg struct {
+	err error
 }
 
 // EditDoneMsg is sent when the user completes editing.
@@ -43,9 +69,17 @@ func NewEditModel(entries []RebaseEntry, styles Styles, keys KeyMap) *EditModel
 		styles:       styles,
 		keys:         keys,
 		messageInput: ti,
+		expanded:     make(map[string]bool),
+		details:      make(map[string]*commitDetails),
 	}
 }
 
+// SetCollector enables the detail view and diff pager, which read commits
+// through collector.
+func (m *EditModel) SetCollector(collector *git.Collector) {
+	m.collector = collector
+}
+
 // Update implements tea.Model.
 func (m *EditModel) Update(msg tea.Msg) (*EditModel, tea.Cmd) {
 	// Handle inline message editing
@@ -54,6 +88,17 @@ func (m *EditModel) Update(msg tea.Msg) (*EditModel, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case commitDetailsMsg:
+		m.details[msg.hash] = &commitDetails{files: msg.files, err: msg.err}
+		return m, nil
+
+	case diffClosedMsg:
+		m.diffErr = ""
+		if msg.err != nil {
+			m.diffErr = msg.err.Error()
+		}
+		return m, nil
+
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keys.Up):
@@ -66,6 +111,12 @@ func (m *EditModel) Update(msg tea.Msg) (*EditModel, tea.Cmd) {
 				m.cursor++
 			}
 
+		case key.Matches(msg, m.keys.Details):
+			return m, m.toggleDetails()
+
+		case key.Matches(msg, m.keys.Diff):
+			return m, m.openDiff()
+
 		case key.Matches(msg, m.keys.MoveUp):
 			m.moveUp()
 
@@ -149,6 +200,38 @@ func (m *EditModel) startMessageEdit() {
 	m.messageInput.CursorEnd()
 }
 
+// toggleDetails expands or collapses the details of the entry under the
+// cursor, loading its files the first time.
+func (m *EditModel) toggleDetails() tea.Cmd {
+	if m.collector == nil || m.cursor >= len(m.entries) {
+		return nil
+	}
+
+	hash := m.entries[m.cursor].Commit.Hash
+	m.expanded[hash] = !m.expanded[hash]
+	if !m.expanded[hash] || m.details[hash] != nil {
+		return nil
+	}
+
+	m.details[hash] = &commitDetails{loading: true}
+	collector := m.collector
+	return func() tea.Msg {
+		files, err := collector.CommitFileStats(hash)
+		return commitDetailsMsg{hash: hash, files: files, err: err}
+	}
+}
+
+// openDiff shows the diff of the entry under the cursor in git's pager.
+func (m *EditModel) openDiff() tea.Cmd {
+	if m.collector == nil || m.cursor >= len(m.entries) {
+		return nil
+	}
+	cmd := m.collector.ShowCommand(m.entries[m.cursor].Commit.Hash)
+	return tea.ExecProcess(cmd.Cmd, func(err error) tea.Msg {
+		return diffClosedMsg{err: err}
+	})
+}
+
 // moveUp moves the current entry up in the list.
 func (m *EditModel) moveUp() {
 	if m.cursor <= 0 {
@@ -232,6 +315,14 @@ func (m *EditModel) View() string {
 
 		line := fmt.Sprintf("%s%s%s %s %s%s", cursor, indent, opStr, hash, msgStyled, editMark)
 		s += line + "\n"
+
+		if m.expanded[entry.Commit.Hash] {
+			s += m.renderDetails(entry.Commit)
+		}
+	}
+
+	if m.diffErr != "" {
+		s += "\n" + m.styles.Error.Render("Diff: "+m.diffErr) + "\n"
 	}
 
 	// Help bar
@@ -239,7 +330,12 @@ func (m *EditModel) View() string {
 	s += m.styles.HelpKey.Render("↑/↓") + m.styles.HelpDesc.Render(" navigate  ")
 	s += m.styles.HelpKey.Render("K/J") + m.styles.HelpDesc.Render(" move  ")
 	s += m.styles.HelpKey.Render("tab") + m.styles.HelpDesc.Render(" cycle op  ")
-	s += m.styles.HelpKey.Render("e") + m.styles.HelpDesc.Render(" edit msg\n")
+	s += m.styles.HelpKey.Render("e") + m.styles.HelpDesc.Render(" edit msg  ")
+	if m.collector != nil {
+		s += m.styles.HelpKey.Render("v") + m.styles.HelpDesc.Render(" details  ")
+		s += m.styles.HelpKey.Render("o") + m.styles.HelpDesc.Render(" diff")
+	}
+	s += "\n"
 	s += m.styles.HelpKey.Render("p") + m.styles.HelpDesc.Render(" pick  ")
 	s += m.styles.HelpKey.Render("s") + m.styles.HelpDesc.Render(" squash  ")
 	s += m.styles.HelpKey.Render("r") + m.styles.HelpDesc.Render(" reword  ")
@@ -250,6 +346,32 @@ func (m *EditModel) View() string {
 	return s
 }
 
+// renderDetails renders the files an expanded entry's commit changed.
+func (m *EditModel) renderDetails(commit RebaseCommit) string {
+	const indent = "        "
+	d := m.details[commit.Hash]
+	switch {
+	case d == nil || d.loading:
+		return indent + m.styles.Subtle.Render("Loading files...") + "\n"
+	case d.err != nil:
+		return indent + m.styles.Error.Render(d.err.Error()) + "\n"
+	}
+
+	var s string
+	added, removed := 0, 0
+	for _, f := range d.files {
+		stat := "binary"
+		if !f.Binary {
+			stat = fmt.Sprintf("+%d -%d", f.Added, f.Removed)
+			added += f.Added
+			removed += f.Removed
+		}
+		s += indent + f.Path + " " + m.styles.Subtle.Render(stat) + "\n"
+	}
+	s += indent + m.styles.Subtle.Render(fmt.Sprintf("%d files changed, +%d -%d", len(d.files), added, removed)) + "\n"
+	return s
+}
+
 // renderMessageEdit renders the inline message editing overlay.
 func (m *EditModel) renderMessageEdit() string {
 	entry := m.entries[m.editingIndex]
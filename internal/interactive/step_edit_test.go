@@ -5,6 +5,8 @@ import (
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/testutil"
 )
 
 func makeTestEntries() []RebaseEntry {
@@ -348,6 +350,61 @@ func TestEditModel_View_ShowsHelp(t *testing.T) {
 	}
 }
 
+func TestEditModel_Details(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "a.txt", "one\n")
+	testutil.CreateFile(t, repoDir, "b.txt", "two\nthree\n")
+	testutil.GitAdd(t, repoDir, "a.txt", "b.txt")
+	hash := testutil.GitCommit(t, repoDir, "feat: add files")
+
+	entries := []RebaseEntry{{
+		Commit:    RebaseCommit{Hash: hash, ShortHash: hash, Message: "feat: add files", Date: time.Now()},
+		Operation: OpPick,
+	}}
+	model := NewEditModel(entries, DefaultStyles(), DefaultKeyMap())
+	model.SetCollector(git.NewCollector(repoDir))
+
+	details := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}}
+	_, cmd := model.Update(details)
+	if cmd == nil {
+		t.Fatal("expected a command loading the commit's files")
+	}
+	if view := model.View(); !contains(view, "Loading files") {
+		t.Errorf("expected a loading line while files are fetched, got:\n%s", view)
+	}
+
+	model.Update(cmd())
+	view := model.View()
+	for _, want := range []string{"a.txt +1 -0", "b.txt +2 -0", "2 files changed, +3 -0"} {
+		if !contains(view, want) {
+			t.Errorf("view should contain %q, got:\n%s", want, view)
+		}
+	}
+
+	// Collapsing and expanding again reuses the loaded files
+	model.Update(details)
+	if contains(model.View(), "a.txt") {
+		t.Error("expected details to collapse")
+	}
+	if _, cmd := model.Update(details); cmd != nil {
+		t.Error("expected loaded details not to be fetched again")
+	}
+	if !contains(model.View(), "a.txt") {
+		t.Error("expected details to expand again")
+	}
+}
+
+func TestEditModel_Details_NoCollector(t *testing.T) {
+	model := NewEditModel(makeTestEntries(), DefaultStyles(), DefaultKeyMap())
+
+	if _, cmd := model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'v'}}); cmd != nil {
+		t.Error("expected no command without a collector")
+	}
+	if contains(model.View(), "details") {
+		t.Error("help bar should not offer details without a collector")
+	}
+}
+
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
 }
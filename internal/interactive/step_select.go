@@ -2,7 +2,6 @@ package interactive
 
 import (
 	"fmt"
-	"os/exec"
 	"strings"
 	"time"
 
@@ -24,6 +23,7 @@ type SelectModel struct {
 	loading   bool
 	hasMore   bool
 	err       error
+	notice    string // why the last selection was refused
 	styles    Styles
 	keys      KeyMap
 }
@@ -80,6 +80,7 @@ func (m *SelectModel) loadCommits(count int) tea.Cmd {
 				Author:    gc.Author,
 				Date:      gc.Date,
 				IsPushed:  gc.IsPushed,
+				IsMerge:   gc.IsMerge,
 			})
 		}
 
@@ -106,6 +107,7 @@ func (m *SelectModel) Update(msg tea.Msg) (*SelectModel, tea.Cmd) {
 		if m.loading {
 			return m, nil
 		}
+		m.notice = ""
 
 		switch {
 		case key.Matches(msg, m.keys.Up):
@@ -139,6 +141,15 @@ func (m *SelectModel) selectCommit() tea.Cmd {
 	selectedIdx := m.cursor
 	selectedCommit := m.commits[selectedIdx]
 
+	// git rebase drops merges and replays the merged branch's commits one by
+	// one, so a rebase can't start at or before one
+	for i := selectedIdx; i >= 0; i-- {
+		if m.commits[i].IsMerge {
+			m.notice = fmt.Sprintf("%s is a merge commit; select a commit after it, since rebasing past a merge would flatten the merged branch", m.commits[i].ShortHash)
+			return nil
+		}
+	}
+
 	// Include selected commit and all commits before it (newer commits)
 	// Commits are in reverse chronological order, so index 0 is most recent
 	var entries []RebaseEntry
@@ -170,7 +181,7 @@ func (m *SelectModel) selectCommit() tea.Cmd {
 // getParentHash returns the parent commit hash for the given commit.
 // Returns empty string if the commit has no parent (root commit).
 func (m *SelectModel) getParentHash(hash string) string {
-	cmd := exec.Command("git", "rev-parse", hash+"^")
+	cmd := git.Command("rev-parse", hash+"^")
 	out, err := cmd.Output()
 	if err != nil {
 		// No parent (root commit) or invalid ref
@@ -209,6 +220,9 @@ func (m *SelectModel) View() string {
 		if commit.IsPushed {
 			line += " " + m.styles.CommitPushed.Render("(pushed)")
 		}
+		if commit.IsMerge {
+			line += " " + m.styles.Subtle.Render("(merge)")
+		}
 
 		s += line + "\n"
 	}
@@ -221,6 +235,10 @@ func (m *SelectModel) View() string {
 		}
 	}
 
+	if m.notice != "" {
+		s += "\n" + m.styles.Warning.Render(m.notice)
+	}
+
 	// Help bar
 	s += "\n\n"
 	s += m.styles.HelpKey.Render("↑/↓") + m.styles.HelpDesc.Render(" navigate  ")
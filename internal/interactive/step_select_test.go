@@ -60,6 +60,30 @@ func TestSelectModel_SelectCommit_IncludesAllNewerCommits(t *testing.T) {
 	}
 }
 
+func TestSelectModel_SelectCommit_StopsAtMerge(t *testing.T) {
+	m := &SelectModel{
+		commits: []RebaseCommit{
+			{Hash: "hash0", ShortHash: "abc0000", Message: "commit 0"},
+			{Hash: "hash1", ShortHash: "abc1111", Message: "Merge branch 'side'", IsMerge: true},
+			{Hash: "hash2", ShortHash: "abc2222", Message: "commit 2"},
+		},
+		cursor: 2,
+	}
+
+	if cmd := m.selectCommit(); cmd != nil {
+		t.Fatalf("expected selection past a merge to be refused, got %+v", cmd())
+	}
+	if !strings.Contains(m.notice, "abc1111") {
+		t.Errorf("notice = %q, want it to name the merge", m.notice)
+	}
+
+	// Commits after the merge can still be rewritten
+	m.cursor = 0
+	if cmd := m.selectCommit(); cmd == nil {
+		t.Error("expected the commit after the merge to be selectable")
+	}
+}
+
 func TestSelectModel_GetParentHash(t *testing.T) {
 	// This test requires a real git repo
 	repoDir := testutil.TestRepo(t)
@@ -172,6 +172,12 @@ func (s Styles) RenderStepIndicator(current WizardStep) string {
 		{StepConfirm, "Confirm"},
 	}
 
+	// Conflicts are resolved as part of the confirm step
+	active := current
+	if current == StepConflict {
+		active = StepConfirm
+	}
+
 	var parts []string
 	parts = append(parts, s.StepArrow.Render("←"))
 
@@ -179,11 +185,11 @@ func (s Styles) RenderStepIndicator(current WizardStep) string {
 		var marker, name string
 
 		switch {
-		case step.step < current || (current == StepPushWarning && step.step == StepSelect):
+		case step.step < active || (current == StepPushWarning && step.step == StepSelect):
 			// Completed
 			marker = s.StepCompleted.Render("✓")
 			name = s.StepCompleted.Render(step.name)
-		case step.step == current ||
+		case step.step == active ||
 			(current == StepPushWarning && step.step == StepSelect) ||
 			(current == StepSquashMessage && step.step == StepConfirm):
 			// Active
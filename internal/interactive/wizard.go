@@ -10,8 +10,11 @@ import (
 // WizardModel is the main model for the interactive rebase wizard.
 type WizardModel struct {
 	// Configuration
-	gitRoot string
-	force   bool
+	gitRoot     string
+	forcePushed bool
+	forceDirty  bool
+	suggester   ConflictSuggester
+	collector   *git.Collector
 
 	// State
 	step      WizardStep
@@ -20,9 +23,13 @@ type WizardModel struct {
 	err       error
 
 	// Sub-models
-	selectModel  *SelectModel
-	editModel    *EditModel
-	confirmModel *ConfirmModel
+	selectModel   *SelectModel
+	editModel     *EditModel
+	confirmModel  *ConfirmModel
+	conflictModel *ConflictModel
+
+	// conflict is set while a rebase is stopped on conflicts
+	conflict *ConflictError
 
 	// Collected data
 	baseCommit string // The commit to rebase onto
@@ -40,16 +47,31 @@ type WizardModel struct {
 // Config holds configuration for the wizard.
 type Config struct {
 	GitRoot string
-	Force   bool
+
+	// ForcePushed allows rewriting commits that were already pushed
+	ForcePushed bool
+	// ForceDirty stashes uncommitted changes around the rebase
+	ForceDirty bool
+
+	// Suggester enables LLM-assisted conflict resolution; nil disables it
+	Suggester ConflictSuggester
+
+	// RemoteRefs, if set, answers which commits were pushed, so a
+	// background fetch mid-run can't change the answer
+	RemoteRefs *git.RemoteRefs
 }
 
 // NewWizard creates a new interactive rebase wizard.
 func NewWizard(cfg Config) *WizardModel {
 	collector := git.NewCollector(cfg.GitRoot)
+	collector.SetRemoteRefs(cfg.RemoteRefs)
 
 	return &WizardModel{
 		gitRoot:     cfg.GitRoot,
-		force:       cfg.Force,
+		forcePushed: cfg.ForcePushed,
+		forceDirty:  cfg.ForceDirty,
+		suggester:   cfg.Suggester,
+		collector:   collector,
 		step:        StepSelect,
 		selectModel: NewSelectModel(collector),
 		styles:      DefaultStyles(),
@@ -75,6 +97,11 @@ func (m *WizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyMsg:
 		// Global cancel handling
 		if key.Matches(msg, m.keys.Cancel) {
+			// Leaving mid-conflict keeps the rebase in progress; report it
+			if m.conflict != nil {
+				m.err = m.conflict
+				return m, tea.Quit
+			}
 			m.cancelled = true
 			return m, tea.Quit
 		}
@@ -93,6 +120,8 @@ func (m *WizardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		cmd = m.updateSquashMessage(msg)
 	case StepConfirm:
 		cmd = m.updateConfirm(msg)
+	case StepConflict:
+		cmd = m.updateConflict(msg)
 	}
 
 	return m, cmd
@@ -114,7 +143,7 @@ func (m *WizardModel) updateSelect(msg tea.Msg) tea.Cmd {
 			}
 		}
 
-		if pushedCount > 0 && !m.force {
+		if pushedCount > 0 && !m.forcePushed {
 			m.step = StepPushWarning
 			return nil
 		}
@@ -122,6 +151,7 @@ func (m *WizardModel) updateSelect(msg tea.Msg) tea.Cmd {
 		// Move to edit step
 		m.step = StepEdit
 		m.editModel = NewEditModel(m.entries, m.styles, m.keys)
+		m.editModel.SetCollector(m.collector)
 		return nil
 
 	default:
@@ -137,10 +167,10 @@ func (m *WizardModel) updatePushWarning(msg tea.Msg) tea.Cmd {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keys.Enter):
-			// User chose to continue (would need --force)
+			// User chose to continue (would need --force-pushed)
 			m.err = &PushedCommitError{}
 			return tea.Quit
-		case key.Matches(msg, m.keys.Back):
+		case key.Matches(msg, m.keys.Back), key.Matches(msg, m.keys.No):
 			// Go back to selection
 			m.step = StepSelect
 			return nil
@@ -156,6 +186,7 @@ func (m *WizardModel) updateEdit(msg tea.Msg) tea.Cmd {
 		m.entries = msg.Entries
 		m.step = StepConfirm
 		m.confirmModel = NewConfirmModel(m.entries, m.baseCommit, m.gitRoot, m.styles, m.keys)
+		m.confirmModel.autostash = m.forceDirty
 		return nil
 
 	case EditBackMsg:
@@ -182,6 +213,12 @@ func (m *WizardModel) updateConfirm(msg tea.Msg) tea.Cmd {
 		if msg.Executed {
 			m.completed = true
 		}
+		if conflictErr, ok := msg.Err.(*ConflictError); ok {
+			m.conflict = conflictErr
+			m.step = StepConflict
+			m.conflictModel = NewConflictModel(m.gitRoot, conflictErr.Files, m.suggester, m.styles, m.keys)
+			return nil
+		}
 		if msg.Err != nil {
 			m.err = msg.Err
 		}
@@ -190,6 +227,7 @@ func (m *WizardModel) updateConfirm(msg tea.Msg) tea.Cmd {
 	case ConfirmBackMsg:
 		m.step = StepEdit
 		m.editModel = NewEditModel(m.entries, m.styles, m.keys)
+		m.editModel.SetCollector(m.collector)
 		return nil
 
 	default:
@@ -199,6 +237,28 @@ func (m *WizardModel) updateConfirm(msg tea.Msg) tea.Cmd {
 	}
 }
 
+// updateConflict handles the conflict resolution step.
+func (m *WizardModel) updateConflict(msg tea.Msg) tea.Cmd {
+	switch msg := msg.(type) {
+	case ConflictDoneMsg:
+		switch {
+		case msg.Err != nil:
+			m.err = msg.Err
+		case msg.Aborted:
+			m.cancelled = true
+		default:
+			// Rebase remains in progress for the user to continue
+			m.err = m.conflict
+		}
+		return tea.Quit
+
+	default:
+		var cmd tea.Cmd
+		m.conflictModel, cmd = m.conflictModel.Update(msg)
+		return cmd
+	}
+}
+
 // View implements tea.Model.
 func (m *WizardModel) View() string {
 	if m.cancelled {
@@ -225,6 +285,8 @@ func (m *WizardModel) View() string {
 		content = m.editModel.View()
 	case StepConfirm:
 		content = m.confirmModel.View()
+	case StepConflict:
+		content = m.conflictModel.View()
 	}
 
 	return header + "\n\n" + content
@@ -243,8 +305,8 @@ func (m *WizardModel) renderPushWarning() string {
 	s += m.styles.Warning.Render("Warning: ") + m.styles.Title.Render("Pushed commits detected\n\n")
 	s += m.styles.Subtle.Render("This rebase includes commits that have been pushed to origin.\n")
 	s += m.styles.Subtle.Render("Rebasing will require force-push to sync with remote.\n\n")
-	s += m.styles.Subtle.Render("Re-run with --force to proceed, or press 'b' to go back.\n\n")
-	s += m.styles.HelpKey.Render("b") + m.styles.HelpDesc.Render(" back  ")
+	s += m.styles.Subtle.Render("Re-run with --force-pushed to proceed, or press 'b' to go back.\n\n")
+	s += m.styles.HelpKey.Render("n/b") + m.styles.HelpDesc.Render(" back  ")
 	s += m.styles.HelpKey.Render("q") + m.styles.HelpDesc.Render(" cancel")
 
 	return s
@@ -255,11 +317,11 @@ func (m *WizardModel) Result() (completed bool, cancelled bool, err error) {
 	return m.completed, m.cancelled, m.err
 }
 
-// PushedCommitError is returned when rebase includes pushed commits without --force.
+// PushedCommitError is returned when rebase includes pushed commits without --force-pushed.
 type PushedCommitError struct{}
 
 func (e *PushedCommitError) Error() string {
-	return "rebase includes pushed commits; use --force to proceed"
+	return "rebase includes pushed commits; use --force-pushed to proceed"
 }
 
 // Run starts the interactive wizard and returns when complete.
@@ -17,10 +17,14 @@ const (
 
 // AnthropicProvider implements the Provider interface for Anthropic's Claude.
 type AnthropicProvider struct {
-	apiKey  string
-	model   string
-	client  *http.Client
-	baseURL string
+	apiKey     string
+	model      string
+	client     *http.Client
+	baseURL    string
+	apiVersion string
+	// temperature is nil to leave Anthropic's default
+	temperature *float64
+	sampling    sampling
 }
 
 // NewAnthropicProvider creates a new Anthropic provider.
@@ -32,10 +36,13 @@ func NewAnthropicProvider(apiKey, model string, opts ProviderOptions) (*Anthropi
 	}
 
 	return &AnthropicProvider{
-		apiKey:  apiKey,
-		model:   model,
-		baseURL: opts.baseURLOr(anthropicAPIURL),
-		client:  newHTTPClient(opts.timeout()),
+		apiKey:      apiKey,
+		model:       model,
+		baseURL:     opts.baseURLOr(anthropicAPIURL),
+		apiVersion:  opts.apiVersionOr(anthropicAPIVersion),
+		client:      newHTTPClient(opts.timeout(), opts.Headers),
+		temperature: opts.Temperature,
+		sampling:    opts.sampling(),
 	}, nil
 }
 
@@ -54,12 +61,14 @@ func (p *AnthropicProvider) Analyze(ctx context.Context, req *types.AnalysisRequ
 	assert.NotNil(req, "analysis request cannot be nil")
 	assert.NotEmpty(req.Files, "analysis request must have files")
 
-	systemPrompt, userPrompt := BuildPrompt(req)
+	systemPrompt, userPrompt := buildFittedPrompt(p.model, p.sampling.maxTokens, req)
 
 	requestBody := anthropicRequest{
-		Model:     p.model,
-		MaxTokens: 8192,
-		System:    systemPrompt,
+		Model:       p.model,
+		MaxTokens:   p.sampling.maxTokens,
+		Temperature: p.temperature,
+		TopP:        p.sampling.topP,
+		System:      systemPrompt,
 		Messages: []anthropicMessage{
 			{Role: "user", Content: userPrompt},
 		},
@@ -90,9 +99,11 @@ func (p *AnthropicProvider) Analyze(ctx context.Context, req *types.AnalysisRequ
 // AnalyzeDiff sends a diff analysis request to Anthropic and returns the analysis.
 func (p *AnthropicProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
 	requestBody := anthropicRequest{
-		Model:     p.model,
-		MaxTokens: 8192,
-		System:    system,
+		Model:       p.model,
+		MaxTokens:   p.sampling.maxTokens,
+		Temperature: p.temperature,
+		TopP:        p.sampling.topP,
+		System:      system,
 		Messages: []anthropicMessage{
 			{Role: "user", Content: user},
 		},
@@ -124,15 +135,17 @@ func (p *AnthropicProvider) headers() map[string]string {
 	return map[string]string{
 		"Content-Type":      "application/json",
 		"x-api-key":         p.apiKey,
-		"anthropic-version": anthropicAPIVersion,
+		"anthropic-version": p.apiVersion,
 	}
 }
 
 type anthropicRequest struct {
-	Model     string             `json:"model"`
-	MaxTokens int                `json:"max_tokens"`
-	System    string             `json:"system,omitempty"`
-	Messages  []anthropicMessage `json:"messages"`
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []anthropicMessage `json:"messages"`
+	Temperature *float64           `json:"temperature,omitempty"`
+	TopP        *float64           `json:"top_p,omitempty"`
 }
 
 type anthropicMessage struct {
@@ -25,6 +25,10 @@ type AzureFoundryProvider struct {
 	model       string
 	client      *http.Client
 	isAnthropic bool
+	apiVersion  string
+	sampling    sampling
+	// temperature is nil to leave Anthropic's default for Claude deployments
+	temperature *float64
 }
 
 // NewAzureFoundryProvider creates a new Azure Foundry provider.
@@ -45,10 +49,21 @@ func NewAzureFoundryProvider(endpoint, apiKey, deployment, model string, opts Pr
 		deployment:  deployment,
 		model:       model,
 		isAnthropic: isAnthropic,
-		client:      newHTTPClient(opts.timeout()),
+		apiVersion:  opts.apiVersionOr(defaultAzureAPIVersion(isAnthropic)),
+		client:      newHTTPClient(opts.timeout(), opts.Headers),
+		sampling:    opts.sampling(),
+		temperature: opts.Temperature,
 	}, nil
 }
 
+// defaultAzureAPIVersion returns the API version for the deployment's API format.
+func defaultAzureAPIVersion(isAnthropic bool) string {
+	if isAnthropic {
+		return azureAnthropicAPIVersion
+	}
+	return azureOpenAIAPIVersion
+}
+
 // isAnthropicDeployment checks if the deployment name indicates an Anthropic model.
 func isAnthropicDeployment(deployment string) bool {
 	lower := strings.ToLower(deployment)
@@ -73,7 +88,7 @@ func (p *AzureFoundryProvider) Analyze(ctx context.Context, req *types.AnalysisR
 	assert.NotNil(req, "analysis request cannot be nil")
 	assert.NotEmpty(req.Files, "analysis request must have files")
 
-	systemPrompt, userPrompt := BuildPrompt(req)
+	systemPrompt, userPrompt := buildFittedPrompt(p.Model(), p.sampling.maxTokens, req)
 
 	var content string
 	var err error
@@ -90,7 +105,7 @@ func (p *AzureFoundryProvider) Analyze(ctx context.Context, req *types.AnalysisR
 
 	plan, err := parseCommitPlan(content)
 	if err != nil {
-		return nil, &ProviderError{Provider: "azure-foundry", Message: "failed to parse commit plan", Err: err}
+		return nil, &ProviderError{Provider: "azure-foundry", Message: "failed to parse commit plan", Err: &PlanParseError{Content: content, Err: err}}
 	}
 
 	assert.NotNil(plan, "commit plan should not be nil")
@@ -108,9 +123,11 @@ func (p *AzureFoundryProvider) AnalyzeDiff(ctx context.Context, system, user str
 // callAnthropicAPI makes a request using the Anthropic Messages API format.
 func (p *AzureFoundryProvider) callAnthropicAPI(ctx context.Context, system, user string) (string, error) {
 	requestBody := anthropicAPIRequest{
-		Model:     p.deployment,
-		MaxTokens: 8192,
-		System:    system,
+		Model:       p.deployment,
+		MaxTokens:   p.sampling.maxTokens,
+		Temperature: p.temperature,
+		TopP:        p.sampling.topP,
+		System:      system,
 		Messages: []anthropicAPIMessage{
 			{Role: "user", Content: user},
 		},
@@ -126,7 +143,7 @@ func (p *AzureFoundryProvider) callAnthropicAPI(ctx context.Context, system, use
 		headers: map[string]string{
 			"Content-Type":      "application/json",
 			"Authorization":     "Bearer " + p.apiKey,
-			"anthropic-version": azureAnthropicAPIVersion,
+			"anthropic-version": p.apiVersion,
 		},
 		body:     requestBody,
 		provider: "azure-foundry",
@@ -158,12 +175,14 @@ func (p *AzureFoundryProvider) callOpenAIAPI(ctx context.Context, system, user s
 			{Role: "system", Content: system},
 			{Role: "user", Content: user},
 		},
-		Temperature: 0.3,
-		MaxTokens:   8192,
+		Temperature: &p.sampling.temperature,
+		Seed:        p.sampling.seed,
+		TopP:        p.sampling.topP,
+		MaxTokens:   p.sampling.maxTokens,
 	}
 
 	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
-		p.endpoint, p.deployment, azureOpenAIAPIVersion)
+		p.endpoint, p.deployment, p.apiVersion)
 
 	resp, err := doRequest(&llmRequest{
 		ctx:    ctx,
@@ -212,10 +231,12 @@ func parseCommitPlan(content string) (*types.CommitPlan, error) {
 // Anthropic API types (specific to Azure Foundry's Anthropic proxy)
 
 type anthropicAPIRequest struct {
-	Model     string                `json:"model"`
-	MaxTokens int                   `json:"max_tokens"`
-	System    string                `json:"system,omitempty"`
-	Messages  []anthropicAPIMessage `json:"messages"`
+	Model       string                `json:"model"`
+	MaxTokens   int                   `json:"max_tokens"`
+	System      string                `json:"system,omitempty"`
+	Messages    []anthropicAPIMessage `json:"messages"`
+	Temperature *float64              `json:"temperature,omitempty"`
+	TopP        *float64              `json:"top_p,omitempty"`
 }
 
 type anthropicAPIMessage struct {
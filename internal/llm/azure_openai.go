@@ -0,0 +1,98 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// AzureOpenAIProvider implements the Provider interface for an Azure OpenAI
+// resource. Requests are routed by deployment name rather than model, and
+// every request carries the api-version query parameter.
+type AzureOpenAIProvider struct {
+	endpoint   string
+	apiKey     string
+	deployment string
+	model      string
+	apiVersion string
+	client     *http.Client
+	sampling   sampling
+}
+
+// NewAzureOpenAIProvider creates a new Azure OpenAI provider for the resource
+// at endpoint, e.g. https://my-resource.openai.azure.com.
+func NewAzureOpenAIProvider(endpoint, apiKey, deployment, model string, opts ProviderOptions) (*AzureOpenAIProvider, error) {
+	assert.NotEmptyString(endpoint, "Azure OpenAI endpoint is required")
+	assert.NotEmptyString(apiKey, "Azure OpenAI API key is required")
+	assert.NotEmptyString(deployment, "Azure OpenAI deployment name is required")
+
+	// Accept the endpoint with or without the /openai path the portal sometimes shows
+	endpoint = strings.TrimSuffix(strings.TrimSuffix(endpoint, "/"), "/openai")
+
+	return &AzureOpenAIProvider{
+		endpoint:   endpoint,
+		apiKey:     apiKey,
+		deployment: deployment,
+		model:      model,
+		apiVersion: opts.apiVersionOr(azureOpenAIAPIVersion),
+		client:     newHTTPClient(opts.timeout(), opts.Headers),
+		sampling:   opts.sampling(),
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *AzureOpenAIProvider) Name() string {
+	return "azure-openai"
+}
+
+// Model returns the model being used, or the deployment name when no model
+// is configured.
+func (p *AzureOpenAIProvider) Model() string {
+	if p.model != "" {
+		return p.model
+	}
+	return p.deployment
+}
+
+// Analyze sends an analysis request to the deployment and returns a commit plan.
+func (p *AzureOpenAIProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	assert.NotNil(req, "analysis request cannot be nil")
+	assert.NotEmpty(req.Files, "analysis request must have files")
+
+	return analyzeChatCompletion(ctx, p.requestParams(), req)
+}
+
+// AnalyzeDiff sends a diff analysis request to the deployment and returns the analysis.
+func (p *AzureOpenAIProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	return analyzeDiffChatCompletion(ctx, p.requestParams(), system, user)
+}
+
+// apiURL returns the deployment's chat completions URL.
+func (p *AzureOpenAIProvider) apiURL() string {
+	return fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		p.endpoint, url.PathEscape(p.deployment), url.QueryEscape(p.apiVersion))
+}
+
+// requestParams leaves the model out of requests, since the deployment
+// already selects it.
+func (p *AzureOpenAIProvider) requestParams() llmRequestParams {
+	return llmRequestParams{
+		httpClient: p.client,
+		url:        p.apiURL(),
+		headers:    p.headers(),
+		provider:   "azure-openai",
+		sampling:   p.sampling,
+	}
+}
+
+func (p *AzureOpenAIProvider) headers() map[string]string {
+	return map[string]string{
+		"Content-Type": "application/json",
+		"api-key":      p.apiKey,
+	}
+}
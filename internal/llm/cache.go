@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// DefaultCacheTTL is how long a cached response is reused when
+// COMMIT_CACHE_TTL isn't set.
+const DefaultCacheTTL = 24 * time.Hour
+
+// ResponseCache keeps the commit plans providers returned on disk, one file
+// per request, so an identical request (the same changes, rules, provider and
+// sampling) within the TTL is answered without calling the provider again.
+// A nil *ResponseCache caches nothing.
+type ResponseCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// CachedResponse is a plan read back from the cache.
+type CachedResponse struct {
+	CreatedAt time.Time         `json:"created_at"`
+	Provider  string            `json:"provider"`
+	Model     string            `json:"model,omitempty"`
+	Plan      *types.CommitPlan `json:"plan"`
+}
+
+// NewResponseCache creates a cache in dir whose entries expire after ttl.
+func NewResponseCache(dir string, ttl time.Duration) *ResponseCache {
+	return &ResponseCache{dir: dir, ttl: ttl}
+}
+
+// ResponseKey hashes everything that shapes a provider's answer to req: the
+// request itself, the provider and model, and inputs such as the sampling
+// settings.
+func ResponseKey(req *types.AnalysisRequest, provider, model string, inputs ...string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s", provider, model)
+	for _, input := range inputs {
+		fmt.Fprintf(h, "\x00%s", input)
+	}
+	h.Write([]byte{0})
+	_ = json.NewEncoder(h).Encode(req) // plain data; it always encodes
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Load returns the response cached under key if there is one younger than
+// the TTL.
+func (c *ResponseCache) Load(key string) (*CachedResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var cached CachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false
+	}
+	if time.Since(cached.CreatedAt) > c.ttl || cached.Plan == nil || len(cached.Plan.Commits) == 0 {
+		return nil, false
+	}
+	return &cached, true
+}
+
+// Save stores plan, as provider's answer, under key and removes expired
+// entries. Failures are ignored; the cache only saves a request.
+func (c *ResponseCache) Save(key, provider, model string, plan *types.CommitPlan) {
+	if c == nil || plan == nil || len(plan.Commits) == 0 {
+		return
+	}
+	data, err := json.Marshal(CachedResponse{CreatedAt: time.Now().UTC(), Provider: provider, Model: model, Plan: plan})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return
+	}
+	_ = os.WriteFile(c.path(key), data, 0600)
+	c.prune()
+}
+
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// prune removes entries older than the TTL.
+func (c *ResponseCache) prune() {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > c.ttl {
+			_ = os.Remove(filepath.Join(c.dir, entry.Name()))
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/tokens"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// MaxParallelChunks is how many chunks of a split request are analyzed at
+// once, to stay clear of provider rate limits.
+const MaxParallelChunks = 4
+
+// SplitRequest splits req into requests whose prompts each fit model's
+// context window alongside an answer of up to maxTokens, so a change set too
+// large for one request is analyzed in several instead of losing most of its
+// diff to truncation. Files stay together by scope, or by top-level
+// directory when they have none, unless a group alone is too large. It
+// returns req alone when it fits, asks for a single commit, or can't be split.
+func SplitRequest(model string, maxTokens int, req *types.AnalysisRequest) []*types.AnalysisRequest {
+	budget := tokens.ContextWindow(model) - ResponseTokens(maxTokens)
+	if req.SingleCommit || len(req.Files) < 2 || PromptTokens(model, req) <= budget {
+		return []*types.AnalysisRequest{req}
+	}
+
+	// Everything but the files and their diffs is in every chunk's prompt
+	budget -= PromptTokens(model, subRequest(req, nil))
+	if budget <= 0 {
+		return []*types.AnalysisRequest{req}
+	}
+	sections := git.DiffByFile(req.Diff)
+	size := func(files []types.FileChange) int {
+		n := tokens.Count(model, formatFiles(files))
+		for _, f := range files {
+			n += tokens.Count(model, sections[f.Path])
+		}
+		return n
+	}
+
+	var units [][]types.FileChange
+	for _, group := range groupFiles(req.Files) {
+		if len(group) == 1 || size(group) <= budget {
+			units = append(units, group)
+			continue
+		}
+		for _, f := range group {
+			units = append(units, []types.FileChange{f})
+		}
+	}
+
+	// Fill each chunk in order; a file too large on its own gets a chunk to
+	// itself and is trimmed like any oversized prompt
+	var chunks []*types.AnalysisRequest
+	var current []types.FileChange
+	used := 0
+	for _, unit := range units {
+		n := size(unit)
+		if len(current) > 0 && used+n > budget {
+			chunks = append(chunks, subRequest(req, current))
+			current, used = nil, 0
+		}
+		current = append(current, unit...)
+		used += n
+	}
+	chunks = append(chunks, subRequest(req, current))
+
+	if len(chunks) < 2 {
+		return []*types.AnalysisRequest{req}
+	}
+	return chunks
+}
+
+// groupFiles groups files by scope, or by top-level directory for files
+// without one, in the order the groups first appear.
+func groupFiles(files []types.FileChange) [][]types.FileChange {
+	var keys []string
+	groups := make(map[string][]types.FileChange)
+	for _, f := range files {
+		key := "scope:" + f.Scope
+		if f.Scope == "" {
+			dir, _, _ := strings.Cut(f.Path, "/")
+			if dir == f.Path {
+				dir = "" // a file at the root
+			}
+			key = "dir:" + dir
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], f)
+	}
+
+	result := make([][]types.FileChange, len(keys))
+	for i, key := range keys {
+		result[i] = groups[key]
+	}
+	return result
+}
+
+// subRequest returns a copy of req narrowed to files: their diffs and the
+// type guesses for them.
+func subRequest(req *types.AnalysisRequest, files []types.FileChange) *types.AnalysisRequest {
+	sub := *req
+	sub.Files = files
+	sub.GeneratedFiles = nil
+
+	paths := make([]string, len(files))
+	inChunk := make(map[string]bool)
+	for i, f := range files {
+		paths[i] = f.Path
+		inChunk[f.Path] = true
+	}
+	sub.Diff = ""
+	if len(paths) > 0 {
+		sub.Diff = git.FilterDiff(req.Diff, paths)
+	}
+
+	sub.TypeGuesses = nil
+	for _, g := range req.TypeGuesses {
+		var kept []string
+		for _, f := range g.Files {
+			if inChunk[f] {
+				kept = append(kept, f)
+			}
+		}
+		if len(kept) > 0 {
+			g.Files = kept
+			sub.TypeGuesses = append(sub.TypeGuesses, g)
+		}
+	}
+	return &sub
+}
+
+// AnalyzeChunks analyzes each of chunks, as split by SplitRequest, with
+// AnalyzeWithRepair, up to MaxParallelChunks at once, and returns their plans'
+// commits together in chunk order for the validator to merge and check. The
+// first chunk to fail cancels the rest. A single chunk is analyzed as is.
+func AnalyzeChunks(ctx context.Context, provider Provider, chunks []*types.AnalysisRequest, onRepair func(parseErr error)) (*types.CommitPlan, error) {
+	if len(chunks) == 1 {
+		return AnalyzeWithRepair(ctx, provider, chunks[0], onRepair)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	plans := make([]*types.CommitPlan, len(chunks))
+	errs := make([]error, len(chunks))
+	slots := make(chan struct{}, MaxParallelChunks)
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk *types.AnalysisRequest) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			if ctx.Err() != nil {
+				errs[i] = ctx.Err()
+				return
+			}
+			if plans[i], errs[i] = AnalyzeWithRepair(ctx, provider, chunk, onRepair); errs[i] != nil {
+				cancel()
+			}
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	// Report the failure that cancelled the others, not a cancellation
+	var failed error
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+		err = fmt.Errorf("chunk %d of %d: %w", i+1, len(chunks), err)
+		if failed == nil || errors.Is(failed, context.Canceled) && !errors.Is(err, context.Canceled) {
+			failed = err
+		}
+	}
+	if failed != nil {
+		return nil, failed
+	}
+
+	plan := &types.CommitPlan{}
+	for _, p := range plans {
+		plan.Commits = append(plan.Commits, p.Commits...)
+	}
+	return plan, nil
+}
@@ -0,0 +1,95 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+const (
+	deepSeekAPIURL       = "https://api.deepseek.com/chat/completions"
+	defaultDeepSeekModel = "deepseek-chat"
+)
+
+// DeepSeekProvider implements the Provider interface for DeepSeek, e.g.
+// deepseek-chat or the slower, reasoning deepseek-reasoner.
+type DeepSeekProvider struct {
+	apiKey   string
+	model    string
+	client   *http.Client
+	baseURL  string
+	sampling sampling
+}
+
+// NewDeepSeekProvider creates a new DeepSeek provider.
+func NewDeepSeekProvider(apiKey, model string, opts ProviderOptions) (*DeepSeekProvider, error) {
+	assert.NotEmptyString(apiKey, "DeepSeek API key is required")
+
+	if model == "" {
+		model = defaultDeepSeekModel
+	}
+
+	return &DeepSeekProvider{
+		apiKey:   apiKey,
+		model:    model,
+		baseURL:  opts.baseURLOr(deepSeekAPIURL),
+		client:   newHTTPClient(opts.timeout(), opts.Headers),
+		sampling: opts.sampling(),
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *DeepSeekProvider) Name() string {
+	return "deepseek"
+}
+
+// Model returns the model being used.
+func (p *DeepSeekProvider) Model() string {
+	return p.model
+}
+
+// Analyze sends an analysis request to DeepSeek and returns a commit plan.
+// DeepSeek uses an OpenAI-compatible API.
+func (p *DeepSeekProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	assert.NotNil(req, "analysis request cannot be nil")
+	assert.NotEmpty(req.Files, "analysis request must have files")
+
+	return analyzeChatCompletion(ctx, p.requestParams(), req)
+}
+
+// AnalyzeDiff sends a diff analysis request to DeepSeek and returns the analysis.
+func (p *DeepSeekProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	return analyzeDiffChatCompletion(ctx, p.requestParams(), system, user)
+}
+
+// listModels returns the IDs of the models the key can use.
+func (p *DeepSeekProvider) listModels(ctx context.Context) ([]string, error) {
+	return listModelIDs(ctx, p.requestParams(), strings.TrimSuffix(p.baseURL, "/chat/completions")+"/models")
+}
+
+// checkKey lists the models, which needs a valid key, and checks the model
+// is among them, since DeepSeek can't look up a model by ID.
+func (p *DeepSeekProvider) checkKey(ctx context.Context) error {
+	return checkModelListed(ctx, p, "deepseek", p.model, "use deepseek-chat or deepseek-reasoner")
+}
+
+func (p *DeepSeekProvider) requestParams() llmRequestParams {
+	return llmRequestParams{
+		httpClient: p.client,
+		model:      p.model,
+		url:        p.baseURL,
+		headers:    p.headers(),
+		provider:   "deepseek",
+		sampling:   p.sampling,
+	}
+}
+
+func (p *DeepSeekProvider) headers() map[string]string {
+	return map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + p.apiKey,
+	}
+}
@@ -0,0 +1,28 @@
+package llm
+
+// endpointer is implemented by providers that call a known API URL.
+type endpointer interface {
+	apiEndpoint() string
+}
+
+// Endpoint returns the URL provider sends requests to, or "" if unknown,
+// e.g. to report which proxy requests to it go through.
+func Endpoint(provider Provider) string {
+	if e, ok := provider.(endpointer); ok {
+		return e.apiEndpoint()
+	}
+	return ""
+}
+
+func (p *OpenAIProvider) apiEndpoint() string       { return p.baseURL }
+func (p *GrokProvider) apiEndpoint() string         { return p.baseURL }
+func (p *AnthropicProvider) apiEndpoint() string    { return p.baseURL }
+func (p *GeminiProvider) apiEndpoint() string       { return p.apiURL() }
+func (p *AzureFoundryProvider) apiEndpoint() string { return p.endpoint }
+func (p *AzureOpenAIProvider) apiEndpoint() string  { return p.apiURL() }
+func (p *OllamaProvider) apiEndpoint() string       { return p.baseURL }
+func (p *OpenRouterProvider) apiEndpoint() string   { return p.baseURL }
+func (p *MistralProvider) apiEndpoint() string      { return p.baseURL }
+func (p *VertexProvider) apiEndpoint() string       { return p.modelURL("generateContent") }
+func (p *DeepSeekProvider) apiEndpoint() string     { return p.baseURL }
+func (p *GitHubModelsProvider) apiEndpoint() string { return p.baseURL }
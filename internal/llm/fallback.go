@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// FallbackProvider implements the Provider interface over a chain of
+// providers: when one is rate limited, failing or too slow the request is
+// sent to the next. Once a provider has answered, later requests (such as a
+// repair turn) start with it. It's safe for concurrent use, e.g. by
+// AnalyzeChunks.
+type FallbackProvider struct {
+	providers []Provider
+	mu        sync.Mutex // guards current
+	current   int
+	// attemptTimeout bounds each provider's attempt so a slow one leaves the
+	// rest time to answer; 0 leaves only the caller's deadline
+	attemptTimeout time.Duration
+	onFallback     func(failed, next Provider, err error)
+}
+
+// NewFallbackProvider creates a provider that tries providers in order.
+func NewFallbackProvider(providers []Provider) *FallbackProvider {
+	assert.NotEmpty(providers, "fallback chain must have a provider")
+	return &FallbackProvider{providers: providers}
+}
+
+// SetAttemptTimeout limits how long each provider gets before the next is
+// tried.
+func (p *FallbackProvider) SetAttemptTimeout(d time.Duration) {
+	p.attemptTimeout = d
+}
+
+// SetOnFallback sets a function called with the provider that failed, the
+// one tried next and the error, before the request is sent again.
+func (p *FallbackProvider) SetOnFallback(fn func(failed, next Provider, err error)) {
+	p.onFallback = fn
+}
+
+// Len returns the number of providers in the chain.
+func (p *FallbackProvider) Len() int {
+	return len(p.providers)
+}
+
+// Name returns the name of the provider that answered last, or of the
+// first provider before any has.
+func (p *FallbackProvider) Name() string {
+	return p.provider().Name()
+}
+
+// Model returns the model of the provider that answered last, or of the
+// first provider before any has.
+func (p *FallbackProvider) Model() string {
+	return p.provider().Model()
+}
+
+// provider returns the provider that answered last.
+func (p *FallbackProvider) provider() Provider {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.providers[p.current]
+}
+
+// Analyze sends an analysis request along the chain and returns the first
+// commit plan.
+func (p *FallbackProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	var plan *types.CommitPlan
+	err := p.try(ctx, func(ctx context.Context, provider Provider) error {
+		var err error
+		plan, err = provider.Analyze(ctx, req)
+		return err
+	})
+	return plan, err
+}
+
+// AnalyzeDiff sends a diff analysis request along the chain and returns the
+// first analysis.
+func (p *FallbackProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	var content string
+	err := p.try(ctx, func(ctx context.Context, provider Provider) error {
+		var err error
+		content, err = provider.AnalyzeDiff(ctx, system, user)
+		return err
+	})
+	return content, err
+}
+
+// try calls fn with each provider from the current one on until one
+// succeeds, fails for a reason another provider wouldn't fix, or ctx ends.
+func (p *FallbackProvider) try(ctx context.Context, fn func(context.Context, Provider) error) error {
+	p.mu.Lock()
+	start := p.current
+	p.mu.Unlock()
+
+	for i := start; ; i++ {
+		err := p.attempt(ctx, p.providers[i], fn)
+		if err == nil {
+			p.mu.Lock()
+			p.current = max(p.current, i)
+			p.mu.Unlock()
+			return nil
+		}
+		if i == len(p.providers)-1 || ctx.Err() != nil || !unavailable(err) {
+			return err
+		}
+		if p.onFallback != nil {
+			p.onFallback(p.providers[i], p.providers[i+1], err)
+		}
+	}
+}
+
+func (p *FallbackProvider) attempt(ctx context.Context, provider Provider, fn func(context.Context, Provider) error) error {
+	if p.attemptTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, p.attemptTimeout)
+		defer cancel()
+	}
+	return fn(ctx, provider)
+}
+
+// unavailable reports whether err means the provider couldn't serve the
+// request right now, so another provider might: it was rate limited (429),
+// failed on its side (5xx) or timed out. Rejected keys and bad requests
+// aren't, since they'd fail the same way on retry.
+func unavailable(err error) bool {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) &&
+		(providerErr.StatusCode == http.StatusTooManyRequests || providerErr.StatusCode >= 500) {
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
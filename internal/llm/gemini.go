@@ -11,16 +11,19 @@ import (
 )
 
 const (
-	geminiAPIURL       = "https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent"
-	defaultGeminiModel = "gemini-1.5-pro"
+	// geminiAPIURL takes the API version; the result takes the model
+	geminiAPIURL            = "https://generativelanguage.googleapis.com/%s/models/%%s:generateContent"
+	defaultGeminiAPIVersion = "v1beta"
+	defaultGeminiModel      = "gemini-1.5-pro"
 )
 
 // GeminiProvider implements the Provider interface for Google's Gemini.
 type GeminiProvider struct {
-	apiKey  string
-	model   string
-	client  *http.Client
-	baseURL string
+	apiKey   string
+	model    string
+	client   *http.Client
+	baseURL  string
+	sampling sampling
 }
 
 // NewGeminiProvider creates a new Gemini provider.
@@ -32,10 +35,11 @@ func NewGeminiProvider(apiKey, model string, opts ProviderOptions) (*GeminiProvi
 	}
 
 	return &GeminiProvider{
-		apiKey:  apiKey,
-		model:   model,
-		baseURL: opts.baseURLOr(geminiAPIURL),
-		client:  newHTTPClient(opts.timeout()),
+		apiKey:   apiKey,
+		model:    model,
+		baseURL:  opts.baseURLOr(fmt.Sprintf(geminiAPIURL, opts.apiVersionOr(defaultGeminiAPIVersion))),
+		client:   newHTTPClient(opts.timeout(), opts.Headers),
+		sampling: opts.sampling(),
 	}, nil
 }
 
@@ -54,85 +58,79 @@ func (p *GeminiProvider) Analyze(ctx context.Context, req *types.AnalysisRequest
 	assert.NotNil(req, "analysis request cannot be nil")
 	assert.NotEmpty(req.Files, "analysis request must have files")
 
-	systemPrompt, userPrompt := BuildPrompt(req)
-
-	// Gemini uses a different format - combine system and user prompts
-	combinedPrompt := systemPrompt + "\n\n---\n\n" + userPrompt
-
-	requestBody := geminiRequest{
-		Contents: []geminiContent{
-			{
-				Parts: []geminiPart{
-					{Text: combinedPrompt},
-				},
-			},
-		},
-		GenerationConfig: geminiGenerationConfig{
-			Temperature:     0.3,
-			MaxOutputTokens: 8192,
-		},
-	}
-
-	resp, err := doRequest(&llmRequest{
-		ctx:      ctx,
-		client:   p.client,
-		method:   "POST",
-		url:      p.apiURL(),
-		headers:  p.headers(),
-		body:     requestBody,
-		provider: "gemini",
-	})
+	systemPrompt, userPrompt := buildFittedPrompt(p.model, p.sampling.maxTokens, req)
+	content, truncated, err := generateGeminiContent(ctx, p.requestParams(), systemPrompt, userPrompt)
 	if err != nil {
 		return nil, err
 	}
-
-	var geminiResp geminiResponse
-	if err := json.Unmarshal(resp.Body, &geminiResp); err != nil {
-		return nil, &ProviderError{Provider: "gemini", Message: "failed to parse response", Err: err}
-	}
-
-	content, truncated := extractGeminiContent(geminiResp)
 	return processAnalyzeResponse("gemini", content, truncated)
 }
 
 // AnalyzeDiff sends a diff analysis request to Gemini and returns the analysis.
 func (p *GeminiProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	content, truncated, err := generateGeminiContent(ctx, p.requestParams(), system, user)
+	if err != nil {
+		return "", err
+	}
+	return processTextResponse("gemini", content, truncated)
+}
+
+func (p *GeminiProvider) requestParams() llmRequestParams {
+	return llmRequestParams{
+		httpClient: p.client,
+		model:      p.model,
+		url:        p.apiURL(),
+		headers:    p.headers(),
+		provider:   "gemini",
+		sampling:   p.sampling,
+	}
+}
+
+// generateGeminiContent sends the prompts to a Gemini generateContent
+// endpoint (Gemini API or Vertex AI) and returns the reply's text and whether
+// it was cut off.
+func generateGeminiContent(ctx context.Context, params llmRequestParams, system, user string) (string, bool, error) {
+	// Gemini uses a different format - combine system and user prompts
 	combinedPrompt := system + "\n\n---\n\n" + user
 
 	requestBody := geminiRequest{
 		Contents: []geminiContent{
 			{
+				Role: "user",
 				Parts: []geminiPart{
 					{Text: combinedPrompt},
 				},
 			},
 		},
 		GenerationConfig: geminiGenerationConfig{
-			Temperature:     0.3,
-			MaxOutputTokens: 8192,
+			Temperature:     &params.sampling.temperature,
+			Seed:            params.sampling.seed,
+			TopP:            params.sampling.topP,
+			MaxOutputTokens: params.sampling.maxTokens,
 		},
 	}
 
 	resp, err := doRequest(&llmRequest{
-		ctx:      ctx,
-		client:   p.client,
-		method:   "POST",
-		url:      p.apiURL(),
-		headers:  p.headers(),
-		body:     requestBody,
-		provider: "gemini",
+		ctx:        ctx,
+		client:     params.httpClient,
+		method:     "POST",
+		url:        params.url,
+		headers:    params.headers,
+		body:       requestBody,
+		provider:   params.provider,
+		parseError: params.parseError,
 	})
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	var geminiResp geminiResponse
 	if err := json.Unmarshal(resp.Body, &geminiResp); err != nil {
-		return "", &ProviderError{Provider: "gemini", Message: "failed to parse response", Err: err}
+		return "", false, &ProviderError{Provider: params.provider, Message: "failed to parse response", Err: err}
 	}
 
 	content, truncated := extractGeminiContent(geminiResp)
-	return processTextResponse("gemini", content, truncated)
+	return content, truncated, nil
 }
 
 // apiURL returns the full API URL with model substituted.
@@ -153,6 +151,7 @@ type geminiRequest struct {
 }
 
 type geminiContent struct {
+	Role  string       `json:"role,omitempty"` // required by Vertex AI
 	Parts []geminiPart `json:"parts"`
 }
 
@@ -161,8 +160,10 @@ type geminiPart struct {
 }
 
 type geminiGenerationConfig struct {
-	Temperature     float64 `json:"temperature,omitempty"`
-	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+	Temperature     *float64 `json:"temperature,omitempty"`
+	Seed            *int     `json:"seed,omitempty"`
+	TopP            *float64 `json:"topP,omitempty"`
+	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
 }
 
 type geminiResponse struct {
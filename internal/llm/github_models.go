@@ -0,0 +1,134 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+const (
+	gitHubModelsAPIURL       = "https://models.github.ai/inference/chat/completions"
+	defaultGitHubModelsModel = "openai/gpt-4.1"
+	gitHubAPIVersion         = "2022-11-28"
+)
+
+// GitHubModelsProvider implements the Provider interface for GitHub Models,
+// authenticated with a GitHub token rather than a separate API key. Models
+// are named publisher/model, e.g. "openai/gpt-4.1" or "meta/llama-4-scout".
+type GitHubModelsProvider struct {
+	token    string
+	model    string
+	client   *http.Client
+	baseURL  string
+	sampling sampling
+}
+
+// NewGitHubModelsProvider creates a new GitHub Models provider. token needs
+// the models:read permission; gh auth tokens and GITHUB_TOKEN in Actions
+// (with permissions: models: read) have it.
+func NewGitHubModelsProvider(token, model string, opts ProviderOptions) (*GitHubModelsProvider, error) {
+	assert.NotEmptyString(token, "GitHub token is required")
+
+	if model == "" {
+		model = defaultGitHubModelsModel
+	}
+
+	return &GitHubModelsProvider{
+		token:    token,
+		model:    model,
+		baseURL:  opts.baseURLOr(gitHubModelsAPIURL),
+		client:   newHTTPClient(opts.timeout(), opts.Headers),
+		sampling: opts.sampling(),
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *GitHubModelsProvider) Name() string {
+	return "github-models"
+}
+
+// Model returns the model being used.
+func (p *GitHubModelsProvider) Model() string {
+	return p.model
+}
+
+// Analyze sends an analysis request to GitHub Models and returns a commit
+// plan. GitHub Models uses an OpenAI-compatible API.
+func (p *GitHubModelsProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	assert.NotNil(req, "analysis request cannot be nil")
+	assert.NotEmpty(req.Files, "analysis request must have files")
+
+	return analyzeChatCompletion(ctx, p.requestParams(), req)
+}
+
+// AnalyzeDiff sends a diff analysis request to GitHub Models and returns the analysis.
+func (p *GitHubModelsProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	return analyzeDiffChatCompletion(ctx, p.requestParams(), system, user)
+}
+
+// listModels returns the IDs of the models in the GitHub Models catalog.
+func (p *GitHubModelsProvider) listModels(ctx context.Context) ([]string, error) {
+	params := p.requestParams()
+	resp, err := doRequest(&llmRequest{
+		ctx:      ctx,
+		client:   params.httpClient,
+		method:   "GET",
+		url:      p.catalogURL(),
+		headers:  params.headers,
+		provider: params.provider,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Unlike OpenAI's, the catalog is a bare array
+	var catalog []struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(resp.Body, &catalog); err != nil {
+		return nil, &ProviderError{Provider: params.provider, Message: "failed to parse model catalog", Err: err}
+	}
+	models := make([]string, 0, len(catalog))
+	for _, m := range catalog {
+		models = append(models, m.ID)
+	}
+	return models, nil
+}
+
+// checkKey lists the catalog, which refuses a bad token, and checks the
+// model is in it.
+func (p *GitHubModelsProvider) checkKey(ctx context.Context) error {
+	return checkModelListed(ctx, p, "github-models", p.model, "model IDs look like openai/gpt-4.1; see https://github.com/marketplace?type=models")
+}
+
+// catalogURL returns the model catalog next to the inference endpoint, which
+// may be an organization's (.../orgs/ORG/inference/chat/completions).
+func (p *GitHubModelsProvider) catalogURL() string {
+	root := p.baseURL
+	if i := strings.Index(root, "/orgs/"); i >= 0 {
+		root = root[:i]
+	} else if i := strings.Index(root, "/inference"); i >= 0 {
+		root = root[:i]
+	}
+	return root + "/catalog/models"
+}
+
+func (p *GitHubModelsProvider) requestParams() llmRequestParams {
+	return llmRequestParams{
+		httpClient: p.client,
+		model:      p.model,
+		url:        p.baseURL,
+		headers: map[string]string{
+			"Content-Type":         "application/json",
+			"Accept":               "application/vnd.github+json",
+			"Authorization":        "Bearer " + p.token,
+			"X-GitHub-Api-Version": gitHubAPIVersion,
+		},
+		provider: "github-models",
+		sampling: p.sampling,
+	}
+}
@@ -0,0 +1,296 @@
+package llm
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	googleTokenURL   = "https://oauth2.googleapis.com/token"
+	googleCloudScope = "https://www.googleapis.com/auth/cloud-platform"
+	// googleMetadataTokenURL serves the attached service account's tokens on
+	// Google Cloud (GCE, GKE, Cloud Run)
+	googleMetadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+	// tokenExpiryMargin renews tokens this long before they expire
+	tokenExpiryMargin = time.Minute
+)
+
+// googleCredentials is a service account key or the authorized user file
+// gcloud auth application-default login writes.
+type googleCredentials struct {
+	Type string `json:"type"` // "service_account" or "authorized_user"
+
+	// Service account keys
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+
+	// Authorized users
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// googleTokenSource gets OAuth access tokens for Google Cloud from
+// Application Default Credentials and caches them until shortly before
+// they expire.
+type googleTokenSource struct {
+	client *http.Client
+	// creds is nil when tokens come from the metadata server
+	creds *googleCredentials
+	// source names where the credentials came from, for errors
+	source      string
+	tokenURL    string
+	metadataURL string
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// newGoogleTokenSource finds Application Default Credentials: the file at
+// path if set, else $GOOGLE_APPLICATION_CREDENTIALS, else the file gcloud
+// auth application-default login writes, else the metadata server of the
+// Google Cloud machine it runs on.
+func newGoogleTokenSource(client *http.Client, path string) (*googleTokenSource, error) {
+	ts := &googleTokenSource{
+		client:      client,
+		source:      "metadata server",
+		tokenURL:    googleTokenURL,
+		metadataURL: googleMetadataTokenURL,
+	}
+
+	if path == "" {
+		path = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	if path == "" {
+		if wellKnown := gcloudCredentialsPath(); wellKnown != "" {
+			if _, err := os.Stat(wellKnown); err == nil {
+				path = wellKnown
+			}
+		}
+	}
+	if path == "" {
+		return ts, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Google credentials: %w", err)
+	}
+	var creds googleCredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse Google credentials %s: %w", path, err)
+	}
+	switch creds.Type {
+	case "service_account":
+		if creds.ClientEmail == "" || creds.PrivateKey == "" {
+			return nil, fmt.Errorf("google credentials %s: service account key has no client_email or private_key", path)
+		}
+	case "authorized_user":
+		if creds.RefreshToken == "" {
+			return nil, fmt.Errorf("google credentials %s: authorized user has no refresh_token", path)
+		}
+	default:
+		return nil, fmt.Errorf("google credentials %s: unsupported type %q (use a service account key or gcloud auth application-default login)", path, creds.Type)
+	}
+	if creds.TokenURI != "" {
+		ts.tokenURL = creds.TokenURI
+	}
+	ts.creds = &creds
+	ts.source = path
+	return ts, nil
+}
+
+// gcloudCredentialsPath returns where gcloud auth application-default login
+// writes credentials, or "" if the config directory can't be found.
+func gcloudCredentialsPath() string {
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return filepath.Join(dir, "application_default_credentials.json")
+	}
+	if runtime.GOOS == "windows" {
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return filepath.Join(dir, "gcloud", "application_default_credentials.json")
+		}
+		return ""
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".config", "gcloud", "application_default_credentials.json")
+}
+
+// Token returns a cached access token, or gets a new one.
+func (ts *googleTokenSource) Token(ctx context.Context) (string, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token != "" && time.Now().Before(ts.expiry.Add(-tokenExpiryMargin)) {
+		return ts.token, nil
+	}
+
+	var req *http.Request
+	var err error
+	switch {
+	case ts.creds == nil:
+		req, err = http.NewRequestWithContext(ctx, "GET", ts.metadataURL+"?scopes="+url.QueryEscape(googleCloudScope), nil)
+		if req != nil {
+			req.Header.Set("Metadata-Flavor", "Google")
+		}
+	case ts.creds.Type == "service_account":
+		var assertion string
+		if assertion, err = ts.signedJWT(); err != nil {
+			return "", err
+		}
+		req, err = newFormRequest(ctx, ts.tokenURL, url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		})
+	default:
+		req, err = newFormRequest(ctx, ts.tokenURL, url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {ts.creds.ClientID},
+			"client_secret": {ts.creds.ClientSecret},
+			"refresh_token": {ts.creds.RefreshToken},
+		})
+	}
+	if err != nil {
+		return "", err
+	}
+
+	token, expiresIn, err := ts.exchange(req)
+	if err != nil {
+		return "", err
+	}
+	ts.token = token
+	ts.expiry = time.Now().Add(time.Duration(expiresIn) * time.Second)
+	return ts.token, nil
+}
+
+// exchange sends a token request and reads the access token and its
+// lifetime in seconds from the response.
+func (ts *googleTokenSource) exchange(req *http.Request) (string, int, error) {
+	resp, err := ts.client.Do(req)
+	if err != nil {
+		return "", 0, &GoogleAuthError{Source: ts.source, Description: err.Error()}
+	}
+	defer resp.Body.Close() //nolint:errcheck // HTTP response body
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, &GoogleAuthError{Source: ts.source, Description: err.Error()}
+	}
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &result)
+	if resp.StatusCode != http.StatusOK || result.AccessToken == "" {
+		authErr := &GoogleAuthError{Source: ts.source, StatusCode: resp.StatusCode, Code: result.Error, Description: result.ErrorDescription}
+		if authErr.Code == "" && authErr.Description == "" {
+			authErr.Description = strings.TrimSpace(string(body))
+		}
+		return "", 0, authErr
+	}
+	return result.AccessToken, result.ExpiresIn, nil
+}
+
+// signedJWT returns the service account's RS256-signed token request
+// assertion.
+func (ts *googleTokenSource) signedJWT() (string, error) {
+	key, err := parseRSAPrivateKey(ts.creds.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("google credentials %s: %w", ts.source, err)
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": ts.creds.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   ts.creds.ClientEmail,
+		"scope": googleCloudScope,
+		"aud":   ts.tokenURL,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token request: %w", err)
+	}
+	return unsigned + "." + enc.EncodeToString(signature), nil
+}
+
+// parseRSAPrivateKey reads a PEM-encoded PKCS #8 or PKCS #1 RSA key.
+func parseRSAPrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("private_key is not PEM-encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse private_key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private_key is not an RSA key")
+	}
+	return key, nil
+}
+
+// newFormRequest creates a form-encoded POST request.
+func newFormRequest(ctx context.Context, target string, form url.Values) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", target, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return req, nil
+}
+
+// GoogleAuthError is a failure to get a Google Cloud access token, e.g. a
+// revoked refresh token (Code "invalid_grant") or no credentials at all.
+type GoogleAuthError struct {
+	Source      string // credentials file, or "metadata server"
+	StatusCode  int    // 0 if the token endpoint couldn't be reached
+	Code        string
+	Description string
+}
+
+func (e *GoogleAuthError) Error() string {
+	msg := e.Description
+	if e.Code != "" {
+		msg = strings.TrimSuffix(e.Code+": "+e.Description, ": ")
+	}
+	if e.Source == "metadata server" && e.StatusCode == 0 {
+		return fmt.Sprintf("no Google credentials found (set VERTEX_CREDENTIALS or GOOGLE_APPLICATION_CREDENTIALS, or run gcloud auth application-default login): %s", msg)
+	}
+	return fmt.Sprintf("google credentials %s: %s", e.Source, msg)
+}
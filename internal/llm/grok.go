@@ -15,10 +15,11 @@ const (
 
 // GrokProvider implements the Provider interface for xAI's Grok.
 type GrokProvider struct {
-	apiKey  string
-	model   string
-	client  *http.Client
-	baseURL string
+	apiKey   string
+	model    string
+	client   *http.Client
+	baseURL  string
+	sampling sampling
 }
 
 // NewGrokProvider creates a new Grok provider.
@@ -30,10 +31,11 @@ func NewGrokProvider(apiKey, model string, opts ProviderOptions) (*GrokProvider,
 	}
 
 	return &GrokProvider{
-		apiKey:  apiKey,
-		model:   model,
-		baseURL: opts.baseURLOr(grokAPIURL),
-		client:  newHTTPClient(opts.timeout()),
+		apiKey:   apiKey,
+		model:    model,
+		baseURL:  opts.baseURLOr(grokAPIURL),
+		client:   newHTTPClient(opts.timeout(), opts.Headers),
+		sampling: opts.sampling(),
 	}, nil
 }
 
@@ -68,6 +70,7 @@ func (p *GrokProvider) requestParams() llmRequestParams {
 		url:        p.baseURL,
 		headers:    p.headers(),
 		provider:   "grok",
+		sampling:   p.sampling,
 	}
 }
 
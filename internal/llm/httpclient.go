@@ -6,29 +6,114 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/dsswift/commit/internal/httpclient"
 )
 
-// newHTTPClient creates an HTTP client using the shared transport with the given timeout.
-func newHTTPClient(timeout time.Duration) *http.Client {
-	return httpclient.NewClient(timeout)
+// newHTTPClient creates an HTTP client using the shared transport with the
+// given timeout. headers are added to every request that doesn't set them.
+func newHTTPClient(timeout time.Duration, headers map[string]string) *http.Client {
+	client := httpclient.NewClient(timeout)
+	if len(headers) > 0 {
+		client.Transport = &headerTransport{base: client.Transport, headers: headers}
+	}
+	return client
+}
+
+// headerTransport adds extra headers, such as an organization or billing
+// project, to requests. A provider's own headers take precedence.
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		if req.Header.Get(k) == "" {
+			req.Header.Set(k, v)
+		}
+	}
+	return t.base.RoundTrip(req)
+}
+
+// defaultMaxAttempts is how many times a request is sent (1 initial + 2
+// retries) unless SetMaxAttempts says otherwise.
+const defaultMaxAttempts = 3
+
+const (
+	// maxBackoff caps the exponential backoff between attempts
+	maxBackoff = 30 * time.Second
+	// maxRetryAfter caps how long a Retry-After header can make a retry wait
+	maxRetryAfter = time.Minute
+)
+
+var (
+	maxAttempts = defaultMaxAttempts
+	// retryBaseDelay is the backoff before the first retry; it doubles for
+	// each one after
+	retryBaseDelay = 500 * time.Millisecond
+)
+
+// SetMaxAttempts sets how many times a request that fails with a network
+// error or a retryable status is sent in all; n <= 0 restores the default
+// of 3. Call it before making requests.
+func SetMaxAttempts(n int) {
+	if n <= 0 {
+		n = defaultMaxAttempts
+	}
+	maxAttempts = n
 }
 
-// maxRetries is the total number of attempts (1 initial + 2 retries).
-const maxRetries = 3
+// MaxAttempts returns how many times a failing request is sent in all.
+func MaxAttempts() int {
+	return maxAttempts
+}
 
 // retryableStatusCode returns true for HTTP status codes that warrant a retry.
 func retryableStatusCode(code int) bool {
 	return code == http.StatusTooManyRequests ||
+		code == http.StatusInternalServerError ||
 		code == http.StatusBadGateway ||
 		code == http.StatusServiceUnavailable ||
 		code == http.StatusGatewayTimeout
 }
 
+// retryDelay returns how long to wait before the given retry (1 for the
+// first): what the provider asked for in Retry-After, if anything, else
+// exponential backoff with jitter so clients limited together don't all
+// retry together.
+func retryDelay(retry int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryAfter)
+	}
+	backoff := maxBackoff
+	if retry < 16 {
+		backoff = min(retryBaseDelay<<(retry-1), maxBackoff)
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+// parseRetryAfter reads a Retry-After header, either delay seconds or an
+// HTTP date. It returns 0 if the header is missing or unreadable.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+	if sec, err := strconv.Atoi(strings.TrimSpace(header)); err == nil {
+		return max(time.Duration(sec)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
 // llmRequest describes an HTTP request to an LLM provider.
 type llmRequest struct {
 	ctx      context.Context
@@ -38,6 +123,9 @@ type llmRequest struct {
 	headers  map[string]string
 	body     interface{}
 	provider string
+	// parseError, if set, turns a failed response's body into the
+	// provider's typed error, which the ProviderError wraps
+	parseError func(body []byte) error
 }
 
 // llmResponse contains the raw HTTP response from an LLM provider.
@@ -48,23 +136,33 @@ type llmResponse struct {
 
 // doRequest marshals body, sends the HTTP request with retry, reads the response, and checks status.
 func doRequest(req *llmRequest) (*llmResponse, error) {
-	bodyBytes, err := json.Marshal(req.body)
-	if err != nil {
-		return nil, &ProviderError{Provider: req.provider, Message: "failed to marshal request", Err: err}
+	// GET requests (e.g. key checks) have no body
+	var bodyBytes []byte
+	if req.body != nil {
+		var err error
+		if bodyBytes, err = json.Marshal(req.body); err != nil {
+			return nil, &ProviderError{Provider: req.provider, Message: "failed to marshal request", Err: err}
+		}
 	}
 
 	var lastErr error
-	backoff := 500 * time.Millisecond
+	var retryAfter time.Duration
 
-	for attempt := 0; attempt < maxRetries; attempt++ {
+	for attempt := 0; attempt < maxAttempts; attempt++ {
 		if attempt > 0 {
-			// Wait with exponential backoff, respecting context cancellation
+			delay := retryDelay(attempt, retryAfter)
+			// Report the failure rather than a cancellation when the wait
+			// would outlast the deadline anyway
+			if deadline, ok := req.ctx.Deadline(); ok && time.Until(deadline) < delay {
+				return nil, lastErr
+			}
+			// Wait, respecting context cancellation
 			select {
 			case <-req.ctx.Done():
 				return nil, &ProviderError{Provider: req.provider, Message: "request cancelled", Err: req.ctx.Err()}
-			case <-time.After(backoff):
+			case <-time.After(delay):
 			}
-			backoff *= 2
+			retryAfter = 0
 		}
 
 		httpReq, err := http.NewRequestWithContext(req.ctx, req.method, req.url, bytes.NewReader(bodyBytes))
@@ -107,14 +205,26 @@ func doRequest(req *llmRequest) (*llmResponse, error) {
 		}
 
 		lastErr = &ProviderError{
-			Provider: req.provider,
-			Message:  fmt.Sprintf("API error (status %d): %s", resp.StatusCode, errorBody),
+			Provider:   req.provider,
+			Message:    fmt.Sprintf("API error (status %d): %s", resp.StatusCode, errorBody),
+			StatusCode: resp.StatusCode,
+		}
+		if req.parseError != nil {
+			if apiErr := req.parseError(respBody); apiErr != nil {
+				lastErr = &ProviderError{
+					Provider:   req.provider,
+					Message:    fmt.Sprintf("API error (status %d)", resp.StatusCode),
+					Err:        apiErr,
+					StatusCode: resp.StatusCode,
+				}
+			}
 		}
 
 		// Only retry on retryable status codes
 		if !retryableStatusCode(resp.StatusCode) {
 			return nil, lastErr
 		}
+		retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
 	}
 
 	return nil, lastErr
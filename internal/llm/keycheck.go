@@ -0,0 +1,160 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// keyChecker is implemented by providers that can verify their API key by
+// looking up their model, which costs no tokens.
+type keyChecker interface {
+	checkKey(ctx context.Context) error
+}
+
+// CheckKey makes an inexpensive authenticated request to verify provider's API
+// key and model. Providers without a model lookup send a minimal prompt.
+// A rejected key is reported as a *ProviderError whose Unauthorized is true.
+func CheckKey(ctx context.Context, provider Provider) error {
+	if checker, ok := provider.(keyChecker); ok {
+		return checker.checkKey(ctx)
+	}
+	_, err := provider.AnalyzeDiff(ctx, "Reply with OK.", "OK")
+	return err
+}
+
+// modelLister is implemented by providers that can list the models their
+// API key can use.
+type modelLister interface {
+	listModels(ctx context.Context) ([]string, error)
+}
+
+// ListModels returns the models provider can route requests to, sorted.
+// The bool is false for providers that have no model listing.
+func ListModels(ctx context.Context, provider Provider) ([]string, bool, error) {
+	lister, ok := provider.(modelLister)
+	if !ok {
+		return nil, false, nil
+	}
+	models, err := lister.listModels(ctx)
+	if err != nil {
+		return nil, true, err
+	}
+	slices.Sort(models)
+	return models, true, nil
+}
+
+// getModel requests url with headers, discarding the response.
+func getModel(ctx context.Context, params llmRequestParams, url string) error {
+	_, err := doRequest(&llmRequest{
+		ctx:        ctx,
+		client:     params.httpClient,
+		method:     "GET",
+		url:        url,
+		headers:    params.headers,
+		provider:   params.provider,
+		parseError: params.parseError,
+	})
+	return err
+}
+
+// listModelIDs requests an OpenAI-style model list, {"data": [{"id": ...}]},
+// and returns the IDs.
+func listModelIDs(ctx context.Context, params llmRequestParams, url string) ([]string, error) {
+	resp, err := doRequest(&llmRequest{
+		ctx:        ctx,
+		client:     params.httpClient,
+		method:     "GET",
+		url:        url,
+		headers:    params.headers,
+		provider:   params.provider,
+		parseError: params.parseError,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var listing struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp.Body, &listing); err != nil {
+		return nil, &ProviderError{Provider: params.provider, Message: "failed to parse model list", Err: err}
+	}
+	models := make([]string, 0, len(listing.Data))
+	for _, m := range listing.Data {
+		models = append(models, m.ID)
+	}
+	return models, nil
+}
+
+// checkModelListed returns a not-found ProviderError, with hint, unless
+// lister lists model.
+func checkModelListed(ctx context.Context, lister modelLister, provider, model, hint string) error {
+	models, err := lister.listModels(ctx)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(models, model) {
+		return &ProviderError{
+			Provider:   provider,
+			Message:    "model " + model + " is not available; " + hint,
+			StatusCode: http.StatusNotFound,
+		}
+	}
+	return nil
+}
+
+// chatModelURL derives the model endpoint from an OpenAI-compatible chat
+// completions URL, e.g. https://api.openai.com/v1/models/gpt-4o.
+func chatModelURL(chatURL, model string) string {
+	return strings.TrimSuffix(chatURL, "/chat/completions") + "/models/" + model
+}
+
+func (p *OpenAIProvider) checkKey(ctx context.Context) error {
+	// OpenAI-compatible servers list their models but may not serve one by ID
+	if p.compatible {
+		return checkModelListed(ctx, p, "openai", p.model, "set COMMIT_MODEL to a model the server lists")
+	}
+	return getModel(ctx, p.requestParams(), p.apiRoot+"/models/"+p.model)
+}
+
+func (p *GrokProvider) checkKey(ctx context.Context) error {
+	return getModel(ctx, p.requestParams(), chatModelURL(p.baseURL, p.model))
+}
+
+func (p *MistralProvider) checkKey(ctx context.Context) error {
+	return getModel(ctx, p.requestParams(), chatModelURL(p.baseURL, p.model))
+}
+
+func (p *AnthropicProvider) checkKey(ctx context.Context) error {
+	url := strings.TrimSuffix(p.baseURL, "/messages") + "/models/" + p.model
+	return getModel(ctx, llmRequestParams{httpClient: p.client, headers: p.headers(), provider: "anthropic"}, url)
+}
+
+func (p *GeminiProvider) checkKey(ctx context.Context) error {
+	url := strings.TrimSuffix(p.apiURL(), ":generateContent")
+	return getModel(ctx, llmRequestParams{httpClient: p.client, headers: p.headers(), provider: "gemini"}, url)
+}
+
+// checkKey gets an access token and counts a prompt's tokens, which checks
+// the credentials, project, location and model without generating anything.
+func (p *VertexProvider) checkKey(ctx context.Context) error {
+	params, err := p.requestParams(ctx, "countTokens")
+	if err != nil {
+		return err
+	}
+	_, err = doRequest(&llmRequest{
+		ctx:      ctx,
+		client:   params.httpClient,
+		method:   "POST",
+		url:      params.url,
+		headers:  params.headers,
+		body:     geminiRequest{Contents: []geminiContent{{Role: "user", Parts: []geminiPart{{Text: "OK"}}}}},
+		provider: params.provider,
+	})
+	return err
+}
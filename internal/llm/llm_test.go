@@ -1,9 +1,15 @@
 package llm
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/dsswift/commit/internal/convention"
 	"github.com/dsswift/commit/internal/testutil"
+	"github.com/dsswift/commit/internal/tokens"
 	"github.com/dsswift/commit/pkg/types"
 )
 
@@ -81,6 +87,29 @@ func TestBuildPrompt_NoRecentCommits(t *testing.T) {
 	}
 }
 
+func TestBuildPrompt_SuggestedScope(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "billing/invoice.go", Status: "modified", SuggestedScope: "billing"},
+			{Path: "README.md", Status: "modified"},
+		},
+		Diff: "diff",
+		Rules: types.CommitRules{
+			Types:            []string{"feat", "fix"},
+			MaxMessageLength: 50,
+		},
+	}
+
+	_, user := BuildPrompt(req)
+
+	if !testutil.ContainsString(user, "billing/invoice.go [modified]  → (no scope, suggested: billing)") {
+		t.Errorf("user prompt should show the suggested scope:\n%s", user)
+	}
+	if !testutil.ContainsString(user, "README.md [modified]  → (no scope)\n") {
+		t.Errorf("files without a suggestion should have no scope:\n%s", user)
+	}
+}
+
 func TestBuildPrompt_SingleCommit(t *testing.T) {
 	req := &types.AnalysisRequest{
 		Files: []types.FileChange{
@@ -103,6 +132,79 @@ func TestBuildPrompt_SingleCommit(t *testing.T) {
 	}
 }
 
+func TestBuildPrompt_ScopePolicy(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{{Path: "api/handler.go", Status: "modified"}},
+		Diff:  "diff",
+		Rules: types.CommitRules{Types: []string{"feat", "fix"}, MaxMessageLength: 50},
+	}
+
+	_, user := BuildPrompt(req)
+	if testutil.ContainsString(user, "MUST have a scope") || testutil.ContainsString(user, "Never use a scope") {
+		t.Error("user prompt should not mention a scope policy by default")
+	}
+
+	req.Rules.ScopePolicy = types.ScopePolicyRequired
+	if _, user := BuildPrompt(req); !testutil.ContainsString(user, "MUST have a scope") {
+		t.Error("user prompt should require scopes when scopePolicy is required")
+	}
+
+	req.Rules.ScopePolicy = types.ScopePolicyForbidden
+	if _, user := BuildPrompt(req); !testutil.ContainsString(user, "Never use a scope") {
+		t.Error("user prompt should forbid scopes when scopePolicy is forbidden")
+	}
+}
+
+func TestBuildPrompt_MessageBudget(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "rebase/todo.go", Status: "modified", Scope: "interactive-rebase"},
+			{Path: "main.go", Status: "modified"},
+		},
+		Diff:  "diff",
+		Rules: types.CommitRules{Types: []string{"feat", "docs"}, MaxMessageLength: 50},
+	}
+
+	_, user := BuildPrompt(req)
+	for _, want := range []string{
+		"- interactive-rebase: 24",
+		"- (no scope): 44",
+		"type and scope prefix included",
+	} {
+		if !testutil.ContainsString(user, want) {
+			t.Errorf("user prompt should contain %q:\n%s", want, user)
+		}
+	}
+
+	req.Rules.Types = []string{"feat", "fix"}
+	if _, user := BuildPrompt(req); !testutil.ContainsString(user, "- interactive-rebase: feat 24, fix 25") {
+		t.Errorf("user prompt should give each type its own budget:\n%s", user)
+	}
+
+	defer convention.Use(convention.Active())
+	convention.Use(convention.Plain{})
+	if _, user := BuildPrompt(req); !testutil.ContainsString(user, "- every commit: 50") {
+		t.Errorf("user prompt should give one budget when the prefix is fixed:\n%s", user)
+	}
+}
+
+func TestBuildPrompt_TypeGuesses(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{{Path: "README.md", Status: "modified"}},
+		Diff:  "diff",
+		Rules: types.CommitRules{Types: []string{"feat", "docs"}, MaxMessageLength: 50},
+	}
+
+	if _, user := BuildPrompt(req); testutil.ContainsString(user, "TYPE GUESSES") {
+		t.Error("user prompt should not have a TYPE GUESSES section without guesses")
+	}
+
+	req.TypeGuesses = []types.TypeGuess{{Files: []string{"README.md"}, Type: "docs", Confidence: 0.95, Reason: "documentation only"}}
+	if _, user := BuildPrompt(req); !testutil.ContainsString(user, "- docs (95%, documentation only): README.md") {
+		t.Errorf("user prompt should list the type guesses:\n%s", user)
+	}
+}
+
 func TestBuildPrompt_MultipleCommits(t *testing.T) {
 	req := &types.AnalysisRequest{
 		Files: []types.FileChange{
@@ -338,6 +440,23 @@ func TestNewProvider_Unsupported(t *testing.T) {
 	}
 }
 
+func TestEndpoint(t *testing.T) {
+	openai, _ := NewOpenAIProvider("key", "", ProviderOptions{})
+	if got := Endpoint(openai); got != openaiAPIURL {
+		t.Errorf("Endpoint(openai) = %q, want %q", got, openaiAPIURL)
+	}
+
+	custom, _ := NewAnthropicProvider("key", "", ProviderOptions{BaseURL: "https://llm.corp/v1/messages"})
+	if got := Endpoint(custom); got != "https://llm.corp/v1/messages" {
+		t.Errorf("Endpoint(anthropic) = %q, want the base URL override", got)
+	}
+
+	gemini, _ := NewGeminiProvider("key", "gemini-pro", ProviderOptions{})
+	if got := Endpoint(gemini); !strings.Contains(got, "/models/gemini-pro:generateContent") {
+		t.Errorf("Endpoint(gemini) = %q, want the model's URL", got)
+	}
+}
+
 func TestProviderError(t *testing.T) {
 	err := &ProviderError{
 		Provider: "anthropic",
@@ -467,3 +586,337 @@ func TestBuildPrompt_WithoutGuidingMessage(t *testing.T) {
 		t.Error("user prompt should NOT contain USER CONTEXT when GuidingMessage is empty")
 	}
 }
+
+func TestBuildPrompt_ModeChange(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "deploy.sh", Status: "modified", DiffSummary: "+0 -0", ModeChange: "made executable"},
+			{Path: "run.sh", Status: "modified", DiffSummary: "+1 -0", ModeChange: "made executable"},
+		},
+		Diff: "diff",
+		Rules: types.CommitRules{
+			Types:            []string{"feat", "chore"},
+			MaxMessageLength: 50,
+		},
+	}
+
+	system, user := BuildPrompt(req)
+
+	if !testutil.ContainsString(user, "deploy.sh [modified] (mode only: made executable) →") {
+		t.Errorf("user prompt should mark the mode-only change:\n%s", user)
+	}
+	if !testutil.ContainsString(user, "run.sh [modified] +1 -0 (made executable) →") {
+		t.Errorf("user prompt should note the mode change alongside content changes:\n%s", user)
+	}
+	if !testutil.ContainsString(system, `"mode only"`) {
+		t.Error("system prompt should explain mode-only files")
+	}
+}
+
+func TestBuildPrompt_LFSChange(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "art/hero.psd", Status: "modified", DiffSummary: "+2 -2", LFSChange: "LFS asset updated (1.0 KB → 3.0 MB)"},
+		},
+		Diff: "diff",
+		Rules: types.CommitRules{
+			Types:            []string{"feat", "chore"},
+			MaxMessageLength: 50,
+		},
+	}
+
+	system, user := BuildPrompt(req)
+
+	if !testutil.ContainsString(user, "art/hero.psd [modified] (LFS asset updated (1.0 KB → 3.0 MB)) →") {
+		t.Errorf("user prompt should summarize the LFS change instead of the pointer diff:\n%s", user)
+	}
+	if !testutil.ContainsString(system, `"LFS asset"`) {
+		t.Error("system prompt should explain LFS assets")
+	}
+}
+
+func TestBuildPrompt_Symlink(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "current.yaml", Status: "modified", DiffSummary: "+1 -1", Symlink: "symlink retargeted (dev.yaml → prod.yaml)"},
+		},
+		Diff: "diff",
+		Rules: types.CommitRules{
+			Types:            []string{"feat", "chore"},
+			MaxMessageLength: 50,
+		},
+	}
+
+	system, user := BuildPrompt(req)
+
+	if !testutil.ContainsString(user, "current.yaml [modified] (symlink retargeted (dev.yaml → prod.yaml)) →") {
+		t.Errorf("user prompt should describe the symlink change:\n%s", user)
+	}
+	if !testutil.ContainsString(system, `"symlink"`) {
+		t.Error("system prompt should explain symlinks")
+	}
+}
+
+func TestBuildPrompt_Focused(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "api/handler.go", Status: "modified", DiffSummary: "+1 -0", Focused: true},
+			{Path: "web/app.ts", Status: "modified", DiffSummary: "+1 -1"},
+		},
+		Diff: "diff",
+		Rules: types.CommitRules{
+			Types:            []string{"feat", "chore"},
+			MaxMessageLength: 50,
+		},
+	}
+
+	system, user := BuildPrompt(req)
+
+	if !testutil.ContainsString(user, "api/handler.go [modified] +1 -0 (focus) →") {
+		t.Errorf("user prompt should mark the focused file:\n%s", user)
+	}
+	if testutil.ContainsString(user, "web/app.ts [modified] +1 -1 (focus)") {
+		t.Errorf("user prompt should not mark unfocused files:\n%s", user)
+	}
+	if !testutil.ContainsString(system, `"focus"`) {
+		t.Error("system prompt should explain focused files")
+	}
+}
+
+func TestBuildPrompt_Convention(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{{Path: "file1.go", Status: "modified"}},
+		Diff:  "diff",
+		Rules: types.CommitRules{
+			Types:            []string{"feat", "fix"},
+			MaxMessageLength: 50,
+			Convention:       "gitmoji",
+		},
+	}
+
+	system, _ := BuildPrompt(req)
+
+	if !testutil.ContainsString(system, "gitmoji") {
+		t.Error("system prompt should describe the gitmoji convention")
+	}
+	if testutil.ContainsString(system, "Use conventional commit format") {
+		t.Error("system prompt should not ask for conventional commits")
+	}
+}
+
+func TestBuildPrompt_Templates(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files:         []types.FileChange{{Path: "api/handler.go", Status: "modified", DiffSummary: "+1 -1", Scope: "api"}},
+		Diff:          "the diff",
+		RecentCommits: []string{"feat(api): add handler"},
+		Rules:         types.CommitRules{Types: []string{"feat", "fix"}, MaxMessageLength: 50},
+	}
+	builtInSystem, builtInUser := BuildPrompt(req)
+
+	req.Prompts = &types.PromptTemplates{
+		User: "{{range .Request.Files}}{{.Path}} {{end}}| {{.Diff}} | {{.AllowedTypes}} | {{.Rules.MaxMessageLength}}\n{{.RecentCommits}}",
+	}
+	system, user := BuildPrompt(req)
+	if system != builtInSystem {
+		t.Error("system prompt without a template should be the built-in one")
+	}
+	if want := "api/handler.go | the diff | feat | fix | 50\n- feat(api): add handler\n"; user != want {
+		t.Errorf("user prompt = %q, want %q", user, want)
+	}
+
+	// A template can add to the built-in prompt
+	req.Prompts = &types.PromptTemplates{System: "{{.Default}}\nWrite messages in German."}
+	system, user = BuildPrompt(req)
+	if system != builtInSystem+"\nWrite messages in German." || user != builtInUser {
+		t.Errorf("expected the built-in system prompt plus a rule, got:\n%s", system)
+	}
+}
+
+func TestCheckPromptTemplates(t *testing.T) {
+	if err := CheckPromptTemplates(nil); err != nil {
+		t.Errorf("no templates: %v", err)
+	}
+	valid := &types.PromptTemplates{System: "{{.Default}}", User: "{{.Files}}{{.Diff}}{{.Budgets}}{{.TypeGuesses}}{{.Convention}}"}
+	if err := CheckPromptTemplates(valid); err != nil {
+		t.Errorf("valid templates: %v", err)
+	}
+
+	for _, bad := range []*types.PromptTemplates{
+		{System: "{{.Default"},
+		{User: "{{.Dif}}"},
+	} {
+		if err := CheckPromptTemplates(bad); err == nil {
+			t.Errorf("expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestPromptAndPlanTokens(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{{Path: "file1.go", Status: "modified"}},
+		Diff:  strings.Repeat("+line\n", 200),
+		Rules: types.CommitRules{Types: []string{"feat"}, MaxMessageLength: 50},
+	}
+	system, user := BuildPrompt(req)
+	if got, want := PromptTokens("gpt-4o", req), tokens.Count("gpt-4o", system)+tokens.Count("gpt-4o", user); got != want {
+		t.Errorf("PromptTokens = %d, want %d", got, want)
+	}
+	if PromptTokens("claude-3-5-sonnet", req) <= PromptTokens("gpt-4o", req) {
+		t.Error("expected Claude's tokenizer to count more tokens than GPT-4o's")
+	}
+
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{{Type: "feat", Message: "add file", Files: []string{"file1.go"}}}}
+	if n := PlanTokens("gpt-4o", plan); n == 0 {
+		t.Error("expected PlanTokens to count the plan")
+	}
+}
+
+func TestFitPrompt(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{{Path: "big.txt", Status: "modified"}},
+		Diff:  strings.Repeat("+some added line\n", 10000),
+		Rules: types.CommitRules{Types: []string{"feat"}, MaxMessageLength: 50},
+	}
+
+	// Claude's window holds the whole diff
+	fitted, fit := FitPrompt("claude-3-5-sonnet", 0, req)
+	if fitted != req || fit.Dropped != 0 || fit.Tokens != PromptTokens("claude-3-5-sonnet", req) {
+		t.Errorf("expected the request to fit unchanged, got %+v", fit)
+	}
+
+	// A local model's default window doesn't
+	fitted, fit = FitPrompt("llama3.1", 0, req)
+	if fitted == req || fitted.Diff == req.Diff {
+		t.Fatal("expected a trimmed copy of the request")
+	}
+	if !strings.HasSuffix(fitted.Diff, "\n\n... (truncated)") || !strings.HasPrefix(req.Diff, strings.TrimSuffix(fitted.Diff, "\n\n... (truncated)")) {
+		t.Errorf("expected the diff cut at a line with a marker, got ...%q", fitted.Diff[len(fitted.Diff)-40:])
+	}
+	if fit.Window != tokens.DefaultContextWindow || fit.Dropped == 0 {
+		t.Errorf("unexpected fit %+v", fit)
+	}
+	if limit := fit.Window - defaultMaxTokens; fit.Tokens > limit || fit.Tokens < limit*9/10 {
+		t.Errorf("prompt takes %d tokens, want just under %d", fit.Tokens, limit)
+	}
+	if fit.Tokens != PromptTokens("llama3.1", fitted) {
+		t.Errorf("Tokens = %d, want the trimmed prompt's %d", fit.Tokens, PromptTokens("llama3.1", fitted))
+	}
+
+	// Providers send the trimmed diff
+	system, user := buildFittedPrompt("llama3.1", 0, req)
+	if tokens.Count("llama3.1", system)+tokens.Count("llama3.1", user) != fit.Tokens {
+		t.Error("buildFittedPrompt didn't trim like FitPrompt")
+	}
+
+	// A smaller answer cap leaves more of the window to the prompt
+	_, small := FitPrompt("llama3.1", 2048, req)
+	if small.Tokens <= fit.Tokens || small.Tokens > fit.Window-2048 {
+		t.Errorf("with 2048 max tokens the prompt takes %d tokens, want more than %d and at most %d", small.Tokens, fit.Tokens, fit.Window-2048)
+	}
+}
+
+func TestSplitRequest(t *testing.T) {
+	section := func(file string, lines int) string {
+		return "diff --git a/" + file + " b/" + file + "\n--- a/" + file + "\n+++ b/" + file + "\n@@ -0,0 +1 @@\n" +
+			strings.Repeat("+some added line\n", lines)
+	}
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "api/a.go", Status: "modified", Scope: "api"},
+			{Path: "web/b.ts", Status: "modified"},
+			{Path: "api/c.go", Status: "modified", Scope: "api"},
+			{Path: "web/d.ts", Status: "modified"},
+		},
+		Diff:        section("api/a.go", 800) + section("web/b.ts", 800) + section("api/c.go", 800) + section("web/d.ts", 800),
+		Rules:       types.CommitRules{Types: []string{"feat"}, MaxMessageLength: 50},
+		TypeGuesses: []types.TypeGuess{{Files: []string{"api/a.go", "web/b.ts"}, Type: "feat", Confidence: 0.5, Reason: "code"}},
+	}
+
+	// Claude's window holds everything
+	if chunks := SplitRequest("claude-3-5-sonnet", 0, req); len(chunks) != 1 || chunks[0] != req {
+		t.Fatalf("expected the request alone, got %d chunks", len(chunks))
+	}
+
+	// With a tight answer cap leaving llama3.1 room for about two files,
+	// files stay with their scope or directory
+	window := tokens.ContextWindow("llama3.1")
+	overhead := PromptTokens("llama3.1", subRequest(req, nil))
+	maxTokens := window - (PromptTokens("llama3.1", req)+overhead)/2 - 200
+	chunks := SplitRequest("llama3.1", maxTokens, req)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	for i, want := range [][]string{{"api/a.go", "api/c.go"}, {"web/b.ts", "web/d.ts"}} {
+		var got []string
+		for _, f := range chunks[i].Files {
+			got = append(got, f.Path)
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("chunk %d files = %v, want %v", i+1, got, want)
+		}
+		if chunks[i].Diff != section(want[0], 800)+section(want[1], 800) {
+			t.Errorf("chunk %d has the wrong diff", i+1)
+		}
+		if PromptTokens("llama3.1", chunks[i]) > window-maxTokens {
+			t.Errorf("chunk %d doesn't fit: %d tokens", i+1, PromptTokens("llama3.1", chunks[i]))
+		}
+	}
+	if g := chunks[1].TypeGuesses; len(g) != 1 || len(g[0].Files) != 1 || g[0].Files[0] != "web/b.ts" {
+		t.Errorf("expected the type guess narrowed to web/b.ts, got %+v", g)
+	}
+
+	// A single commit covers every file, so it isn't split
+	single := *req
+	single.SingleCommit = true
+	if chunks := SplitRequest("llama3.1", maxTokens, &single); len(chunks) != 1 {
+		t.Errorf("expected a single-commit request alone, got %d chunks", len(chunks))
+	}
+}
+
+func TestResponseCache(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewResponseCache(dir, time.Hour)
+	req := &types.AnalysisRequest{Files: []types.FileChange{{Path: "main.go", Status: "modified"}}, Diff: "+x"}
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{{Type: "fix", Message: "fix crash", Files: []string{"main.go"}}}}
+
+	key := ResponseKey(req, "anthropic", "claude-sonnet-4-5", "temperature=0")
+	if _, ok := cache.Load(key); ok {
+		t.Fatal("empty cache returned a response")
+	}
+	cache.Save(key, "anthropic", "claude-sonnet-4-5", plan)
+	got, ok := cache.Load(key)
+	if !ok || got.Model != "claude-sonnet-4-5" || len(got.Plan.Commits) != 1 || got.Plan.Commits[0].Message != "fix crash" {
+		t.Fatalf("Load = %+v, %v", got, ok)
+	}
+
+	// Anything that changes the answer changes the key
+	changed := *req
+	changed.Diff = "+y"
+	for name, other := range map[string]string{
+		"diff":     ResponseKey(&changed, "anthropic", "claude-sonnet-4-5", "temperature=0"),
+		"provider": ResponseKey(req, "openai", "claude-sonnet-4-5", "temperature=0"),
+		"model":    ResponseKey(req, "anthropic", "claude-haiku-4-5", "temperature=0"),
+		"sampling": ResponseKey(req, "anthropic", "claude-sonnet-4-5", "temperature=1"),
+	} {
+		if other == key {
+			t.Errorf("changing the %s kept the key", name)
+		}
+	}
+
+	// Expired entries aren't returned, and are pruned on the next save
+	expired := NewResponseCache(dir, time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	if _, ok := expired.Load(key); ok {
+		t.Error("expired response was returned")
+	}
+	expired.Save("other", "anthropic", "claude-sonnet-4-5", plan)
+	if _, err := os.Stat(filepath.Join(dir, key+".json")); err == nil {
+		t.Error("expired response wasn't pruned")
+	}
+
+	var disabled *ResponseCache
+	disabled.Save(key, "anthropic", "claude-sonnet-4-5", plan)
+	if _, ok := disabled.Load(key); ok {
+		t.Error("nil cache returned a response")
+	}
+}
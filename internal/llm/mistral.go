@@ -0,0 +1,159 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+const (
+	mistralAPIURL       = "https://api.mistral.ai/v1/chat/completions"
+	defaultMistralModel = "mistral-large-latest"
+)
+
+// MistralProvider implements the Provider interface for Mistral AI, e.g.
+// mistral-large-latest or codestral-latest.
+type MistralProvider struct {
+	apiKey   string
+	model    string
+	client   *http.Client
+	baseURL  string
+	sampling sampling
+}
+
+// NewMistralProvider creates a new Mistral provider.
+func NewMistralProvider(apiKey, model string, opts ProviderOptions) (*MistralProvider, error) {
+	assert.NotEmptyString(apiKey, "Mistral API key is required")
+
+	if model == "" {
+		model = defaultMistralModel
+	}
+
+	return &MistralProvider{
+		apiKey:   apiKey,
+		model:    model,
+		baseURL:  opts.baseURLOr(mistralAPIURL),
+		client:   newHTTPClient(opts.timeout(), opts.Headers),
+		sampling: opts.sampling(),
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *MistralProvider) Name() string {
+	return "mistral"
+}
+
+// Model returns the model being used.
+func (p *MistralProvider) Model() string {
+	return p.model
+}
+
+// Analyze sends an analysis request to Mistral and returns a commit plan.
+// Mistral uses an OpenAI-compatible API.
+func (p *MistralProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	assert.NotNil(req, "analysis request cannot be nil")
+	assert.NotEmpty(req.Files, "analysis request must have files")
+
+	return analyzeChatCompletion(ctx, p.requestParams(), req)
+}
+
+// AnalyzeDiff sends a diff analysis request to Mistral and returns the analysis.
+func (p *MistralProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	return analyzeDiffChatCompletion(ctx, p.requestParams(), system, user)
+}
+
+func (p *MistralProvider) requestParams() llmRequestParams {
+	return llmRequestParams{
+		httpClient: p.client,
+		model:      p.model,
+		url:        p.baseURL,
+		headers:    p.headers(),
+		provider:   "mistral",
+		parseError: parseMistralError,
+		sampling:   p.sampling,
+	}
+}
+
+func (p *MistralProvider) headers() map[string]string {
+	return map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + p.apiKey,
+	}
+}
+
+// MistralError is an error response from the Mistral API. Type is empty for
+// errors from its gateway, such as a rejected key or a rate limit.
+type MistralError struct {
+	Type    string
+	Message string
+	Code    string
+}
+
+func (e *MistralError) Error() string {
+	msg := e.Message
+	if e.Code != "" {
+		msg += " (code " + e.Code + ")"
+	}
+	if e.Type != "" {
+		return e.Type + ": " + msg
+	}
+	return msg
+}
+
+// ContextExceeded reports whether the request was rejected for exceeding the
+// model's context window, so a smaller diff may succeed.
+func (e *MistralError) ContextExceeded() bool {
+	return e.Type == "invalid_request_error" && strings.Contains(e.Message, "too large for model")
+}
+
+// parseMistralError parses a Mistral error body, or returns nil if it isn't
+// one. The message is a string, or for request validation errors an object
+// listing the invalid fields.
+func parseMistralError(body []byte) error {
+	var resp struct {
+		Type    string          `json:"type"`
+		Message json.RawMessage `json:"message"`
+		Code    json.RawMessage `json:"code"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || len(resp.Message) == 0 {
+		return nil
+	}
+
+	apiErr := &MistralError{Type: resp.Type}
+	var text string
+	var validation struct {
+		Detail []struct {
+			Loc []any  `json:"loc"`
+			Msg string `json:"msg"`
+		} `json:"detail"`
+	}
+	switch {
+	case json.Unmarshal(resp.Message, &text) == nil:
+		apiErr.Message = text
+	case json.Unmarshal(resp.Message, &validation) == nil && len(validation.Detail) > 0:
+		var details []string
+		for _, d := range validation.Detail {
+			loc := make([]string, len(d.Loc))
+			for i, l := range d.Loc {
+				loc[i] = fmt.Sprint(l)
+			}
+			details = append(details, strings.Join(loc, ".")+": "+d.Msg)
+		}
+		apiErr.Message = strings.Join(details, "; ")
+	default:
+		return nil
+	}
+
+	if code := strings.Trim(string(resp.Code), `"`); code != "null" {
+		apiErr.Code = code
+	}
+	if len(apiErr.Message) > 500 {
+		apiErr.Message = apiErr.Message[:500] + "... (truncated)"
+	}
+	return apiErr
+}
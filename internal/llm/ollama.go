@@ -0,0 +1,168 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+const (
+	defaultOllamaHost  = "http://localhost:11434"
+	defaultOllamaModel = "llama3.1"
+	// ollamaTimeoutSec is the default timeout for Ollama, which runs models
+	// on local hardware and can take minutes on a large diff.
+	ollamaTimeoutSec = 300
+)
+
+// OllamaProvider implements the Provider interface for a local Ollama server.
+// Diffs never leave the machine (or the network the server runs on).
+type OllamaProvider struct {
+	model    string
+	client   *http.Client
+	baseURL  string
+	sampling sampling
+}
+
+// NewOllamaProvider creates a new Ollama provider for the server at host,
+// e.g. "http://localhost:11434". An empty host uses Ollama's default.
+func NewOllamaProvider(host, model string, opts ProviderOptions) (*OllamaProvider, error) {
+	if host == "" {
+		host = defaultOllamaHost
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	if model == "" {
+		model = defaultOllamaModel
+	}
+
+	timeout := DefaultTimeout("ollama")
+	if opts.TimeoutSec > 0 {
+		timeout = opts.timeout()
+	}
+
+	return &OllamaProvider{
+		model:    model,
+		baseURL:  opts.baseURLOr(strings.TrimSuffix(host, "/") + "/api/chat"),
+		client:   newHTTPClient(timeout, opts.Headers),
+		sampling: opts.sampling(),
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *OllamaProvider) Name() string {
+	return "ollama"
+}
+
+// Model returns the model being used.
+func (p *OllamaProvider) Model() string {
+	return p.model
+}
+
+// Analyze sends an analysis request to Ollama and returns a commit plan.
+// The response is constrained to JSON, which smaller local models otherwise
+// tend to wrap in prose.
+func (p *OllamaProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	assert.NotNil(req, "analysis request cannot be nil")
+	assert.NotEmpty(req.Files, "analysis request must have files")
+
+	systemPrompt, userPrompt := buildFittedPrompt(p.model, p.sampling.maxTokens, req)
+	content, truncated, err := p.chat(ctx, systemPrompt, userPrompt, "json")
+	if err != nil {
+		return nil, err
+	}
+	return processAnalyzeResponse("ollama", content, truncated)
+}
+
+// AnalyzeDiff sends a diff analysis request to Ollama and returns the analysis.
+func (p *OllamaProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	content, truncated, err := p.chat(ctx, system, user, "")
+	if err != nil {
+		return "", err
+	}
+	return processTextResponse("ollama", content, truncated)
+}
+
+// chat sends a non-streaming chat request and returns the reply and whether
+// it was cut off at the token limit.
+func (p *OllamaProvider) chat(ctx context.Context, system, user, format string) (string, bool, error) {
+	requestBody := ollamaRequest{
+		Model: p.model,
+		Messages: []chatMessage{
+			{Role: "system", Content: system},
+			{Role: "user", Content: user},
+		},
+		Stream: false,
+		Format: format,
+		Options: ollamaOptions{
+			Temperature: p.sampling.temperature,
+			Seed:        p.sampling.seed,
+			TopP:        p.sampling.topP,
+			NumPredict:  p.sampling.maxTokens,
+		},
+	}
+
+	resp, err := doRequest(&llmRequest{
+		ctx:      ctx,
+		client:   p.client,
+		method:   "POST",
+		url:      p.baseURL,
+		headers:  p.headers(),
+		body:     requestBody,
+		provider: "ollama",
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	var ollamaResp ollamaResponse
+	if err := json.Unmarshal(resp.Body, &ollamaResp); err != nil {
+		return "", false, &ProviderError{Provider: "ollama", Message: "failed to parse response", Err: err}
+	}
+	return ollamaResp.Message.Content, ollamaResp.DoneReason == "length", nil
+}
+
+func (p *OllamaProvider) headers() map[string]string {
+	return map[string]string{
+		"Content-Type": "application/json",
+	}
+}
+
+// checkKey verifies the server is reachable and has the model pulled.
+// Ollama has no API keys.
+func (p *OllamaProvider) checkKey(ctx context.Context) error {
+	_, err := doRequest(&llmRequest{
+		ctx:      ctx,
+		client:   p.client,
+		method:   "POST",
+		url:      strings.TrimSuffix(p.baseURL, "/chat") + "/show",
+		headers:  p.headers(),
+		body:     map[string]string{"model": p.model},
+		provider: "ollama",
+	})
+	return err
+}
+
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []chatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Format   string        `json:"format,omitempty"`
+	Options  ollamaOptions `json:"options"`
+}
+
+type ollamaOptions struct {
+	Temperature float64  `json:"temperature"`
+	Seed        *int     `json:"seed,omitempty"`
+	TopP        *float64 `json:"top_p,omitempty"`
+	NumPredict  int      `json:"num_predict,omitempty"`
+}
+
+type ollamaResponse struct {
+	Message    chatMessage `json:"message"`
+	DoneReason string      `json:"done_reason"`
+}
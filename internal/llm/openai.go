@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"net/http"
+	"strings"
 
 	"github.com/dsswift/commit/internal/assert"
 	"github.com/dsswift/commit/pkg/types"
@@ -13,27 +14,51 @@ const (
 	defaultOpenAIModel = "gpt-4-turbo-preview"
 )
 
-// OpenAIProvider implements the Provider interface for OpenAI.
+// OpenAIProvider implements the Provider interface for OpenAI, or for an
+// OpenAI-compatible server such as vLLM, LM Studio or a LiteLLM proxy.
 type OpenAIProvider struct {
 	apiKey  string
 	model   string
 	client  *http.Client
 	baseURL string
+	// apiRoot is the URL models are listed under, e.g. .../v1
+	apiRoot string
+	// compatible is set for a server configured with an API path, which
+	// lists its models but may not look them up one at a time
+	compatible bool
+	sampling   sampling
 }
 
-// NewOpenAIProvider creates a new OpenAI provider.
+// NewOpenAIProvider creates a new OpenAI provider. With opts.APIPath set,
+// requests go to opts.BaseURL+opts.APIPath and the API key may be empty.
 func NewOpenAIProvider(apiKey, model string, opts ProviderOptions) (*OpenAIProvider, error) {
-	assert.NotEmptyString(apiKey, "OpenAI API key is required")
-
 	if model == "" {
 		model = defaultOpenAIModel
 	}
 
+	if opts.APIPath != "" {
+		assert.NotEmptyString(opts.BaseURL, "OpenAI-compatible base URL is required with an API path")
+		apiRoot := strings.TrimRight(opts.BaseURL, "/")
+		return &OpenAIProvider{
+			apiKey:     apiKey,
+			model:      model,
+			baseURL:    apiRoot + opts.APIPath,
+			apiRoot:    apiRoot,
+			compatible: true,
+			client:     newHTTPClient(opts.timeout(), opts.Headers),
+			sampling:   opts.sampling(),
+		}, nil
+	}
+
+	assert.NotEmptyString(apiKey, "OpenAI API key is required")
+	baseURL := opts.baseURLOr(openaiAPIURL)
 	return &OpenAIProvider{
-		apiKey:  apiKey,
-		model:   model,
-		baseURL: opts.baseURLOr(openaiAPIURL),
-		client:  newHTTPClient(opts.timeout()),
+		apiKey:   apiKey,
+		model:    model,
+		baseURL:  baseURL,
+		apiRoot:  strings.TrimSuffix(baseURL, "/chat/completions"),
+		client:   newHTTPClient(opts.timeout(), opts.Headers),
+		sampling: opts.sampling(),
 	}, nil
 }
 
@@ -67,12 +92,20 @@ func (p *OpenAIProvider) requestParams() llmRequestParams {
 		url:        p.baseURL,
 		headers:    p.headers(),
 		provider:   "openai",
+		sampling:   p.sampling,
 	}
 }
 
 func (p *OpenAIProvider) headers() map[string]string {
-	return map[string]string{
-		"Content-Type":  "application/json",
-		"Authorization": "Bearer " + p.apiKey,
+	headers := map[string]string{"Content-Type": "application/json"}
+	// Self-hosted servers may run without a key
+	if p.apiKey != "" {
+		headers["Authorization"] = "Bearer " + p.apiKey
 	}
+	return headers
+}
+
+// listModels returns the IDs of the models the API serves.
+func (p *OpenAIProvider) listModels(ctx context.Context) ([]string, error) {
+	return listModelIDs(ctx, p.requestParams(), p.apiRoot+"/models")
 }
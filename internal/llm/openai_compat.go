@@ -1,6 +1,7 @@
 package llm
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"net/http"
@@ -13,7 +14,9 @@ import (
 type chatRequest struct {
 	Model       string        `json:"model,omitempty"`
 	Messages    []chatMessage `json:"messages"`
-	Temperature float64       `json:"temperature,omitempty"`
+	Temperature *float64      `json:"temperature,omitempty"`
+	Seed        *int          `json:"seed,omitempty"`
+	TopP        *float64      `json:"top_p,omitempty"`
 	MaxTokens   int           `json:"max_tokens,omitempty"`
 }
 
@@ -45,12 +48,22 @@ type llmRequestParams struct {
 	url        string
 	headers    map[string]string
 	provider   string
+	parseError func(body []byte) error
+	sampling   sampling
+}
+
+// seed returns the seed to send, or nil if the provider's API takes none.
+func (p llmRequestParams) seed() *int {
+	if !SupportsSeed(p.provider) {
+		return nil
+	}
+	return p.sampling.seed
 }
 
 // analyzeChatCompletion sends an analysis request using the OpenAI-compatible chat completions format
 // and returns a parsed CommitPlan.
 func analyzeChatCompletion(ctx context.Context, params llmRequestParams, req *types.AnalysisRequest) (*types.CommitPlan, error) {
-	systemPrompt, userPrompt := BuildPrompt(req)
+	systemPrompt, userPrompt := buildFittedPrompt(cmp.Or(params.model, params.provider), params.sampling.maxTokens, req)
 
 	requestBody := chatRequest{
 		Model: params.model,
@@ -58,18 +71,21 @@ func analyzeChatCompletion(ctx context.Context, params llmRequestParams, req *ty
 			{Role: "system", Content: systemPrompt},
 			{Role: "user", Content: userPrompt},
 		},
-		Temperature: 0.3,
-		MaxTokens:   8192,
+		Temperature: &params.sampling.temperature,
+		Seed:        params.seed(),
+		TopP:        params.sampling.topP,
+		MaxTokens:   params.sampling.maxTokens,
 	}
 
 	resp, err := doRequest(&llmRequest{
-		ctx:      ctx,
-		client:   params.httpClient,
-		method:   "POST",
-		url:      params.url,
-		headers:  params.headers,
-		body:     requestBody,
-		provider: params.provider,
+		ctx:        ctx,
+		client:     params.httpClient,
+		method:     "POST",
+		url:        params.url,
+		headers:    params.headers,
+		body:       requestBody,
+		provider:   params.provider,
+		parseError: params.parseError,
 	})
 	if err != nil {
 		return nil, err
@@ -92,7 +108,7 @@ func analyzeChatCompletion(ctx context.Context, params llmRequestParams, req *ty
 
 	var plan types.CommitPlan
 	if err := json.Unmarshal([]byte(content), &plan); err != nil {
-		return nil, &ProviderError{Provider: params.provider, Message: "failed to parse commit plan", Err: err}
+		return nil, &ProviderError{Provider: params.provider, Message: "failed to parse commit plan", Err: &PlanParseError{Content: content, Err: err}}
 	}
 
 	return &plan, nil
@@ -106,18 +122,21 @@ func analyzeDiffChatCompletion(ctx context.Context, params llmRequestParams, sys
 			{Role: "system", Content: system},
 			{Role: "user", Content: user},
 		},
-		Temperature: 0.3,
-		MaxTokens:   8192,
+		Temperature: &params.sampling.temperature,
+		Seed:        params.seed(),
+		TopP:        params.sampling.topP,
+		MaxTokens:   params.sampling.maxTokens,
 	}
 
 	resp, err := doRequest(&llmRequest{
-		ctx:      ctx,
-		client:   params.httpClient,
-		method:   "POST",
-		url:      params.url,
-		headers:  params.headers,
-		body:     requestBody,
-		provider: params.provider,
+		ctx:        ctx,
+		client:     params.httpClient,
+		method:     "POST",
+		url:        params.url,
+		headers:    params.headers,
+		body:       requestBody,
+		provider:   params.provider,
+		parseError: params.parseError,
 	})
 	if err != nil {
 		return "", err
@@ -0,0 +1,111 @@
+package llm
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+const (
+	openRouterAPIURL       = "https://openrouter.ai/api/v1/chat/completions"
+	defaultOpenRouterModel = "anthropic/claude-3.5-sonnet"
+	// OpenRouter shows these on its usage pages to attribute requests to an app.
+	defaultOpenRouterReferer = "https://github.com/dsswift/commit"
+	defaultOpenRouterTitle   = "commit"
+)
+
+// OpenRouterProvider implements the Provider interface for OpenRouter, which
+// routes an OpenAI-compatible API to many vendors' models with one key.
+// Models are named vendor/model, e.g. "openai/gpt-4o".
+type OpenRouterProvider struct {
+	apiKey   string
+	model    string
+	client   *http.Client
+	baseURL  string
+	sampling sampling
+}
+
+// NewOpenRouterProvider creates a new OpenRouter provider. The HTTP-Referer
+// and X-Title headers identify the tool unless opts.Headers sets them.
+func NewOpenRouterProvider(apiKey, model string, opts ProviderOptions) (*OpenRouterProvider, error) {
+	assert.NotEmptyString(apiKey, "OpenRouter API key is required")
+
+	if model == "" {
+		model = defaultOpenRouterModel
+	}
+
+	headers := map[string]string{
+		"HTTP-Referer": defaultOpenRouterReferer,
+		"X-Title":      defaultOpenRouterTitle,
+	}
+	for k, v := range opts.Headers {
+		headers[k] = v
+	}
+
+	return &OpenRouterProvider{
+		apiKey:   apiKey,
+		model:    model,
+		baseURL:  opts.baseURLOr(openRouterAPIURL),
+		client:   newHTTPClient(opts.timeout(), headers),
+		sampling: opts.sampling(),
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *OpenRouterProvider) Name() string {
+	return "openrouter"
+}
+
+// Model returns the model being used.
+func (p *OpenRouterProvider) Model() string {
+	return p.model
+}
+
+// Analyze sends an analysis request to OpenRouter and returns a commit plan.
+func (p *OpenRouterProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	assert.NotNil(req, "analysis request cannot be nil")
+	assert.NotEmpty(req.Files, "analysis request must have files")
+
+	return analyzeChatCompletion(ctx, p.requestParams(), req)
+}
+
+// AnalyzeDiff sends a diff analysis request to OpenRouter and returns the analysis.
+func (p *OpenRouterProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	return analyzeDiffChatCompletion(ctx, p.requestParams(), system, user)
+}
+
+// listModels returns the IDs of the models OpenRouter can route to.
+func (p *OpenRouterProvider) listModels(ctx context.Context) ([]string, error) {
+	return listModelIDs(ctx, p.requestParams(), strings.TrimSuffix(p.baseURL, "/chat/completions")+"/models")
+}
+
+// checkKey verifies the key with OpenRouter's key endpoint, since the model
+// list is public, then checks the model is one OpenRouter routes to.
+func (p *OpenRouterProvider) checkKey(ctx context.Context) error {
+	keyURL := strings.TrimSuffix(p.baseURL, "/chat/completions") + "/key"
+	if err := getModel(ctx, p.requestParams(), keyURL); err != nil {
+		return err
+	}
+	return checkModelListed(ctx, p, "openrouter", p.model, "use a vendor/model ID from https://openrouter.ai/models")
+}
+
+func (p *OpenRouterProvider) requestParams() llmRequestParams {
+	return llmRequestParams{
+		httpClient: p.client,
+		model:      p.model,
+		url:        p.baseURL,
+		headers:    p.headers(),
+		provider:   "openrouter",
+		sampling:   p.sampling,
+	}
+}
+
+func (p *OpenRouterProvider) headers() map[string]string {
+	return map[string]string{
+		"Content-Type":  "application/json",
+		"Authorization": "Bearer " + p.apiKey,
+	}
+}
@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// promptData is what a prompt template is executed with.
+type promptData struct {
+	// Default is the built-in prompt the template replaces, for templates
+	// that add to it rather than start over.
+	Default string
+
+	Files         string // one line per file: path, status, diff summary and scope
+	Diff          string
+	RecentCommits string // one line per commit
+	Rules         types.CommitRules
+	AllowedTypes  string // e.g. "feat | fix | chore"
+	Convention    string // the message format rules for the commit convention
+	Budgets       string // characters left for the message per scope
+	TypeGuesses   string // one line per guessed group; empty without guesses
+
+	HasScopes      bool
+	SingleCommit   bool
+	GuidingMessage string
+
+	// Request is the whole analysis request, e.g. to range over its Files.
+	Request *types.AnalysisRequest
+}
+
+// renderPrompt executes the named prompt template for req. builtIn is the
+// prompt it replaces.
+func renderPrompt(name, text string, req *types.AnalysisRequest, builtIn string) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	data := promptData{
+		Default:        builtIn,
+		Files:          formatFiles(req.Files),
+		Diff:           req.Diff,
+		RecentCommits:  formatCommits(req.RecentCommits),
+		Rules:          req.Rules,
+		AllowedTypes:   formatTypes(req.Rules.Types),
+		Convention:     convention.Instructions(req.Rules.Convention),
+		Budgets:        formatBudgets(req),
+		TypeGuesses:    formatTypeGuesses(req.TypeGuesses),
+		HasScopes:      req.HasScopes,
+		SingleCommit:   req.SingleCommit,
+		GuidingMessage: req.GuidingMessage,
+		Request:        req,
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// CheckPromptTemplates parses each template and executes it against a sample
+// request, so a typo in a template fails before anything is sent instead of
+// silently falling back to the built-in prompt.
+func CheckPromptTemplates(prompts *types.PromptTemplates) error {
+	if prompts == nil {
+		return nil
+	}
+	sample := &types.AnalysisRequest{
+		Files:         []types.FileChange{{Path: "api/handler.go", Status: "modified", DiffSummary: "+1 -1", Scope: "api"}},
+		Diff:          "diff --git a/api/handler.go b/api/handler.go",
+		RecentCommits: []string{"feat(api): add handler"},
+		HasScopes:     true,
+		Rules:         types.CommitRules{Types: types.DefaultCommitTypes(), MaxMessageLength: 72},
+		TypeGuesses:   []types.TypeGuess{{Files: []string{"api/handler.go"}, Type: "feat", Confidence: 0.5, Reason: "code change"}},
+	}
+	system, user := defaultPrompt(sample)
+
+	for _, p := range []struct{ name, text, builtIn string }{
+		{"system", prompts.System, system},
+		{"user", prompts.User, user},
+	} {
+		if p.text == "" {
+			continue
+		}
+		if _, err := renderPrompt(p.name, p.text, sample, p.builtIn); err != nil {
+			return fmt.Errorf("invalid %s prompt template: %w", p.name, err)
+		}
+	}
+	return nil
+}
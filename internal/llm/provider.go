@@ -4,14 +4,26 @@ package llm
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
+	"unicode/utf8"
 
+	"github.com/dsswift/commit/internal/convention"
 	"github.com/dsswift/commit/pkg/types"
 )
 
 const defaultTimeoutSec = 60
 
+// defaultTemperature keeps plans close to the changes while leaving the
+// wording some room.
+const defaultTemperature = 0.3
+
+// defaultMaxTokens caps the tokens a provider may answer with unless
+// COMMIT_LLM_MAX_TOKENS sets another cap. The prompt is trimmed to leave the
+// cap free in the model's context window.
+const defaultMaxTokens = 8192
+
 // Provider is the interface for LLM providers.
 type Provider interface {
 	// Analyze sends an analysis request to the LLM and returns a commit plan.
@@ -31,6 +43,51 @@ type Provider interface {
 type ProviderOptions struct {
 	BaseURL    string
 	TimeoutSec int
+	// APIVersion pins the provider's API revision (anthropic-version header,
+	// Gemini endpoint version or Azure api-version). Empty uses the default.
+	APIVersion string
+	// Headers are sent with every request, e.g. OpenAI-Organization.
+	Headers map[string]string
+	// APIPath is appended to BaseURL to form the chat completions URL of an
+	// OpenAI-compatible server; BaseURL is then the API root, under which
+	// models are listed. Empty means BaseURL is the full URL.
+	APIPath string
+	// Temperature overrides the sampling temperature; nil uses 0.3, or
+	// Anthropic's own default for Claude models.
+	Temperature *float64
+	// Seed is sent to providers whose APIs take one (see SupportsSeed), so
+	// the same prompt samples the same way.
+	Seed *int
+	// TopP samples only from the most likely tokens that together make up
+	// this probability; nil leaves the provider's default.
+	TopP *float64
+	// MaxTokens caps the tokens in each answer; 0 uses 8192.
+	MaxTokens int
+}
+
+// sampling holds the sampling settings sent with every request.
+type sampling struct {
+	temperature float64
+	seed        *int // nil for providers without a seed parameter
+	topP        *float64
+	maxTokens   int
+}
+
+func (o ProviderOptions) sampling() sampling {
+	s := sampling{temperature: defaultTemperature, seed: o.Seed, topP: o.TopP, maxTokens: ResponseTokens(o.MaxTokens)}
+	if o.Temperature != nil {
+		s.temperature = *o.Temperature
+	}
+	return s
+}
+
+// ResponseTokens returns the answer cap for a configured maxTokens, where 0
+// means the default.
+func ResponseTokens(maxTokens int) int {
+	if maxTokens > 0 {
+		return maxTokens
+	}
+	return defaultMaxTokens
 }
 
 func (o ProviderOptions) timeout() time.Duration {
@@ -47,15 +104,51 @@ func (o ProviderOptions) baseURLOr(fallback string) string {
 	return fallback
 }
 
+func (o ProviderOptions) apiVersionOr(fallback string) string {
+	if o.APIVersion != "" {
+		return o.APIVersion
+	}
+	return fallback
+}
+
+// DefaultTimeout returns the request timeout provider uses when
+// COMMIT_TIMEOUT isn't set.
+func DefaultTimeout(provider string) time.Duration {
+	if provider == "ollama" {
+		return time.Duration(ollamaTimeoutSec) * time.Second
+	}
+	return time.Duration(defaultTimeoutSec) * time.Second
+}
+
+// SupportsSeed reports whether provider's API takes a sampling seed.
+// Anthropic's doesn't, nor do Claude deployments on Azure AI Foundry.
+func SupportsSeed(provider string) bool {
+	switch provider {
+	case "openai", "azure-openai", "azure-foundry", "grok", "openrouter", "github-models", "gemini", "vertex", "ollama":
+		return true
+	}
+	return false
+}
+
 // NewProvider creates a provider based on the user configuration.
 func NewProvider(config *types.UserConfig) (Provider, error) {
 	opts := ProviderOptions{
-		BaseURL:    config.BaseURL,
-		TimeoutSec: config.TimeoutSec,
+		BaseURL:     config.BaseURL,
+		TimeoutSec:  config.TimeoutSec,
+		Headers:     providerHeaders(config),
+		Temperature: config.Temperature,
+		Seed:        config.Seed,
+		TopP:        config.TopP,
+		MaxTokens:   config.MaxTokens,
 	}
 
 	switch config.Provider {
 	case "azure-foundry":
+		if isAnthropicDeployment(config.AzureFoundryDeployment) {
+			opts.APIVersion = config.AnthropicAPIVersion
+		} else {
+			opts.APIVersion = config.AzureOpenAIAPIVersion
+		}
 		return NewAzureFoundryProvider(
 			config.AzureFoundryEndpoint,
 			config.AzureFoundryAPIKey,
@@ -63,21 +156,104 @@ func NewProvider(config *types.UserConfig) (Provider, error) {
 			config.Model,
 			opts,
 		)
+	case "azure-openai":
+		opts.APIVersion = config.AzureOpenAIAPIVersion
+		return NewAzureOpenAIProvider(
+			config.AzureOpenAIEndpoint,
+			config.AzureOpenAIAPIKey,
+			config.AzureOpenAIDeployment,
+			config.Model,
+			opts,
+		)
 	case "anthropic":
+		opts.APIVersion = config.AnthropicAPIVersion
 		return NewAnthropicProvider(config.AnthropicAPIKey, config.Model, opts)
 	case "openai":
+		if config.OpenAIBaseURL != "" {
+			opts.BaseURL = config.OpenAIBaseURL
+			opts.APIPath = config.OpenAIPath
+			if opts.APIPath == "" {
+				opts.APIPath = "/chat/completions"
+			}
+		}
 		return NewOpenAIProvider(config.OpenAIAPIKey, config.Model, opts)
 	case "grok":
 		return NewGrokProvider(config.GrokAPIKey, config.Model, opts)
 	case "gemini":
+		opts.APIVersion = config.GeminiAPIVersion
 		return NewGeminiProvider(config.GeminiAPIKey, config.Model, opts)
+	case "ollama":
+		return NewOllamaProvider(config.OllamaHost, config.Model, opts)
+	case "openrouter":
+		return NewOpenRouterProvider(config.OpenRouterAPIKey, config.Model, opts)
+	case "mistral":
+		return NewMistralProvider(config.MistralAPIKey, config.Model, opts)
+	case "deepseek":
+		return NewDeepSeekProvider(config.DeepSeekAPIKey, config.Model, opts)
+	case "github-models":
+		return NewGitHubModelsProvider(config.GitHubToken, config.Model, opts)
+	case "vertex":
+		return NewVertexProvider(config.VertexProject, config.VertexLocation, config.VertexCredentials, config.Model, opts)
 	default:
 		return nil, fmt.Errorf("unsupported provider: %s", config.Provider)
 	}
 }
 
-// BuildPrompt creates the system and user prompts for commit analysis.
+// providerHeaders returns the extra headers for the configured provider: the
+// organization, project or billing headers its settings name, plus
+// COMMIT_EXTRA_HEADERS, which the named settings override.
+func providerHeaders(config *types.UserConfig) map[string]string {
+	headers := make(map[string]string)
+	for k, v := range config.ExtraHeaders {
+		headers[k] = v
+	}
+
+	named := map[string]string{}
+	switch config.Provider {
+	case "openai":
+		named["OpenAI-Organization"] = config.OpenAIOrganization
+		named["OpenAI-Project"] = config.OpenAIProject
+	case "gemini":
+		named["x-goog-user-project"] = config.GeminiProject
+	case "openrouter":
+		named["HTTP-Referer"] = config.OpenRouterSiteURL
+		named["X-Title"] = config.OpenRouterAppName
+	}
+	for k, v := range named {
+		if v != "" {
+			headers[k] = v
+		}
+	}
+
+	if len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+// BuildPrompt creates the system and user prompts for commit analysis, from
+// req.Prompts' templates where set and the built-in prompts otherwise.
 func BuildPrompt(req *types.AnalysisRequest) (system string, user string) {
+	system, user = defaultPrompt(req)
+	if req.Prompts == nil {
+		return system, user
+	}
+	// Templates are checked when loaded; one failing here keeps the built-in
+	if req.Prompts.System != "" {
+		if rendered, err := renderPrompt("system", req.Prompts.System, req, system); err == nil {
+			system = rendered
+		}
+	}
+	if req.Prompts.User != "" {
+		if rendered, err := renderPrompt("user", req.Prompts.User, req, user); err == nil {
+			user = rendered
+		}
+	}
+	return system, user
+}
+
+// defaultPrompt creates the built-in system and user prompts.
+func defaultPrompt(req *types.AnalysisRequest) (system string, user string) {
 	system = `You are a git commit message generator. Analyze the provided code changes and create semantic commits.
 
 RULES:
@@ -122,12 +298,16 @@ SCOPE:
 10. The scope after → is the pre-computed MOST SPECIFIC scope for each file - use it exactly as shown
 11. Do not substitute a more general scope even if it also matches the file path
 12. If hasScopes is true, include scope in format "type(scope): message"
-13. If hasScopes is false, use format "type: message"
+13. If hasScopes is false, use format "type: message" - unless files show a suggested scope (derived from their directory), which you may use as "type(scope): message" when all of a commit's files share it
 
 MESSAGE FORMAT:
-14. Use conventional commit format: "type(scope): message"
-15. Message must be lowercase, imperative mood, no period at end
+` + convention.Instructions(req.Rules.Convention) + `
 16. Message must not exceed the specified max length
+18. Files marked "mode only" changed permissions, not content; say so explicitly (e.g. "make deploy.sh executable") instead of describing content changes
+19. Files marked "LFS asset" are large binaries stored in Git LFS and are not in the diff; describe them from their path and size change (e.g. "update hero image")
+20. Files marked "focus" are the change the developer cares most about and have full diffs; other files' diffs may be summarized as line counts. Still include every file in a commit
+21. A hunk followed by "same change in N more files" was made identically in those files, whose diffs only say "repeated change"; group them with the file shown unless their other changes differ
+22. Files marked "symlink" are symbolic links whose content is only the path they point to; describe the link by what it points to (e.g. "point current config at prod.yaml"), not as a content change
 
 OUTPUT FORMAT:
 Return a JSON object with a "commits" array. Each commit has:
@@ -167,6 +347,19 @@ Example responses:
 		singleCommitRule = "\n- IMPORTANT: Create exactly ONE commit containing ALL files"
 	}
 
+	scopePolicyRule := ""
+	switch req.Rules.ScopePolicy {
+	case types.ScopePolicyRequired:
+		scopePolicyRule = "\n- IMPORTANT: Every commit MUST have a scope. Use the assigned or suggested scope; if a commit's files have none, choose a short scope naming the area they change"
+	case types.ScopePolicyForbidden:
+		scopePolicyRule = "\n- IMPORTANT: Never use a scope. Set scope to null for every commit, ignoring assigned and suggested scopes"
+	}
+
+	typeGuesses := ""
+	if len(req.TypeGuesses) > 0 {
+		typeGuesses = "\n\nTYPE GUESSES (made locally from paths and line counts; a prior, so override them when the diff disagrees):\n" + formatTypeGuesses(req.TypeGuesses)
+	}
+
 	guidingMessageRule := ""
 	if req.GuidingMessage != "" {
 		guidingMessageRule = fmt.Sprintf("\n- USER CONTEXT: The developer describes this change as: %q. Use this to guide commit type selection and message wording, but still split into multiple commits by scope/concern as appropriate.", req.GuidingMessage)
@@ -175,6 +368,9 @@ Example responses:
 	user = fmt.Sprintf(`Analyze these changes and create semantic commits:
 
 FILES (path [status] diff_summary → assigned_scope):
+%s%s
+
+MESSAGE BUDGET (characters left for the message once the subject prefix is written):
 %s
 
 DIFF:
@@ -185,12 +381,14 @@ RECENT COMMITS (for style reference):
 
 RULES:
 - ALLOWED TYPES (use ONLY these, substituting per rules above): %s
-- Max message length: %d characters
+- Max subject length: %d characters for the whole first line, type and scope prefix included
 - Has scopes: %v
-- Behavioral test: %s%s%s
+- Behavioral test: %s%s%s%s
 
 Return JSON only, no markdown code blocks.`,
 		formatFiles(req.Files),
+		typeGuesses,
+		formatBudgets(req),
 		req.Diff,
 		formatCommits(req.RecentCommits),
 		formatTypes(req.Rules.Types),
@@ -198,24 +396,122 @@ Return JSON only, no markdown code blocks.`,
 		req.HasScopes,
 		req.Rules.BehavioralTest,
 		singleCommitRule,
+		scopePolicyRule,
 		guidingMessageRule,
 	)
 
 	return system, user
 }
 
+// maxGuessFiles is how many of a type guess's files the prompt lists.
+const maxGuessFiles = 5
+
+// formatTypeGuesses lists type guesses one group per line, e.g.
+// "- docs (95%, documentation only): README.md, docs/setup.md".
+func formatTypeGuesses(guesses []types.TypeGuess) string {
+	var lines []string
+	for _, g := range guesses {
+		files := strings.Join(g.Files, ", ")
+		if len(g.Files) > maxGuessFiles {
+			files = fmt.Sprintf("%s and %d more", strings.Join(g.Files[:maxGuessFiles], ", "), len(g.Files)-maxGuessFiles)
+		}
+		lines = append(lines, fmt.Sprintf("- %s (%.0f%%, %s): %s", g.Type, g.Confidence*100, g.Reason, files))
+	}
+	return strings.Join(lines, "\n")
+}
+
 func formatFiles(files []types.FileChange) string {
 	result := ""
 	for _, f := range files {
 		scope := f.Scope
-		if scope == "" {
+		if scope == "" && f.SuggestedScope != "" {
+			scope = fmt.Sprintf("(no scope, suggested: %s)", f.SuggestedScope)
+		} else if scope == "" {
 			scope = "(no scope)"
 		}
-		result += fmt.Sprintf("- %s [%s] %s → %s\n", f.Path, f.Status, f.DiffSummary, scope)
+		summary := f.DiffSummary
+		if f.ModeOnly() {
+			summary = fmt.Sprintf("(mode only: %s)", f.ModeChange)
+		} else if f.ModeChange != "" {
+			summary += fmt.Sprintf(" (%s)", f.ModeChange)
+		}
+		if f.Symlink != "" {
+			summary = fmt.Sprintf("(%s)", f.Symlink)
+		}
+		if f.LFSChange != "" {
+			summary = fmt.Sprintf("(%s)", f.LFSChange)
+		}
+		if f.RemovedDir != "" {
+			summary = fmt.Sprintf("(%s)", f.RemovedDir)
+		}
+		if f.Focused {
+			summary += " (focus)"
+		}
+		result += fmt.Sprintf("- %s [%s] %s → %s\n", f.Path, f.Status, summary, scope)
 	}
 	return result
 }
 
+// formatBudgets lists how many characters each scope in the files leaves for
+// the message under each allowed type, e.g. "- api: feat 40, fix 41", or one
+// line when the prefix doesn't depend on them.
+func formatBudgets(req *types.AnalysisRequest) string {
+	scopes := []string{""}
+	if req.Rules.ScopePolicy != types.ScopePolicyForbidden {
+		seen := map[string]bool{"": true}
+		for _, f := range req.Files {
+			scope := f.Scope
+			if scope == "" {
+				scope = f.SuggestedScope
+			}
+			if !seen[scope] {
+				seen[scope] = true
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	typeNames := req.Rules.Types
+	if len(typeNames) == 0 {
+		typeNames = []string{""}
+	}
+
+	var lines []string
+	budgets := make(map[int]bool)
+	for _, scope := range scopes {
+		left := make([]int, len(typeNames))
+		same := true
+		for i, t := range typeNames {
+			c := types.PlannedCommit{Type: t}
+			if scope != "" {
+				c.Scope = &scope
+			}
+			left[i] = req.Rules.MaxMessageLength - utf8.RuneCountInString(convention.FormatWith(req.Convention, c))
+			same = same && left[i] == left[0]
+			budgets[left[i]] = true
+		}
+
+		name := scope
+		if name == "" {
+			name = "(no scope)"
+		}
+		if same {
+			lines = append(lines, fmt.Sprintf("- %s: %d", name, left[0]))
+			continue
+		}
+		parts := make([]string, len(typeNames))
+		for i, t := range typeNames {
+			parts[i] = fmt.Sprintf("%s %d", t, left[i])
+		}
+		lines = append(lines, fmt.Sprintf("- %s: %s", name, strings.Join(parts, ", ")))
+	}
+	if len(budgets) == 1 {
+		for n := range budgets {
+			return fmt.Sprintf("- every commit: %d", n)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 func formatCommits(commits []string) string {
 	if len(commits) == 0 {
 		return "(no recent commits)"
@@ -236,6 +532,8 @@ type ProviderError struct {
 	Provider string
 	Message  string
 	Err      error
+	// StatusCode is the HTTP status of a failed API call, or 0
+	StatusCode int
 }
 
 func (e *ProviderError) Error() string {
@@ -248,3 +546,8 @@ func (e *ProviderError) Error() string {
 func (e *ProviderError) Unwrap() error {
 	return e.Err
 }
+
+// Unauthorized reports whether the provider rejected the API key.
+func (e *ProviderError) Unauthorized() bool {
+	return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+}
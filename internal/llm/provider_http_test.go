@@ -2,11 +2,25 @@ package llm
 
 import (
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/dsswift/commit/pkg/types"
 )
@@ -124,6 +138,17 @@ func geminiEmptyBody() string {
 	return string(b)
 }
 
+// --- Ollama response helpers ---
+
+func ollamaBody(content, doneReason string) string {
+	resp := ollamaResponse{
+		Message:    chatMessage{Role: "assistant", Content: content},
+		DoneReason: doneReason,
+	}
+	b, _ := json.Marshal(resp)
+	return string(b)
+}
+
 // --- Provider factory helpers ---
 
 func newTestAnthropic(serverURL string) *AnthropicProvider {
@@ -141,6 +166,21 @@ func newTestGrok(serverURL string) *GrokProvider {
 	return p
 }
 
+func newTestMistral(serverURL string) *MistralProvider {
+	p, _ := NewMistralProvider("test-key", "test-model", ProviderOptions{BaseURL: serverURL})
+	return p
+}
+
+func newTestDeepSeek(serverURL string) *DeepSeekProvider {
+	p, _ := NewDeepSeekProvider("test-key", "deepseek-chat", ProviderOptions{BaseURL: serverURL})
+	return p
+}
+
+func newTestOllama(serverURL string) *OllamaProvider {
+	p, _ := NewOllamaProvider(serverURL, "test-model", ProviderOptions{})
+	return p
+}
+
 func newTestGemini(serverURL string) *GeminiProvider {
 	p, _ := NewGeminiProvider("test-key", "test-model", ProviderOptions{BaseURL: serverURL + "/%s"})
 	return p
@@ -833,6 +873,90 @@ func TestAnthropicProvider_SendsCorrectHeaders(t *testing.T) {
 	}
 }
 
+func TestProviders_PinnedAPIVersion(t *testing.T) {
+	var captured *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = r.Clone(context.Background())
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(openaiSuccessBody("ok")))
+	}))
+	defer server.Close()
+
+	anthropic, _ := NewAnthropicProvider("key", "", ProviderOptions{BaseURL: server.URL, APIVersion: "2099-01-01"})
+	_, _ = anthropic.AnalyzeDiff(context.Background(), "system", "user")
+	if got := captured.Header.Get("anthropic-version"); got != "2099-01-01" {
+		t.Errorf("expected pinned anthropic-version, got %q", got)
+	}
+
+	azure, _ := NewAzureFoundryProvider(server.URL, "key", "gpt-4o", "", ProviderOptions{APIVersion: "2025-01-01"})
+	_, _ = azure.AnalyzeDiff(context.Background(), "system", "user")
+	if got := captured.URL.Query().Get("api-version"); got != "2025-01-01" {
+		t.Errorf("expected pinned api-version, got %q", got)
+	}
+
+	azure, _ = NewAzureFoundryProvider(server.URL, "key", "gpt-4o", "", ProviderOptions{})
+	_, _ = azure.AnalyzeDiff(context.Background(), "system", "user")
+	if got := captured.URL.Query().Get("api-version"); got != azureOpenAIAPIVersion {
+		t.Errorf("expected default api-version, got %q", got)
+	}
+
+	gemini, _ := NewGeminiProvider("key", "gemini-x", ProviderOptions{APIVersion: "v1"})
+	if got := gemini.apiURL(); got != "https://generativelanguage.googleapis.com/v1/models/gemini-x:generateContent" {
+		t.Errorf("unexpected pinned Gemini URL: %s", got)
+	}
+	gemini, _ = NewGeminiProvider("key", "gemini-x", ProviderOptions{})
+	if got := gemini.apiURL(); !strings.Contains(got, "/v1beta/models/gemini-x:") {
+		t.Errorf("unexpected default Gemini URL: %s", got)
+	}
+}
+
+func TestProviders_ExtraHeaders(t *testing.T) {
+	var captured http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		captured = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(openaiSuccessBody("ok")))
+	}))
+	defer server.Close()
+
+	config := &types.UserConfig{
+		Provider:           "openai",
+		OpenAIAPIKey:       "test-key",
+		BaseURL:            server.URL,
+		OpenAIOrganization: "org-123",
+		OpenAIProject:      "proj_abc",
+		GeminiProject:      "not-sent",
+		ExtraHeaders:       map[string]string{"X-Team": "payments", "Authorization": "ignored", "OpenAI-Project": "overridden"},
+	}
+	provider, err := NewProvider(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = provider.AnalyzeDiff(context.Background(), "system", "user")
+
+	want := map[string]string{
+		"OpenAI-Organization": "org-123",
+		"OpenAI-Project":      "proj_abc",
+		"X-Team":              "payments",
+		"Authorization":       "Bearer test-key", // the provider's own headers win
+		"X-Goog-User-Project": "",
+	}
+	for name, value := range want {
+		if got := captured.Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+
+	// Gemini sends its billing project
+	config = &types.UserConfig{Provider: "gemini", GeminiAPIKey: "key", GeminiProject: "billing-1", OpenAIOrganization: "org-123"}
+	if got := providerHeaders(config); len(got) != 1 || got["x-goog-user-project"] != "billing-1" {
+		t.Errorf("providerHeaders(gemini) = %v", got)
+	}
+	if got := providerHeaders(&types.UserConfig{Provider: "anthropic"}); got != nil {
+		t.Errorf("expected no extra headers, got %v", got)
+	}
+}
+
 func TestOpenAIProvider_SendsCorrectHeaders(t *testing.T) {
 	var capturedHeaders http.Header
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -952,6 +1076,150 @@ func TestOpenAIProvider_SendsCorrectRequestBody(t *testing.T) {
 	}
 }
 
+func TestProviders_SendSampling(t *testing.T) {
+	zero, seed := 0.0, 42
+	deterministic := ProviderOptions{Temperature: &zero, Seed: &seed}
+
+	tests := []struct {
+		name        string
+		response    string
+		newProvider func(url string, opts ProviderOptions) Provider
+		opts        ProviderOptions
+		// settings is where the sampling settings sit in the request body
+		settings        string
+		wantTemperature any // nil if left out
+		wantSeed        any
+	}{
+		{"openai default", openaiSuccessBody(validCommitPlanJSON), func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url
+			p, _ := NewOpenAIProvider("test-key", "test-model", opts)
+			return p
+		}, ProviderOptions{}, "", 0.3, nil},
+		{"openai deterministic", openaiSuccessBody(validCommitPlanJSON), func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url
+			p, _ := NewOpenAIProvider("test-key", "test-model", opts)
+			return p
+		}, deterministic, "", 0.0, 42.0},
+		{"mistral takes no seed", openaiSuccessBody(validCommitPlanJSON), func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url
+			p, _ := NewMistralProvider("test-key", "test-model", opts)
+			return p
+		}, deterministic, "", 0.0, nil},
+		{"anthropic default", anthropicSuccessBody(validCommitPlanJSON), func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url
+			p, _ := NewAnthropicProvider("test-key", "test-model", opts)
+			return p
+		}, ProviderOptions{}, "", nil, nil},
+		{"anthropic deterministic", anthropicSuccessBody(validCommitPlanJSON), func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url
+			p, _ := NewAnthropicProvider("test-key", "test-model", opts)
+			return p
+		}, deterministic, "", 0.0, nil},
+		{"gemini deterministic", geminiSuccessBody(validCommitPlanJSON), func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url + "/%s"
+			p, _ := NewGeminiProvider("test-key", "test-model", opts)
+			return p
+		}, deterministic, "generationConfig", 0.0, 42.0},
+		{"ollama deterministic", `{"message":{"role":"assistant","content":` + strconv.Quote(validCommitPlanJSON) + `},"done_reason":"stop"}`, func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url
+			p, _ := NewOllamaProvider(url, "test-model", opts)
+			return p
+		}, deterministic, "options", 0.0, 42.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			if _, err := tt.newProvider(server.URL, tt.opts).Analyze(context.Background(), analysisRequest()); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			settings := body
+			if tt.settings != "" {
+				settings, _ = body[tt.settings].(map[string]any)
+			}
+			if got := settings["temperature"]; got != tt.wantTemperature {
+				t.Errorf("temperature = %v, want %v", got, tt.wantTemperature)
+			}
+			if got := settings["seed"]; got != tt.wantSeed {
+				t.Errorf("seed = %v, want %v", got, tt.wantSeed)
+			}
+		})
+	}
+}
+
+func TestProviders_SendGenerationLimits(t *testing.T) {
+	topP := 0.9
+	limited := ProviderOptions{TopP: &topP, MaxTokens: 2048}
+
+	tests := []struct {
+		name        string
+		response    string
+		newProvider func(url string, opts ProviderOptions) Provider
+		opts        ProviderOptions
+		// settings is where the limits sit in the request body, and under
+		// which names
+		settings, topPKey, maxTokensKey string
+		wantTopP, wantMaxTokens         any // nil if left out
+	}{
+		{"openai default", openaiSuccessBody(validCommitPlanJSON), func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url
+			p, _ := NewOpenAIProvider("test-key", "test-model", opts)
+			return p
+		}, ProviderOptions{}, "", "top_p", "max_tokens", nil, 8192.0},
+		{"openai", openaiSuccessBody(validCommitPlanJSON), func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url
+			p, _ := NewOpenAIProvider("test-key", "test-model", opts)
+			return p
+		}, limited, "", "top_p", "max_tokens", 0.9, 2048.0},
+		{"anthropic", anthropicSuccessBody(validCommitPlanJSON), func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url
+			p, _ := NewAnthropicProvider("test-key", "test-model", opts)
+			return p
+		}, limited, "", "top_p", "max_tokens", 0.9, 2048.0},
+		{"gemini", geminiSuccessBody(validCommitPlanJSON), func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url + "/%s"
+			p, _ := NewGeminiProvider("test-key", "test-model", opts)
+			return p
+		}, limited, "generationConfig", "topP", "maxOutputTokens", 0.9, 2048.0},
+		{"ollama", `{"message":{"role":"assistant","content":` + strconv.Quote(validCommitPlanJSON) + `},"done_reason":"stop"}`, func(url string, opts ProviderOptions) Provider {
+			opts.BaseURL = url
+			p, _ := NewOllamaProvider(url, "test-model", opts)
+			return p
+		}, limited, "options", "top_p", "num_predict", 0.9, 2048.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body map[string]any
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_ = json.NewDecoder(r.Body).Decode(&body)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			if _, err := tt.newProvider(server.URL, tt.opts).Analyze(context.Background(), analysisRequest()); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			settings := body
+			if tt.settings != "" {
+				settings, _ = body[tt.settings].(map[string]any)
+			}
+			if got := settings[tt.topPKey]; got != tt.wantTopP {
+				t.Errorf("%s = %v, want %v", tt.topPKey, got, tt.wantTopP)
+			}
+			if got := settings[tt.maxTokensKey]; got != tt.wantMaxTokens {
+				t.Errorf("%s = %v, want %v", tt.maxTokensKey, got, tt.wantMaxTokens)
+			}
+		})
+	}
+}
+
 // =====================================================================
 // Context cancellation test
 // =====================================================================
@@ -1111,8 +1379,10 @@ func TestAllProviders_Analyze_ValidatesCommitPlanFields(t *testing.T) {
 		{"openai", openaiSuccessBody(validCommitPlanJSON), func(u string) Provider { return newTestOpenAI(u) }},
 		{"grok", grokSuccessBody(validCommitPlanJSON), func(u string) Provider { return newTestGrok(u) }},
 		{"gemini", geminiSuccessBody(validCommitPlanJSON), func(u string) Provider { return newTestGemini(u) }},
+		{"ollama", ollamaBody(validCommitPlanJSON, "stop"), func(u string) Provider { return newTestOllama(u) }},
 		{"azure-foundry-anthropic", azureAnthropicSuccessBody(validCommitPlanJSON), func(u string) Provider { return newTestAzureFoundryAnthropic(u) }},
 		{"azure-foundry-openai", openaiSuccessBody(validCommitPlanJSON), func(u string) Provider { return newTestAzureFoundryOpenAI(u) }},
+		{"azure-openai", openaiSuccessBody(validCommitPlanJSON), func(u string) Provider { return newTestAzureOpenAI(u) }},
 	}
 
 	for _, tc := range providers {
@@ -1184,6 +1454,11 @@ func newTestAzureFoundryAnthropic(serverURL string) *AzureFoundryProvider {
 	return p
 }
 
+func newTestAzureOpenAI(serverURL string) *AzureOpenAIProvider {
+	p, _ := NewAzureOpenAIProvider(serverURL, "test-key", "gpt-4o-prod", "", ProviderOptions{})
+	return p
+}
+
 func newTestAzureFoundryOpenAI(serverURL string) *AzureFoundryProvider {
 	p, _ := NewAzureFoundryProvider(serverURL, "test-key", "gpt-4o", "gpt-4o", ProviderOptions{})
 	return p
@@ -1367,3 +1642,1046 @@ func TestAzureFoundryProvider_AnalyzeDiff_OpenAI_Success(t *testing.T) {
 		t.Errorf("expected 'azure openai diff result', got %q", result)
 	}
 }
+
+// --- Repair turn ---
+
+func TestAnalyzeWithRepair(t *testing.T) {
+	malformed := `{"commits":[{"type":"feat","message":"add endpoint","files":["src/api.go"],}]}`
+
+	tests := []struct {
+		name        string
+		responses   []string
+		wantErr     bool
+		wantRepairs int
+		wantCalls   int
+	}{
+		{"valid first time", []string{validCommitPlanJSON}, false, 0, 1},
+		{"repaired", []string{malformed, validCommitPlanJSON}, false, 1, 2},
+		{"repair also malformed", []string{malformed, malformed}, true, 1, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req chatRequest
+				_ = json.NewDecoder(r.Body).Decode(&req)
+				bodies = append(bodies, req.Messages[len(req.Messages)-1].Content)
+
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(openaiSuccessBody(tt.responses[len(bodies)-1])))
+			}))
+			defer server.Close()
+
+			repairs := 0
+			plan, err := AnalyzeWithRepair(context.Background(), newTestOpenAI(server.URL), analysisRequest(), func(error) {
+				repairs++
+			})
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+			} else if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if len(plan.Commits) != 1 {
+				t.Errorf("expected 1 commit, got %d", len(plan.Commits))
+			}
+
+			if repairs != tt.wantRepairs {
+				t.Errorf("expected %d repair callbacks, got %d", tt.wantRepairs, repairs)
+			}
+			if len(bodies) != tt.wantCalls {
+				t.Fatalf("expected %d requests, got %d", tt.wantCalls, len(bodies))
+			}
+			if tt.wantCalls > 1 && !strings.Contains(bodies[1], malformed) {
+				t.Errorf("expected repair request to include the malformed output, got: %s", bodies[1])
+			}
+		})
+	}
+}
+
+func TestAnalyzeWithRepair_NonParseErrorNotRepaired(t *testing.T) {
+	server := newTestServer(http.StatusInternalServerError, "boom")
+	defer server.Close()
+
+	repaired := false
+	_, err := AnalyzeWithRepair(context.Background(), newTestOpenAI(server.URL), analysisRequest(), func(error) {
+		repaired = true
+	})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if repaired {
+		t.Error("expected HTTP errors not to trigger a repair turn")
+	}
+}
+
+func TestAnalyzeChunks(t *testing.T) {
+	chunk := func(file string) *types.AnalysisRequest {
+		req := analysisRequest()
+		req.Files = []types.FileChange{{Path: file, Status: "modified", DiffSummary: "+1 -1"}}
+		return req
+	}
+	chunks := []*types.AnalysisRequest{chunk("api/a.go"), chunk("web/b.ts"), chunk("docs/c.md")}
+
+	// Each chunk's plan commits the files it was sent; web/ fails when failWeb is set
+	var calls atomic.Int32
+	failWeb := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req chatRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		prompt := req.Messages[len(req.Messages)-1].Content
+		calls.Add(1)
+
+		for _, file := range []string{"api/a.go", "web/b.ts", "docs/c.md"} {
+			if !strings.Contains(prompt, file) {
+				continue
+			}
+			if failWeb && file == "web/b.ts" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":{"message":"bad request"}}`))
+				return
+			}
+			plan := fmt.Sprintf(`{"commits":[{"type":"feat","message":"change %s","files":[%q]}]}`, file, file)
+			_, _ = w.Write([]byte(openaiSuccessBody(plan)))
+			return
+		}
+	}))
+	defer server.Close()
+
+	plan, err := AnalyzeChunks(context.Background(), newTestOpenAI(server.URL), chunks, nil)
+	if err != nil {
+		t.Fatalf("AnalyzeChunks failed: %v", err)
+	}
+	if calls.Load() != 3 || len(plan.Commits) != 3 {
+		t.Fatalf("expected 3 requests and 3 commits, got %d and %d", calls.Load(), len(plan.Commits))
+	}
+	for i, want := range []string{"api/a.go", "web/b.ts", "docs/c.md"} {
+		if plan.Commits[i].Files[0] != want {
+			t.Errorf("commit %d has %v, want [%s] in chunk order", i+1, plan.Commits[i].Files, want)
+		}
+	}
+
+	failWeb = true
+	_, err = AnalyzeChunks(context.Background(), newTestOpenAI(server.URL), chunks, nil)
+	if err == nil || !strings.Contains(err.Error(), "chunk 2 of 3") || !strings.Contains(err.Error(), "bad request") {
+		t.Errorf("expected chunk 2's error, got %v", err)
+	}
+}
+
+// =====================================================================
+// Key check tests
+// =====================================================================
+
+func TestCheckKey(t *testing.T) {
+	var gotMethod, gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		if r.Header.Get("Authorization") != "Bearer test-key" && r.Header.Get("x-api-key") != "test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"test-model"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		provider Provider
+		wantPath string
+	}{
+		{"openai", newTestOpenAI(server.URL + "/v1/chat/completions"), "/v1/models/test-model"},
+		{"grok", newTestGrok(server.URL + "/v1/chat/completions"), "/v1/models/test-model"},
+		{"mistral", newTestMistral(server.URL + "/v1/chat/completions"), "/v1/models/test-model"},
+		{"anthropic", newTestAnthropic(server.URL + "/v1/messages"), "/v1/models/test-model"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckKey(context.Background(), tt.provider); err != nil {
+				t.Fatalf("CheckKey failed: %v", err)
+			}
+			if gotMethod != "GET" || gotPath != tt.wantPath {
+				t.Errorf("request = %s %s, want GET %s", gotMethod, gotPath, tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestCheckKey_Rejected(t *testing.T) {
+	server := newTestServer(http.StatusUnauthorized, `{"error":"invalid api key"}`)
+	defer server.Close()
+
+	err := CheckKey(context.Background(), newTestOpenAI(server.URL))
+	pe, ok := err.(*ProviderError)
+	if !ok || !pe.Unauthorized() {
+		t.Errorf("expected an unauthorized *ProviderError, got %T: %v", err, err)
+	}
+}
+
+// =====================================================================
+// Ollama tests
+// =====================================================================
+
+func TestOllamaProvider_Analyze_RequestsJSON(t *testing.T) {
+	var capturedPath string
+	var capturedBody ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedPath = r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&capturedBody)
+		_, _ = w.Write([]byte(ollamaBody(validCommitPlanJSON, "stop")))
+	}))
+	defer server.Close()
+
+	p := newTestOllama(server.URL)
+	plan, err := p.Analyze(context.Background(), analysisRequest())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(plan.Commits) != 1 {
+		t.Fatalf("expected 1 commit, got %d", len(plan.Commits))
+	}
+	if capturedPath != "/api/chat" {
+		t.Errorf("expected path /api/chat, got %q", capturedPath)
+	}
+	if capturedBody.Model != "test-model" || capturedBody.Stream || capturedBody.Format != "json" {
+		t.Errorf("expected a non-streaming JSON request for test-model, got %+v", capturedBody)
+	}
+	if len(capturedBody.Messages) != 2 || capturedBody.Messages[0].Role != "system" {
+		t.Errorf("expected system and user messages, got %+v", capturedBody.Messages)
+	}
+}
+
+func TestOllamaProvider_Analyze_Truncated(t *testing.T) {
+	server := newTestServer(http.StatusOK, ollamaBody(`{"commits":[]`, "length"))
+	defer server.Close()
+
+	_, err := newTestOllama(server.URL).Analyze(context.Background(), analysisRequest())
+	if err == nil || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("expected truncated error, got: %v", err)
+	}
+}
+
+func TestOllamaProvider_AnalyzeDiff(t *testing.T) {
+	var capturedBody ollamaRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewDecoder(r.Body).Decode(&capturedBody)
+		_, _ = w.Write([]byte(ollamaBody("ollama diff analysis", "stop")))
+	}))
+	defer server.Close()
+
+	result, err := newTestOllama(server.URL).AnalyzeDiff(context.Background(), "system prompt", "user prompt")
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if result != "ollama diff analysis" {
+		t.Errorf("expected 'ollama diff analysis', got %q", result)
+	}
+	if capturedBody.Format != "" {
+		t.Errorf("expected free-form text for AnalyzeDiff, got format %q", capturedBody.Format)
+	}
+}
+
+func TestOllamaProvider_APIError(t *testing.T) {
+	server := newTestServer(http.StatusNotFound, `{"error":"model 'test-model' not found"}`)
+	defer server.Close()
+
+	err := CheckKey(context.Background(), newTestOllama(server.URL))
+	pe, ok := err.(*ProviderError)
+	if !ok {
+		t.Fatalf("expected *ProviderError, got %T: %v", err, err)
+	}
+	if pe.Provider != "ollama" || pe.StatusCode != http.StatusNotFound {
+		t.Errorf("expected ollama 404, got %q %d", pe.Provider, pe.StatusCode)
+	}
+}
+
+func TestNewOllamaProvider_Defaults(t *testing.T) {
+	p, _ := NewOllamaProvider("", "", ProviderOptions{})
+	if p.Model() != defaultOllamaModel {
+		t.Errorf("expected model %q, got %q", defaultOllamaModel, p.Model())
+	}
+	if Endpoint(p) != "http://localhost:11434/api/chat" {
+		t.Errorf("expected the local server, got %q", Endpoint(p))
+	}
+	if p.client.Timeout.Seconds() != ollamaTimeoutSec {
+		t.Errorf("expected a %ds timeout, got %v", ollamaTimeoutSec, p.client.Timeout)
+	}
+
+	p, _ = NewOllamaProvider("gpu-box:11434", "qwen2.5-coder", ProviderOptions{TimeoutSec: 30})
+	if Endpoint(p) != "http://gpu-box:11434/api/chat" {
+		t.Errorf("expected the scheme to be added, got %q", Endpoint(p))
+	}
+	if p.client.Timeout.Seconds() != 30 {
+		t.Errorf("expected COMMIT_TIMEOUT to win, got %v", p.client.Timeout)
+	}
+}
+
+// =====================================================================
+// Azure OpenAI tests
+// =====================================================================
+
+func TestAzureOpenAIProvider_RoutesByDeployment(t *testing.T) {
+	var capturedPath, capturedVersion, capturedKey string
+	var capturedBody chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		capturedPath = r.URL.Path
+		capturedVersion = r.URL.Query().Get("api-version")
+		capturedKey = r.Header.Get("api-key")
+		_ = json.NewDecoder(r.Body).Decode(&capturedBody)
+		_, _ = w.Write([]byte(openaiSuccessBody("analysis")))
+	}))
+	defer server.Close()
+
+	// The portal shows endpoints both with and without /openai
+	p, _ := NewAzureOpenAIProvider(server.URL+"/openai/", "test-key", "gpt-4o-prod", "", ProviderOptions{APIVersion: "2024-10-21"})
+	if _, err := p.AnalyzeDiff(context.Background(), "system prompt", "user prompt"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+
+	if capturedPath != "/openai/deployments/gpt-4o-prod/chat/completions" {
+		t.Errorf("unexpected path %q", capturedPath)
+	}
+	if capturedVersion != "2024-10-21" {
+		t.Errorf("expected api-version 2024-10-21, got %q", capturedVersion)
+	}
+	if capturedKey != "test-key" {
+		t.Errorf("expected api-key header, got %q", capturedKey)
+	}
+	if capturedBody.Model != "" {
+		t.Errorf("expected no model in the body, got %q", capturedBody.Model)
+	}
+	if p.Name() != "azure-openai" || p.Model() != "gpt-4o-prod" {
+		t.Errorf("unexpected name/model %q/%q", p.Name(), p.Model())
+	}
+}
+
+func TestAzureOpenAIProvider_DefaultAPIVersion(t *testing.T) {
+	p := newTestAzureOpenAI("https://res.openai.azure.com")
+	if want := "api-version=" + azureOpenAIAPIVersion; !strings.HasSuffix(Endpoint(p), want) {
+		t.Errorf("expected %s in %q", want, Endpoint(p))
+	}
+
+	server := newTestServer(http.StatusUnauthorized, `{"error":{"code":"401"}}`)
+	defer server.Close()
+	_, err := newTestAzureOpenAI(server.URL).Analyze(context.Background(), analysisRequest())
+	pe, ok := err.(*ProviderError)
+	if !ok || pe.Provider != "azure-openai" || !pe.Unauthorized() {
+		t.Errorf("expected an unauthorized azure-openai error, got %v", err)
+	}
+}
+
+// =====================================================================
+// OpenRouter tests
+// =====================================================================
+
+func TestOpenRouterProvider_AttributionHeaders(t *testing.T) {
+	var referer, title, auth, model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		referer, title, auth = r.Header.Get("HTTP-Referer"), r.Header.Get("X-Title"), r.Header.Get("Authorization")
+		var body chatRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		model = body.Model
+		_, _ = w.Write([]byte(openaiSuccessBody("analysis")))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		headers     map[string]string
+		wantReferer string
+		wantTitle   string
+	}{
+		{"defaults", nil, defaultOpenRouterReferer, defaultOpenRouterTitle},
+		{"overridden", map[string]string{"HTTP-Referer": "https://example.com", "X-Title": "my-app"}, "https://example.com", "my-app"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := NewOpenRouterProvider("test-key", "openai/gpt-4o", ProviderOptions{BaseURL: server.URL, Headers: tt.headers})
+			if _, err := p.AnalyzeDiff(context.Background(), "system prompt", "user prompt"); err != nil {
+				t.Fatalf("expected no error, got: %v", err)
+			}
+			if referer != tt.wantReferer || title != tt.wantTitle {
+				t.Errorf("headers = %q/%q, want %q/%q", referer, title, tt.wantReferer, tt.wantTitle)
+			}
+			if auth != "Bearer test-key" || model != "openai/gpt-4o" {
+				t.Errorf("unexpected auth/model %q/%q", auth, model)
+			}
+		})
+	}
+}
+
+func TestOpenRouterProvider_CheckKeyAndListModels(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/v1/key":
+			_, _ = w.Write([]byte(`{"data":{"label":"sk-or-...abc"}}`))
+		case "/api/v1/models":
+			_, _ = w.Write([]byte(`{"data":[{"id":"openai/gpt-4o"},{"id":"anthropic/claude-3.5-sonnet"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	baseURL := server.URL + "/api/v1/chat/completions"
+
+	p, _ := NewOpenRouterProvider("test-key", "openai/gpt-4o", ProviderOptions{BaseURL: baseURL})
+	if err := CheckKey(context.Background(), p); err != nil {
+		t.Fatalf("CheckKey failed: %v", err)
+	}
+	if strings.Join(paths, " ") != "/api/v1/key /api/v1/models" {
+		t.Errorf("unexpected requests %v", paths)
+	}
+
+	models, ok, err := ListModels(context.Background(), p)
+	if err != nil || !ok {
+		t.Fatalf("ListModels = %v, %v", ok, err)
+	}
+	if strings.Join(models, ",") != "anthropic/claude-3.5-sonnet,openai/gpt-4o" {
+		t.Errorf("expected sorted models, got %v", models)
+	}
+
+	p, _ = NewOpenRouterProvider("test-key", "openai/gpt-5-typo", ProviderOptions{BaseURL: baseURL})
+	pe, ok := CheckKey(context.Background(), p).(*ProviderError)
+	if !ok || pe.StatusCode != http.StatusNotFound || !strings.Contains(pe.Message, "openai/gpt-5-typo") {
+		t.Errorf("expected a not-found error naming the model, got %v", pe)
+	}
+
+	if _, ok, _ := ListModels(context.Background(), newTestGrok(server.URL)); ok {
+		t.Error("expected no model listing for grok")
+	}
+}
+
+// =====================================================================
+// Mistral tests
+// =====================================================================
+
+func TestMistralProvider_AnalyzeDiff(t *testing.T) {
+	var auth string
+	var body chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(openaiSuccessBody("analysis")))
+	}))
+	defer server.Close()
+
+	got, err := newTestMistral(server.URL).AnalyzeDiff(context.Background(), "system prompt", "user prompt")
+	if err != nil || got != "analysis" {
+		t.Fatalf("AnalyzeDiff = %q, %v", got, err)
+	}
+	if auth != "Bearer test-key" || body.Model != "test-model" {
+		t.Errorf("unexpected auth/model %q/%q", auth, body.Model)
+	}
+
+	p, _ := NewMistralProvider("test-key", "", ProviderOptions{})
+	if p.Model() != defaultMistralModel || Endpoint(p) != mistralAPIURL {
+		t.Errorf("unexpected defaults %q, %q", p.Model(), Endpoint(p))
+	}
+}
+
+func TestMistralProvider_TypedErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          int
+		body            string
+		wantType        string
+		wantMessage     string
+		contextExceeded bool
+	}{
+		{
+			name:            "context window",
+			status:          http.StatusBadRequest,
+			body:            `{"object":"error","message":"Prompt contains 40000 tokens, too large for model with 32768 maximum context length","type":"invalid_request_error","param":null,"code":null}`,
+			wantType:        "invalid_request_error",
+			wantMessage:     "Prompt contains 40000 tokens, too large for model with 32768 maximum context length",
+			contextExceeded: true,
+		},
+		{
+			name:        "validation",
+			status:      http.StatusUnprocessableEntity,
+			body:        `{"object":"error","message":{"detail":[{"type":"less_than_equal","loc":["body","temperature"],"msg":"Input should be less than or equal to 1.5"}]},"type":"invalid_request_error","param":null,"code":null}`,
+			wantType:    "invalid_request_error",
+			wantMessage: "body.temperature: Input should be less than or equal to 1.5",
+		},
+		{
+			name:        "unauthorized",
+			status:      http.StatusUnauthorized,
+			body:        `{"message":"Unauthorized","request_id":"abc"}`,
+			wantMessage: "Unauthorized",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(tt.status, tt.body)
+			defer server.Close()
+
+			_, err := newTestMistral(server.URL).Analyze(context.Background(), analysisRequest())
+			var pe *ProviderError
+			var me *MistralError
+			if !errors.As(err, &pe) || !errors.As(err, &me) {
+				t.Fatalf("expected a ProviderError wrapping a MistralError, got %T: %v", err, err)
+			}
+			if pe.StatusCode != tt.status || pe.Provider != "mistral" {
+				t.Errorf("unexpected provider error %+v", pe)
+			}
+			if me.Type != tt.wantType || me.Message != tt.wantMessage || me.ContextExceeded() != tt.contextExceeded {
+				t.Errorf("unexpected Mistral error %+v", me)
+			}
+		})
+	}
+
+	// Bodies that aren't Mistral errors keep the raw body in the message
+	server := newTestServer(http.StatusBadGateway, "<html>bad gateway</html>")
+	defer server.Close()
+	_, err := newTestMistral(server.URL).AnalyzeDiff(context.Background(), "s", "u")
+	var me *MistralError
+	if errors.As(err, &me) || !strings.Contains(err.Error(), "bad gateway") {
+		t.Errorf("expected the raw body, got %v", err)
+	}
+}
+
+// =====================================================================
+// OpenAI-compatible server tests
+// =====================================================================
+
+func TestNewProvider_OpenAICompatible(t *testing.T) {
+	var paths []string
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		auth = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/v1/models":
+			_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"qwen2.5-coder"}]}`))
+		case "/v1/chat/completions", "/v1/openai/chat":
+			_, _ = w.Write([]byte(openaiSuccessBody("analysis")))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		path     string
+		wantPath string
+	}{
+		{"default path", "", "/v1/chat/completions"},
+		{"custom path", "/openai/chat", "/v1/openai/chat"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paths = nil
+			provider, err := NewProvider(&types.UserConfig{
+				Provider:      "openai",
+				Model:         "qwen2.5-coder",
+				OpenAIBaseURL: server.URL + "/v1/",
+				OpenAIPath:    tt.path,
+			})
+			if err != nil {
+				t.Fatalf("NewProvider failed: %v", err)
+			}
+			if _, err := provider.AnalyzeDiff(context.Background(), "system prompt", "user prompt"); err != nil {
+				t.Fatalf("AnalyzeDiff failed: %v", err)
+			}
+			if len(paths) != 1 || paths[0] != tt.wantPath {
+				t.Errorf("requests = %v, want %s", paths, tt.wantPath)
+			}
+			if auth != "" {
+				t.Errorf("expected no Authorization header without a key, got %q", auth)
+			}
+			if err := CheckKey(context.Background(), provider); err != nil {
+				t.Errorf("CheckKey failed: %v", err)
+			}
+		})
+	}
+
+	// A model the server doesn't list fails the check
+	provider, _ := NewProvider(&types.UserConfig{Provider: "openai", Model: "gpt-4o", OpenAIBaseURL: server.URL + "/v1"})
+	pe, ok := CheckKey(context.Background(), provider).(*ProviderError)
+	if !ok || pe.StatusCode != http.StatusNotFound || !strings.Contains(pe.Message, "gpt-4o") {
+		t.Errorf("expected a not-found error naming the model, got %v", pe)
+	}
+}
+
+// =====================================================================
+// Vertex AI tests
+// =====================================================================
+
+// writeCredentials writes creds as a credentials file and returns its path.
+func writeCredentials(t *testing.T, creds map[string]string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	data, _ := json.Marshal(creds)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVertexProvider_ServiceAccount(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+
+	var tokenRequests int
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			_ = r.ParseForm()
+			parts := strings.Split(r.PostForm.Get("assertion"), ".")
+			if r.PostForm.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" || len(parts) != 3 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			signature, _ := base64.RawURLEncoding.DecodeString(parts[2])
+			digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+			if rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Invalid JWT Signature."}`))
+				return
+			}
+			claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+			if !strings.Contains(string(claims), `"iss":"commit@proj.iam.gserviceaccount.com"`) {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"ya29.token","expires_in":3600,"token_type":"Bearer"}`))
+			return
+		}
+		paths = append(paths, r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer ya29.token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body geminiRequest
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Contents) != 1 || body.Contents[0].Role != "user" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(geminiSuccessBody(validCommitPlanJSON)))
+	}))
+	defer server.Close()
+
+	credentials := writeCredentials(t, map[string]string{
+		"type":           "service_account",
+		"client_email":   "commit@proj.iam.gserviceaccount.com",
+		"private_key":    string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"private_key_id": "key-1",
+		"token_uri":      server.URL + "/token",
+	})
+	provider, err := NewProvider(&types.UserConfig{
+		Provider:          "vertex",
+		VertexProject:     "proj",
+		VertexLocation:    "europe-west4",
+		VertexCredentials: credentials,
+		BaseURL:           server.URL,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+
+	for range 2 {
+		if _, err := provider.Analyze(context.Background(), analysisRequest()); err != nil {
+			t.Fatalf("Analyze failed: %v", err)
+		}
+	}
+	if tokenRequests != 1 {
+		t.Errorf("expected the access token to be reused, got %d token requests", tokenRequests)
+	}
+	want := "/v1/projects/proj/locations/europe-west4/publishers/google/models/gemini-2.5-pro:generateContent"
+	if len(paths) != 2 || paths[0] != want {
+		t.Errorf("requests = %v, want %s", paths, want)
+	}
+
+	if err := CheckKey(context.Background(), provider); err != nil {
+		t.Fatalf("CheckKey failed: %v", err)
+	}
+	if got := paths[len(paths)-1]; !strings.HasSuffix(got, "/gemini-2.5-pro:countTokens") {
+		t.Errorf("CheckKey requested %s, want the model's countTokens", got)
+	}
+}
+
+func TestVertexProvider_RevokedCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		if r.URL.Path != "/token" || r.PostForm.Get("refresh_token") != "1//refresh" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":"invalid_grant","error_description":"Token has been expired or revoked."}`))
+	}))
+	defer server.Close()
+
+	credentials := writeCredentials(t, map[string]string{
+		"type":          "authorized_user",
+		"client_id":     "client.apps.googleusercontent.com",
+		"client_secret": "secret",
+		"refresh_token": "1//refresh",
+		"token_uri":     server.URL + "/token",
+	})
+	provider, err := NewVertexProvider("proj", "", credentials, "", ProviderOptions{BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("NewVertexProvider failed: %v", err)
+	}
+
+	err = CheckKey(context.Background(), provider)
+	var pe *ProviderError
+	var authErr *GoogleAuthError
+	if !errors.As(err, &pe) || !pe.Unauthorized() {
+		t.Fatalf("expected an unauthorized *ProviderError, got %T: %v", err, err)
+	}
+	if !errors.As(err, &authErr) || authErr.Code != "invalid_grant" || authErr.Source != credentials {
+		t.Errorf("expected the token endpoint's invalid_grant for %s, got %+v", credentials, authErr)
+	}
+}
+
+func TestVertexProvider_MetadataServer(t *testing.T) {
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("CLOUDSDK_CONFIG", t.TempDir())
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token" && r.Header.Get("Metadata-Flavor") == "Google":
+			_, _ = w.Write([]byte(`{"access_token":"ya29.metadata","expires_in":3600}`))
+		case r.Header.Get("Authorization") == "Bearer ya29.metadata":
+			_, _ = w.Write([]byte(geminiSuccessBody("looks good")))
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewVertexProvider("proj", "global", "", "gemini-2.5-flash", ProviderOptions{})
+	if err != nil {
+		t.Fatalf("NewVertexProvider failed: %v", err)
+	}
+	if got := Endpoint(provider); !strings.HasPrefix(got, "https://aiplatform.googleapis.com/v1/projects/proj/locations/global/") {
+		t.Errorf("global endpoint = %s", got)
+	}
+
+	provider.baseURL = server.URL
+	provider.tokens.metadataURL = server.URL + "/token"
+	if got, err := provider.AnalyzeDiff(context.Background(), "system", "user"); err != nil || got != "looks good" {
+		t.Errorf("AnalyzeDiff = %q, %v", got, err)
+	}
+}
+
+// =====================================================================
+// DeepSeek tests
+// =====================================================================
+
+func TestDeepSeekProvider_Analyze(t *testing.T) {
+	var auth, path string
+	var body chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, path = r.Header.Get("Authorization"), r.URL.Path
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(openaiSuccessBody(validCommitPlanJSON)))
+	}))
+	defer server.Close()
+
+	plan, err := newTestDeepSeek(server.URL+"/chat/completions").Analyze(context.Background(), analysisRequest())
+	if err != nil {
+		t.Fatalf("Analyze failed: %v", err)
+	}
+	if len(plan.Commits) == 0 {
+		t.Error("expected commits in the plan")
+	}
+	if auth != "Bearer test-key" || path != "/chat/completions" || body.Model != "deepseek-chat" {
+		t.Errorf("unexpected request %q %q %q", auth, path, body.Model)
+	}
+
+	p, _ := NewDeepSeekProvider("test-key", "", ProviderOptions{})
+	if p.Name() != "deepseek" || p.Model() != defaultDeepSeekModel || Endpoint(p) != deepSeekAPIURL {
+		t.Errorf("unexpected defaults %q, %q, %q", p.Name(), p.Model(), Endpoint(p))
+	}
+}
+
+func TestDeepSeekProvider_CheckKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" || r.Header.Get("Authorization") != "Bearer test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"deepseek-chat"},{"id":"deepseek-reasoner"}]}`))
+	}))
+	defer server.Close()
+	baseURL := server.URL + "/chat/completions"
+
+	if err := CheckKey(context.Background(), newTestDeepSeek(baseURL)); err != nil {
+		t.Fatalf("CheckKey failed: %v", err)
+	}
+
+	p, _ := NewDeepSeekProvider("test-key", "deepseek-coder-typo", ProviderOptions{BaseURL: baseURL})
+	pe, ok := CheckKey(context.Background(), p).(*ProviderError)
+	if !ok || pe.StatusCode != http.StatusNotFound || !strings.Contains(pe.Message, "deepseek-coder-typo") {
+		t.Errorf("expected a not-found error naming the model, got %v", pe)
+	}
+
+	p, _ = NewDeepSeekProvider("wrong-key", "", ProviderOptions{BaseURL: baseURL})
+	pe, ok = CheckKey(context.Background(), p).(*ProviderError)
+	if !ok || pe.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected an unauthorized error, got %v", pe)
+	}
+}
+
+// =====================================================================
+// GitHub Models tests
+// =====================================================================
+
+func TestGitHubModelsProvider_AnalyzeDiff(t *testing.T) {
+	var header http.Header
+	var body chatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		_, _ = w.Write([]byte(openaiSuccessBody("analysis")))
+	}))
+	defer server.Close()
+
+	p, _ := NewGitHubModelsProvider("gho_test", "", ProviderOptions{BaseURL: server.URL + "/inference/chat/completions"})
+	got, err := p.AnalyzeDiff(context.Background(), "system prompt", "user prompt")
+	if err != nil || got != "analysis" {
+		t.Fatalf("AnalyzeDiff = %q, %v", got, err)
+	}
+	if header.Get("Authorization") != "Bearer gho_test" || header.Get("X-GitHub-Api-Version") != gitHubAPIVersion {
+		t.Errorf("unexpected headers %v", header)
+	}
+	if body.Model != defaultGitHubModelsModel {
+		t.Errorf("model = %q, want %q", body.Model, defaultGitHubModelsModel)
+	}
+
+	p, _ = NewGitHubModelsProvider("gho_test", "", ProviderOptions{})
+	if p.Name() != "github-models" || Endpoint(p) != gitHubModelsAPIURL {
+		t.Errorf("unexpected defaults %q, %q", p.Name(), Endpoint(p))
+	}
+}
+
+func TestGitHubModelsProvider_CheckKey(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer gho_test" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"Bad credentials"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"id":"openai/gpt-4.1","publisher":"OpenAI"},{"id":"meta/llama-4-scout"}]`))
+	}))
+	defer server.Close()
+
+	// Organization endpoints share the catalog
+	p, _ := NewGitHubModelsProvider("gho_test", "meta/llama-4-scout", ProviderOptions{BaseURL: server.URL + "/orgs/acme/inference/chat/completions"})
+	if err := CheckKey(context.Background(), p); err != nil {
+		t.Fatalf("CheckKey failed: %v", err)
+	}
+	if strings.Join(paths, " ") != "/catalog/models" {
+		t.Errorf("unexpected requests %v", paths)
+	}
+
+	p, _ = NewGitHubModelsProvider("gho_test", "gpt-4.1", ProviderOptions{BaseURL: server.URL + "/inference/chat/completions"})
+	pe, ok := CheckKey(context.Background(), p).(*ProviderError)
+	if !ok || pe.StatusCode != http.StatusNotFound || !strings.Contains(pe.Message, "openai/gpt-4.1") {
+		t.Errorf("expected a not-found error with a model ID hint, got %v", pe)
+	}
+
+	p, _ = NewGitHubModelsProvider("expired", "", ProviderOptions{BaseURL: server.URL + "/inference/chat/completions"})
+	pe, ok = CheckKey(context.Background(), p).(*ProviderError)
+	if !ok || !pe.Unauthorized() {
+		t.Errorf("expected an unauthorized error, got %v", pe)
+	}
+}
+
+// =====================================================================
+// Retry tests
+// =====================================================================
+
+// countingServer fails the first failures requests with status, setting
+// header, then answers with an OpenAI plan. calls counts every request.
+func countingServer(failures, status int, header map[string]string, calls *atomic.Int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if int(calls.Add(1)) <= failures {
+			for k, v := range header {
+				w.Header().Set(k, v)
+			}
+			w.WriteHeader(status)
+			return
+		}
+		_, _ = w.Write([]byte(openaiSuccessBody(validCommitPlanJSON)))
+	}))
+}
+
+func TestDoRequest_Retries(t *testing.T) {
+	defer func(d time.Duration) { retryBaseDelay = d }(retryBaseDelay)
+	retryBaseDelay = time.Millisecond
+	defer SetMaxAttempts(0)
+
+	tests := []struct {
+		name        string
+		maxAttempts int
+		failures    int
+		status      int
+		wantCalls   int32
+		wantErr     bool
+	}{
+		{"rate limit then success", 0, 2, http.StatusTooManyRequests, 3, false},
+		{"server error then success", 0, 1, http.StatusInternalServerError, 2, false},
+		{"default gives up after 3", 0, 5, http.StatusServiceUnavailable, 3, true},
+		{"more attempts configured", 6, 5, http.StatusBadGateway, 6, false},
+		{"single attempt", 1, 1, http.StatusTooManyRequests, 1, true},
+		{"bad request isn't retried", 0, 1, http.StatusBadRequest, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetMaxAttempts(tt.maxAttempts)
+			var calls atomic.Int32
+			server := countingServer(tt.failures, tt.status, nil, &calls)
+			defer server.Close()
+
+			_, err := newTestOpenAI(server.URL).Analyze(context.Background(), analysisRequest())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("requests = %d, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDoRequest_RetryAfter(t *testing.T) {
+	// A wait past the deadline returns the rate limit error straight away
+	var calls atomic.Int32
+	server := countingServer(1, http.StatusTooManyRequests, map[string]string{"Retry-After": "30"}, &calls)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	start := time.Now()
+	_, err := newTestOpenAI(server.URL).Analyze(ctx, analysisRequest())
+	var pe *ProviderError
+	if !errors.As(err, &pe) || pe.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the 429 error, got: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second || calls.Load() != 1 {
+		t.Errorf("expected one request and no wait, got %d requests in %s", calls.Load(), elapsed)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	for header, want := range map[string]time.Duration{
+		"":                              0,
+		"7":                             7 * time.Second,
+		"-3":                            0,
+		"soon":                          0,
+		"Fri, 02 Jan 2026 15:04:35 GMT": 30 * time.Second,
+		"Fri, 02 Jan 2026 15:00:00 GMT": 0,
+	} {
+		if got := parseRetryAfter(header, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", header, got, want)
+		}
+	}
+
+	if got := retryDelay(1, 7*time.Second); got != 7*time.Second {
+		t.Errorf("Retry-After not honored: %s", got)
+	}
+	if got := retryDelay(1, time.Hour); got != maxRetryAfter {
+		t.Errorf("Retry-After not capped: %s", got)
+	}
+	for retry, base := range map[int]time.Duration{1: retryBaseDelay, 3: 4 * retryBaseDelay, 40: maxBackoff} {
+		for range 20 {
+			if got := retryDelay(retry, 0); got < base/2 || got > base {
+				t.Errorf("retryDelay(%d) = %s, want between %s and %s", retry, got, base/2, base)
+			}
+		}
+	}
+}
+
+// =====================================================================
+// Fallback chain tests
+// =====================================================================
+
+func TestFallbackProvider_Analyze(t *testing.T) {
+	primary := newTestServer(http.StatusInternalServerError, `{"error":{"message":"overloaded"}}`)
+	defer primary.Close()
+	secondary := newTestServer(http.StatusOK, openaiSuccessBody(validCommitPlanJSON))
+	defer secondary.Close()
+
+	p := NewFallbackProvider([]Provider{newTestAnthropic(primary.URL), newTestOpenAI(secondary.URL)})
+	var fellBack []string
+	p.SetOnFallback(func(failed, next Provider, err error) {
+		fellBack = append(fellBack, failed.Name()+" -> "+next.Name())
+	})
+
+	plan, err := p.Analyze(context.Background(), analysisRequest())
+	if err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(plan.Commits) != 1 {
+		t.Fatalf("expected 1 commit, got %d", len(plan.Commits))
+	}
+	if len(fellBack) != 1 || fellBack[0] != "anthropic -> openai" {
+		t.Errorf("expected one fallback from anthropic to openai, got %v", fellBack)
+	}
+	if p.Name() != "openai" {
+		t.Errorf("expected the plan to come from openai, got %q", p.Name())
+	}
+
+	// Later requests, like a repair turn, go straight to the provider that answered
+	fellBack = nil
+	if _, err := p.AnalyzeDiff(context.Background(), "system", "user"); err != nil {
+		t.Fatalf("expected no error, got: %v", err)
+	}
+	if len(fellBack) != 0 {
+		t.Errorf("expected no fallback once openai answered, got %v", fellBack)
+	}
+}
+
+func TestFallbackProvider_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	secondary := newTestServer(http.StatusOK, openaiSuccessBody(validCommitPlanJSON))
+	defer secondary.Close()
+
+	p := NewFallbackProvider([]Provider{newTestOpenAI(slow.URL), newTestMistral(secondary.URL)})
+	p.SetAttemptTimeout(50 * time.Millisecond)
+
+	if _, err := p.Analyze(context.Background(), analysisRequest()); err != nil {
+		t.Fatalf("expected the second provider to answer, got: %v", err)
+	}
+	if p.Name() != "mistral" {
+		t.Errorf("expected the plan to come from mistral, got %q", p.Name())
+	}
+}
+
+func TestFallbackProvider_NoFallbackOnRejectedKey(t *testing.T) {
+	primary := newTestServer(http.StatusUnauthorized, `{"error":{"message":"invalid x-api-key"}}`)
+	defer primary.Close()
+	secondary := newTestServer(http.StatusOK, openaiSuccessBody(validCommitPlanJSON))
+	defer secondary.Close()
+
+	p := NewFallbackProvider([]Provider{newTestAnthropic(primary.URL), newTestOpenAI(secondary.URL)})
+	p.SetOnFallback(func(failed, next Provider, err error) {
+		t.Errorf("unexpected fallback from %s after %v", failed.Name(), err)
+	})
+
+	_, err := p.Analyze(context.Background(), analysisRequest())
+	var pe *ProviderError
+	if !errors.As(err, &pe) || !pe.Unauthorized() {
+		t.Fatalf("expected the primary's unauthorized error, got: %v", err)
+	}
+}
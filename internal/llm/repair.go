@@ -0,0 +1,71 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dsswift/commit/internal/tokens"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// maxRepairTokens caps the malformed response echoed back in a repair turn.
+const maxRepairTokens = 5000
+
+// PlanParseError is returned (wrapped in a ProviderError) when a response is
+// not valid commit plan JSON. It keeps the raw content for a repair turn.
+type PlanParseError struct {
+	Content string
+	Err     error
+}
+
+func (e *PlanParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PlanParseError) Unwrap() error {
+	return e.Err
+}
+
+// AnalyzeWithRepair calls provider.Analyze and, if the response can't be
+// parsed as a commit plan, sends one follow-up request with the malformed
+// output asking the model to return corrected JSON. onRepair, if non-nil, is
+// called with the parse error before the repair request is sent.
+func AnalyzeWithRepair(ctx context.Context, provider Provider, req *types.AnalysisRequest, onRepair func(parseErr error)) (*types.CommitPlan, error) {
+	plan, err := provider.Analyze(ctx, req)
+
+	var parseErr *PlanParseError
+	if err == nil || !errors.As(err, &parseErr) {
+		return plan, err
+	}
+
+	if onRepair != nil {
+		onRepair(parseErr)
+	}
+
+	system, user := BuildRepairPrompt(parseErr, provider.Model())
+	content, repairErr := provider.AnalyzeDiff(ctx, system, user)
+	if repairErr != nil {
+		return nil, fmt.Errorf("%w (repair failed: %v)", err, repairErr)
+	}
+
+	return processAnalyzeResponse(provider.Name(), content, false)
+}
+
+// BuildRepairPrompt creates the prompts for a repair turn to model.
+func BuildRepairPrompt(parseErr *PlanParseError, model string) (system, user string) {
+	system = `You fix malformed JSON produced by a git commit planner.
+
+Return the same commit plan as strict, valid JSON: a single object with a "commits" array.
+Each commit has "type", "scope" (string or null), "message", "files" (array of paths) and "reasoning".
+Keep every commit, file and message from the original; only fix the syntax.
+Return JSON only, no markdown code blocks and no commentary.`
+
+	content := parseErr.Content
+	if end := tokens.Fit(model, content, maxRepairTokens); end < len(content) {
+		content = content[:end] + "\n... (truncated)"
+	}
+
+	user = fmt.Sprintf("Parse error: %v\n\nMalformed response:\n%s", parseErr.Err, content)
+	return system, user
+}
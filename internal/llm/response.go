@@ -21,7 +21,7 @@ func processAnalyzeResponse(provider, content string, truncated bool) (*types.Co
 
 	var plan types.CommitPlan
 	if err := json.Unmarshal([]byte(content), &plan); err != nil {
-		return nil, &ProviderError{Provider: provider, Message: "failed to parse commit plan", Err: err}
+		return nil, &ProviderError{Provider: provider, Message: "failed to parse commit plan", Err: &PlanParseError{Content: content, Err: err}}
 	}
 
 	return &plan, nil
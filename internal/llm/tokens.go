@@ -0,0 +1,69 @@
+package llm
+
+import (
+	"encoding/json"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/tokens"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// PromptTokens approximates the tokens the analysis prompts for req take up
+// with model.
+func PromptTokens(model string, req *types.AnalysisRequest) int {
+	system, user := BuildPrompt(req)
+	return tokens.Count(model, system) + tokens.Count(model, user)
+}
+
+// PlanTokens approximates the tokens model spent writing plan as JSON.
+// Providers don't report usage to callers, so the plan is re-encoded.
+func PlanTokens(model string, plan *types.CommitPlan) int {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return 0
+	}
+	return tokens.Count(model, string(data))
+}
+
+// PromptFit describes how the analysis prompts for a request fit a model's
+// context window.
+type PromptFit struct {
+	Tokens  int // approximate prompt tokens, after any trimming
+	Window  int // the model's context window
+	Dropped int // approximate diff tokens cut to fit; 0 if none
+}
+
+// FitPrompt returns req with its diff cut, at a line boundary where it can
+// be, so the analysis prompts leave room in model's context window for an
+// answer of up to maxTokens (0 for the default). req itself is returned when
+// it already fits; otherwise it's copied, not changed.
+func FitPrompt(model string, maxTokens int, req *types.AnalysisRequest) (*types.AnalysisRequest, PromptFit) {
+	fit := PromptFit{Tokens: PromptTokens(model, req), Window: tokens.ContextWindow(model)}
+	over := fit.Tokens - (fit.Window - ResponseTokens(maxTokens))
+	if over <= 0 || req.Diff == "" {
+		return req, fit
+	}
+
+	diffTokens := tokens.Count(model, req.Diff)
+	trimmed := *req
+	trimmed.Diff = truncatedMarker
+	if keep := diffTokens - over - tokens.Count(model, truncatedMarker); keep > 0 {
+		if end := tokens.Fit(model, req.Diff, keep); end > 0 {
+			trimmed.Diff = git.TruncateDiff(req.Diff, end)
+		}
+	}
+
+	fit.Tokens = PromptTokens(model, &trimmed)
+	fit.Dropped = diffTokens - tokens.Count(model, trimmed.Diff)
+	return &trimmed, fit
+}
+
+// buildFittedPrompt builds the analysis prompts for req with the diff cut to
+// fit model's context window alongside an answer of up to maxTokens.
+func buildFittedPrompt(model string, maxTokens int, req *types.AnalysisRequest) (system, user string) {
+	req, _ = FitPrompt(model, maxTokens, req)
+	return BuildPrompt(req)
+}
+
+// truncatedMarker stands in for a diff cut away entirely.
+const truncatedMarker = "... (truncated)"
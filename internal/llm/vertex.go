@@ -0,0 +1,143 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/internal/httpclient"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+const (
+	// vertexRegionalURL takes the location; vertexGlobalURL serves "global"
+	vertexRegionalURL     = "https://%s-aiplatform.googleapis.com"
+	vertexGlobalURL       = "https://aiplatform.googleapis.com"
+	defaultVertexLocation = "us-central1"
+	defaultVertexModel    = "gemini-2.5-pro"
+)
+
+// VertexProvider implements the Provider interface for Gemini models on
+// Google Cloud Vertex AI, authenticated with Application Default Credentials
+// (a service account key, gcloud's login or the metadata server) rather than
+// an API key.
+type VertexProvider struct {
+	project  string
+	location string
+	model    string
+	client   *http.Client
+	// baseURL is the API root, e.g. https://us-central1-aiplatform.googleapis.com
+	baseURL  string
+	tokens   *googleTokenSource
+	sampling sampling
+}
+
+// NewVertexProvider creates a new Vertex AI provider for project's models in
+// location. credentials is a service account key or authorized user file;
+// empty finds Application Default Credentials.
+func NewVertexProvider(project, location, credentials, model string, opts ProviderOptions) (*VertexProvider, error) {
+	assert.NotEmptyString(project, "Vertex AI project is required")
+
+	if location == "" {
+		location = defaultVertexLocation
+	}
+	if model == "" {
+		model = defaultVertexModel
+	}
+
+	baseURL := fmt.Sprintf(vertexRegionalURL, location)
+	if location == "global" {
+		baseURL = vertexGlobalURL
+	}
+
+	// Token requests don't get the provider's extra headers
+	tokens, err := newGoogleTokenSource(httpclient.NewClient(opts.timeout()), credentials)
+	if err != nil {
+		return nil, &ProviderError{Provider: "vertex", Message: "failed to load credentials", Err: err}
+	}
+
+	return &VertexProvider{
+		project:  project,
+		location: location,
+		model:    model,
+		baseURL:  opts.baseURLOr(baseURL),
+		client:   newHTTPClient(opts.timeout(), opts.Headers),
+		sampling: opts.sampling(),
+		tokens:   tokens,
+	}, nil
+}
+
+// Name returns the provider name.
+func (p *VertexProvider) Name() string {
+	return "vertex"
+}
+
+// Model returns the model being used.
+func (p *VertexProvider) Model() string {
+	return p.model
+}
+
+// Analyze sends an analysis request to Vertex AI and returns a commit plan.
+// Vertex AI serves Gemini's generateContent API.
+func (p *VertexProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	assert.NotNil(req, "analysis request cannot be nil")
+	assert.NotEmpty(req.Files, "analysis request must have files")
+
+	params, err := p.requestParams(ctx, "generateContent")
+	if err != nil {
+		return nil, err
+	}
+	systemPrompt, userPrompt := buildFittedPrompt(p.model, p.sampling.maxTokens, req)
+	content, truncated, err := generateGeminiContent(ctx, params, systemPrompt, userPrompt)
+	if err != nil {
+		return nil, err
+	}
+	return processAnalyzeResponse("vertex", content, truncated)
+}
+
+// AnalyzeDiff sends a diff analysis request to Vertex AI and returns the analysis.
+func (p *VertexProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	params, err := p.requestParams(ctx, "generateContent")
+	if err != nil {
+		return "", err
+	}
+	content, truncated, err := generateGeminiContent(ctx, params, system, user)
+	if err != nil {
+		return "", err
+	}
+	return processTextResponse("vertex", content, truncated)
+}
+
+// requestParams returns the parameters for calling method on the model,
+// with a current access token. Credentials that can't be used are reported
+// as unauthorized.
+func (p *VertexProvider) requestParams(ctx context.Context, method string) (llmRequestParams, error) {
+	token, err := p.tokens.Token(ctx)
+	if err != nil {
+		return llmRequestParams{}, &ProviderError{
+			Provider:   "vertex",
+			Message:    "failed to get an access token",
+			Err:        err,
+			StatusCode: http.StatusUnauthorized,
+		}
+	}
+	return llmRequestParams{
+		httpClient: p.client,
+		model:      p.model,
+		url:        p.modelURL(method),
+		headers: map[string]string{
+			"Content-Type":  "application/json",
+			"Authorization": "Bearer " + token,
+		},
+		provider: "vertex",
+		sampling: p.sampling,
+	}, nil
+}
+
+// modelURL returns the URL of method on the model, e.g.
+// .../v1/projects/p/locations/us-central1/publishers/google/models/m:generateContent.
+func (p *VertexProvider) modelURL(method string) string {
+	return fmt.Sprintf("%s/v1/projects/%s/locations/%s/publishers/google/models/%s:%s",
+		p.baseURL, p.project, p.location, p.model, method)
+}
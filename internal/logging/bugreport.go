@@ -0,0 +1,135 @@
+package logging
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dsswift/commit/internal/config"
+)
+
+// NoFailedRunError indicates there is no failed run with a log to report.
+type NoFailedRunError struct{}
+
+func (e *NoFailedRunError) Error() string {
+	return "no failed runs with an execution log"
+}
+
+// secretPatterns match credentials that may appear in logged output, such as
+// provider error messages that echo a key or an Authorization header.
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`sk-[A-Za-z0-9_\-]{16,}`),
+	regexp.MustCompile(`xai-[A-Za-z0-9_\-]{16,}`),
+	regexp.MustCompile(`AIza[A-Za-z0-9_\-]{30,}`),
+	regexp.MustCompile(`gh[pousr]_[A-Za-z0-9]{20,}`),
+	regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._\-]{8,}`),
+	regexp.MustCompile(`(?i)((?:api[_-]?key|token|secret|password)["']?\s*[:=]\s*["']?)[^\s"',&]+`),
+}
+
+// emailPattern matches email addresses, e.g. in commit authors.
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// Redact removes credentials, email addresses and the home directory from
+// text. secrets are literal values (e.g. configured API keys) that are
+// removed wherever they appear.
+func Redact(text string, secrets []string) string {
+	for _, s := range secrets {
+		if len(s) >= 8 {
+			text = strings.ReplaceAll(text, s, "[REDACTED]")
+		}
+	}
+	for _, p := range secretPatterns {
+		if p.NumSubexp() > 0 {
+			text = p.ReplaceAllString(text, "${1}[REDACTED]")
+		} else {
+			text = p.ReplaceAllString(text, "[REDACTED]")
+		}
+	}
+	text = emailPattern.ReplaceAllString(text, "[EMAIL]")
+	if home, err := os.UserHomeDir(); err == nil && len(home) > 1 {
+		text = strings.ReplaceAll(text, home, "~")
+	}
+	return text
+}
+
+// LatestFailure returns the most recent run that exited non-zero and still
+// has its execution log, along with the log's path.
+func LatestFailure() (*RegistryEntry, string, error) {
+	configPath, err := config.ProfilePath()
+	if err != nil {
+		return nil, "", err
+	}
+
+	matches, err := SearchHistory("")
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, m := range matches {
+		if m.Entry.ExitCode == 0 {
+			continue
+		}
+		path := filepath.Join(configPath, "logs", "executions", m.Entry.ExecutionID+".jsonl")
+		if _, err := os.Stat(path); err == nil {
+			entry := m.Entry
+			return &entry, path, nil
+		}
+	}
+	return nil, "", &NoFailedRunError{}
+}
+
+// WriteBugReport writes a gzipped tar archive for the run in entry holding its
+// redacted registry entry and execution log, plus env for the current machine.
+func WriteBugReport(w io.Writer, entry RegistryEntry, logPath string, env Environment, secrets []string) error {
+	executionLog, err := os.ReadFile(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to read execution log: %w", err)
+	}
+	run, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+	environment, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+
+	files := []struct {
+		name string
+		data string
+	}{
+		{"run.json", Redact(string(run), secrets)},
+		{"environment.json", Redact(string(environment), secrets)},
+		{"execution.jsonl", Redact(string(executionLog), secrets)},
+	}
+	for _, f := range files {
+		header := &tar.Header{
+			Name:    entry.ExecutionID + "/" + f.name,
+			Mode:    0600,
+			Size:    int64(len(f.data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write([]byte(f.data)); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
@@ -0,0 +1,101 @@
+package logging
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/dsswift/commit/internal/git"
+)
+
+// Environment describes the machine and repository a run happened in, so a
+// failure can be reproduced. It never includes credentials.
+type Environment struct {
+	GitVersion string `json:"git_version,omitempty"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	GoVersion  string `json:"go_version"`
+	Terminal   string `json:"terminal,omitempty"`
+	Locale     string `json:"locale,omitempty"`
+	Provider   string `json:"provider,omitempty"`
+	Model      string `json:"model,omitempty"`
+
+	// Repository size, when the run is inside one
+	RepoFiles   int   `json:"repo_files,omitempty"`
+	RepoCommits int   `json:"repo_commits,omitempty"`
+	RepoSizeKB  int64 `json:"repo_size_kb,omitempty"`
+}
+
+// CaptureEnvironment collects the environment for a run in gitRoot, which may
+// be empty outside a repository. Values that can't be read are left empty.
+func CaptureEnvironment(gitRoot string) Environment {
+	env := Environment{
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		GoVersion: runtime.Version(),
+		Terminal:  terminalName(),
+		Locale:    locale(),
+	}
+
+	if out, err := git.Command("--version").Output(); err == nil {
+		env.GitVersion = strings.TrimPrefix(strings.TrimSpace(string(out)), "git version ")
+	}
+
+	if gitRoot == "" {
+		return env
+	}
+
+	gitOutput := func(args ...string) []byte {
+		cmd := git.Command(args...)
+		cmd.Dir = gitRoot
+		out, _ := cmd.Output()
+		return out
+	}
+
+	if out := gitOutput("ls-files", "-z"); len(out) > 0 {
+		env.RepoFiles = bytes.Count(out, []byte{0})
+	}
+	env.RepoCommits, _ = strconv.Atoi(strings.TrimSpace(string(gitOutput("rev-list", "--count", "HEAD"))))
+	env.RepoSizeKB = objectSizeKB(string(gitOutput("count-objects", "-v")))
+
+	return env
+}
+
+// terminalName describes the terminal from TERM and TERM_PROGRAM.
+func terminalName() string {
+	term := os.Getenv("TERM")
+	if program := os.Getenv("TERM_PROGRAM"); program != "" {
+		if term == "" {
+			return program
+		}
+		return term + " (" + program + ")"
+	}
+	return term
+}
+
+// locale returns the effective locale, following the POSIX precedence.
+func locale() string {
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// objectSizeKB sums loose and packed object sizes from `git count-objects -v`.
+func objectSizeKB(output string) int64 {
+	var total int64
+	for _, line := range strings.Split(output, "\n") {
+		key, value, ok := strings.Cut(line, ": ")
+		if !ok || (key != "size" && key != "size-pack") {
+			continue
+		}
+		if n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64); err == nil {
+			total += n
+		}
+	}
+	return total
+}
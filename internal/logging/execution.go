@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/pkg/types"
 )
 
 // ExecutionLogger logs events for a single execution.
@@ -28,7 +29,7 @@ type LogEvent struct {
 
 // NewExecutionLogger creates a new execution logger.
 func NewExecutionLogger(executionID string) (*ExecutionLogger, error) {
-	configPath, err := config.ConfigPath()
+	configPath, err := config.ProfilePath()
 	if err != nil {
 		return nil, err
 	}
@@ -53,6 +54,11 @@ func NewExecutionLogger(executionID string) (*ExecutionLogger, error) {
 	}, nil
 }
 
+// ExecutionID returns the ID of the execution being logged.
+func (l *ExecutionLogger) ExecutionID() string {
+	return l.executionID
+}
+
 // Log writes an event to the execution log.
 func (l *ExecutionLogger) Log(event string, data any) {
 	if l.file == nil {
@@ -92,6 +98,14 @@ func (l *ExecutionLogger) LogConfigLoaded(provider string, hasRepoConfig bool, s
 	})
 }
 
+// LogProviderOverride logs a provider or model overridden on the command line.
+func (l *ExecutionLogger) LogProviderOverride(provider, model string) {
+	l.Log("provider_override", map[string]any{
+		"provider": provider,
+		"model":    model,
+	})
+}
+
 // LogGitStatus logs the git status.
 func (l *ExecutionLogger) LogGitStatus(output string) {
 	l.Log("git_status", map[string]any{
@@ -115,28 +129,172 @@ func (l *ExecutionLogger) LogGitLog(recentCommits []string) {
 }
 
 // LogContextBuilt logs the analysis context summary.
-func (l *ExecutionLogger) LogContextBuilt(fileCount int, diffChars int, scopes []string) {
+func (l *ExecutionLogger) LogContextBuilt(fileCount int, diffTokens int, scopes []string) {
 	l.Log("context_built", map[string]any{
-		"file_count":       fileCount,
-		"total_diff_chars": diffChars,
-		"scopes_detected":  scopes,
+		"file_count":        fileCount,
+		"total_diff_tokens": diffTokens,
+		"scopes_detected":   scopes,
 	})
 }
 
 // LogLLMRequest logs the LLM request (without sensitive content).
-func (l *ExecutionLogger) LogLLMRequest(provider, model string, promptLength int) {
+func (l *ExecutionLogger) LogLLMRequest(provider, model string, promptTokens int) {
 	l.Log("llm_request", map[string]any{
 		"provider":      provider,
 		"model":         model,
-		"prompt_length": promptLength,
+		"prompt_tokens": promptTokens,
+	})
+}
+
+// LogPromptTrimmed logs a diff cut so the analysis prompt fits model's
+// context window, with the estimated tokens sent and left out.
+func (l *ExecutionLogger) LogPromptTrimmed(model string, window, promptTokens, droppedTokens int) {
+	l.Log("prompt_trimmed", map[string]any{
+		"model":          model,
+		"context_window": window,
+		"prompt_tokens":  promptTokens,
+		"dropped_tokens": droppedTokens,
+	})
+}
+
+// LogAnalysisChunked logs a change set too large for model's context window
+// being analyzed in chunks, with each chunk's approximate prompt tokens.
+func (l *ExecutionLogger) LogAnalysisChunked(model string, window int, chunkTokens []int) {
+	l.Log("analysis_chunked", map[string]any{
+		"model":          model,
+		"context_window": window,
+		"chunks":         len(chunkTokens),
+		"chunk_tokens":   chunkTokens,
 	})
 }
 
-// LogLLMResponse logs the LLM response.
-func (l *ExecutionLogger) LogLLMResponse(responseLength int, commitsPlanned int) {
-	l.Log("llm_response", map[string]any{
-		"response_length": responseLength,
+// LogLLMResponse logs the LLM response. A negative cost means the model's
+// price is unknown and is left out.
+func (l *ExecutionLogger) LogLLMResponse(responseTokens int, commitsPlanned int, estimatedCost float64) {
+	data := map[string]any{
+		"response_tokens": responseTokens,
 		"commits_planned": commitsPlanned,
+	}
+	if estimatedCost >= 0 {
+		data["estimated_cost_usd"] = estimatedCost
+	}
+	l.Log("llm_response", data)
+}
+
+// LogBudgetAlert logs a request that nears or exceeds the monthly budget:
+// what was spent this month, what the request is estimated to add, and
+// whether it was refused.
+func (l *ExecutionLogger) LogBudgetAlert(spent, request, budget float64, refused bool) {
+	l.Log("budget_alert", map[string]any{
+		"spent_usd":   spent,
+		"request_usd": request,
+		"budget_usd":  budget,
+		"refused":     refused,
+	})
+}
+
+// LogPlanRepair logs a repair turn sent after an unparseable plan response.
+func (l *ExecutionLogger) LogPlanRepair(parseErr error) {
+	l.Log("plan_repair", map[string]any{
+		"error": parseErr.Error(),
+	})
+}
+
+// LogAnalysisTimeout logs an LLM request cancelled after --max-wait and the
+// plan used instead.
+func (l *ExecutionLogger) LogAnalysisTimeout(maxWait time.Duration, fallback string) {
+	l.Log("analysis_timeout", map[string]any{
+		"max_wait_ms": maxWait.Milliseconds(),
+		"fallback":    fallback,
+	})
+}
+
+// LogSampling logs the sampling settings sent with LLM requests, so a plan
+// can be reproduced. A nil temperature, seed or top_p, or a zero maxTokens,
+// is left out; the provider's defaults applied.
+func (l *ExecutionLogger) LogSampling(temperature *float64, seed *int, topP *float64, maxTokens int, deterministic bool) {
+	data := map[string]any{
+		"deterministic": deterministic,
+	}
+	if temperature != nil {
+		data["temperature"] = *temperature
+	}
+	if seed != nil {
+		data["seed"] = *seed
+	}
+	if topP != nil {
+		data["top_p"] = *topP
+	}
+	if maxTokens > 0 {
+		data["max_tokens"] = maxTokens
+	}
+	l.Log("sampling", data)
+}
+
+// LogProviderFallback logs a request sent to the next provider in
+// COMMIT_PROVIDER_FALLBACKS after failed, given as provider/model, couldn't
+// serve it.
+func (l *ExecutionLogger) LogProviderFallback(failed, next string, err error) {
+	l.Log("provider_fallback", map[string]any{
+		"failed": failed,
+		"next":   next,
+		"error":  err.Error(),
+	})
+}
+
+// LogSnapshot logs the --snapshot copy taken of the working tree's
+// uncommitted files before the run staged anything.
+func (l *ExecutionLogger) LogSnapshot(dir string, files []string) {
+	l.Log("snapshot", map[string]any{
+		"dir":   dir,
+		"files": files,
+	})
+}
+
+// LogFastPlan logs a plan made locally by --fast instead of by the LLM.
+func (l *ExecutionLogger) LogFastPlan(commitsPlanned int) {
+	l.Log("fast_plan", map[string]any{
+		"commits_planned": commitsPlanned,
+	})
+}
+
+// LogPlanCacheHit logs a plan reused from the plan cache instead of the LLM.
+func (l *ExecutionLogger) LogPlanCacheHit(key string, commitsPlanned int) {
+	l.Log("plan_cache_hit", map[string]any{
+		"key":             key,
+		"commits_planned": commitsPlanned,
+	})
+}
+
+// LogResponseCacheHit logs a provider response reused from the response
+// cache instead of calling the provider.
+func (l *ExecutionLogger) LogResponseCacheHit(key, model string, age time.Duration, commitsPlanned int) {
+	l.Log("response_cache_hit", map[string]any{
+		"key":             key,
+		"model":           model,
+		"age_ms":          age.Milliseconds(),
+		"commits_planned": commitsPlanned,
+	})
+}
+
+// LogPlanDelta logs a cached plan reused for all but the changed files, which
+// are re-analyzed.
+func (l *ExecutionLogger) LogPlanDelta(changed []string, commitsReused int) {
+	l.Log("plan_delta", map[string]any{
+		"changed":        changed,
+		"commits_reused": commitsReused,
+	})
+}
+
+// LogStaleCheck logs a re-check of the changes before committing a plan made
+// age ago: whether HEAD moved, which planned files changed and how many
+// validation errors the plan now has.
+func (l *ExecutionLogger) LogStaleCheck(age time.Duration, headMoved bool, changed []string, validationErrors int) {
+	l.Log("stale_check", map[string]any{
+		"age_ms":            age.Milliseconds(),
+		"head_moved":        headMoved,
+		"changed":           changed,
+		"validation_errors": validationErrors,
 	})
 }
 
@@ -148,6 +306,38 @@ func (l *ExecutionLogger) LogPlanValidated(valid bool, errors []string) {
 	})
 }
 
+// LogPlanNormalized logs the message changes made by the consistency pass.
+func (l *ExecutionLogger) LogPlanNormalized(changes []string) {
+	l.Log("plan_normalized", map[string]any{
+		"changes": changes,
+	})
+}
+
+// LogDuplicatesMerged logs near-duplicate commits merged into one.
+func (l *ExecutionLogger) LogDuplicatesMerged(merges []string) {
+	l.Log("duplicates_merged", map[string]any{
+		"merges": merges,
+	})
+}
+
+// LogBranchSuggestions logs the suggested branch names and the one created,
+// or "" if the run stayed on its branch.
+func (l *ExecutionLogger) LogBranchSuggestions(suggestions []string, created string) {
+	l.Log("branch_suggestions", map[string]any{
+		"suggestions": suggestions,
+		"created":     created,
+	})
+}
+
+// LogMassDeletion logs a plan over the mass-deletion thresholds and whether it went ahead.
+func (l *ExecutionLogger) LogMassDeletion(files []string, summary string, allowed bool) {
+	l.Log("mass_deletion", map[string]any{
+		"files":   files,
+		"summary": summary,
+		"allowed": allowed,
+	})
+}
+
 // LogCommitExecuted logs a successfully executed commit.
 func (l *ExecutionLogger) LogCommitExecuted(hash, message string, files []string) {
 	l.Log("commit_executed", map[string]any{
@@ -157,6 +347,84 @@ func (l *ExecutionLogger) LogCommitExecuted(hash, message string, files []string
 	})
 }
 
+// LogCommitSkipped logs a planned commit skipped because it was empty.
+func (l *ExecutionLogger) LogCommitSkipped(message string, files []string, reason string) {
+	l.Log("commit_skipped", map[string]any{
+		"message": message,
+		"files":   files,
+		"reason":  reason,
+	})
+}
+
+// LogCommitFailed logs a planned commit that failed and was skipped under
+// --on-error skip or ask. hash is set if the commit was created anyway.
+func (l *ExecutionLogger) LogCommitFailed(message string, files []string, hash string, err error) {
+	data := map[string]any{
+		"message": message,
+		"files":   files,
+		"error":   err.Error(),
+	}
+	if hash != "" {
+		data["hash"] = hash
+	}
+	l.Log("commit_failed", data)
+}
+
+// LogCommitVerification logs how an executed commit compared to its plan.
+func (l *ExecutionLogger) LogCommitVerification(hash string, verification *types.CommitVerification) {
+	l.Log("commit_verified", map[string]any{
+		"hash":          hash,
+		"status":        verification.Status,
+		"extra_files":   verification.ExtraFiles,
+		"missing_files": verification.MissingFiles,
+	})
+}
+
+// LogHookRewrite logs files a pre-commit hook rewrote while creating a
+// commit and the hookAutofix mode that handled them.
+func (l *ExecutionLogger) LogHookRewrite(hash string, files []string, mode string) {
+	l.Log("hook_rewrite", map[string]any{
+		"hash":  hash,
+		"files": files,
+		"mode":  mode,
+	})
+}
+
+// LogLeftover logs planned files still changed after the plan was executed.
+func (l *ExecutionLogger) LogLeftover(files []string) {
+	l.Log("leftover_changes", map[string]any{
+		"files": files,
+	})
+}
+
+// LogCommitBuild logs the result of running the verify command at a
+// created commit; err is nil when it passed.
+func (l *ExecutionLogger) LogCommitBuild(hash, command string, err error, output string) {
+	data := map[string]any{
+		"hash":    hash,
+		"command": command,
+		"passed":  err == nil,
+	}
+	if err != nil {
+		data["error"] = err.Error()
+		data["output"] = output
+	}
+	l.Log("commit_build", data)
+}
+
+// LogWebhook logs delivery of the run summary to the webhook; err is nil
+// when it was accepted.
+func (l *ExecutionLogger) LogWebhook(host string, err error) {
+	data := map[string]any{
+		"host":      host,
+		"delivered": err == nil,
+	}
+	if err != nil {
+		data["error"] = err.Error()
+	}
+	l.Log("webhook", data)
+}
+
 // LogDryRun logs dry run output.
 func (l *ExecutionLogger) LogDryRun(commits []map[string]any) {
 	l.Log("dry_run", map[string]any{
@@ -164,6 +432,50 @@ func (l *ExecutionLogger) LogDryRun(commits []map[string]any) {
 	})
 }
 
+// LogGitCommand logs a git invocation recorded by the git trace.
+func (l *ExecutionLogger) LogGitCommand(args []string, duration time.Duration, exitCode int, output, stderr string) {
+	l.Log("git_command", map[string]any{
+		"args":        args,
+		"duration_ms": duration.Milliseconds(),
+		"exit_code":   exitCode,
+		"output":      output,
+		"stderr":      stderr,
+	})
+}
+
+// LogInterrupted logs an execution stopped by a signal, with the commits it didn't create.
+func (l *ExecutionLogger) LogInterrupted(completed int, remaining []string) {
+	l.Log("interrupted", map[string]any{
+		"commits_created": completed,
+		"remaining":       remaining,
+	})
+}
+
+// LogEnvironment logs the environment the run happened in.
+func (l *ExecutionLogger) LogEnvironment(env Environment) {
+	l.Log("environment", map[string]any{
+		"git_version":  env.GitVersion,
+		"os":           env.OS,
+		"arch":         env.Arch,
+		"go_version":   env.GoVersion,
+		"terminal":     env.Terminal,
+		"locale":       env.Locale,
+		"provider":     env.Provider,
+		"model":        env.Model,
+		"repo_files":   env.RepoFiles,
+		"repo_commits": env.RepoCommits,
+		"repo_size_kb": env.RepoSizeKB,
+	})
+}
+
+// LogConsole logs a message printed to the console, with its level.
+func (l *ExecutionLogger) LogConsole(level, message string) {
+	l.Log("console", map[string]any{
+		"level":   level,
+		"message": message,
+	})
+}
+
 // LogError logs an error.
 func (l *ExecutionLogger) LogError(err error) {
 	l.Log("error", map[string]any{
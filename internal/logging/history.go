@@ -0,0 +1,159 @@
+package logging
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dsswift/commit/internal/config"
+)
+
+// HistoryCommit is a commit created by a past execution.
+type HistoryCommit struct {
+	Hash    string   `json:"hash"`
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+}
+
+// HistoryMatch is a past execution matching a history search.
+type HistoryMatch struct {
+	Entry   RegistryEntry
+	Commits []HistoryCommit
+	// MatchedIn lists where the query matched: "message", "file" or "context".
+	MatchedIn []string
+}
+
+// SearchHistory returns the executions in the registry (including rotated
+// backups) whose commit messages, committed files, arguments, paths or
+// logged context contain query, case-insensitively, newest first.
+// An empty query matches every execution.
+func SearchHistory(query string) ([]HistoryMatch, error) {
+	configPath, err := config.ProfilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	logsDir := filepath.Join(configPath, "logs")
+	registryPath := filepath.Join(logsDir, registryFile)
+
+	entries, err := readRegistry(registryPath)
+	if err != nil {
+		return nil, err
+	}
+
+	query = strings.ToLower(query)
+	var matches []HistoryMatch
+	for _, entry := range entries {
+		commits, context := readExecutionLog(filepath.Join(logsDir, "executions", entry.ExecutionID+".jsonl"))
+		match := HistoryMatch{Entry: entry, Commits: commits}
+
+		if query != "" {
+			match.MatchedIn = matchHistory(query, entry, commits, context)
+			if len(match.MatchedIn) == 0 {
+				continue
+			}
+		}
+		matches = append(matches, match)
+	}
+
+	// Registry order is chronological; RFC 3339 UTC timestamps sort as strings
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Entry.Timestamp > matches[j].Entry.Timestamp
+	})
+
+	return matches, nil
+}
+
+// readExecutionLog returns the commits and every other logged string value
+// from an execution log. A missing log (e.g. cleaned up) yields nothing.
+func readExecutionLog(path string) ([]HistoryCommit, []string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil
+	}
+
+	var commits []HistoryCommit
+	var context []string
+	for _, line := range splitLines(data) {
+		var event struct {
+			Event string          `json:"event"`
+			Data  json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(line, &event); err != nil {
+			continue
+		}
+
+		if event.Event == "commit_executed" {
+			var commit HistoryCommit
+			if err := json.Unmarshal(event.Data, &commit); err == nil {
+				commits = append(commits, commit)
+			}
+			continue
+		}
+
+		var value any
+		if err := json.Unmarshal(event.Data, &value); err == nil {
+			context = appendStrings(context, value)
+		}
+	}
+
+	return commits, context
+}
+
+// appendStrings appends all string values nested in v to out.
+func appendStrings(out []string, v any) []string {
+	switch val := v.(type) {
+	case string:
+		out = append(out, val)
+	case []any:
+		for _, item := range val {
+			out = appendStrings(out, item)
+		}
+	case map[string]any:
+		for _, item := range val {
+			out = appendStrings(out, item)
+		}
+	}
+	return out
+}
+
+// matchHistory reports where a lowercase query matches an execution.
+func matchHistory(query string, entry RegistryEntry, commits []HistoryCommit, context []string) []string {
+	contains := func(s string) bool {
+		return strings.Contains(strings.ToLower(s), query)
+	}
+
+	var matchedMessage, matchedFile, matchedContext bool
+	for _, c := range commits {
+		if contains(c.Message) {
+			matchedMessage = true
+		}
+		for _, f := range c.Files {
+			if contains(f) {
+				matchedFile = true
+			}
+		}
+	}
+
+	fields := append([]string{entry.CWD, entry.GitRoot}, entry.Args...)
+	for _, s := range append(fields, context...) {
+		if contains(s) {
+			matchedContext = true
+			break
+		}
+	}
+
+	var matchedIn []string
+	if matchedMessage {
+		matchedIn = append(matchedIn, "message")
+	}
+	if matchedFile {
+		matchedIn = append(matchedIn, "file")
+	}
+	if matchedContext {
+		matchedIn = append(matchedIn, "context")
+	}
+	return matchedIn
+}
@@ -1,7 +1,12 @@
 package logging
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -98,6 +103,10 @@ func TestExecutionLogger_Path(t *testing.T) {
 	if !strings.HasSuffix(path, ".jsonl") {
 		t.Errorf("expected .jsonl extension, got %q", path)
 	}
+
+	if logger.ExecutionID() != execID {
+		t.Errorf("ExecutionID() = %q, want %q", logger.ExecutionID(), execID)
+	}
 }
 
 func TestWriteRegistryEntry(t *testing.T) {
@@ -280,16 +289,42 @@ func TestExecutionLogger_AllLogMethods(t *testing.T) {
 
 	// Call all log methods to ensure they don't panic
 	logger.LogStart("1.0.0", []string{})
+	logger.LogEnvironment(CaptureEnvironment(""))
 	logger.LogConfigLoaded("anthropic", true, []string{"api"})
 	logger.LogGitStatus("M file.go")
 	logger.LogGitDiff([]string{"file.go"}, 100)
 	logger.LogGitLog([]string{"commit 1", "commit 2"})
+	logger.LogGitCommand([]string{"status", "--porcelain"}, 5*time.Millisecond, 0, "M file.go", "")
 	logger.LogContextBuilt(5, 1000, []string{"api", "core"})
 	logger.LogLLMRequest("anthropic", "claude-3-5-sonnet", 2000)
-	logger.LogLLMResponse(500, 3)
+	logger.LogLLMResponse(500, 3, 0.01)
+	logger.LogAnalysisTimeout(20*time.Second, "heuristic")
+	temperature, seed := 0.0, 42
+	logger.LogSampling(&temperature, &seed, nil, 4096, true)
+	logger.LogProviderFallback("anthropic/claude-3-5-sonnet", "openai/gpt-4o", fmt.Errorf("rate limited"))
+	logger.LogSnapshot("/repo/.git/commit-tool/snapshots/exec_1", []string{"api.go"})
+	logger.LogPromptTrimmed("llama3.1", 32768, 24500, 61000)
+	logger.LogAnalysisChunked("llama3.1", 32768, []int{24000, 18500})
+	logger.LogBudgetAlert(9.5, 0.75, 10, true)
+	logger.LogResponseCacheHit("abc123", "anthropic/claude-sonnet-4-5", 5*time.Minute, 2)
+	logger.LogFastPlan(2)
+	logger.LogPlanDelta([]string{"api.go"}, 2)
+	logger.LogStaleCheck(15*time.Minute, true, []string{"api.go"}, 1)
 	logger.LogPlanValidated(true, nil)
+	logger.LogPlanNormalized([]string{`"feat: Added api" → "feat: add api"`})
+	logger.LogDuplicatesMerged([]string{`"correct retry logic" + "fix retry logic bug" → "correct retry logic"`})
+	logger.LogBranchSuggestions([]string{"feat/retry-backoff", "feat/http-retries"}, "feat/retry-backoff")
+	logger.LogInterrupted(1, []string{"feat: add api"})
+	logger.LogMassDeletion([]string{"old/a.go"}, "plan deletes 1 files", true)
 	logger.LogCommitExecuted("abc123", "feat: add feature", []string{"file.go"})
 	logger.LogDryRun([]map[string]any{{"type": "feat"}})
+	logger.LogHookRewrite("abc123", []string{"api.go"}, "amend")
+	logger.LogLeftover([]string{"api.go"})
+	logger.LogCommitSkipped("fix: tidy", []string{"fmt.go"}, "nothing to commit")
+	logger.LogCommitFailed("feat: add b", []string{"b.go"}, "", fmt.Errorf("pre-commit hook failed"))
+	logger.LogCommitBuild("abc123", "go build ./...", nil, "")
+	logger.LogWebhook("hooks.example.com", nil)
+	logger.LogConsole("warn", "Ignoring theme")
 	logger.LogError(&testError{"test error"})
 	logger.LogComplete(0, 3)
 
@@ -383,3 +418,237 @@ type testError struct {
 func (e *testError) Error() string {
 	return e.msg
 }
+
+func TestSearchHistory(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	writeRun := func(id, ts string, scopes []string, commits ...[]string) {
+		t.Helper()
+		logger, err := NewExecutionLogger(id)
+		if err != nil {
+			t.Fatalf("NewExecutionLogger failed: %v", err)
+		}
+		logger.LogConfigLoaded("openai", true, scopes)
+		for _, c := range commits {
+			logger.LogCommitExecuted(c[0], c[1], c[2:])
+		}
+		_ = logger.Close()
+
+		if err := WriteRegistryEntry(RegistryEntry{ExecutionID: id, Timestamp: ts, GitRoot: "/repo", CommitsCreated: len(commits)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	writeRun("exec_1", "2026-10-13T09:00:00Z", nil,
+		[]string{"aaa1111", "feat(billing): add invoice totals", "billing/invoice.go"})
+	writeRun("exec_2", "2026-10-14T09:00:00Z", []string{"payments"},
+		[]string{"bbb2222", "fix: handle empty cart", "cart/Invoice_test.go"})
+	writeRun("exec_3", "2026-10-15T09:00:00Z", nil)
+
+	matches, err := SearchHistory("INVOICE")
+	if err != nil {
+		t.Fatalf("SearchHistory failed: %v", err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(matches))
+	}
+	// Newest first
+	if matches[0].Entry.ExecutionID != "exec_2" || matches[1].Entry.ExecutionID != "exec_1" {
+		t.Errorf("unexpected order: %s, %s", matches[0].Entry.ExecutionID, matches[1].Entry.ExecutionID)
+	}
+	if got := strings.Join(matches[1].MatchedIn, ","); got != "message,file" {
+		t.Errorf("expected message and file match, got %q", got)
+	}
+	if len(matches[1].Commits) != 1 || matches[1].Commits[0].Hash != "aaa1111" {
+		t.Errorf("expected commit hash in match, got %+v", matches[1].Commits)
+	}
+
+	// Context strings (e.g. detected scopes) are searchable too
+	matches, _ = SearchHistory("payments")
+	if len(matches) != 1 || matches[0].MatchedIn[0] != "context" {
+		t.Errorf("expected context match, got %+v", matches)
+	}
+
+	// An empty query lists every run
+	matches, _ = SearchHistory("")
+	if len(matches) != 3 {
+		t.Errorf("expected all 3 runs, got %d", len(matches))
+	}
+}
+
+func TestReadStats(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	entries := []RegistryEntry{
+		{ExecutionID: "exec_old", Timestamp: "2026-08-01T09:00:00Z", GitRoot: "/repo", CommitsCreated: 1,
+			Commits: []RegistryCommit{{Hash: "a", Type: "feat", Scope: "api", Files: []string{"api/old.go"}}}},
+		// Written before commits were recorded
+		{ExecutionID: "exec_1", Timestamp: "2026-10-01T09:00:00Z", GitRoot: "/repo", CommitsCreated: 2},
+		{ExecutionID: "exec_2", Timestamp: "2026-10-10T09:00:00Z", GitRoot: "/repo", CommitsCreated: 2,
+			Commits: []RegistryCommit{
+				{Hash: "b", Type: "fix", Scope: "api", Files: []string{"api/handler.go", "api/handler_test.go"}},
+				{Hash: "c", Type: "docs", Files: []string{"README.md"}},
+			}},
+		{ExecutionID: "exec_3", Timestamp: "2026-10-12T09:00:00Z", GitRoot: "/repo", CommitsCreated: 1,
+			Commits: []RegistryCommit{{Hash: "d", Type: "fix", Scope: "api", Files: []string{"api/handler.go"}}}},
+		{ExecutionID: "exec_4", Timestamp: "2026-10-12T10:00:00Z", GitRoot: "/other", CommitsCreated: 1,
+			Commits: []RegistryCommit{{Hash: "e", Type: "feat", Scope: "ui", Files: []string{"api/handler.go"}}}},
+	}
+	for _, e := range entries {
+		if err := WriteRegistryEntry(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	since := time.Date(2026, 9, 15, 0, 0, 0, 0, time.UTC)
+	stats, err := ReadStats(since, "/repo")
+	if err != nil {
+		t.Fatalf("ReadStats failed: %v", err)
+	}
+	if stats.Runs != 3 || stats.Commits != 5 || stats.Recorded != 3 {
+		t.Errorf("runs, commits, recorded = %d, %d, %d, want 3, 5, 3", stats.Runs, stats.Commits, stats.Recorded)
+	}
+	if want := []StatCount{{"api/handler.go", 2}, {"README.md", 1}, {"api/handler_test.go", 1}}; fmt.Sprint(stats.Files) != fmt.Sprint(want) {
+		t.Errorf("Files = %v, want %v", stats.Files, want)
+	}
+	if want := []StatCount{{"api", 2}}; fmt.Sprint(stats.Scopes) != fmt.Sprint(want) {
+		t.Errorf("Scopes = %v, want %v", stats.Scopes, want)
+	}
+	if want := []StatCount{{"fix", 2}, {"docs", 1}}; fmt.Sprint(stats.Types) != fmt.Sprint(want) {
+		t.Errorf("Types = %v, want %v", stats.Types, want)
+	}
+
+	// Across repositories, files are prefixed with their repository
+	stats, _ = ReadStats(since, "")
+	if stats.Runs != 4 || stats.Files[0] != (StatCount{"repo/api/handler.go", 2}) {
+		t.Errorf("unexpected stats across repositories: %+v", stats)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	usage, err := ReadUsage()
+	if err != nil || len(usage.Months) != 0 {
+		t.Fatalf("ReadUsage without a file = %+v, %v", usage, err)
+	}
+
+	oct := time.Date(2026, 10, 16, 12, 0, 0, 0, time.Local)
+	old := time.Date(2025, 9, 1, 12, 0, 0, 0, time.Local)
+	for _, r := range []struct {
+		at       time.Time
+		provider string
+		cost     float64
+	}{
+		{old, "openai", 5},
+		{oct, "anthropic", 0.25},
+		{oct, "openai", 0.5},
+		{oct, "anthropic", 0.5},
+		{oct, "ollama", -1},
+	} {
+		if err := RecordUsage(r.at, r.provider, 1000, 200, r.cost); err != nil {
+			t.Fatalf("RecordUsage failed: %v", err)
+		}
+	}
+
+	usage, err = ReadUsage()
+	if err != nil {
+		t.Fatalf("ReadUsage failed: %v", err)
+	}
+	if got := usage.MonthCost(oct); got != 1.25 {
+		t.Errorf("MonthCost = %v, want 1.25", got)
+	}
+	if got := usage.Providers(oct); fmt.Sprint(got) != "[anthropic openai ollama]" {
+		t.Errorf("Providers = %v", got)
+	}
+	anthropic := usage.Months["2026-10"]["anthropic"]
+	if anthropic.Requests != 2 || anthropic.PromptTokens != 2000 || anthropic.ResponseTokens != 400 {
+		t.Errorf("anthropic usage = %+v", anthropic)
+	}
+	if ollama := usage.Months["2026-10"]["ollama"]; ollama.Unpriced != 1 || ollama.CostUSD != 0 {
+		t.Errorf("unpriced usage = %+v", ollama)
+	}
+	if _, ok := usage.Months["2025-09"]; ok {
+		t.Error("usage from over a year ago wasn't pruned")
+	}
+}
+
+func TestRedact(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	text := `error: 401 for key sk-ant-REDACTED from ` + home + `/repo by dev@example.com ` +
+		`Authorization: Bearer abcdef123456 "api_key": "plainvalue" custom-secret-value`
+
+	got := Redact(text, []string{"custom-secret-value", "short"})
+
+	for _, leaked := range []string{"sk-ant-REDACTED", home, "dev@example.com", "abcdef123456", "plainvalue", "custom-secret-value"} {
+		if strings.Contains(got, leaked) {
+			t.Errorf("Redact() leaked %q: %s", leaked, got)
+		}
+	}
+	if !strings.Contains(got, "~/repo") || !strings.Contains(got, `"api_key": "[REDACTED]"`) {
+		t.Errorf("Redact() = %s", got)
+	}
+}
+
+func TestBugReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	if _, _, err := LatestFailure(); err == nil {
+		t.Fatal("expected NoFailedRunError with no runs")
+	}
+
+	for i, exitCode := range []int{1, 0} {
+		id := fmt.Sprintf("exec_%d", i+1)
+		logger, _ := NewExecutionLogger(id)
+		logger.LogError(&testError{"provider rejected key sk-abcdefghijklmnopqrstuv"})
+		_ = logger.Close()
+		_ = WriteRegistryEntry(RegistryEntry{ExecutionID: id, Timestamp: fmt.Sprintf("2026-10-1%dT09:00:00Z", i+1), ExitCode: exitCode})
+	}
+
+	entry, logPath, err := LatestFailure()
+	if err != nil {
+		t.Fatalf("LatestFailure failed: %v", err)
+	}
+	if entry.ExecutionID != "exec_1" {
+		t.Errorf("LatestFailure() = %s, want the failed run exec_1", entry.ExecutionID)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteBugReport(&buf, *entry, logPath, Environment{OS: "linux"}, nil); err != nil {
+		t.Fatalf("WriteBugReport failed: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, header.Name)
+		data, _ := io.ReadAll(tr)
+		if strings.Contains(string(data), "sk-abcdefghijklmnopqrstuv") {
+			t.Errorf("%s contains an unredacted key", header.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != "exec_1/run.json,exec_1/environment.json,exec_1/execution.jsonl" {
+		t.Errorf("archive contents = %s", got)
+	}
+}
+
+func TestObjectSizeKB(t *testing.T) {
+	output := "count: 4\nsize: 12\nin-pack: 100\npacks: 1\nsize-pack: 340\nprune-packable: 0\n"
+	if got := objectSizeKB(output); got != 352 {
+		t.Errorf("objectSizeKB() = %d, want 352", got)
+	}
+}
@@ -29,6 +29,18 @@ type RegistryEntry struct {
 	DurationMS     int64    `json:"duration_ms"`
 	ExitCode       int      `json:"exit_code"`
 	CommitsCreated int      `json:"commits_created"`
+	Unverified     int      `json:"commits_unverified,omitempty"`
+	Partial        bool     `json:"partial,omitempty"`
+	// Commits is empty for entries written before scopes and files were recorded
+	Commits []RegistryCommit `json:"commits,omitempty"`
+}
+
+// RegistryCommit is a commit created by an execution.
+type RegistryCommit struct {
+	Hash  string   `json:"hash"`
+	Type  string   `json:"type"`
+	Scope string   `json:"scope,omitempty"`
+	Files []string `json:"files"`
 }
 
 // GenerateExecutionID creates a unique execution ID.
@@ -45,7 +57,7 @@ func GenerateExecutionID() string {
 
 // WriteRegistryEntry appends an entry to the tool_executions.jsonl file.
 func WriteRegistryEntry(entry RegistryEntry) error {
-	configPath, err := config.ConfigPath()
+	configPath, err := config.ProfilePath()
 	if err != nil {
 		return err
 	}
@@ -98,7 +110,7 @@ func rotateRegistry(path string) {
 
 // CleanupOldLogs removes execution logs older than retention period.
 func CleanupOldLogs() error {
-	configPath, err := config.ConfigPath()
+	configPath, err := config.ProfilePath()
 	if err != nil {
 		return err
 	}
@@ -135,7 +147,7 @@ func CleanupOldLogs() error {
 
 // GetRecentExecutions returns the most recent N executions from the registry.
 func GetRecentExecutions(count int) ([]RegistryEntry, error) {
-	configPath, err := config.ConfigPath()
+	configPath, err := config.ProfilePath()
 	if err != nil {
 		return nil, err
 	}
@@ -171,6 +183,29 @@ func GetRecentExecutions(count int) ([]RegistryEntry, error) {
 	return entries, nil
 }
 
+// readRegistry reads the entries in the registry at registryPath and its
+// rotated backups, oldest first. Malformed lines are skipped.
+func readRegistry(registryPath string) ([]RegistryEntry, error) {
+	var entries []RegistryEntry
+	for _, path := range []string{registryPath + ".2", registryPath + ".1", registryPath} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, line := range splitLines(data) {
+			var entry RegistryEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
 // splitLines splits byte data into lines.
 func splitLines(data []byte) [][]byte {
 	var lines [][]byte
@@ -0,0 +1,102 @@
+package logging
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dsswift/commit/internal/config"
+)
+
+// StatCount is how many commits touched a file, scope or type.
+type StatCount struct {
+	Name    string
+	Commits int
+}
+
+// Stats summarizes the executions in the registry over a period.
+type Stats struct {
+	Since   time.Time
+	Runs    int
+	Commits int
+	// Recorded is how many of Commits recorded their type, scope and files;
+	// older executions only recorded a count.
+	Recorded int
+	Types    []StatCount
+	Scopes   []StatCount
+	Files    []StatCount
+}
+
+// ReadStats summarizes the executions in the registry (including rotated
+// backups) since the given time. A non-empty gitRoot limits it to
+// executions in that repository; otherwise files are prefixed with their
+// repository's directory name so paths from different repositories don't
+// merge.
+func ReadStats(since time.Time, gitRoot string) (*Stats, error) {
+	configPath, err := config.ProfilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := readRegistry(filepath.Join(configPath, "logs", registryFile))
+	if err != nil {
+		return nil, err
+	}
+	return ComputeStats(entries, since, gitRoot), nil
+}
+
+// ComputeStats summarizes entries like ReadStats.
+func ComputeStats(entries []RegistryEntry, since time.Time, gitRoot string) *Stats {
+	stats := &Stats{Since: since}
+	types := map[string]int{}
+	scopes := map[string]int{}
+	files := map[string]int{}
+
+	for _, entry := range entries {
+		if gitRoot != "" && entry.GitRoot != gitRoot {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339, entry.Timestamp)
+		if err != nil || ts.Before(since) {
+			continue
+		}
+
+		stats.Runs++
+		stats.Commits += entry.CommitsCreated
+		stats.Recorded += len(entry.Commits)
+		for _, c := range entry.Commits {
+			if c.Type != "" {
+				types[c.Type]++
+			}
+			if c.Scope != "" {
+				scopes[c.Scope]++
+			}
+			for _, f := range c.Files {
+				if gitRoot == "" && entry.GitRoot != "" {
+					f = filepath.Base(entry.GitRoot) + "/" + f
+				}
+				files[f]++
+			}
+		}
+	}
+
+	stats.Types = sortCounts(types)
+	stats.Scopes = sortCounts(scopes)
+	stats.Files = sortCounts(files)
+	return stats
+}
+
+// sortCounts returns counts ordered by most commits, then name.
+func sortCounts(counts map[string]int) []StatCount {
+	sorted := make([]StatCount, 0, len(counts))
+	for name, n := range counts {
+		sorted = append(sorted, StatCount{Name: name, Commits: n})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Commits != sorted[j].Commits {
+			return sorted[i].Commits > sorted[j].Commits
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	return sorted
+}
@@ -0,0 +1,166 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/dsswift/commit/internal/config"
+)
+
+const (
+	usageFile = "usage.json"
+	// usageMonths is how many months of usage are kept, the current one
+	// included.
+	usageMonths = 12
+)
+
+// ProviderUsage is a provider's usage in one month.
+type ProviderUsage struct {
+	Requests       int     `json:"requests"`
+	PromptTokens   int     `json:"prompt_tokens"`
+	ResponseTokens int     `json:"response_tokens"`
+	CostUSD        float64 `json:"cost_usd"`
+	// Unpriced counts requests to models without a known price, which add
+	// tokens but no cost.
+	Unpriced int `json:"unpriced_requests,omitempty"`
+}
+
+// Usage is the LLM usage recorded in usage.json, by month ("2006-01") and
+// provider. Costs are estimates from token counts and list prices.
+type Usage struct {
+	Months map[string]map[string]*ProviderUsage `json:"months"`
+}
+
+// UsageMonth returns the key of the month t falls in.
+func UsageMonth(t time.Time) string {
+	return t.Local().Format("2006-01")
+}
+
+// Add records a request to provider in the month t falls in. A negative cost
+// means the model's price is unknown.
+func (u *Usage) Add(t time.Time, provider string, promptTokens, responseTokens int, cost float64) {
+	month := UsageMonth(t)
+	if u.Months == nil {
+		u.Months = map[string]map[string]*ProviderUsage{}
+	}
+	if u.Months[month] == nil {
+		u.Months[month] = map[string]*ProviderUsage{}
+	}
+	p := u.Months[month][provider]
+	if p == nil {
+		p = &ProviderUsage{}
+		u.Months[month][provider] = p
+	}
+
+	p.Requests++
+	p.PromptTokens += promptTokens
+	p.ResponseTokens += responseTokens
+	if cost < 0 {
+		p.Unpriced++
+	} else {
+		p.CostUSD += cost
+	}
+}
+
+// MonthCost returns the estimated cost of every request in the month t
+// falls in.
+func (u *Usage) MonthCost(t time.Time) float64 {
+	total := 0.0
+	for _, p := range u.Months[UsageMonth(t)] {
+		total += p.CostUSD
+	}
+	return total
+}
+
+// Providers returns the providers used in the month t falls in, most
+// expensive first.
+func (u *Usage) Providers(t time.Time) []string {
+	month := u.Months[UsageMonth(t)]
+	var names []string
+	for name := range month {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := month[names[i]], month[names[j]]
+		if a.CostUSD != b.CostUSD {
+			return a.CostUSD > b.CostUSD
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// prune drops months more than usageMonths before t.
+func (u *Usage) prune(t time.Time) {
+	oldest := UsageMonth(t.AddDate(0, 1-usageMonths, 0))
+	for month := range u.Months {
+		if month < oldest {
+			delete(u.Months, month)
+		}
+	}
+}
+
+// ReadUsage reads the usage recorded in the active profile's usage.json.
+// A missing file is no usage.
+func ReadUsage() (*Usage, error) {
+	path, err := usagePath()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &Usage{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return usage, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, usage); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return usage, nil
+}
+
+// RecordUsage adds a request to provider to usage.json, like Usage.Add, and
+// drops months older than a year.
+func RecordUsage(t time.Time, provider string, promptTokens, responseTokens int, cost float64) error {
+	usage, err := ReadUsage()
+	if err != nil {
+		// Start over rather than stop recording
+		usage = &Usage{}
+	}
+	usage.Add(t, provider, promptTokens, responseTokens, cost)
+	usage.prune(t)
+
+	path, err := usagePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(usage, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage: %w", err)
+	}
+
+	// Replace the file whole so a concurrent run never reads half of it
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func usagePath() (string, error) {
+	configPath, err := config.ProfilePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, usageFile), nil
+}
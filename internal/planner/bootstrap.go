@@ -0,0 +1,117 @@
+package planner
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// BootstrapReasoning marks commits planned locally for a repository's first commit.
+const BootstrapReasoning = "Planned locally for the repository's first commit"
+
+// configFiles are base names of build manifests, lockfiles and tool
+// configuration.
+var configFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "go.work": true,
+	"package.json": true, "package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"tsconfig.json": true, "cargo.toml": true, "cargo.lock": true,
+	"pyproject.toml": true, "requirements.txt": true, "setup.py": true, "setup.cfg": true, "poetry.lock": true,
+	"gemfile": true, "gemfile.lock": true, "pom.xml": true, "build.gradle": true, "settings.gradle": true,
+	"makefile": true, "dockerfile": true, "docker-compose.yml": true, "docker-compose.yaml": true,
+}
+
+// configExtensions are extensions of configuration files at the top level.
+var configExtensions = map[string]bool{
+	".json": true, ".toml": true, ".yaml": true, ".yml": true, ".ini": true, ".cfg": true, ".lock": true,
+}
+
+// bootstrapGroup is one commit of an initial plan.
+type bootstrapGroup struct {
+	typ     string
+	message string
+	files   []string
+}
+
+// BootstrapPlan plans a repository's first commits from file paths alone:
+// configuration (manifests, dotfiles, CI), then the project skeleton (source
+// and tests), then documentation. In single-commit mode, or when every file
+// falls in one group, it plans a single "chore: initial commit".
+func BootstrapPlan(req *types.AnalysisRequest) *types.CommitPlan {
+	if len(req.Files) == 0 {
+		return &types.CommitPlan{}
+	}
+
+	groups := []*bootstrapGroup{
+		{typ: "chore", message: "add project configuration"},
+		{typ: "feat", message: "add project skeleton"},
+		{typ: "docs", message: "add documentation"},
+	}
+	config, skeleton, docs := groups[0], groups[1], groups[2]
+	for _, f := range req.Files {
+		switch {
+		case isConfigFile(f.Path):
+			config.files = append(config.files, f.Path)
+		case isDocFile(f.Path):
+			docs.files = append(docs.files, f.Path)
+		default:
+			skeleton.files = append(skeleton.files, f.Path)
+		}
+	}
+	groups = slices.DeleteFunc(groups, func(g *bootstrapGroup) bool { return len(g.files) == 0 })
+
+	if req.SingleCommit || len(groups) == 1 {
+		commit := types.PlannedCommit{
+			Type:      allowedType("chore", req.Rules.Types),
+			Message:   "initial commit",
+			Reasoning: BootstrapReasoning,
+		}
+		for _, f := range req.Files {
+			commit.Files = append(commit.Files, f.Path)
+		}
+		return &types.CommitPlan{Commits: []types.PlannedCommit{commit}}
+	}
+
+	plan := &types.CommitPlan{}
+	for _, g := range groups {
+		plan.Commits = append(plan.Commits, types.PlannedCommit{
+			Type:      allowedType(g.typ, req.Rules.Types),
+			Message:   g.message,
+			Files:     g.files,
+			Reasoning: BootstrapReasoning,
+		})
+	}
+	return plan
+}
+
+// isConfigFile reports whether p is build or tool configuration: a known
+// manifest, CI configuration, or a top-level dotfile or config file.
+func isConfigFile(p string) bool {
+	trimmed := strings.TrimSuffix(p, "/")
+	base := path.Base(trimmed)
+	if configFiles[strings.ToLower(base)] {
+		return true
+	}
+	if strings.HasPrefix(p, ".github/") || strings.HasPrefix(p, ".gitlab/") || strings.HasPrefix(p, ".circleci/") {
+		return true
+	}
+	if strings.Contains(trimmed, "/") {
+		return false
+	}
+	// Top-level dotfiles and dot-directories (.gitignore, .vscode/) configure tools
+	return strings.HasPrefix(base, ".") ||
+		(!strings.HasSuffix(p, "/") && configExtensions[strings.ToLower(path.Ext(base))])
+}
+
+// allowedType returns typ if allowed permits it, otherwise chore or the
+// first allowed type.
+func allowedType(typ string, allowed []string) string {
+	if len(allowed) == 0 || slices.Contains(allowed, typ) {
+		return typ
+	}
+	if slices.Contains(allowed, "chore") {
+		return "chore"
+	}
+	return allowed[0]
+}
@@ -0,0 +1,71 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func TestBootstrapPlan(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: ".gitignore", Status: "added"},
+			{Path: ".github/", Status: "added"},
+			{Path: "go.mod", Status: "added"},
+			{Path: "cmd/", Status: "added"},
+			{Path: "main.go", Status: "added"},
+			{Path: "config/defaults.yaml", Status: "added"},
+			{Path: "README.md", Status: "added"},
+			{Path: "docs/", Status: "added"},
+		},
+		Rules: types.CommitRules{Types: []string{"feat", "docs", "chore"}},
+	}
+
+	plan := BootstrapPlan(req)
+
+	want := []struct {
+		header string
+		files  int
+	}{
+		{"chore: add project configuration", 3},
+		{"feat: add project skeleton", 3},
+		{"docs: add documentation", 2},
+	}
+	if len(plan.Commits) != len(want) {
+		t.Fatalf("expected %d commits, got %+v", len(want), plan.Commits)
+	}
+	for i, c := range plan.Commits {
+		if got := formatHeader(c); got != want[i].header || len(c.Files) != want[i].files {
+			t.Errorf("commit %d = %q with %v, want %q with %d files", i, got, c.Files, want[i].header, want[i].files)
+		}
+		if c.Reasoning != BootstrapReasoning {
+			t.Errorf("commit %d reasoning = %q", i, c.Reasoning)
+		}
+	}
+}
+
+func TestBootstrapPlan_InitialCommit(t *testing.T) {
+	files := []types.FileChange{{Path: "main.go"}, {Path: "README.md"}}
+
+	// Single-commit mode
+	plan := BootstrapPlan(&types.AnalysisRequest{Files: files, SingleCommit: true})
+	if len(plan.Commits) != 1 || formatHeader(plan.Commits[0]) != "chore: initial commit" || len(plan.Commits[0].Files) != 2 {
+		t.Errorf("expected one initial commit, got %+v", plan.Commits)
+	}
+
+	// Files all in one group
+	plan = BootstrapPlan(&types.AnalysisRequest{Files: files[:1]})
+	if len(plan.Commits) != 1 || formatHeader(plan.Commits[0]) != "chore: initial commit" {
+		t.Errorf("expected one initial commit, got %+v", plan.Commits)
+	}
+
+	// Disallowed types fall back
+	plan = BootstrapPlan(&types.AnalysisRequest{Files: files, Rules: types.CommitRules{Types: []string{"build", "docs"}}})
+	if len(plan.Commits) != 2 || plan.Commits[0].Type != "build" || plan.Commits[1].Type != "docs" {
+		t.Errorf("expected build and docs commits, got %+v", plan.Commits)
+	}
+
+	if plan := BootstrapPlan(&types.AnalysisRequest{}); len(plan.Commits) != 0 {
+		t.Errorf("expected an empty plan, got %+v", plan.Commits)
+	}
+}
@@ -0,0 +1,159 @@
+package planner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// BranchSuggestionCount is how many branch names SuggestBranches asks for.
+const BranchSuggestionCount = 3
+
+// maxBranchNameLength keeps suggested names short enough to type.
+const maxBranchNameLength = 50
+
+// branchNameInvalid matches runs of characters that don't belong in a
+// suggested branch name.
+var branchNameInvalid = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// BranchSuggester is the subset of an LLM provider used to suggest branch names.
+type BranchSuggester interface {
+	AnalyzeDiff(ctx context.Context, system, user string) (string, error)
+}
+
+// BuildBranchPrompt creates the LLM prompt for suggesting branch names for
+// the planned commits.
+func BuildBranchPrompt(plan *types.CommitPlan) (system, user string) {
+	system = fmt.Sprintf(`You name git branches for a set of planned commits.
+
+Rules:
+- Suggest exactly %d different names
+- Use the form type/short-description, e.g. feat/retry-backoff or fix/null-config
+- The type is the main conventional commit type of the change (feat, fix, refactor, docs, chore...)
+- The description is 2 to 4 lowercase words joined by hyphens
+- Return JSON only, no markdown code blocks, in the form:
+{"branches": ["feat/...", "feat/...", "fix/..."]}`, BranchSuggestionCount)
+
+	var b strings.Builder
+	b.WriteString("Planned commits:\n")
+	for _, commit := range plan.Commits {
+		subject := commit.Type
+		if scope := scopeOf(commit); scope != "" {
+			subject += "(" + scope + ")"
+		}
+		fmt.Fprintf(&b, "- %s: %s (%d files)\n", subject, commit.Message, len(commit.Files))
+		if commit.Body != "" {
+			fmt.Fprintf(&b, "  %s\n", firstLine(commit.Body))
+		}
+	}
+	user = b.String()
+
+	return system, user
+}
+
+// SuggestBranches asks the LLM for branch names for plan. Names are cleaned
+// up with SanitizeBranchName; duplicates and names that clean up to nothing
+// are dropped.
+func SuggestBranches(ctx context.Context, suggester BranchSuggester, plan *types.CommitPlan) ([]string, error) {
+	system, user := BuildBranchPrompt(plan)
+
+	content, err := suggester.AnalyzeDiff(ctx, system, user)
+	if err != nil {
+		return nil, err
+	}
+
+	content = strings.TrimSpace(content)
+	content = strings.TrimPrefix(content, "```json")
+	content = strings.TrimPrefix(content, "```")
+	content = strings.TrimSuffix(content, "```")
+
+	var response struct {
+		Branches []string `json:"branches"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimSpace(content)), &response); err != nil {
+		return nil, fmt.Errorf("failed to parse branch suggestions: %w", err)
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	for _, raw := range response.Branches {
+		name := SanitizeBranchName(raw)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == BranchSuggestionCount {
+			break
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no usable branch names in the response")
+	}
+	return names, nil
+}
+
+// SanitizeBranchName turns a suggested name into a valid, conventional
+// branch name: lowercase, words joined by hyphens, at most one "/" after
+// the type, no leading or trailing punctuation. It returns "" if nothing
+// usable is left.
+func SanitizeBranchName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Trim(name, "`'\"")
+
+	prefix, rest, hasPrefix := strings.Cut(name, "/")
+	if !hasPrefix {
+		prefix, rest = "", prefix
+	}
+	prefix = cleanBranchPart(prefix)
+	rest = cleanBranchPart(strings.ReplaceAll(rest, "/", "-"))
+
+	if len(rest) > maxBranchNameLength {
+		rest = strings.TrimRight(rest[:maxBranchNameLength], "-._")
+		if i := strings.LastIndex(rest, "-"); i > maxBranchNameLength/2 {
+			rest = rest[:i]
+		}
+	}
+	switch {
+	case rest == "":
+		return ""
+	case prefix == "":
+		return rest
+	}
+	return prefix + "/" + rest
+}
+
+// cleanBranchPart replaces characters git or shells dislike with hyphens
+// and trims punctuation from the ends. ".." and a trailing ".lock" are
+// invalid in ref names.
+func cleanBranchPart(s string) string {
+	s = branchNameInvalid.ReplaceAllString(s, "-")
+	for strings.Contains(s, "..") {
+		s = strings.ReplaceAll(s, "..", ".")
+	}
+	for strings.Contains(s, "--") {
+		s = strings.ReplaceAll(s, "--", "-")
+	}
+	s = strings.TrimSuffix(s, ".lock")
+	return strings.Trim(s, "-._")
+}
+
+// BranchAllowsPlan reports whether branch policies permit every commit in
+// plan on branch, so a suggestion doesn't lead straight into the guard.
+func BranchAllowsPlan(repoConfig *types.RepoConfig, branch string, plan *types.CommitPlan) bool {
+	for _, commit := range plan.Commits {
+		if repoConfig.BranchPolicyViolation(commit.Type, branch) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+	return line
+}
@@ -0,0 +1,84 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+type fakeBranchSuggester struct {
+	response string
+	err      error
+	user     string
+}
+
+func (f *fakeBranchSuggester) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	f.user = user
+	return f.response, f.err
+}
+
+func TestSuggestBranches(t *testing.T) {
+	api := "api"
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Scope: &api, Message: "retry failed requests with backoff", Files: []string{"api/retry.go"}},
+	}}
+
+	suggester := &fakeBranchSuggester{response: "```json\n" +
+		`{"branches": ["feat/retry-backoff", "Feat/Retry Backoff", "feat/http retries!", "fix/", "chore/tidy", "feat/extra"]}` +
+		"\n```"}
+	names, err := SuggestBranches(context.Background(), suggester, plan)
+	if err != nil {
+		t.Fatalf("SuggestBranches failed: %v", err)
+	}
+	if got := strings.Join(names, ","); got != "feat/retry-backoff,feat/http-retries,chore/tidy" {
+		t.Errorf("SuggestBranches() = %v", names)
+	}
+	if !strings.Contains(suggester.user, "feat(api): retry failed requests with backoff") {
+		t.Errorf("prompt doesn't list the plan:\n%s", suggester.user)
+	}
+
+	suggester.response = `{"branches": ["", "///"]}`
+	if _, err := SuggestBranches(context.Background(), suggester, plan); err == nil {
+		t.Error("expected an error when no name is usable")
+	}
+
+	suggester.err = errors.New("rate limited")
+	if _, err := SuggestBranches(context.Background(), suggester, plan); err == nil {
+		t.Error("expected the provider error")
+	}
+}
+
+func TestSanitizeBranchName(t *testing.T) {
+	tests := map[string]string{
+		"feat/retry-backoff":                  "feat/retry-backoff",
+		"  `Fix/Null Config`  ":               "fix/null-config",
+		"feat/api/retry":                      "feat/api-retry",
+		"docs/readme..update.lock":            "docs/readme.update",
+		"retry-backoff":                       "retry-backoff",
+		"feat/--weird__name--":                "feat/weird__name",
+		"feat/" + strings.Repeat("word-", 20): "feat/word-word-word-word-word-word-word-word-word",
+		"feat/":                               "",
+	}
+	for in, want := range tests {
+		if got := SanitizeBranchName(in); got != want {
+			t.Errorf("SanitizeBranchName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestBranchAllowsPlan(t *testing.T) {
+	repoConfig := &types.RepoConfig{BranchPolicies: []types.BranchPolicy{
+		{Branch: "main", Mode: "whitelist", Types: []string{"fix"}},
+	}}
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{{Type: "fix"}, {Type: "feat"}}}
+
+	if BranchAllowsPlan(repoConfig, "main", plan) {
+		t.Error("main only allows fix commits")
+	}
+	if !BranchAllowsPlan(repoConfig, "feat/retry-backoff", plan) {
+		t.Error("no policy applies to feature branches")
+	}
+}
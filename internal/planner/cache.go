@@ -0,0 +1,100 @@
+package planner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// CacheFile is the plan cache's file name inside the repository's git directory.
+const CacheFile = "commit-tool-plan.json"
+
+// PlanCache persists the last validated plan for a repository so a run over
+// an unchanged working tree (typically right after a dry-run) can skip the LLM.
+type PlanCache struct {
+	path string
+}
+
+type cachedPlan struct {
+	Key       string            `json:"key"`
+	CreatedAt time.Time         `json:"created_at"`
+	Plan      *types.CommitPlan `json:"plan"`
+	State     *TreeState        `json:"state,omitempty"`
+}
+
+// NewPlanCache creates a plan cache stored in the git directory of gitRoot,
+// which keeps it out of the working tree and per worktree.
+func NewPlanCache(gitRoot string) (*PlanCache, error) {
+	cmd := git.Command("rev-parse", "--git-path", CacheFile)
+	cmd.Dir = gitRoot
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate git directory: %w", err)
+	}
+
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(gitRoot, path)
+	}
+	return &PlanCache{path: path}, nil
+}
+
+// CacheKey combines a working tree hash with the other inputs that shape a
+// plan (provider, model, mode, guiding message, ...).
+func CacheKey(treeHash string, inputs ...string) string {
+	h := sha256.New()
+	h.Write([]byte(treeHash))
+	for _, input := range inputs {
+		fmt.Fprintf(h, "\x00%s", input)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Load returns the cached plan if it was stored under key.
+func (c *PlanCache) Load(key string) (*types.CommitPlan, bool) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, false
+	}
+
+	var cached cachedPlan
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, false
+	}
+	if cached.Key != key || cached.Plan == nil || len(cached.Plan.Commits) == 0 {
+		return nil, false
+	}
+
+	return cached.Plan, true
+}
+
+// Save replaces the cached plan with plan, stored under key.
+func (c *PlanCache) Save(key string, plan *types.CommitPlan) error {
+	return c.save(cachedPlan{Key: key, Plan: plan})
+}
+
+func (c *PlanCache) save(cached cachedPlan) error {
+	cached.CreatedAt = time.Now()
+	data, err := json.Marshal(cached)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.path, data, 0600)
+}
+
+// Clear removes the cached plan.
+func (c *PlanCache) Clear() error {
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
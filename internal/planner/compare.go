@@ -0,0 +1,89 @@
+package planner
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// PlanDiff compares how two plans group files into commits and word them.
+type PlanDiff struct {
+	// Matched pairs commits that contain exactly the same files
+	Matched []CommitPair
+	// OnlyA and OnlyB are commits whose file grouping has no match in the other plan
+	OnlyA []types.PlannedCommit
+	OnlyB []types.PlannedCommit
+}
+
+// CommitPair is a commit from each plan with the same files.
+type CommitPair struct {
+	A types.PlannedCommit
+	B types.PlannedCommit
+}
+
+// SameMessage reports whether both commits have the same type, scope and message.
+func (p CommitPair) SameMessage() bool {
+	return p.A.Type == p.B.Type && scopeOf(p.A) == scopeOf(p.B) && p.A.Message == p.B.Message
+}
+
+// MessageChanges counts matched commits worded differently.
+func (d PlanDiff) MessageChanges() int {
+	n := 0
+	for _, p := range d.Matched {
+		if !p.SameMessage() {
+			n++
+		}
+	}
+	return n
+}
+
+// Identical reports whether the plans group files the same way with the same messages.
+func (d PlanDiff) Identical() bool {
+	return len(d.OnlyA) == 0 && len(d.OnlyB) == 0 && d.MessageChanges() == 0
+}
+
+// DiffPlans compares plans a and b. Commit order is ignored.
+func DiffPlans(a, b *types.CommitPlan) PlanDiff {
+	var diff PlanDiff
+
+	used := make([]bool, len(b.Commits))
+	for _, ca := range a.Commits {
+		key := fileSetKey(ca.Files)
+		matched := false
+		for j, cb := range b.Commits {
+			if !used[j] && fileSetKey(cb.Files) == key {
+				used[j] = true
+				matched = true
+				diff.Matched = append(diff.Matched, CommitPair{A: ca, B: cb})
+				break
+			}
+		}
+		if !matched {
+			diff.OnlyA = append(diff.OnlyA, ca)
+		}
+	}
+
+	for j, cb := range b.Commits {
+		if !used[j] {
+			diff.OnlyB = append(diff.OnlyB, cb)
+		}
+	}
+
+	return diff
+}
+
+// fileSetKey returns a key identifying a set of files regardless of order.
+func fileSetKey(files []string) string {
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+	return strings.Join(sorted, "\x00")
+}
+
+// scopeOf returns a commit's scope, or "" when it has none.
+func scopeOf(c types.PlannedCommit) string {
+	if c.Scope == nil {
+		return ""
+	}
+	return *c.Scope
+}
@@ -0,0 +1,51 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func TestDiffPlans(t *testing.T) {
+	api := "api"
+	a := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Scope: &api, Message: "add handler", Files: []string{"api/a.go", "api/b.go"}},
+		{Type: "docs", Message: "update readme", Files: []string{"README.md"}},
+		{Type: "chore", Message: "bump deps", Files: []string{"go.mod", "go.sum"}},
+	}}
+	b := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "docs", Message: "document handler", Files: []string{"README.md"}},
+		{Type: "feat", Scope: &api, Message: "add handler", Files: []string{"api/b.go", "api/a.go"}},
+		{Type: "chore", Message: "bump go.mod", Files: []string{"go.mod"}},
+		{Type: "chore", Message: "bump go.sum", Files: []string{"go.sum"}},
+	}}
+
+	diff := DiffPlans(a, b)
+
+	if len(diff.Matched) != 2 {
+		t.Fatalf("Matched = %d, want 2 (file order ignored)", len(diff.Matched))
+	}
+	if diff.MessageChanges() != 1 {
+		t.Errorf("MessageChanges() = %d, want 1", diff.MessageChanges())
+	}
+	if len(diff.OnlyA) != 1 || len(diff.OnlyB) != 2 {
+		t.Errorf("OnlyA = %d, OnlyB = %d; want 1 and 2", len(diff.OnlyA), len(diff.OnlyB))
+	}
+	if diff.Identical() {
+		t.Error("Identical() = true for different plans")
+	}
+}
+
+func TestDiffPlans_Identical(t *testing.T) {
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "fix", Message: "handle nil", Files: []string{"a.go"}},
+	}}
+	other := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "fix", Scope: new(string), Message: "handle nil", Files: []string{"a.go"}},
+	}}
+
+	// An empty scope is the same as none
+	if diff := DiffPlans(plan, other); !diff.Identical() {
+		t.Errorf("DiffPlans() = %+v, want identical", diff)
+	}
+}
@@ -0,0 +1,177 @@
+package planner
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// MassDeleteMinDirFiles is the smallest directory the percentage threshold
+// applies to, so deleting one of two files doesn't count as a mass deletion.
+const MassDeleteMinDirFiles = 5
+
+// MassDeletion describes a plan whose deletions exceed the configured thresholds.
+type MassDeletion struct {
+	// Files is every file the plan deletes
+	Files []string
+	// FileLimitExceeded is set when len(Files) is over the file limit
+	FileLimitExceeded bool
+	// Directories lists the directories losing more than the percentage limit
+	Directories []DirectoryDeletion
+}
+
+// DirectoryDeletion is a directory losing a large share of its files.
+type DirectoryDeletion struct {
+	Dir     string
+	Deleted int
+	Total   int
+}
+
+// Percent returns the deleted share of the directory's files.
+func (d DirectoryDeletion) Percent() int {
+	return d.Deleted * 100 / d.Total
+}
+
+func (d DirectoryDeletion) String() string {
+	return fmt.Sprintf("%s/: %d of %d files (%d%%)", d.Dir, d.Deleted, d.Total, d.Percent())
+}
+
+// DetectMassDeletion checks the files plan deletes against cfg. deleted lists
+// the working tree's deleted files and headFiles every file in HEAD, used to
+// size each directory. It returns nil when the deletions are within limits.
+func DetectMassDeletion(plan *types.CommitPlan, deleted, headFiles []string, cfg types.MassDeleteConfig) *MassDeletion {
+	deletedSet := make(map[string]bool, len(deleted))
+	for _, f := range deleted {
+		deletedSet[f] = true
+	}
+
+	var planned []string
+	for _, c := range plan.Commits {
+		for _, f := range c.Files {
+			switch {
+			case !deletedSet[f]:
+			case strings.HasSuffix(f, "/"):
+				planned = append(planned, filesUnder(f, headFiles)...)
+			default:
+				planned = append(planned, f)
+			}
+		}
+	}
+	if len(planned) == 0 {
+		return nil
+	}
+
+	totals := countByDirectory(headFiles)
+	deletedCounts := countByDirectory(planned)
+
+	var dirs []string
+	for dir, n := range deletedCounts {
+		total := totals[dir]
+		if total >= MassDeleteMinDirFiles && n*100 > total*cfg.PercentLimit() {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+
+	// Report only the outermost directory of a nested wipe
+	var directories []DirectoryDeletion
+	for _, dir := range dirs {
+		if !withinAny(dir, directories) {
+			directories = append(directories, DirectoryDeletion{Dir: dir, Deleted: deletedCounts[dir], Total: totals[dir]})
+		}
+	}
+
+	result := &MassDeletion{
+		Files:             planned,
+		FileLimitExceeded: len(planned) > cfg.FileLimit(),
+		Directories:       directories,
+	}
+	if !result.FileLimitExceeded && len(result.Directories) == 0 {
+		return nil
+	}
+	return result
+}
+
+// filesUnder returns the files under dir ("vendor/"), the entry status lists
+// for a removed directory in place of its deleted files.
+func filesUnder(dir string, files []string) []string {
+	var under []string
+	for _, f := range files {
+		if strings.HasPrefix(f, dir) {
+			under = append(under, f)
+		}
+	}
+	return under
+}
+
+// withinAny reports whether dir is nested in one of the reported directories.
+func withinAny(dir string, reported []DirectoryDeletion) bool {
+	for _, d := range reported {
+		if strings.HasPrefix(dir, d.Dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// countByDirectory counts files under each directory, including nested ones.
+// Files at the repository root aren't counted.
+func countByDirectory(files []string) map[string]int {
+	counts := make(map[string]int)
+	for _, f := range files {
+		for dir := path.Dir(f); dir != "." && dir != "/"; dir = path.Dir(dir) {
+			counts[dir]++
+		}
+	}
+	return counts
+}
+
+// Summary describes the mass deletion in one line.
+func (m *MassDeletion) Summary() string {
+	if len(m.Directories) == 0 {
+		return fmt.Sprintf("plan deletes %d files", len(m.Files))
+	}
+	dirs := make([]string, len(m.Directories))
+	for i, d := range m.Directories {
+		dirs[i] = d.String()
+	}
+	return fmt.Sprintf("plan deletes %d files, including %s", len(m.Files), strings.Join(dirs, ", "))
+}
+
+// AnnotateCommits calls out the deletion in the body of each commit that
+// deletes files, so the history shows it was intentional.
+func (m *MassDeletion) AnnotateCommits(plan *types.CommitPlan) {
+	deleted := make(map[string]bool, len(m.Files))
+	for _, f := range m.Files {
+		deleted[f] = true
+	}
+
+	for i := range plan.Commits {
+		c := &plan.Commits[i]
+
+		var files []string
+		for _, f := range c.Files {
+			if strings.HasSuffix(f, "/") {
+				files = append(files, filesUnder(f, m.Files)...)
+			} else if deleted[f] {
+				files = append(files, f)
+			}
+		}
+		if len(files) == 0 {
+			continue
+		}
+
+		lines := []string{fmt.Sprintf("Deleted files: %d", len(files))}
+		counts := countByDirectory(files)
+		for _, d := range m.Directories {
+			if n := counts[d.Dir]; n > 0 {
+				lines = append(lines, fmt.Sprintf("- %s/: %d of %d files", d.Dir, n, d.Total))
+			}
+		}
+
+		appendBody(c, strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,118 @@
+package planner
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// TreeState records what a cached plan was made from, so a later run over a
+// slightly different tree can reuse it for the files that didn't change.
+type TreeState struct {
+	Inputs string            `json:"inputs"` // CacheKey of every input except the tree
+	Head   string            `json:"head"`
+	Files  map[string]string `json:"files"` // analyzed path → content hash
+}
+
+// PlanDelta is a cached plan that still applies to all but a few files.
+type PlanDelta struct {
+	Plan    *types.CommitPlan // the cached plan, without files that changed or went away
+	Changed []string          // files that are new or different since the plan was made
+}
+
+// LoadDelta returns the cached plan and the files to re-analyze when the plan
+// was made with the same inputs and HEAD as state and fewer than half of the
+// files in state have changed since.
+func (c *PlanCache) LoadDelta(state *TreeState) (*PlanDelta, bool) {
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return nil, false
+	}
+
+	var cached cachedPlan
+	if err := json.Unmarshal(data, &cached); err != nil || cached.Plan == nil || cached.State == nil {
+		return nil, false
+	}
+	if cached.State.Inputs != state.Inputs || cached.State.Head != state.Head {
+		return nil, false
+	}
+
+	var changed []string
+	for path, hash := range state.Files {
+		if old, ok := cached.State.Files[path]; !ok || old != hash {
+			changed = append(changed, path)
+		}
+	}
+	if len(changed)*2 >= len(state.Files) {
+		return nil, false
+	}
+
+	// Keep only unchanged files; changed ones are re-planned and files no
+	// longer in the tree's changes are dropped
+	plan := &types.CommitPlan{}
+	for _, commit := range cached.Plan.Commits {
+		var files []string
+		for _, f := range commit.Files {
+			entry, ok := stateEntry(state.Files, f)
+			if ok && cached.State.Files[entry] == state.Files[entry] {
+				files = append(files, f)
+			}
+		}
+		if len(files) > 0 {
+			commit.Files = files
+			plan.Commits = append(plan.Commits, commit)
+		}
+	}
+	if len(plan.Commits) == 0 {
+		return nil, false
+	}
+
+	sort.Strings(changed)
+	return &PlanDelta{Plan: plan, Changed: changed}, true
+}
+
+// stateEntry returns the key of files that covers file: file itself, or an
+// untracked directory containing it.
+func stateEntry(files map[string]string, file string) (string, bool) {
+	if _, ok := files[file]; ok {
+		return file, true
+	}
+	for dir := path.Dir(file); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if _, ok := files[dir+"/"]; ok {
+			return dir + "/", true
+		}
+	}
+	return "", false
+}
+
+// SaveState replaces the cached plan with plan, stored under key along with
+// the state it was made from.
+func (c *PlanCache) SaveState(key string, plan *types.CommitPlan, state *TreeState) error {
+	return c.save(cachedPlan{Key: key, Plan: plan, State: state})
+}
+
+// MergeDelta adds the commits planned for a delta's changed files to its
+// cached plan. A commit with the same header as an existing one is folded
+// into it; others are appended.
+func MergeDelta(delta *PlanDelta, changes *types.CommitPlan) *types.CommitPlan {
+	merged := &types.CommitPlan{Commits: append([]types.PlannedCommit(nil), delta.Plan.Commits...)}
+	for _, commit := range changes.Commits {
+		folded := false
+		for i := range merged.Commits {
+			if strings.EqualFold(formatHeader(merged.Commits[i]), formatHeader(commit)) {
+				merged.Commits[i].Files = slices.Concat(merged.Commits[i].Files, commit.Files)
+				folded = true
+				break
+			}
+		}
+		if !folded {
+			merged.Commits = append(merged.Commits, commit)
+		}
+	}
+	return merged
+}
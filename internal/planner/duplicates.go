@@ -0,0 +1,161 @@
+package planner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// nearDuplicateSimilarity is the share of content words two subjects must
+// have in common for their commits to count as near-duplicates.
+const nearDuplicateSimilarity = 0.6
+
+// fillerWords carry no meaning of their own in a commit subject, like the
+// verbs that only restate the commit type.
+var fillerWords = map[string]bool{
+	"a": true, "an": true, "the": true, "to": true, "of": true, "for": true, "in": true, "on": true,
+	"and": true, "with": true, "when": true, "into": true, "from": true, "by": true,
+	"fix": true, "fixes": true, "fixed": true, "bug": true, "bugs": true, "issue": true,
+	"correct": true, "corrects": true, "resolve": true, "resolves": true,
+	"update": true, "updates": true, "improve": true, "improves": true, "change": true, "changes": true,
+	"add": true, "adds": true,
+}
+
+// NearDuplicate is a pair of planned commits that look like the same change
+// split in two: same type and scope, files in the same directories and
+// subjects that say the same thing in different words.
+type NearDuplicate struct {
+	First  int
+	Second int
+}
+
+// NearDuplicates returns the near-duplicate pairs in commits, in plan order.
+// Each commit is paired at most once, with the first earlier commit it
+// duplicates.
+func NearDuplicates(commits []types.PlannedCommit) []NearDuplicate {
+	var pairs []NearDuplicate
+	paired := make(map[int]bool)
+	for j := range commits {
+		for i := 0; i < j; i++ {
+			if paired[i] || paired[j] {
+				continue
+			}
+			if isNearDuplicate(commits[i], commits[j]) {
+				pairs = append(pairs, NearDuplicate{First: i, Second: j})
+				paired[i], paired[j] = true, true
+			}
+		}
+	}
+	return pairs
+}
+
+// isNearDuplicate reports whether a and b look like the same change.
+func isNearDuplicate(a, b types.PlannedCommit) bool {
+	if a.Type != b.Type || scopeOf(a) != scopeOf(b) || !sharesDirectory(a.Files, b.Files) {
+		return false
+	}
+	return subjectSimilarity(a.Message, b.Message) >= nearDuplicateSimilarity
+}
+
+// sharesDirectory reports whether any file in a is in the same directory as
+// a file in b.
+func sharesDirectory(a, b []string) bool {
+	dirs := make(map[string]bool)
+	for _, f := range a {
+		dirs[path.Dir(f)] = true
+	}
+	for _, f := range b {
+		if dirs[path.Dir(f)] {
+			return true
+		}
+	}
+	return false
+}
+
+// subjectSimilarity is the Jaccard similarity of two subjects' content
+// words. Subjects with no content words aren't similar to anything.
+func subjectSimilarity(a, b string) float64 {
+	wordsA, wordsB := contentWords(a), contentWords(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+	shared := 0
+	for w := range wordsA {
+		if wordsB[w] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+// contentWords returns the lowercased words of a subject other than filler
+// words, with a plural "s" removed.
+func contentWords(subject string) map[string]bool {
+	words := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(subject), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if fillerWords[w] {
+			continue
+		}
+		if len(w) > 3 && strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") {
+			w = w[:len(w)-1]
+		}
+		words[w] = true
+	}
+	return words
+}
+
+// MergeNearDuplicates merges each pair's second commit into its first and
+// returns the resulting commits along with a description of each merge.
+// The merged commit keeps the more specific of the two subjects (the one
+// with more content words, or the first on a tie) and both commits' files.
+func MergeNearDuplicates(commits []types.PlannedCommit, pairs []NearDuplicate) ([]types.PlannedCommit, []string) {
+	if len(pairs) == 0 {
+		return commits, nil
+	}
+
+	mergedInto := make(map[int]bool)
+	for _, p := range pairs {
+		mergedInto[p.Second] = true
+	}
+
+	result := make([]types.PlannedCommit, len(commits))
+	copy(result, commits)
+	var changes []string
+	for _, p := range pairs {
+		first, second := result[p.First], commits[p.Second]
+		merged := first
+		if len(contentWords(second.Message)) > len(contentWords(first.Message)) {
+			merged.Message = second.Message
+		}
+		if merged.Body == "" {
+			merged.Body = second.Body
+		}
+		if second.Reasoning != "" && second.Reasoning != merged.Reasoning {
+			merged.Reasoning = strings.TrimSpace(merged.Reasoning + " " + second.Reasoning)
+		}
+
+		seen := make(map[string]bool)
+		merged.Files = nil
+		for _, f := range append(append([]string{}, first.Files...), second.Files...) {
+			if !seen[f] {
+				seen[f] = true
+				merged.Files = append(merged.Files, f)
+			}
+		}
+
+		result[p.First] = merged
+		changes = append(changes, fmt.Sprintf("%q + %q → %q", first.Message, second.Message, merged.Message))
+	}
+
+	var kept []types.PlannedCommit
+	for i, c := range result {
+		if !mergedInto[i] {
+			kept = append(kept, c)
+		}
+	}
+	return kept, changes
+}
@@ -0,0 +1,81 @@
+package planner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func TestNearDuplicates(t *testing.T) {
+	api, web := "api", "web"
+	commits := []types.PlannedCommit{
+		{Type: "fix", Scope: &api, Message: "correct retry logic", Files: []string{"api/retry.go"}},
+		{Type: "feat", Scope: &api, Message: "add retry logic", Files: []string{"api/client.go"}},
+		{Type: "fix", Scope: &api, Message: "fix retry logic bug", Files: []string{"api/backoff.go"}},
+		{Type: "fix", Scope: &web, Message: "fix retry logic", Files: []string{"web/retry.ts"}},
+		{Type: "fix", Scope: &api, Message: "handle nil config", Files: []string{"api/config.go"}},
+		{Type: "fix", Scope: &api, Message: "correct retry logic", Files: []string{"cmd/retry.go"}},
+	}
+
+	pairs := NearDuplicates(commits)
+	if len(pairs) != 1 || pairs[0] != (NearDuplicate{First: 0, Second: 2}) {
+		t.Fatalf("NearDuplicates() = %v, want [{0 2}]", pairs)
+	}
+}
+
+func TestMergeNearDuplicates(t *testing.T) {
+	commits := []types.PlannedCommit{
+		{Type: "fix", Message: "fix retry", Files: []string{"api/retry.go"}, Reasoning: "Retry fix."},
+		{Type: "docs", Message: "document retries", Files: []string{"README.md"}},
+		{Type: "fix", Message: "fix retry backoff overflow", Files: []string{"api/backoff.go", "api/retry.go"}, Body: "Caps the delay."},
+	}
+
+	merged, changes := MergeNearDuplicates(commits, []NearDuplicate{{First: 0, Second: 2}})
+	if len(merged) != 2 || len(changes) != 1 {
+		t.Fatalf("got %d commits and %d changes, want 2 and 1", len(merged), len(changes))
+	}
+	got := merged[0]
+	if got.Message != "fix retry backoff overflow" {
+		t.Errorf("Message = %q, want the more specific subject", got.Message)
+	}
+	if len(got.Files) != 2 || got.Files[0] != "api/retry.go" || got.Files[1] != "api/backoff.go" {
+		t.Errorf("Files = %v, want [api/retry.go api/backoff.go]", got.Files)
+	}
+	if got.Body != "Caps the delay." {
+		t.Errorf("Body = %q, want the second commit's body", got.Body)
+	}
+	if merged[1].Message != "document retries" {
+		t.Errorf("second commit = %q, want the docs commit", merged[1].Message)
+	}
+}
+
+func TestValidateAndFix_MergesNearDuplicates(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, f := range []string{"retry.go", "backoff.go"} {
+		_ = os.WriteFile(filepath.Join(tmpDir, f), []byte("content"), 0644)
+	}
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "fix", Message: "correct retry logic", Files: []string{"retry.go"}},
+		{Type: "fix", Message: "fix retry logic bug", Files: []string{"backoff.go"}},
+	}}
+
+	validator := NewValidator(tmpDir, &types.RepoConfig{}, []string{"retry.go", "backoff.go"})
+	fixed, _ := validator.ValidateAndFix(plan)
+	if len(fixed.Commits) != 2 || len(validator.MergedDuplicates()) != 0 {
+		t.Fatalf("merged without mergeNearDuplicates: %d commits", len(fixed.Commits))
+	}
+
+	validator = NewValidator(tmpDir, &types.RepoConfig{MergeNearDuplicates: true}, []string{"retry.go", "backoff.go"})
+	fixed, result := validator.ValidateAndFix(plan)
+	if len(fixed.Commits) != 1 || len(fixed.Commits[0].Files) != 2 {
+		t.Fatalf("got %d commits, want one with both files: %+v", len(fixed.Commits), fixed.Commits)
+	}
+	if len(validator.MergedDuplicates()) != 1 {
+		t.Errorf("MergedDuplicates() = %v, want one merge", validator.MergedDuplicates())
+	}
+	if !result.Valid {
+		t.Errorf("expected valid result, got %v", result.Errors)
+	}
+}
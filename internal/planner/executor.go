@@ -1,10 +1,13 @@
 package planner
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/internal/convention"
 	"github.com/dsswift/commit/internal/git"
 	"github.com/dsswift/commit/pkg/types"
 )
@@ -15,6 +18,47 @@ type Executor struct {
 	committer *git.Committer
 	stager    *git.Stager
 	dryRun    bool
+	pathspecs []string
+
+	conv         convention.Convention
+	verification types.VerificationConfig
+	hookAutofix  string
+	onError      ErrorHandler
+	skipped      []SkippedCommit
+	failed       []FailedCommit
+}
+
+// Policies for a commit that fails while a plan is executed.
+const (
+	OnErrorAbort = "abort" // stop at the failed commit (the default)
+	OnErrorSkip  = "skip"  // leave its files uncommitted and go on
+	OnErrorAsk   = "ask"   // ask whether to skip it or abort
+)
+
+// OnErrorPolicies lists the valid --on-error policies.
+var OnErrorPolicies = []string{OnErrorAbort, OnErrorSkip, OnErrorAsk}
+
+// ErrorHandler decides whether execution goes on past a failed commit:
+// true skips it and continues with the rest of the plan, false aborts.
+type ErrorHandler func(err *ExecutionError) bool
+
+// SkippedCommit is a planned commit the executor skipped because it was
+// empty by the time it was reached.
+type SkippedCommit struct {
+	CommitIndex int
+	Planned     types.PlannedCommit
+	Reason      string
+}
+
+// FailedCommit is a planned commit that failed and was skipped by the
+// ErrorHandler. Hash is set when the commit was created but failed
+// afterwards, e.g. a verification error; otherwise its files are left
+// uncommitted.
+type FailedCommit struct {
+	CommitIndex int
+	Planned     types.PlannedCommit
+	Hash        string
+	Err         error
 }
 
 // NewExecutor creates a new plan executor.
@@ -27,19 +71,75 @@ func NewExecutor(workDir string, dryRun bool) *Executor {
 	}
 }
 
+// SetConvention sets the convention commit subjects are written in. Without
+// one the active convention is used.
+func (e *Executor) SetConvention(conv convention.Convention) {
+	e.conv = conv
+	e.committer.SetConvention(conv)
+}
+
+// SetVerification sets the severities used when a commit doesn't match its plan.
+func (e *Executor) SetVerification(cfg types.VerificationConfig) {
+	e.verification = cfg
+}
+
+// SetHookAutofix sets what happens to files a pre-commit hook rewrites while
+// committing: types.HookAutofixAmend (the default), HookAutofixWarn or
+// HookAutofixFail.
+func (e *Executor) SetHookAutofix(mode string) {
+	e.hookAutofix = mode
+}
+
+// SetPathspecs scopes execution to the given pathspecs, leaving the rest of
+// the index untouched.
+func (e *Executor) SetPathspecs(pathspecs []string) {
+	e.pathspecs = pathspecs
+	e.committer.SetPathspecs(pathspecs)
+	e.stager.SetPathspecs(pathspecs)
+}
+
+// Skipped returns the commits the last execution skipped because none of
+// their files had changes anymore.
+func (e *Executor) Skipped() []SkippedCommit {
+	return e.skipped
+}
+
+// SetErrorHandler sets what happens when a commit fails. Without one (the
+// default) execution aborts at the first failure.
+func (e *Executor) SetErrorHandler(handler ErrorHandler) {
+	e.onError = handler
+}
+
+// Failed returns the commits the last execution skipped after they failed.
+func (e *Executor) Failed() []FailedCommit {
+	return e.failed
+}
+
 // ExecutionProgress is called for each commit being executed.
 type ExecutionProgress func(current, total int, commit types.PlannedCommit)
 
 // Execute runs the commit plan and returns the executed commits.
 func (e *Executor) Execute(plan *types.CommitPlan, progress ExecutionProgress) ([]types.ExecutedCommit, error) {
+	return e.ExecuteContext(context.Background(), plan, progress)
+}
+
+// ExecuteContext runs the commit plan like Execute, but stops at the next
+// commit boundary once ctx is done and returns an InterruptedError.
+func (e *Executor) ExecuteContext(ctx context.Context, plan *types.CommitPlan, progress ExecutionProgress) ([]types.ExecutedCommit, error) {
 	// PRECONDITIONS
 	assert.NotNil(plan, "plan cannot be nil")
 	assert.NotEmpty(plan.Commits, "plan must have commits")
 
 	var executed []types.ExecutedCommit
 	total := len(plan.Commits)
+	e.skipped = nil
+	e.failed = nil
 
 	for i, planned := range plan.Commits {
+		if ctx.Err() != nil {
+			return executed, &InterruptedError{Completed: len(executed), Remaining: plan.Commits[i:]}
+		}
+
 		// Report progress
 		if progress != nil {
 			progress(i+1, total, planned)
@@ -47,25 +147,29 @@ func (e *Executor) Execute(plan *types.CommitPlan, progress ExecutionProgress) (
 
 		if e.dryRun {
 			// In dry-run mode, just create a fake executed commit
-			var fullMessage string
-			if planned.Scope != nil && *planned.Scope != "" {
-				fullMessage = fmt.Sprintf("%s(%s): %s", planned.Type, *planned.Scope, planned.Message)
-			} else {
-				fullMessage = fmt.Sprintf("%s: %s", planned.Type, planned.Message)
-			}
+			fullMessage := convention.FormatWith(e.conv, planned)
 
 			executed = append(executed, types.ExecutedCommit{
-				Hash:    "(dry-run)",
-				Type:    planned.Type,
-				Scope:   planned.Scope,
-				Message: fullMessage,
-				Files:   planned.Files,
+				Hash:      "(dry-run)",
+				Type:      planned.Type,
+				Scope:     planned.Scope,
+				Message:   fullMessage,
+				Files:     planned.Files,
+				Reasoning: planned.Reasoning,
 			})
 			continue
 		}
 
 		// Execute the actual commit
-		result, err := e.committer.ExecutePlannedCommit(planned)
+		result, err := e.commitPlanned(planned)
+		if err != nil && result != nil {
+			// The commit exists, but a hook's rewrite isn't in it
+			executed = append(executed, *result)
+			if e.skipFailed(i, planned, result.Hash, err) {
+				continue
+			}
+			return executed, &ExecutionError{CommitIndex: i, Planned: planned, Err: err, conv: e.conv}
+		}
 		if err != nil {
 			// Skip commits where all files were directories (nothing to stage)
 			var noStagedErr *git.NoStagedFilesError
@@ -73,44 +177,210 @@ func (e *Executor) Execute(plan *types.CommitPlan, progress ExecutionProgress) (
 				// Skip this commit silently - all paths were directories
 				continue
 			}
+			// Files reverted or committed since planning leave nothing to
+			// commit; skip it rather than abort the rest of the plan
+			var emptyErr *git.EmptyCommitError
+			if errors.As(err, &emptyErr) {
+				e.skipped = append(e.skipped, SkippedCommit{CommitIndex: i, Planned: planned, Reason: emptyErr.Error()})
+				continue
+			}
+			// The interrupt also reaches git, so a failure now is the interruption
+			if ctx.Err() != nil {
+				return executed, &InterruptedError{Completed: len(executed), Remaining: plan.Commits[i:]}
+			}
+			if e.skipFailed(i, planned, "", err) {
+				// Leave nothing of it staged for the next commit
+				_ = e.stager.UnstageAll()
+				continue
+			}
 			return executed, &ExecutionError{
 				CommitIndex: i,
 				Planned:     planned,
 				Err:         err,
+				conv:        e.conv,
 			}
 		}
 
+		verifyErr := e.verify(result, planned)
 		executed = append(executed, *result)
+		if verifyErr != nil {
+			if e.skipFailed(i, planned, result.Hash, verifyErr) {
+				continue
+			}
+			return executed, &ExecutionError{
+				CommitIndex: i,
+				Planned:     planned,
+				Err:         verifyErr,
+				conv:        e.conv,
+			}
+		}
 	}
 
-	// POSTCONDITIONS - we may have fewer commits if some were skipped (directories only)
+	// POSTCONDITIONS - we may have fewer commits if some were skipped (directories only, or empty)
 	if !e.dryRun && len(executed) == 0 {
+		if len(e.failed) > 0 {
+			return executed, fmt.Errorf("no commits were executed (%d planned commits failed)", len(e.failed))
+		}
+		if len(e.skipped) > 0 {
+			return executed, fmt.Errorf("no commits were executed (%d planned commits no longer had changes)", len(e.skipped))
+		}
 		return executed, fmt.Errorf("no commits were executed (all planned commits contained only directories)")
 	}
 
 	return executed, nil
 }
 
+// skipFailed asks the ErrorHandler whether to go on past the failed commit
+// at index, and records it as failed if so. hash is the commit's, if created.
+func (e *Executor) skipFailed(index int, planned types.PlannedCommit, hash string, err error) bool {
+	if e.onError == nil || !e.onError(&ExecutionError{CommitIndex: index, Planned: planned, Err: err, conv: e.conv}) {
+		return false
+	}
+	e.failed = append(e.failed, FailedCommit{CommitIndex: index, Planned: planned, Hash: hash, Err: err})
+	return true
+}
+
+// Leftover re-reads the status after plan was executed and returns the
+// planned files that are still changed, e.g. rewritten by a pre-commit hook
+// or edited while the run was committing.
+func (e *Executor) Leftover(plan *types.CommitPlan) ([]string, error) {
+	collector := git.NewCollector(e.workDir)
+	collector.SetPathspecs(e.pathspecs)
+	status, err := collector.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var planned []string
+	for _, c := range plan.Commits {
+		planned = append(planned, c.Files...)
+	}
+
+	var leftover []string
+	for _, f := range status.AllFiles() {
+		if git.MatchesFocus(f, planned) {
+			leftover = append(leftover, f)
+		}
+	}
+	return leftover, nil
+}
+
+// UnstagePartial unstages whatever an interrupted commit left in the index.
+func (e *Executor) UnstagePartial() error {
+	if e.dryRun {
+		return nil
+	}
+	return e.stager.UnstageAll()
+}
+
 // ExecuteSingle executes a single commit from the plan.
 func (e *Executor) ExecuteSingle(planned types.PlannedCommit) (*types.ExecutedCommit, error) {
 	if e.dryRun {
-		var fullMessage string
-		if planned.Scope != nil && *planned.Scope != "" {
-			fullMessage = fmt.Sprintf("%s(%s): %s", planned.Type, *planned.Scope, planned.Message)
-		} else {
-			fullMessage = fmt.Sprintf("%s: %s", planned.Type, planned.Message)
-		}
+		fullMessage := convention.FormatWith(e.conv, planned)
 
 		return &types.ExecutedCommit{
-			Hash:    "(dry-run)",
-			Type:    planned.Type,
-			Scope:   planned.Scope,
-			Message: fullMessage,
-			Files:   planned.Files,
+			Hash:      "(dry-run)",
+			Type:      planned.Type,
+			Scope:     planned.Scope,
+			Message:   fullMessage,
+			Files:     planned.Files,
+			Reasoning: planned.Reasoning,
 		}, nil
 	}
 
-	return e.committer.ExecutePlannedCommit(planned)
+	result, err := e.commitPlanned(planned)
+	if err != nil {
+		return result, err
+	}
+
+	if err := e.verify(result, planned); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// commitPlanned creates planned's commit, then handles files a pre-commit
+// hook rewrote according to the hook autofix mode. In amend mode the rewrite
+// is staged into the commit; a hook that rewrote files and failed the commit
+// gets one retry with them staged, since formatters usually pass on their own
+// output. In fail mode a rewrite returns the commit with a HookRewriteError.
+func (e *Executor) commitPlanned(planned types.PlannedCommit) (*types.ExecutedCommit, error) {
+	amend := e.hookAutofix == "" || e.hookAutofix == types.HookAutofixAmend
+
+	result, err := e.committer.ExecutePlannedCommit(planned)
+	var commitErr *git.CommitError
+	if errors.As(err, &commitErr) && amend {
+		result, err = e.retryRewrittenCommit(planned, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rewritten, err := e.committer.RewrittenFiles(planned.Files)
+	if err != nil {
+		return result, fmt.Errorf("failed to check for files rewritten by hooks: %w", err)
+	}
+	if len(rewritten) == 0 {
+		return result, nil
+	}
+	result.HookRewritten = append(result.HookRewritten, rewritten...)
+
+	switch {
+	case amend:
+		if err := e.stager.StageFiles(rewritten); err != nil {
+			return result, fmt.Errorf("failed to stage files rewritten by hooks: %w", err)
+		}
+		hash, err := e.committer.Amend()
+		if err != nil {
+			return result, fmt.Errorf("failed to amend files rewritten by hooks into %s: %w", result.Hash, err)
+		}
+		result.Hash = hash
+	case e.hookAutofix == types.HookAutofixFail:
+		return result, &HookRewriteError{Hash: result.Hash, Files: rewritten}
+	}
+	return result, nil
+}
+
+// retryRewrittenCommit commits planned again after its commit failed with
+// commitErr, if a hook rewrote any of its files; the rewrite is staged first.
+func (e *Executor) retryRewrittenCommit(planned types.PlannedCommit, commitErr error) (*types.ExecutedCommit, error) {
+	rewritten, err := e.committer.RewrittenFiles(planned.Files)
+	if err != nil || len(rewritten) == 0 {
+		return nil, commitErr
+	}
+	if err := e.stager.StageFiles(rewritten); err != nil {
+		return nil, fmt.Errorf("failed to stage files rewritten by hooks: %w", err)
+	}
+
+	fullMessage := convention.FormatWith(e.conv, planned)
+	hash, err := e.committer.CommitWithBody(fullMessage, planned.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create commit: %w", err)
+	}
+	return &types.ExecutedCommit{
+		Hash:          hash,
+		Type:          planned.Type,
+		Scope:         planned.Scope,
+		Message:       fullMessage,
+		Files:         planned.Files,
+		Reasoning:     planned.Reasoning,
+		HookRewritten: rewritten,
+	}, nil
+}
+
+// verify attaches a verification report to the executed commit and returns
+// an error if the mismatch is configured as fatal. The commit already exists.
+func (e *Executor) verify(result *types.ExecutedCommit, planned types.PlannedCommit) error {
+	verification, err := e.committer.VerifyCommitFiles(planned.Files)
+	if err != nil {
+		return fmt.Errorf("failed to verify commit %s: %w", result.Hash, err)
+	}
+	result.Verification = verification
+
+	if e.verification.Severity(verification.Status) == types.SeverityError {
+		return &VerificationError{Hash: result.Hash, Verification: verification}
+	}
+	return nil
 }
 
 // PreviewPlan returns a human-readable preview of the plan.
@@ -122,12 +392,7 @@ func PreviewPlan(plan *types.CommitPlan) string {
 	result := fmt.Sprintf("📋 %d commits planned:\n", len(plan.Commits))
 
 	for i, commit := range plan.Commits {
-		var msg string
-		if commit.Scope != nil && *commit.Scope != "" {
-			msg = fmt.Sprintf("%s(%s): %s", commit.Type, *commit.Scope, commit.Message)
-		} else {
-			msg = fmt.Sprintf("%s: %s", commit.Type, commit.Message)
-		}
+		msg := convention.Format(commit)
 
 		result += fmt.Sprintf("\n  [%d/%d] %s\n", i+1, len(plan.Commits), msg)
 
@@ -148,15 +413,12 @@ type ExecutionError struct {
 	CommitIndex int
 	Planned     types.PlannedCommit
 	Err         error
+
+	conv convention.Convention // formats Planned; nil is the active convention
 }
 
 func (e *ExecutionError) Error() string {
-	var msg string
-	if e.Planned.Scope != nil && *e.Planned.Scope != "" {
-		msg = fmt.Sprintf("%s(%s): %s", e.Planned.Type, *e.Planned.Scope, e.Planned.Message)
-	} else {
-		msg = fmt.Sprintf("%s: %s", e.Planned.Type, e.Planned.Message)
-	}
+	msg := convention.FormatWith(e.conv, e.Planned)
 
 	return fmt.Sprintf("failed to execute commit %d (%s): %v", e.CommitIndex+1, msg, e.Err)
 }
@@ -164,3 +426,41 @@ func (e *ExecutionError) Error() string {
 func (e *ExecutionError) Unwrap() error {
 	return e.Err
 }
+
+// InterruptedError indicates execution stopped early because it was interrupted.
+type InterruptedError struct {
+	Completed int
+	Remaining []types.PlannedCommit
+}
+
+func (e *InterruptedError) Error() string {
+	return fmt.Sprintf("interrupted after %d commits, %d not created", e.Completed, len(e.Remaining))
+}
+
+// HookRewriteError indicates a pre-commit hook rewrote a commit's files and
+// hookAutofix is "fail". The commit exists without the rewrite.
+type HookRewriteError struct {
+	Hash  string
+	Files []string
+}
+
+func (e *HookRewriteError) Error() string {
+	return fmt.Sprintf("a pre-commit hook rewrote files after commit %s was staged: %s", e.Hash, strings.Join(e.Files, ", "))
+}
+
+// VerificationError indicates a created commit didn't match its plan.
+type VerificationError struct {
+	Hash         string
+	Verification *types.CommitVerification
+}
+
+func (e *VerificationError) Error() string {
+	switch e.Verification.Status {
+	case types.VerificationMissing:
+		return fmt.Sprintf("commit %s is missing planned files: %s", e.Hash, strings.Join(e.Verification.MissingFiles, ", "))
+	case types.VerificationExtra:
+		return fmt.Sprintf("commit %s includes unplanned files: %s", e.Hash, strings.Join(e.Verification.ExtraFiles, ", "))
+	default:
+		return fmt.Sprintf("commit %s failed verification (%s)", e.Hash, e.Verification.Status)
+	}
+}
@@ -1,10 +1,20 @@
 package planner
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
 	"strings"
 	"testing"
 
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/internal/git"
 	"github.com/dsswift/commit/internal/testutil"
 	"github.com/dsswift/commit/pkg/types"
 )
@@ -71,6 +81,77 @@ func TestExecutor_Execute_SingleCommit(t *testing.T) {
 	}
 }
 
+// adversarialFragments are pieces of commit messages that have broken
+// quoting, encoding or git's message handling somewhere.
+var adversarialFragments = []string{
+	"`rm -rf /`", "$(whoami)", "${HOME}", `"double"`, "'single'", `back\slash`,
+	"-n", "--amend", "# not a comment", "%s %n", "emoji 🎉", "👍🏽", "👨‍👩‍👧",
+	"naïve", "e\u0301", "日本語", "\t", "\n", "\r\n", "\x00", "\xff\xfe", "\u200d",
+}
+
+// adversarialMessage joins n random fragments, without separators when
+// glued, so fragments run into each other.
+func adversarialMessage(rng *rand.Rand, n int, glued bool) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		if i > 0 && !glued {
+			b.WriteString(" ")
+		}
+		b.WriteString(adversarialFragments[rng.Intn(len(adversarialFragments))])
+	}
+	return b.String()
+}
+
+// gitCleanup mirrors git's whitespace cleanup of commit messages: trailing
+// whitespace and surrounding and repeated blank lines go.
+func gitCleanup(message string) string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(regexp.MustCompile(`\n{3,}`).ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+func TestExecutor_Execute_AdversarialMessages(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	validator := NewValidator(repoDir, &types.RepoConfig{}, nil)
+	executor := NewExecutor(repoDir, false)
+	rng := rand.New(rand.NewSource(1))
+
+	for i := 0; i < 25; i++ {
+		file := fmt.Sprintf("file%d.txt", i)
+		testutil.CreateFile(t, repoDir, file, file)
+		planned := types.PlannedCommit{
+			Type:    "feat",
+			Message: adversarialMessage(rng, 2+rng.Intn(12), i%3 == 0),
+			Body:    adversarialMessage(rng, rng.Intn(40), i%4 == 0),
+			Files:   []string{file},
+		}
+		if i == 0 {
+			// Far past the limits on a single exec argument
+			planned.Body = strings.Repeat("long body line with \"quotes\" and `ticks`\n", 8000)
+		}
+
+		plan, result := validator.ValidateAndFix(&types.CommitPlan{Commits: []types.PlannedCommit{planned}})
+		if !result.Valid {
+			t.Fatalf("message %d %q: %v", i, planned.Message, result.Errors)
+		}
+		if _, err := executor.Execute(plan, nil); err != nil {
+			t.Fatalf("message %d %q: Execute failed: %v", i, planned.Message, err)
+		}
+
+		out, err := exec.Command("git", "-C", repoDir, "log", "-1", "--format=%B").Output()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixed := plan.Commits[0]
+		want := gitCleanup(convention.Format(fixed) + "\n\n" + fixed.Body)
+		if got := strings.TrimSpace(string(out)); got != want {
+			t.Errorf("message %d: committed %q, want %q", i, got, want)
+		}
+	}
+}
+
 func TestExecutor_Execute_MultipleCommits(t *testing.T) {
 	repoDir := testutil.TestRepo(t)
 
@@ -219,6 +300,55 @@ func TestExecutor_Execute_SkipsDirectoryOnlyCommits(t *testing.T) {
 	}
 }
 
+func TestExecutor_Execute_SkipsEmptyCommits(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	testutil.CreateFile(t, repoDir, "fmt.go", "package fmt")
+	testutil.GitAdd(t, repoDir, "fmt.go")
+	testutil.GitCommit(t, repoDir, "setup")
+
+	testutil.CreateFile(t, repoDir, "fmt.go", "package  fmt")
+	testutil.CreateFile(t, repoDir, "scratch.go", "package scratch")
+	testutil.CreateFile(t, repoDir, "real.go", "package real")
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "style", Message: "format fmt", Files: []string{"fmt.go"}},
+			{Type: "chore", Message: "add scratch", Files: []string{"scratch.go"}},
+			{Type: "feat", Message: "add real", Files: []string{"real.go"}},
+		},
+	}
+
+	// A formatter reverts one file and another is deleted before their turn
+	executor := NewExecutor(repoDir, false)
+	executed, err := executor.Execute(plan, func(current, total int, commit types.PlannedCommit) {
+		if current == 1 {
+			testutil.CreateFile(t, repoDir, "fmt.go", "package fmt")
+			_ = os.Remove(filepath.Join(repoDir, "scratch.go"))
+		}
+	})
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	if len(executed) != 1 || executed[0].Type != "feat" {
+		t.Fatalf("expected only the feat commit, got %+v", executed)
+	}
+	skipped := executor.Skipped()
+	if len(skipped) != 2 || skipped[0].CommitIndex != 0 || skipped[1].CommitIndex != 1 {
+		t.Fatalf("expected the first two commits skipped, got %+v", skipped)
+	}
+	if !strings.Contains(skipped[0].Reason, "nothing to commit") {
+		t.Errorf("unexpected reason %q", skipped[0].Reason)
+	}
+
+	// Every commit empty is still an error
+	executed, err = NewExecutor(repoDir, false).Execute(&types.CommitPlan{Commits: plan.Commits[:1]}, nil)
+	if err == nil || len(executed) != 0 {
+		t.Errorf("expected an error when nothing was committed, got %v, %+v", err, executed)
+	}
+}
+
 func TestExecutor_ExecuteSingle_Success(t *testing.T) {
 	repoDir := testutil.TestRepo(t)
 
@@ -333,3 +463,435 @@ func TestExecutor_Execute_NilScope(t *testing.T) {
 		t.Errorf("expected 'chore: initial commit', got %q", msg)
 	}
 }
+
+// installStagingHook adds a pre-commit hook that stages an unplanned file,
+// mimicking formatters that touch other files.
+func installStagingHook(t *testing.T, repoDir string) {
+	t.Helper()
+	hook := "#!/bin/sh\necho formatted > hook.txt\ngit add hook.txt\n"
+	if err := os.WriteFile(filepath.Join(repoDir, ".git", "hooks", "pre-commit"), []byte(hook), 0755); err != nil {
+		t.Fatalf("failed to write hook: %v", err)
+	}
+}
+
+func TestExecutor_Execute_VerificationReport(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	installStagingHook(t, repoDir)
+
+	testutil.CreateFile(t, repoDir, "main.go", "package main")
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add main", Files: []string{"main.go"}},
+		},
+	}
+
+	// Extra files default to a warning, so execution succeeds
+	executor := NewExecutor(repoDir, false)
+	executed, err := executor.Execute(plan, nil)
+	if err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	v := executed[0].Verification
+	if v == nil {
+		t.Fatal("expected verification report")
+	}
+	if v.Status != types.VerificationExtra {
+		t.Errorf("Status = %q, want %q", v.Status, types.VerificationExtra)
+	}
+	if len(v.ExtraFiles) != 1 || v.ExtraFiles[0] != "hook.txt" {
+		t.Errorf("ExtraFiles = %v, want [hook.txt]", v.ExtraFiles)
+	}
+}
+
+func TestExecutor_Execute_VerificationSeverityError(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	installStagingHook(t, repoDir)
+
+	testutil.CreateFile(t, repoDir, "a.go", "package a")
+	testutil.CreateFile(t, repoDir, "b.go", "package b")
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add a", Files: []string{"a.go"}},
+			{Type: "feat", Message: "add b", Files: []string{"b.go"}},
+		},
+	}
+
+	executor := NewExecutor(repoDir, false)
+	executor.SetVerification(types.VerificationConfig{ExtraFiles: types.SeverityError})
+
+	executed, err := executor.Execute(plan, nil)
+	if err == nil {
+		t.Fatal("expected verification error")
+	}
+
+	var verifyErr *VerificationError
+	if !errors.As(err, &verifyErr) {
+		t.Fatalf("expected *VerificationError, got %T: %v", err, err)
+	}
+
+	// The mismatched commit exists and is reported; later commits don't run
+	if len(executed) != 1 {
+		t.Fatalf("expected 1 executed commit, got %d", len(executed))
+	}
+	if executed[0].Verification.Verified() {
+		t.Error("expected commit to be unverified")
+	}
+}
+
+func TestExecutor_ExecuteContext_Interrupted(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "a.go", "package a")
+	testutil.CreateFile(t, repoDir, "b.go", "package b")
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add a", Files: []string{"a.go"}},
+			{Type: "feat", Message: "add b", Files: []string{"b.go"}},
+		},
+	}
+
+	// Interrupt once the first commit starts; it still completes
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	executor := NewExecutor(repoDir, false)
+	executed, err := executor.ExecuteContext(ctx, plan, func(current, total int, commit types.PlannedCommit) {
+		cancel()
+	})
+
+	var interrupted *InterruptedError
+	if !errors.As(err, &interrupted) {
+		t.Fatalf("expected InterruptedError, got %T: %v", err, err)
+	}
+	if len(executed) != 1 || interrupted.Completed != 1 {
+		t.Errorf("expected 1 completed commit, got %d (%d executed)", interrupted.Completed, len(executed))
+	}
+	if len(interrupted.Remaining) != 1 || interrupted.Remaining[0].Message != "add b" {
+		t.Errorf("unexpected remaining commits: %+v", interrupted.Remaining)
+	}
+
+	// Simulate a half-staged commit and clean it up
+	testutil.GitAdd(t, repoDir, "b.go")
+	if err := executor.UnstagePartial(); err != nil {
+		t.Fatalf("UnstagePartial failed: %v", err)
+	}
+	out, _ := exec.Command("git", "-C", repoDir, "diff", "--cached", "--name-only").Output()
+	if strings.TrimSpace(string(out)) != "" {
+		t.Errorf("expected nothing staged, got %q", out)
+	}
+}
+
+func TestExecutor_Execute_ModeOnlyCommit(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "deploy.sh", "#!/bin/sh\n")
+	testutil.GitAdd(t, repoDir, "deploy.sh")
+	testutil.GitCommit(t, repoDir, "initial commit")
+
+	if err := os.Chmod(filepath.Join(repoDir, "deploy.sh"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "chore", Message: "make deploy.sh executable", Files: []string{"deploy.sh"}},
+	}}
+
+	validator := NewValidator(repoDir, &types.RepoConfig{}, []string{"deploy.sh"})
+	if result := validator.Validate(plan); !result.Valid {
+		t.Fatalf("mode-only commit rejected: %v", result.Errors)
+	}
+
+	executed, err := NewExecutor(repoDir, false).Execute(plan, nil)
+	if err != nil || len(executed) != 1 {
+		t.Fatalf("Execute() = %v, %v", executed, err)
+	}
+
+	out, err := exec.Command("git", "-C", repoDir, "ls-files", "-s", "deploy.sh").Output()
+	if err != nil || !strings.HasPrefix(string(out), "100755") {
+		t.Errorf("deploy.sh committed as %q, want mode 100755", out)
+	}
+}
+
+func TestExecutor_Leftover(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "main.go", "package main")
+	testutil.CreateFile(t, repoDir, "notes.txt", "not planned")
+
+	// A hook that rewrites a file after it was staged leaves it changed
+	hook := filepath.Join(repoDir, ".git", "hooks", "pre-commit")
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\necho '// formatted' >> main.go\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add main package", Files: []string{"main.go"}},
+		},
+	}
+
+	executor := NewExecutor(repoDir, false)
+	if _, err := executor.Execute(plan, nil); err != nil {
+		t.Fatalf("Execute failed: %v", err)
+	}
+
+	leftover, err := executor.Leftover(plan)
+	if err != nil {
+		t.Fatalf("Leftover failed: %v", err)
+	}
+	if len(leftover) != 1 || leftover[0] != "main.go" {
+		t.Errorf("Leftover() = %v, want [main.go]", leftover)
+	}
+}
+
+func TestExecutor_Execute_CommitHookFailure(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "a.go", "package a")
+	testutil.CreateFile(t, repoDir, "b.go", "package b")
+	testutil.CreateFile(t, repoDir, "c.go", "package c")
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add a", Files: []string{"a.go"}},
+			{Type: "feat", Message: "add b", Files: []string{"b.go"}},
+			{Type: "feat", Message: "add c", Files: []string{"c.go"}},
+		},
+	}
+
+	reset := git.InjectFaults(git.Fault{Command: "commit", Call: 2, Message: "pre-commit hook failed: gofmt"})
+	defer reset()
+
+	executor := NewExecutor(repoDir, false)
+	executed, err := executor.Execute(plan, nil)
+
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) {
+		t.Fatalf("expected ExecutionError, got %T: %v", err, err)
+	}
+	if execErr.CommitIndex != 1 || len(executed) != 1 {
+		t.Errorf("expected the second commit to fail after one succeeded, got index %d, %d executed", execErr.CommitIndex, len(executed))
+	}
+	if !strings.Contains(err.Error(), "pre-commit hook failed: gofmt") {
+		t.Errorf("hook output missing from error: %v", err)
+	}
+	if got := getAllCommitMessages(t, repoDir); len(got) != 1 || got[0] != "feat: add a" {
+		t.Errorf("unexpected history after failure: %v", got)
+	}
+
+	// Resuming from the failed commit finishes the plan
+	reset()
+	remaining := &types.CommitPlan{Commits: plan.Commits[execErr.CommitIndex:]}
+	if _, err := executor.Execute(remaining, nil); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+	if got := getAllCommitMessages(t, repoDir); len(got) != 3 || got[2] != "feat: add c" {
+		t.Errorf("unexpected history after resume: %v", got)
+	}
+}
+
+func TestExecutor_Execute_SkipsFailedCommits(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "a.go", "package a")
+	testutil.CreateFile(t, repoDir, "b.go", "package b")
+	testutil.CreateFile(t, repoDir, "c.go", "package c")
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add a", Files: []string{"a.go"}},
+			{Type: "feat", Message: "add b", Files: []string{"b.go"}},
+			{Type: "feat", Message: "add c", Files: []string{"c.go"}},
+		},
+	}
+
+	defer git.InjectFaults(git.Fault{Command: "commit", Call: 2, Message: "pre-commit hook failed: gofmt"})()
+
+	var asked []int
+	executor := NewExecutor(repoDir, false)
+	executor.SetErrorHandler(func(err *ExecutionError) bool {
+		asked = append(asked, err.CommitIndex)
+		return true
+	})
+	executed, err := executor.Execute(plan, nil)
+	if err != nil {
+		t.Fatalf("expected the plan to finish past the failure, got: %v", err)
+	}
+
+	if len(asked) != 1 || asked[0] != 1 {
+		t.Errorf("handler asked about commits %v, want [1]", asked)
+	}
+	if len(executed) != 2 {
+		t.Errorf("expected 2 commits created, got %d", len(executed))
+	}
+	failed := executor.Failed()
+	if len(failed) != 1 || failed[0].CommitIndex != 1 || failed[0].Hash != "" ||
+		!strings.Contains(failed[0].Err.Error(), "pre-commit hook failed: gofmt") {
+		t.Errorf("unexpected failures: %+v", failed)
+	}
+	if got := getAllCommitMessages(t, repoDir); len(got) != 2 || got[0] != "feat: add a" || got[1] != "feat: add c" {
+		t.Errorf("unexpected history: %v", got)
+	}
+
+	// The failed commit's file is left uncommitted and unstaged
+	cmd := exec.Command("git", "status", "--porcelain")
+	cmd.Dir = repoDir
+	out, _ := cmd.Output()
+	if strings.TrimSpace(string(out)) != "?? b.go" {
+		t.Errorf("expected b.go left untracked, got status:\n%s", out)
+	}
+}
+
+func TestExecutor_Execute_ErrorHandlerAborts(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "a.go", "package a")
+	testutil.CreateFile(t, repoDir, "b.go", "package b")
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add a", Files: []string{"a.go"}},
+			{Type: "feat", Message: "add b", Files: []string{"b.go"}},
+		},
+	}
+
+	defer git.InjectFaults(git.Fault{Command: "commit", Call: 1, Message: "pre-commit hook failed"})()
+
+	executor := NewExecutor(repoDir, false)
+	executor.SetErrorHandler(func(*ExecutionError) bool { return false })
+	_, err := executor.Execute(plan, nil)
+
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) || execErr.CommitIndex != 0 || len(executor.Failed()) != 0 {
+		t.Errorf("expected an abort at the first commit, got %v (%d failed)", err, len(executor.Failed()))
+	}
+}
+
+func TestExecutor_Execute_StageFailure(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	testutil.CreateFile(t, repoDir, "README.md", "# Test")
+	testutil.GitAdd(t, repoDir, "README.md")
+	testutil.GitCommit(t, repoDir, "initial")
+	testutil.CreateFile(t, repoDir, "a.go", "package a")
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{{Type: "feat", Message: "add a", Files: []string{"a.go"}}},
+	}
+
+	defer git.InjectFaults(git.Fault{Command: "add", Message: "fatal: Unable to create '.git/index.lock': File exists."})()
+
+	executor := NewExecutor(repoDir, false)
+	executed, err := executor.Execute(plan, nil)
+
+	var execErr *ExecutionError
+	if !errors.As(err, &execErr) || len(executed) != 0 {
+		t.Fatalf("expected ExecutionError with nothing executed, got %T: %v (%d executed)", err, err, len(executed))
+	}
+	if !strings.Contains(err.Error(), "index.lock") {
+		t.Errorf("git output missing from error: %v", err)
+	}
+	if got := getAllCommitMessages(t, repoDir); len(got) != 1 {
+		t.Errorf("expected no new commits, got %v", got)
+	}
+}
+
+// installFormatHook installs a pre-commit hook that collapses double spaces
+// in fmt.go, exiting with status once it has rewritten the file.
+func installFormatHook(t *testing.T, repoDir string, status int) {
+	t.Helper()
+	hook := fmt.Sprintf(`#!/bin/sh
+if grep -q '  ' fmt.go; then
+	printf 'package fmt\n' > fmt.go
+	exit %d
+fi
+`, status)
+	if err := os.WriteFile(filepath.Join(repoDir, ".git", "hooks", "pre-commit"), []byte(hook), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExecutor_Execute_HookRewrite(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell hooks")
+	}
+
+	tests := []struct {
+		mode          string
+		wantCommitted string
+		wantErr       bool
+	}{
+		{"", "package fmt\n", false},
+		{types.HookAutofixAmend, "package fmt\n", false},
+		{types.HookAutofixWarn, "package  fmt", false},
+		{types.HookAutofixFail, "package  fmt", true},
+	}
+	for _, tt := range tests {
+		t.Run("mode="+tt.mode, func(t *testing.T) {
+			repoDir := testutil.TestRepo(t)
+			testutil.CreateFile(t, repoDir, "fmt.go", "package  fmt")
+			installFormatHook(t, repoDir, 0)
+
+			plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+				{Type: "feat", Message: "add fmt", Files: []string{"fmt.go"}},
+			}}
+			executor := NewExecutor(repoDir, false)
+			executor.SetHookAutofix(tt.mode)
+			executed, err := executor.Execute(plan, nil)
+
+			var rewriteErr *HookRewriteError
+			if tt.wantErr != errors.As(err, &rewriteErr) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if len(executed) != 1 || strings.Join(executed[0].HookRewritten, ",") != "fmt.go" {
+				t.Fatalf("expected one commit recording the rewrite, got %+v", executed)
+			}
+
+			out, _ := exec.Command("git", "-C", repoDir, "show", "HEAD:fmt.go").Output()
+			if string(out) != tt.wantCommitted {
+				t.Errorf("committed %q, want %q", out, tt.wantCommitted)
+			}
+			hash, _ := exec.Command("git", "-C", repoDir, "rev-parse", "--short", "HEAD").Output()
+			if executed[0].Hash != strings.TrimSpace(string(hash)) {
+				t.Errorf("executed hash %s is not HEAD %s", executed[0].Hash, hash)
+			}
+			if got := getAllCommitMessages(t, repoDir); len(got) != 1 {
+				t.Errorf("expected a single commit, got %v", got)
+			}
+		})
+	}
+}
+
+func TestExecutor_Execute_HookRewriteRejectsCommit(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell hooks")
+	}
+
+	for _, mode := range []string{types.HookAutofixAmend, types.HookAutofixWarn} {
+		t.Run(mode, func(t *testing.T) {
+			repoDir := testutil.TestRepo(t)
+			testutil.CreateFile(t, repoDir, "fmt.go", "package  fmt")
+			installFormatHook(t, repoDir, 1)
+
+			plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+				{Type: "feat", Message: "add fmt", Files: []string{"fmt.go"}},
+			}}
+			executor := NewExecutor(repoDir, false)
+			executor.SetHookAutofix(mode)
+			_, err := executor.Execute(plan, nil)
+
+			// Only amend retries with the formatted file staged
+			if mode == types.HookAutofixWarn {
+				var commitErr *git.CommitError
+				if !errors.As(err, &commitErr) {
+					t.Errorf("expected the hook's failure, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected the retry to succeed, got %v", err)
+			}
+			out, _ := exec.Command("git", "-C", repoDir, "show", "HEAD:fmt.go").Output()
+			if string(out) != "package fmt\n" {
+				t.Errorf("committed %q, want the formatted file", out)
+			}
+		})
+	}
+}
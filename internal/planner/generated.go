@@ -0,0 +1,52 @@
+package planner
+
+import "github.com/dsswift/commit/pkg/types"
+
+// GeneratedCommitType and GeneratedCommitMessage describe the commit that
+// groups files marked as generated in .gitattributes.
+const (
+	GeneratedCommitType    = "chore"
+	GeneratedCommitMessage = "update generated files"
+)
+
+// AddGeneratedFiles moves generated files out of the plan's commits so they
+// never shape a commit's type, then commits them separately as
+// "chore: update generated files". In single-commit mode they join the one
+// commit instead. Applying it to a plan that already has the generated
+// commit is a no-op.
+func AddGeneratedFiles(plan *types.CommitPlan, generated []string, single bool) {
+	if len(generated) == 0 {
+		return
+	}
+
+	isGenerated := make(map[string]bool, len(generated))
+	for _, f := range generated {
+		isGenerated[f] = true
+	}
+
+	var commits []types.PlannedCommit
+	for _, commit := range plan.Commits {
+		var files []string
+		for _, f := range commit.Files {
+			if !isGenerated[f] {
+				files = append(files, f)
+			}
+		}
+		if len(files) > 0 {
+			commit.Files = files
+			commits = append(commits, commit)
+		}
+	}
+
+	if single && len(commits) > 0 {
+		commits[0].Files = append(commits[0].Files, generated...)
+	} else {
+		commits = append(commits, types.PlannedCommit{
+			Type:      GeneratedCommitType,
+			Message:   GeneratedCommitMessage,
+			Files:     append([]string(nil), generated...),
+			Reasoning: "Files marked linguist-generated or -diff in .gitattributes",
+		})
+	}
+	plan.Commits = commits
+}
@@ -0,0 +1,43 @@
+package planner
+
+import (
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// GitignoreCommitType and GitignoreCommitMessage describe the commit that
+// adds junk patterns to .gitignore (--fix-gitignore).
+const (
+	GitignoreCommitType    = "chore"
+	GitignoreCommitMessage = "update gitignore"
+)
+
+// AddGitignoreCommit moves .gitignore out of the plan's commits into its own
+// "chore: update gitignore" commit, placed first so the junk is ignored
+// before anything else is committed. Applying it to a plan that already has
+// the commit is a no-op.
+func AddGitignoreCommit(plan *types.CommitPlan) {
+	var commits []types.PlannedCommit
+	for _, commit := range plan.Commits {
+		if commit.Message == GitignoreCommitMessage && len(commit.Files) == 1 && commit.Files[0] == git.GitignoreFile {
+			return
+		}
+		var files []string
+		for _, f := range commit.Files {
+			if f != git.GitignoreFile {
+				files = append(files, f)
+			}
+		}
+		if len(files) > 0 {
+			commit.Files = files
+			commits = append(commits, commit)
+		}
+	}
+
+	plan.Commits = append([]types.PlannedCommit{{
+		Type:      GitignoreCommitType,
+		Message:   GitignoreCommitMessage,
+		Files:     []string{git.GitignoreFile},
+		Reasoning: "Ignore junk files found among the changes",
+	}}, commits...)
+}
@@ -0,0 +1,132 @@
+package planner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// FastConfidence is the confidence every type guess needs for --fast to
+// plan without the LLM.
+const FastConfidence = 0.9
+
+// FastReasoning marks commits planned locally by --fast.
+const FastReasoning = "Planned locally with --fast: "
+
+// dependencyFiles are base names of dependency manifests and lockfiles.
+var dependencyFiles = map[string]bool{
+	"go.mod": true, "go.sum": true, "go.work": true, "go.work.sum": true,
+	"package.json": true, "package-lock.json": true, "npm-shrinkwrap.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"cargo.toml": true, "cargo.lock": true,
+	"requirements.txt": true, "poetry.lock": true, "pipfile": true, "pipfile.lock": true, "uv.lock": true,
+	"gemfile": true, "gemfile.lock": true, "composer.json": true, "composer.lock": true,
+	"packages.lock.json": true, "directory.packages.props": true,
+}
+
+// deletionHeavyRatio is the share of changed lines that must be removals
+// for a group to count as deletion-heavy.
+const deletionHeavyRatio = 0.8
+
+// GuessTypes guesses a commit type for each group of req's files, grouped
+// like HeuristicPlan. Groups the paths and line counts don't suggest a type
+// for are left out.
+func GuessTypes(req *types.AnalysisRequest) []types.TypeGuess {
+	groups := groupFiles(req)
+	var guesses []types.TypeGuess
+	for _, group := range groups {
+		guess := guessType(group.files, req.Rules.Types)
+		if guess.Type == "" {
+			continue
+		}
+		// Tests next to other changes usually belong with them
+		if guess.Reason == reasonTests && len(groups) > 1 {
+			guess.Confidence = 0.6
+		}
+		for _, f := range group.files {
+			guess.Files = append(guess.Files, f.Path)
+		}
+		guesses = append(guesses, guess)
+	}
+	return guesses
+}
+
+// Reasons given for type guesses.
+const (
+	reasonDocs         = "documentation only"
+	reasonTests        = "tests only"
+	reasonDependencies = "dependency manifests and lockfiles only"
+	reasonDeleted      = "files removed"
+	reasonDeletions    = "mostly deletions"
+)
+
+// guessType guesses the type of a commit of files, or returns a zero guess
+// when the paths and line counts don't suggest one.
+func guessType(files []types.FileChange, allowed []string) types.TypeGuess {
+	allDocs, allTests, allDeps, allDeleted := true, true, true, true
+	added, removed := 0, 0
+	for _, f := range files {
+		allDeps = allDeps && isDependencyFile(f.Path)
+		allDocs = allDocs && isDocFile(f.Path)
+		allTests = allTests && isTestFile(f.Path)
+		allDeleted = allDeleted && f.Status == "deleted"
+		a, r := diffLines(f.DiffSummary)
+		added += a
+		removed += r
+	}
+
+	switch {
+	// Dependency files go first, since requirements.txt looks like documentation
+	case allDeps:
+		return types.TypeGuess{Type: allowedType("build", allowed), Confidence: 0.9, Reason: reasonDependencies}
+	case allDocs:
+		return types.TypeGuess{Type: allowedType("docs", allowed), Confidence: 0.95, Reason: reasonDocs}
+	case allTests:
+		return types.TypeGuess{Type: allowedType("test", allowed), Confidence: 0.9, Reason: reasonTests}
+	case allDeleted:
+		return types.TypeGuess{Type: allowedType("chore", allowed), Confidence: 0.7, Reason: reasonDeleted}
+	case removed >= 20 && float64(removed) >= deletionHeavyRatio*float64(added+removed):
+		return types.TypeGuess{Type: allowedType("refactor", allowed), Confidence: 0.5, Reason: reasonDeletions}
+	}
+	return types.TypeGuess{}
+}
+
+// FastPlan plans commits from the type guesses alone when every group of
+// files has one with at least FastConfidence. It returns false otherwise.
+func FastPlan(req *types.AnalysisRequest) (*types.CommitPlan, bool) {
+	groups := groupFiles(req)
+	if len(groups) == 0 {
+		return nil, false
+	}
+
+	guesses := GuessTypes(req)
+	if len(guesses) != len(groups) {
+		return nil, false
+	}
+
+	plan := &types.CommitPlan{}
+	for i, group := range groups {
+		if guesses[i].Confidence < FastConfidence {
+			return nil, false
+		}
+		commit := heuristicCommit(group, guesses[i].Type, FastReasoning+guesses[i].Reason, req.Rules.MaxMessageLength, req.Convention)
+		if guesses[i].Reason == reasonDependencies {
+			commit.Message = "update dependencies"
+		}
+		plan.Commits = append(plan.Commits, commit)
+	}
+	return plan, true
+}
+
+// isDependencyFile reports whether p is a dependency manifest or lockfile.
+func isDependencyFile(p string) bool {
+	base := strings.ToLower(path.Base(p))
+	return dependencyFiles[base] || (strings.HasPrefix(base, "requirements") && path.Ext(base) == ".txt")
+}
+
+// diffLines parses a "+45 -12" diff summary. Binary files count as no lines.
+func diffLines(summary string) (added, removed int) {
+	_, _ = fmt.Sscanf(summary, "+%d -%d", &added, &removed)
+	return added, removed
+}
@@ -0,0 +1,94 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func TestGuessType(t *testing.T) {
+	allowed := []string{"feat", "fix", "docs", "test", "build", "refactor", "chore"}
+	tests := []struct {
+		name     string
+		files    []types.FileChange
+		allowed  []string
+		wantType string
+		wantConf float64
+	}{
+		{"docs", []types.FileChange{{Path: "README.md"}, {Path: "docs/api.md"}}, allowed, "docs", 0.95},
+		{"tests", []types.FileChange{{Path: "api/a_test.go"}}, allowed, "test", 0.9},
+		{"dependencies", []types.FileChange{{Path: "go.mod"}, {Path: "go.sum"}}, allowed, "build", 0.9},
+		{"requirements", []types.FileChange{{Path: "requirements-dev.txt"}}, allowed, "build", 0.9},
+		{"build not allowed", []types.FileChange{{Path: "package.json"}}, []string{"feat", "chore"}, "chore", 0.9},
+		{"deleted", []types.FileChange{{Path: "old/a.go", Status: "deleted", DiffSummary: "+0 -80"}}, allowed, "chore", 0.7},
+		{"mostly deletions", []types.FileChange{{Path: "api/a.go", DiffSummary: "+5 -120"}}, allowed, "refactor", 0.5},
+		{"small deletion", []types.FileChange{{Path: "api/a.go", DiffSummary: "+0 -3"}}, allowed, "", 0},
+		{"code", []types.FileChange{{Path: "api/a.go", DiffSummary: "+40 -2"}}, allowed, "", 0},
+		{"binary", []types.FileChange{{Path: "logo.png", DiffSummary: "+binary -binary"}}, allowed, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := guessType(tt.files, tt.allowed)
+			if got.Type != tt.wantType || got.Confidence != tt.wantConf {
+				t.Errorf("guessType() = %q (%.2f), want %q (%.2f)", got.Type, got.Confidence, tt.wantType, tt.wantConf)
+			}
+		})
+	}
+}
+
+func TestGuessTypes(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "api/handler.go", Scope: "api", DiffSummary: "+20 -3"},
+			{Path: "docs/setup.md"},
+			{Path: "tests/handler_test.go"},
+		},
+		Rules: types.CommitRules{Types: []string{"feat", "docs", "test", "chore"}},
+	}
+
+	guesses := GuessTypes(req)
+	if len(guesses) != 2 {
+		t.Fatalf("expected guesses for docs and tests only, got %+v", guesses)
+	}
+	if guesses[0].Type != "docs" || len(guesses[0].Files) != 1 || guesses[0].Files[0] != "docs/setup.md" {
+		t.Errorf("docs guess = %+v", guesses[0])
+	}
+	// Tests changed alongside code probably belong with it
+	if guesses[1].Type != "test" || guesses[1].Confidence >= FastConfidence {
+		t.Errorf("tests guess = %+v, want low confidence", guesses[1])
+	}
+}
+
+func TestFastPlan(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "docs/setup.md", Status: "added"},
+			{Path: "go.mod", Status: "modified"},
+			{Path: "go.sum", Status: "modified"},
+		},
+		Rules: types.CommitRules{Types: []string{"feat", "docs", "build", "chore"}, MaxMessageLength: 50},
+	}
+
+	plan, ok := FastPlan(req)
+	if !ok {
+		t.Fatal("expected a fast plan for docs and dependencies")
+	}
+	want := []string{"build: update dependencies", "docs: add setup.md"}
+	if len(plan.Commits) != len(want) {
+		t.Fatalf("expected %d commits, got %+v", len(want), plan.Commits)
+	}
+	for i, c := range plan.Commits {
+		if got := formatHeader(c); got != want[i] {
+			t.Errorf("commit %d = %q, want %q", i, got, want[i])
+		}
+	}
+	if plan.Commits[0].Reasoning != FastReasoning+reasonDependencies {
+		t.Errorf("reasoning = %q", plan.Commits[0].Reasoning)
+	}
+
+	// Any group without an obvious type needs the LLM
+	req.Files = append(req.Files, types.FileChange{Path: "api/handler.go", Status: "modified", DiffSummary: "+10 -2"})
+	if _, ok := FastPlan(req); ok {
+		t.Error("expected no fast plan when code changed")
+	}
+}
@@ -0,0 +1,177 @@
+package planner
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// HeuristicReasoning marks commits planned locally instead of by the LLM.
+const HeuristicReasoning = "Planned locally from file paths; the LLM did not respond in time"
+
+// docExtensions are file extensions treated as documentation.
+var docExtensions = map[string]bool{".md": true, ".mdx": true, ".rst": true, ".txt": true, ".adoc": true}
+
+// HeuristicPlan plans commits from file paths alone, for when the LLM can't
+// be used: one commit per scope (or suggested scope, or top-level directory),
+// typed docs or test when every file is documentation or tests, and chore
+// otherwise. In single-commit mode all files go in one commit.
+func HeuristicPlan(req *types.AnalysisRequest) *types.CommitPlan {
+	plan := &types.CommitPlan{}
+	for _, group := range groupFiles(req) {
+		typ := heuristicType(group.files, req.Rules.Types)
+		plan.Commits = append(plan.Commits, heuristicCommit(group, typ, HeuristicReasoning, req.Rules.MaxMessageLength, req.Convention))
+	}
+	return plan
+}
+
+// fileGroup is files sharing a scope, suggested scope or top-level directory.
+type fileGroup struct {
+	key   string
+	files []types.FileChange
+}
+
+// groupFiles groups req's files by groupKey, sorted by key. In single-commit
+// mode all files form one group.
+func groupFiles(req *types.AnalysisRequest) []fileGroup {
+	groups := make(map[string][]types.FileChange)
+	var order []string
+	for _, f := range req.Files {
+		key := ""
+		if !req.SingleCommit {
+			key = groupKey(f)
+		}
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], f)
+	}
+	sort.Strings(order)
+
+	result := make([]fileGroup, len(order))
+	for i, key := range order {
+		result[i] = fileGroup{key: key, files: groups[key]}
+	}
+	return result
+}
+
+// heuristicCommit plans group as one commit of type typ, its subject as conv
+// formats it shortened to fit maxLength when that is set.
+func heuristicCommit(group fileGroup, typ, reasoning string, maxLength int, conv types.SubjectFormatter) types.PlannedCommit {
+	commit := types.PlannedCommit{
+		Type:      typ,
+		Message:   heuristicMessage(group.key, group.files),
+		Reasoning: reasoning,
+	}
+	if scope := group.files[0].Scope; scope != "" && group.key == scope {
+		commit.Scope = &scope
+	}
+	for _, f := range group.files {
+		commit.Files = append(commit.Files, f.Path)
+	}
+	if maxLength > 0 {
+		fitSubject(conv, &commit, maxLength)
+	}
+	return commit
+}
+
+// groupKey returns the scope, suggested scope or top-level directory of f.
+func groupKey(f types.FileChange) string {
+	switch {
+	case f.Scope != "":
+		return f.Scope
+	case f.SuggestedScope != "":
+		return f.SuggestedScope
+	}
+	if dir, _, ok := strings.Cut(f.Path, "/"); ok {
+		return dir
+	}
+	return ""
+}
+
+// heuristicType picks docs or test when all files are documentation or
+// tests and that type is allowed, otherwise chore (or the first allowed type).
+func heuristicType(files []types.FileChange, allowed []string) string {
+	isAllowed := func(t string) bool {
+		for _, a := range allowed {
+			if a == t {
+				return true
+			}
+		}
+		return false
+	}
+
+	allDocs, allTests := true, true
+	for _, f := range files {
+		allDocs = allDocs && isDocFile(f.Path)
+		allTests = allTests && isTestFile(f.Path)
+	}
+
+	for _, candidate := range []struct {
+		typ string
+		ok  bool
+	}{{"docs", allDocs}, {"test", allTests}, {"chore", true}} {
+		if candidate.ok && isAllowed(candidate.typ) {
+			return candidate.typ
+		}
+	}
+	if len(allowed) > 0 {
+		return allowed[0]
+	}
+	return "chore"
+}
+
+// heuristicMessage describes files by what happened to them ("add", "remove"
+// or "update") and what they are.
+func heuristicMessage(key string, files []types.FileChange) string {
+	verb := "update"
+	if status := commonStatus(files); status == "added" {
+		verb = "add"
+	} else if status == "deleted" {
+		verb = "remove"
+	}
+
+	name := key
+	switch {
+	case len(files) == 1:
+		name = path.Base(files[0].Path)
+	case name == "":
+		name = "project files"
+	default:
+		name += " files"
+	}
+
+	return verb + " " + name
+}
+
+// commonStatus returns the status shared by all files, or "".
+func commonStatus(files []types.FileChange) string {
+	status := files[0].Status
+	for _, f := range files[1:] {
+		if f.Status != status {
+			return ""
+		}
+	}
+	return status
+}
+
+// isDocFile reports whether p is documentation.
+func isDocFile(p string) bool {
+	base := strings.ToUpper(path.Base(p))
+	if strings.HasPrefix(base, "README") || strings.HasPrefix(base, "CHANGELOG") || strings.HasPrefix(base, "LICENSE") {
+		return true
+	}
+	return docExtensions[strings.ToLower(path.Ext(p))] || strings.HasPrefix(p, "docs/")
+}
+
+// isTestFile reports whether p is a test by common naming conventions.
+func isTestFile(p string) bool {
+	base := path.Base(p)
+	return strings.HasSuffix(base, "_test.go") ||
+		strings.Contains(base, ".test.") || strings.Contains(base, ".spec.") ||
+		strings.HasPrefix(base, "test_") ||
+		strings.HasPrefix(p, "test/") || strings.HasPrefix(p, "tests/") ||
+		strings.Contains(p, "/test/") || strings.Contains(p, "/tests/")
+}
@@ -0,0 +1,96 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func TestHeuristicPlan(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "api/handler.go", Status: "modified", Scope: "api"},
+			{Path: "api/handler_test.go", Status: "modified", Scope: "api"},
+			{Path: "docs/setup.md", Status: "added"},
+			{Path: "docs/usage.md", Status: "added"},
+			{Path: "Makefile", Status: "deleted"},
+		},
+		Rules: types.CommitRules{Types: []string{"feat", "fix", "docs", "test", "chore"}, MaxMessageLength: 50},
+	}
+
+	plan := HeuristicPlan(req)
+
+	want := []string{
+		"chore: remove Makefile",
+		"chore(api): update api files",
+		"docs: add docs files",
+	}
+	if len(plan.Commits) != len(want) {
+		t.Fatalf("expected %d commits, got %d: %+v", len(want), len(plan.Commits), plan.Commits)
+	}
+	for i, c := range plan.Commits {
+		if got := formatHeader(c); got != want[i] {
+			t.Errorf("commit %d = %q, want %q", i, got, want[i])
+		}
+		if c.Reasoning != HeuristicReasoning {
+			t.Errorf("commit %d reasoning = %q", i, c.Reasoning)
+		}
+	}
+	if len(plan.Commits[1].Files) != 2 {
+		t.Errorf("api commit files = %v", plan.Commits[1].Files)
+	}
+}
+
+func TestHeuristicPlan_Single(t *testing.T) {
+	req := &types.AnalysisRequest{
+		Files: []types.FileChange{
+			{Path: "api/handler.go", Status: "modified", Scope: "api"},
+			{Path: "web/app.ts", Status: "added"},
+		},
+		SingleCommit: true,
+		Rules:        types.CommitRules{Types: []string{"feat", "chore"}},
+	}
+
+	plan := HeuristicPlan(req)
+	if len(plan.Commits) != 1 {
+		t.Fatalf("expected 1 commit, got %d", len(plan.Commits))
+	}
+	if got := formatHeader(plan.Commits[0]); got != "chore: update project files" {
+		t.Errorf("header = %q", got)
+	}
+	if len(plan.Commits[0].Files) != 2 {
+		t.Errorf("files = %v", plan.Commits[0].Files)
+	}
+}
+
+func TestHeuristicType(t *testing.T) {
+	tests := []struct {
+		name    string
+		paths   []string
+		allowed []string
+		want    string
+	}{
+		{"docs", []string{"README.md", "docs/api.md"}, []string{"docs", "chore"}, "docs"},
+		{"tests", []string{"pkg/a_test.go", "web/app.spec.ts"}, []string{"test", "chore"}, "test"},
+		{"mixed", []string{"README.md", "main.go"}, []string{"docs", "chore"}, "chore"},
+		{"chore not allowed", []string{"main.go"}, []string{"feat", "fix"}, "feat"},
+		{"no types", []string{"main.go"}, nil, "chore"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var files []types.FileChange
+			for _, p := range tt.paths {
+				files = append(files, types.FileChange{Path: p})
+			}
+			if got := heuristicType(files, tt.allowed); got != tt.want {
+				t.Errorf("heuristicType(%v) = %q, want %q", tt.paths, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHeuristicPlan_Empty(t *testing.T) {
+	if plan := HeuristicPlan(&types.AnalysisRequest{}); len(plan.Commits) != 0 {
+		t.Errorf("expected no commits, got %+v", plan.Commits)
+	}
+}
@@ -0,0 +1,101 @@
+package planner
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// headerPattern matches a conventional commit subject: type(scope)!: message.
+var headerPattern = regexp.MustCompile(`^([A-Za-z]+)(?:\(([^)]*)\))?!?: (.*)$`)
+
+// lintSkipPrefixes start subjects written by git itself or meant to be
+// squashed away, which aren't held to the rules.
+var lintSkipPrefixes = []string{"fixup! ", "squash! ", "amend! ", "Merge ", `Revert "`}
+
+// ParseHeader splits a conventional commit subject into the type, scope and
+// message of a planned commit. It returns false if subject isn't in
+// "type(scope): message" form.
+func ParseHeader(subject string) (types.PlannedCommit, bool) {
+	m := headerPattern.FindStringSubmatch(subject)
+	if m == nil {
+		return types.PlannedCommit{}, false
+	}
+	commit := types.PlannedCommit{Type: m[1], Message: m[3]}
+	if m[2] != "" {
+		scope := m[2]
+		commit.Scope = &scope
+	}
+	return commit, true
+}
+
+// SkipLint reports whether subject was written by git or a fixup workflow
+// rather than by hand.
+func SkipLint(subject string) bool {
+	for _, prefix := range lintSkipPrefixes {
+		if strings.HasPrefix(subject, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateMessage checks an existing commit subject against the rules
+// planned commits are held to: the convention's form, an allowed type
+// (including branch policies) when the convention shows one, a non-empty
+// message within the length limit, the repo's scope policy and, when the
+// repo configures scopes, a configured scope.
+func (v *Validator) ValidateMessage(subject string) []ValidationError {
+	active := v.convention()
+	parse := active.Parse
+	if _, ok := active.(convention.Conventional); ok {
+		parse = ParseHeader
+	}
+	commit, ok := parse(subject)
+	if !ok {
+		return []ValidationError{{
+			Field:   "subject",
+			Message: fmt.Sprintf("not in %q form", active.Form()),
+		}}
+	}
+
+	// Plain and ticket subjects carry no type to check
+	errs := validateText("", commit, v.maxSubjectLength(), active)
+	if commit.Type != "" {
+		errs = append(v.validateType("", commit), errs...)
+	}
+	if commit.Type != "" {
+		errs = append(errs, v.validateScopePolicy("", commit)...)
+	}
+	if scope := scopeOf(commit); scope != "" && !v.scopeConfigured(scope) {
+		var configured []string
+		for _, s := range v.repoConfig.Scopes {
+			configured = append(configured, s.Scope)
+		}
+		errs = append(errs, ValidationError{
+			Field:   "scope",
+			Message: fmt.Sprintf("scope %q not configured (configured: %v)", scope, configured),
+		})
+	}
+	return errs
+}
+
+// scopeConfigured reports whether scope is allowed by the repo config. Repos
+// without configured scopes accept any scope.
+func (v *Validator) scopeConfigured(scope string) bool {
+	if len(v.repoConfig.Scopes) == 0 {
+		return true
+	}
+	if v.repoConfig.DefaultScope != nil && *v.repoConfig.DefaultScope == scope {
+		return true
+	}
+	for _, s := range v.repoConfig.Scopes {
+		if s.Scope == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package planner
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func TestParseHeader(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    string
+		ok      bool
+	}{
+		{"feat(api): add retry", "feat(api): add retry", true},
+		{"fix: handle nil config", "fix: handle nil config", true},
+		{"feat(api)!: drop v1 routes", "feat(api): drop v1 routes", true},
+		{"Add retry", "", false},
+		{"feat add retry", "", false},
+	}
+	for _, tt := range tests {
+		commit, ok := ParseHeader(tt.subject)
+		if ok != tt.ok {
+			t.Errorf("ParseHeader(%q) ok = %v, want %v", tt.subject, ok, tt.ok)
+			continue
+		}
+		if ok && formatHeader(commit) != tt.want {
+			t.Errorf("ParseHeader(%q) = %q, want %q", tt.subject, formatHeader(commit), tt.want)
+		}
+	}
+}
+
+func TestSkipLint(t *testing.T) {
+	for _, subject := range []string{"fixup! feat: add retry", `Revert "feat: add retry"`, "Merge branch 'main'"} {
+		if !SkipLint(subject) {
+			t.Errorf("SkipLint(%q) = false, want true", subject)
+		}
+	}
+	if SkipLint("feat: add retry") {
+		t.Error("SkipLint should not skip ordinary subjects")
+	}
+}
+
+func TestValidator_ValidateMessage(t *testing.T) {
+	repoConfig := &types.RepoConfig{
+		Scopes: []types.ScopeConfig{{Path: "api/", Scope: "api"}},
+		CommitTypes: types.CommitTypeConfig{
+			Mode:  "whitelist",
+			Types: []string{"feat", "fix", "chore"},
+		},
+	}
+	v := NewValidator(t.TempDir(), repoConfig, nil)
+
+	tests := []struct {
+		subject string
+		want    string // substring of the only error, or "" for none
+	}{
+		{"feat(api): add retry", ""},
+		{"chore: bump deps", ""},
+		{"docs(api): document retry", `commit type "docs" not allowed`},
+		{"feat(web): add page", `scope "web" not configured`},
+		{"feat(api): " + strings.Repeat("x", 51), "exceeds 50 chars"},
+		{"Add retry", "not in"},
+	}
+	for _, tt := range tests {
+		errs := v.ValidateMessage(tt.subject)
+		if tt.want == "" {
+			if len(errs) != 0 {
+				t.Errorf("ValidateMessage(%q) = %v, want no errors", tt.subject, errs)
+			}
+			continue
+		}
+		if len(errs) != 1 || !strings.Contains(errs[0].Message, tt.want) {
+			t.Errorf("ValidateMessage(%q) = %v, want one error containing %q", tt.subject, errs, tt.want)
+		}
+	}
+}
+
+func TestValidator_ValidateMessage_AnyScopeWithoutConfig(t *testing.T) {
+	v := NewValidator(t.TempDir(), &types.RepoConfig{}, nil)
+	if errs := v.ValidateMessage("feat(anything): add retry"); len(errs) != 0 {
+		t.Errorf("expected any scope to pass without configured scopes, got %v", errs)
+	}
+}
+
+func TestValidator_ValidateMessage_Convention(t *testing.T) {
+	defer convention.Use(convention.Active())
+	v := NewValidator(t.TempDir(), &types.RepoConfig{}, nil)
+
+	convention.Use(convention.Plain{})
+	if errs := v.ValidateMessage("Add retry"); len(errs) != 0 {
+		t.Errorf("plain subject should pass, got %v", errs)
+	}
+
+	ticket, err := convention.New(&types.RepoConfig{Convention: convention.NameTicket}, "PROJ-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	convention.Use(ticket)
+	if errs := v.ValidateMessage("[PROJ-7] Add retry"); len(errs) != 0 {
+		t.Errorf("ticket subject should pass, got %v", errs)
+	}
+	errs := v.ValidateMessage("feat: add retry")
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, `not in "[TICKET-123] Summary" form`) {
+		t.Errorf("expected form error, got %v", errs)
+	}
+}
+
+func TestValidator_ValidateMessage_ScopePolicy(t *testing.T) {
+	required := NewValidator(t.TempDir(), &types.RepoConfig{ScopePolicy: types.ScopePolicyRequired}, nil)
+	if errs := required.ValidateMessage("feat: add retry"); len(errs) != 1 || errs[0].Field != "scope" {
+		t.Errorf("required: ValidateMessage = %v, want a scope error", errs)
+	}
+	if errs := required.ValidateMessage("feat(api): add retry"); len(errs) != 0 {
+		t.Errorf("required: ValidateMessage = %v, want no errors", errs)
+	}
+
+	forbidden := NewValidator(t.TempDir(), &types.RepoConfig{ScopePolicy: types.ScopePolicyForbidden}, nil)
+	if errs := forbidden.ValidateMessage("feat(api): add retry"); len(errs) != 1 || errs[0].Field != "scope" {
+		t.Errorf("forbidden: ValidateMessage = %v, want a scope error", errs)
+	}
+}
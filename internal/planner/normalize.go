@@ -0,0 +1,305 @@
+package planner
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// defaultMaxMessageLength matches the analyzer's default subject limit, which
+// covers the whole formatted subject, type and scope included.
+const defaultMaxMessageLength = 50
+
+// commitVerbs are the verbs commit subjects usually start with. Their past
+// tense, third person and gerund forms are rewritten to the imperative.
+var commitVerbs = []string{
+	"add", "adjust", "align", "allow", "apply", "avoid", "build", "bump", "cache",
+	"change", "check", "clarify", "clean", "configure", "convert", "correct", "create",
+	"delete", "deprecate", "disable", "document", "drop", "enable", "ensure", "expose",
+	"extract", "fix", "handle", "hide", "implement", "improve", "include", "increase",
+	"initialize", "install", "introduce", "load", "log", "make", "merge", "migrate",
+	"move", "optimize", "parse", "prevent", "reduce", "refactor", "release", "remove",
+	"rename", "render", "reorganize", "replace", "resolve", "restore", "return",
+	"revert", "run", "set", "show", "simplify", "skip", "sort", "split", "support",
+	"switch", "test", "tweak", "update", "upgrade", "use", "validate", "wrap", "write",
+}
+
+// irregularVerbs maps irregular inflections to their base form.
+var irregularVerbs = map[string]string{
+	"built": "build", "made": "make", "ran": "run", "wrote": "write", "written": "write",
+}
+
+// nounGerunds are -ing forms that usually start a noun phrase ("logging
+// improvements"), so they're left alone.
+var nounGerunds = map[string]bool{
+	"building": true, "caching": true, "logging": true, "parsing": true,
+	"rendering": true, "setting": true, "sorting": true, "testing": true,
+}
+
+// imperativeForms maps inflected verb forms to the imperative.
+var imperativeForms = buildImperativeForms()
+
+func buildImperativeForms() map[string]string {
+	forms := make(map[string]string)
+	for inflected, base := range irregularVerbs {
+		forms[inflected] = base
+	}
+	for _, base := range commitVerbs {
+		stem := base
+		last := base[len(base)-1]
+		if strings.HasSuffix(base, "e") {
+			stem = base[:len(base)-1]
+		} else if isShortCVC(base) {
+			// drop → dropped, dropping
+			stem = base + string(last)
+		}
+
+		switch {
+		case strings.HasSuffix(base, "y") && !strings.ContainsRune("aeiou", rune(base[len(base)-2])):
+			forms[base[:len(base)-1]+"ies"] = base
+			forms[base[:len(base)-1]+"ied"] = base
+		case strings.HasSuffix(base, "s") || strings.HasSuffix(base, "x") || strings.HasSuffix(base, "ch") || strings.HasSuffix(base, "sh"):
+			forms[base+"es"] = base
+			forms[stem+"ed"] = base
+		default:
+			forms[base+"s"] = base
+			forms[stem+"ed"] = base
+		}
+		if !nounGerunds[stem+"ing"] {
+			forms[stem+"ing"] = base
+		}
+	}
+	return forms
+}
+
+// isShortCVC reports whether a one-syllable verb doubles its final consonant
+// before -ed and -ing (drop, skip, wrap, log).
+func isShortCVC(word string) bool {
+	if len(word) < 3 || len(word) > 4 {
+		return false
+	}
+	vowels := "aeiou"
+	c1, v, c2 := word[len(word)-3], word[len(word)-2], word[len(word)-1]
+	return !strings.ContainsRune(vowels, rune(c1)) && strings.ContainsRune(vowels, rune(v)) &&
+		!strings.ContainsRune(vowels+"wxy", rune(c2)) && strings.Count(word, string(v)) == 1
+}
+
+// NormalizePlan makes the commit messages in plan consistent without another
+// LLM call: subjects start with an imperative verb, share the plan's majority
+// capitalization and have no trailing period; scopes differing only in case
+// use one spelling (a configured scope's, if any); and commits with identical
+// subjects are told apart by their files, measured as conv (or the active
+// convention if nil) formats them. It returns a description of each change
+// made.
+func NormalizePlan(plan *types.CommitPlan, repoConfig *types.RepoConfig, conv convention.Convention) []string {
+	if plan == nil || len(plan.Commits) == 0 {
+		return nil
+	}
+
+	maxLength := defaultMaxMessageLength
+	var configured []string
+	if repoConfig != nil {
+		if repoConfig.MaxMessageLength > 0 {
+			maxLength = repoConfig.MaxMessageLength
+		}
+		for _, s := range repoConfig.Scopes {
+			configured = append(configured, s.Scope)
+		}
+	}
+
+	before := make([]string, len(plan.Commits))
+	for i, c := range plan.Commits {
+		before[i] = formatHeader(c)
+	}
+
+	alignScopes(plan.Commits, configured)
+
+	capitalize := majorityCapitalized(plan.Commits)
+	for i := range plan.Commits {
+		c := &plan.Commits[i]
+		c.Message = strings.TrimSpace(c.Message)
+		// Keep the ellipsis ValidateAndFix leaves on truncated messages
+		if strings.HasSuffix(c.Message, ".") && !strings.HasSuffix(c.Message, "...") {
+			c.Message = strings.TrimSuffix(c.Message, ".")
+		}
+		c.Message = imperative(c.Message)
+		c.Message = setInitialCase(c.Message, capitalize)
+	}
+
+	disambiguate(plan.Commits, maxLength, conv)
+
+	var changes []string
+	for i, c := range plan.Commits {
+		if after := formatHeader(c); after != before[i] {
+			changes = append(changes, fmt.Sprintf("%q → %q", before[i], after))
+		}
+	}
+	return changes
+}
+
+// formatHeader formats a planned commit as "type(scope): message".
+func formatHeader(c types.PlannedCommit) string {
+	if scope := scopeOf(c); scope != "" {
+		return fmt.Sprintf("%s(%s): %s", c.Type, scope, c.Message)
+	}
+	return fmt.Sprintf("%s: %s", c.Type, c.Message)
+}
+
+// alignScopes gives scopes that differ only in case a single spelling: the
+// configured one if it exists, otherwise the most common in the plan.
+func alignScopes(commits []types.PlannedCommit, configured []string) {
+	canonical := make(map[string]string)
+	for _, s := range configured {
+		canonical[strings.ToLower(s)] = s
+	}
+
+	counts := make(map[string]int)
+	for _, c := range commits {
+		if scope := scopeOf(c); scope != "" {
+			counts[scope]++
+		}
+	}
+
+	// Ties go to the spelling seen first
+	best := make(map[string]string)
+	for _, c := range commits {
+		scope := scopeOf(c)
+		key := strings.ToLower(scope)
+		if _, ok := canonical[key]; ok || scope == "" {
+			continue
+		}
+		if b, ok := best[key]; !ok || counts[scope] > counts[b] {
+			best[key] = scope
+		}
+	}
+	for key, scope := range best {
+		canonical[key] = scope
+	}
+
+	for i := range commits {
+		scope := scopeOf(commits[i])
+		if scope == "" {
+			continue
+		}
+		if spelling := canonical[strings.ToLower(scope)]; spelling != scope {
+			commits[i].Scope = &spelling
+		}
+	}
+}
+
+// imperative rewrites a leading "added"/"adds"/"adding" to "add".
+func imperative(message string) string {
+	word, rest, _ := strings.Cut(message, " ")
+	base, ok := imperativeForms[strings.ToLower(word)]
+	if !ok {
+		return message
+	}
+	if first, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(first) {
+		base = strings.ToUpper(base[:1]) + base[1:]
+	}
+	if rest == "" {
+		return base
+	}
+	return base + " " + rest
+}
+
+// majorityCapitalized reports whether most subjects start with a capital
+// letter; ties go to lowercase, the conventional commit style.
+func majorityCapitalized(commits []types.PlannedCommit) bool {
+	upper, lower := 0, 0
+	for _, c := range commits {
+		if !isPlainWord(firstWord(c.Message)) {
+			continue
+		}
+		if first, _ := utf8.DecodeRuneInString(c.Message); unicode.IsUpper(first) {
+			upper++
+		} else {
+			lower++
+		}
+	}
+	return upper > lower
+}
+
+// setInitialCase upper- or lowercases the first letter of message, leaving
+// acronyms and identifiers (README, iOS, getUser) alone.
+func setInitialCase(message string, capitalize bool) string {
+	if !isPlainWord(firstWord(message)) {
+		return message
+	}
+	first, size := utf8.DecodeRuneInString(message)
+	if capitalize {
+		return string(unicode.ToUpper(first)) + message[size:]
+	}
+	return string(unicode.ToLower(first)) + message[size:]
+}
+
+// firstWord returns the first space-separated word of s.
+func firstWord(s string) string {
+	word, _, _ := strings.Cut(s, " ")
+	return word
+}
+
+// isPlainWord reports whether word is letters with only the first possibly
+// uppercase, so changing its initial case doesn't change its meaning.
+func isPlainWord(word string) bool {
+	if word == "" {
+		return false
+	}
+	for i, r := range word {
+		if !unicode.IsLetter(r) || (i > 0 && unicode.IsUpper(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// disambiguate appends a distinguishing name to commits whose header repeats
+// an earlier commit's, e.g. "update config in api", keeping the subject as
+// conv formats it within maxLength.
+func disambiguate(commits []types.PlannedCommit, maxLength int, conv convention.Convention) {
+	seen := make(map[string]bool)
+	for i := range commits {
+		key := strings.ToLower(formatHeader(commits[i]))
+		if !seen[key] {
+			seen[key] = true
+			continue
+		}
+
+		if name := filesName(commits[i].Files); name != "" {
+			candidate := commits[i]
+			candidate.Message = fmt.Sprintf("%s in %s", commits[i].Message, name)
+			candidateKey := strings.ToLower(formatHeader(candidate))
+			if subjectLength(conv, candidate) <= maxLength && !seen[candidateKey] {
+				commits[i] = candidate
+				seen[candidateKey] = true
+			}
+		}
+	}
+}
+
+// filesName names a commit's files: the file's base name for one file, else
+// their deepest common directory's name.
+func filesName(files []string) string {
+	switch len(files) {
+	case 0:
+		return ""
+	case 1:
+		return path.Base(files[0])
+	}
+
+	common := path.Dir(files[0])
+	for _, f := range files[1:] {
+		for common != "." && common != "/" && !strings.HasPrefix(f, common+"/") {
+			common = path.Dir(common)
+		}
+	}
+	if common == "." || common == "/" {
+		return ""
+	}
+	return path.Base(common)
+}
@@ -0,0 +1,98 @@
+package planner
+
+import (
+	"testing"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func TestNormalizePlan(t *testing.T) {
+	api, apiUpper, web := "api", "API", "web"
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Scope: &api, Message: "Added retry to client.", Files: []string{"api/client.go"}},
+		{Type: "fix", Scope: &apiUpper, Message: "handles nil config", Files: []string{"api/config.go"}},
+		{Type: "chore", Scope: &web, Message: "update dependencies", Files: []string{"web/package.json"}},
+		{Type: "chore", Scope: &web, Message: "Update dependencies", Files: []string{"web/admin/package.json", "web/admin/lock.json"}},
+		{Type: "docs", Message: "README tweaks", Files: []string{"README.md"}},
+		{Type: "refactor", Message: "logging cleanup", Files: []string{"log.go"}},
+	}}
+
+	changes := NormalizePlan(plan, &types.RepoConfig{}, nil)
+
+	want := []string{
+		"feat(api): add retry to client",
+		"fix(api): handle nil config",
+		"chore(web): update dependencies",
+		"chore(web): update dependencies in admin",
+		"docs: README tweaks",
+		"refactor: logging cleanup",
+	}
+	for i, c := range plan.Commits {
+		if got := formatHeader(c); got != want[i] {
+			t.Errorf("commit %d = %q, want %q", i, got, want[i])
+		}
+	}
+	if len(changes) != 3 {
+		t.Errorf("expected 3 changes, got %d: %v", len(changes), changes)
+	}
+}
+
+func TestNormalizePlan_MajorityCapitalized(t *testing.T) {
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "Add export", Files: []string{"a.go"}},
+		{Type: "fix", Message: "Fixes import", Files: []string{"b.go"}},
+		{Type: "chore", Message: "bump deps", Files: []string{"go.mod"}},
+	}}
+
+	NormalizePlan(plan, nil, nil)
+
+	for i, want := range []string{"Add export", "Fix import", "Bump deps"} {
+		if plan.Commits[i].Message != want {
+			t.Errorf("commit %d message = %q, want %q", i, plan.Commits[i].Message, want)
+		}
+	}
+}
+
+func TestNormalizePlan_ConfiguredScopeSpelling(t *testing.T) {
+	lower := "ios"
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Scope: &lower, Message: "add widget", Files: []string{"ios/a.swift"}},
+	}}
+	config := &types.RepoConfig{Scopes: []types.ScopeConfig{{Path: "ios/", Scope: "iOS"}}}
+
+	NormalizePlan(plan, config, nil)
+
+	if got := scopeOf(plan.Commits[0]); got != "iOS" {
+		t.Errorf("scope = %q, want configured spelling iOS", got)
+	}
+}
+
+func TestNormalizePlan_KeepsTruncationEllipsis(t *testing.T) {
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add a very long message that was cut...", Files: []string{"a.go"}},
+	}}
+
+	if changes := NormalizePlan(plan, nil, nil); len(changes) != 0 {
+		t.Errorf("expected no changes, got %v", changes)
+	}
+}
+
+func TestImperative(t *testing.T) {
+	tests := map[string]string{
+		"added tests":         "add tests",
+		"Updated readme":      "Update readme",
+		"fixes crash":         "fix crash",
+		"dropped support":     "drop support",
+		"simplifies parser":   "simplify parser",
+		"removing dead code":  "remove dead code",
+		"made it faster":      "make it faster",
+		"testing helpers":     "testing helpers",
+		"add support":         "add support",
+		"prefixed error text": "prefixed error text",
+	}
+	for in, want := range tests {
+		if got := imperative(in); got != want {
+			t.Errorf("imperative(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
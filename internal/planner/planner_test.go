@@ -1,10 +1,17 @@
 package planner
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/dsswift/commit/internal/git"
 	"github.com/dsswift/commit/internal/testutil"
 	"github.com/dsswift/commit/pkg/types"
 )
@@ -122,6 +129,40 @@ func TestValidator_Validate_InvalidCommitType(t *testing.T) {
 	}
 }
 
+func TestValidator_Validate_BranchPolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(tmpDir, "file.go"), []byte("content"), 0644)
+
+	config := &types.RepoConfig{
+		BranchPolicies: []types.BranchPolicy{
+			{Branch: "release/*", Mode: "whitelist", Types: []string{"fix", "docs"}},
+		},
+	}
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add feature", Files: []string{"file.go"}},
+		},
+	}
+
+	validator := NewValidator(tmpDir, config, []string{"file.go"})
+	validator.SetBranch("release/1.2")
+
+	result := validator.Validate(plan)
+	if result.Valid {
+		t.Fatal("expected feat to be rejected on release branch")
+	}
+	if !testutil.ContainsString(result.Errors[0].Message, "release/1.2") {
+		t.Errorf("expected error to mention branch, got: %v", result.Errors)
+	}
+
+	// Same plan on an unrelated branch passes
+	validator.SetBranch("feature/login")
+	if result := validator.Validate(plan); !result.Valid {
+		t.Errorf("expected plan to be valid on feature branch, got: %v", result.Errors)
+	}
+}
+
 func TestValidator_Validate_MessageTooLong(t *testing.T) {
 	tmpDir, _ := os.MkdirTemp("", "planner-test-*")
 	defer os.RemoveAll(tmpDir) //nolint:errcheck // test cleanup
@@ -147,6 +188,80 @@ func TestValidator_Validate_MessageTooLong(t *testing.T) {
 	}
 }
 
+func TestValidator_Validate_CountsSubjectPrefix(t *testing.T) {
+	scope := "interactive-rebase"
+	commit := types.PlannedCommit{Type: "feat", Scope: &scope, Message: "support editing todo lines in place", Files: []string{"file.go"}}
+	validator := NewValidator(t.TempDir(), &types.RepoConfig{}, []string{"file.go"})
+
+	errs := validator.validateHeader("", commit)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "exceeds 50 chars: 61 chars") {
+		t.Errorf("expected the formatted subject to be over the limit, got %v", errs)
+	}
+
+	validator = NewValidator(t.TempDir(), &types.RepoConfig{MaxMessageLength: 72}, []string{"file.go"})
+	if errs := validator.validateHeader("", commit); len(errs) != 0 {
+		t.Errorf("expected the configured limit to apply, got %v", errs)
+	}
+}
+
+func TestFitSubject(t *testing.T) {
+	scope := "interactive-rebase"
+	tests := []struct {
+		name   string
+		commit types.PlannedCommit
+		want   string
+	}{
+		{"fits", types.PlannedCommit{Type: "feat", Message: "add todo editing"}, "add todo editing"},
+		{"word boundary", types.PlannedCommit{Type: "feat", Scope: &scope, Message: "support editing todo lines in place"}, "support editing todo..."},
+		{"trailing punctuation", types.PlannedCommit{Type: "fix", Message: "handle empty todo lists and missing files, stale locks"}, "handle empty todo lists and missing files..."},
+		{"one long word", types.PlannedCommit{Type: "feat", Scope: &scope, Message: "supercalifragilisticexpialidocious"}, "supercalifragilistice..."},
+		{"multibyte words", types.PlannedCommit{Type: "docs", Message: "übersetze die Anleitung für Benutzer ohne Vorkenntnisse"}, "übersetze die Anleitung für Benutzer ohne..."},
+		{"emoji skin tone", types.PlannedCommit{Type: "feat", Message: strings.Repeat("👍🏽", 30)}, strings.Repeat("👍🏽", 20) + "..."},
+		{"zero-width joiner", types.PlannedCommit{Type: "fix", Message: strings.Repeat("👨‍👩‍👧", 12)}, strings.Repeat("👨‍👩‍👧", 8) + "..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commit := tt.commit
+			fitSubject(nil, &commit, 50)
+			if commit.Message != tt.want {
+				t.Errorf("message = %q, want %q", commit.Message, tt.want)
+			}
+			if n := subjectLength(nil, commit); n > 50 {
+				t.Errorf("subject is %d chars, want at most 50", n)
+			}
+		})
+	}
+}
+
+func TestValidator_ValidateAndFix_CleansMessages(t *testing.T) {
+	scope := "api\n"
+	validator := NewValidator(t.TempDir(), &types.RepoConfig{}, []string{"api.go"})
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{{
+		Type:    "feat",
+		Scope:   &scope,
+		Message: " add parser\r\n\nwith \x00 tests\xff",
+		Body:    "line one\r\nline\x00 two\r\tindented\xfe",
+		Files:   []string{"api.go"},
+	}}}
+
+	fixed, result := validator.ValidateAndFix(plan)
+	if !result.Valid {
+		t.Fatalf("expected a valid plan, got %v", result.Errors)
+	}
+	c := fixed.Commits[0]
+	if c.Message != "add parser with tests\uFFFD" || *c.Scope != "api" {
+		t.Errorf("subject = %q, scope = %q", c.Message, *c.Scope)
+	}
+	if c.Body != "line one\nline two\n\tindented\uFFFD" {
+		t.Errorf("body = %q", c.Body)
+	}
+
+	// Plans that skip the fixes are still rejected
+	if result := validator.Validate(plan); result.Valid {
+		t.Error("expected a message with line breaks and NUL bytes to be invalid")
+	}
+}
+
 func TestValidator_Validate_EmptyMessage(t *testing.T) {
 	tmpDir, _ := os.MkdirTemp("", "planner-test-*")
 	defer os.RemoveAll(tmpDir) //nolint:errcheck // test cleanup
@@ -247,6 +362,42 @@ func TestValidator_Validate_DuplicateFiles(t *testing.T) {
 	}
 }
 
+func TestValidator_Validate_SkippedFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.MkdirAll(filepath.Join(tmpDir, "ui", "__snapshots__"), 0755)
+	_ = os.WriteFile(filepath.Join(tmpDir, "ui", "app.ts"), []byte("content"), 0644)
+	_ = os.WriteFile(filepath.Join(tmpDir, "ui", "__snapshots__", "app.snap"), []byte("content"), 0644)
+
+	validator := NewValidator(tmpDir, &types.RepoConfig{}, []string{"ui/app.ts", "ui/__snapshots__/app.snap"})
+	validator.SetPathspecs(append([]string{"ui"}, git.SkipPathspecs([]string{"**/*.snap"})...))
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add app", Files: []string{"ui/app.ts", "ui/__snapshots__/app.snap"}},
+		},
+	}
+
+	result := validator.Validate(plan)
+	if result.Valid || len(result.Errors) != 1 || !strings.Contains(result.Errors[0].Message, "skipped for this run") {
+		t.Errorf("expected one skipped-file error, got %v", result.Errors)
+	}
+}
+
+func TestValidationError_CommitIndex(t *testing.T) {
+	tests := map[string]int{
+		"commits[3].type":      3,
+		"commits[12].files[0]": 12,
+		"commits":              -1,
+		"plan":                 -1,
+	}
+	for field, want := range tests {
+		e := &ValidationError{Field: field}
+		if got := e.CommitIndex(); got != want {
+			t.Errorf("CommitIndex(%q) = %d, want %d", field, got, want)
+		}
+	}
+}
+
 func TestValidateAndFix_TruncatesLongMessage(t *testing.T) {
 	tmpDir, _ := os.MkdirTemp("", "planner-test-*")
 	defer os.RemoveAll(tmpDir) //nolint:errcheck // test cleanup
@@ -271,8 +422,11 @@ func TestValidateAndFix_TruncatesLongMessage(t *testing.T) {
 		t.Fatal("expected non-nil fixed plan")
 	}
 
-	if len(fixedPlan.Commits[0].Message) > 50 {
-		t.Errorf("expected message to be truncated, got length %d", len(fixedPlan.Commits[0].Message))
+	if n := subjectLength(nil, fixedPlan.Commits[0]); n > 50 {
+		t.Errorf("expected subject to be truncated, got length %d", n)
+	}
+	if want := "this message is way too long and exceeds..."; fixedPlan.Commits[0].Message != want {
+		t.Errorf("message = %q, want %q", fixedPlan.Commits[0].Message, want)
 	}
 
 	if !result.Valid {
@@ -541,3 +695,422 @@ type testError struct {
 func (e *testError) Error() string {
 	return e.msg
 }
+
+func TestPlanCache(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	cache, err := NewPlanCache(repoDir)
+	if err != nil {
+		t.Fatalf("NewPlanCache failed: %v", err)
+	}
+
+	key := CacheKey("tree", "openai", "false")
+	if _, ok := cache.Load(key); ok {
+		t.Fatal("expected empty cache to miss")
+	}
+
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add thing", Files: []string{"a.go"}},
+	}}
+	if err := cache.Save(key, plan); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, ok := cache.Load(key)
+	if !ok || len(loaded.Commits) != 1 || loaded.Commits[0].Message != "add thing" {
+		t.Errorf("unexpected cached plan: %+v, %v", loaded, ok)
+	}
+
+	if _, ok := cache.Load(CacheKey("tree", "openai", "true")); ok {
+		t.Error("expected a different key to miss")
+	}
+
+	if err := cache.Clear(); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, ok := cache.Load(key); ok {
+		t.Error("expected cleared cache to miss")
+	}
+}
+
+func TestPlanCache_LoadDelta(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+	cache, err := NewPlanCache(repoDir)
+	if err != nil {
+		t.Fatalf("NewPlanCache failed: %v", err)
+	}
+
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add api", Files: []string{"api/a.go", "api/b.go"}},
+		{Type: "docs", Message: "update readme", Files: []string{"README.md"}},
+		{Type: "test", Message: "add web tests", Files: []string{"web/a_test.go"}},
+	}}
+	state := &TreeState{Inputs: "inputs", Head: "head", Files: map[string]string{
+		"api/a.go": "1", "api/b.go": "2", "README.md": "3", "web/": "4",
+	}}
+	if err := cache.SaveState("key", plan, state); err != nil {
+		t.Fatalf("SaveState failed: %v", err)
+	}
+
+	// README.md changed, lib/c.go is new and api/b.go is no longer changed
+	next := &TreeState{Inputs: "inputs", Head: "head", Files: map[string]string{
+		"api/a.go": "1", "README.md": "5", "web/": "4", "lib/c.go": "6",
+	}}
+	if _, ok := cache.LoadDelta(next); ok {
+		t.Error("expected half the files changed to need a full analysis")
+	}
+
+	delete(next.Files, "lib/c.go")
+	delta, ok := cache.LoadDelta(next)
+	if !ok {
+		t.Fatal("expected a delta")
+	}
+	if !reflect.DeepEqual(delta.Changed, []string{"README.md"}) {
+		t.Errorf("Changed = %v, want [README.md]", delta.Changed)
+	}
+
+	want := []types.PlannedCommit{
+		{Type: "feat", Message: "add api", Files: []string{"api/a.go"}},
+		{Type: "test", Message: "add web tests", Files: []string{"web/a_test.go"}},
+	}
+	if !reflect.DeepEqual(delta.Plan.Commits, want) {
+		t.Errorf("delta plan = %+v, want %+v", delta.Plan.Commits, want)
+	}
+
+	next.Head = "other"
+	if _, ok := cache.LoadDelta(next); ok {
+		t.Error("expected a new HEAD to need a full analysis")
+	}
+}
+
+func TestMergeDelta(t *testing.T) {
+	delta := &PlanDelta{Plan: &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add api", Files: []string{"api/a.go"}},
+	}}}
+	changes := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "Add API", Files: []string{"api/b.go"}},
+		{Type: "docs", Message: "update readme", Files: []string{"README.md"}},
+	}}
+
+	merged := MergeDelta(delta, changes)
+	want := []types.PlannedCommit{
+		{Type: "feat", Message: "add api", Files: []string{"api/a.go", "api/b.go"}},
+		{Type: "docs", Message: "update readme", Files: []string{"README.md"}},
+	}
+	if !reflect.DeepEqual(merged.Commits, want) {
+		t.Errorf("MergeDelta() = %+v, want %+v", merged.Commits, want)
+	}
+	if len(delta.Plan.Commits[0].Files) != 1 {
+		t.Error("MergeDelta modified the cached plan")
+	}
+}
+
+func TestCheckFresh(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(tmpDir, "api.go"), []byte("content"), 0644)
+	plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add api", Files: []string{"api.go", "gen/a.go"}},
+	}}
+	before := &TreeState{Head: "abc", Files: map[string]string{"api.go": "1", "gen/": "2", "docs.md": "3"}}
+	validator := NewValidator(tmpDir, &types.RepoConfig{}, []string{"api.go", "gen/a.go"})
+
+	// Unplanned files may change freely
+	after := &TreeState{Head: "abc", Files: map[string]string{"api.go": "1", "gen/": "2", "docs.md": "4"}}
+	if err := CheckFresh(plan, before, after, validator, time.Hour); err != nil {
+		t.Errorf("expected a fresh plan, got %v", err)
+	}
+
+	// gen/a.go went away meanwhile
+	validator = NewValidator(tmpDir, &types.RepoConfig{}, []string{"api.go"})
+	after = &TreeState{Head: "def", Files: map[string]string{"api.go": "1", "gen/": "5", "docs.md": "3"}}
+	var stale *StalePlanError
+	if err := CheckFresh(plan, before, after, validator, time.Hour); !errors.As(err, &stale) {
+		t.Fatalf("expected a *StalePlanError, got %v", err)
+	}
+	if !stale.HeadMoved || !reflect.DeepEqual(stale.Changed, []string{"gen/"}) || len(stale.Errors) != 1 {
+		t.Errorf("stale = %+v, want HEAD moved, gen/ changed and gen/a.go missing", stale)
+	}
+	if want := "plan made 1h0m0s ago no longer matches the changes: HEAD moved, 1 planned files changed, 1 validation errors"; stale.Error() != want {
+		t.Errorf("Error() = %q, want %q", stale.Error(), want)
+	}
+}
+
+func TestValidator_MergeOverlappingCommits_KeepsOrder(t *testing.T) {
+	v := NewValidator(t.TempDir(), &types.RepoConfig{}, nil)
+	commits := []types.PlannedCommit{
+		{Type: "chore", Message: "first", Files: []string{"a"}},
+		{Type: "feat", Message: "second", Files: []string{"b"}},
+		{Type: "fix", Message: "third", Files: []string{"c", "d"}},
+		{Type: "fix", Message: "fourth", Files: []string{"d"}},
+		{Type: "docs", Message: "fifth", Files: []string{"e"}},
+	}
+	for range 20 {
+		merged := v.mergeOverlappingCommits(commits)
+		var messages []string
+		for _, c := range merged {
+			messages = append(messages, c.Message)
+		}
+		if want := []string{"first", "second", "third", "fifth"}; !reflect.DeepEqual(messages, want) {
+			t.Fatalf("merged commits = %v, want %v", messages, want)
+		}
+	}
+}
+
+func TestAddGitignoreCommit(t *testing.T) {
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add api", Files: []string{"api.go", ".gitignore"}},
+			{Type: "chore", Message: "ignore env files", Files: []string{".gitignore"}},
+		},
+	}
+
+	AddGitignoreCommit(plan)
+
+	if len(plan.Commits) != 2 {
+		t.Fatalf("expected 2 commits, got %d: %+v", len(plan.Commits), plan.Commits)
+	}
+	first := plan.Commits[0]
+	if first.Type != GitignoreCommitType || first.Message != GitignoreCommitMessage || len(first.Files) != 1 {
+		t.Errorf("unexpected gitignore commit: %+v", first)
+	}
+	if files := plan.Commits[1].Files; len(files) != 1 || files[0] != "api.go" {
+		t.Errorf("expected .gitignore removed from feat commit, got %v", files)
+	}
+
+	// Re-applying (e.g. to a cached plan) doesn't add a second commit
+	AddGitignoreCommit(plan)
+	if len(plan.Commits) != 2 {
+		t.Errorf("expected idempotent result, got %d commits", len(plan.Commits))
+	}
+
+	empty := &types.CommitPlan{}
+	AddGitignoreCommit(empty)
+	if len(empty.Commits) != 1 {
+		t.Errorf("expected the gitignore commit alone, got %+v", empty.Commits)
+	}
+}
+
+func TestAddGeneratedFiles(t *testing.T) {
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add api", Files: []string{"api.go", "api.pb.go"}},
+			{Type: "fix", Message: "regenerate mocks", Files: []string{"mock_api.go"}},
+		},
+	}
+	generated := []string{"api.pb.go", "mock_api.go"}
+
+	AddGeneratedFiles(plan, generated, false)
+
+	if len(plan.Commits) != 2 {
+		t.Fatalf("expected 2 commits, got %d: %+v", len(plan.Commits), plan.Commits)
+	}
+	if files := plan.Commits[0].Files; len(files) != 1 || files[0] != "api.go" {
+		t.Errorf("expected generated file removed from feat commit, got %v", files)
+	}
+	gen := plan.Commits[1]
+	if gen.Type != GeneratedCommitType || gen.Message != GeneratedCommitMessage || len(gen.Files) != 2 {
+		t.Errorf("unexpected generated commit: %+v", gen)
+	}
+
+	// Re-applying (e.g. to a cached plan) doesn't add a second commit
+	AddGeneratedFiles(plan, generated, false)
+	if len(plan.Commits) != 2 {
+		t.Errorf("expected idempotent result, got %d commits", len(plan.Commits))
+	}
+
+	single := &types.CommitPlan{
+		Commits: []types.PlannedCommit{{Type: "feat", Message: "add api", Files: []string{"api.go"}}},
+	}
+	AddGeneratedFiles(single, generated, true)
+	if len(single.Commits) != 1 || len(single.Commits[0].Files) != 3 {
+		t.Errorf("expected generated files folded into single commit, got %+v", single.Commits)
+	}
+}
+
+func TestDetectMassDeletion(t *testing.T) {
+	var headFiles []string
+	for i := 0; i < 10; i++ {
+		headFiles = append(headFiles, fmt.Sprintf("legacy/old/f%d.go", i), fmt.Sprintf("src/f%d.go", i))
+	}
+	deleted := []string{"src/f0.go"}
+	for i := 0; i < 8; i++ {
+		deleted = append(deleted, fmt.Sprintf("legacy/old/f%d.go", i))
+	}
+
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "chore", Message: "remove legacy", Files: deleted[1:]},
+			{Type: "fix", Message: "drop helper", Files: []string{"src/f0.go", "src/f1.go"}},
+		},
+	}
+
+	m := DetectMassDeletion(plan, deleted, headFiles, types.MassDeleteConfig{})
+	if m == nil {
+		t.Fatal("expected a mass deletion")
+	}
+	if len(m.Files) != 9 || m.FileLimitExceeded {
+		t.Errorf("Files = %d, FileLimitExceeded = %v", len(m.Files), m.FileLimitExceeded)
+	}
+	// legacy/old is nested in legacy, so only the outer directory is reported
+	if len(m.Directories) != 1 || m.Directories[0] != (DirectoryDeletion{Dir: "legacy", Deleted: 8, Total: 10}) {
+		t.Errorf("Directories = %+v", m.Directories)
+	}
+
+	m.AnnotateCommits(plan)
+	if want := "Deleted files: 8\n- legacy/: 8 of 10 files"; plan.Commits[0].Body != want {
+		t.Errorf("Body = %q, want %q", plan.Commits[0].Body, want)
+	}
+	if plan.Commits[1].Body != "Deleted files: 1" {
+		t.Errorf("Body = %q", plan.Commits[1].Body)
+	}
+
+	// Raised thresholds allow the same plan
+	if m := DetectMassDeletion(plan, deleted, headFiles, types.MassDeleteConfig{MaxPercent: 90}); m != nil {
+		t.Errorf("expected no mass deletion under a 90%% limit, got %+v", m)
+	}
+
+	// The file count alone can trigger the guard
+	m = DetectMassDeletion(plan, deleted, headFiles, types.MassDeleteConfig{MaxFiles: 5, MaxPercent: 90})
+	if m == nil || !m.FileLimitExceeded || len(m.Directories) != 0 {
+		t.Errorf("expected file limit exceeded, got %+v", m)
+	}
+}
+
+func TestDetectMassDeletion_RemovedDirectory(t *testing.T) {
+	headFiles := []string{"main.go"}
+	for i := 0; i < 30; i++ {
+		headFiles = append(headFiles, fmt.Sprintf("vendor/lib/f%d.go", i))
+	}
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "chore", Message: "remove vendored lib", Files: []string{"vendor/"}},
+		},
+	}
+
+	m := DetectMassDeletion(plan, []string{"vendor/"}, headFiles, types.MassDeleteConfig{})
+	if m == nil || len(m.Files) != 30 || len(m.Directories) != 1 || m.Directories[0].Dir != "vendor" {
+		t.Fatalf("expected vendor/ counted file by file, got %+v", m)
+	}
+	m.AnnotateCommits(plan)
+	if !strings.HasPrefix(plan.Commits[0].Body, "Deleted files: 30") {
+		t.Errorf("Body = %q", plan.Commits[0].Body)
+	}
+}
+
+func TestAddTrailers(t *testing.T) {
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add api", Files: []string{"api.go"}},
+			{Type: "chore", Message: "remove legacy", Files: []string{"old.go"}, Body: "Deleted files: 1"},
+		},
+	}
+	meta := PlanMetadata{Version: "1.2.3", PlanID: "20260101-abc", Reviewed: true}
+
+	// Nothing is added unless enabled
+	AddTrailers(plan, types.TrailerConfig{}, meta)
+	if plan.Commits[0].Body != "" {
+		t.Errorf("expected no trailers by default, got %q", plan.Commits[0].Body)
+	}
+
+	AddTrailers(plan, types.TrailerConfig{Version: true, PlanID: true, ReviewedPlan: true}, meta)
+	want := "Commit-Tool-Version: 1.2.3\nCommit-Plan-Id: 20260101-abc\nReviewed-Plan: true"
+	if plan.Commits[0].Body != want {
+		t.Errorf("Body = %q, want %q", plan.Commits[0].Body, want)
+	}
+	if plan.Commits[1].Body != "Deleted files: 1\n\n"+want {
+		t.Errorf("expected trailers after the existing body, got %q", plan.Commits[1].Body)
+	}
+}
+
+func TestAddTrailers_ChangeID(t *testing.T) {
+	plan := &types.CommitPlan{
+		Commits: []types.PlannedCommit{
+			{Type: "feat", Message: "add api", Files: []string{"api.go"}},
+			{Type: "chore", Message: "remove legacy", Files: []string{"old.go"}},
+		},
+	}
+	meta := PlanMetadata{Version: "1.2.3", PlanID: "20260101-abc"}
+
+	AddTrailers(plan, types.TrailerConfig{Version: true, ChangeID: true}, meta)
+
+	changeID := regexp.MustCompile(`^Commit-Tool-Version: 1\.2\.3\nChange-Id: I[0-9a-f]{40}$`)
+	for i, c := range plan.Commits {
+		if !changeID.MatchString(c.Body) {
+			t.Errorf("commit %d: Body = %q, want the version then a Change-Id", i, c.Body)
+		}
+	}
+	if plan.Commits[0].Body == plan.Commits[1].Body {
+		t.Error("expected each commit to get its own Change-Id")
+	}
+
+	// The same commit in another run is another change
+	c := types.PlannedCommit{Type: "feat", Message: "add api", Files: []string{"api.go"}}
+	if ChangeID("run-1", c) != ChangeID("run-1", c) || ChangeID("run-1", c) == ChangeID("run-2", c) {
+		t.Error("expected Change-Ids to be stable within a run and differ across runs")
+	}
+}
+
+func TestValidator_Validate_ScopePolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	_ = os.WriteFile(filepath.Join(tmpDir, "file.go"), []byte("content"), 0644)
+	api := "api"
+
+	tests := []struct {
+		policy string
+		scope  *string
+		valid  bool
+	}{
+		{types.ScopePolicyRequired, &api, true},
+		{types.ScopePolicyRequired, nil, false},
+		{types.ScopePolicyOptional, nil, true},
+		{types.ScopePolicyOptional, &api, true},
+		{types.ScopePolicyForbidden, nil, true},
+		{types.ScopePolicyForbidden, &api, false},
+	}
+	for _, tt := range tests {
+		validator := NewValidator(tmpDir, &types.RepoConfig{ScopePolicy: tt.policy}, []string{"file.go"})
+		plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+			{Type: "feat", Scope: tt.scope, Message: "add feature", Files: []string{"file.go"}},
+		}}
+		if result := validator.Validate(plan); result.Valid != tt.valid {
+			t.Errorf("policy %q, scope %v: valid = %v, want %v (errors: %v)", tt.policy, tt.scope != nil, result.Valid, tt.valid, result.Errors)
+		}
+	}
+}
+
+func TestValidateAndFix_ScopePolicy(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, f := range []string{"api/handler.go", "api/routes.go", "web/page.go"} {
+		_ = os.MkdirAll(filepath.Join(tmpDir, filepath.Dir(f)), 0755)
+		_ = os.WriteFile(filepath.Join(tmpDir, f), []byte("content"), 0644)
+	}
+	known := []string{"api/handler.go", "api/routes.go", "web/page.go"}
+
+	// Required: a scope is inferred when all files share one
+	validator := NewValidator(tmpDir, &types.RepoConfig{ScopePolicy: types.ScopePolicyRequired}, known)
+	fixed, result := validator.ValidateAndFix(&types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add routes", Files: []string{"api/handler.go", "api/routes.go"}},
+		{Type: "feat", Message: "add page", Files: []string{"web/page.go"}},
+	}})
+	if !result.Valid || scopeOf(fixed.Commits[0]) != "api" || scopeOf(fixed.Commits[1]) != "web" {
+		t.Errorf("required: scopes = %q, %q, errors %v", scopeOf(fixed.Commits[0]), scopeOf(fixed.Commits[1]), result.Errors)
+	}
+
+	// Files in different areas leave the commit invalid
+	_, result = validator.ValidateAndFix(&types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add page", Files: []string{"api/handler.go", "web/page.go"}},
+	}})
+	if result.Valid {
+		t.Error("required: expected an error when no scope can be inferred")
+	}
+
+	// Forbidden: scopes are dropped
+	api := "api"
+	validator = NewValidator(tmpDir, &types.RepoConfig{ScopePolicy: types.ScopePolicyForbidden}, known)
+	fixed, result = validator.ValidateAndFix(&types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Scope: &api, Message: "add routes", Files: []string{"api/routes.go"}},
+	}})
+	if !result.Valid || fixed.Commits[0].Scope != nil {
+		t.Errorf("forbidden: scope = %q, errors %v", scopeOf(fixed.Commits[0]), result.Errors)
+	}
+}
@@ -0,0 +1,79 @@
+package planner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// DefaultStaleAfter is how old a plan can get, e.g. while a prompt waits for
+// an answer, before the changes are re-checked ahead of committing it.
+const DefaultStaleAfter = 10 * time.Minute
+
+// CheckFresh re-checks a plan made from before against the tree as it is
+// now: after holds the same files' current state and validator knows the
+// files changed now. It returns a *StalePlanError if HEAD moved, a planned
+// file changed or the plan no longer validates, so committing it would
+// record something other than what was analyzed.
+func CheckFresh(plan *types.CommitPlan, before, after *TreeState, validator *Validator, age time.Duration) error {
+	stale := &StalePlanError{
+		Age:       age,
+		HeadMoved: before.Head != after.Head,
+		Changed:   StaleFiles(plan, before, after),
+	}
+	if result := validator.Validate(plan); !result.Valid {
+		stale.Errors = result.Errors
+	}
+	if !stale.HeadMoved && len(stale.Changed) == 0 && len(stale.Errors) == 0 {
+		return nil
+	}
+	return stale
+}
+
+// StaleFiles returns the planned files whose state differs between before,
+// the tree the plan was made from, and after, sorted. Files before doesn't
+// cover aren't compared.
+func StaleFiles(plan *types.CommitPlan, before, after *TreeState) []string {
+	seen := make(map[string]bool)
+	var stale []string
+	for _, commit := range plan.Commits {
+		for _, f := range commit.Files {
+			entry, ok := stateEntry(before.Files, f)
+			if !ok || seen[entry] {
+				continue
+			}
+			seen[entry] = true
+			if after.Files[entry] != before.Files[entry] {
+				stale = append(stale, entry)
+			}
+		}
+	}
+	sort.Strings(stale)
+	return stale
+}
+
+// StalePlanError indicates the tree changed while a plan waited to be
+// committed.
+type StalePlanError struct {
+	Age       time.Duration
+	HeadMoved bool              // something was committed or checked out meanwhile
+	Changed   []string          // planned files edited since the analysis
+	Errors    []ValidationError // the plan checked against the current changes
+}
+
+func (e *StalePlanError) Error() string {
+	var reasons []string
+	if e.HeadMoved {
+		reasons = append(reasons, "HEAD moved")
+	}
+	if len(e.Changed) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d planned files changed", len(e.Changed)))
+	}
+	if len(e.Errors) > 0 {
+		reasons = append(reasons, fmt.Sprintf("%d validation errors", len(e.Errors)))
+	}
+	return fmt.Sprintf("plan made %s ago no longer matches the changes: %s", e.Age.Round(time.Second), strings.Join(reasons, ", "))
+}
@@ -0,0 +1,73 @@
+package planner
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// Metadata trailer keys.
+const (
+	TrailerVersion      = "Commit-Tool-Version"
+	TrailerPlanID       = "Commit-Plan-Id"
+	TrailerReviewedPlan = "Reviewed-Plan"
+	TrailerChangeID     = "Change-Id"
+)
+
+// PlanMetadata describes how a plan was produced, for commit trailers.
+type PlanMetadata struct {
+	Version string
+	PlanID  string
+	// Reviewed is set when the user saw the plan before it was committed
+	Reviewed bool
+}
+
+// AddTrailers appends the trailers enabled in cfg to every commit's body.
+func AddTrailers(plan *types.CommitPlan, cfg types.TrailerConfig, meta PlanMetadata) {
+	var trailers []string
+	if cfg.Version {
+		trailers = append(trailers, fmt.Sprintf("%s: %s", TrailerVersion, meta.Version))
+	}
+	if cfg.PlanID {
+		trailers = append(trailers, fmt.Sprintf("%s: %s", TrailerPlanID, meta.PlanID))
+	}
+	if cfg.ReviewedPlan {
+		trailers = append(trailers, fmt.Sprintf("%s: %s", TrailerReviewedPlan, strconv.FormatBool(meta.Reviewed)))
+	}
+	if len(trailers) == 0 && !cfg.ChangeID {
+		return
+	}
+
+	// Trailers must be the message's last paragraph
+	for i := range plan.Commits {
+		block := trailers
+		if cfg.ChangeID {
+			block = append(slices.Clone(trailers), fmt.Sprintf("%s: %s", TrailerChangeID, ChangeID(meta.PlanID, plan.Commits[i])))
+		}
+		appendBody(&plan.Commits[i], strings.Join(block, "\n"))
+	}
+}
+
+// ChangeID returns a Gerrit Change-Id for commit c of the plan planID: "I"
+// followed by 40 hex digits, unique to the run and the commit's contents.
+func ChangeID(planID string, c types.PlannedCommit) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", planID, formatHeader(c), c.Body)
+	for _, file := range c.Files {
+		fmt.Fprintf(h, "%s\n", file)
+	}
+	return fmt.Sprintf("I%x", h.Sum(nil))
+}
+
+// appendBody adds paragraph to the end of a commit's body.
+func appendBody(c *types.PlannedCommit, paragraph string) {
+	if c.Body != "" {
+		c.Body += "\n\n" + paragraph
+	} else {
+		c.Body = paragraph
+	}
+}
@@ -6,8 +6,13 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 
 	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/internal/git"
 	"github.com/dsswift/commit/pkg/types"
 )
 
@@ -16,6 +21,10 @@ type Validator struct {
 	workDir    string
 	repoConfig *types.RepoConfig
 	knownFiles map[string]bool
+	branch     string
+	pathspecs  []string
+	merged     []string
+	conv       convention.Convention
 }
 
 // NewValidator creates a new validator.
@@ -32,6 +41,62 @@ func NewValidator(workDir string, repoConfig *types.RepoConfig, knownFiles []str
 	}
 }
 
+// SetBranch sets the current branch used to evaluate branch policies.
+func (v *Validator) SetBranch(branch string) {
+	v.branch = branch
+}
+
+// SetPathspecs restricts plan files to the given pathspecs (scoped runs).
+func (v *Validator) SetPathspecs(pathspecs []string) {
+	v.pathspecs = pathspecs
+}
+
+// SetConvention sets the convention subjects are checked in. Without one the
+// active convention is used.
+func (v *Validator) SetConvention(conv convention.Convention) {
+	v.conv = conv
+}
+
+// convention returns the convention subjects are checked in.
+func (v *Validator) convention() convention.Convention {
+	if v.conv == nil {
+		return convention.Active()
+	}
+	return v.conv
+}
+
+// MergedDuplicates describes the near-duplicate commits the last
+// ValidateAndFix merged because of the mergeNearDuplicates setting.
+func (v *Validator) MergedDuplicates() []string {
+	return v.merged
+}
+
+// isSkipped reports whether file is left out of the run by a skip pathspec.
+func (v *Validator) isSkipped(file string) bool {
+	_, skips := git.SplitSkipPathspecs(v.pathspecs)
+	for _, pattern := range skips {
+		if git.MatchGlob(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// inScope reports whether file falls under the validator's pathspecs.
+func (v *Validator) inScope(file string) bool {
+	include, _ := git.SplitSkipPathspecs(v.pathspecs)
+	if len(include) == 0 {
+		return true
+	}
+	for _, p := range include {
+		p = strings.TrimSuffix(p, "/")
+		if p == "." || file == p || strings.HasPrefix(file, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidationError represents a plan validation failure.
 type ValidationError struct {
 	Field   string
@@ -42,6 +107,16 @@ func (e *ValidationError) Error() string {
 	return fmt.Sprintf("validation error in %s: %s", e.Field, e.Message)
 }
 
+// CommitIndex returns the index of the commit the error refers to, or -1
+// when it concerns the plan as a whole.
+func (e *ValidationError) CommitIndex() int {
+	var index int
+	if _, err := fmt.Sscanf(e.Field, "commits[%d]", &index); err != nil {
+		return -1
+	}
+	return index
+}
+
 // ValidationResult contains the outcome of plan validation.
 type ValidationResult struct {
 	Valid  bool
@@ -73,34 +148,10 @@ func (v *Validator) Validate(plan *types.CommitPlan) *ValidationResult {
 	seenFiles := make(map[string]bool)
 
 	for i, commit := range plan.Commits {
-		// Validate commit type
-		if commit.Type == "" {
-			result.Valid = false
-			result.Errors = append(result.Errors, ValidationError{
-				Field:   fmt.Sprintf("commits[%d].type", i),
-				Message: "commit type is empty",
-			})
-		} else if !v.repoConfig.IsTypeAllowed(commit.Type) {
+		// Validate type and message
+		if errs := v.validateHeader(fmt.Sprintf("commits[%d].", i), commit); len(errs) > 0 {
 			result.Valid = false
-			result.Errors = append(result.Errors, ValidationError{
-				Field:   fmt.Sprintf("commits[%d].type", i),
-				Message: fmt.Sprintf("commit type %q not allowed (allowed: %v)", commit.Type, v.repoConfig.AllowedTypes()),
-			})
-		}
-
-		// Validate message
-		if commit.Message == "" {
-			result.Valid = false
-			result.Errors = append(result.Errors, ValidationError{
-				Field:   fmt.Sprintf("commits[%d].message", i),
-				Message: "commit message is empty",
-			})
-		} else if len(commit.Message) > 50 {
-			result.Valid = false
-			result.Errors = append(result.Errors, ValidationError{
-				Field:   fmt.Sprintf("commits[%d].message", i),
-				Message: fmt.Sprintf("commit message exceeds 50 chars: %d chars", len(commit.Message)),
-			})
+			result.Errors = append(result.Errors, errs...)
 		}
 
 		// Validate files
@@ -123,11 +174,30 @@ func (v *Validator) Validate(plan *types.CommitPlan) *ValidationResult {
 				continue
 			}
 
+			if v.isSkipped(file) {
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   fmt.Sprintf("commits[%d].files[%d]", i, j),
+					Message: fmt.Sprintf("file skipped for this run: %s", file),
+				})
+				continue
+			}
+
+			if !v.inScope(file) {
+				result.Valid = false
+				result.Errors = append(result.Errors, ValidationError{
+					Field:   fmt.Sprintf("commits[%d].files[%d]", i, j),
+					Message: fmt.Sprintf("file outside requested paths: %s", file),
+				})
+				continue
+			}
+
 			// Check if file is in known files list
 			if !v.knownFiles[file] {
-				// Also check if file exists on disk (might be untracked)
+				// Also check if file exists on disk (might be untracked, or
+				// a symlink whose target doesn't exist)
 				fullPath := filepath.Join(v.workDir, file)
-				if _, err := os.Stat(fullPath); os.IsNotExist(err) {
+				if _, err := os.Lstat(fullPath); os.IsNotExist(err) {
 					result.Valid = false
 					result.Errors = append(result.Errors, ValidationError{
 						Field:   fmt.Sprintf("commits[%d].files[%d]", i, j),
@@ -151,6 +221,205 @@ func (v *Validator) Validate(plan *types.CommitPlan) *ValidationResult {
 	return result
 }
 
+// validateHeader checks a commit's type, scope and message, prefixing error
+// fields with prefix (e.g. "commits[0].").
+func (v *Validator) validateHeader(prefix string, commit types.PlannedCommit) []ValidationError {
+	errs := append(v.validateType(prefix, commit), v.validateScopePolicy(prefix, commit)...)
+	return append(errs, validateText(prefix, commit, v.maxSubjectLength(), v.conv)...)
+}
+
+// maxSubjectLength returns the repo's subject length limit, or the default.
+func (v *Validator) maxSubjectLength() int {
+	if v.repoConfig != nil && v.repoConfig.MaxMessageLength > 0 {
+		return v.repoConfig.MaxMessageLength
+	}
+	return defaultMaxMessageLength
+}
+
+// validateScopePolicy checks that a commit has a scope when the repo requires
+// one, and none when the repo forbids them.
+func (v *Validator) validateScopePolicy(prefix string, commit types.PlannedCommit) []ValidationError {
+	scope := scopeOf(commit)
+	switch {
+	case v.repoConfig.ScopePolicy == types.ScopePolicyRequired && scope == "":
+		return []ValidationError{{
+			Field:   prefix + "scope",
+			Message: "commit has no scope (scopePolicy is required)",
+		}}
+	case v.repoConfig.ScopePolicy == types.ScopePolicyForbidden && scope != "":
+		return []ValidationError{{
+			Field:   prefix + "scope",
+			Message: fmt.Sprintf("commit has scope %q (scopePolicy is forbidden)", scope),
+		}}
+	}
+	return nil
+}
+
+// inferScope returns the scope all of files share: the configured one, or
+// failing that the one suggested by their directory. It returns "" when the
+// files disagree.
+func (v *Validator) inferScope(files []string) string {
+	for _, scopeFor := range []func(string) string{
+		func(f string) string { return config.ResolveScope(f, v.repoConfig) },
+		config.SuggestScope,
+	} {
+		scope := ""
+		for i, file := range files {
+			s := scopeFor(file)
+			if s == "" || (i > 0 && s != scope) {
+				scope = ""
+				break
+			}
+			scope = s
+		}
+		if scope != "" {
+			return scope
+		}
+	}
+	return ""
+}
+
+// validateType checks that a commit's type is set and allowed, including by
+// branch policies.
+func (v *Validator) validateType(prefix string, commit types.PlannedCommit) []ValidationError {
+	var errs []ValidationError
+
+	if commit.Type == "" {
+		errs = append(errs, ValidationError{
+			Field:   prefix + "type",
+			Message: "commit type is empty",
+		})
+	} else if !v.repoConfig.IsTypeAllowed(commit.Type) {
+		errs = append(errs, ValidationError{
+			Field:   prefix + "type",
+			Message: fmt.Sprintf("commit type %q not allowed (allowed: %v)", commit.Type, v.repoConfig.AllowedTypes()),
+		})
+	} else if policy := v.repoConfig.BranchPolicyViolation(commit.Type, v.branch); policy != nil {
+		errs = append(errs, ValidationError{
+			Field:   prefix + "type",
+			Message: fmt.Sprintf("commit type %q not allowed on branch %q (policy %q %s: %v)", commit.Type, v.branch, policy.Branch, policy.Mode, policy.Types),
+		})
+	}
+	return errs
+}
+
+// validateText checks that a commit's message is set and that its subject,
+// formatted by conv (or the active convention if nil) with its type and
+// scope, fits in maxLength characters.
+func validateText(prefix string, commit types.PlannedCommit, maxLength int, conv types.SubjectFormatter) []ValidationError {
+	var errs []ValidationError
+
+	if commit.Message == "" {
+		errs = append(errs, ValidationError{
+			Field:   prefix + "message",
+			Message: "commit message is empty",
+		})
+	} else if !isCleanSubject(commit.Message) {
+		errs = append(errs, ValidationError{
+			Field:   prefix + "message",
+			Message: fmt.Sprintf("commit message has invalid UTF-8, a line break or a control character: %q", commit.Message),
+		})
+	} else if n := subjectLength(conv, commit); n > maxLength {
+		errs = append(errs, ValidationError{
+			Field:   prefix + "message",
+			Message: fmt.Sprintf("commit subject exceeds %d chars: %d chars (%q)", maxLength, n, convention.FormatWith(conv, commit)),
+		})
+	}
+
+	return errs
+}
+
+// subjectLength returns the length in characters of commit's subject as conv
+// (or the active convention if nil) formats it, prefix included.
+func subjectLength(conv types.SubjectFormatter, commit types.PlannedCommit) int {
+	return utf8.RuneCountInString(convention.FormatWith(conv, commit))
+}
+
+// fitSubject shortens commit's message at a word boundary, ending it with
+// "...", so that its subject as conv formats it fits in maxLength characters.
+func fitSubject(conv types.SubjectFormatter, commit *types.PlannedCommit, maxLength int) {
+	message := []rune(commit.Message)
+	budget := maxLength - (subjectLength(conv, *commit) - len(message))
+	if len(message) <= budget {
+		return
+	}
+
+	room := budget - len("...")
+	if room <= 0 {
+		// No room for words; keep what fits
+		commit.Message = string(message[:clusterStart(message, max(budget, 0))])
+		return
+	}
+	cut := message[:clusterStart(message, room)]
+	if !unicode.IsSpace(message[room]) {
+		if i := lastSpace(cut); i > 0 {
+			cut = cut[:i]
+		}
+	}
+	commit.Message = strings.TrimRight(string(cut), " \t,;:-") + "..."
+}
+
+// clusterStart moves i back to the start of the character r[i] belongs to,
+// so that cutting r at i doesn't strip an accent, emoji modifier or
+// zero-width joiner off the character before it.
+func clusterStart(r []rune, i int) int {
+	for i > 0 && i < len(r) && (continuesCluster(r[i]) || r[i-1] == zeroWidthJoiner) {
+		i--
+	}
+	return i
+}
+
+const zeroWidthJoiner = '\u200d'
+
+// continuesCluster reports whether r attaches to the character before it:
+// a combining mark, variation selector, emoji skin tone or joiner.
+func continuesCluster(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Variation_Selector) ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) || r == zeroWidthJoiner
+}
+
+// cleanSubject makes s fit for a subject line: invalid UTF-8 becomes U+FFFD,
+// and runs of line breaks, control characters and spaces around them
+// become single spaces.
+func cleanSubject(s string) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	if strings.IndexFunc(s, unicode.IsControl) < 0 {
+		return s
+	}
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return unicode.IsControl(r) || unicode.IsSpace(r)
+	}), " ")
+}
+
+// isCleanSubject reports whether s is valid UTF-8 without control characters.
+func isCleanSubject(s string) bool {
+	return utf8.ValidString(s) && strings.IndexFunc(s, unicode.IsControl) < 0
+}
+
+// cleanBody makes s fit for a commit body: invalid UTF-8 becomes U+FFFD,
+// line endings become "\n" and control characters other than newlines and
+// tabs, which git would reject (NUL) or mangle, are dropped.
+func cleanBody(s string) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	s = strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s)
+	return strings.Map(func(r rune) rune {
+		if r != '\n' && r != '\t' && unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// lastSpace returns the index of the last whitespace rune in r, or -1.
+func lastSpace(r []rune) int {
+	for i := len(r) - 1; i >= 0; i-- {
+		if unicode.IsSpace(r[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
 // ValidateAndFix attempts to fix minor validation issues.
 // Returns the fixed plan and any remaining errors.
 func (v *Validator) ValidateAndFix(plan *types.CommitPlan) (*types.CommitPlan, *ValidationResult) {
@@ -169,15 +438,42 @@ func (v *Validator) ValidateAndFix(plan *types.CommitPlan) (*types.CommitPlan, *
 
 	// Fix truncatable issues
 	for i := range fixedPlan.Commits {
-		// Truncate overly long messages
-		if len(fixedPlan.Commits[i].Message) > 50 {
-			fixedPlan.Commits[i].Message = fixedPlan.Commits[i].Message[:47] + "..."
+		// Keep stray bytes and line breaks from the model out of the commit
+		c := &fixedPlan.Commits[i]
+		c.Message = cleanSubject(strings.TrimSpace(c.Message))
+		c.Body = cleanBody(c.Body)
+		if c.Scope != nil {
+			scope := cleanSubject(*c.Scope)
+			c.Scope = &scope
+		}
+
+		// Apply the scope policy where the files make the scope clear
+		switch v.repoConfig.ScopePolicy {
+		case types.ScopePolicyForbidden:
+			fixedPlan.Commits[i].Scope = nil
+		case types.ScopePolicyRequired:
+			if scopeOf(fixedPlan.Commits[i]) == "" {
+				if scope := v.inferScope(fixedPlan.Commits[i].Files); scope != "" {
+					fixedPlan.Commits[i].Scope = &scope
+				}
+			}
 		}
 	}
 
 	// Merge commits that share files
 	fixedPlan.Commits = v.mergeOverlappingCommits(fixedPlan.Commits)
 
+	// Merge commits that make the same change under different subjects
+	v.merged = nil
+	if v.repoConfig.MergeNearDuplicates {
+		fixedPlan.Commits, v.merged = MergeNearDuplicates(fixedPlan.Commits, NearDuplicates(fixedPlan.Commits))
+	}
+
+	// Shorten messages whose subjects, prefix included, run over the limit
+	for i := range fixedPlan.Commits {
+		fitSubject(v.conv, &fixedPlan.Commits[i], v.maxSubjectLength())
+	}
+
 	// Validate the fixed plan
 	result := v.Validate(fixedPlan)
 
@@ -230,16 +526,21 @@ func (v *Validator) mergeOverlappingCommits(commits []types.PlannedCommit) []typ
 		}
 	}
 
-	// Group commits by their root
+	// Group commits by their root, in the order the plan first mentions them
 	groups := make(map[int][]int)
+	var roots []int
 	for i := range commits {
 		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
 		groups[root] = append(groups[root], i)
 	}
 
 	// Build merged commits
 	var result []types.PlannedCommit
-	for _, indices := range groups {
+	for _, root := range roots {
+		indices := groups[root]
 		if len(indices) == 1 {
 			// No merge needed
 			result = append(result, commits[indices[0]])
@@ -337,6 +638,18 @@ func FilterSensitiveFiles(plan *types.CommitPlan) (filtered []string) {
 	return filtered
 }
 
+// SplitSensitiveFiles separates files matching sensitive patterns from the rest.
+func SplitSensitiveFiles(files []string) (safe, sensitive []string) {
+	for _, file := range files {
+		if isSensitiveFile(file) {
+			sensitive = append(sensitive, file)
+		} else {
+			safe = append(safe, file)
+		}
+	}
+	return safe, sensitive
+}
+
 // isSensitiveFile checks if a file matches sensitive patterns.
 func isSensitiveFile(file string) bool {
 	base := filepath.Base(file)
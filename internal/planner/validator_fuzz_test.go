@@ -0,0 +1,51 @@
+package planner
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func FuzzValidateAndFix(f *testing.F) {
+	// Seed with messages models have been seen to produce
+	f.Add("add endpoint", "api", "")
+	f.Add("fix `go vet` warnings in \"quoted\" 'strings'", "", "Body with `backticks` and $(whoami)")
+	f.Add("add parser\nwith tests", "api\n", "line one\r\nline two")
+	f.Add("ajoute la prise en charge des caractères accentués dans les résumés", "i18n", "")
+	f.Add(strings.Repeat("🎉", 60), "", strings.Repeat("👨‍👩‍👧 ", 100))
+	f.Add("é́́ stacked accents over the limit of fifty characters", "", "")
+	f.Add("invalid \xff\xfe bytes\x00", "sc\xc3ope", "nul\x00 body\xff")
+	f.Add("-n --amend # not a comment", "", "# kept\n\n\n\nafter blank lines")
+
+	f.Fuzz(func(t *testing.T, message, scope, body string) {
+		commit := types.PlannedCommit{Type: "feat", Message: message, Body: body, Files: []string{"a.go"}}
+		if scope != "" {
+			commit.Scope = &scope
+		}
+		validator := NewValidator(t.TempDir(), &types.RepoConfig{}, []string{"a.go"})
+
+		fixed, result := validator.ValidateAndFix(&types.CommitPlan{Commits: []types.PlannedCommit{commit}})
+		got := fixed.Commits[0]
+
+		if !isCleanSubject(got.Message) || (got.Scope != nil && !isCleanSubject(*got.Scope)) {
+			t.Fatalf("subject not cleaned: %q, scope %v", got.Message, got.Scope)
+		}
+		if !utf8.ValidString(got.Body) || strings.ContainsAny(got.Body, "\x00\r") {
+			t.Fatalf("body not cleaned: %q", got.Body)
+		}
+		if result.Valid && subjectLength(nil, got) > defaultMaxMessageLength {
+			t.Fatalf("valid subject is %d chars: %q", subjectLength(nil, got), got.Message)
+		}
+		if trimmed, ok := strings.CutSuffix(got.Message, "..."); ok && trimmed != "" {
+			if last, _ := utf8.DecodeLastRuneInString(trimmed); last == zeroWidthJoiner {
+				t.Fatalf("truncation left a dangling joiner: %q", got.Message)
+			}
+		}
+		if got.Message != "" && unicode.IsSpace([]rune(got.Message)[0]) {
+			t.Fatalf("subject starts with a space: %q", got.Message)
+		}
+	})
+}
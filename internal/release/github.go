@@ -0,0 +1,126 @@
+package release
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/httpclient"
+)
+
+const (
+	// GitHubAPIURL is the default GitHub API base URL.
+	GitHubAPIURL = "https://api.github.com"
+
+	// PublishTimeout is the timeout for creating a release.
+	PublishTimeout = 30 * time.Second
+)
+
+// githubRemotePattern matches SSH and HTTPS GitHub remote URLs.
+var githubRemotePattern = regexp.MustCompile(`github\.com[:/]([^/]+)/([^/]+?)(?:\.git)?/?$`)
+
+// ParseGitHubRemote extracts the owner and repository from a GitHub remote URL.
+func ParseGitHubRemote(remoteURL string) (owner, repo string, ok bool) {
+	m := githubRemotePattern.FindStringSubmatch(strings.TrimSpace(remoteURL))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// OriginRepo returns the GitHub owner and repository of the origin remote.
+func OriginRepo(gitRoot string) (owner, repo string, err error) {
+	cmd := git.Command("remote", "get-url", "origin")
+	cmd.Dir = gitRoot
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read origin remote: %w", err)
+	}
+
+	owner, repo, ok := ParseGitHubRemote(string(out))
+	if !ok {
+		return "", "", fmt.Errorf("origin is not a GitHub remote: %s", strings.TrimSpace(string(out)))
+	}
+	return owner, repo, nil
+}
+
+// Publisher creates GitHub releases.
+type Publisher struct {
+	token   string
+	baseURL string
+	client  *http.Client
+}
+
+// NewPublisher creates a GitHub release publisher. An empty baseURL uses GitHubAPIURL.
+func NewPublisher(token, baseURL string) *Publisher {
+	if baseURL == "" {
+		baseURL = GitHubAPIURL
+	}
+	return &Publisher{
+		token:   token,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  httpclient.NewClient(PublishTimeout),
+	}
+}
+
+type createReleaseRequest struct {
+	TagName string `json:"tag_name"`
+	Name    string `json:"name"`
+	Body    string `json:"body"`
+	Draft   bool   `json:"draft"`
+}
+
+type createReleaseResponse struct {
+	HTMLURL string `json:"html_url"`
+}
+
+// PublishDraft creates a draft release for tag and returns its URL.
+// Drafts are never visible publicly until edited and published on GitHub.
+func (p *Publisher) PublishDraft(ctx context.Context, owner, repo string, notes *Notes) (string, error) {
+	body, err := json.Marshal(createReleaseRequest{
+		TagName: notes.Tag,
+		Name:    notes.Tag,
+		Body:    notes.Draft,
+		Draft:   true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/releases", p.baseURL, owner, repo)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "commit-tool")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to create release: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // HTTP response body
+
+	if resp.StatusCode != http.StatusCreated {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	var created createReleaseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&created); err != nil {
+		return "", fmt.Errorf("failed to parse release response: %w", err)
+	}
+
+	return created.HTMLURL, nil
+}
@@ -0,0 +1,75 @@
+package release
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseGitHubRemote(t *testing.T) {
+	tests := []struct {
+		url       string
+		owner     string
+		repo      string
+		wantMatch bool
+	}{
+		{"git@github.com:dsswift/commit.git", "dsswift", "commit", true},
+		{"https://github.com/dsswift/commit.git", "dsswift", "commit", true},
+		{"https://github.com/dsswift/commit", "dsswift", "commit", true},
+		{"ssh://git@github.com/dsswift/commit.git\n", "dsswift", "commit", true},
+		{"https://gitlab.com/dsswift/commit.git", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			owner, repo, ok := ParseGitHubRemote(tt.url)
+			if ok != tt.wantMatch || owner != tt.owner || repo != tt.repo {
+				t.Errorf("ParseGitHubRemote() = (%q, %q, %v), want (%q, %q, %v)", owner, repo, ok, tt.owner, tt.repo, tt.wantMatch)
+			}
+		})
+	}
+}
+
+func TestPublisher_PublishDraft(t *testing.T) {
+	var got createReleaseRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/dsswift/commit/releases" {
+			t.Errorf("unexpected path: %s", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			t.Errorf("unexpected auth header: %q", r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"html_url": "https://github.com/dsswift/commit/releases/tag/untagged-1"}`))
+	}))
+	defer server.Close()
+
+	publisher := NewPublisher("test-token", server.URL)
+	url, err := publisher.PublishDraft(context.Background(), "dsswift", "commit", &Notes{Tag: "v1.1.0", Draft: "notes"})
+	if err != nil {
+		t.Fatalf("PublishDraft failed: %v", err)
+	}
+
+	if url != "https://github.com/dsswift/commit/releases/tag/untagged-1" {
+		t.Errorf("unexpected url: %q", url)
+	}
+	if !got.Draft || got.TagName != "v1.1.0" || got.Body != "notes" {
+		t.Errorf("unexpected request: %+v", got)
+	}
+}
+
+func TestPublisher_PublishDraft_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"message": "Bad credentials"}`))
+	}))
+	defer server.Close()
+
+	_, err := NewPublisher("bad", server.URL).PublishDraft(context.Background(), "o", "r", &Notes{Tag: "v1"})
+	if err == nil {
+		t.Fatal("expected error for unauthorized response")
+	}
+}
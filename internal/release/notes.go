@@ -0,0 +1,289 @@
+// Package release drafts release notes from commit history.
+package release
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dsswift/commit/internal/assert"
+	"github.com/dsswift/commit/internal/git"
+)
+
+// MaxPromptCommits caps the number of commits sent to the LLM.
+const MaxPromptCommits = 300
+
+// Commit is a commit included in a release.
+type Commit struct {
+	Hash     string
+	Type     string // empty for non-conventional subjects
+	Scope    string
+	Subject  string // description without the type/scope prefix
+	Breaking bool
+}
+
+// Group is a set of commits sharing a type.
+type Group struct {
+	Type    string
+	Commits []Commit
+}
+
+// Notes holds the commits gathered for a release and the drafted text.
+type Notes struct {
+	Tag      string
+	PrevTag  string // empty when this is the first release
+	Groups   []Group
+	Breaking []Commit
+	Draft    string
+}
+
+// NotesProvider is the subset of an LLM provider used to draft notes.
+type NotesProvider interface {
+	AnalyzeDiff(ctx context.Context, system, user string) (string, error)
+}
+
+// conventionalPattern matches "type(scope)!: subject".
+var conventionalPattern = regexp.MustCompile(`^([a-z]+)(?:\(([^)]*)\))?(!)?:\s*(.+)$`)
+
+// typeOrder controls the order of groups in the notes.
+var typeOrder = []string{"feat", "fix", "perf", "refactor", "docs", "test", "style", "chore"}
+
+// ParseCommit parses a subject and body into a release commit.
+func ParseCommit(hash, subject, body string) Commit {
+	c := Commit{Hash: hash, Subject: subject}
+
+	if m := conventionalPattern.FindStringSubmatch(subject); m != nil {
+		c.Type = m[1]
+		c.Scope = m[2]
+		c.Breaking = m[3] == "!"
+		c.Subject = m[4]
+	}
+
+	if strings.Contains(body, "BREAKING CHANGE:") || strings.Contains(body, "BREAKING-CHANGE:") {
+		c.Breaking = true
+	}
+
+	return c
+}
+
+// Collector gathers commits for a release.
+type Collector struct {
+	gitRoot string
+}
+
+// NewCollector creates a new release collector for the given repository.
+func NewCollector(gitRoot string) *Collector {
+	return &Collector{gitRoot: gitRoot}
+}
+
+// Collect gathers the non-merge commits between the previous tag and tag.
+// If tag doesn't exist yet, HEAD is used as the end of the range so notes
+// can be drafted before tagging.
+func (c *Collector) Collect(tag string) (*Notes, error) {
+	assert.NotEmptyString(tag, "tag cannot be empty")
+
+	end := tag
+	if !c.refExists(tag) {
+		end = "HEAD"
+	}
+
+	notes := &Notes{Tag: tag, PrevTag: c.previousTag(end)}
+
+	rangeSpec := end
+	if notes.PrevTag != "" {
+		rangeSpec = notes.PrevTag + ".." + end
+	}
+
+	cmd := git.Command("log", "--no-merges", "--format=%h%x1f%s%x1f%b%x1e", rangeSpec)
+	cmd.Dir = c.gitRoot
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read commits for %s: %w", rangeSpec, err)
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(out), "\x1e") {
+		parts := strings.SplitN(strings.TrimSpace(record), "\x1f", 3)
+		if len(parts) < 2 {
+			continue
+		}
+		body := ""
+		if len(parts) == 3 {
+			body = parts[2]
+		}
+		commits = append(commits, ParseCommit(parts[0], parts[1], body))
+	}
+
+	notes.Groups = GroupCommits(commits)
+	for _, commit := range commits {
+		if commit.Breaking {
+			notes.Breaking = append(notes.Breaking, commit)
+		}
+	}
+
+	return notes, nil
+}
+
+// refExists reports whether ref resolves to a commit.
+func (c *Collector) refExists(ref string) bool {
+	cmd := git.Command("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	cmd.Dir = c.gitRoot
+	return cmd.Run() == nil
+}
+
+// previousTag returns the most recent tag reachable from before ref.
+func (c *Collector) previousTag(ref string) string {
+	// For an existing tag, start from its parent so the tag itself is skipped
+	start := ref
+	if ref != "HEAD" {
+		start = ref + "^"
+	}
+
+	cmd := git.Command("describe", "--tags", "--abbrev=0", start)
+	cmd.Dir = c.gitRoot
+
+	out, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// GroupCommits groups commits by type, ordered by typeOrder with unknown
+// types sorted alphabetically after them and non-conventional commits last.
+func GroupCommits(commits []Commit) []Group {
+	byType := make(map[string][]Commit)
+	for _, c := range commits {
+		byType[c.Type] = append(byType[c.Type], c)
+	}
+
+	rank := make(map[string]int, len(typeOrder))
+	for i, t := range typeOrder {
+		rank[t] = i
+	}
+
+	var types []string
+	for t := range byType {
+		types = append(types, t)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		a, b := types[i], types[j]
+		if (a == "") != (b == "") {
+			return b == ""
+		}
+		ra, aKnown := rank[a]
+		rb, bKnown := rank[b]
+		switch {
+		case aKnown && bKnown:
+			return ra < rb
+		case aKnown != bKnown:
+			return aKnown
+		default:
+			return a < b
+		}
+	})
+
+	groups := make([]Group, 0, len(types))
+	for _, t := range types {
+		groups = append(groups, Group{Type: t, Commits: byType[t]})
+	}
+	return groups
+}
+
+// BuildPrompt creates the LLM prompt for drafting release notes.
+func BuildPrompt(notes *Notes) (system, user string) {
+	system = `You are a release notes writer. Given grouped commits for a release, write
+polished release notes in Markdown.
+
+Rules:
+- Start with a short "Highlights" section of the 1-5 most notable user-facing changes
+- Follow with sections for features, fixes and other changes; omit empty sections
+- If any commits are marked BREAKING, add a "Breaking Changes" section explaining each
+- Rewrite commit subjects into clear, user-facing sentences; merge duplicates
+- Do not invent changes that are not in the list
+- Do not wrap output in markdown code blocks`
+
+	var b strings.Builder
+	if notes.PrevTag != "" {
+		fmt.Fprintf(&b, "Release %s (changes since %s)\n", notes.Tag, notes.PrevTag)
+	} else {
+		fmt.Fprintf(&b, "Release %s (first release)\n", notes.Tag)
+	}
+
+	count := 0
+	for _, group := range notes.Groups {
+		name := group.Type
+		if name == "" {
+			name = "other"
+		}
+		fmt.Fprintf(&b, "\n## %s\n", name)
+		for _, c := range group.Commits {
+			if count >= MaxPromptCommits {
+				break
+			}
+			count++
+
+			line := c.Subject
+			if c.Scope != "" {
+				line = fmt.Sprintf("(%s) %s", c.Scope, line)
+			}
+			if c.Breaking {
+				line += " [BREAKING]"
+			}
+			fmt.Fprintf(&b, "- %s %s\n", c.Hash, line)
+		}
+	}
+
+	if total := notes.CommitCount(); total > count {
+		fmt.Fprintf(&b, "\n... and %d more commits\n", total-count)
+	}
+
+	user = b.String()
+	return system, user
+}
+
+// CommitCount returns the number of commits in the release.
+func (n *Notes) CommitCount() int {
+	total := 0
+	for _, g := range n.Groups {
+		total += len(g.Commits)
+	}
+	return total
+}
+
+// Draft collects commits for tag and asks the provider for a notes draft.
+func Draft(ctx context.Context, gitRoot, tag string, provider NotesProvider) (*Notes, error) {
+	notes, err := NewCollector(gitRoot).Collect(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if notes.CommitCount() == 0 {
+		return nil, &NoCommitsError{Tag: tag, PrevTag: notes.PrevTag}
+	}
+
+	system, user := BuildPrompt(notes)
+	draft, err := provider.AnalyzeDiff(ctx, system, user)
+	if err != nil {
+		return nil, err
+	}
+
+	notes.Draft = strings.TrimSpace(draft)
+	return notes, nil
+}
+
+// NoCommitsError is returned when a release range contains no commits.
+type NoCommitsError struct {
+	Tag     string
+	PrevTag string
+}
+
+func (e *NoCommitsError) Error() string {
+	if e.PrevTag == "" {
+		return fmt.Sprintf("no commits found for %s", e.Tag)
+	}
+	return fmt.Sprintf("no commits between %s and %s", e.PrevTag, e.Tag)
+}
@@ -0,0 +1,144 @@
+package release
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/dsswift/commit/internal/testutil"
+)
+
+type mockProvider struct {
+	user string
+}
+
+func (m *mockProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	m.user = user
+	return "## Highlights\n- Added login\n", nil
+}
+
+func TestParseCommit(t *testing.T) {
+	tests := []struct {
+		subject string
+		body    string
+		want    Commit
+	}{
+		{"feat(auth): add login", "", Commit{Type: "feat", Scope: "auth", Subject: "add login"}},
+		{"fix: handle nil", "", Commit{Type: "fix", Subject: "handle nil"}},
+		{"refactor!: drop v1 API", "", Commit{Type: "refactor", Subject: "drop v1 API", Breaking: true}},
+		{"feat: new config", "BREAKING CHANGE: old keys removed", Commit{Type: "feat", Subject: "new config", Breaking: true}},
+		{"Update README", "", Commit{Subject: "Update README"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			got := ParseCommit("abc1234", tt.subject, tt.body)
+			tt.want.Hash = "abc1234"
+			if got != tt.want {
+				t.Errorf("ParseCommit() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupCommits_Order(t *testing.T) {
+	commits := []Commit{
+		{Type: "chore"}, {Type: ""}, {Type: "zzz"}, {Type: "fix"}, {Type: "feat"}, {Type: "build"}, {Type: "feat"},
+	}
+
+	groups := GroupCommits(commits)
+
+	var order []string
+	for _, g := range groups {
+		order = append(order, g.Type)
+	}
+	expected := []string{"feat", "fix", "chore", "build", "zzz", ""}
+	if strings.Join(order, ",") != strings.Join(expected, ",") {
+		t.Errorf("group order = %v, want %v", order, expected)
+	}
+	if len(groups[0].Commits) != 2 {
+		t.Errorf("expected 2 feat commits, got %d", len(groups[0].Commits))
+	}
+}
+
+func TestDraft_SincePreviousTag(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v failed: %s: %v", args, out, err)
+		}
+	}
+
+	testutil.CreateFile(t, repoDir, "a.txt", "a")
+	testutil.GitAdd(t, repoDir, "a.txt")
+	testutil.GitCommit(t, repoDir, "feat: first feature")
+	git("tag", "v1.0.0")
+
+	testutil.CreateFile(t, repoDir, "b.txt", "b")
+	testutil.GitAdd(t, repoDir, "b.txt")
+	testutil.GitCommit(t, repoDir, "feat(auth): add login")
+
+	testutil.CreateFile(t, repoDir, "c.txt", "c")
+	testutil.GitAdd(t, repoDir, "c.txt")
+	testutil.GitCommit(t, repoDir, "fix!: change token format")
+
+	// Drafting for an untagged release uses HEAD as the end of the range
+	provider := &mockProvider{}
+	notes, err := Draft(context.Background(), repoDir, "v1.1.0", provider)
+	if err != nil {
+		t.Fatalf("Draft failed: %v", err)
+	}
+
+	if notes.PrevTag != "v1.0.0" {
+		t.Errorf("PrevTag = %q, want v1.0.0", notes.PrevTag)
+	}
+	if notes.CommitCount() != 2 {
+		t.Errorf("expected 2 commits, got %d", notes.CommitCount())
+	}
+	if len(notes.Breaking) != 1 {
+		t.Errorf("expected 1 breaking change, got %d", len(notes.Breaking))
+	}
+	if strings.Contains(provider.user, "first feature") {
+		t.Error("prompt should not include commits from the previous release")
+	}
+	if !strings.Contains(provider.user, "(auth) add login") || !strings.Contains(provider.user, "[BREAKING]") {
+		t.Errorf("prompt missing expected commits:\n%s", provider.user)
+	}
+	if !strings.HasPrefix(notes.Draft, "## Highlights") {
+		t.Errorf("unexpected draft: %q", notes.Draft)
+	}
+
+	// An existing tag uses the tag's own range
+	git("tag", "v1.1.0")
+	notes, err = NewCollector(repoDir).Collect("v1.0.0")
+	if err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+	if notes.PrevTag != "" || notes.CommitCount() != 1 {
+		t.Errorf("expected first release with 1 commit, got prev=%q count=%d", notes.PrevTag, notes.CommitCount())
+	}
+}
+
+func TestDraft_NoCommits(t *testing.T) {
+	repoDir := testutil.TestRepo(t)
+
+	testutil.CreateFile(t, repoDir, "a.txt", "a")
+	testutil.GitAdd(t, repoDir, "a.txt")
+	testutil.GitCommit(t, repoDir, "feat: first feature")
+
+	cmd := exec.Command("git", "tag", "v1.0.0")
+	cmd.Dir = repoDir
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Draft(context.Background(), repoDir, "v1.0.1", &mockProvider{})
+	if _, ok := err.(*NoCommitsError); !ok {
+		t.Errorf("expected *NoCommitsError, got %v", err)
+	}
+}
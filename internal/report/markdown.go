@@ -0,0 +1,86 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// summaryFileLimit is how many files a commit lists before the rest are
+// folded into a collapsed <details> block.
+const summaryFileLimit = 10
+
+// WritePlanSummary writes the executed commits to w as a markdown section
+// for a pull request description: each commit's subject, why its files were
+// grouped together and the files themselves, so reviewers can see why the
+// change was split the way it was and review it commit by commit.
+func WritePlanSummary(w io.Writer, commits []types.ExecutedCommit) error {
+	var b strings.Builder
+	b.WriteString("## Commits\n\n")
+	if len(commits) == 1 {
+		b.WriteString("This change is a single commit.\n\n")
+	} else {
+		fmt.Fprintf(&b, "This change is split into %d commits that can be reviewed one at a time:\n\n", len(commits))
+	}
+
+	for i, c := range commits {
+		fmt.Fprintf(&b, "%d. **%s**", i+1, inlineCode(c.Message))
+		if c.Hash != "" && c.Hash != "(dry-run)" {
+			fmt.Fprintf(&b, " (%s)", shortHash(c.Hash))
+		}
+		b.WriteString("\n")
+		if reasoning := strings.Join(strings.Fields(c.Reasoning), " "); reasoning != "" {
+			fmt.Fprintf(&b, "   %s\n", reasoning)
+		}
+		b.WriteString("\n")
+
+		files := c.Files
+		if len(files) > summaryFileLimit {
+			files = files[:summaryFileLimit]
+		}
+		for _, f := range files {
+			fmt.Fprintf(&b, "   - %s\n", inlineCode(f))
+		}
+		if rest := c.Files[len(files):]; len(rest) > 0 {
+			fmt.Fprintf(&b, "   <details><summary>%d more files</summary>\n\n", len(rest))
+			for _, f := range rest {
+				fmt.Fprintf(&b, "   - %s\n", inlineCode(f))
+			}
+			b.WriteString("\n   </details>\n")
+		}
+		b.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, strings.TrimRight(b.String(), "\n")+"\n")
+	return err
+}
+
+// inlineCode wraps s in a code span, using a longer backtick fence than
+// any run of backticks in s so they show literally.
+func inlineCode(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	if longest > 0 {
+		// A space keeps a leading or trailing backtick off the fence
+		return fence + " " + s + " " + fence
+	}
+	return fence + s + fence
+}
+
+// shortHash abbreviates a commit hash to the 7 characters GitHub links.
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
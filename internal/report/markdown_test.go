@@ -0,0 +1,53 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/dsswift/commit/pkg/types"
+)
+
+func TestWritePlanSummary(t *testing.T) {
+	var many []string
+	for i := 0; i < 12; i++ {
+		many = append(many, fmt.Sprintf("docs/page%02d.md", i))
+	}
+	commits := []types.ExecutedCommit{
+		{
+			Hash:      "0123456789abcdef",
+			Message:   "feat(api): add `limit` query parameter",
+			Files:     []string{"api/list.go", "api/list_test.go"},
+			Reasoning: "The handler and its tests change together;\nthe docs are separate.",
+		},
+		{Hash: "fedcba9876543210", Message: "docs: document pagination", Files: many},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePlanSummary(&buf, commits); err != nil {
+		t.Fatalf("WritePlanSummary() error = %v", err)
+	}
+	got := buf.String()
+
+	for _, want := range []string{
+		"## Commits\n\nThis change is split into 2 commits",
+		"1. **`` feat(api): add `limit` query parameter ``** (0123456)\n   The handler and its tests change together; the docs are separate.\n\n   - `api/list.go`\n   - `api/list_test.go`\n",
+		"2. **`docs: document pagination`** (fedcba9)\n\n   - `docs/page00.md`\n",
+		"   - `docs/page09.md`\n   <details><summary>2 more files</summary>\n\n   - `docs/page10.md`\n   - `docs/page11.md`\n\n   </details>\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("summary missing %q:\n%s", want, got)
+		}
+	}
+	if !strings.HasSuffix(got, "</details>\n") {
+		t.Errorf("summary should end with a single newline:\n%q", got)
+	}
+
+	// Dry runs have no hashes to show
+	buf.Reset()
+	_ = WritePlanSummary(&buf, []types.ExecutedCommit{{Hash: "(dry-run)", Message: "fix: handle empty pages", Files: []string{"api/list.go"}}})
+	if got := buf.String(); !strings.Contains(got, "single commit") || strings.Contains(got, "dry-run") {
+		t.Errorf("unexpected dry-run summary:\n%s", got)
+	}
+}
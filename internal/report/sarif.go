@@ -0,0 +1,131 @@
+// Package report writes a run's findings in machine-readable formats for
+// automation, such as SARIF for GitHub code scanning and editor problem panes,
+// and its commits as markdown for pull request descriptions.
+package report
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Rule IDs for findings.
+const (
+	// RuleInvalidPlan is a commit plan that failed validation.
+	RuleInvalidPlan = "invalid-plan"
+
+	// RuleSensitiveFile is a file kept out of the commits because it may hold secrets.
+	RuleSensitiveFile = "sensitive-file"
+
+	// RuleInvalidMessage is an existing commit message that breaks the repo's rules (--lint).
+	RuleInvalidMessage = "invalid-message"
+)
+
+// Finding levels, as defined by SARIF.
+const (
+	LevelError   = "error"
+	LevelWarning = "warning"
+)
+
+// rules describes every rule a finding can reference.
+var rules = []sarifRule{
+	{ID: RuleInvalidPlan, ShortDescription: sarifText{Text: "Commit plan failed validation"}},
+	{ID: RuleSensitiveFile, ShortDescription: sarifText{Text: "Sensitive file excluded from commits"}},
+	{ID: RuleInvalidMessage, ShortDescription: sarifText{Text: "Commit message breaks the repository's rules"}},
+}
+
+// Finding is a problem found during a run.
+type Finding struct {
+	RuleID  string
+	Level   string
+	Message string
+	// File is the path, relative to the repository root, the finding refers to.
+	// Empty for findings about the plan as a whole.
+	File string
+}
+
+// SARIF 2.1.0 log structure, limited to the fields the tool emits.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string    `json:"id"`
+	ShortDescription sarifText `json:"shortDescription"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifText       `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// WriteSARIF writes findings to w as a SARIF 2.1.0 log for the given tool version.
+func WriteSARIF(w io.Writer, version string, findings []Finding) error {
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		result := sarifResult{
+			RuleID:  f.RuleID,
+			Level:   f.Level,
+			Message: sarifText{Text: f.Message},
+		}
+		if f.File != "" {
+			result.Locations = []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: f.File}},
+			}}
+		}
+		results = append(results, result)
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{{
+			Tool: sarifTool{Driver: sarifDriver{
+				Name:           "commit",
+				Version:        version,
+				InformationURI: "https://github.com/dsswift/commit",
+				Rules:          rules,
+			}},
+			Results: results,
+		}},
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
@@ -0,0 +1,55 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	findings := []Finding{
+		{RuleID: RuleInvalidPlan, Level: LevelError, Message: "file does not exist: gone.go", File: "gone.go"},
+		{RuleID: RuleInvalidPlan, Level: LevelError, Message: "no commits in plan"},
+		{RuleID: RuleSensitiveFile, Level: LevelWarning, Message: "excluded from commits", File: "config/.env"},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, "1.2.3", findings); err != nil {
+		t.Fatalf("WriteSARIF() error = %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("version = %q, runs = %d", log.Version, len(log.Runs))
+	}
+
+	run := log.Runs[0]
+	if run.Tool.Driver.Version != "1.2.3" || len(run.Tool.Driver.Rules) != 3 {
+		t.Errorf("driver = %+v", run.Tool.Driver)
+	}
+	if len(run.Results) != 3 {
+		t.Fatalf("results = %d, want 3", len(run.Results))
+	}
+	if uri := run.Results[0].Locations[0].PhysicalLocation.ArtifactLocation.URI; uri != "gone.go" {
+		t.Errorf("location = %q, want gone.go", uri)
+	}
+	if len(run.Results[1].Locations) != 0 {
+		t.Error("plan-level finding should have no location")
+	}
+	if run.Results[2].Level != LevelWarning {
+		t.Errorf("level = %q, want warning", run.Results[2].Level)
+	}
+}
+
+func TestWriteSARIF_NoFindings(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, "dev", nil); err != nil {
+		t.Fatalf("WriteSARIF() error = %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte(`"results": []`)) {
+		t.Errorf("expected an empty results array:\n%s", buf.String())
+	}
+}
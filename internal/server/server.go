@@ -0,0 +1,443 @@
+// Package server serves the commit pipeline over a local HTTP API, so editor
+// plugins can ask for plans and messages without starting the CLI each time.
+// The provider, repository configs and plans stay warm between requests.
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/llm"
+	"github.com/dsswift/commit/pkg/commit"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// DefaultTimeout bounds each request's analysis, as for a CLI run.
+const DefaultTimeout = 60 * time.Second
+
+// maxBodyBytes limits request bodies, which may carry a whole patch.
+const maxBodyBytes = 32 << 20
+
+// Request is the JSON body of every pipeline endpoint. Dir is the repository
+// (or any directory inside it); the other fields mirror the CLI's flags.
+type Request struct {
+	Dir     string   `json:"dir"`
+	Paths   []string `json:"paths,omitempty"`
+	Staged  bool     `json:"staged,omitempty"`
+	Single  bool     `json:"single,omitempty"`
+	Message string   `json:"message,omitempty"`
+	DryRun  bool     `json:"dryRun,omitempty"`
+
+	// Patch is the unified diff planned by /analyze
+	Patch string `json:"patch,omitempty"`
+}
+
+// PlanResponse is returned by /plan and /analyze.
+type PlanResponse struct {
+	Plan *types.CommitPlan `json:"plan"`
+	// Messages are the planned commits' messages in the repo's convention
+	Messages []string `json:"messages"`
+}
+
+// MessageResponse is returned by /message.
+type MessageResponse struct {
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+}
+
+// CommitResponse is returned by /commit.
+type CommitResponse struct {
+	Plan    *types.CommitPlan      `json:"plan"`
+	Commits []types.ExecutedCommit `json:"commits"`
+}
+
+// ErrorResponse is returned with every non-2xx status.
+type ErrorResponse struct {
+	Error string `json:"error"`
+	// Code classifies the error: bad_request, unauthorized, no_changes,
+	// invalid_plan, locked, provider, timeout or internal
+	Code string `json:"code"`
+}
+
+// HealthResponse is returned by /health.
+type HealthResponse struct {
+	Version  string `json:"version"`
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+}
+
+// RequestLogFunc receives each handled request.
+type RequestLogFunc func(method, path string, status int, elapsed time.Duration)
+
+// Server handles API requests with one provider and a shared cache.
+// Pipeline requests run one at a time, since git doesn't allow concurrent
+// commits to one repository.
+type Server struct {
+	provider commit.Provider
+	version  string
+	cache    *commit.Cache
+	timeout  time.Duration
+	logFn    RequestLogFunc
+	token    string
+
+	mu sync.Mutex
+}
+
+// New creates a server analyzing with provider. version is reported by /health.
+func New(provider commit.Provider, version string) *Server {
+	return &Server{
+		provider: provider,
+		version:  version,
+		cache:    commit.NewCache(),
+		timeout:  DefaultTimeout,
+	}
+}
+
+// SetTimeout bounds each request's pipeline run.
+func (s *Server) SetTimeout(d time.Duration) {
+	s.timeout = d
+}
+
+// SetRequestLog sets a function called after each request.
+func (s *Server) SetRequestLog(fn RequestLogFunc) {
+	s.logFn = fn
+}
+
+// SetToken requires every request to carry token as a bearer token and to be
+// addressed to a loopback host name. A TCP listener needs both, since any web
+// page the user visits can send requests to it, and DNS rebinding can read the
+// answers; a Unix socket is protected by its file mode instead.
+func (s *Server) SetToken(token string) {
+	s.token = token
+}
+
+// NewToken returns a random token for SetToken.
+func NewToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Handler returns the API's HTTP handler.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("POST /plan", s.pipeline(s.plan))
+	mux.HandleFunc("POST /message", s.pipeline(s.message))
+	mux.HandleFunc("POST /commit", s.pipeline(s.commit))
+	mux.HandleFunc("POST /analyze", s.pipeline(s.analyze))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		if s.authorize(rec, r) {
+			mux.ServeHTTP(rec, r)
+		}
+		if s.logFn != nil {
+			s.logFn(r.Method, r.URL.Path, rec.status, time.Since(start))
+		}
+	})
+}
+
+// Serve serves the API on ln until ctx is cancelled, then waits for requests
+// in progress to finish.
+func (s *Server) Serve(ctx context.Context, ln net.Listener) error {
+	srv := &http.Server{Handler: s.Handler(), ReadHeaderTimeout: 10 * time.Second}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		defer cancel()
+		return srv.Shutdown(shutdownCtx)
+	}
+}
+
+// Listen opens addr: a Unix socket path ("unix:/path", or any path with a
+// slash) or a loopback TCP address ("127.0.0.1:7373", "localhost:7373"). Other
+// hosts are refused, since the API can create commits. A socket left behind
+// by a server that's no longer running is replaced.
+func Listen(addr string) (net.Listener, error) {
+	if path, ok := socketPath(addr); ok {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close() //nolint:errcheck // probe connection
+			return nil, &InUseError{Addr: path}
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+			return nil, err
+		}
+		_ = os.Remove(path)
+
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Chmod(path, 0600); err != nil {
+			ln.Close() //nolint:errcheck // already failing
+			return nil, err
+		}
+		return ln, nil
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address %q: %w", addr, err)
+	}
+	if !isLoopback(host) {
+		return nil, &NotLoopbackError{Addr: addr}
+	}
+	return net.Listen("tcp", addr)
+}
+
+// socketPath returns the Unix socket path addr names, if it names one.
+func socketPath(addr string) (string, bool) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		return path, true
+	}
+	return addr, strings.ContainsRune(addr, '/') || strings.ContainsRune(addr, filepath.Separator)
+}
+
+// isLoopback reports whether host only accepts local connections.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// InUseError indicates another server is already listening on the socket.
+type InUseError struct {
+	Addr string
+}
+
+func (e *InUseError) Error() string {
+	return fmt.Sprintf("a server is already listening on %s", e.Addr)
+}
+
+// NotLoopbackError indicates a TCP address reachable from other machines.
+type NotLoopbackError struct {
+	Addr string
+}
+
+func (e *NotLoopbackError) Error() string {
+	return fmt.Sprintf("refusing to serve on %s: only loopback addresses (127.0.0.1, ::1, localhost) are allowed", e.Addr)
+}
+
+// authorize checks the request's host and token when the server has a token,
+// writing an error response and returning false if either is wrong.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	if !isLoopbackName(r.Host) {
+		writeJSON(w, http.StatusForbidden, ErrorResponse{Error: fmt.Sprintf("host %q is not allowed", r.Host), Code: "unauthorized"})
+		return false
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+		writeJSON(w, http.StatusUnauthorized, ErrorResponse{Error: "missing or invalid bearer token", Code: "unauthorized"})
+		return false
+	}
+	return true
+}
+
+// isLoopbackName reports whether a Host header names this machine by a
+// loopback name or address, rather than a domain that may resolve to it.
+func isLoopbackName(hostport string) bool {
+	host, _, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host = strings.TrimSuffix(strings.TrimPrefix(hostport, "["), "]")
+	}
+	switch host {
+	case "localhost", "127.0.0.1", "::1":
+		return true
+	}
+	return false
+}
+
+// statusRecorder captures the status a handler wrote.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, HealthResponse{
+		Version:  s.version,
+		Provider: s.provider.Name(),
+		Model:    s.provider.Model(),
+	})
+}
+
+// pipeline decodes a Request and runs fn with the server's lock held and the
+// request's deadline applied, writing its result or error as JSON.
+func (s *Server) pipeline(fn func(context.Context, Request) (any, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Browsers send form and text bodies across sites without asking
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+			writeJSON(w, http.StatusUnsupportedMediaType, ErrorResponse{Error: "Content-Type must be application/json", Code: "bad_request"})
+			return
+		}
+
+		var req Request
+		dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "invalid request: " + err.Error(), Code: "bad_request"})
+			return
+		}
+		if req.Dir == "" || !filepath.IsAbs(req.Dir) {
+			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "dir must be an absolute path", Code: "bad_request"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), s.timeout)
+		defer cancel()
+
+		result, err := func() (any, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			return fn(ctx, req)
+		}()
+		if err != nil {
+			status, resp := errorResponse(err)
+			writeJSON(w, status, resp)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// options converts req to pipeline options using the server's provider and cache.
+func (s *Server) options(req Request) commit.Options {
+	return commit.Options{
+		Dir:        req.Dir,
+		Provider:   s.provider,
+		StagedOnly: req.Staged,
+		Single:     req.Single,
+		Message:    req.Message,
+		Paths:      req.Paths,
+		DryRun:     req.DryRun,
+		Cache:      s.cache,
+	}
+}
+
+func (s *Server) plan(ctx context.Context, req Request) (any, error) {
+	opts := s.options(req)
+	plan, err := commit.Plan(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return planResponse(opts, plan)
+}
+
+// message plans a single commit, as an editor filling in a commit message
+// would, and returns its formatted message.
+func (s *Server) message(ctx context.Context, req Request) (any, error) {
+	req.Single = true
+	opts := s.options(req)
+	plan, err := commit.Plan(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	subjects, err := commit.Subjects(opts, plan)
+	if err != nil {
+		return nil, err
+	}
+	return MessageResponse{Message: subjects[0], Files: plan.Commits[0].Files}, nil
+}
+
+func (s *Server) commit(ctx context.Context, req Request) (any, error) {
+	opts := s.options(req)
+	plan, err := commit.Plan(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	executed, err := commit.Execute(ctx, opts, plan)
+	if err != nil {
+		return nil, err
+	}
+	return CommitResponse{Plan: plan, Commits: executed}, nil
+}
+
+func (s *Server) analyze(ctx context.Context, req Request) (any, error) {
+	opts := s.options(req)
+	plan, err := commit.PlanPatch(ctx, opts, req.Patch)
+	if err != nil {
+		return nil, err
+	}
+	return planResponse(opts, plan)
+}
+
+// planResponse pairs plan with its messages in the repository's convention.
+func planResponse(opts commit.Options, plan *types.CommitPlan) (PlanResponse, error) {
+	messages, err := commit.Subjects(opts, plan)
+	if err != nil {
+		return PlanResponse{}, err
+	}
+	return PlanResponse{Plan: plan, Messages: messages}, nil
+}
+
+// errorResponse maps a pipeline error to an HTTP status and error body.
+func errorResponse(err error) (int, ErrorResponse) {
+	var (
+		noChanges   *commit.NoChangesError
+		invalidPlan *commit.InvalidPlanError
+		locked      *git.LockedError
+		providerErr *llm.ProviderError
+	)
+	resp := ErrorResponse{Error: err.Error()}
+	switch {
+	case errors.As(err, &noChanges):
+		resp.Code = "no_changes"
+		return http.StatusUnprocessableEntity, resp
+	case errors.As(err, &invalidPlan):
+		resp.Code = "invalid_plan"
+		return http.StatusUnprocessableEntity, resp
+	case errors.As(err, &locked):
+		resp.Code = "locked"
+		return http.StatusConflict, resp
+	case errors.Is(err, context.DeadlineExceeded):
+		resp.Code = "timeout"
+		return http.StatusGatewayTimeout, resp
+	case errors.As(err, &providerErr):
+		resp.Code = "provider"
+		return http.StatusBadGateway, resp
+	default:
+		resp.Code = "internal"
+		return http.StatusInternalServerError, resp
+	}
+}
+
+// writeJSON writes v as the response body with status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,310 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dsswift/commit/internal/testutil"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// fakeProvider returns a fixed plan and counts analyses. With panics set, the
+// next analysis panics instead.
+type fakeProvider struct {
+	mu     sync.Mutex
+	plan   *types.CommitPlan
+	calls  int
+	panics bool
+}
+
+func (p *fakeProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls++
+	if p.panics {
+		p.panics = false
+		panic("analysis failed")
+	}
+	return p.plan, nil
+}
+
+func (p *fakeProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	return "", nil
+}
+
+func (p *fakeProvider) Name() string  { return "fake" }
+func (p *fakeProvider) Model() string { return "fake-model" }
+
+func setupServer(t *testing.T, plan *types.CommitPlan) (*httptest.Server, *fakeProvider, string) {
+	t.Helper()
+	dir := testutil.TestRepo(t)
+	testutil.CreateFile(t, dir, "README.md", "# test\n")
+	testutil.GitAdd(t, dir, "README.md")
+	testutil.GitCommit(t, dir, "chore: initial commit")
+	testutil.CreateFile(t, dir, "api/handler.go", "package api\n")
+
+	provider := &fakeProvider{plan: plan}
+	ts := httptest.NewServer(New(provider, "1.2.3").Handler())
+	t.Cleanup(ts.Close)
+	return ts, provider, dir
+}
+
+func handlerPlan() *types.CommitPlan {
+	return &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add handler", Files: []string{"api/handler.go"}},
+	}}
+}
+
+func post(t *testing.T, ts *httptest.Server, path string, req Request, out any) int {
+	t.Helper()
+	body, _ := json.Marshal(req)
+	resp, err := http.Post(ts.URL+path, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s failed: %v", path, err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		t.Fatalf("POST %s: decoding response: %v", path, err)
+	}
+	return resp.StatusCode
+}
+
+func TestServer_Plan(t *testing.T) {
+	ts, provider, dir := setupServer(t, handlerPlan())
+
+	var resp PlanResponse
+	if status := post(t, ts, "/plan", Request{Dir: dir}, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if len(resp.Plan.Commits) != 1 || resp.Messages[0] != "feat: add handler" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	// An unchanged tree is served from the cache
+	if status := post(t, ts, "/plan", Request{Dir: dir}, &resp); status != http.StatusOK {
+		t.Fatalf("second status = %d", status)
+	}
+	if provider.calls != 1 {
+		t.Errorf("provider called %d times, want 1", provider.calls)
+	}
+}
+
+func TestServer_Message(t *testing.T) {
+	ts, _, dir := setupServer(t, handlerPlan())
+
+	var resp MessageResponse
+	if status := post(t, ts, "/message", Request{Dir: dir}, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if resp.Message != "feat: add handler" || len(resp.Files) != 1 {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestServer_Commit(t *testing.T) {
+	ts, _, dir := setupServer(t, handlerPlan())
+
+	var resp CommitResponse
+	if status := post(t, ts, "/commit", Request{Dir: dir}, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if len(resp.Commits) != 1 {
+		t.Fatalf("commits = %+v", resp.Commits)
+	}
+
+	out, _ := exec.Command("git", "-C", dir, "log", "-1", "--format=%s").Output()
+	if got := strings.TrimSpace(string(out)); got != "feat: add handler" {
+		t.Errorf("HEAD = %q", got)
+	}
+
+	// Nothing is left to commit
+	var errResp ErrorResponse
+	if status := post(t, ts, "/commit", Request{Dir: dir}, &errResp); status != http.StatusUnprocessableEntity || errResp.Code != "no_changes" {
+		t.Errorf("second commit = %d %+v, want 422 no_changes", status, errResp)
+	}
+}
+
+func TestServer_Analyze(t *testing.T) {
+	ts, _, dir := setupServer(t, &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "fix", Message: "correct greeting", Files: []string{"hello.go"}},
+	}})
+
+	patch := "diff --git a/hello.go b/hello.go\n--- a/hello.go\n+++ b/hello.go\n@@ -1 +1 @@\n-hi\n+hello\n"
+	var resp PlanResponse
+	if status := post(t, ts, "/analyze", Request{Dir: dir, Patch: patch}, &resp); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+	if len(resp.Messages) != 1 || resp.Messages[0] != "fix: correct greeting" {
+		t.Errorf("messages = %v", resp.Messages)
+	}
+}
+
+func TestServer_BadRequests(t *testing.T) {
+	ts, _, _ := setupServer(t, handlerPlan())
+
+	var errResp ErrorResponse
+	if status := post(t, ts, "/plan", Request{Dir: "relative/dir"}, &errResp); status != http.StatusBadRequest || errResp.Code != "bad_request" {
+		t.Errorf("relative dir = %d %+v", status, errResp)
+	}
+
+	resp, err := http.Post(ts.URL+"/plan", "application/json", strings.NewReader(`{"dir": "/tmp", "bogus": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown field: status = %d", resp.StatusCode)
+	}
+
+	// A form or text body can be sent by any web page
+	resp, err = http.Post(ts.URL+"/plan", "text/plain", strings.NewReader(`{"dir": "/tmp"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain: status = %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(ts.URL + "/plan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /plan: status = %d", resp.StatusCode)
+	}
+}
+
+func TestServer_Token(t *testing.T) {
+	dir := testutil.TestRepo(t)
+	srv := New(&fakeProvider{plan: handlerPlan()}, "dev")
+	srv.SetToken("secret")
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	send := func(host, auth string) int {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/plan", strings.NewReader(`{"dir": "`+dir+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		if host != "" {
+			req.Host = host
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := send("", ""); status != http.StatusUnauthorized {
+		t.Errorf("no token: status = %d, want 401", status)
+	}
+	if status := send("", "Bearer wrong"); status != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", status)
+	}
+	// A rebound domain resolving to 127.0.0.1 still sends its own name
+	if status := send("attacker.example:7373", "Bearer secret"); status != http.StatusForbidden {
+		t.Errorf("foreign host: status = %d, want 403", status)
+	}
+	for _, host := range []string{"", "localhost:7373", "[::1]:7373"} {
+		if status := send(host, "Bearer secret"); status == http.StatusUnauthorized || status == http.StatusForbidden {
+			t.Errorf("host %q with token: status = %d", host, status)
+		}
+	}
+}
+
+func TestServer_PanicReleasesLock(t *testing.T) {
+	dir := testutil.TestRepo(t)
+	testutil.CreateFile(t, dir, "README.md", "# test\n")
+	testutil.GitAdd(t, dir, "README.md")
+	testutil.GitCommit(t, dir, "chore: initial commit")
+	testutil.CreateFile(t, dir, "api/handler.go", "package api\n")
+	provider := &fakeProvider{plan: handlerPlan(), panics: true}
+	srv := New(provider, "dev")
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	// net/http recovers the panic and drops the connection
+	resp, err := http.Post(ts.URL+"/plan", "application/json", strings.NewReader(`{"dir": "`+dir+`"}`))
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	if provider.calls != 1 {
+		t.Fatalf("provider called %d times, want 1", provider.calls)
+	}
+	if !srv.mu.TryLock() {
+		t.Fatal("lock still held after a request panicked")
+	}
+	srv.mu.Unlock()
+}
+
+func TestServer_Health(t *testing.T) {
+	ts, _, _ := setupServer(t, handlerPlan())
+
+	resp, err := http.Get(ts.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var health HealthResponse
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if health.Version != "1.2.3" || health.Provider != "fake" || health.Model != "fake-model" {
+		t.Errorf("health = %+v", health)
+	}
+}
+
+func TestListen(t *testing.T) {
+	if _, err := Listen("0.0.0.0:0"); !errors.As(err, new(*NotLoopbackError)) {
+		t.Errorf("Listen(0.0.0.0:0) error = %v, want NotLoopbackError", err)
+	}
+
+	ln, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen(127.0.0.1:0) error = %v", err)
+	}
+	ln.Close()
+
+	sock := filepath.Join(t.TempDir(), "c.sock")
+	ln, err = Listen(sock)
+	if err != nil {
+		t.Fatalf("Listen(%s) error = %v", sock, err)
+	}
+	defer ln.Close()
+
+	if _, err := Listen("unix:" + sock); !errors.As(err, new(*InUseError)) {
+		t.Errorf("second Listen error = %v, want InUseError", err)
+	}
+}
+
+func TestServe_Shutdown(t *testing.T) {
+	ln, err := Listen(filepath.Join(t.TempDir(), "c.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- New(&fakeProvider{}, "dev").Serve(ctx, ln) }()
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Serve() error = %v, want nil after cancel", err)
+	}
+}
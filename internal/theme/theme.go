@@ -0,0 +1,192 @@
+// Package theme defines the glyphs, colors and box characters used for
+// console output.
+package theme
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DefaultName is the name of the theme used when none is configured.
+const DefaultName = "default"
+
+// Theme controls how console output is decorated.
+type Theme struct {
+	// Icons replaces step and final emoji, keyed by the default emoji
+	Icons map[string]string `json:"icons,omitempty"`
+	// Icon replaces any emoji missing from Icons; empty keeps the default emoji
+	Icon string `json:"icon,omitempty"`
+
+	Success  string `json:"success"`
+	Error    string `json:"error"`
+	Progress string `json:"progress"`
+	Verbose  string `json:"verbose"`
+	Warning  string `json:"warning"`
+
+	Box    Box    `json:"box"`
+	Colors Colors `json:"colors"`
+}
+
+// Box holds the characters used to draw commit trees.
+type Box struct {
+	Top      string `json:"top"`
+	Middle   string `json:"middle"`
+	Bottom   string `json:"bottom"`
+	Vertical string `json:"vertical"`
+	Leaf     string `json:"leaf"`
+}
+
+// Colors holds lipgloss color values (ANSI numbers or hex). Empty means uncolored.
+type Colors struct {
+	Step    string `json:"step,omitempty"`
+	Success string `json:"success,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Warning string `json:"warning,omitempty"`
+	Subtle  string `json:"subtle,omitempty"`
+}
+
+// fileTheme is the JSON form of a theme file. Unset fields fall back to Base.
+type fileTheme struct {
+	Base string `json:"base"`
+}
+
+var builtin = map[string]Theme{
+	DefaultName: {
+		Success:  "✓",
+		Error:    "✗",
+		Progress: "⋯",
+		Verbose:  "│",
+		Warning:  "⚠️ ",
+		Box:      Box{Top: "┌─", Middle: "├─", Bottom: "└─", Vertical: "│", Leaf: "└─"},
+	},
+	"minimal": {
+		Icon:     "==>",
+		Success:  "+",
+		Error:    "x",
+		Progress: "...",
+		Verbose:  "|",
+		Warning:  "!",
+		Box:      Box{Top: "+-", Middle: "+-", Bottom: "`-", Vertical: "|", Leaf: "`-"},
+	},
+	"nerd-font": {
+		Icons: map[string]string{
+			"✅": "\uf00c", // nf-fa-check
+			"❌": "\uf00d", // nf-fa-times
+			"📂": "\uf07c", // nf-fa-folder_open
+			"📋": "\uf0ea", // nf-fa-clipboard
+			"📜": "\uf1da", // nf-fa-history
+			"📝": "\uf044", // nf-fa-pencil_square_o
+			"🔄": "\uf021", // nf-fa-refresh
+			"🔍": "\uf002", // nf-fa-search
+			"🔧": "\uf0ad", // nf-fa-wrench
+			"🚀": "\uf135", // nf-fa-rocket
+			"🤖": "\uf544", // nf-fa-robot
+			"🩹": "\uf0fa", // nf-fa-medkit
+		},
+		Success:  "\uf00c",
+		Error:    "\uf00d",
+		Progress: "\uf110", // nf-fa-spinner
+		Verbose:  "│",
+		Warning:  "\uf071", // nf-fa-warning
+		Box:      Box{Top: "╭─", Middle: "├─", Bottom: "╰─", Vertical: "│", Leaf: "╰─"},
+		Colors:   Colors{Step: "12", Success: "10", Error: "9", Warning: "11", Subtle: "8"},
+	},
+	"corporate": {
+		Icon:     "▸",
+		Success:  "✔",
+		Error:    "✖",
+		Progress: "…",
+		Verbose:  "│",
+		Warning:  "▲",
+		Box:      Box{Top: "┌─", Middle: "├─", Bottom: "└─", Vertical: "│", Leaf: "└─"},
+		Colors:   Colors{Step: "33", Success: "35", Error: "160", Warning: "172", Subtle: "245"},
+	},
+}
+
+// Default returns the default theme.
+func Default() Theme {
+	return builtin[DefaultName]
+}
+
+// Names returns the built-in theme names, sorted.
+func Names() []string {
+	names := make([]string, 0, len(builtin))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the built-in theme with the given name, or reads a theme
+// from a JSON file when nameOrPath looks like a path.
+func Load(nameOrPath string) (Theme, error) {
+	if nameOrPath == "" {
+		return Default(), nil
+	}
+	if t, ok := builtin[nameOrPath]; ok {
+		return t, nil
+	}
+	if strings.HasSuffix(nameOrPath, ".json") || strings.ContainsRune(nameOrPath, filepath.Separator) {
+		return LoadFile(nameOrPath)
+	}
+	return Theme{}, &UnknownThemeError{Name: nameOrPath}
+}
+
+// LoadFile reads a theme from a JSON file. The file may set "base" to a
+// built-in theme name; any field it omits is taken from that theme.
+func LoadFile(path string) (Theme, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, fmt.Errorf("failed to read theme file: %w", err)
+	}
+
+	var header fileTheme
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Theme{}, fmt.Errorf("invalid theme file %s: %w", path, err)
+	}
+	if header.Base == "" {
+		header.Base = DefaultName
+	}
+
+	base, ok := builtin[header.Base]
+	if !ok {
+		return Theme{}, &UnknownThemeError{Name: header.Base}
+	}
+
+	// Copy the base icons so the built-in map is never modified
+	t := base
+	t.Icons = make(map[string]string, len(base.Icons))
+	for k, v := range base.Icons {
+		t.Icons[k] = v
+	}
+
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Theme{}, fmt.Errorf("invalid theme file %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// StepIcon returns the icon to show in place of emoji.
+func (t Theme) StepIcon(emoji string) string {
+	if icon, ok := t.Icons[emoji]; ok {
+		return icon
+	}
+	if t.Icon != "" {
+		return t.Icon
+	}
+	return emoji
+}
+
+// UnknownThemeError indicates a theme name that is neither built in nor a file.
+type UnknownThemeError struct {
+	Name string
+}
+
+func (e *UnknownThemeError) Error() string {
+	return fmt.Sprintf("unknown theme %q. Available: %s, or a path to a .json theme file", e.Name, strings.Join(Names(), ", "))
+}
@@ -0,0 +1,75 @@
+package theme
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_Builtin(t *testing.T) {
+	for _, name := range Names() {
+		th, err := Load(name)
+		if err != nil {
+			t.Fatalf("Load(%q) failed: %v", name, err)
+		}
+		if th.Success == "" || th.Box.Top == "" {
+			t.Errorf("theme %q is missing glyphs: %+v", name, th)
+		}
+	}
+
+	if th, _ := Load(""); th.Success != Default().Success {
+		t.Error("expected empty name to load the default theme")
+	}
+
+	_, err := Load("sparkly")
+	if _, ok := err.(*UnknownThemeError); !ok {
+		t.Errorf("expected UnknownThemeError, got %T: %v", err, err)
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "brand.json")
+	content := `{"base": "minimal", "success": "OK", "icons": {"🚀": ">>"}, "colors": {"step": "#0055aa"}}`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	th, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if th.Success != "OK" {
+		t.Errorf("expected overridden success glyph, got %q", th.Success)
+	}
+	if th.Error != "x" {
+		t.Errorf("expected error glyph from base theme, got %q", th.Error)
+	}
+	if th.StepIcon("🚀") != ">>" || th.StepIcon("🔧") != "==>" {
+		t.Errorf("unexpected icons: %q %q", th.StepIcon("🚀"), th.StepIcon("🔧"))
+	}
+	if th.Colors.Step != "#0055aa" {
+		t.Errorf("expected step color, got %q", th.Colors.Step)
+	}
+
+	// Loading a file must not leak into the built-in theme
+	if _, ok := builtin["minimal"].Icons["🚀"]; ok {
+		t.Error("theme file modified the built-in theme")
+	}
+}
+
+func TestLoadFile_UnknownBase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.json")
+	if err := os.WriteFile(path, []byte(`{"base": "nope"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(path); err == nil {
+		t.Error("expected error for unknown base theme")
+	}
+}
+
+func TestStepIcon_Default(t *testing.T) {
+	if got := Default().StepIcon("🚀"); got != "🚀" {
+		t.Errorf("default theme should keep emoji, got %q", got)
+	}
+}
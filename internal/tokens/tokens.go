@@ -0,0 +1,185 @@
+// Package tokens approximates how many tokens LLM tokenizers split text into.
+// Providers tokenize differently and their tokenizers aren't available
+// offline, so counts are estimated from characters per token, calibrated on
+// source diffs for each model family. Budgets, truncation, logging and cost
+// estimates all use these counts so they agree across providers.
+package tokens
+
+import (
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// family is a group of models sharing a tokenizer.
+type family struct {
+	prefixes      []string // model (or provider) name prefixes
+	charsPerToken float64  // ASCII characters per token in diffs
+}
+
+// families lists model families by name prefix. Earlier entries win, so
+// narrower prefixes come first.
+var families = []family{
+	{prefixes: []string{"gpt-4o", "gpt-4.1", "gpt-5", "o1", "o3", "o4", "chatgpt"}, charsPerToken: 4.0},
+	{prefixes: []string{"gpt-", "openai"}, charsPerToken: 3.7},
+	{prefixes: []string{"claude", "anthropic"}, charsPerToken: 3.5},
+	{prefixes: []string{"gemini"}, charsPerToken: 4.0},
+	{prefixes: []string{"grok"}, charsPerToken: 3.8},
+	{prefixes: []string{"mistral", "codestral", "ministral", "open-mistral"}, charsPerToken: 3.6},
+	{prefixes: []string{"deepseek"}, charsPerToken: 3.3},
+}
+
+// defaultCharsPerToken is used for unknown models.
+const defaultCharsPerToken = 4.0
+
+// charsPerToken returns the calibration for model.
+func charsPerToken(model string) float64 {
+	model = strings.ToLower(model)
+	for _, f := range families {
+		for _, prefix := range f.prefixes {
+			if strings.HasPrefix(model, prefix) {
+				return f.charsPerToken
+			}
+		}
+	}
+	return defaultCharsPerToken
+}
+
+// Count approximates the number of tokens text takes up for model. model may
+// also be a provider name, for when the provider's default model is used.
+// Non-ASCII characters count as a token each, since tokenizers rarely merge
+// them.
+func Count(model, text string) int {
+	if text == "" {
+		return 0
+	}
+	ascii, other := 0, 0
+	for _, r := range text {
+		if r < utf8.RuneSelf {
+			ascii++
+		} else {
+			other++
+		}
+	}
+	return int(math.Ceil(float64(ascii)/charsPerToken(model))) + other
+}
+
+// Fit returns the length in bytes of the longest prefix of text, ending on a
+// character boundary, that takes up at most maxTokens for model.
+func Fit(model, text string, maxTokens int) int {
+	if Count(model, text) <= maxTokens {
+		return len(text)
+	}
+	perToken := charsPerToken(model) // a non-ASCII character costs a whole token
+	budget := float64(maxTokens) * perToken
+	end, spent := len(text), 0.0
+	for i, r := range text {
+		cost := 1.0
+		if r >= utf8.RuneSelf {
+			cost = perToken
+		}
+		if spent+cost > budget {
+			end = i
+			break
+		}
+		spent += cost
+	}
+	// Rounding can leave the prefix a token over
+	for end > 0 && Count(model, text[:end]) > maxTokens {
+		_, size := utf8.DecodeLastRuneInString(text[:end])
+		end -= size
+	}
+	return end
+}
+
+// window is a model's context window: the tokens its prompt and response
+// share.
+type window struct {
+	prefix string // model (or provider) name prefix
+	tokens int
+}
+
+// windows are the context windows of common models, narrowest prefix first.
+// Provider names stand for their default model.
+var windows = []window{
+	{"gpt-4o", 128_000},
+	{"gpt-4.1", 1_047_576},
+	{"gpt-5", 400_000},
+	{"gpt-4-turbo", 128_000},
+	{"gpt-4", 8_192},
+	{"gpt-3.5", 16_385},
+	{"o1", 200_000},
+	{"o3", 200_000},
+	{"o4", 200_000},
+	{"chatgpt", 128_000},
+	{"openai", 128_000},
+	{"azure-openai", 128_000},
+	{"claude", 200_000},
+	{"anthropic", 200_000},
+	{"gemini", 1_048_576},
+	{"vertex", 1_048_576},
+	{"grok", 131_072},
+	{"codestral", 256_000},
+	{"mistral", 128_000},
+	{"ministral", 128_000},
+	{"open-mistral", 128_000},
+	{"deepseek", 64_000},
+}
+
+// DefaultContextWindow is assumed for models not in the table, such as local
+// models, whose window depends on how they're served.
+const DefaultContextWindow = 32_768
+
+// ContextWindow returns the context window of model in tokens. model may also
+// be a provider name, for when the provider's default model is used.
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	for _, w := range windows {
+		if strings.HasPrefix(model, w.prefix) {
+			return w.tokens
+		}
+	}
+	return DefaultContextWindow
+}
+
+// price is a model's list price in USD per million tokens.
+type price struct {
+	prefix        string
+	input, output float64
+}
+
+// prices are list prices of common models, narrowest prefix first. They
+// only feed cost estimates in the execution log.
+var prices = []price{
+	{"gpt-4o-mini", 0.15, 0.60},
+	{"gpt-4o", 2.50, 10},
+	{"gpt-4.1-mini", 0.40, 1.60},
+	{"gpt-4.1", 2, 8},
+	{"gpt-4-turbo", 10, 30},
+	{"claude-3-5-haiku", 0.80, 4},
+	{"claude-3-haiku", 0.25, 1.25},
+	{"claude-3-5-sonnet", 3, 15},
+	{"claude-3-7-sonnet", 3, 15},
+	{"claude-sonnet", 3, 15},
+	{"claude-3-opus", 15, 75},
+	{"claude-opus", 15, 75},
+	{"gemini-1.5-flash", 0.075, 0.30},
+	{"gemini-1.5-pro", 1.25, 5},
+	{"gemini-2.5-flash", 0.30, 2.50},
+	{"gemini-2.5-pro", 1.25, 10},
+	{"grok-beta", 5, 15},
+	{"deepseek-chat", 0.27, 1.10},
+	{"deepseek-reasoner", 0.55, 2.19},
+}
+
+// EstimateCost returns the approximate cost in USD of a request to model
+// with the given token counts. ok is false if the model's price is unknown.
+func EstimateCost(model string, inputTokens, outputTokens int) (cost float64, ok bool) {
+	model = strings.ToLower(model)
+	for _, p := range prices {
+		if strings.HasPrefix(model, p.prefix) {
+			return (float64(inputTokens)*p.input + float64(outputTokens)*p.output) / 1e6, true
+		}
+	}
+	return 0, false
+}
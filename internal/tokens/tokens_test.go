@@ -0,0 +1,85 @@
+package tokens
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		model string
+		text  string
+		want  int
+	}{
+		{"gpt-4o", "", 0},
+		{"gpt-4o", strings.Repeat("a", 400), 100},
+		{"gpt-4-turbo-preview", strings.Repeat("a", 370), 100},
+		{"claude-3-5-sonnet-20241022", strings.Repeat("a", 350), 100},
+		{"anthropic", strings.Repeat("a", 350), 100},
+		{"unknown-model", strings.Repeat("a", 401), 101},
+		{"", "日本語", 3},
+		{"gemini-1.5-pro", "abcd→", 2},
+	}
+	for _, tt := range tests {
+		if got := Count(tt.model, tt.text); got != tt.want {
+			t.Errorf("Count(%q, %d chars) = %d, want %d", tt.model, len(tt.text), got, tt.want)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	text := strings.Repeat("line of diff\n", 100) + "日本語"
+	for _, model := range []string{"gpt-4o", "gpt-4", "claude-3-opus", "grok-beta", ""} {
+		for _, max := range []int{0, 1, 10, 333, 1000} {
+			end := Fit(model, text, max)
+			if got := Count(model, text[:end]); got > max {
+				t.Errorf("Fit(%q, %d) kept %d tokens", model, max, got)
+			}
+			if end < len(text) && Count(model, text[:end+1]) <= max {
+				t.Errorf("Fit(%q, %d) = %d stops short", model, max, end)
+			}
+		}
+	}
+	if end := Fit("gpt-4o", "abc", 10); end != 3 {
+		t.Errorf("Fit of text within budget = %d, want 3", end)
+	}
+	// Multi-byte characters are never split
+	if end := Fit("gpt-4o", "ab日本", 2); end != 2 && end != 5 {
+		t.Errorf("Fit split a character: %d", end)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"gpt-4o-mini", 128_000},
+		{"gpt-4.1-mini", 1_047_576},
+		{"gpt-4-0613", 8_192},
+		{"Claude-3-5-Sonnet-20241022", 200_000},
+		{"anthropic", 200_000},
+		{"gemini-2.5-pro", 1_048_576},
+		{"codestral-latest", 256_000},
+		{"llama3.1:8b", DefaultContextWindow},
+		{"", DefaultContextWindow},
+	}
+	for _, tt := range tests {
+		if got := ContextWindow(tt.model); got != tt.want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	cost, ok := EstimateCost("gpt-4o-mini-2024-07-18", 1_000_000, 1_000_000)
+	if !ok || cost != 0.75 {
+		t.Errorf("EstimateCost(gpt-4o-mini) = %v, %v; want 0.75", cost, ok)
+	}
+	if cost, ok := EstimateCost("claude-3-5-sonnet-20241022", 2000, 500); !ok || cost != 0.0135 {
+		t.Errorf("EstimateCost(claude-3-5-sonnet) = %v, %v; want 0.0135", cost, ok)
+	}
+	if _, ok := EstimateCost("my-local-model", 1000, 1000); ok {
+		t.Error("expected unknown model to have no estimate")
+	}
+}
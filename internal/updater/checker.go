@@ -28,25 +28,47 @@ const (
 	CheckTimeout = 5 * time.Second
 )
 
+// Update check modes, set with COMMIT_UPDATE_CHECK.
+const (
+	CheckAlways = "always" // check on every run
+	CheckDaily  = "daily"  // check at most once every CacheDuration (the default)
+	CheckNever  = "never"  // never check
+)
+
+// CheckModes lists the update check modes.
+var CheckModes = []string{CheckAlways, CheckDaily, CheckNever}
+
+// releasesURL is GitHubReleasesURL, overridable for testing.
+var releasesURL = GitHubReleasesURL
+
 // VersionInfo contains information about available versions.
 type VersionInfo struct {
 	CurrentVersion  string
 	LatestVersion   string
 	UpdateAvailable bool
 	ReleaseURL      string
+	Models          *ModelManifest // deprecated models; nil if the manifest couldn't be fetched
 }
 
 // VersionCache stores the cached version check result.
 type VersionCache struct {
-	CheckedAt     time.Time `json:"checked_at"`
-	LatestVersion string    `json:"latest_version"`
-	ReleaseURL    string    `json:"release_url"`
+	CheckedAt     time.Time      `json:"checked_at"`
+	LatestVersion string         `json:"latest_version"`
+	ReleaseURL    string         `json:"release_url"`
+	Models        *ModelManifest `json:"models,omitempty"`
 }
 
 // GitHubRelease represents a GitHub release API response.
 type GitHubRelease struct {
-	TagName string `json:"tag_name"`
-	HTMLURL string `json:"html_url"`
+	TagName string         `json:"tag_name"`
+	HTMLURL string         `json:"html_url"`
+	Assets  []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file attached to a GitHub release.
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
 }
 
 // CheckVersion checks if a newer version is available.
@@ -73,19 +95,33 @@ func checkVersion(currentVersion string, skipCache bool) *VersionInfo {
 	}
 
 	// Check cache first (unless skipping)
+	cached, cacheErr := loadCache()
 	if !skipCache {
-		cached, err := loadCache()
-		if err == nil && time.Since(cached.CheckedAt) < CacheDuration {
+		if cacheErr == nil && time.Since(cached.CheckedAt) < CacheDuration {
 			info.LatestVersion = cached.LatestVersion
 			info.ReleaseURL = cached.ReleaseURL
+			info.Models = cached.Models
 			info.UpdateAvailable = isNewerVersion(cached.LatestVersion, currentVersion)
 			return info
 		}
 	}
 
+	// The model manifest is best effort and refreshed with the version
+	info.Models, _ = fetchModelManifest()
+
 	// Fetch from GitHub
 	release, err := fetchLatestRelease()
 	if err != nil {
+		// Record the attempt so an unreachable GitHub (say, behind a CI
+		// firewall) is tried once a day rather than on every run
+		attempt := &VersionCache{CheckedAt: time.Now(), Models: info.Models}
+		if cacheErr == nil {
+			attempt.LatestVersion, attempt.ReleaseURL = cached.LatestVersion, cached.ReleaseURL
+			if attempt.Models == nil {
+				attempt.Models = cached.Models
+			}
+		}
+		_ = saveCache(attempt)
 		return info
 	}
 
@@ -94,6 +130,7 @@ func checkVersion(currentVersion string, skipCache bool) *VersionInfo {
 		CheckedAt:     time.Now(),
 		LatestVersion: release.TagName,
 		ReleaseURL:    release.HTMLURL,
+		Models:        info.Models,
 	})
 
 	info.LatestVersion = release.TagName
@@ -107,7 +144,7 @@ func checkVersion(currentVersion string, skipCache bool) *VersionInfo {
 func fetchLatestRelease() (*GitHubRelease, error) {
 	client := httpclient.NewClient(CheckTimeout)
 
-	req, err := http.NewRequest("GET", GitHubReleasesURL, nil)
+	req, err := http.NewRequest("GET", releasesURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -223,7 +260,7 @@ func isNewerVersion(latest, current string) bool {
 
 // FormatUpdateNotice returns a formatted string for the update notice.
 func FormatUpdateNotice(info *VersionInfo) string {
-	if !info.UpdateAvailable {
+	if info == nil || !info.UpdateAvailable {
 		return ""
 	}
 
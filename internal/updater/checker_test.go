@@ -1,6 +1,8 @@
 package updater
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -224,3 +226,43 @@ func TestCheckVersionFresh_BypassesCache(t *testing.T) {
 		t.Errorf("expected current version v1.0.0, got %q", info.CurrentVersion)
 	}
 }
+
+func TestCheckVersion_RecordsFailedCheck(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	origReleases, origManifest := releasesURL, modelManifestURL
+	releasesURL, modelManifestURL = server.URL, server.URL
+	defer func() { releasesURL, modelManifestURL = origReleases, origManifest }()
+
+	_ = saveCache(&VersionCache{
+		CheckedAt:     time.Now().Add(-48 * time.Hour),
+		LatestVersion: "v2.0.0",
+		ReleaseURL:    "https://example.com",
+	})
+
+	CheckVersion("v1.0.0")
+	if requests == 0 {
+		t.Fatal("expected an expired cache to be refreshed")
+	}
+
+	// The failed attempt counts as today's check, keeping the last known release
+	cache, err := loadCache()
+	if err != nil {
+		t.Fatalf("loadCache failed: %v", err)
+	}
+	if time.Since(cache.CheckedAt) > time.Minute || cache.LatestVersion != "v2.0.0" {
+		t.Errorf("cache = %+v, want a recent attempt with v2.0.0", cache)
+	}
+
+	requests = 0
+	if info := CheckVersion("v1.0.0"); !info.UpdateAvailable || requests != 0 {
+		t.Errorf("second check: update %v after %d requests, want the cached release without requests", info.UpdateAvailable, requests)
+	}
+}
@@ -0,0 +1,14 @@
+//go:build linux || darwin
+
+package updater
+
+import "syscall"
+
+// freeDiskSpace reports the bytes available to unprivileged users in dir.
+func freeDiskSpace(dir string) (uint64, bool) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, false
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), true
+}
@@ -0,0 +1,157 @@
+package updater
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dsswift/commit/internal/httpclient"
+)
+
+const (
+	// ModelManifestURL is the manifest of deprecated models, maintained in the
+	// repository as models.json.
+	ModelManifestURL = "https://raw.githubusercontent.com/dsswift/commit/main/models.json"
+
+	// WarnedModelsFileName records the models a deprecation warning was shown for.
+	WarnedModelsFileName = ".model-warnings"
+)
+
+// modelManifestURL is a variable so tests can point it at a local server.
+var modelManifestURL = ModelManifestURL
+
+// ModelManifest lists deprecated or renamed models by provider.
+type ModelManifest struct {
+	Deprecated map[string][]DeprecatedModel `json:"deprecated"`
+}
+
+// DeprecatedModel is a model a provider has retired or plans to retire.
+type DeprecatedModel struct {
+	Model       string `json:"model"`
+	Replacement string `json:"replacement,omitempty"`
+	RetiresOn   string `json:"retiresOn,omitempty"` // YYYY-MM-DD; empty if already retired or unannounced
+}
+
+// Lookup returns the manifest entry for provider's model, or nil.
+func (m *ModelManifest) Lookup(provider, model string) *DeprecatedModel {
+	if m == nil || model == "" {
+		return nil
+	}
+	for _, d := range m.Deprecated[provider] {
+		if strings.EqualFold(d.Model, model) {
+			return &d
+		}
+	}
+	return nil
+}
+
+// fetchModelManifest fetches the deprecated model manifest.
+func fetchModelManifest() (*ModelManifest, error) {
+	client := httpclient.NewClient(CheckTimeout)
+
+	req, err := http.NewRequest("GET", modelManifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "commit-tool")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // HTTP response body
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("model manifest returned status %d", resp.StatusCode)
+	}
+
+	var manifest ModelManifest
+	if err := json.NewDecoder(resp.Body).Decode(&manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// ModelDeprecationNotice returns a warning when provider's model is in the
+// manifest fetched with the version check. Each model is warned about once;
+// later calls for the same model return "".
+func ModelDeprecationNotice(info *VersionInfo, provider, model string) string {
+	if info == nil {
+		return ""
+	}
+	deprecated := info.Models.Lookup(provider, model)
+	if deprecated == nil {
+		return ""
+	}
+
+	key := provider + "/" + deprecated.Model
+	warned := loadWarnedModels()
+	for _, w := range warned {
+		if w == key {
+			return ""
+		}
+	}
+	if err := saveWarnedModels(append(warned, key)); err != nil {
+		return ""
+	}
+
+	return FormatDeprecationNotice(provider, deprecated)
+}
+
+// FormatDeprecationNotice returns a formatted warning for a deprecated model.
+func FormatDeprecationNotice(provider string, d *DeprecatedModel) string {
+	when := "has been retired"
+	if d.RetiresOn != "" {
+		when = "will be retired on " + d.RetiresOn
+	}
+	notice := fmt.Sprintf("\n⚠️  Model %s/%s %s", provider, d.Model, when)
+	if d.Replacement != "" {
+		notice += fmt.Sprintf("\n   Switch to %s by setting COMMIT_MODEL=%s in your config", d.Replacement, d.Replacement)
+	}
+	return notice
+}
+
+// loadWarnedModels returns the provider/model pairs already warned about.
+func loadWarnedModels() []string {
+	path, err := warnedModelsPath()
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var warned []string
+	if err := json.Unmarshal(data, &warned); err != nil {
+		return nil
+	}
+	return warned
+}
+
+// saveWarnedModels records the provider/model pairs warned about.
+func saveWarnedModels(warned []string) error {
+	path, err := warnedModelsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(warned)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// warnedModelsPath returns the path to the warned models file.
+func warnedModelsPath() (string, error) {
+	cachePath, err := getCachePath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(cachePath), WarnedModelsFileName), nil
+}
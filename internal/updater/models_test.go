@@ -0,0 +1,128 @@
+package updater
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/dsswift/commit/internal/testutil"
+)
+
+func testManifest() *ModelManifest {
+	return &ModelManifest{Deprecated: map[string][]DeprecatedModel{
+		"openai": {
+			{Model: "gpt-4-32k", Replacement: "gpt-4o"},
+			{Model: "gpt-4.5-preview", Replacement: "gpt-4.1", RetiresOn: "2025-07-14"},
+		},
+	}}
+}
+
+func TestModelManifest_Lookup(t *testing.T) {
+	m := testManifest()
+
+	if d := m.Lookup("openai", "GPT-4-32K"); d == nil || d.Replacement != "gpt-4o" {
+		t.Errorf("Lookup(gpt-4-32k) = %+v", d)
+	}
+	if d := m.Lookup("openai", "gpt-4o"); d != nil {
+		t.Errorf("expected no entry for gpt-4o, got %+v", d)
+	}
+	if d := m.Lookup("anthropic", "gpt-4-32k"); d != nil {
+		t.Errorf("expected no entry for another provider, got %+v", d)
+	}
+	if d := m.Lookup("openai", ""); d != nil {
+		t.Errorf("expected no entry for the default model, got %+v", d)
+	}
+
+	var nilManifest *ModelManifest
+	if d := nilManifest.Lookup("openai", "gpt-4-32k"); d != nil {
+		t.Errorf("expected nil manifest to find nothing, got %+v", d)
+	}
+}
+
+func TestFormatDeprecationNotice(t *testing.T) {
+	retired := FormatDeprecationNotice("openai", &DeprecatedModel{Model: "gpt-4-32k", Replacement: "gpt-4o"})
+	if !testutil.ContainsString(retired, "has been retired") || !testutil.ContainsString(retired, "COMMIT_MODEL=gpt-4o") {
+		t.Errorf("unexpected notice: %q", retired)
+	}
+
+	scheduled := FormatDeprecationNotice("openai", &DeprecatedModel{Model: "gpt-4.5-preview", RetiresOn: "2025-07-14"})
+	if !testutil.ContainsString(scheduled, "will be retired on 2025-07-14") || testutil.ContainsString(scheduled, "COMMIT_MODEL") {
+		t.Errorf("unexpected notice: %q", scheduled)
+	}
+}
+
+func TestModelDeprecationNotice_Once(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	info := &VersionInfo{Models: testManifest()}
+
+	if notice := ModelDeprecationNotice(info, "openai", "gpt-4-32k"); notice == "" {
+		t.Fatal("expected a notice the first time")
+	}
+	if notice := ModelDeprecationNotice(info, "openai", "gpt-4-32k"); notice != "" {
+		t.Errorf("expected no notice the second time, got %q", notice)
+	}
+	if notice := ModelDeprecationNotice(info, "openai", "gpt-4.5-preview"); notice == "" {
+		t.Error("expected a notice for a different model")
+	}
+	if notice := ModelDeprecationNotice(info, "openai", "gpt-4o"); notice != "" {
+		t.Errorf("expected no notice for a current model, got %q", notice)
+	}
+	if notice := ModelDeprecationNotice(nil, "openai", "gpt-4-32k"); notice != "" {
+		t.Errorf("expected no notice without version info, got %q", notice)
+	}
+}
+
+func TestFetchModelManifest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(testManifest())
+	}))
+	defer server.Close()
+
+	orig := modelManifestURL
+	modelManifestURL = server.URL
+	defer func() { modelManifestURL = orig }()
+
+	manifest, err := fetchModelManifest()
+	if err != nil {
+		t.Fatalf("fetchModelManifest failed: %v", err)
+	}
+	if d := manifest.Lookup("openai", "gpt-4-32k"); d == nil {
+		t.Error("expected fetched manifest to list gpt-4-32k")
+	}
+}
+
+func TestFetchModelManifest_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	orig := modelManifestURL
+	modelManifestURL = server.URL
+	defer func() { modelManifestURL = orig }()
+
+	if _, err := fetchModelManifest(); err == nil {
+		t.Error("expected an error for a missing manifest")
+	}
+}
+
+func TestModelManifestFile(t *testing.T) {
+	data, err := os.ReadFile("../../models.json")
+	if err != nil {
+		t.Fatalf("failed to read models.json: %v", err)
+	}
+	var manifest ModelManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatalf("models.json is invalid: %v", err)
+	}
+	for provider, models := range manifest.Deprecated {
+		for _, d := range models {
+			if d.Model == "" {
+				t.Errorf("%s: entry without a model", provider)
+			}
+			if d.Replacement != "" && manifest.Lookup(provider, d.Replacement) != nil {
+				t.Errorf("%s: replacement %s is itself deprecated", provider, d.Replacement)
+			}
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package updater
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// PreflightError describes an upgrade that was stopped before downloading,
+// with what the user can do about it.
+type PreflightError struct {
+	Problem string
+	Remedy  string
+}
+
+func (e *PreflightError) Error() string {
+	return fmt.Sprintf("%s; %s", e.Problem, e.Remedy)
+}
+
+// diskFree reports the bytes available in a directory. Overridable for testing.
+var diskFree = freeDiskSpace
+
+// preflight checks that release can be installed over execPath before
+// anything is downloaded: the release has a build for this platform, there
+// is room for it in tempDir, and execPath can be replaced.
+func preflight(release *GitHubRelease, execPath, tempDir string) error {
+	asset := findAsset(release, buildBinaryName())
+	if asset == nil {
+		remedy := fmt.Sprintf("build from source or download a build manually from %s", release.HTMLURL)
+		if len(release.Assets) == 0 {
+			remedy = "its files may still be uploading; try again in a few minutes"
+		}
+		return &PreflightError{
+			Problem: fmt.Sprintf("release %s has no build for %s/%s", release.TagName, runtime.GOOS, runtime.GOARCH),
+			Remedy:  remedy,
+		}
+	}
+
+	if free, ok := diskFree(tempDir); ok && free < uint64(asset.Size) {
+		return &PreflightError{
+			Problem: fmt.Sprintf("only %s free in %s but the download is %s", formatSize(int64(free)), tempDir, formatSize(asset.Size)),
+			Remedy:  "free up space or set TMPDIR to a directory on a larger disk",
+		}
+	}
+
+	return checkWritable(execPath)
+}
+
+// findAsset returns the release asset called name, or nil.
+func findAsset(release *GitHubRelease, name string) *ReleaseAsset {
+	for i := range release.Assets {
+		if release.Assets[i].Name == name {
+			return &release.Assets[i]
+		}
+	}
+	return nil
+}
+
+// checkWritable verifies the binary at execPath can be overwritten in place
+// (and, on Windows, renamed aside within its directory).
+func checkWritable(execPath string) error {
+	f, err := os.OpenFile(execPath, os.O_WRONLY, 0)
+	if err == nil {
+		err = f.Close()
+	}
+	if err == nil && runtime.GOOS == "windows" {
+		var probe *os.File
+		if probe, err = os.CreateTemp(filepath.Dir(execPath), ".commit-upgrade-*"); err == nil {
+			_ = probe.Close()
+			_ = os.Remove(probe.Name())
+		}
+	}
+	if err == nil {
+		return nil
+	}
+
+	problem := fmt.Sprintf("%s is not writable by the current user", execPath)
+	if !os.IsPermission(err) {
+		return &PreflightError{
+			Problem: fmt.Sprintf("%s can't be replaced: %v", execPath, err),
+			Remedy:  "reinstall with the install script",
+		}
+	}
+	switch {
+	case runtime.GOOS == "windows":
+		return &PreflightError{Problem: problem, Remedy: "run the upgrade from an Administrator terminal"}
+	case os.Geteuid() != 0:
+		return &PreflightError{Problem: problem, Remedy: "it needs root to replace; run: sudo commit --upgrade"}
+	default:
+		return &PreflightError{Problem: problem, Remedy: "check the file's permissions and that its filesystem isn't read-only"}
+	}
+}
+
+// formatSize formats a size in bytes with a binary unit, e.g. "1.5 MB".
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
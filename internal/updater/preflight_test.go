@@ -0,0 +1,92 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	tmpDir := t.TempDir()
+	execPath := filepath.Join(tmpDir, "commit")
+	if err := os.WriteFile(execPath, []byte("old"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	origDiskFree := diskFree
+	defer func() { diskFree = origDiskFree }()
+	diskFree = func(string) (uint64, bool) { return 10 << 20, true }
+
+	release := &GitHubRelease{
+		TagName: "v1.2.0",
+		HTMLURL: "https://github.com/dsswift/commit/releases/tag/v1.2.0",
+		Assets: []ReleaseAsset{
+			{Name: "checksums.txt", Size: 512},
+			{Name: buildBinaryName(), Size: 5 << 20},
+		},
+	}
+	if err := preflight(release, execPath, tmpDir); err != nil {
+		t.Fatalf("preflight() = %v, want nil", err)
+	}
+
+	diskFree = func(string) (uint64, bool) { return 1 << 20, true }
+	err := preflight(release, execPath, tmpDir)
+	if _, ok := err.(*PreflightError); !ok || !strings.Contains(err.Error(), "1.0 MB free") || !strings.Contains(err.Error(), "5.0 MB") {
+		t.Errorf("preflight() with a full disk = %v", err)
+	}
+
+	diskFree = func(string) (uint64, bool) { return 0, false }
+	if err := preflight(release, execPath, tmpDir); err != nil {
+		t.Errorf("preflight() with unknown free space = %v, want nil", err)
+	}
+
+	other := &GitHubRelease{TagName: "v1.2.0", HTMLURL: release.HTMLURL, Assets: []ReleaseAsset{{Name: "commit-plan9-mips"}}}
+	err = preflight(other, execPath, tmpDir)
+	if err == nil || !strings.Contains(err.Error(), "no build for "+runtime.GOOS+"/"+runtime.GOARCH) || !strings.Contains(err.Error(), release.HTMLURL) {
+		t.Errorf("preflight() without a platform asset = %v", err)
+	}
+
+	err = preflight(&GitHubRelease{TagName: "v1.2.0"}, execPath, tmpDir)
+	if err == nil || !strings.Contains(err.Error(), "still be uploading") {
+		t.Errorf("preflight() for a release without assets = %v", err)
+	}
+}
+
+func TestCheckWritable(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+		t.Skip("file permissions don't restrict this user")
+	}
+
+	execPath := filepath.Join(t.TempDir(), "commit")
+	if err := os.WriteFile(execPath, []byte("old"), 0555); err != nil {
+		t.Fatal(err)
+	}
+
+	err := checkWritable(execPath)
+	if _, ok := err.(*PreflightError); !ok || !strings.Contains(err.Error(), "sudo commit --upgrade") {
+		t.Errorf("checkWritable() on a read-only binary = %v", err)
+	}
+
+	if err := os.Chmod(execPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkWritable(execPath); err != nil {
+		t.Errorf("checkWritable() = %v, want nil", err)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := map[int64]string{
+		512:           "512 B",
+		1536:          "1.5 KB",
+		5 << 20:       "5.0 MB",
+		3 * (1 << 30): "3.0 GB",
+	}
+	for n, want := range tests {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
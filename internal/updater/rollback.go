@@ -0,0 +1,166 @@
+package updater
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SelfCheckTimeout bounds running an installed binary to read its version.
+const SelfCheckTimeout = 10 * time.Second
+
+// versionPrefix starts the first line of `commit --version`.
+const versionPrefix = "commit version "
+
+// BackupPath returns where --upgrade keeps the binary it replaced: next to
+// it, as commit.bak.
+func BackupPath(execPath string) string {
+	return strings.TrimSuffix(execPath, ".exe") + ".bak"
+}
+
+// NoBackupError indicates --rollback found no previous binary to restore.
+type NoBackupError struct {
+	Path string
+}
+
+func (e *NoBackupError) Error() string {
+	return fmt.Sprintf("no previous version to restore (%s not found); it is kept by the last --upgrade", e.Path)
+}
+
+// SelfCheckError indicates an installed binary didn't report the expected version.
+type SelfCheckError struct {
+	Path string
+	Want string
+	Got  string
+	Err  error
+}
+
+func (e *SelfCheckError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s --version failed: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("%s --version reported %q, want %s", e.Path, e.Got, e.Want)
+}
+
+func (e *SelfCheckError) Unwrap() error { return e.Err }
+
+// Rollback restores the binary kept by the last --upgrade. The backup is
+// kept, so it can be restored again after a later upgrade fails.
+func Rollback(currentVersion string) *UpgradeResult {
+	result := &UpgradeResult{CurrentVersion: currentVersion, Rollback: true}
+
+	execPath, err := os.Executable()
+	if err == nil {
+		execPath, err = filepath.EvalSymlinks(execPath)
+	}
+	if err != nil {
+		result.Error = fmt.Errorf("failed to get executable path: %w", err)
+		return result
+	}
+
+	result.NewVersion, result.Error = rollbackAt(execPath)
+	result.Success = result.Error == nil
+	return result
+}
+
+// rollbackAt restores execPath from its backup after checking the backup
+// runs, returning the restored version.
+func rollbackAt(execPath string) (string, error) {
+	backup := BackupPath(execPath)
+	if _, err := os.Stat(backup); os.IsNotExist(err) {
+		return "", &NoBackupError{Path: backup}
+	}
+
+	version, err := readVersion(backup)
+	if err != nil {
+		return "", fmt.Errorf("previous version doesn't run, leaving the current one in place: %w", err)
+	}
+
+	if err := checkWritable(execPath); err != nil {
+		return "", err
+	}
+	if err := replaceBinary(execPath, backup); err != nil {
+		return "", fmt.Errorf("failed to restore %s: %w", backup, err)
+	}
+	return version, nil
+}
+
+// backupBinary copies execPath to its backup path, replacing an older backup.
+func backupBinary(execPath string) error {
+	backup := BackupPath(execPath)
+	_ = os.Remove(backup)
+
+	src, err := os.Open(execPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close() //nolint:errcheck // read-only file
+
+	dst, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close() //nolint:errcheck // already failing
+		return err
+	}
+	return dst.Close()
+}
+
+// selfCheck runs the binary at path with --version and verifies it reports
+// version, so an upgrade that installed a broken build can be undone.
+func selfCheck(path, version string) error {
+	got, err := readVersion(path)
+	if err != nil {
+		return &SelfCheckError{Path: path, Want: version, Err: err}
+	}
+	if strings.TrimPrefix(got, "v") != strings.TrimPrefix(version, "v") {
+		return &SelfCheckError{Path: path, Want: version, Got: got}
+	}
+	return nil
+}
+
+// readVersion runs the binary at path with --version and returns the version
+// from its first line. The update check --version runs afterwards is cut
+// short once the line is read.
+func readVersion(path string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), SelfCheckTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, path, "--version")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}()
+
+	line, err := bufio.NewReader(stdout).ReadString('\n')
+	line = strings.TrimSpace(line)
+	if line == "" {
+		if err == nil || err == io.EOF {
+			err = fmt.Errorf("no output")
+		}
+		if ctx.Err() != nil {
+			err = fmt.Errorf("no output after %s", SelfCheckTimeout)
+		}
+		return "", err
+	}
+
+	version, ok := strings.CutPrefix(line, versionPrefix)
+	if !ok {
+		return "", fmt.Errorf("unexpected output %q", line)
+	}
+	return version, nil
+}
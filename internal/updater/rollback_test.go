@@ -0,0 +1,106 @@
+package updater
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeBinary writes a script that prints script's output for --version.
+func fakeBinary(t *testing.T, path, script string) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake binaries are shell scripts")
+	}
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackupPath(t *testing.T) {
+	if got := BackupPath("/usr/local/bin/commit"); got != "/usr/local/bin/commit.bak" {
+		t.Errorf("BackupPath() = %q", got)
+	}
+	if got := BackupPath(`C:\tools\commit.exe`); got != `C:\tools\commit.bak` {
+		t.Errorf("BackupPath(.exe) = %q", got)
+	}
+}
+
+func TestSelfCheck(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good")
+	fakeBinary(t, good, `echo "commit version v1.2.0"; sleep 5`)
+
+	if err := selfCheck(good, "1.2.0"); err != nil {
+		t.Errorf("selfCheck() error = %v", err)
+	}
+
+	var checkErr *SelfCheckError
+	if err := selfCheck(good, "v1.3.0"); !errors.As(err, &checkErr) || checkErr.Got != "v1.2.0" {
+		t.Errorf("selfCheck(wrong version) error = %v", err)
+	}
+
+	broken := filepath.Join(dir, "broken")
+	fakeBinary(t, broken, `echo "illegal instruction" >&2; exit 132`)
+	if err := selfCheck(broken, "v1.2.0"); !errors.As(err, &checkErr) {
+		t.Errorf("selfCheck(broken) error = %v, want SelfCheckError", err)
+	}
+}
+
+func TestRollbackAt(t *testing.T) {
+	dir := t.TempDir()
+	execPath := filepath.Join(dir, "commit")
+	fakeBinary(t, execPath, `echo "commit version v1.1.0"`)
+
+	if _, err := rollbackAt(execPath); !errors.As(err, new(*NoBackupError)) {
+		t.Errorf("rollbackAt() without backup error = %v, want NoBackupError", err)
+	}
+
+	if err := backupBinary(execPath); err != nil {
+		t.Fatalf("backupBinary() error = %v", err)
+	}
+	fakeBinary(t, execPath, `echo "commit version v1.2.0"`)
+
+	version, err := rollbackAt(execPath)
+	if err != nil {
+		t.Fatalf("rollbackAt() error = %v", err)
+	}
+	if version != "v1.1.0" {
+		t.Errorf("restored version = %q, want v1.1.0", version)
+	}
+	if got, _ := readVersion(execPath); got != "v1.1.0" {
+		t.Errorf("installed version = %q after rollback", got)
+	}
+	if _, err := os.Stat(BackupPath(execPath)); err != nil {
+		t.Errorf("backup should be kept: %v", err)
+	}
+}
+
+func TestRollbackAt_BrokenBackup(t *testing.T) {
+	dir := t.TempDir()
+	execPath := filepath.Join(dir, "commit")
+	fakeBinary(t, execPath, `echo "commit version v1.2.0"`)
+	fakeBinary(t, BackupPath(execPath), `exit 1`)
+
+	if _, err := rollbackAt(execPath); err == nil || !strings.Contains(err.Error(), "doesn't run") {
+		t.Errorf("rollbackAt() error = %v, want the backup to be refused", err)
+	}
+	if got, _ := readVersion(execPath); got != "v1.2.0" {
+		t.Errorf("current binary was replaced: %q", got)
+	}
+}
+
+func TestFormatUpgradeResult_Rollback(t *testing.T) {
+	ok := FormatUpgradeResult(&UpgradeResult{Rollback: true, Success: true, CurrentVersion: "v1.2.0", NewVersion: "v1.1.0"})
+	if !strings.Contains(ok, "Rolled back: 1.2.0 → 1.1.0") {
+		t.Errorf("rollback = %q", ok)
+	}
+
+	failed := FormatUpgradeResult(&UpgradeResult{Rollback: true, Error: &NoBackupError{Path: "/bin/commit.bak"}})
+	if !strings.Contains(failed, "Rollback failed") || !strings.Contains(failed, "commit.bak") {
+		t.Errorf("failed rollback = %q", failed)
+	}
+}
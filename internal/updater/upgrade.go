@@ -34,6 +34,12 @@ type UpgradeResult struct {
 	CurrentVersion string
 	NewVersion     string
 	Error          error
+
+	// Rollback marks a --rollback; NewVersion is the restored version
+	Rollback bool
+	// RolledBack reports that the new binary failed its self-check and the
+	// previous one was restored
+	RolledBack bool
 }
 
 // Upgrade performs a self-update of the binary.
@@ -78,6 +84,12 @@ func Upgrade(currentVersion string) *UpgradeResult {
 		return result
 	}
 
+	// Check the release can be installed before downloading it
+	if err := preflight(release, execPath, os.TempDir()); err != nil {
+		result.Error = err
+		return result
+	}
+
 	// Download new binary
 	downloadURL := buildDownloadURL(release.TagName)
 	tempPath, err := downloadBinary(downloadURL)
@@ -106,12 +118,29 @@ func Upgrade(currentVersion string) *UpgradeResult {
 		return result
 	}
 
+	// Keep the current binary so a bad release can be rolled back
+	if err := backupBinary(execPath); err != nil {
+		result.Error = fmt.Errorf("failed to back up the current binary to %s: %w", BackupPath(execPath), err)
+		return result
+	}
+
 	// Replace current binary
 	if err := replaceBinary(execPath, tempPath); err != nil {
 		result.Error = fmt.Errorf("failed to install update: %w", err)
 		return result
 	}
 
+	// A build that doesn't run is replaced by the previous one right away
+	if err := selfCheck(execPath, release.TagName); err != nil {
+		if restoreErr := replaceBinary(execPath, BackupPath(execPath)); restoreErr != nil {
+			result.Error = fmt.Errorf("new version failed its self-check (%v) and restoring %s failed: %w", err, BackupPath(execPath), restoreErr)
+			return result
+		}
+		result.RolledBack = true
+		result.Error = fmt.Errorf("new version failed its self-check, kept %s: %w", currentVersion, err)
+		return result
+	}
+
 	// Update cache
 	_ = saveCache(&VersionCache{
 		CheckedAt:     time.Now(),
@@ -283,6 +312,15 @@ func verifyChecksum(binaryPath, expectedHash string) error {
 
 // FormatUpgradeResult returns a formatted string for the upgrade result.
 func FormatUpgradeResult(result *UpgradeResult) string {
+	if result.Rollback {
+		if !result.Success {
+			return fmt.Sprintf("❌ Rollback failed: %v", result.Error)
+		}
+		return fmt.Sprintf("↩️  Rolled back: %s → %s",
+			strings.TrimPrefix(result.CurrentVersion, "v"),
+			strings.TrimPrefix(result.NewVersion, "v"))
+	}
+
 	if result.Error != nil && !result.Success {
 		return fmt.Sprintf("❌ Upgrade failed: %v", result.Error)
 	}
@@ -293,7 +331,7 @@ func FormatUpgradeResult(result *UpgradeResult) string {
 	}
 
 	if result.Success {
-		return fmt.Sprintf("✅ Upgraded: %s → %s",
+		return fmt.Sprintf("✅ Upgraded: %s → %s (undo with commit --upgrade --rollback)",
 			strings.TrimPrefix(result.CurrentVersion, "v"),
 			strings.TrimPrefix(result.NewVersion, "v"))
 	}
@@ -0,0 +1,103 @@
+// Package webhook posts a JSON summary of each run to a configured URL, so
+// commit activity can feed chat bots and dashboards without parsing logs.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dsswift/commit/internal/httpclient"
+)
+
+// Timeout bounds delivering a summary, which happens as the run exits.
+const Timeout = 5 * time.Second
+
+// SignatureHeader carries the HMAC-SHA256 of the body, as "sha256=<hex>".
+const SignatureHeader = "X-Commit-Signature"
+
+// EventRunCompleted is the event of a run's summary.
+const EventRunCompleted = "run.completed"
+
+// Summary describes a finished run.
+type Summary struct {
+	Event     string `json:"event"`
+	Timestamp string `json:"timestamp"`
+	Version   string `json:"version"`
+
+	// Repo is the repository's directory name; Remote is its origin URL
+	Repo   string `json:"repo"`
+	Remote string `json:"remote,omitempty"`
+	Branch string `json:"branch,omitempty"`
+
+	Commits    []Commit `json:"commits"`
+	DurationMS int64    `json:"durationMs"`
+	Success    bool     `json:"success"`
+	ExitCode   int      `json:"exitCode"`
+	DryRun     bool     `json:"dryRun,omitempty"`
+}
+
+// Commit is a commit the run created.
+type Commit struct {
+	Hash    string   `json:"hash"`
+	Message string   `json:"message"`
+	Files   []string `json:"files"`
+}
+
+// DeliveryError indicates the webhook answered with a non-2xx status.
+type DeliveryError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *DeliveryError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("webhook returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("webhook returned status %d: %s", e.StatusCode, e.Body)
+}
+
+// Sign returns the signature header value of body for secret.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Send posts summary to url as JSON. With a secret, the body is signed in
+// SignatureHeader so the receiver can check it came from this tool.
+func Send(ctx context.Context, url, secret string, summary *Summary) error {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "commit/"+summary.Version)
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	resp, err := httpclient.NewClient(Timeout).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck // HTTP response body
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 200))
+		return &DeliveryError{StatusCode: resp.StatusCode, Body: string(bytes.TrimSpace(msg))}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSend(t *testing.T) {
+	var got Summary
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signature = r.Header.Get(SignatureHeader)
+		if signature != Sign("s3cret", body) {
+			t.Errorf("signature %q doesn't match the body", signature)
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	summary := &Summary{
+		Event:   EventRunCompleted,
+		Version: "1.2.0",
+		Repo:    "api",
+		Branch:  "main",
+		Commits: []Commit{{Hash: "abc1234", Message: "feat: add handler", Files: []string{"api/handler.go"}}},
+		Success: true,
+	}
+	if err := Send(context.Background(), server.URL, "s3cret", summary); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got.Repo != "api" || len(got.Commits) != 1 || got.Commits[0].Hash != "abc1234" || !got.Success {
+		t.Errorf("received %+v", got)
+	}
+}
+
+func TestSend_Unsigned(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sig := r.Header.Get(SignatureHeader); sig != "" {
+			t.Errorf("unexpected signature %q without a secret", sig)
+		}
+	}))
+	defer server.Close()
+
+	if err := Send(context.Background(), server.URL, "", &Summary{Event: EventRunCompleted}); err != nil {
+		t.Errorf("Send() error = %v", err)
+	}
+}
+
+func TestSend_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad signature", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	var delivery *DeliveryError
+	err := Send(context.Background(), server.URL, "wrong", &Summary{Event: EventRunCompleted})
+	if !errors.As(err, &delivery) || delivery.StatusCode != http.StatusUnauthorized || delivery.Body != "bad signature" {
+		t.Errorf("Send() error = %v, want a 401 DeliveryError", err)
+	}
+}
+
+func TestSign(t *testing.T) {
+	// echo -n '{}' | openssl dgst -sha256 -hmac key
+	want := "sha256=a777724d943eb48dc69bca8a4a6d57a04db3f9ec7e1de4e581e860265bdf3032"
+	if got := Sign("key", []byte("{}")); got != want {
+		t.Errorf("Sign() = %q, want %q", got, want)
+	}
+}
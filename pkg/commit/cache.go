@@ -0,0 +1,102 @@
+package commit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// Cache keeps each repository's config and last plan between calls, for
+// long-running callers such as `commit --serve`. A config is reloaded when its
+// .commit.json changes; a plan is reused while the working tree and the
+// options that shape it are unchanged. A Cache is safe for concurrent use.
+type Cache struct {
+	mu    sync.Mutex
+	repos map[string]*repoCache
+}
+
+// repoCache is what a Cache holds for one repository.
+type repoCache struct {
+	config    *types.RepoConfig
+	configMod time.Time
+	planKey   string
+	plan      *types.CommitPlan
+}
+
+// NewCache creates an empty cache.
+func NewCache() *Cache {
+	return &Cache{repos: make(map[string]*repoCache)}
+}
+
+// repo returns the entry for root, creating it if needed. c.mu must be held.
+func (c *Cache) repo(root string) *repoCache {
+	rc, ok := c.repos[root]
+	if !ok {
+		rc = &repoCache{}
+		c.repos[root] = rc
+	}
+	return rc
+}
+
+// repoConfig returns root's config, loading it again only if .commit.json
+// was modified, created or removed since it was cached.
+func (c *Cache) repoConfig(root string) (*types.RepoConfig, error) {
+	var mod time.Time
+	if info, err := os.Stat(filepath.Join(root, config.RepoConfigFile)); err == nil {
+		mod = info.ModTime()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rc := c.repo(root)
+	if rc.config != nil && rc.configMod.Equal(mod) {
+		return rc.config, nil
+	}
+
+	repoConfig, err := config.LoadRepoConfig(root)
+	if err != nil {
+		return nil, err
+	}
+	rc.config, rc.configMod = repoConfig, mod
+	return repoConfig, nil
+}
+
+// loadPlan returns a copy of root's cached plan if it was stored under key.
+func (c *Cache) loadPlan(root, key string) (*types.CommitPlan, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rc := c.repo(root)
+	if rc.plan == nil || rc.planKey != key {
+		return nil, false
+	}
+	return clonePlan(rc.plan), true
+}
+
+// savePlan caches a copy of plan for root under key, replacing the previous one.
+func (c *Cache) savePlan(root, key string, plan *types.CommitPlan) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rc := c.repo(root)
+	rc.planKey, rc.plan = key, clonePlan(plan)
+}
+
+// clonePlan deep-copies plan so callers can edit the plans they get.
+func clonePlan(plan *types.CommitPlan) *types.CommitPlan {
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return plan
+	}
+	var clone types.CommitPlan
+	if err := json.Unmarshal(data, &clone); err != nil {
+		return plan
+	}
+	return &clone
+}
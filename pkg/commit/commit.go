@@ -0,0 +1,416 @@
+// Package commit runs the commit tool's pipeline as a library, for programs
+// that want semantic commits without shelling out to the CLI.
+//
+// The pipeline has the same stages as the command: collect the working tree's
+// changes, analyze them with an LLM Provider, plan and validate the commits,
+// then execute them:
+//
+//	plan, err := commit.Plan(ctx, commit.Options{Dir: repo, Provider: p})
+//	if err != nil {
+//		return err
+//	}
+//	executed, err := commit.Execute(ctx, commit.Options{Dir: repo}, plan)
+//
+// Run does both in one call.
+package commit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/dsswift/commit/internal/analyzer"
+	"github.com/dsswift/commit/internal/config"
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/internal/git"
+	"github.com/dsswift/commit/internal/llm"
+	"github.com/dsswift/commit/internal/planner"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// Provider analyzes changes and returns a commit plan. Implementations can be
+// created with the CLI's providers or supplied by the caller, e.g. in tests.
+type Provider = llm.Provider
+
+// Stage identifies a step of the pipeline in progress callbacks.
+type Stage string
+
+const (
+	StageCollect  Stage = "collect"
+	StageAnalyze  Stage = "analyze"
+	StagePlan     Stage = "plan"
+	StageValidate Stage = "validate"
+	StageExecute  Stage = "execute"
+)
+
+// Progress describes a pipeline step as it starts. Current and Total count
+// commits during StageExecute, where Commit is the commit being created.
+type Progress struct {
+	Stage   Stage
+	Message string
+	Current int
+	Total   int
+	Commit  *types.PlannedCommit
+}
+
+// ProgressFunc receives progress updates. It is called on the calling goroutine.
+type ProgressFunc func(Progress)
+
+// Options configures a pipeline run.
+type Options struct {
+	// Dir is any directory inside the repository. Defaults to the working directory.
+	Dir string
+
+	// Provider analyzes the changes. Required by Plan and Run.
+	Provider Provider
+
+	// RepoConfig overrides the repository's .commit.json when set.
+	RepoConfig *types.RepoConfig
+
+	// StagedOnly limits the run to staged changes, like --staged.
+	StagedOnly bool
+
+	// Single asks for one commit covering every change, like --single.
+	Single bool
+
+	// Message is a guiding message for the analysis, like -m.
+	Message string
+
+	// Paths limits the run to these paths, relative to Dir.
+	Paths []string
+
+	// DryRun previews execution without creating commits.
+	DryRun bool
+
+	// Progress receives updates as the pipeline runs. Optional.
+	Progress ProgressFunc
+
+	// Cache keeps configs and plans between calls. Optional.
+	Cache *Cache
+}
+
+// NoChangesError indicates there are no changes to commit.
+type NoChangesError = analyzer.NoChangesError
+
+// InvalidPlanError indicates a plan that failed validation.
+type InvalidPlanError struct {
+	Problems []string
+}
+
+func (e *InvalidPlanError) Error() string {
+	return "invalid commit plan: " + strings.Join(e.Problems, "; ")
+}
+
+// MissingProviderError indicates Plan or Run was called without a Provider.
+type MissingProviderError struct{}
+
+func (e *MissingProviderError) Error() string {
+	return "no LLM provider given"
+}
+
+// repo is the resolved repository a run operates on.
+type repo struct {
+	root       string
+	config     *types.RepoConfig
+	pathspecs  []string
+	collector  *git.Collector
+	conv       convention.Convention
+	progressFn ProgressFunc
+}
+
+// openRepo resolves the repository, its config, convention and pathspecs for
+// opts. The convention is passed to each stage rather than made active, so
+// calls for repositories with different conventions can run concurrently.
+func openRepo(opts Options) (*repo, error) {
+	dir := opts.Dir
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	root, err := git.FindGitRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	repoConfig := opts.RepoConfig
+	if repoConfig == nil {
+		if opts.Cache != nil {
+			repoConfig, err = opts.Cache.repoConfig(root)
+		} else {
+			repoConfig, err = config.LoadRepoConfig(root)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var pathspecs []string
+	if len(opts.Paths) > 0 {
+		if pathspecs, err = git.ResolvePathspecs(root, dir, opts.Paths); err != nil {
+			return nil, err
+		}
+	}
+
+	collector := git.NewCollector(root)
+	collector.SetPathspecs(pathspecs)
+
+	branch, _ := collector.CurrentBranch()
+	conv, err := convention.New(repoConfig, branch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &repo{
+		root:       root,
+		config:     repoConfig,
+		pathspecs:  pathspecs,
+		collector:  collector,
+		conv:       conv,
+		progressFn: opts.Progress,
+	}, nil
+}
+
+// progress reports p if a callback is set.
+func (r *repo) progress(p Progress) {
+	if r.progressFn != nil {
+		r.progressFn(p)
+	}
+}
+
+// Plan collects the repository's changes, asks the provider for a commit plan
+// and validates it. The returned plan is ready for Execute.
+func Plan(ctx context.Context, opts Options) (*types.CommitPlan, error) {
+	if opts.Provider == nil {
+		return nil, &MissingProviderError{}
+	}
+
+	r, err := openRepo(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r.progress(Progress{Stage: StageCollect, Message: "Collecting changes"})
+
+	// An unchanged tree planned with the same options gets the cached plan
+	var cacheKey string
+	if opts.Cache != nil {
+		hash, err := r.collector.WorkingTreeHash(opts.StagedOnly)
+		if err != nil {
+			return nil, err
+		}
+		cacheKey = planner.CacheKey(hash, opts.Provider.Name(), opts.Provider.Model(),
+			fmt.Sprint(opts.StagedOnly, opts.Single), opts.Message, strings.Join(r.pathspecs, "\x00"))
+		if plan, ok := opts.Cache.loadPlan(r.root, cacheKey); ok {
+			return plan, nil
+		}
+	}
+
+	status, err := r.collector.Status()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get git status: %w", err)
+	}
+
+	files := status.AllFiles()
+	if opts.StagedOnly {
+		files = status.Staged
+	}
+	if len(files) == 0 {
+		return nil, &NoChangesError{}
+	}
+
+	contextBuilder := analyzer.NewContextBuilder(r.root, r.config)
+	contextBuilder.SetPathspecs(r.pathspecs)
+	req, err := contextBuilder.Build(opts.StagedOnly)
+	if err != nil {
+		return nil, err
+	}
+	req.SingleCommit = opts.Single
+	req.GuidingMessage = opts.Message
+	req.Convention = r.conv
+
+	plan := &types.CommitPlan{}
+	if !r.collector.HasCommits() {
+		// A new repository's first commits are planned from file paths
+		plan = planner.BootstrapPlan(req)
+	} else if len(req.Files) > 0 {
+		r.progress(Progress{Stage: StageAnalyze, Message: fmt.Sprintf("Sending to %s", opts.Provider.Model())})
+		chunks := llm.SplitRequest(opts.Provider.Model(), 0, req)
+		if plan, err = llm.AnalyzeChunks(ctx, opts.Provider, chunks, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	r.progress(Progress{Stage: StagePlan, Message: "Planning commits"})
+	planner.AddGeneratedFiles(plan, req.GeneratedFiles, opts.Single)
+
+	r.progress(Progress{Stage: StageValidate, Message: "Validating plan"})
+	validator := planner.NewValidator(r.root, r.config, files)
+	if branch, err := r.collector.CurrentBranch(); err == nil {
+		validator.SetBranch(branch)
+	}
+	validator.SetPathspecs(r.pathspecs)
+	validator.SetConvention(r.conv)
+
+	plan, result := validator.ValidateAndFix(plan)
+	if !result.Valid {
+		problems := make([]string, len(result.Errors))
+		for i, e := range result.Errors {
+			problems[i] = e.Error()
+		}
+		return nil, &InvalidPlanError{Problems: problems}
+	}
+
+	planner.NormalizePlan(plan, r.config, r.conv)
+	planner.FilterSensitiveFiles(plan)
+	if len(plan.Commits) == 0 {
+		return nil, &NoChangesError{}
+	}
+	if opts.Cache != nil {
+		opts.Cache.savePlan(r.root, cacheKey, plan)
+	}
+	return plan, nil
+}
+
+// PlanPatch plans commits for a unified diff instead of the working tree,
+// like the CLI's --analyze. Inside a repository its .commit.json and recent
+// commits are used; outside one, Dir needn't be a repository at all and the
+// default rules apply. opts.StagedOnly, Paths and DryRun are ignored.
+func PlanPatch(ctx context.Context, opts Options, patch string) (*types.CommitPlan, error) {
+	if opts.Provider == nil {
+		return nil, &MissingProviderError{}
+	}
+
+	workDir, repoConfig, conv, err := patchTarget(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	contextBuilder := analyzer.NewContextBuilder(workDir, repoConfig)
+	req, err := contextBuilder.BuildFromPatch(patch)
+	if err != nil {
+		return nil, err
+	}
+	req.SingleCommit = opts.Single
+	req.GuidingMessage = opts.Message
+	req.Convention = conv
+
+	if opts.Progress != nil {
+		opts.Progress(Progress{Stage: StageAnalyze, Message: fmt.Sprintf("Sending to %s", opts.Provider.Model())})
+	}
+	plan, err := llm.AnalyzeWithRepair(ctx, opts.Provider, req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	planner.NormalizePlan(plan, repoConfig, conv)
+	return plan, nil
+}
+
+// patchTarget resolves the directory, config and convention PlanPatch plans
+// with: the repository's when opts.Dir is inside one, otherwise opts.Dir
+// itself with opts.RepoConfig or the default rules.
+func patchTarget(opts Options) (string, *types.RepoConfig, convention.Convention, error) {
+	dir := opts.Dir
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if root, err := git.FindGitRoot(dir); err == nil {
+		r, err := openRepo(Options{Dir: root, RepoConfig: opts.RepoConfig, Cache: opts.Cache})
+		if err != nil {
+			return "", nil, nil, err
+		}
+		return r.root, r.config, r.conv, nil
+	}
+
+	repoConfig := opts.RepoConfig
+	if repoConfig == nil {
+		repoConfig = &types.RepoConfig{}
+	}
+	conv, err := convention.New(repoConfig, "")
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return dir, repoConfig, conv, nil
+}
+
+// Execute creates the commits in plan. It stops at the next commit boundary
+// when ctx is cancelled, returning the commits created so far along with the
+// error. Unless opts.DryRun is set, the repository's run lock is held
+// throughout, so Execute fails while the CLI or another caller is committing.
+// Commits whose files no longer have changes are skipped and reported as
+// "Skipped empty commit" progress.
+func Execute(ctx context.Context, opts Options, plan *types.CommitPlan) ([]types.ExecutedCommit, error) {
+	if plan == nil || len(plan.Commits) == 0 {
+		return nil, &NoChangesError{}
+	}
+
+	r, err := openRepo(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.DryRun {
+		lock, err := git.AcquireLock(r.root)
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = lock.Release() }()
+	}
+
+	executor := planner.NewExecutor(r.root, opts.DryRun)
+	executor.SetConvention(r.conv)
+	executor.SetVerification(r.config.Verification)
+	executor.SetHookAutofix(r.config.HookAutofix)
+	executor.SetPathspecs(r.pathspecs)
+
+	executed, err := executor.ExecuteContext(ctx, plan, func(current, total int, c types.PlannedCommit) {
+		r.progress(Progress{Stage: StageExecute, Message: "Creating commit", Current: current, Total: total, Commit: &c})
+	})
+	for _, skipped := range executor.Skipped() {
+		r.progress(Progress{Stage: StageExecute, Message: "Skipped empty commit", Current: skipped.CommitIndex + 1, Total: len(plan.Commits), Commit: &skipped.Planned})
+	}
+	if err != nil {
+		var interrupted *planner.InterruptedError
+		if errors.As(err, &interrupted) {
+			_ = executor.UnstagePartial()
+			return executed, ctx.Err()
+		}
+	}
+	return executed, err
+}
+
+// Subjects formats plan's commits as subject lines in the convention of the
+// repository at opts.Dir, as Execute would write them. Outside a repository
+// opts.RepoConfig's convention is used, as by PlanPatch.
+func Subjects(opts Options, plan *types.CommitPlan) ([]string, error) {
+	_, _, conv, err := patchTarget(opts)
+	if err != nil {
+		return nil, err
+	}
+	subjects := make([]string, len(plan.Commits))
+	for i, c := range plan.Commits {
+		subjects[i] = conv.Format(c)
+	}
+	return subjects, nil
+}
+
+// Run plans and executes commits for the repository's changes.
+func Run(ctx context.Context, opts Options) (*types.CommitPlan, []types.ExecutedCommit, error) {
+	plan, err := Plan(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	executed, err := Execute(ctx, opts, plan)
+	return plan, executed, err
+}
@@ -0,0 +1,238 @@
+package commit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dsswift/commit/internal/convention"
+	"github.com/dsswift/commit/internal/testutil"
+	"github.com/dsswift/commit/pkg/types"
+)
+
+// fakeProvider returns a fixed plan.
+type fakeProvider struct {
+	plan *types.CommitPlan
+	reqs []*types.AnalysisRequest
+}
+
+func (p *fakeProvider) Analyze(ctx context.Context, req *types.AnalysisRequest) (*types.CommitPlan, error) {
+	p.reqs = append(p.reqs, req)
+	return p.plan, nil
+}
+
+func (p *fakeProvider) AnalyzeDiff(ctx context.Context, system, user string) (string, error) {
+	return "", nil
+}
+
+func (p *fakeProvider) Name() string  { return "fake" }
+func (p *fakeProvider) Model() string { return "fake-model" }
+
+func setupRepo(t *testing.T) string {
+	t.Helper()
+	dir := testutil.TestRepo(t)
+	testutil.CreateFile(t, dir, "README.md", "# test\n")
+	testutil.GitAdd(t, dir, "README.md")
+	testutil.GitCommit(t, dir, "chore: initial commit")
+
+	testutil.CreateFile(t, dir, "api/handler.go", "package api\n")
+	testutil.CreateFile(t, dir, "docs/guide.md", "# guide\n")
+	return dir
+}
+
+func twoCommitPlan() *types.CommitPlan {
+	return &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add handler", Files: []string{"api/handler.go"}},
+		{Type: "docs", Message: "add guide", Files: []string{"docs/guide.md"}},
+	}}
+}
+
+func TestRun(t *testing.T) {
+	dir := setupRepo(t)
+	provider := &fakeProvider{plan: twoCommitPlan()}
+
+	var stages []Stage
+	opts := Options{
+		Dir:      dir,
+		Provider: provider,
+		Message:  "api work",
+		Progress: func(p Progress) { stages = append(stages, p.Stage) },
+	}
+
+	plan, executed, err := Run(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(plan.Commits) != 2 || len(executed) != 2 {
+		t.Fatalf("plan = %d commits, executed = %d; want 2 and 2", len(plan.Commits), len(executed))
+	}
+	if provider.reqs[0].GuidingMessage != "api work" {
+		t.Errorf("GuidingMessage = %q", provider.reqs[0].GuidingMessage)
+	}
+
+	if got := fmt.Sprint(stages); got != "[collect analyze plan validate execute execute]" {
+		t.Errorf("stages = %s", got)
+	}
+
+	out, _ := exec.Command("git", "-C", dir, "log", "--format=%s").Output()
+	if n := strings.Count(string(out), "\n"); n != 3 {
+		t.Errorf("git log has %d commits, want 3:\n%s", n, out)
+	}
+}
+
+func TestRun_ConventionPerRepo(t *testing.T) {
+	// The library never reads or sets the CLI's active convention
+	convention.Use(convention.Gitmoji{})
+	defer convention.Use(convention.Conventional{})
+
+	plainDir := setupRepo(t)
+	testutil.CreateFile(t, plainDir, ".commit.json", `{"convention": "plain"}`)
+	conventionalDir := setupRepo(t)
+
+	var wg sync.WaitGroup
+	for _, dir := range []string{plainDir, conventionalDir} {
+		wg.Add(1)
+		go func(dir string) {
+			defer wg.Done()
+			plan := &types.CommitPlan{Commits: []types.PlannedCommit{
+				{Type: "feat", Message: "add handler", Files: []string{"api/handler.go", "docs/guide.md", ".commit.json"}},
+			}}
+			if dir == conventionalDir {
+				plan.Commits[0].Files = plan.Commits[0].Files[:2]
+			}
+			if _, _, err := Run(context.Background(), Options{Dir: dir, Provider: &fakeProvider{plan: plan}}); err != nil {
+				t.Errorf("Run(%s) error = %v", dir, err)
+			}
+		}(dir)
+	}
+	wg.Wait()
+
+	for dir, want := range map[string]string{plainDir: "add handler", conventionalDir: "feat: add handler"} {
+		out, _ := exec.Command("git", "-C", dir, "log", "-1", "--format=%s").Output()
+		if got := strings.TrimSpace(string(out)); got != want {
+			t.Errorf("HEAD subject = %q, want %q", got, want)
+		}
+	}
+
+	subjects, err := Subjects(Options{Dir: plainDir}, twoCommitPlan())
+	if err != nil {
+		t.Fatalf("Subjects() error = %v", err)
+	}
+	if subjects[0] != "add handler" {
+		t.Errorf("Subjects() = %q, want the plain message", subjects)
+	}
+}
+
+func TestPlan_Paths(t *testing.T) {
+	dir := setupRepo(t)
+	provider := &fakeProvider{plan: &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "docs", Message: "add guide", Files: []string{"docs/guide.md"}},
+	}}}
+
+	plan, err := Plan(context.Background(), Options{Dir: dir + "/docs", Provider: provider, Paths: []string{"."}})
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	if len(plan.Commits) != 1 {
+		t.Errorf("plan = %+v", plan)
+	}
+	for _, f := range provider.reqs[0].Files {
+		if !strings.HasPrefix(f.Path, "docs/") {
+			t.Errorf("file %s outside the requested path was analyzed", f.Path)
+		}
+	}
+}
+
+func TestPlan_Errors(t *testing.T) {
+	dir := setupRepo(t)
+
+	if _, err := Plan(context.Background(), Options{Dir: dir}); !errors.As(err, new(*MissingProviderError)) {
+		t.Errorf("missing provider: err = %v", err)
+	}
+
+	bad := &fakeProvider{plan: &types.CommitPlan{Commits: []types.PlannedCommit{
+		{Type: "feat", Message: "add handler", Files: []string{"missing.go"}},
+	}}}
+	if _, err := Plan(context.Background(), Options{Dir: dir, Provider: bad}); !errors.As(err, new(*InvalidPlanError)) {
+		t.Errorf("invalid plan: err = %v", err)
+	}
+
+	clean := testutil.TestRepo(t)
+	if _, err := Plan(context.Background(), Options{Dir: clean, Provider: bad}); !errors.As(err, new(*NoChangesError)) {
+		t.Errorf("clean repo: err = %v", err)
+	}
+}
+
+func TestExecute_Cancelled(t *testing.T) {
+	dir := setupRepo(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	executed, err := Execute(ctx, Options{Dir: dir}, twoCommitPlan())
+	if !errors.Is(err, context.Canceled) || len(executed) != 0 {
+		t.Errorf("Execute() = %d commits, %v; want none and context.Canceled", len(executed), err)
+	}
+}
+
+func TestPlan_Cache(t *testing.T) {
+	dir := setupRepo(t)
+	provider := &fakeProvider{plan: twoCommitPlan()}
+	opts := Options{Dir: dir, Provider: provider, Cache: NewCache()}
+
+	first, err := Plan(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("Plan() error = %v", err)
+	}
+	first.Commits[0].Message = "edited by the caller"
+
+	second, err := Plan(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("second Plan() error = %v", err)
+	}
+	if len(provider.reqs) != 1 {
+		t.Errorf("provider called %d times, want 1 for an unchanged tree", len(provider.reqs))
+	}
+	if second.Commits[0].Message != "add handler" {
+		t.Errorf("cached plan was changed through an earlier result: %q", second.Commits[0].Message)
+	}
+
+	// Different options or a changed tree are planned again
+	opts.Message = "api work"
+	if _, err := Plan(context.Background(), opts); err != nil {
+		t.Fatalf("Plan() with a message error = %v", err)
+	}
+	testutil.CreateFile(t, dir, "api/handler.go", "package api\n\nfunc Handle() {}\n")
+	if _, err := Plan(context.Background(), opts); err != nil {
+		t.Fatalf("Plan() after a change error = %v", err)
+	}
+	if len(provider.reqs) != 3 {
+		t.Errorf("provider called %d times, want 3", len(provider.reqs))
+	}
+}
+
+func TestCache_RepoConfig(t *testing.T) {
+	dir := setupRepo(t)
+	cache := NewCache()
+
+	cfg, err := cache.repoConfig(dir)
+	if err != nil {
+		t.Fatalf("repoConfig() error = %v", err)
+	}
+	if again, _ := cache.repoConfig(dir); again != cfg {
+		t.Error("expected the cached config for an unchanged .commit.json")
+	}
+
+	testutil.CreateFile(t, dir, ".commit.json", `{"maxMessageLength": 60}`)
+	cfg, err = cache.repoConfig(dir)
+	if err != nil {
+		t.Fatalf("repoConfig() error = %v", err)
+	}
+	if cfg.MaxMessageLength != 60 {
+		t.Errorf("MaxMessageLength = %d, want 60 after .commit.json was created", cfg.MaxMessageLength)
+	}
+}
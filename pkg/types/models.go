@@ -1,7 +1,10 @@
 // Package types defines shared types for the commit tool.
 package types
 
-import "time"
+import (
+	"path"
+	"time"
+)
 
 // FileChange represents a single file change detected by git.
 type FileChange struct {
@@ -9,6 +12,34 @@ type FileChange struct {
 	Status      string `json:"status"` // "modified", "added", "deleted", "renamed"
 	Scope       string `json:"scope,omitempty"`
 	DiffSummary string `json:"diffSummary"` // e.g., "+45 -12"
+
+	// ModeChange describes a file mode change, e.g. "made executable"
+	ModeChange string `json:"modeChange,omitempty"`
+
+	// LFSChange summarizes a change to a Git LFS-tracked file, whose pointer
+	// diff is left out of the prompt, e.g. "LFS asset updated (1.2 MB → 1.5 MB)"
+	LFSChange string `json:"lfsChange,omitempty"`
+
+	// Symlink summarizes a change to a symbolic link, whose diff is only its
+	// target path, e.g. "symlink retargeted (dev.yaml → prod.yaml)"
+	Symlink string `json:"symlink,omitempty"`
+
+	// RemovedDir summarizes a directory deleted as a whole, listed as one
+	// "dir/" entry instead of each file, e.g. "removed directory vendor (1204 files)"
+	RemovedDir string `json:"removedDir,omitempty"`
+
+	// SuggestedScope is a fallback scope derived from the file's directory,
+	// set only when neither the repo config nor recent history defines scopes.
+	SuggestedScope string `json:"suggestedScope,omitempty"`
+
+	// Focused marks a file matching --focus; its full diff is sent while
+	// other files' diffs are summarized.
+	Focused bool `json:"focused,omitempty"`
+}
+
+// ModeOnly reports whether the file's mode changed without any content change.
+func (f FileChange) ModeOnly() bool {
+	return f.ModeChange != "" && (f.DiffSummary == "" || f.DiffSummary == "+0 -0")
 }
 
 // AnalysisRequest is the structured request sent to the LLM.
@@ -20,6 +51,42 @@ type AnalysisRequest struct {
 	SingleCommit   bool         `json:"singleCommit"`
 	GuidingMessage string       `json:"guidingMessage,omitempty"`
 	Rules          CommitRules  `json:"rules"`
+
+	// GeneratedFiles are changed files marked linguist-generated or -diff in
+	// .gitattributes. They are kept out of Files and Diff and committed separately.
+	GeneratedFiles []string `json:"-"`
+
+	// TypeGuesses are commit types guessed locally for groups of Files,
+	// given to the LLM as a prior.
+	TypeGuesses []TypeGuess `json:"typeGuesses,omitempty"`
+
+	// Prompts replace the built-in prompts; nil uses them.
+	Prompts *PromptTemplates `json:"prompts,omitempty"`
+
+	// Convention formats subjects when budgeting message lengths; nil uses
+	// the active convention.
+	Convention SubjectFormatter `json:"-"`
+}
+
+// SubjectFormatter renders a planned commit's subject line in a commit
+// convention.
+type SubjectFormatter interface {
+	Format(c PlannedCommit) string
+}
+
+// PromptTemplates are Go text/template sources for the system and user
+// prompts sent to the LLM. An empty one keeps the built-in prompt.
+type PromptTemplates struct {
+	System string `json:"system,omitempty"`
+	User   string `json:"user,omitempty"`
+}
+
+// TypeGuess is a commit type guessed from file paths and line counts alone.
+type TypeGuess struct {
+	Files      []string `json:"files"`
+	Type       string   `json:"type"`
+	Confidence float64  `json:"confidence"` // 0 to 1
+	Reason     string   `json:"reason"`
 }
 
 // CommitRules defines constraints for commit messages.
@@ -27,6 +94,8 @@ type CommitRules struct {
 	Types            []string `json:"types"`
 	MaxMessageLength int      `json:"maxMessageLength"`
 	BehavioralTest   string   `json:"behavioralTest"`
+	Convention       string   `json:"convention,omitempty"`  // subject style; empty is conventional
+	ScopePolicy      string   `json:"scopePolicy,omitempty"` // empty is optional
 }
 
 // PlannedCommit represents a single commit planned by the LLM.
@@ -36,6 +105,9 @@ type PlannedCommit struct {
 	Message   string   `json:"message"`
 	Files     []string `json:"files"`
 	Reasoning string   `json:"reasoning"`
+
+	// Body is an optional commit message body added by the tool after planning.
+	Body string `json:"body,omitempty"`
 }
 
 // CommitPlan is the structured response from the LLM.
@@ -45,11 +117,68 @@ type CommitPlan struct {
 
 // ExecutedCommit represents a commit that was successfully created.
 type ExecutedCommit struct {
-	Hash    string   `json:"hash"`
-	Type    string   `json:"type"`
-	Scope   *string  `json:"scope,omitempty"`
-	Message string   `json:"message"`
-	Files   []string `json:"files"`
+	Hash         string              `json:"hash"`
+	Type         string              `json:"type"`
+	Scope        *string             `json:"scope,omitempty"`
+	Message      string              `json:"message"`
+	Files        []string            `json:"files"`
+	Reasoning    string              `json:"reasoning,omitempty"`    // why the plan grouped these files
+	Verification *CommitVerification `json:"verification,omitempty"` // nil in dry-run
+	// HookRewritten lists the files a pre-commit hook rewrote while this
+	// commit was created; RepoConfig.HookAutofix decides what became of them.
+	HookRewritten []string `json:"hookRewritten,omitempty"`
+}
+
+// Verification statuses comparing a commit's files against its plan.
+const (
+	VerificationExact   = "exact"         // committed files match the plan
+	VerificationExtra   = "extra_files"   // plan files committed, plus others (e.g. hook-formatted)
+	VerificationMissing = "missing_files" // some planned files were not committed
+)
+
+// Scope policies controlling whether commits carry a scope.
+const (
+	ScopePolicyRequired  = "required"  // every commit must have a scope
+	ScopePolicyOptional  = "optional"  // scopes are used when they fit (the default)
+	ScopePolicyForbidden = "forbidden" // commits never have a scope
+)
+
+// Hook autofix modes for files a pre-commit hook rewrites.
+const (
+	HookAutofixAmend = "amend" // re-stage them into the commit (the default)
+	HookAutofixWarn  = "warn"  // leave them changed and report them
+	HookAutofixFail  = "fail"  // stop executing the plan
+)
+
+// Verification severities controlling how mismatches are reported.
+const (
+	SeverityIgnore = "ignore"
+	SeverityWarn   = "warn"
+	SeverityError  = "error"
+)
+
+// CommitVerification reports how a created commit compares to its planned files.
+type CommitVerification struct {
+	Status       string   `json:"status"`
+	ExtraFiles   []string `json:"extraFiles,omitempty"`
+	MissingFiles []string `json:"missingFiles,omitempty"`
+}
+
+// NewCommitVerification builds a verification from the extra and missing file lists.
+func NewCommitVerification(extra, missing []string) *CommitVerification {
+	v := &CommitVerification{Status: VerificationExact, ExtraFiles: extra, MissingFiles: missing}
+	switch {
+	case len(missing) > 0:
+		v.Status = VerificationMissing
+	case len(extra) > 0:
+		v.Status = VerificationExtra
+	}
+	return v
+}
+
+// Verified reports whether the commit exactly matches its plan.
+func (v *CommitVerification) Verified() bool {
+	return v.Status == VerificationExact
 }
 
 // UserConfig represents the user's global configuration from ~/.commit-tool/.env.
@@ -59,6 +188,10 @@ type UserConfig struct {
 	DryRun      bool   `json:"dryRun,omitempty"`
 	DefaultMode string `json:"defaultMode,omitempty"` // "smart" or "single"
 
+	// ProviderFallbacks are tried in order, as "provider" or "provider/model",
+	// when the provider is rate limited, failing or too slow
+	ProviderFallbacks []string `json:"providerFallbacks,omitempty"`
+
 	// API keys for different providers
 	AnthropicAPIKey string `json:"-"` // Never log API keys
 	OpenAIAPIKey    string `json:"-"`
@@ -70,9 +203,94 @@ type UserConfig struct {
 	AzureFoundryAPIKey     string `json:"-"`
 	AzureFoundryDeployment string `json:"azureFoundryDeployment,omitempty"`
 
+	// Azure OpenAI settings
+	AzureOpenAIEndpoint   string `json:"-"`
+	AzureOpenAIAPIKey     string `json:"-"`
+	AzureOpenAIDeployment string `json:"azureOpenAiDeployment,omitempty"`
+
+	// OpenRouter settings; one key for many vendors' models
+	OpenRouterAPIKey  string `json:"-"`
+	OpenRouterSiteURL string `json:"openRouterSiteUrl,omitempty"` // HTTP-Referer header OpenRouter attributes usage to
+	OpenRouterAppName string `json:"openRouterAppName,omitempty"` // X-Title header shown in OpenRouter's usage pages
+
+	// Mistral settings
+	MistralAPIKey string `json:"-"`
+
+	// DeepSeek settings
+	DeepSeekAPIKey string `json:"-"`
+
+	// GitHub Models settings; the token falls back to the environment and gh
+	GitHubToken string `json:"-"`
+
+	// Vertex AI settings; authenticated with Google Cloud credentials, not an API key
+	VertexProject     string `json:"vertexProject,omitempty"`
+	VertexLocation    string `json:"vertexLocation,omitempty"`    // region such as europe-west4, or "global" (default us-central1)
+	VertexCredentials string `json:"vertexCredentials,omitempty"` // service account key file; empty uses Application Default Credentials
+
+	// Ollama settings; local models need no API key
+	OllamaHost string `json:"ollamaHost,omitempty"` // Ollama server address (default http://localhost:11434)
+
 	// Optional overrides
 	BaseURL    string `json:"baseUrl,omitempty"`    // Override provider API URL (proxy/enterprise)
 	TimeoutSec int    `json:"timeoutSec,omitempty"` // Override HTTP timeout in seconds (default: 60)
+
+	// Sampling; nil uses the tool's temperature (0.3, or Anthropic's default)
+	// and no seed. --deterministic sets both for reproducible plans.
+	Temperature *float64 `json:"temperature,omitempty"`
+	Seed        *int     `json:"seed,omitempty"`      // sent only to providers whose APIs take one
+	TopP        *float64 `json:"topP,omitempty"`      // nil leaves the provider's default
+	MaxTokens   int      `json:"maxTokens,omitempty"` // answer cap; 0 uses the default (8192)
+
+	// How long provider responses are reused for identical requests; nil
+	// uses the default (24h) and 0 disables the cache.
+	CacheTTL *time.Duration `json:"cacheTtl,omitempty"`
+
+	// Monthly spending limit in USD across providers; 0 means none. Runs
+	// warn as it nears, or stop with --hard-budget.
+	MonthlyBudgetUSD float64 `json:"monthlyBudgetUsd,omitempty"`
+
+	// OpenAI-compatible server (vLLM, LM Studio, LiteLLM) for the openai
+	// provider. The API key is optional when it's set.
+	OpenAIBaseURL string `json:"openAiBaseUrl,omitempty"` // e.g. http://localhost:8000/v1
+	OpenAIPath    string `json:"openAiPath,omitempty"`    // chat completions path under it (default /chat/completions)
+
+	// API version pins; empty uses the tool's default for each provider
+	AnthropicAPIVersion   string `json:"anthropicApiVersion,omitempty"`   // anthropic-version header (Anthropic and Azure Claude deployments)
+	GeminiAPIVersion      string `json:"geminiApiVersion,omitempty"`      // Gemini endpoint version, e.g. "v1" or "v1beta"
+	AzureOpenAIAPIVersion string `json:"azureOpenAiApiVersion,omitempty"` // api-version query parameter for Azure OpenAI (and Foundry OpenAI) deployments
+
+	// Usage attribution; sent as headers so usage is billed to the right team
+	OpenAIOrganization string            `json:"openAiOrganization,omitempty"` // OpenAI-Organization header
+	OpenAIProject      string            `json:"openAiProject,omitempty"`      // OpenAI-Project header
+	GeminiProject      string            `json:"geminiProject,omitempty"`      // x-goog-user-project header (Gemini billing project)
+	ExtraHeaders       map[string]string `json:"-"`                            // COMMIT_EXTRA_HEADERS, sent to any provider
+}
+
+// APIKey returns the API key for the configured provider.
+func (c *UserConfig) APIKey() string {
+	switch c.Provider {
+	case "anthropic":
+		return c.AnthropicAPIKey
+	case "openai":
+		return c.OpenAIAPIKey
+	case "grok":
+		return c.GrokAPIKey
+	case "gemini":
+		return c.GeminiAPIKey
+	case "azure-foundry":
+		return c.AzureFoundryAPIKey
+	case "azure-openai":
+		return c.AzureOpenAIAPIKey
+	case "openrouter":
+		return c.OpenRouterAPIKey
+	case "mistral":
+		return c.MistralAPIKey
+	case "deepseek":
+		return c.DeepSeekAPIKey
+	case "github-models":
+		return c.GitHubToken
+	}
+	return ""
 }
 
 // ScopeConfig defines a path-to-scope mapping.
@@ -87,12 +305,165 @@ type CommitTypeConfig struct {
 	Types []string `json:"types"`
 }
 
+// BranchPolicy restricts commit types on branches matching a glob pattern.
+type BranchPolicy struct {
+	Branch string   `json:"branch"` // glob pattern, e.g. "main" or "release/*"
+	Mode   string   `json:"mode"`   // "whitelist" or "blacklist"
+	Types  []string `json:"types"`
+}
+
+// Matches reports whether the policy applies to the given branch.
+func (p *BranchPolicy) Matches(branch string) bool {
+	if branch == "" {
+		return false
+	}
+	matched, err := path.Match(p.Branch, branch)
+	return err == nil && matched
+}
+
+// Allows reports whether the policy permits the given commit type.
+func (p *BranchPolicy) Allows(commitType string) bool {
+	found := false
+	for _, t := range p.Types {
+		if t == commitType {
+			found = true
+			break
+		}
+	}
+
+	if p.Mode == "whitelist" {
+		return found
+	}
+	// blacklist mode
+	return !found
+}
+
 // RepoConfig represents the repository-specific configuration from .commit.json.
 type RepoConfig struct {
-	Scopes           []ScopeConfig    `json:"scopes"`
-	DefaultScope     *string          `json:"defaultScope,omitempty"`
-	CommitTypes      CommitTypeConfig `json:"commitTypes,omitempty"`
-	MaxMessageLength int              `json:"maxMessageLength,omitempty"`
+	Scopes           []ScopeConfig      `json:"scopes"`
+	DefaultScope     *string            `json:"defaultScope,omitempty"`
+	CommitTypes      CommitTypeConfig   `json:"commitTypes,omitempty"`
+	MaxMessageLength int                `json:"maxMessageLength,omitempty"`
+	BranchPolicies   []BranchPolicy     `json:"branchPolicies,omitempty"`
+	Verification     VerificationConfig `json:"verification,omitempty"`
+	MassDelete       MassDeleteConfig   `json:"massDelete,omitempty"`
+	Trailers         TrailerConfig      `json:"trailers,omitempty"`
+
+	// Convention selects the commit subject style: "conventional" (the
+	// default), "plain", "bracketed-ticket" or "gitmoji".
+	Convention string `json:"convention,omitempty"`
+	// TicketPattern is a regular expression matching ticket keys in branch
+	// names for the bracketed-ticket convention.
+	TicketPattern string `json:"ticketPattern,omitempty"`
+	// ScopePolicy is "required", "optional" (the default) or "forbidden".
+	ScopePolicy string `json:"scopePolicy,omitempty"`
+
+	// DiffAlgorithm selects git's diff algorithm for the diff sent to the LLM
+	// ("myers", "minimal", "patience" or "histogram"); empty uses git's default.
+	DiffAlgorithm string `json:"diffAlgorithm,omitempty"`
+	// FunctionContext sends whole functions around each change instead of
+	// three lines of context.
+	FunctionContext bool `json:"functionContext,omitempty"`
+
+	// ExcludeAuthors lists glob patterns matched against author name or email.
+	// Matching commits are left out of the style examples sent to the LLM.
+	// Nil uses DefaultExcludedAuthors; an empty list disables filtering.
+	ExcludeAuthors []string `json:"excludeAuthors,omitempty"`
+
+	// VerifyCommand is run through the shell in a checkout of each created
+	// commit when --verify is given, e.g. "go build ./...".
+	VerifyCommand string `json:"verifyCommand,omitempty"`
+
+	// MergeNearDuplicates merges planned commits whose subjects say the same
+	// thing in different words instead of offering to.
+	MergeNearDuplicates bool `json:"mergeNearDuplicates,omitempty"`
+
+	// Presets maps names to flag lists invoked as "commit @name".
+	Presets map[string][]string `json:"presets,omitempty"`
+
+	// HookAutofix is what happens when a pre-commit hook rewrites a commit's
+	// files, e.g. a formatter: "amend" (the default), "warn" or "fail".
+	HookAutofix string `json:"hookAutofix,omitempty"`
+
+	// Prompts replace the built-in LLM prompts for this repository, unless
+	// ~/.commit-tool/prompts/ has its own.
+	Prompts PromptTemplates `json:"prompts,omitempty"`
+}
+
+// DefaultExcludedAuthors returns author patterns for common automation bots.
+func DefaultExcludedAuthors() []string {
+	return []string{`*\[bot\]`, "dependabot*", "renovate*", "github-actions*"}
+}
+
+// ExcludedAuthors returns the author patterns to exclude from recent commits.
+func (c *RepoConfig) ExcludedAuthors() []string {
+	if c.ExcludeAuthors == nil {
+		return DefaultExcludedAuthors()
+	}
+	return c.ExcludeAuthors
+}
+
+// VerificationConfig sets the severity of commit verification mismatches.
+// Empty values use the defaults: extra files warn, missing files error.
+type VerificationConfig struct {
+	ExtraFiles   string `json:"extraFiles,omitempty"`   // "ignore", "warn", or "error"
+	MissingFiles string `json:"missingFiles,omitempty"` // "ignore", "warn", or "error"
+}
+
+// Severity returns the configured severity for a verification status.
+func (c VerificationConfig) Severity(status string) string {
+	switch status {
+	case VerificationExtra:
+		if c.ExtraFiles != "" {
+			return c.ExtraFiles
+		}
+		return SeverityWarn
+	case VerificationMissing:
+		if c.MissingFiles != "" {
+			return c.MissingFiles
+		}
+		return SeverityError
+	default:
+		return SeverityIgnore
+	}
+}
+
+// TrailerConfig selects the metadata trailers appended to each commit so
+// tooling can recognize and trace generated commits. All are off by default.
+type TrailerConfig struct {
+	Version      bool `json:"version,omitempty"`      // Commit-Tool-Version
+	PlanID       bool `json:"planId,omitempty"`       // Commit-Plan-Id
+	ReviewedPlan bool `json:"reviewedPlan,omitempty"` // Reviewed-Plan
+	ChangeID     bool `json:"changeId,omitempty"`     // Gerrit Change-Id
+}
+
+// Default mass-deletion thresholds.
+const (
+	DefaultMassDeleteMaxFiles   = 50
+	DefaultMassDeleteMaxPercent = 50
+)
+
+// MassDeleteConfig sets when a plan's deletions need explicit confirmation.
+// Zero values use the defaults.
+type MassDeleteConfig struct {
+	MaxFiles   int `json:"maxFiles,omitempty"`   // deleted files across the plan
+	MaxPercent int `json:"maxPercent,omitempty"` // deleted share of a directory's files
+}
+
+// FileLimit returns the number of deleted files allowed without confirmation.
+func (c MassDeleteConfig) FileLimit() int {
+	if c.MaxFiles > 0 {
+		return c.MaxFiles
+	}
+	return DefaultMassDeleteMaxFiles
+}
+
+// PercentLimit returns the share of a directory that may be deleted without confirmation.
+func (c MassDeleteConfig) PercentLimit() int {
+	if c.MaxPercent > 0 {
+		return c.MaxPercent
+	}
+	return DefaultMassDeleteMaxPercent
 }
 
 // DefaultCommitTypes returns the standard set of allowed commit types.
@@ -154,6 +525,18 @@ func (c *RepoConfig) AllowedTypes() []string {
 	return allowed
 }
 
+// BranchPolicyViolation returns the first branch policy that forbids the commit
+// type on the given branch, or nil if the type is permitted.
+func (c *RepoConfig) BranchPolicyViolation(commitType, branch string) *BranchPolicy {
+	for i := range c.BranchPolicies {
+		policy := &c.BranchPolicies[i]
+		if policy.Matches(branch) && !policy.Allows(commitType) {
+			return policy
+		}
+	}
+	return nil
+}
+
 // ExecutionResult represents the outcome of a commit tool run.
 type ExecutionResult struct {
 	ExecutionID    string           `json:"execution_id"`
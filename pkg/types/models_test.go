@@ -250,3 +250,47 @@ func TestGitStatus_AllFiles_NoDuplicates(t *testing.T) {
 		t.Errorf("expected 1 file, got %d: %v", len(files), files)
 	}
 }
+
+func TestVerificationConfig_SeverityDefaults(t *testing.T) {
+	var cfg VerificationConfig
+
+	if got := cfg.Severity(VerificationExact); got != SeverityIgnore {
+		t.Errorf("exact severity = %q, want %q", got, SeverityIgnore)
+	}
+	if got := cfg.Severity(VerificationExtra); got != SeverityWarn {
+		t.Errorf("extra severity = %q, want %q", got, SeverityWarn)
+	}
+	if got := cfg.Severity(VerificationMissing); got != SeverityError {
+		t.Errorf("missing severity = %q, want %q", got, SeverityError)
+	}
+}
+
+func TestNewCommitVerification(t *testing.T) {
+	if v := NewCommitVerification(nil, nil); !v.Verified() {
+		t.Errorf("expected exact match, got %q", v.Status)
+	}
+	if v := NewCommitVerification([]string{"a"}, nil); v.Status != VerificationExtra {
+		t.Errorf("Status = %q, want %q", v.Status, VerificationExtra)
+	}
+	// Missing files take precedence over extras
+	if v := NewCommitVerification([]string{"a"}, []string{"b"}); v.Status != VerificationMissing {
+		t.Errorf("Status = %q, want %q", v.Status, VerificationMissing)
+	}
+}
+
+func TestFileChange_ModeOnly(t *testing.T) {
+	tests := []struct {
+		change FileChange
+		want   bool
+	}{
+		{FileChange{ModeChange: "made executable", DiffSummary: "+0 -0"}, true},
+		{FileChange{ModeChange: "made executable"}, true},
+		{FileChange{ModeChange: "made executable", DiffSummary: "+3 -1"}, false},
+		{FileChange{DiffSummary: "+0 -0"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.change.ModeOnly(); got != tt.want {
+			t.Errorf("%+v.ModeOnly() = %v, want %v", tt.change, got, tt.want)
+		}
+	}
+}